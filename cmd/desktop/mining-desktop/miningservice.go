@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/Snider/Mining/pkg/mining"
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 // MiningService exposes mining functionality to the Wails frontend.
@@ -16,6 +19,11 @@ type MiningService struct {
 	manager     *mining.Manager
 	profileMgr  *mining.ProfileManager
 	settingsMgr *mining.SettingsManager
+	eventHub    *mining.EventHub
+
+	unsubscribeMu sync.Mutex
+	unsubscribe   func()
+	shutdownOnce  sync.Once
 }
 
 // NewMiningService creates a new mining service with an initialized manager.
@@ -23,13 +31,44 @@ func NewMiningService() *MiningService {
 	manager := mining.NewManager()
 	profileMgr, _ := mining.NewProfileManager()
 	settingsMgr, _ := mining.NewSettingsManager()
+
+	// In-process event hub so miner events can be pushed to the frontend
+	eventHub := mining.NewEventHub()
+	go eventHub.Run()
+	manager.SetEventHub(eventHub)
+	if settingsMgr != nil {
+		manager.SetDonateLevelPolicy(settingsMgr.Get().DonateLevelPolicy)
+	}
+
 	return &MiningService{
 		manager:     manager,
 		profileMgr:  profileMgr,
 		settingsMgr: settingsMgr,
+		eventHub:    eventHub,
 	}
 }
 
+// ServiceStartup subscribes to miner events and forwards them to the frontend
+// as Wails events named after the event type (e.g. "miner.stats").
+func (s *MiningService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	unsubscribe := s.manager.SubscribeEvents(mining.EventSinkFunc(func(event mining.Event) {
+		if app := application.Get(); app != nil {
+			app.Event.Emit(string(event.Type), event)
+		}
+	}))
+
+	s.unsubscribeMu.Lock()
+	s.unsubscribe = unsubscribe
+	s.unsubscribeMu.Unlock()
+	return nil
+}
+
+// ServiceShutdown is called by Wails when the application exits.
+func (s *MiningService) ServiceShutdown() error {
+	s.Shutdown()
+	return nil
+}
+
 // SystemInfo represents system information for the frontend.
 type SystemInfo struct {
 	Platform string             `json:"platform"`
@@ -156,9 +195,9 @@ func (s *MiningService) StartMinerFromProfile(profileID string) (string, error)
 	if s.profileMgr == nil {
 		return "", fmt.Errorf("profile manager not initialized")
 	}
-	profile, ok := s.profileMgr.GetProfile(profileID)
-	if !ok {
-		return "", fmt.Errorf("profile not found: %s", profileID)
+	profile, err := s.profileMgr.ResolvedProfile(profileID)
+	if err != nil {
+		return "", err
 	}
 
 	// Convert RawConfig to *Config
@@ -296,9 +335,21 @@ func (s *MiningService) SendStdin(name, input string) error {
 	return miner.WriteStdin(input)
 }
 
-// Shutdown gracefully shuts down all miners.
+// Shutdown unsubscribes from events and gracefully shuts down all miners.
 func (s *MiningService) Shutdown() {
-	s.manager.Stop()
+	s.shutdownOnce.Do(func() {
+		s.unsubscribeMu.Lock()
+		if s.unsubscribe != nil {
+			s.unsubscribe()
+			s.unsubscribe = nil
+		}
+		s.unsubscribeMu.Unlock()
+
+		s.manager.Stop()
+		if s.eventHub != nil {
+			s.eventHub.Stop()
+		}
+	})
 }
 
 // === Settings Methods ===
@@ -361,6 +412,14 @@ func (s *MiningService) SetStartOnBoot(enabled bool) error {
 	return s.settingsMgr.SetStartOnBoot(enabled)
 }
 
+// GetStartOnBootStatus reports whether start on boot is enabled at the OS level
+func (s *MiningService) GetStartOnBootStatus() mining.StartOnBootStatus {
+	if s.settingsMgr == nil {
+		return mining.StartOnBootStatus{}
+	}
+	return s.settingsMgr.GetStartOnBootStatus()
+}
+
 // SetAutostartMiners enables/disables automatic miner start
 func (s *MiningService) SetAutostartMiners(enabled bool) error {
 	if s.settingsMgr == nil {
@@ -15,6 +15,8 @@ import (
 
 const signpostFilename = ".installed-miners"
 
+var doctorSystem bool
+
 // validateConfigPath validates that a config path is within the expected XDG config directory
 // This prevents path traversal attacks via manipulated signpost files
 func validateConfigPath(configPath string) error {
@@ -36,8 +38,14 @@ func validateConfigPath(configPath string) error {
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Check and refresh the status of installed miners",
-	Long:  `Performs a live check for installed miners, displays their status, and updates the local cache.`,
+	Long: `Performs a live check for installed miners, displays their status, and updates the local cache.
+With --system, checks host prerequisites that affect hashrate instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if doctorSystem {
+			displaySystemChecks(mining.RunSystemChecks())
+			return nil
+		}
+
 		fmt.Println("--- Mining Doctor ---")
 		fmt.Println("Performing live check and refreshing cache...")
 		fmt.Println()
@@ -159,6 +167,18 @@ func displayDetails(minerName string, details *mining.InstallationDetails) {
 	fmt.Println()
 }
 
+func displaySystemChecks(report mining.SystemDoctorReport) {
+	fmt.Printf("--- System Doctor (%s) ---\n", report.OS)
+	for _, check := range report.Checks {
+		fmt.Printf("  [%-4s] %-15s %s\n", strings.ToUpper(string(check.Status)), check.Name, check.Message)
+		if check.Remediation != "" {
+			fmt.Printf("         %-15s fix: %s\n", "", check.Remediation)
+		}
+	}
+	fmt.Printf("\nOverall: %s\n", report.Status)
+}
+
 func init() {
 	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorSystem, "system", false, "Check host mining prerequisites (huge pages, MSR, CPU governor, NUMA, privileges)")
 }
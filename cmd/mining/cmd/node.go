@@ -128,6 +128,7 @@ var nodeServeCmd = &cobra.Command{
 This allows other nodes to connect, send commands, and receive stats.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		listen, _ := cmd.Flags().GetString("listen")
+		tlsAuto, _ := cmd.Flags().GetBool("tls-auto")
 
 		nm, err := node.NewNodeManager()
 		if err != nil {
@@ -147,6 +148,7 @@ This allows other nodes to connect, send commands, and receive stats.`,
 		if listen != "" {
 			config.ListenAddr = listen
 		}
+		config.TLSAutoCert = tlsAuto
 
 		transport := node.NewTransport(nm, pr, config)
 
@@ -162,6 +164,9 @@ This allows other nodes to connect, send commands, and receive stats.`,
 		fmt.Printf("P2P server started on %s\n", config.ListenAddr)
 		fmt.Printf("Node ID: %s (%s)\n", identity.ID, identity.Name)
 		fmt.Printf("Role: %s\n", identity.Role)
+		if fingerprint := transport.TLSFingerprint(); fingerprint != "" {
+			fmt.Printf("TLS fingerprint: %s\n", fingerprint)
+		}
 		fmt.Println()
 		fmt.Println("Press Ctrl+C to stop...")
 
@@ -243,6 +248,7 @@ func init() {
 	// node serve
 	nodeCmd.AddCommand(nodeServeCmd)
 	nodeServeCmd.Flags().StringP("listen", "l", ":9091", "Address to listen on")
+	nodeServeCmd.Flags().Bool("tls-auto", false, "Serve wss:// with a self-signed certificate generated for the node identity")
 
 	// node reset
 	nodeCmd.AddCommand(nodeResetCmd)
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Snider/Mining/pkg/client"
+	"github.com/Snider/Mining/pkg/node"
+	"github.com/spf13/cobra"
+)
+
+// peerAuthStore is where the node auth commands read and change the auth
+// mode and allowlist: the local registry files, or a running node's API.
+type peerAuthStore interface {
+	Mode() (string, error)
+	SetMode(mode string) error
+	Allowlist() ([]string, error)
+	Allow(publicKey string) error
+	Revoke(publicKey string) error
+	Close() error
+}
+
+// localPeerAuth changes the registry files directly. A running node picks the
+// changes up when it restarts.
+type localPeerAuth struct {
+	registry *node.PeerRegistry
+}
+
+func (l *localPeerAuth) Mode() (string, error) { return l.registry.GetAuthMode().String(), nil }
+
+func (l *localPeerAuth) SetMode(mode string) error {
+	parsed, err := node.ParsePeerAuthMode(mode)
+	if err != nil {
+		return err
+	}
+	return l.registry.SetAuthMode(parsed)
+}
+
+func (l *localPeerAuth) Allowlist() ([]string, error)  { return l.registry.ListAllowedPublicKeys(), nil }
+func (l *localPeerAuth) Allow(publicKey string) error  { return l.registry.AllowPublicKey(publicKey) }
+func (l *localPeerAuth) Revoke(publicKey string) error { return l.registry.RevokePublicKey(publicKey) }
+func (l *localPeerAuth) Close() error                  { return l.registry.Close() }
+
+// remotePeerAuth changes a running node through its API, taking effect at once.
+type remotePeerAuth struct {
+	client *client.Client
+}
+
+func (r *remotePeerAuth) Mode() (string, error) { return r.client.PeerAuthMode(context.Background()) }
+func (r *remotePeerAuth) SetMode(mode string) error {
+	return r.client.SetPeerAuthMode(context.Background(), mode)
+}
+func (r *remotePeerAuth) Allowlist() ([]string, error) {
+	return r.client.PeerAllowlist(context.Background())
+}
+func (r *remotePeerAuth) Allow(publicKey string) error {
+	return r.client.AllowPublicKey(context.Background(), publicKey)
+}
+func (r *remotePeerAuth) Revoke(publicKey string) error {
+	return r.client.RevokePublicKey(context.Background(), publicKey)
+}
+func (r *remotePeerAuth) Close() error { return nil }
+
+// openPeerAuth returns the store selected by the --api flag. API credentials
+// come from MINING_API_USER and MINING_API_PASS, as for the server.
+func openPeerAuth(cmd *cobra.Command) (peerAuthStore, error) {
+	apiURL, _ := cmd.Flags().GetString("api")
+	if apiURL == "" {
+		pr, err := node.NewPeerRegistry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get peer registry: %w", err)
+		}
+		return &localPeerAuth{registry: pr}, nil
+	}
+
+	var opts []client.Option
+	if user := os.Getenv("MINING_API_USER"); user != "" {
+		opts = append(opts, client.WithCredentials(user, os.Getenv("MINING_API_PASS")))
+	}
+	c, err := client.New(apiURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &remotePeerAuth{client: c}, nil
+}
+
+// runPeerAuth opens the store, runs fn and closes the store, reporting
+// where the change was made.
+func runPeerAuth(cmd *cobra.Command, fn func(store peerAuthStore) error) error {
+	store, err := openPeerAuth(cmd)
+	if err != nil {
+		return err
+	}
+	if err := fn(store); err != nil {
+		store.Close()
+		return err
+	}
+	return store.Close()
+}
+
+// printLocalNote reminds that local changes apply to a running node on restart.
+func printLocalNote(store peerAuthStore) {
+	if _, ok := store.(*localPeerAuth); ok {
+		fmt.Println("Restart a running node to apply, or use --api to change it live.")
+	}
+}
+
+// nodeAuthCmd is the parent of the peer auth commands
+var nodeAuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage which peers may connect",
+	Long: `Show and change the peer authentication mode and public-key allowlist.
+
+In open mode any peer may connect. In allowlist mode only registered peers
+and peers whose public key is on the allowlist may connect.
+
+Changes are saved to the local node's files. Pass --api with the base URL of
+a running node (e.g. http://localhost:9090/api/v1/mining) to change it live
+instead; MINING_API_USER and MINING_API_PASS supply its credentials.`,
+}
+
+// nodeAuthModeCmd shows or sets the auth mode
+var nodeAuthModeCmd = &cobra.Command{
+	Use:       "mode [open|allowlist]",
+	Short:     "Show or set the peer authentication mode",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"open", "allowlist"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPeerAuth(cmd, func(store peerAuthStore) error {
+			if len(args) == 0 {
+				mode, err := store.Mode()
+				if err != nil {
+					return fmt.Errorf("failed to get auth mode: %w", err)
+				}
+				fmt.Printf("Auth mode: %s\n", mode)
+				return nil
+			}
+
+			if _, err := node.ParsePeerAuthMode(args[0]); err != nil {
+				return err
+			}
+			if err := store.SetMode(args[0]); err != nil {
+				return fmt.Errorf("failed to set auth mode: %w", err)
+			}
+			fmt.Printf("Auth mode set to %s\n", args[0])
+			printLocalNote(store)
+			return nil
+		})
+	},
+}
+
+// nodeAuthAllowCmd adds a key to the allowlist
+var nodeAuthAllowCmd = &cobra.Command{
+	Use:   "allow <public-key>",
+	Short: "Allow a peer's public key to connect",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := node.ValidatePublicKey(args[0]); err != nil {
+			return err
+		}
+		return runPeerAuth(cmd, func(store peerAuthStore) error {
+			if err := store.Allow(args[0]); err != nil {
+				return fmt.Errorf("failed to allow public key: %w", err)
+			}
+			fmt.Printf("Public key allowed: %s\n", args[0])
+			if mode, err := store.Mode(); err == nil && mode != "allowlist" {
+				fmt.Println("Note: the allowlist is only enforced in allowlist mode ('node auth mode allowlist').")
+			}
+			printLocalNote(store)
+			return nil
+		})
+	},
+}
+
+// nodeAuthRevokeCmd removes a key from the allowlist
+var nodeAuthRevokeCmd = &cobra.Command{
+	Use:   "revoke <public-key>",
+	Short: "Remove a public key from the allowlist",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPeerAuth(cmd, func(store peerAuthStore) error {
+			if err := store.Revoke(args[0]); err != nil {
+				return fmt.Errorf("failed to revoke public key: %w", err)
+			}
+			fmt.Printf("Public key revoked: %s\n", args[0])
+			printLocalNote(store)
+			return nil
+		})
+	},
+}
+
+// nodeAuthListCmd shows the mode and allowlist
+var nodeAuthListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show the auth mode and allowlisted public keys",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPeerAuth(cmd, func(store peerAuthStore) error {
+			mode, err := store.Mode()
+			if err != nil {
+				return fmt.Errorf("failed to get auth mode: %w", err)
+			}
+			keys, err := store.Allowlist()
+			if err != nil {
+				return fmt.Errorf("failed to list allowlist: %w", err)
+			}
+
+			fmt.Printf("Auth mode: %s\n", mode)
+			if len(keys) == 0 {
+				fmt.Println("No public keys allowlisted.")
+				fmt.Println("Use 'node auth allow <public-key>' to add one.")
+				return nil
+			}
+			fmt.Printf("\nAllowlisted Public Keys (%d):\n", len(keys))
+			for _, key := range keys {
+				fmt.Printf("  %s\n", key)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	nodeCmd.AddCommand(nodeAuthCmd)
+	nodeAuthCmd.PersistentFlags().String("api", "", "Base URL of a running node's API to change instead of the local files")
+	nodeAuthCmd.AddCommand(nodeAuthModeCmd)
+	nodeAuthCmd.AddCommand(nodeAuthAllowCmd)
+	nodeAuthCmd.AddCommand(nodeAuthRevokeCmd)
+	nodeAuthCmd.AddCommand(nodeAuthListCmd)
+}
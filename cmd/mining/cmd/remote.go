@@ -155,12 +155,18 @@ var remoteStopCmd = &cobra.Command{
 var remoteLogsCmd = &cobra.Command{
 	Use:   "logs <peer-id> <miner-name>",
 	Short: "Get console logs from remote miner",
-	Long:  `Retrieve console output logs from a miner running on a remote peer.`,
-	Args:  cobra.ExactArgs(2),
+	Long: `Retrieve console output logs from a miner running on a remote peer.
+Lines are filtered with --grep on the peer, so only matches cross the network.
+Use --offset with the value printed after a page to fetch older lines.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		peerID := args[0]
 		minerName := args[1]
 		lines, _ := cmd.Flags().GetInt("lines")
+		offset, _ := cmd.Flags().GetInt("offset")
+		grep, _ := cmd.Flags().GetString("grep")
+		ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+		invert, _ := cmd.Flags().GetBool("invert")
 
 		peer := findPeerByPartialID(peerID)
 		if peer == nil {
@@ -172,16 +178,26 @@ var remoteLogsCmd = &cobra.Command{
 			return err
 		}
 
-		logLines, err := ctrl.GetRemoteLogs(peer.ID, minerName, lines)
+		logs, err := ctrl.GetRemoteLogsPage(peer.ID, node.GetLogsPayload{
+			MinerName:  minerName,
+			Lines:      lines,
+			Offset:     offset,
+			Grep:       grep,
+			IgnoreCase: ignoreCase,
+			Invert:     invert,
+		})
 		if err != nil {
 			return fmt.Errorf("failed to get logs: %w", err)
 		}
 
-		fmt.Printf("Logs from %s on %s (%d lines):\n", minerName, peer.Name, len(logLines))
+		fmt.Printf("Logs from %s on %s (%d lines):\n", minerName, peer.Name, len(logs.Lines))
 		fmt.Println("────────────────────────────────────")
-		for _, line := range logLines {
+		for _, line := range logs.Lines {
 			fmt.Println(line)
 		}
+		if logs.HasMore {
+			fmt.Printf("\nOlder lines available: --offset %d\n", logs.NextOffset)
+		}
 
 		return nil
 	},
@@ -311,6 +327,10 @@ func init() {
 	// remote logs
 	remoteCmd.AddCommand(remoteLogsCmd)
 	remoteLogsCmd.Flags().IntP("lines", "n", 100, "Number of log lines to retrieve")
+	remoteLogsCmd.Flags().Int("offset", 0, "Newest matching lines to skip, to page back through older logs")
+	remoteLogsCmd.Flags().StringP("grep", "g", "", "Only lines matching this regular expression")
+	remoteLogsCmd.Flags().BoolP("ignore-case", "i", false, "Match --grep case-insensitively")
+	remoteLogsCmd.Flags().BoolP("invert", "v", false, "Only lines NOT matching --grep")
 
 	// remote connect
 	remoteCmd.AddCommand(remoteConnectCmd)
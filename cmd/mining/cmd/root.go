@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/Snider/Mining/pkg/mining"
@@ -9,6 +10,12 @@ import (
 
 var (
 	manager *mining.Manager
+
+	// configFile is the --config flag; configPath is the file actually
+	// loaded, if any, and serviceConfig the settings loaded
+	configFile    string
+	configPath    string
+	serviceConfig mining.ServiceConfig
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -27,7 +34,34 @@ func Execute() error {
 }
 
 func init() {
-	cobra.OnInitialize(initManager)
+	cobra.OnInitialize(initConfig, initPaths, initManager)
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Service config file, YAML or JSON (default $MINING_CONFIG_FILE or ~/.config/lethean-desktop/config.yaml)")
+}
+
+// initConfig loads the service config file, exiting on a bad value rather
+// than running with settings the operator didn't ask for. Its database
+// settings must be applied before the manager is created.
+func initConfig() {
+	path := configFile
+	if path == "" {
+		path = mining.ServiceConfigPath()
+	}
+	config, err := mining.LoadServiceConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	configPath = path
+	serviceConfig = config
+	mining.SetDatabaseConfig(config.Database)
+}
+
+// initPaths applies install, staging and log file settings from the
+// environment so CLI commands behave the same as the server.
+func initPaths() {
+	mining.SetPathsConfig(mining.PathsConfigFromEnv())
+	mining.SetLogFileConfig(mining.LogFileConfigFromEnv())
+	mining.SetLogTimestampFormat(os.Getenv("MINING_LOG_TIMESTAMP_FORMAT"))
 }
 
 // initManager initializes the miner manager
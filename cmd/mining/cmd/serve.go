@@ -45,10 +45,15 @@ var serveCmd = &cobra.Command{
 		// Use the global manager instance
 		mgr := getManager() // This ensures we get the manager initialized by initManager
 
-		service, err := mining.NewService(mgr, listenAddr, displayAddr, namespace) // Pass the global manager
+		service, err := mining.NewServiceWithConfig(serviceConfig, mgr, listenAddr, displayAddr, namespace) // Pass the global manager
 		if err != nil {
 			return fmt.Errorf("failed to create new service: %w", err)
 		}
+		service.ConfigPath = configPath
+		if service.SettingsManager != nil {
+			// Start at login the way this service was started, e.g. "serve --port 9090"
+			service.SettingsManager.SetStartOnBootArgs(os.Args[1:])
+		}
 
 		// Start the server in a goroutine
 		go func() {
@@ -62,6 +67,24 @@ var serveCmd = &cobra.Command{
 		signalChan := make(chan os.Signal, 1)
 		signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
+		// Reload API credentials on SIGHUP, e.g. after rotating a password file
+		reloadChan := make(chan os.Signal, 1)
+		signal.Notify(reloadChan, syscall.SIGHUP)
+		go func() {
+			for {
+				select {
+				case <-reloadChan:
+					if err := service.ReloadAuth(); err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to reload API credentials: %v\n", err)
+					} else {
+						fmt.Println("API credentials reloaded.")
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
 		// Start interactive shell in a goroutine
 		go func() {
 			fmt.Printf("Mining service started on http://%s:%d\n", displayHost, port)
@@ -19,6 +19,10 @@ var (
 	simPreset    string
 	simHashrate  int
 	simAlgorithm string
+
+	simLogRate      float64
+	simLogLineBytes int
+	simCPUPercent   float64
 )
 
 // simulateCmd represents the simulate command
@@ -39,6 +43,14 @@ Examples:
   # Start with a mix of presets
   miner-ctrl simulate --count 1 --preset gpu-ethash
 
+  # Load-test the service: 50 miners each logging 200 lines/s and using 5% CPU
+  miner-ctrl simulate --count 50 --log-rate 200 --cpu-percent 5
+
+Faults can be injected into simulated miners through the API, to test crash
+restarts and error handling:
+  POST /miners/{name}/simulate/fault   {"fault": "crash|stall|pool-disconnect|api-error"}
+  PUT  /miners/{name}/simulate/faults  {"crashChance": 0.01, "apiErrorChance": 0.1}
+
 Available presets:
   cpu-low      - Low-end CPU (500 H/s, rx/0)
   cpu-medium   - Medium CPU (5 kH/s, rx/0)
@@ -83,10 +95,12 @@ Available presets:
 		}
 
 		// Create and start the service
-		service, err := mining.NewService(mgr, listenAddr, displayAddr, namespace)
+		service, err := mining.NewServiceWithConfig(serviceConfig, mgr, listenAddr, displayAddr, namespace)
 		if err != nil {
 			return fmt.Errorf("failed to create new service: %w", err)
 		}
+		service.Simulation = true
+		service.ConfigPath = configPath
 
 		// Start the server in a goroutine
 		go func() {
@@ -152,6 +166,12 @@ func getSimulatedConfig(index int) mining.SimulatedMinerConfig {
 	config.BaseHashrate = int(float64(config.BaseHashrate) * (0.9 + rand.Float64()*0.2))
 	config.Variance = variance
 
+	config.Load = mining.SimulatedLoad{
+		LogLinesPerSecond: simLogRate,
+		LogLineBytes:      simLogLineBytes,
+		CPUPercent:        simCPUPercent,
+	}
+
 	return config
 }
 
@@ -163,6 +183,9 @@ func init() {
 	simulateCmd.Flags().StringVar(&simPreset, "preset", "cpu-medium", "Miner preset (cpu-low, cpu-medium, cpu-high, gpu-ethash, gpu-kawpow)")
 	simulateCmd.Flags().IntVar(&simHashrate, "hashrate", 0, "Custom base hashrate (overrides preset)")
 	simulateCmd.Flags().StringVar(&simAlgorithm, "algorithm", "", "Custom algorithm (overrides preset)")
+	simulateCmd.Flags().Float64Var(&simLogRate, "log-rate", 0, "Extra log lines per second per miner, for load testing")
+	simulateCmd.Flags().IntVar(&simLogLineBytes, "log-line-bytes", mining.DefaultSimulatedLogBytes, "Length of each extra log line")
+	simulateCmd.Flags().Float64Var(&simCPUPercent, "cpu-percent", 0, "CPU each miner burns, in percent of one core, for load testing")
 
 	// Reuse serve command flags
 	simulateCmd.Flags().StringVar(&host, "host", "127.0.0.1", "Host to listen on")
@@ -0,0 +1,316 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Snider/Mining/pkg/mining"
+	"github.com/Snider/Mining/pkg/node"
+)
+
+// InstallResult is the response of InstallMiner.
+type InstallResult struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+// LogOptions filters miner logs server-side. The zero value returns every line.
+type LogOptions struct {
+	Grep       string // Regular expression lines must match
+	Level      string // Minimum severity: error, warn, info or all
+	Tail       int    // Only the last N matching lines
+	Stream     string // stdout or stderr; empty for both
+	IgnoreCase bool
+	Invert     bool
+}
+
+func (o LogOptions) query() url.Values {
+	q := url.Values{}
+	if o.Grep != "" {
+		q.Set("grep", o.Grep)
+	}
+	if o.Level != "" {
+		q.Set("level", o.Level)
+	}
+	if o.Stream != "" {
+		q.Set("stream", o.Stream)
+	}
+	if o.Tail > 0 {
+		q.Set("tail", strconv.Itoa(o.Tail))
+	}
+	if o.IgnoreCase {
+		q.Set("ignore_case", "true")
+	}
+	if o.Invert {
+		q.Set("invert", "true")
+	}
+	return q
+}
+
+func minerPath(name string, suffix string) string {
+	return "/miners/" + url.PathEscape(name) + suffix
+}
+
+// Health reports liveness; it needs no credentials.
+func (c *Client) Health(ctx context.Context) (*mining.HealthResponse, error) {
+	var health mining.HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/health", nil, nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// Info returns system information and installed miners.
+func (c *Client) Info(ctx context.Context) (*mining.SystemInfo, error) {
+	var info mining.SystemInfo
+	if err := c.do(ctx, http.MethodGet, "/info", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Setup completes first-run setup, setting the admin credential. Use
+// WithCredentials with the same credential for later calls.
+func (c *Client) Setup(ctx context.Context, req mining.SetupRequest) (*mining.SetupResponse, error) {
+	var resp mining.SetupResponse
+	if err := c.do(ctx, http.MethodPost, "/setup", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListMiners returns the running miners.
+func (c *Client) ListMiners(ctx context.Context) ([]*mining.BaseMiner, error) {
+	var miners []*mining.BaseMiner
+	if err := c.do(ctx, http.MethodGet, "/miners", nil, nil, &miners); err != nil {
+		return nil, err
+	}
+	return miners, nil
+}
+
+// ListAvailableMiners returns the miner types that can be installed.
+func (c *Client) ListAvailableMiners(ctx context.Context) ([]mining.AvailableMiner, error) {
+	var miners []mining.AvailableMiner
+	if err := c.do(ctx, http.MethodGet, "/miners/available", nil, nil, &miners); err != nil {
+		return nil, err
+	}
+	return miners, nil
+}
+
+// InstallMiner installs or updates a miner type such as "xmrig".
+func (c *Client) InstallMiner(ctx context.Context, minerType string) (*InstallResult, error) {
+	var result InstallResult
+	if err := c.do(ctx, http.MethodPost, minerPath(minerType, "/install"), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// UninstallMiner removes an installed miner type.
+func (c *Client) UninstallMiner(ctx context.Context, minerType string) error {
+	return c.do(ctx, http.MethodDelete, minerPath(minerType, "/uninstall"), nil, nil, nil)
+}
+
+// StartMiner starts a miner from a pool and wallet without a profile. Values
+// left empty fall back to the miner defaults in the server's settings.
+func (c *Client) StartMiner(ctx context.Context, req mining.StartMiningRequest) (*mining.StartMiningResult, error) {
+	var result mining.StartMiningResult
+	if err := c.do(ctx, http.MethodPost, "/start_mining", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// StopMiner stops a running miner by name.
+func (c *Client) StopMiner(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, minerPath(name, ""), nil, nil, nil)
+}
+
+// ValidateMinerConfig checks a config without starting anything.
+func (c *Client) ValidateMinerConfig(ctx context.Context, minerType string, config mining.Config) (*mining.ConfigValidationResult, error) {
+	var result mining.ConfigValidationResult
+	req := mining.ValidateConfigRequest{MinerType: minerType, Config: config}
+	if err := c.do(ctx, http.MethodPost, "/miners/validate", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetStats returns a running miner's current stats.
+func (c *Client) GetStats(ctx context.Context, name string) (*mining.PerformanceMetrics, error) {
+	var stats mining.PerformanceMetrics
+	if err := c.do(ctx, http.MethodGet, minerPath(name, "/stats"), nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetHashrateHistory returns a running miner's in-memory hashrate history.
+func (c *Client) GetHashrateHistory(ctx context.Context, name string) ([]mining.HashratePoint, error) {
+	var history []mining.HashratePoint
+	if err := c.do(ctx, http.MethodGet, minerPath(name, "/hashrate-history"), nil, nil, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetLogs returns a running miner's captured output, decoded from the
+// base64 the API sends, with ANSI escape codes intact.
+func (c *Client) GetLogs(ctx context.Context, name string, opts LogOptions) ([]string, error) {
+	var encoded []string
+	if err := c.do(ctx, http.MethodGet, minerPath(name, "/logs"), opts.query(), nil, &encoded); err != nil {
+		return nil, err
+	}
+	lines := make([]string, len(encoded))
+	for i, line := range encoded {
+		decoded, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log line %d: %w", i, err)
+		}
+		lines[i] = string(decoded)
+	}
+	return lines, nil
+}
+
+// ListProfiles returns the saved mining profiles.
+func (c *Client) ListProfiles(ctx context.Context) ([]mining.MiningProfile, error) {
+	var profiles []mining.MiningProfile
+	if err := c.do(ctx, http.MethodGet, "/profiles", nil, nil, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// GetProfile returns a profile by ID.
+func (c *Client) GetProfile(ctx context.Context, id string) (*mining.MiningProfile, error) {
+	var profile mining.MiningProfile
+	if err := c.do(ctx, http.MethodGet, "/profiles/"+url.PathEscape(id), nil, nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// CreateProfile saves a new profile and returns it with its assigned ID.
+func (c *Client) CreateProfile(ctx context.Context, profile mining.MiningProfile) (*mining.MiningProfile, error) {
+	var created mining.MiningProfile
+	if err := c.do(ctx, http.MethodPost, "/profiles", nil, profile, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateProfile replaces the profile with profile.ID.
+func (c *Client) UpdateProfile(ctx context.Context, profile mining.MiningProfile) (*mining.MiningProfile, error) {
+	var updated mining.MiningProfile
+	if err := c.do(ctx, http.MethodPut, "/profiles/"+url.PathEscape(profile.ID), nil, profile, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteProfile deletes a profile. Deleting a missing profile succeeds.
+func (c *Client) DeleteProfile(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/profiles/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// StartProfile starts a miner with a saved profile's config.
+func (c *Client) StartProfile(ctx context.Context, id string) (*mining.BaseMiner, error) {
+	var miner mining.BaseMiner
+	if err := c.do(ctx, http.MethodPost, "/profiles/"+url.PathEscape(id)+"/start", nil, nil, &miner); err != nil {
+		return nil, err
+	}
+	return &miner, nil
+}
+
+// GetSettings returns the application settings.
+func (c *Client) GetSettings(ctx context.Context) (*mining.AppSettings, error) {
+	var settings mining.AppSettings
+	if err := c.do(ctx, http.MethodGet, "/settings", nil, nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// PatchSettings merges patch, any JSON-encodable partial settings object,
+// into the settings and returns the result.
+func (c *Client) PatchSettings(ctx context.Context, patch interface{}) (*mining.AppSettings, error) {
+	var settings mining.AppSettings
+	if err := c.do(ctx, http.MethodPatch, "/settings", nil, patch, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// FleetSummary returns the compact fleet overview.
+func (c *Client) FleetSummary(ctx context.Context) (*mining.FleetSummary, error) {
+	var summary mining.FleetSummary
+	if err := c.do(ctx, http.MethodGet, "/fleet_summary", nil, nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// EventSchema describes the event payloads the server sends, including their version.
+func (c *Client) EventSchema(ctx context.Context) (*mining.EventSchema, error) {
+	var schema mining.EventSchema
+	if err := c.do(ctx, http.MethodGet, "/ws/events/schema", nil, nil, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// ListPeers returns the known P2P peers. Fails with 404 if P2P is unavailable.
+func (c *Client) ListPeers(ctx context.Context) ([]*node.Peer, error) {
+	var peers []*node.Peer
+	if err := c.do(ctx, http.MethodGet, "/peers", nil, nil, &peers); err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+// RemoteStats returns the last stats received from each connected peer, by peer ID.
+func (c *Client) RemoteStats(ctx context.Context) (map[string]*node.StatsPayload, error) {
+	var stats map[string]*node.StatsPayload
+	if err := c.do(ctx, http.MethodGet, "/remote/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// PeerAuthMode returns how the node treats unknown peers: "open" or "allowlist".
+func (c *Client) PeerAuthMode(ctx context.Context) (string, error) {
+	var resp mining.AuthModeResponse
+	if err := c.do(ctx, http.MethodGet, "/node/auth-mode", nil, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Mode, nil
+}
+
+// SetPeerAuthMode sets how the node treats unknown peers: "open" or "allowlist".
+func (c *Client) SetPeerAuthMode(ctx context.Context, mode string) error {
+	return c.do(ctx, http.MethodPut, "/node/auth-mode", nil, mining.SetAuthModeRequest{Mode: mode}, nil)
+}
+
+// PeerAllowlist returns the public keys allowed to connect in allowlist mode.
+func (c *Client) PeerAllowlist(ctx context.Context) ([]string, error) {
+	var resp mining.AllowlistResponse
+	if err := c.do(ctx, http.MethodGet, "/node/allowlist", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.PublicKeys, nil
+}
+
+// AllowPublicKey adds a peer's public key to the allowlist.
+func (c *Client) AllowPublicKey(ctx context.Context, publicKey string) error {
+	return c.do(ctx, http.MethodPost, "/node/allowlist", nil, mining.AddAllowlistRequest{PublicKey: publicKey}, nil)
+}
+
+// RevokePublicKey removes a public key from the allowlist.
+func (c *Client) RevokePublicKey(ctx context.Context, publicKey string) error {
+	return c.do(ctx, http.MethodDelete, "/node/allowlist", url.Values{"publicKey": {publicKey}}, nil, nil)
+}
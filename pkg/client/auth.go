@@ -0,0 +1,118 @@
+package client
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// authenticator answers the server's WWW-Authenticate challenges. The last
+// digest challenge is reused, with an increasing nonce count, so only the
+// first request (and one after each nonce expiry) takes an extra round trip.
+type authenticator struct {
+	username string
+	password string
+
+	mu     sync.Mutex
+	digest map[string]string // parameters of the last digest challenge, nil before one
+	basic  bool              // the server only offered Basic
+	nc     int
+}
+
+func newAuthenticator(username, password string) *authenticator {
+	return &authenticator{username: username, password: password}
+}
+
+// challenge records the challenge in a 401 response. Returns false if it
+// offers neither Digest nor Basic auth, so a retry would not help.
+func (a *authenticator) challenge(resp *http.Response) bool {
+	for _, header := range resp.Header.Values("WWW-Authenticate") {
+		scheme, params, _ := strings.Cut(header, " ")
+		switch strings.ToLower(scheme) {
+		case "digest":
+			a.mu.Lock()
+			a.digest = parseChallenge(params)
+			a.nc = 0
+			a.mu.Unlock()
+			return true
+		case "basic":
+			a.mu.Lock()
+			a.basic = true
+			a.mu.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// authorize sets the Authorization header from the last challenge, if any.
+func (a *authenticator) authorize(req *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case a.digest != nil:
+		a.nc++
+		req.Header.Set("Authorization", a.digestHeader(req.Method, req.URL.RequestURI()))
+	case a.basic:
+		req.SetBasicAuth(a.username, a.password)
+	}
+}
+
+// digestHeader computes an RFC 2617 response with qop=auth. Caller must hold a.mu.
+func (a *authenticator) digestHeader(method, uri string) string {
+	realm, nonce, opaque := a.digest["realm"], a.digest["nonce"], a.digest["opaque"]
+	nc := fmt.Sprintf("%08x", a.nc)
+	cnonce := newCnonce()
+
+	ha1 := md5Hex(a.username + ":" + realm + ":" + a.password)
+	ha2 := md5Hex(method + ":" + uri)
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, a.username, realm, nonce, uri)
+	if qopOffered(a.digest["qop"], "auth") {
+		response := md5Hex(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":auth:" + ha2)
+		header += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s", response="%s"`, nc, cnonce, response)
+	} else {
+		header += fmt.Sprintf(`, response="%s"`, md5Hex(ha1+":"+nonce+":"+ha2))
+	}
+	if opaque != "" {
+		header += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	return header
+}
+
+// parseChallenge splits the comma-separated key="value" pairs of a challenge.
+func parseChallenge(params string) map[string]string {
+	result := make(map[string]string)
+	for _, part := range strings.Split(params, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		result[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return result
+}
+
+func qopOffered(qop, want string) bool {
+	for _, q := range strings.Split(qop, " ") {
+		if strings.TrimSpace(q) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func newCnonce() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func md5Hex(s string) string {
+	h := md5.Sum([]byte(s))
+	return hex.EncodeToString(h[:])
+}
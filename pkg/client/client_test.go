@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Snider/Mining/pkg/mining"
+	"github.com/adrg/xdg"
+)
+
+// newTestServer runs the real API, with digest auth and one simulated miner,
+// against temporary config and data directories. Returns the API base URL.
+func newTestServer(t *testing.T) string {
+	t.Helper()
+	t.Cleanup(xdg.Reload) // Runs after Setenv restores the environment
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("MINING_API_AUTH", "true")
+	t.Setenv("MINING_API_USER", "admin")
+	t.Setenv("MINING_API_PASS", "secret")
+	xdg.Reload()
+
+	manager := mining.NewManagerForSimulation()
+	miner := mining.NewSimulatedMiner(mining.SimulatedMinerConfig{Name: "sim-1", Algorithm: "rx/0", BaseHashrate: 1000})
+	if err := miner.Start(&mining.Config{}); err != nil {
+		t.Fatalf("failed to start simulated miner: %v", err)
+	}
+	if err := manager.RegisterMiner(miner); err != nil {
+		t.Fatalf("failed to register simulated miner: %v", err)
+	}
+
+	// Each service registers its swagger docs under its namespace, which must be unique
+	namespace := "/api/" + t.Name()
+	service, err := mining.NewService(manager, "127.0.0.1:0", "127.0.0.1:0", namespace)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	service.InitRouter()
+	server := httptest.NewServer(service.Router)
+	t.Cleanup(func() {
+		server.Close()
+		service.Stop()
+		manager.Stop()
+	})
+	return server.URL + namespace
+}
+
+func TestClient(t *testing.T) {
+	baseURL := newTestServer(t)
+	ctx := context.Background()
+
+	anonymous, err := New(baseURL)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := anonymous.Health(ctx); err != nil {
+		t.Fatalf("health should not need credentials: %v", err)
+	}
+	_, err = anonymous.ListMiners(ctx)
+	if !IsCode(err, "AUTH_REQUIRED") {
+		t.Fatalf("expected AUTH_REQUIRED, got %v", err)
+	}
+	if apiErr := err.(*Error); apiErr.StatusCode != http.StatusUnauthorized || apiErr.RequestID == "" {
+		t.Fatalf("unexpected error: %+v", apiErr)
+	}
+
+	c, err := New(baseURL+"/", WithCredentials("admin", "secret"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	miners, err := c.ListMiners(ctx)
+	if err != nil {
+		t.Fatalf("ListMiners failed: %v", err)
+	}
+	if len(miners) != 1 || miners[0].Name != "sim-1" {
+		t.Fatalf("unexpected miners: %+v", miners)
+	}
+
+	// The cached digest challenge is reused with an increasing nonce count
+	stats, err := c.GetStats(ctx, "sim-1")
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.Algorithm != "rx/0" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	_, err = c.GetStats(WithRequestID(ctx, "req-123"), "missing")
+	if !IsCode(err, mining.ErrCodeMinerNotFound) {
+		t.Fatalf("expected MINER_NOT_FOUND, got %v", err)
+	}
+	if apiErr := err.(*Error); apiErr.RequestID != "req-123" || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected error: %+v", apiErr)
+	}
+
+	created, err := c.CreateProfile(ctx, mining.MiningProfile{Name: "test", MinerType: "xmrig", Config: mining.RawConfig(`{"pool":"stratum+tcp://pool.example.com:3333"}`)})
+	if err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	profiles, err := c.ListProfiles(ctx)
+	if err != nil || len(profiles) != 1 || profiles[0].ID != created.ID {
+		t.Fatalf("unexpected profiles %+v: %v", profiles, err)
+	}
+	if err := c.DeleteProfile(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteProfile failed: %v", err)
+	}
+}
+
+func TestClientSubscribe(t *testing.T) {
+	baseURL := newTestServer(t)
+	ctx := context.Background()
+
+	c, err := New(baseURL, WithCredentials("admin", "secret"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	sub, err := c.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer sub.Close()
+
+	// Give the hub time to register the subscription before the event
+	time.Sleep(100 * time.Millisecond)
+	if err := c.StopMiner(ctx, "sim-1"); err != nil {
+		t.Fatalf("StopMiner failed: %v", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				t.Fatalf("subscription ended: %v", sub.Err())
+			}
+			if event.Type != mining.EventMinerStopped {
+				continue
+			}
+			var data mining.MinerEventData
+			if err := DecodeEventData(event, &data); err != nil {
+				t.Fatalf("DecodeEventData failed: %v", err)
+			}
+			if data.Name != "sim-1" {
+				t.Fatalf("unexpected event data: %+v", data)
+			}
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for miner.stopped")
+		}
+	}
+}
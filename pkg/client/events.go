@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Snider/Mining/pkg/mining"
+	"github.com/gorilla/websocket"
+)
+
+// Subscription receives events from /ws/events until closed.
+type Subscription struct {
+	conn   *websocket.Conn
+	events chan mining.Event
+	done   chan struct{}
+
+	closeOnce sync.Once
+	writeMu   sync.Mutex // serializes Resume and the close message
+	mu        sync.Mutex
+	err       error
+}
+
+// Subscribe opens the event WebSocket. Events for all miners are delivered
+// unless miner names are given. Each event's Data is a json.RawMessage;
+// decode it with DecodeEventData. Events with a Version above
+// mining.EventVersion come from a newer server and may not decode as expected.
+func (c *Client) Subscribe(ctx context.Context, miners ...string) (*Subscription, error) {
+	wsURL := *c.baseURL
+	wsURL.Path += "/ws/events"
+	switch wsURL.Scheme {
+	case "https":
+		wsURL.Scheme = "wss"
+	default:
+		wsURL.Scheme = "ws"
+	}
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+	}
+	if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+		dialer.TLSClientConfig = transport.TLSClientConfig
+	}
+
+	dial := func() (*websocket.Conn, *http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, wsURL.String(), nil)
+		req.Header.Set("User-Agent", c.userAgent)
+		req.Header.Set("X-Request-ID", requestID(ctx))
+		if c.auth != nil {
+			c.auth.authorize(req)
+		}
+		return dialer.DialContext(ctx, wsURL.String(), req.Header)
+	}
+
+	conn, resp, err := dial()
+	if err != nil && resp != nil && resp.StatusCode == http.StatusUnauthorized && c.auth != nil && c.auth.challenge(resp) {
+		conn, resp, err = dial()
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return nil, parseError(resp)
+		}
+		return nil, fmt.Errorf("failed to connect to event stream: %w", err)
+	}
+
+	if len(miners) == 0 {
+		miners = []string{"*"}
+	}
+	subscribe := map[string]interface{}{"type": "subscribe", "miners": miners, "client": c.userAgent}
+	if err := conn.WriteJSON(subscribe); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	sub := &Subscription{
+		conn:   conn,
+		events: make(chan mining.Event, 64),
+		done:   make(chan struct{}),
+	}
+	go sub.readLoop()
+	return sub, nil
+}
+
+// Resume asks the server for the events after lastSeq, the Seq of the last
+// event received on a previous subscription. If they are no longer buffered
+// the server sends a state.sync event instead.
+func (s *Subscription) Resume(lastSeq uint64) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteJSON(map[string]interface{}{"type": "resume", "lastSeq": lastSeq})
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription ends; Err then reports why.
+func (s *Subscription) Events() <-chan mining.Event {
+	return s.events
+}
+
+// Err returns the error that ended the subscription, or nil if it was closed.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription. Safe to call multiple times.
+func (s *Subscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.writeMu.Lock()
+		s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		s.writeMu.Unlock()
+		err = s.conn.Close()
+	})
+	return err
+}
+
+func (s *Subscription) readLoop() {
+	defer close(s.events)
+	for {
+		var raw struct {
+			Seq       uint64           `json:"seq"`
+			Version   int              `json:"version"`
+			Type      mining.EventType `json:"type"`
+			Timestamp time.Time        `json:"timestamp"`
+			Data      json.RawMessage  `json:"data,omitempty"`
+		}
+		if err := s.conn.ReadJSON(&raw); err != nil {
+			select {
+			case <-s.done:
+			default:
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					s.mu.Lock()
+					s.err = err
+					s.mu.Unlock()
+				}
+				s.conn.Close()
+			}
+			return
+		}
+
+		event := mining.Event{Seq: raw.Seq, Version: raw.Version, Type: raw.Type, Timestamp: raw.Timestamp}
+		if len(raw.Data) > 0 {
+			event.Data = raw.Data
+		}
+		select {
+		case s.events <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// DecodeEventData decodes the data of an event received from a
+// Subscription, e.g. into a mining.MinerStatsData for miner.stats events.
+func DecodeEventData(event mining.Event, v interface{}) error {
+	data, ok := event.Data.(json.RawMessage)
+	if !ok {
+		return errors.New("event has no data")
+	}
+	return json.Unmarshal(data, v)
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -14,8 +15,9 @@ import (
 
 // DB is the global database instance
 var (
-	db   *sql.DB
-	dbMu sync.RWMutex
+	db     *sql.DB
+	dbPath string // file backing db, for size reporting
+	dbMu   sync.RWMutex
 )
 
 // Config holds database configuration options
@@ -55,17 +57,17 @@ func Initialize(cfg Config) error {
 		return nil
 	}
 
-	dbPath := cfg.Path
-	if dbPath == "" {
+	path := cfg.Path
+	if path == "" {
 		var err error
-		dbPath, err = defaultDBPath()
+		path, err = defaultDBPath()
 		if err != nil {
 			return err
 		}
 	}
 
 	var err error
-	db, err = sql.Open("sqlite3", dbPath+"?_journal=WAL&_timeout=5000")
+	db, err = sql.Open("sqlite3", path+"?_journal=WAL&_timeout=5000")
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -84,6 +86,7 @@ func Initialize(cfg Config) error {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	dbPath = path
 	return nil
 }
 
@@ -98,6 +101,7 @@ func Close() error {
 
 	err := db.Close()
 	db = nil
+	dbPath = ""
 	return err
 }
 
@@ -170,8 +174,9 @@ func Cleanup(retentionDays int) error {
 	return err
 }
 
-// vacuumDB optimizes the database file size
-func vacuumDB() error {
+// CleanupResolution removes rows of one resolution older than retentionDays,
+// so high resolution history can be dropped sooner than its minute averages.
+func CleanupResolution(resolution Resolution, retentionDays int) error {
 	dbMu.RLock()
 	defer dbMu.RUnlock()
 
@@ -179,6 +184,62 @@ func vacuumDB() error {
 		return nil
 	}
 
-	_, err := db.Exec("VACUUM")
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+
+	_, err := db.Exec(`
+		DELETE FROM hashrate_history
+		WHERE resolution = ? AND timestamp < ?
+	`, string(resolution), cutoff)
+
 	return err
 }
+
+// VacuumResult reports the on-disk size of the database, including its WAL,
+// before and after a vacuum.
+type VacuumResult struct {
+	SizeBefore int64 `json:"sizeBefore"`
+	SizeAfter  int64 `json:"sizeAfter"`
+	DurationMs int64 `json:"durationMs"`
+}
+
+// Vacuum rebuilds the database file to return the space freed by Cleanup to
+// the OS, then truncates the WAL. VACUUM needs the database to itself and the
+// pool holds a single connection, so inserts and queries wait until it
+// finishes; expect that to take about as long as copying the file once.
+// Returns nil if the database is not enabled.
+func Vacuum(ctx context.Context) (*VacuumResult, error) {
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+
+	if db == nil {
+		return nil, nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	start := time.Now()
+	result := &VacuumResult{SizeBefore: fileSize(dbPath)}
+
+	if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return nil, fmt.Errorf("failed to checkpoint WAL: %w", err)
+	}
+
+	result.SizeAfter = fileSize(dbPath)
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// fileSize returns the combined size of a SQLite database and its WAL file.
+func fileSize(path string) int64 {
+	var size int64
+	for _, p := range []string{path, path + "-wal"} {
+		if info, err := os.Stat(p); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"sync"
@@ -63,7 +64,7 @@ func TestConcurrentHashrateInserts(t *testing.T) {
 	// Verify data was inserted
 	for i := 0; i < 10; i++ {
 		minerName := "miner" + string(rune('A'+i))
-		history, err := GetHashrateHistory(minerName, ResolutionHigh, time.Now().Add(-2*time.Minute), time.Now())
+		history, err := GetHashrateHistory(context.Background(), minerName, ResolutionHigh, time.Now().Add(-2*time.Minute), time.Now())
 		if err != nil {
 			t.Errorf("Failed to get history for %s: %v", minerName, err)
 		}
@@ -111,7 +112,7 @@ func TestConcurrentInsertAndQuery(t *testing.T) {
 				case <-stop:
 					return
 				default:
-					GetHashrateHistory("concurrent-test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now())
+					GetHashrateHistory(context.Background(), "concurrent-test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now())
 					time.Sleep(2 * time.Millisecond)
 				}
 			}
@@ -208,7 +209,7 @@ func TestConcurrentStats(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			for j := 0; j < 50; j++ {
-				stats, err := GetHashrateStats(minerName)
+				stats, err := GetHashrateStats(context.Background(), minerName)
 				if err != nil {
 					t.Errorf("Stats error: %v", err)
 				}
@@ -250,7 +251,7 @@ func TestConcurrentGetAllStats(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			for j := 0; j < 30; j++ {
-				_, err := GetAllMinerStats()
+				_, err := GetAllMinerStats(context.Background())
 				if err != nil {
 					t.Errorf("GetAllMinerStats error: %v", err)
 				}
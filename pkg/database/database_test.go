@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -83,7 +85,7 @@ func TestHashrateStorage(t *testing.T) {
 	}
 
 	// Retrieve the data
-	retrieved, err := GetHashrateHistory(minerName, ResolutionHigh, now.Add(-10*time.Minute), now)
+	retrieved, err := GetHashrateHistory(context.Background(), minerName, ResolutionHigh, now.Add(-10*time.Minute), now)
 	if err != nil {
 		t.Fatalf("Failed to get hashrate history: %v", err)
 	}
@@ -114,7 +116,7 @@ func TestGetHashrateStats(t *testing.T) {
 		}
 	}
 
-	stats, err := GetHashrateStats(minerName)
+	stats, err := GetHashrateStats(context.Background(), minerName)
 	if err != nil {
 		t.Fatalf("Failed to get stats: %v", err)
 	}
@@ -186,7 +188,7 @@ func TestCleanupRetention(t *testing.T) {
 	}
 
 	// Verify all 3 points exist
-	history, err := GetHashrateHistory(minerName, ResolutionHigh, now.AddDate(0, 0, -40), now)
+	history, err := GetHashrateHistory(context.Background(), minerName, ResolutionHigh, now.AddDate(0, 0, -40), now)
 	if err != nil {
 		t.Fatalf("Failed to get history before cleanup: %v", err)
 	}
@@ -200,7 +202,7 @@ func TestCleanupRetention(t *testing.T) {
 	}
 
 	// Verify only 2 points remain (35-day old point should be deleted)
-	history, err = GetHashrateHistory(minerName, ResolutionHigh, now.AddDate(0, 0, -40), now)
+	history, err = GetHashrateHistory(context.Background(), minerName, ResolutionHigh, now.AddDate(0, 0, -40), now)
 	if err != nil {
 		t.Fatalf("Failed to get history after cleanup: %v", err)
 	}
@@ -246,7 +248,7 @@ func TestGetHashrateHistoryTimeRange(t *testing.T) {
 	// Query for middle range (should get 3 points: -8, -6, -4 minutes)
 	since := now.Add(-9 * time.Minute)
 	until := now.Add(-3 * time.Minute)
-	history, err := GetHashrateHistory(minerName, ResolutionHigh, since, until)
+	history, err := GetHashrateHistory(context.Background(), minerName, ResolutionHigh, since, until)
 	if err != nil {
 		t.Fatalf("Failed to get history: %v", err)
 	}
@@ -258,7 +260,7 @@ func TestGetHashrateHistoryTimeRange(t *testing.T) {
 	// Query boundary condition - exact timestamp match
 	exactSince := now.Add(-6 * time.Minute)
 	exactUntil := now.Add(-6 * time.Minute).Add(time.Second)
-	history, err = GetHashrateHistory(minerName, ResolutionHigh, exactSince, exactUntil)
+	history, err = GetHashrateHistory(context.Background(), minerName, ResolutionHigh, exactSince, exactUntil)
 	if err != nil {
 		t.Fatalf("Failed to get exact history: %v", err)
 	}
@@ -269,6 +271,39 @@ func TestGetHashrateHistoryTimeRange(t *testing.T) {
 	}
 }
 
+func TestStreamHashrateHistory(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		point := HashratePoint{Timestamp: now.Add(time.Duration(i-5) * time.Minute), Hashrate: 1000 + i}
+		if err := InsertHashratePoint(nil, "stream-test", "xmrig", point, ResolutionHigh); err != nil {
+			t.Fatalf("Failed to insert point: %v", err)
+		}
+	}
+
+	var got []int
+	err := StreamHashrateHistory(context.Background(), "stream-test", ResolutionHigh, now.Add(-time.Hour), now, func(p HashratePoint) error {
+		got = append(got, p.Hashrate)
+		return nil
+	})
+	if err != nil || len(got) != 5 || got[0] != 1000 || got[4] != 1004 {
+		t.Fatalf("expected 5 points oldest first, got %v %v", got, err)
+	}
+
+	// An error from fn stops the stream
+	stop := errors.New("client gone")
+	calls := 0
+	err = StreamHashrateHistory(context.Background(), "stream-test", ResolutionHigh, now.Add(-time.Hour), now, func(p HashratePoint) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected the stream stopped after 1 call with fn's error, got %d calls, %v", calls, err)
+	}
+}
+
 func TestMultipleMinerStats(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -298,7 +333,7 @@ func TestMultipleMinerStats(t *testing.T) {
 	}
 
 	// Get all miner stats
-	allStats, err := GetAllMinerStats()
+	allStats, err := GetAllMinerStats(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to get all stats: %v", err)
 	}
@@ -428,7 +463,7 @@ func TestReInitializeExistingDB(t *testing.T) {
 	}()
 
 	// Verify data persisted
-	history, err := GetHashrateHistory(minerName, ResolutionHigh, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	history, err := GetHashrateHistory(context.Background(), minerName, ResolutionHigh, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
 	if err != nil {
 		t.Fatalf("Failed to get history after reinit: %v", err)
 	}
@@ -469,7 +504,7 @@ func TestConcurrentDatabaseAccess(t *testing.T) {
 				}
 
 				// Read
-				_, err := GetHashrateHistory(minerName, ResolutionHigh, now.Add(-time.Hour), now)
+				_, err := GetHashrateHistory(context.Background(), minerName, ResolutionHigh, now.Add(-time.Hour), now)
 				if err != nil {
 					errors <- err
 				}
@@ -495,3 +530,101 @@ func TestConcurrentDatabaseAccess(t *testing.T) {
 		t.Errorf("Got %d errors during concurrent access", errCount)
 	}
 }
+
+func TestVacuum(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	old := time.Now().AddDate(0, 0, -60)
+	for i := 0; i < 2000; i++ {
+		point := HashratePoint{Timestamp: old.Add(time.Duration(i) * time.Second), Hashrate: i}
+		if err := InsertHashratePoint(nil, "vacuum-test", "xmrig", point, ResolutionHigh); err != nil {
+			t.Fatalf("Failed to insert point: %v", err)
+		}
+	}
+	if err := Cleanup(30); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+
+	result, err := Vacuum(context.Background())
+	if err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+	if result.SizeBefore == 0 || result.SizeAfter == 0 {
+		t.Fatalf("expected sizes to be reported, got %+v", result)
+	}
+	if result.SizeAfter >= result.SizeBefore {
+		t.Errorf("expected vacuum to shrink the database, got %+v", result)
+	}
+
+	Close()
+	if result, err := Vacuum(context.Background()); result != nil || err != nil {
+		t.Errorf("expected vacuum to be skipped when disabled, got %+v, %v", result, err)
+	}
+}
+
+func TestGetStorageStats(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	points := []struct {
+		miner      string
+		resolution Resolution
+		at         time.Time
+	}{
+		{"storage-a", ResolutionHigh, now.Add(-time.Hour)},
+		{"storage-a", ResolutionHigh, now},
+		{"storage-b", ResolutionLow, now.Add(-2 * time.Hour)},
+	}
+	for _, p := range points {
+		if err := InsertHashratePoint(nil, p.miner, "xmrig", HashratePoint{Timestamp: p.at, Hashrate: 100}, p.resolution); err != nil {
+			t.Fatalf("Failed to insert point: %v", err)
+		}
+	}
+
+	stats, err := GetStorageStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStorageStats failed: %v", err)
+	}
+	if stats.TotalRows != 3 || stats.RowsByMiner["storage-a"] != 2 || stats.RowsByMiner["storage-b"] != 1 {
+		t.Errorf("unexpected row counts: %+v", stats)
+	}
+	if stats.RowsByResolution["high"] != 2 || stats.RowsByResolution["low"] != 1 {
+		t.Errorf("unexpected resolution counts: %+v", stats.RowsByResolution)
+	}
+	if stats.FileSize == 0 {
+		t.Error("expected a non-zero file size")
+	}
+	if !stats.Oldest.Before(stats.Newest) || stats.Newest.Sub(stats.Oldest) < time.Hour {
+		t.Errorf("unexpected time range %v - %v", stats.Oldest, stats.Newest)
+	}
+
+	// A repeat call within the TTL is served from cache
+	InsertHashratePoint(nil, "storage-c", "xmrig", HashratePoint{Timestamp: now, Hashrate: 1}, ResolutionHigh)
+	cached, _ := GetStorageStats(context.Background())
+	if cached.TotalRows != 3 {
+		t.Errorf("expected cached stats, got %d rows", cached.TotalRows)
+	}
+}
+
+func TestCleanupResolution(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	old := time.Now().AddDate(0, 0, -10)
+	InsertHashratePoint(nil, "res-test", "xmrig", HashratePoint{Timestamp: old, Hashrate: 100}, ResolutionHigh)
+	InsertHashratePoint(nil, "res-test", "xmrig", HashratePoint{Timestamp: old, Hashrate: 100}, ResolutionLow)
+
+	if err := CleanupResolution(ResolutionHigh, 7); err != nil {
+		t.Fatalf("CleanupResolution failed: %v", err)
+	}
+
+	since, until := old.Add(-time.Hour), time.Now()
+	if high, _ := GetHashrateHistory(context.Background(), "res-test", ResolutionHigh, since, until); len(high) != 0 {
+		t.Errorf("expected old high resolution rows removed, got %d", len(high))
+	}
+	if low, _ := GetHashrateHistory(context.Background(), "res-test", ResolutionLow, since, until); len(low) != 1 {
+		t.Errorf("expected low resolution rows kept, got %d", len(low))
+	}
+}
@@ -51,8 +51,24 @@ type HashratePoint struct {
 // dbInsertTimeout is the maximum time to wait for a database insert operation
 const dbInsertTimeout = 5 * time.Second
 
+// dbQueryTimeout is the maximum time to wait for a database read operation
+const dbQueryTimeout = 10 * time.Second
+
+// withDefaultTimeout returns ctx bounded by timeout when it has no deadline of
+// its own, so a nil or Background context can't leave a query running forever.
+// A caller's cancellation still applies.
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // InsertHashratePoint stores a hashrate measurement in the database.
-// If ctx is nil, a default timeout context will be used.
+// If ctx is nil or has no deadline, a default timeout will be used.
 func InsertHashratePoint(ctx context.Context, minerName, minerType string, point HashratePoint, resolution Resolution) error {
 	dbMu.RLock()
 	defer dbMu.RUnlock()
@@ -61,12 +77,8 @@ func InsertHashratePoint(ctx context.Context, minerName, minerType string, point
 		return nil // DB not enabled, silently skip
 	}
 
-	// Use provided context or create one with default timeout
-	if ctx == nil {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(context.Background(), dbInsertTimeout)
-		defer cancel()
-	}
+	ctx, cancel := withDefaultTimeout(ctx, dbInsertTimeout)
+	defer cancel()
 
 	_, err := db.ExecContext(ctx, `
 		INSERT INTO hashrate_history (miner_name, miner_type, timestamp, hashrate, resolution)
@@ -76,16 +88,37 @@ func InsertHashratePoint(ctx context.Context, minerName, minerType string, point
 	return err
 }
 
-// GetHashrateHistory retrieves hashrate history for a miner within a time range
-func GetHashrateHistory(minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error) {
+// GetHashrateHistory retrieves hashrate history for a miner within a time range.
+// If ctx is nil or has no deadline, a default timeout will be used.
+func GetHashrateHistory(ctx context.Context, minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error) {
+	var points []HashratePoint
+	err := StreamHashrateHistory(ctx, minerName, resolution, since, until, func(point HashratePoint) error {
+		points = append(points, point)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// StreamHashrateHistory calls fn with each point of a miner's hashrate
+// history within a time range, oldest first, as the rows are read, so long
+// ranges needn't be held in memory. It stops at the first error fn returns.
+// The database stays read-locked until it returns, so fn shouldn't block
+// for long. If ctx is nil or has no deadline, a default timeout will be used.
+func StreamHashrateHistory(ctx context.Context, minerName string, resolution Resolution, since, until time.Time, fn func(HashratePoint) error) error {
 	dbMu.RLock()
 	defer dbMu.RUnlock()
 
 	if db == nil {
-		return nil, nil
+		return nil
 	}
 
-	rows, err := db.Query(`
+	ctx, cancel := withDefaultTimeout(ctx, dbQueryTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
 		SELECT timestamp, hashrate
 		FROM hashrate_history
 		WHERE miner_name = ?
@@ -95,23 +128,24 @@ func GetHashrateHistory(minerName string, resolution Resolution, since, until ti
 		ORDER BY timestamp ASC
 	`, minerName, string(resolution), since, until)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query hashrate history: %w", err)
+		return fmt.Errorf("failed to query hashrate history: %w", err)
 	}
 	defer rows.Close()
 
-	var points []HashratePoint
 	for rows.Next() {
 		var point HashratePoint
 		if err := rows.Scan(&point.Timestamp, &point.Hashrate); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := fn(point); err != nil {
+			return err
 		}
-		points = append(points, point)
 	}
 
-	return points, rows.Err()
+	return rows.Err()
 }
 
-// GetHashrateStats retrieves aggregated stats for a miner
+// HashrateStats holds aggregated stats for a miner
 type HashrateStats struct {
 	MinerName   string    `json:"minerName"`
 	TotalPoints int       `json:"totalPoints"`
@@ -122,7 +156,9 @@ type HashrateStats struct {
 	LastSeen    time.Time `json:"lastSeen"`
 }
 
-func GetHashrateStats(minerName string) (*HashrateStats, error) {
+// GetHashrateStats retrieves aggregated stats for a miner.
+// If ctx is nil or has no deadline, a default timeout will be used.
+func GetHashrateStats(ctx context.Context, minerName string) (*HashrateStats, error) {
 	dbMu.RLock()
 	defer dbMu.RUnlock()
 
@@ -130,9 +166,12 @@ func GetHashrateStats(minerName string) (*HashrateStats, error) {
 		return nil, nil
 	}
 
+	ctx, cancel := withDefaultTimeout(ctx, dbQueryTimeout)
+	defer cancel()
+
 	// First check if there are any rows for this miner
 	var count int
-	err := db.QueryRow(`SELECT COUNT(*) FROM hashrate_history WHERE miner_name = ?`, minerName).Scan(&count)
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM hashrate_history WHERE miner_name = ?`, minerName).Scan(&count)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +187,7 @@ func GetHashrateStats(minerName string) (*HashrateStats, error) {
 	// SQLite returns timestamps as strings and AVG as float64, so scan them appropriately
 	var firstSeenStr, lastSeenStr string
 	var avgRate float64
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT
 			COUNT(*),
 			COALESCE(AVG(hashrate), 0),
@@ -179,8 +218,9 @@ func GetHashrateStats(minerName string) (*HashrateStats, error) {
 	return &stats, nil
 }
 
-// GetAllMinerStats retrieves stats for all miners
-func GetAllMinerStats() ([]HashrateStats, error) {
+// GetAllMinerStats retrieves stats for all miners.
+// If ctx is nil or has no deadline, a default timeout will be used.
+func GetAllMinerStats(ctx context.Context) ([]HashrateStats, error) {
 	dbMu.RLock()
 	defer dbMu.RUnlock()
 
@@ -188,7 +228,10 @@ func GetAllMinerStats() ([]HashrateStats, error) {
 		return nil, nil
 	}
 
-	rows, err := db.Query(`
+	ctx, cancel := withDefaultTimeout(ctx, dbQueryTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
 		SELECT
 			miner_name,
 			COUNT(*),
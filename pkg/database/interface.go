@@ -9,17 +9,17 @@ import (
 // This interface allows for dependency injection and easier testing.
 type HashrateStore interface {
 	// InsertHashratePoint stores a hashrate measurement.
-	// If ctx is nil, a default timeout will be used.
+	// If ctx is nil or has no deadline, a default timeout will be used.
 	InsertHashratePoint(ctx context.Context, minerName, minerType string, point HashratePoint, resolution Resolution) error
 
 	// GetHashrateHistory retrieves hashrate history for a miner within a time range.
-	GetHashrateHistory(minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error)
+	GetHashrateHistory(ctx context.Context, minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error)
 
 	// GetHashrateStats retrieves aggregated statistics for a specific miner.
-	GetHashrateStats(minerName string) (*HashrateStats, error)
+	GetHashrateStats(ctx context.Context, minerName string) (*HashrateStats, error)
 
 	// GetAllMinerStats retrieves statistics for all miners.
-	GetAllMinerStats() ([]HashrateStats, error)
+	GetAllMinerStats(ctx context.Context) ([]HashrateStats, error)
 
 	// Cleanup removes old data based on retention settings.
 	Cleanup(retentionDays int) error
@@ -42,16 +42,16 @@ func (s *defaultStore) InsertHashratePoint(ctx context.Context, minerName, miner
 	return InsertHashratePoint(ctx, minerName, minerType, point, resolution)
 }
 
-func (s *defaultStore) GetHashrateHistory(minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error) {
-	return GetHashrateHistory(minerName, resolution, since, until)
+func (s *defaultStore) GetHashrateHistory(ctx context.Context, minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error) {
+	return GetHashrateHistory(ctx, minerName, resolution, since, until)
 }
 
-func (s *defaultStore) GetHashrateStats(minerName string) (*HashrateStats, error) {
-	return GetHashrateStats(minerName)
+func (s *defaultStore) GetHashrateStats(ctx context.Context, minerName string) (*HashrateStats, error) {
+	return GetHashrateStats(ctx, minerName)
 }
 
-func (s *defaultStore) GetAllMinerStats() ([]HashrateStats, error) {
-	return GetAllMinerStats()
+func (s *defaultStore) GetAllMinerStats(ctx context.Context) ([]HashrateStats, error) {
+	return GetAllMinerStats(ctx)
 }
 
 func (s *defaultStore) Cleanup(retentionDays int) error {
@@ -74,15 +74,15 @@ func (s *nopStore) InsertHashratePoint(ctx context.Context, minerName, minerType
 	return nil
 }
 
-func (s *nopStore) GetHashrateHistory(minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error) {
+func (s *nopStore) GetHashrateHistory(ctx context.Context, minerName string, resolution Resolution, since, until time.Time) ([]HashratePoint, error) {
 	return nil, nil
 }
 
-func (s *nopStore) GetHashrateStats(minerName string) (*HashrateStats, error) {
+func (s *nopStore) GetHashrateStats(ctx context.Context, minerName string) (*HashrateStats, error) {
 	return nil, nil
 }
 
-func (s *nopStore) GetAllMinerStats() ([]HashrateStats, error) {
+func (s *nopStore) GetAllMinerStats(ctx context.Context) ([]HashrateStats, error) {
 	return nil, nil
 }
 
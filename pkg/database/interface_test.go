@@ -22,7 +22,7 @@ func TestDefaultStore(t *testing.T) {
 	}
 
 	// Test GetHashrateHistory
-	history, err := store.GetHashrateHistory("interface-test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	history, err := store.GetHashrateHistory(context.Background(), "interface-test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
 	if err != nil {
 		t.Fatalf("GetHashrateHistory failed: %v", err)
 	}
@@ -31,7 +31,7 @@ func TestDefaultStore(t *testing.T) {
 	}
 
 	// Test GetHashrateStats
-	stats, err := store.GetHashrateStats("interface-test")
+	stats, err := store.GetHashrateStats(context.Background(), "interface-test")
 	if err != nil {
 		t.Fatalf("GetHashrateStats failed: %v", err)
 	}
@@ -43,7 +43,7 @@ func TestDefaultStore(t *testing.T) {
 	}
 
 	// Test GetAllMinerStats
-	allStats, err := store.GetAllMinerStats()
+	allStats, err := store.GetAllMinerStats(context.Background())
 	if err != nil {
 		t.Fatalf("GetAllMinerStats failed: %v", err)
 	}
@@ -72,7 +72,7 @@ func TestDefaultStore_WithContext(t *testing.T) {
 		t.Fatalf("InsertHashratePoint with context failed: %v", err)
 	}
 
-	history, err := store.GetHashrateHistory("ctx-test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	history, err := store.GetHashrateHistory(ctx, "ctx-test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
 	if err != nil {
 		t.Fatalf("GetHashrateHistory failed: %v", err)
 	}
@@ -81,6 +81,25 @@ func TestDefaultStore_WithContext(t *testing.T) {
 	}
 }
 
+func TestDefaultStore_CancelledContext(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	store := DefaultStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := store.GetHashrateHistory(ctx, "ctx-test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now()); err == nil {
+		t.Error("expected GetHashrateHistory to fail with a cancelled context")
+	}
+	if _, err := store.GetHashrateStats(ctx, "ctx-test"); err == nil {
+		t.Error("expected GetHashrateStats to fail with a cancelled context")
+	}
+	if _, err := store.GetAllMinerStats(ctx); err == nil {
+		t.Error("expected GetAllMinerStats to fail with a cancelled context")
+	}
+}
+
 func TestNopStore(t *testing.T) {
 	store := NopStore()
 
@@ -93,7 +112,7 @@ func TestNopStore(t *testing.T) {
 		t.Errorf("NopStore InsertHashratePoint should not error: %v", err)
 	}
 
-	history, err := store.GetHashrateHistory("test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now())
+	history, err := store.GetHashrateHistory(context.Background(), "test", ResolutionHigh, time.Now().Add(-time.Hour), time.Now())
 	if err != nil {
 		t.Errorf("NopStore GetHashrateHistory should not error: %v", err)
 	}
@@ -101,7 +120,7 @@ func TestNopStore(t *testing.T) {
 		t.Errorf("NopStore GetHashrateHistory should return nil, got %v", history)
 	}
 
-	stats, err := store.GetHashrateStats("test")
+	stats, err := store.GetHashrateStats(context.Background(), "test")
 	if err != nil {
 		t.Errorf("NopStore GetHashrateStats should not error: %v", err)
 	}
@@ -109,7 +128,7 @@ func TestNopStore(t *testing.T) {
 		t.Errorf("NopStore GetHashrateStats should return nil, got %v", stats)
 	}
 
-	allStats, err := store.GetAllMinerStats()
+	allStats, err := store.GetAllMinerStats(context.Background())
 	if err != nil {
 		t.Errorf("NopStore GetAllMinerStats should not error: %v", err)
 	}
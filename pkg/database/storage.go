@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// storageStatsTTL is how long GetStorageStats reuses a previous result.
+const storageStatsTTL = 30 * time.Second
+
+// StorageStats describes how much history the database holds and how much
+// disk it uses, to help tune retention.
+type StorageStats struct {
+	FileSize         int64          `json:"fileSize"` // Database plus WAL, in bytes
+	TotalRows        int            `json:"totalRows"`
+	RowsByMiner      map[string]int `json:"rowsByMiner"`
+	RowsByResolution map[string]int `json:"rowsByResolution"`
+	Oldest           time.Time      `json:"oldest,omitempty"`
+	Newest           time.Time      `json:"newest,omitempty"`
+	ComputedAt       time.Time      `json:"computedAt"`
+}
+
+var (
+	storageStatsCache     *StorageStats
+	storageStatsCachePath string // database the cached stats were computed for
+	storageStatsCacheMu   sync.Mutex
+)
+
+// GetStorageStats returns row counts and disk usage for the hashrate history.
+// Results are cached for storageStatsTTL since the counts scan the table.
+// If ctx is nil or has no deadline, a default timeout will be used.
+// Returns nil if the database is not enabled.
+func GetStorageStats(ctx context.Context) (*StorageStats, error) {
+	storageStatsCacheMu.Lock()
+	defer storageStatsCacheMu.Unlock()
+
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+
+	if db == nil {
+		return nil, nil
+	}
+	if storageStatsCache != nil && storageStatsCachePath == dbPath && time.Since(storageStatsCache.ComputedAt) < storageStatsTTL {
+		return storageStatsCache, nil
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, dbQueryTimeout)
+	defer cancel()
+
+	stats := &StorageStats{
+		FileSize:         fileSize(dbPath),
+		RowsByMiner:      make(map[string]int),
+		RowsByResolution: make(map[string]int),
+		ComputedAt:       time.Now(),
+	}
+
+	var oldest, newest *string
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*), MIN(timestamp), MAX(timestamp)
+		FROM hashrate_history
+	`).Scan(&stats.TotalRows, &oldest, &newest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count hashrate history: %w", err)
+	}
+	if oldest != nil {
+		stats.Oldest = parseSQLiteTimestamp(*oldest)
+	}
+	if newest != nil {
+		stats.Newest = parseSQLiteTimestamp(*newest)
+	}
+
+	if err := countRowsBy(ctx, "miner_name", stats.RowsByMiner); err != nil {
+		return nil, err
+	}
+	if err := countRowsBy(ctx, "resolution", stats.RowsByResolution); err != nil {
+		return nil, err
+	}
+
+	storageStatsCache, storageStatsCachePath = stats, dbPath
+	return stats, nil
+}
+
+// countRowsBy fills counts with the number of history rows per value of
+// column. column must be a trusted identifier. Caller must hold dbMu.
+func countRowsBy(ctx context.Context, column string, counts map[string]int) error {
+	rows, err := db.QueryContext(ctx, `SELECT `+column+`, COUNT(*) FROM hashrate_history GROUP BY `+column)
+	if err != nil {
+		return fmt.Errorf("failed to count rows by %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		counts[key] = count
+	}
+	return rows.Err()
+}
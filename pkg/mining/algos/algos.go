@@ -0,0 +1,261 @@
+// Package algos is the registry of mining algorithms: the coins mined with
+// them, the unit their hashrate is measured in, typical hashrates, wallet
+// address formats and the miners that support them. Validation, formatting
+// and the API read it instead of hardcoding algorithm knowledge.
+package algos
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Hardware an algorithm is mined on.
+const (
+	HardwareCPU = "cpu"
+	HardwareGPU = "gpu"
+)
+
+// base58 is the Bitcoin/Monero base58 alphabet as a character class body.
+const base58 = `1-9A-HJ-NP-Za-km-z`
+
+// AddressFormat describes valid wallet addresses for an algorithm's coins.
+type AddressFormat struct {
+	Name        string `json:"name"`        // Coin or family, e.g. "Monero"
+	Pattern     string `json:"pattern"`     // Regular expression a bare address matches
+	Description string `json:"description"` // What a valid address looks like
+	re          *regexp.Regexp
+}
+
+// Matches reports whether address has the format.
+func (f *AddressFormat) Matches(address string) bool {
+	re := f.re
+	if re == nil {
+		re = regexp.MustCompile(f.Pattern)
+	}
+	return re.MatchString(address)
+}
+
+// HashrateRange is the hashrate a single device typically reaches, in the
+// algorithm's unit per second.
+type HashrateRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Algorithm is the registry entry for one mining algorithm.
+type Algorithm struct {
+	Name            string         `json:"name"` // Canonical name, as passed to miners
+	Aliases         []string       `json:"aliases,omitempty"`
+	DisplayName     string         `json:"displayName"`
+	Coins           []string       `json:"coins"`    // Lowercase coin names and tickers
+	Unit            string         `json:"unit"`     // Hashrate unit before SI prefixes, e.g. "H/s"
+	Hardware        []string       `json:"hardware"` // HardwareCPU and/or HardwareGPU
+	TypicalHashrate HashrateRange  `json:"typicalHashrate"`
+	Miners          []string       `json:"miners"` // Miner types that can mine it
+	Address         *AddressFormat `json:"address,omitempty"`
+}
+
+// SupportedBy reports whether minerType can mine the algorithm.
+func (a Algorithm) SupportedBy(minerType string) bool {
+	return contains(a.Miners, minerType)
+}
+
+// MinedOn reports whether the algorithm is mined on hardware (HardwareCPU or HardwareGPU).
+func (a Algorithm) MinedOn(hardware string) bool {
+	return contains(a.Hardware, hardware)
+}
+
+// FormatHashrate renders h in the algorithm's unit with an SI prefix, e.g. "12.35 kH/s".
+func (a Algorithm) FormatHashrate(h float64) string {
+	return formatRate(h, a.Unit)
+}
+
+// FormatHashrate renders hashes per second with an SI prefix, for hashrates
+// not tied to one algorithm, such as fleet totals.
+func FormatHashrate(h float64) string {
+	return formatRate(h, "H/s")
+}
+
+func formatRate(h float64, unit string) string {
+	switch {
+	case h >= 1e9:
+		return strconv.FormatFloat(h/1e9, 'f', 2, 64) + " G" + unit
+	case h >= 1e6:
+		return strconv.FormatFloat(h/1e6, 'f', 2, 64) + " M" + unit
+	case h >= 1e3:
+		return strconv.FormatFloat(h/1e3, 'f', 2, 64) + " k" + unit
+	default:
+		return strconv.FormatFloat(h, 'f', 0, 64) + " " + unit
+	}
+}
+
+var (
+	moneroAddress = &AddressFormat{
+		Name:        "Monero",
+		Pattern:     `^[48][` + base58 + `]{94}([` + base58 + `]{11})?$`,
+		Description: "95 base58 characters starting with 4 or 8 (106 for integrated addresses)",
+	}
+	ethereumAddress = &AddressFormat{
+		Name:        "Ethereum-style",
+		Pattern:     `^0x[0-9a-fA-F]{40}$`,
+		Description: "0x followed by 40 hex characters",
+	}
+	ravencoinAddress = &AddressFormat{
+		Name:        "Ravencoin",
+		Pattern:     `^R[` + base58 + `]{33}$`,
+		Description: "34 base58 characters starting with R",
+	}
+)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]*Algorithm{}
+	aliases  = map[string]string{} // lowercase alias -> canonical name
+)
+
+func init() {
+	for _, a := range []Algorithm{
+		{
+			Name: "rx/0", Aliases: []string{"randomx", "rx"}, DisplayName: "RandomX",
+			Coins: []string{"monero", "xmr"}, Unit: "H/s", Hardware: []string{HardwareCPU},
+			TypicalHashrate: HashrateRange{Min: 500, Max: 30000},
+			Miners:          []string{"xmrig", "simulated"}, Address: moneroAddress,
+		},
+		{
+			Name: "rx/wow", DisplayName: "RandomWOW",
+			Coins: []string{"wownero", "wow"}, Unit: "H/s", Hardware: []string{HardwareCPU},
+			TypicalHashrate: HashrateRange{Min: 500, Max: 40000},
+			Miners:          []string{"xmrig", "simulated"},
+			Address: &AddressFormat{
+				Name:        "Wownero",
+				Pattern:     `^W[oW][` + base58 + `]{95}$`,
+				Description: "97 base58 characters starting with Wo or WW",
+			},
+		},
+		{
+			Name: "ghostrider", Aliases: []string{"gr"}, DisplayName: "GhostRider",
+			Coins: []string{"raptoreum", "rtm"}, Unit: "H/s", Hardware: []string{HardwareCPU},
+			TypicalHashrate: HashrateRange{Min: 200, Max: 8000},
+			Miners:          []string{"xmrig", "simulated"},
+			Address: &AddressFormat{
+				Name:        "Raptoreum",
+				Pattern:     `^R[` + base58 + `]{33}$`,
+				Description: "34 base58 characters starting with R",
+			},
+		},
+		{
+			Name: "kawpow", DisplayName: "KawPow",
+			Coins: []string{"ravencoin", "rvn"}, Unit: "H/s", Hardware: []string{HardwareGPU},
+			TypicalHashrate: HashrateRange{Min: 5e6, Max: 60e6},
+			Miners:          []string{"xmrig", "tt-miner", "simulated"}, Address: ravencoinAddress,
+		},
+		{
+			Name: "ethash", DisplayName: "Ethash",
+			Coins: []string{"ethereumpow", "ethw", "ethereum", "eth"}, Unit: "H/s", Hardware: []string{HardwareGPU},
+			TypicalHashrate: HashrateRange{Min: 20e6, Max: 130e6},
+			Miners:          []string{"tt-miner", "simulated"}, Address: ethereumAddress,
+		},
+		{
+			Name: "etchash", DisplayName: "Etchash",
+			Coins: []string{"ethereum-classic", "etc"}, Unit: "H/s", Hardware: []string{HardwareGPU},
+			TypicalHashrate: HashrateRange{Min: 20e6, Max: 130e6},
+			Miners:          []string{"tt-miner", "simulated"}, Address: ethereumAddress,
+		},
+	} {
+		if err := Register(a); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register adds an algorithm to the registry. Its name and aliases must not
+// already be registered, and its address pattern must compile.
+func Register(a Algorithm) error {
+	if a.Name == "" {
+		return fmt.Errorf("algorithm name is required")
+	}
+	if a.Unit == "" {
+		a.Unit = "H/s"
+	}
+	if a.Address != nil {
+		re, err := regexp.Compile(a.Address.Pattern)
+		if err != nil {
+			return fmt.Errorf("algorithm %s: invalid address pattern: %w", a.Name, err)
+		}
+		address := *a.Address
+		address.re = re
+		a.Address = &address
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	names := append([]string{a.Name}, a.Aliases...)
+	for _, name := range names {
+		if _, taken := aliases[strings.ToLower(name)]; taken {
+			return fmt.Errorf("algorithm %s is already registered", name)
+		}
+	}
+	for _, name := range names {
+		aliases[strings.ToLower(name)] = a.Name
+	}
+	registry[a.Name] = &a
+	return nil
+}
+
+// Lookup returns the algorithm with the given name or alias, ignoring case.
+func Lookup(name string) (Algorithm, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	canonical, ok := aliases[strings.ToLower(name)]
+	if !ok {
+		return Algorithm{}, false
+	}
+	return *registry[canonical], true
+}
+
+// ForCoin returns the algorithm a coin is mined with, by name or ticker.
+func ForCoin(coin string) (Algorithm, bool) {
+	coin = strings.ToLower(coin)
+	for _, a := range All() {
+		if contains(a.Coins, coin) {
+			return a, true
+		}
+	}
+	return Algorithm{}, false
+}
+
+// All returns every registered algorithm, sorted by name.
+func All() []Algorithm {
+	mu.RLock()
+	defer mu.RUnlock()
+	all := make([]Algorithm, 0, len(registry))
+	for _, a := range registry {
+		all = append(all, *a)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// ForMiner returns the algorithms minerType supports, sorted by name.
+func ForMiner(minerType string) []Algorithm {
+	var supported []Algorithm
+	for _, a := range All() {
+		if a.SupportedBy(minerType) {
+			supported = append(supported, a)
+		}
+	}
+	return supported
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package algos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"rx/0", "RandomX", "RX"} {
+		if a, ok := Lookup(name); !ok || a.Name != "rx/0" {
+			t.Errorf("Lookup(%q) = %q, %v", name, a.Name, ok)
+		}
+	}
+	if _, ok := Lookup("cn/unknown"); ok {
+		t.Error("expected an unknown algorithm not to be found")
+	}
+	if a, ok := ForCoin("RVN"); !ok || a.Name != "kawpow" {
+		t.Errorf("ForCoin(RVN) = %q, %v", a.Name, ok)
+	}
+}
+
+func TestForMiner(t *testing.T) {
+	var names []string
+	for _, a := range ForMiner("tt-miner") {
+		names = append(names, a.Name)
+	}
+	if strings.Join(names, ",") != "etchash,ethash,kawpow" {
+		t.Errorf("unexpected tt-miner algorithms %v", names)
+	}
+	if a, _ := Lookup("rx/0"); a.SupportedBy("tt-miner") || !a.SupportedBy("xmrig") || !a.MinedOn(HardwareCPU) {
+		t.Errorf("unexpected RandomX support %+v", a)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register(Algorithm{Name: "RX/0"}); err == nil {
+		t.Error("expected a duplicate name to be rejected")
+	}
+	if err := Register(Algorithm{Name: "test-bad", Address: &AddressFormat{Pattern: "("}}); err == nil {
+		t.Error("expected an invalid address pattern to be rejected")
+	}
+	if err := Register(Algorithm{Name: "test/sol", Unit: "Sol/s", Address: &AddressFormat{Pattern: `^t1\w+$`}}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	a, ok := Lookup("test/sol")
+	if !ok || !a.Address.Matches("t1abc") || a.Address.Matches("x1abc") {
+		t.Errorf("unexpected registered algorithm %+v", a)
+	}
+	if got := a.FormatHashrate(2500); got != "2.50 kSol/s" {
+		t.Errorf("FormatHashrate = %q", got)
+	}
+}
+
+func TestFormatHashrate(t *testing.T) {
+	tests := map[float64]string{
+		950:     "950 H/s",
+		12345:   "12.35 kH/s",
+		1200000: "1.20 MH/s",
+		3.5e9:   "3.50 GH/s",
+	}
+	for in, want := range tests {
+		if got := FormatHashrate(in); got != want {
+			t.Errorf("FormatHashrate(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
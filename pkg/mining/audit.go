@@ -0,0 +1,204 @@
+package mining
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/adrg/xdg"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// auditMaxSize is the size at which the active audit file is rotated.
+	auditMaxSize = 10 << 20 // 10MB
+	// auditMaxFiles is how many compressed rotated audit files are kept.
+	auditMaxFiles = 20
+)
+
+// Audit results.
+const (
+	AuditResultSuccess = "success"
+	AuditResultFailure = "failure"
+)
+
+// AuditEntry records one administrative action.
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"requestId,omitempty"`
+	Identity  string    `json:"identity"` // Authenticated user, or "anonymous" when auth is disabled
+	ClientIP  string    `json:"clientIp"`
+	Action    string    `json:"action"`           // Method and route, e.g. "POST /miners/:miner_name/install"
+	Target    string    `json:"target,omitempty"` // Route parameters, e.g. "miner_name=xmrig"
+	Status    int       `json:"status"`
+	Result    string    `json:"result"` // AuditResultSuccess or AuditResultFailure
+}
+
+// AuditLog is an append-only JSON-lines record of mutating API requests,
+// kept in rotating files so it survives restarts. Only the route and its path
+// parameters are recorded; request bodies and query strings, which carry
+// passwords, wallets and config, never are.
+type AuditLog struct {
+	mu   sync.Mutex
+	file *RotatingFile
+}
+
+// AuditLogPathFromEnv returns the audit log path, MINING_AUDIT_LOG if set.
+func AuditLogPathFromEnv() string {
+	if path := os.Getenv("MINING_AUDIT_LOG"); path != "" {
+		return path
+	}
+	return filepath.Join(xdg.StateHome, "lethean-desktop", "audit", "audit.log")
+}
+
+// NewAuditLog opens (or appends to) the audit log at path.
+func NewAuditLog(path string) (*AuditLog, error) {
+	file, err := NewRotatingFile(path, auditMaxSize, auditMaxFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &AuditLog{file: file}, nil
+}
+
+// Record appends an entry.
+func (a *AuditLog) Record(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Query returns entries at or after since whose action contains action
+// (case-insensitive; empty matches all), oldest first. At most limit of the
+// most recent matches are returned.
+func (a *AuditLog) Query(since time.Time, action string, limit int) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	action = strings.ToLower(action)
+	entries := make([]AuditEntry, 0)
+	match := func(entry AuditEntry) {
+		if entry.Time.Before(since) || !strings.Contains(strings.ToLower(entry.Action), action) {
+			return
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) > limit {
+			entries = entries[1:]
+		}
+	}
+
+	// Oldest rotated file first, then the active file
+	for i := auditMaxFiles; i >= 1; i-- {
+		if err := readAuditFile(a.file.backupPath(i), true, match); err != nil {
+			return nil, err
+		}
+	}
+	if err := readAuditFile(a.file.Path(), false, match); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// readAuditFile calls fn for each entry in a (possibly gzipped) audit file.
+// Missing files and unparseable lines are skipped.
+func readAuditFile(path string, gzipped bool, fn func(AuditEntry)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read audit file %s: %w", filepath.Base(path), err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			fn(entry)
+		}
+	}
+	return scanner.Err()
+}
+
+// Close closes the audit log.
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// Middleware records every POST, PUT, PATCH and DELETE to a known route once
+// it has been handled. basePath is trimmed from the recorded action. Install
+// it before the auth middleware so rejected attempts are recorded too.
+func (a *AuditLog) Middleware(basePath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			return // Unmatched routes aren't actions
+		}
+
+		identity := "anonymous"
+		if user, ok := c.Get("authUser"); ok {
+			identity = user.(string)
+		}
+		targets := make([]string, 0, len(c.Params))
+		for _, p := range c.Params {
+			targets = append(targets, p.Key+"="+p.Value)
+		}
+		status := c.Writer.Status()
+		result := AuditResultSuccess
+		if status >= http.StatusBadRequest {
+			result = AuditResultFailure
+		}
+
+		entry := AuditEntry{
+			Time:      time.Now().UTC(),
+			RequestID: getRequestID(c),
+			Identity:  identity,
+			ClientIP:  c.ClientIP(),
+			Action:    c.Request.Method + " " + strings.TrimPrefix(route, strings.TrimSuffix(basePath, "/")),
+			Target:    strings.Join(targets, ","),
+			Status:    status,
+			Result:    result,
+		}
+		if err := a.Record(entry); err != nil {
+			logging.Error("failed to record audit entry", logging.Fields{"action": entry.Action, "error": err})
+		}
+	}
+}
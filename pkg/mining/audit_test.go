@@ -0,0 +1,94 @@
+package mining
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditLogMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewAuditLog(path)
+	if err != nil {
+		t.Fatalf("NewAuditLog failed: %v", err)
+	}
+
+	auth := NewDigestAuth(AuthConfig{Enabled: true, Username: "admin", Password: "secret", Realm: "Test", NonceExpiry: time.Minute})
+	defer auth.Stop()
+
+	router := gin.New()
+	router.Use(requestIDMiddleware())
+	api := router.Group("/api/v1/mining")
+	api.Use(audit.Middleware("/api/v1/mining"), auth.Middleware())
+	api.GET("/miners", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/miners/:miner_name/install", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/profiles", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	send := func(method, url, body string, authed bool) {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if authed {
+			req.SetBasicAuth("admin", "secret")
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send(http.MethodGet, "/api/v1/mining/miners", "", true)
+	send(http.MethodPost, "/api/v1/mining/miners/xmrig/install", "", true)
+	send(http.MethodPost, "/api/v1/mining/profiles", `{"config":{"pass":"hunter2"}}`, true)
+	send(http.MethodPost, "/api/v1/mining/miners/xmrig/install", "", false)
+
+	entries, err := audit.Query(time.Time{}, "", 0)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 mutating requests recorded (GET skipped), got %+v", entries)
+	}
+	install := entries[0]
+	if install.Action != "POST /miners/:miner_name/install" || install.Target != "miner_name=xmrig" ||
+		install.Identity != "admin" || install.Result != AuditResultSuccess || install.RequestID == "" {
+		t.Errorf("unexpected install entry %+v", install)
+	}
+	if entries[1].Result != AuditResultFailure || entries[1].Status != http.StatusInternalServerError {
+		t.Errorf("expected failed profile save, got %+v", entries[1])
+	}
+	if rejected := entries[2]; rejected.Identity != "anonymous" || rejected.Status != http.StatusUnauthorized {
+		t.Errorf("expected rejected unauthenticated attempt, got %+v", rejected)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "secret") {
+		t.Error("audit log must not contain request bodies or credentials")
+	}
+
+	if filtered, _ := audit.Query(time.Time{}, "install", 0); len(filtered) != 2 {
+		t.Errorf("expected 2 install entries, got %d", len(filtered))
+	}
+	if latest, _ := audit.Query(time.Time{}, "", 1); len(latest) != 1 || latest[0].Status != http.StatusUnauthorized {
+		t.Errorf("expected only the most recent entry, got %+v", latest)
+	}
+	if future, _ := audit.Query(time.Now().Add(time.Hour), "", 0); len(future) != 0 {
+		t.Errorf("expected no entries after since, got %d", len(future))
+	}
+
+	// Entries survive a restart
+	if err := audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewAuditLog(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if entries, _ := reopened.Query(time.Time{}, "", 0); len(entries) != 3 {
+		t.Errorf("expected 3 entries after reopen, got %d", len(entries))
+	}
+}
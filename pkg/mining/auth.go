@@ -6,6 +6,7 @@ import (
 	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -19,15 +20,21 @@ import (
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
 	// Enabled determines if authentication is required
-	Enabled bool
+	Enabled bool `yaml:"enabled"`
 	// Username for basic/digest auth
-	Username string
+	Username string `yaml:"username"`
 	// Password for basic/digest auth
-	Password string
+	Password string `yaml:"password"`
+	// PasswordFile is a secrets file holding the password, read in place of
+	// Password so it can be rotated and reloaded without editing the config
+	PasswordFile string `yaml:"passwordFile"`
+	// PasswordHash is the digest HA1, MD5(username:realm:password), checked
+	// in place of Password. Setup stores this rather than the password.
+	PasswordHash string `yaml:"-"`
 	// Realm for digest auth
-	Realm string
+	Realm string `yaml:"realm"`
 	// NonceExpiry is how long a nonce is valid
-	NonceExpiry time.Duration
+	NonceExpiry time.Duration `yaml:"nonceExpiry"`
 }
 
 // DefaultAuthConfig returns the default auth configuration.
@@ -45,19 +52,29 @@ func DefaultAuthConfig() AuthConfig {
 // AuthConfigFromEnv creates auth config from environment variables.
 // Set MINING_API_AUTH=true to enable, MINING_API_USER and MINING_API_PASS for credentials.
 func AuthConfigFromEnv() AuthConfig {
-	config := DefaultAuthConfig()
-
-	if os.Getenv("MINING_API_AUTH") == "true" {
-		config.Enabled = true
-		config.Username = os.Getenv("MINING_API_USER")
-		config.Password = os.Getenv("MINING_API_PASS")
+	return authConfigWithEnv(DefaultAuthConfig())
+}
 
-		if config.Username == "" || config.Password == "" {
-			logging.Warn("API auth enabled but credentials not set", logging.Fields{
-				"hint": "Set MINING_API_USER and MINING_API_PASS environment variables",
-			})
-			config.Enabled = false
-		}
+// authConfigWithEnv overrides config with any auth environment variables set.
+func authConfigWithEnv(config AuthConfig) AuthConfig {
+	if v := os.Getenv("MINING_API_AUTH"); v != "" {
+		config.Enabled = v == "true"
+	}
+	if user := os.Getenv("MINING_API_USER"); user != "" {
+		config.Username = user
+	}
+	if pass := os.Getenv("MINING_API_PASS"); pass != "" {
+		config.Password = pass
+		config.PasswordFile = ""
+	}
+	if path := os.Getenv("MINING_API_PASS_FILE"); path != "" {
+		config.PasswordFile = path
+	}
+	if os.Getenv("MINING_API_AUTH") == "true" && (config.Username == "" || (config.Password == "" && config.PasswordFile == "")) {
+		logging.Warn("API auth enabled but credentials not set", logging.Fields{
+			"hint": "Set MINING_API_USER and MINING_API_PASS environment variables",
+		})
+		config.Enabled = false
 	}
 
 	if realm := os.Getenv("MINING_API_REALM"); realm != "" {
@@ -67,6 +84,20 @@ func AuthConfigFromEnv() AuthConfig {
 	return config
 }
 
+// Validate checks an enabled config has credentials to check against.
+func (c AuthConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Username == "" || (c.Password == "" && c.PasswordHash == "") {
+		return fmt.Errorf("auth is enabled but username or password is empty")
+	}
+	if c.NonceExpiry <= 0 {
+		return fmt.Errorf("nonce expiry must be positive, got %s", c.NonceExpiry)
+	}
+	return nil
+}
+
 // DigestAuth implements HTTP Digest Authentication middleware
 type DigestAuth struct {
 	config   AuthConfig
@@ -111,6 +142,7 @@ func (da *DigestAuth) Middleware() gin.HandlerFunc {
 		// Try digest auth first
 		if strings.HasPrefix(authHeader, "Digest ") {
 			if da.validateDigest(c, authHeader) {
+				c.Set("authUser", da.config.Username)
 				c.Next()
 				return
 			}
@@ -121,6 +153,7 @@ func (da *DigestAuth) Middleware() gin.HandlerFunc {
 		// Fall back to basic auth
 		if strings.HasPrefix(authHeader, "Basic ") {
 			if da.validateBasic(c, authHeader) {
+				c.Set("authUser", da.config.Username)
 				c.Next()
 				return
 			}
@@ -130,6 +163,27 @@ func (da *DigestAuth) Middleware() gin.HandlerFunc {
 	}
 }
 
+// requireAdminMiddleware guards security settings. With API auth enabled the
+// caller has already authenticated as the admin; without it only requests
+// from this machine are accepted, so the settings can't be changed by anyone
+// who can reach the port. RemoteAddr is used rather than ClientIP as
+// forwarding headers can be forged.
+func requireAdminMiddleware(authEnabled func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authEnabled() {
+			c.Next()
+			return
+		}
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if ip := net.ParseIP(host); err == nil && ip != nil && ip.IsLoopback() {
+			c.Next()
+			return
+		}
+		respondWithMiningError(c, ErrAdminRequired())
+		c.Abort()
+	}
+}
+
 // sendChallenge sends a 401 response with digest auth challenge
 func (da *DigestAuth) sendChallenge(c *gin.Context) {
 	nonce := da.generateNonce()
@@ -175,7 +229,7 @@ func (da *DigestAuth) validateDigest(c *gin.Context, authHeader string) bool {
 	}
 
 	// Calculate expected response
-	ha1 := md5Hash(fmt.Sprintf("%s:%s:%s", da.config.Username, da.config.Realm, da.config.Password))
+	ha1 := da.ha1()
 	ha2 := md5Hash(fmt.Sprintf("%s:%s", c.Request.Method, params["uri"]))
 
 	var expectedResponse string
@@ -198,13 +252,27 @@ func (da *DigestAuth) validateBasic(c *gin.Context, authHeader string) bool {
 		return false
 	}
 
-	// Constant-time comparison to prevent timing attacks
+	// Constant-time comparison to prevent timing attacks. The password is
+	// checked through its HA1, as that may be all that is stored
 	userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(da.config.Username)) == 1
-	passMatch := subtle.ConstantTimeCompare([]byte(pass), []byte(da.config.Password)) == 1
+	passMatch := subtle.ConstantTimeCompare([]byte(digestHA1(user, da.config.Realm, pass)), []byte(da.ha1())) == 1
 
 	return userMatch && passMatch
 }
 
+// ha1 returns the digest HA1 of the configured credential.
+func (da *DigestAuth) ha1() string {
+	if da.config.PasswordHash != "" {
+		return da.config.PasswordHash
+	}
+	return digestHA1(da.config.Username, da.config.Realm, da.config.Password)
+}
+
+// digestHA1 returns MD5(username:realm:password), the digest auth secret.
+func digestHA1(username, realm, password string) string {
+	return md5Hash(fmt.Sprintf("%s:%s:%s", username, realm, password))
+}
+
 // generateNonce creates a cryptographically random nonce
 func (da *DigestAuth) generateNonce() string {
 	b := make([]byte, 16)
@@ -0,0 +1,106 @@
+package mining
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// readPasswordFile replaces the password with the contents of PasswordFile,
+// if set, so the password can live in a secrets file that's rotated on its own.
+func (c *AuthConfig) readPasswordFile() error {
+	if c.PasswordFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.PasswordFile)
+	if err != nil {
+		return fmt.Errorf("failed to read password file: %w", err)
+	}
+	c.Password = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
+
+// resolveAuthConfig falls back to the credential stored by first-run setup
+// when the config file and environment don't enable auth.
+func resolveAuthConfig(config AuthConfig, credentialsPath string) (AuthConfig, error) {
+	if config.Enabled {
+		return config, nil
+	}
+	creds, err := loadAdminCredentials(credentialsPath)
+	if err != nil {
+		return config, err
+	}
+	if creds != nil {
+		config.Enabled = true
+		config.Username = creds.Username
+		config.Realm = creds.Realm // The stored HA1 only matches its own realm
+		config.Password = ""
+		config.PasswordHash = creds.HA1
+	}
+	return config, nil
+}
+
+// ReloadAuth re-reads the API credentials from the environment, the config
+// file at ConfigPath (and its password file) and the credential stored by
+// setup, and swaps them in. Requests already authenticated complete;
+// outstanding digest nonces are dropped, so digest clients are challenged
+// again. A reload that would disable auth is refused, as that is more likely
+// a broken secrets file than intended; restart to disable auth.
+func (s *Service) ReloadAuth() error {
+	// Serialized with setup, which also swaps the auth
+	s.setupMu.Lock()
+	defer s.setupMu.Unlock()
+
+	config, err := LoadServiceConfig(s.ConfigPath)
+	if err != nil {
+		return err
+	}
+	authConfig, err := resolveAuthConfig(config.Auth, s.credentialsPath)
+	if err != nil {
+		return err
+	}
+	if !authConfig.Enabled {
+		if s.currentAuth() != nil {
+			return fmt.Errorf("reloaded configuration has no API credentials; keeping the current ones")
+		}
+		return nil
+	}
+
+	s.setAuth(NewDigestAuth(authConfig))
+	s.authConfig = authConfig
+	logging.Info("API credentials reloaded", logging.Fields{"user": authConfig.Username, "realm": authConfig.Realm})
+	return nil
+}
+
+// AuthReloadResponse is the response from reloading the API credentials
+type AuthReloadResponse struct {
+	Status   string `json:"status"`
+	Username string `json:"username,omitempty"` // Empty if auth is still disabled
+}
+
+// handleReloadAuth godoc
+// @Summary Reload API credentials
+// @Description Re-reads the API credentials from the environment, config file, password file and setup credential without a restart. Digest clients must re-authenticate. The current credentials are kept if the new ones are invalid or missing.
+// @Tags system
+// @Produce json
+// @Success 200 {object} AuthReloadResponse
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Failure 500 {object} APIError "Invalid configuration"
+// @Router /auth/reload [post]
+func (s *Service) handleReloadAuth(c *gin.Context) {
+	if err := s.ReloadAuth(); err != nil {
+		respondWithMiningError(c, ErrConfigInvalid(err))
+		return
+	}
+	resp := AuthReloadResponse{Status: "reloaded"}
+	if s.currentAuth() != nil {
+		s.setupMu.Lock()
+		resp.Username = s.authConfig.Username
+		s.setupMu.Unlock()
+	}
+	c.JSON(http.StatusOK, resp)
+}
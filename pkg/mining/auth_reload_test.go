@@ -0,0 +1,149 @@
+package mining
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupReloadService returns a service whose credentials come from a config
+// file using a password file, and a function to rotate the password.
+func setupReloadService(t *testing.T) (*Service, func(password string)) {
+	t.Helper()
+	for _, env := range []string{"MINING_API_AUTH", "MINING_API_USER", "MINING_API_PASS", "MINING_API_PASS_FILE", "MINING_CONFIG_FILE"} {
+		t.Setenv(env, "")
+	}
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "api-password")
+	rotate := func(password string) {
+		if err := os.WriteFile(passwordFile, []byte(password+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rotate("first-pass")
+	configPath := filepath.Join(dir, "config.yaml")
+	config := "auth:\n  enabled: true\n  username: admin\n  passwordFile: " + passwordFile + "\n"
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &Service{
+		Manager:         &MockManager{ListMinersFunc: func() []Miner { return []Miner{} }},
+		Router:          gin.New(),
+		APIBasePath:     "/api",
+		SwaggerUIPath:   "/api/swagger",
+		ConfigPath:      configPath,
+		credentialsPath: filepath.Join(dir, credentialsFileName),
+	}
+	service.SetupRoutes()
+	if err := service.ReloadAuth(); err != nil {
+		t.Fatalf("initial ReloadAuth failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if auth := service.currentAuth(); auth != nil {
+			auth.Stop()
+		}
+	})
+	return service, rotate
+}
+
+func requestAs(service *Service, method, path, password string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if password != "" {
+		req.SetBasicAuth("admin", password)
+	}
+	w := httptest.NewRecorder()
+	service.Router.ServeHTTP(w, req)
+	return w
+}
+
+func TestReloadAuth(t *testing.T) {
+	service, rotate := setupReloadService(t)
+
+	if w := requestAs(service, "GET", "/api/miners", "first-pass"); w.Code != http.StatusOK {
+		t.Fatalf("expected the password file's password to be accepted, got %d", w.Code)
+	}
+	// Collect a digest nonce issued before rotation
+	w := requestAs(service, "GET", "/api/miners", "")
+	nonce := parseDigestParams(strings.TrimPrefix(w.Header().Get("WWW-Authenticate"), "Digest "))["nonce"]
+	if nonce == "" {
+		t.Fatal("expected a digest challenge")
+	}
+
+	rotate("second-pass")
+	if err := service.ReloadAuth(); err != nil {
+		t.Fatalf("ReloadAuth failed: %v", err)
+	}
+	if w := requestAs(service, "GET", "/api/miners", "first-pass"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the old password to be rejected, got %d", w.Code)
+	}
+	if w := requestAs(service, "GET", "/api/miners", "second-pass"); w.Code != http.StatusOK {
+		t.Errorf("expected the new password to be accepted, got %d", w.Code)
+	}
+	if _, ok := service.currentAuth().nonces.Load(nonce); ok {
+		t.Error("expected nonces issued before rotation to be invalidated")
+	}
+
+	// A broken or empty config keeps the current credentials
+	if err := os.WriteFile(service.ConfigPath, []byte("auth:\n  enabled: false\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReloadAuth(); err == nil {
+		t.Error("expected a reload that disables auth to be refused")
+	}
+	if err := os.WriteFile(service.ConfigPath, []byte("auth: ["), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReloadAuth(); err == nil {
+		t.Error("expected an invalid config to be refused")
+	}
+	if w := requestAs(service, "GET", "/api/miners", "second-pass"); w.Code != http.StatusOK {
+		t.Errorf("expected the current password to still work, got %d", w.Code)
+	}
+}
+
+func TestHandleReloadAuth(t *testing.T) {
+	service, rotate := setupReloadService(t)
+
+	if w := requestAs(service, "POST", "/api/auth/reload", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected reload to require auth, got %d", w.Code)
+	}
+	rotate("second-pass")
+	w := requestAs(service, "POST", "/api/auth/reload", "first-pass")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"username":"admin"`) {
+		t.Fatalf("expected reload to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := requestAs(service, "GET", "/api/miners", "second-pass"); w.Code != http.StatusOK {
+		t.Errorf("expected the new password after reload, got %d", w.Code)
+	}
+}
+
+func TestReloadAuthConcurrent(t *testing.T) {
+	service, _ := setupReloadService(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if w := requestAs(service, "GET", "/api/miners", "first-pass"); w.Code != http.StatusOK {
+					t.Errorf("expected requests to keep authenticating during reloads, got %d", w.Code)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := service.ReloadAuth(); err != nil {
+			t.Fatalf("ReloadAuth failed: %v", err)
+		}
+	}
+	wg.Wait()
+}
@@ -602,3 +602,38 @@ func BenchmarkBasicAuthValidation(b *testing.B) {
 		router.ServeHTTP(w, req)
 	}
 }
+
+func TestRequireAdminMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		authEnabled bool
+		remoteAddr  string
+		want        int
+	}{
+		{"auth enabled", true, "192.0.2.1:1234", http.StatusOK},
+		{"local IPv4", false, "127.0.0.1:1234", http.StatusOK},
+		{"local IPv6", false, "[::1]:1234", http.StatusOK},
+		{"remote", false, "192.0.2.1:1234", http.StatusForbidden},
+		{"unparsable", false, "localhost", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/test", requireAdminMiddleware(func() bool { return tt.authEnabled }), func(c *gin.Context) {
+				c.String(http.StatusOK, "success")
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			// Forwarding headers must not make a remote caller look local
+			req.Header.Set("X-Forwarded-For", "127.0.0.1")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,654 @@
+package mining
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Snider/Mining/pkg/node"
+	"github.com/adrg/xdg"
+)
+
+// backupFormatVersion is bumped when the archive layout changes incompatibly.
+const backupFormatVersion = 1
+
+// maxBackupSize bounds the uncompressed size of a backup accepted for restore.
+const maxBackupSize = 16 << 20
+
+const (
+	backupManifestEntry = "manifest.json"
+	backupSecretsEntry  = "secrets.json"
+)
+
+// ErrInvalidBackup wraps restore errors caused by the archive itself.
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// BackupManifest describes the contents of a backup archive.
+type BackupManifest struct {
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Components []string  `json:"components"`
+	Secrets    bool      `json:"secrets"` // Node identity, key and unmasked miner configs, encrypted with the backup passphrase
+}
+
+// BackupOptions controls what CreateBackup includes.
+type BackupOptions struct {
+	IncludeSecrets bool
+	Passphrase     string // Required with IncludeSecrets
+}
+
+// RestoreOptions controls how RestoreBackup applies an archive.
+type RestoreOptions struct {
+	DryRun     bool
+	Passphrase string // Required if the backup contains secrets
+}
+
+// RestoreChange reports what restoring one component does.
+type RestoreChange struct {
+	Component string `json:"component"`
+	Action    string `json:"action"` // "create", "replace" or "unchanged"
+}
+
+// RestoreReport summarizes a restore or, with DryRun, what it would change.
+type RestoreReport struct {
+	DryRun          bool            `json:"dryRun"`
+	Manifest        BackupManifest  `json:"manifest"`
+	Changes         []RestoreChange `json:"changes"`
+	RestartRequired bool            `json:"restartRequired"` // Peers, node identity and miner config are only read at startup
+}
+
+// backupSecrets is the plaintext of the encrypted secrets entry.
+type backupSecrets struct {
+	NodeConfig []byte            `json:"nodeConfig,omitempty"`
+	PrivateKey []byte            `json:"privateKey,omitempty"`
+	Configs    map[string][]byte `json:"configs,omitempty"` // Unmasked components, by name
+}
+
+// backupPaths locates the files a backup reads and a restore writes.
+type backupPaths struct {
+	Settings   string
+	Miners     string
+	Profiles   string
+	Templates  string
+	Peers      string
+	NodeConfig string
+	NodeKey    string
+}
+
+// defaultBackupPaths returns the locations the app's managers use.
+func defaultBackupPaths() (backupPaths, error) {
+	var paths backupPaths
+	var err error
+	if paths.Settings, err = xdg.ConfigFile(filepath.Join("lethean-desktop", settingsFileName)); err != nil {
+		return paths, err
+	}
+	if paths.Miners, err = getMinersConfigPath(); err != nil {
+		return paths, err
+	}
+	if paths.Profiles, err = xdg.ConfigFile(filepath.Join("lethean-desktop", profileConfigFileName)); err != nil {
+		return paths, err
+	}
+	if paths.Templates, err = xdg.ConfigFile(filepath.Join("lethean-desktop", templateConfigFileName)); err != nil {
+		return paths, err
+	}
+	if paths.Peers, err = xdg.ConfigFile("lethean-desktop/peers.json"); err != nil {
+		return paths, err
+	}
+	if paths.NodeConfig, err = xdg.ConfigFile("lethean-desktop/node.json"); err != nil {
+		return paths, err
+	}
+	if paths.NodeKey, err = xdg.DataFile("lethean-desktop/node/private.key"); err != nil {
+		return paths, err
+	}
+	return paths, nil
+}
+
+// backupComponent is one plain JSON file carried in a backup.
+type backupComponent struct {
+	name     string
+	path     string
+	validate func(data []byte) error
+	mask     func(data []byte) ([]byte, error)          // Replaces secrets; returns data unchanged if there are none
+	unmask   func(data, current []byte) ([]byte, error) // Puts the current file's secrets back over masked ones
+	restart  bool                                       // Restoring it only takes effect after a restart
+}
+
+func (p backupPaths) components() []backupComponent {
+	return []backupComponent{
+		{name: "settings", path: p.Settings, validate: validateSettingsBackup},
+		{name: "miners", path: p.Miners, validate: validateMinersBackup, mask: maskMinersBackup, unmask: unmaskMinersBackup, restart: true},
+		{name: "profiles", path: p.Profiles, validate: validateProfilesBackup, mask: maskProfilesBackup, unmask: unmaskProfilesBackup},
+		{name: "templates", path: p.Templates, validate: validateTemplatesBackup},
+		{name: "peers", path: p.Peers, validate: validatePeersBackup, restart: true},
+	}
+}
+
+// CreateBackup writes a tar.gz of the app's settings, miner config, profiles
+// and peers to w. Components that don't exist yet are left out. Pool passwords
+// and access tokens in the miner config and profiles are masked; they and the
+// node identity are only included on request, encrypted with the passphrase.
+func CreateBackup(w io.Writer, opts BackupOptions) error {
+	paths, err := defaultBackupPaths()
+	if err != nil {
+		return fmt.Errorf("could not resolve config paths: %w", err)
+	}
+	return createBackup(w, paths, opts)
+}
+
+func createBackup(w io.Writer, paths backupPaths, opts BackupOptions) error {
+	if opts.IncludeSecrets && opts.Passphrase == "" {
+		return fmt.Errorf("a passphrase is required to include secrets")
+	}
+
+	manifest := BackupManifest{Version: backupFormatVersion, CreatedAt: time.Now().UTC()}
+	entries := make(map[string][]byte)
+	unmasked := make(map[string][]byte)
+	for _, component := range paths.components() {
+		data, err := readBackupFile(component.path, component.name == "miners")
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", component.name, err)
+		}
+		if component.mask != nil {
+			masked, err := component.mask(data)
+			if err != nil {
+				return fmt.Errorf("failed to mask %s: %w", component.name, err)
+			}
+			if !bytes.Equal(masked, data) {
+				unmasked[component.name] = data
+				data = masked
+			}
+		}
+		entries[component.name+".json"] = data
+		manifest.Components = append(manifest.Components, component.name)
+	}
+
+	if opts.IncludeSecrets {
+		secrets, err := packBackupSecrets(paths, unmasked, opts.Passphrase)
+		if err != nil {
+			return err
+		}
+		if secrets != nil {
+			entries[backupSecretsEntry] = secrets
+			manifest.Secrets = true
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	names := append([]string{backupManifestEntry}, entryNames(manifest)...)
+	entries[backupManifestEntry] = manifestData
+	for _, name := range names {
+		data := entries[name]
+		header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// entryNames lists the archive entries a manifest declares, in a stable order.
+func entryNames(manifest BackupManifest) []string {
+	names := make([]string, 0, len(manifest.Components)+1)
+	for _, component := range manifest.Components {
+		names = append(names, component+".json")
+	}
+	if manifest.Secrets {
+		names = append(names, backupSecretsEntry)
+	}
+	return names
+}
+
+// packBackupSecrets encrypts the node identity, private key and unmasked
+// components. Returns nil if there is nothing to protect.
+func packBackupSecrets(paths backupPaths, unmasked map[string][]byte, passphrase string) ([]byte, error) {
+	secrets := backupSecrets{Configs: unmasked}
+	nodeConfig, err := os.ReadFile(paths.NodeConfig)
+	switch {
+	case err == nil:
+		secrets.NodeConfig = nodeConfig
+		if secrets.PrivateKey, err = os.ReadFile(paths.NodeKey); err != nil {
+			return nil, fmt.Errorf("failed to read node private key: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read node identity: %w", err)
+	}
+	if secrets.NodeConfig == nil && len(secrets.Configs) == 0 {
+		return nil, nil
+	}
+
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := node.CreateProfileBundle(plain, "secrets", passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(bundle)
+}
+
+// unpackBackupSecrets decrypts and validates the secrets entry.
+func unpackBackupSecrets(data []byte, passphrase string) (*backupSecrets, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("backup contains secrets; a passphrase is required to restore it")
+	}
+	var bundle node.Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	if json.Valid(bundle.Data) {
+		return nil, fmt.Errorf("secrets: not encrypted")
+	}
+	plain, err := node.ExtractProfileBundle(&bundle, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+
+	var secrets backupSecrets
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	if secrets.NodeConfig == nil {
+		return &secrets, nil
+	}
+	var identity node.NodeIdentity
+	if err := json.Unmarshal(secrets.NodeConfig, &identity); err != nil {
+		return nil, fmt.Errorf("secrets: invalid node identity: %w", err)
+	}
+	if identity.ID == "" || identity.PublicKey == "" {
+		return nil, fmt.Errorf("secrets: node identity has no ID or public key")
+	}
+	if len(secrets.PrivateKey) == 0 {
+		return nil, fmt.Errorf("secrets: private key is empty")
+	}
+	return &secrets, nil
+}
+
+// RestoreBackup validates every component of a backup read from r and, unless
+// opts.DryRun is set, writes the ones that differ from the current files.
+// Nothing is written if any component is invalid. Masked secrets in a backup
+// made without them keep the current values instead of replacing them.
+func RestoreBackup(r io.Reader, opts RestoreOptions) (*RestoreReport, error) {
+	paths, err := defaultBackupPaths()
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve config paths: %w", err)
+	}
+	return restoreBackup(r, paths, opts)
+}
+
+func restoreBackup(r io.Reader, paths backupPaths, opts RestoreOptions) (*RestoreReport, error) {
+	entries, err := readBackupArchive(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(entries[backupManifestEntry], &manifest); err != nil {
+		return nil, fmt.Errorf("%w: manifest: %v", ErrInvalidBackup, err)
+	}
+	if manifest.Version < 1 || manifest.Version > backupFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported backup version %d", ErrInvalidBackup, manifest.Version)
+	}
+	for _, name := range entryNames(manifest) {
+		if _, ok := entries[name]; !ok {
+			return nil, fmt.Errorf("%w: %s listed in manifest but missing", ErrInvalidBackup, name)
+		}
+	}
+
+	// Validate everything before writing anything
+	type pendingWrite struct {
+		path   string
+		data   []byte
+		miners bool
+	}
+	var writes []pendingWrite
+	report := &RestoreReport{DryRun: opts.DryRun, Manifest: manifest, Changes: []RestoreChange{}}
+	plan := func(component, path string, data []byte, miners, restart bool) {
+		change := RestoreChange{Component: component, Action: "create"}
+		if current, err := os.ReadFile(path); err == nil {
+			change.Action = "replace"
+			if bytes.Equal(current, data) {
+				change.Action = "unchanged"
+			}
+		}
+		report.Changes = append(report.Changes, change)
+		if change.Action != "unchanged" {
+			writes = append(writes, pendingWrite{path: path, data: data, miners: miners})
+			report.RestartRequired = report.RestartRequired || restart
+		}
+	}
+
+	// Secrets hold the unmasked form of components, so decrypt them first
+	secrets := &backupSecrets{}
+	if manifest.Secrets {
+		if secrets, err = unpackBackupSecrets(entries[backupSecretsEntry], opts.Passphrase); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+		}
+	}
+
+	for _, component := range paths.components() {
+		data, ok := entries[component.name+".json"]
+		if !ok || !slices.Contains(manifest.Components, component.name) {
+			continue
+		}
+		if raw, ok := secrets.Configs[component.name]; ok {
+			data = raw
+		} else if component.unmask != nil {
+			// Without the secrets, keep the current ones rather than writing the mask over them
+			current, err := readBackupFile(component.path, component.name == "miners")
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read %s: %w", component.path, err)
+			}
+			if data, err = component.unmask(data, current); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBackup, component.name, err)
+			}
+		}
+		if err := component.validate(data); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBackup, component.name, err)
+		}
+		plan(component.name, component.path, data, component.name == "miners", component.restart)
+	}
+
+	if secrets.NodeConfig != nil {
+		plan("identity", paths.NodeConfig, secrets.NodeConfig, false, true)
+		plan("privateKey", paths.NodeKey, secrets.PrivateKey, false, true)
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	for _, w := range writes {
+		if err := writeBackupFile(w.path, w.data, w.miners); err != nil {
+			return report, fmt.Errorf("failed to write %s: %w", w.path, err)
+		}
+	}
+	return report, nil
+}
+
+// readBackupArchive reads the known entries of a tar.gz backup into memory.
+func readBackupArchive(r io.Reader) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(io.LimitReader(r, maxBackupSize))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	entries := make(map[string][]byte)
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || strings.ContainsAny(header.Name, `/\`) {
+			return nil, fmt.Errorf("unexpected entry %q", header.Name)
+		}
+		total += header.Size
+		if total > maxBackupSize {
+			return nil, fmt.Errorf("backup exceeds %d bytes", maxBackupSize)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		entries[header.Name] = data
+	}
+	if _, ok := entries[backupManifestEntry]; !ok {
+		return nil, fmt.Errorf("missing %s", backupManifestEntry)
+	}
+	return entries, nil
+}
+
+// readBackupFile reads a component, holding the miners config lock for that file.
+func readBackupFile(path string, miners bool) ([]byte, error) {
+	if miners {
+		configMu.RLock()
+		defer configMu.RUnlock()
+	}
+	return os.ReadFile(path)
+}
+
+// writeBackupFile restores a component, holding the miners config lock for that file.
+func writeBackupFile(path string, data []byte, miners bool) error {
+	if miners {
+		configMu.Lock()
+		defer configMu.Unlock()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return AtomicWriteFile(path, data, 0600)
+}
+
+func validateSettingsBackup(data []byte) error {
+	var settings AppSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+	return settings.Validate()
+}
+
+func validateMinersBackup(data []byte) error {
+	var cfg MinersConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	for _, miner := range cfg.Miners {
+		if miner.MinerType == "" {
+			return fmt.Errorf("miner entry without a minerType")
+		}
+		if miner.Config != nil {
+			if err := miner.Config.Validate(); err != nil {
+				return fmt.Errorf("%s: %w", miner.MinerType, err)
+			}
+		}
+	}
+	return nil
+}
+
+// maskMinersBackup masks the secrets in each miner's last used config.
+func maskMinersBackup(data []byte) ([]byte, error) {
+	var cfg MinersConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	changed := false
+	for i, miner := range cfg.Miners {
+		if miner.Config != nil && *miner.Config != miner.Config.Masked() {
+			masked := miner.Config.Masked()
+			cfg.Miners[i].Config = &masked
+			changed = true
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.MarshalIndent(cfg, "", "  ")
+}
+
+// maskProfilesBackup masks the secrets in each profile's config.
+func maskProfilesBackup(data []byte) ([]byte, error) {
+	var profiles []*MiningProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+	changed := false
+	for i, profile := range profiles {
+		if profile == nil {
+			continue
+		}
+		// Masked re-encodes the config, so check for secrets before replacing it
+		var fields map[string]interface{}
+		if json.Unmarshal(profile.Config, &fields) == nil && !slices.ContainsFunc(secretConfigKeys, func(key string) bool {
+			value, _ := fields[key].(string)
+			return value != ""
+		}) {
+			continue
+		}
+		profiles[i] = profile.Masked()
+		changed = true
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.MarshalIndent(profiles, "", "  ")
+}
+
+// unmaskSecret replaces a masked value with the current one, or clears it
+// when there is no current value, so the mask is never saved as a secret.
+func unmaskSecret(value *string, current string) bool {
+	if *value != maskedSecret {
+		return false
+	}
+	*value = current
+	return true
+}
+
+// unmaskMinersBackup puts the secrets from the current miners config back
+// into masked miner configs, matching miners by type.
+func unmaskMinersBackup(data, current []byte) ([]byte, error) {
+	var cfg, currentCfg MinersConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	json.Unmarshal(current, &currentCfg) // A missing or unreadable current file has no secrets to keep
+	kept := make(map[string]*Config)
+	for _, miner := range currentCfg.Miners {
+		if miner.Config != nil {
+			kept[miner.MinerType] = miner.Config
+		}
+	}
+
+	changed := false
+	for _, miner := range cfg.Miners {
+		if miner.Config == nil {
+			continue
+		}
+		currentConfig := kept[miner.MinerType]
+		for i, field := range miner.Config.secretFields() {
+			value := ""
+			if currentConfig != nil {
+				value = *currentConfig.secretFields()[i]
+			}
+			changed = unmaskSecret(field, value) || changed
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.MarshalIndent(cfg, "", "  ")
+}
+
+// unmaskProfilesBackup puts the secrets from the current profiles back into
+// masked profile configs, matching profiles by ID.
+func unmaskProfilesBackup(data, current []byte) ([]byte, error) {
+	var profiles, currentProfiles []*MiningProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+	json.Unmarshal(current, &currentProfiles) // A missing or unreadable current file has no secrets to keep
+	kept := make(map[string]map[string]interface{})
+	for _, profile := range currentProfiles {
+		var fields map[string]interface{}
+		if profile != nil && json.Unmarshal(profile.Config, &fields) == nil {
+			kept[profile.ID] = fields
+		}
+	}
+
+	changed := false
+	for _, profile := range profiles {
+		var fields map[string]interface{}
+		if profile == nil || json.Unmarshal(profile.Config, &fields) != nil {
+			continue
+		}
+		profileChanged := false
+		for _, key := range secretConfigKeys {
+			value, _ := fields[key].(string)
+			currentValue, _ := kept[profile.ID][key].(string)
+			if unmaskSecret(&value, currentValue) {
+				fields[key] = value
+				profileChanged = true
+			}
+		}
+		if !profileChanged {
+			continue
+		}
+		config, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		profile.Config = config
+		changed = true
+	}
+	if !changed {
+		return data, nil
+	}
+	return json.MarshalIndent(profiles, "", "  ")
+}
+
+func validateProfilesBackup(data []byte) error {
+	var profiles []*MiningProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if profile == nil || profile.ID == "" || profile.MinerType == "" {
+			return fmt.Errorf("profile without an id or minerType")
+		}
+	}
+	return nil
+}
+
+func validateTemplatesBackup(data []byte) error {
+	var templates []*MiningTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return err
+	}
+	for _, template := range templates {
+		if template == nil || template.ID == "" || template.Name == "" {
+			return fmt.Errorf("template without an id or name")
+		}
+	}
+	return nil
+}
+
+func validatePeersBackup(data []byte) error {
+	var peers []*node.Peer
+	if err := json.Unmarshal(data, &peers); err != nil {
+		return err
+	}
+	for _, peer := range peers {
+		if peer == nil || peer.ID == "" {
+			return fmt.Errorf("peer without an id")
+		}
+	}
+	return nil
+}
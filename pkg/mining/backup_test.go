@@ -0,0 +1,206 @@
+package mining
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testBackupPaths(t *testing.T) backupPaths {
+	dir := t.TempDir()
+	return backupPaths{
+		Settings:   filepath.Join(dir, "settings.json"),
+		Miners:     filepath.Join(dir, "miners", "config.json"),
+		Profiles:   filepath.Join(dir, "mining_profiles.json"),
+		Templates:  filepath.Join(dir, "mining_templates.json"),
+		Peers:      filepath.Join(dir, "peers.json"),
+		NodeConfig: filepath.Join(dir, "node.json"),
+		NodeKey:    filepath.Join(dir, "node", "private.key"),
+	}
+}
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	src := testBackupPaths(t)
+	writeTestFile(t, src.Settings, `{"theme": "dark"}`)
+	writeTestFile(t, src.Profiles, `[{"id": "p1", "name": "main", "minerType": "xmrig", "config": {}}]`)
+	writeTestFile(t, src.Peers, `[{"id": "peer1", "name": "rig"}]`)
+
+	var archive bytes.Buffer
+	if err := createBackup(&archive, src, BackupOptions{}); err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+
+	dst := testBackupPaths(t)
+	writeTestFile(t, dst.Settings, `{"theme": "light"}`)
+
+	report, err := restoreBackup(bytes.NewReader(archive.Bytes()), dst, RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	want := map[string]string{"settings": "replace", "profiles": "create", "peers": "create"}
+	if len(report.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), report.Changes)
+	}
+	for _, change := range report.Changes {
+		if want[change.Component] != change.Action {
+			t.Errorf("%s: expected %s, got %s", change.Component, want[change.Component], change.Action)
+		}
+	}
+	if _, err := os.Stat(dst.Profiles); !os.IsNotExist(err) {
+		t.Error("dry run should not write files")
+	}
+
+	if _, err := restoreBackup(bytes.NewReader(archive.Bytes()), dst, RestoreOptions{}); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	for _, path := range []string{dst.Settings, dst.Profiles, dst.Peers} {
+		got, _ := os.ReadFile(path)
+		orig, _ := os.ReadFile(filepath.Join(filepath.Dir(src.Settings), filepath.Base(path)))
+		if !bytes.Equal(got, orig) {
+			t.Errorf("%s: restored %q, want %q", filepath.Base(path), got, orig)
+		}
+	}
+	if _, err := os.Stat(dst.Miners); !os.IsNotExist(err) {
+		t.Error("components absent from the backup should not be written")
+	}
+}
+
+func TestRestoreRejectsInvalidComponent(t *testing.T) {
+	src := testBackupPaths(t)
+	writeTestFile(t, src.Settings, `{"theme": "dark"}`)
+	writeTestFile(t, src.Profiles, `[{"name": "no id"}]`)
+
+	var archive bytes.Buffer
+	if err := createBackup(&archive, src, BackupOptions{}); err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+
+	dst := testBackupPaths(t)
+	if _, err := restoreBackup(&archive, dst, RestoreOptions{}); !errors.Is(err, ErrInvalidBackup) {
+		t.Fatalf("expected ErrInvalidBackup, got %v", err)
+	}
+	if _, err := os.Stat(dst.Settings); !os.IsNotExist(err) {
+		t.Error("nothing should be written when a component is invalid")
+	}
+}
+
+func TestBackupSecretsEncrypted(t *testing.T) {
+	src := testBackupPaths(t)
+	writeTestFile(t, src.NodeConfig, `{"id": "abc", "name": "node", "publicKey": "cHVi"}`)
+	writeTestFile(t, src.NodeKey, "super-secret-key")
+
+	if err := createBackup(&bytes.Buffer{}, src, BackupOptions{IncludeSecrets: true}); err == nil {
+		t.Error("expected an error including secrets without a passphrase")
+	}
+
+	var archive bytes.Buffer
+	if err := createBackup(&archive, src, BackupOptions{IncludeSecrets: true, Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+	if bytes.Contains(archive.Bytes(), []byte("super-secret-key")) {
+		t.Fatal("private key stored in plaintext")
+	}
+
+	dst := testBackupPaths(t)
+	for _, passphrase := range []string{"", "wrong"} {
+		if _, err := restoreBackup(bytes.NewReader(archive.Bytes()), dst, RestoreOptions{Passphrase: passphrase}); !errors.Is(err, ErrInvalidBackup) {
+			t.Errorf("passphrase %q: expected ErrInvalidBackup, got %v", passphrase, err)
+		}
+	}
+
+	report, err := restoreBackup(bytes.NewReader(archive.Bytes()), dst, RestoreOptions{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if !report.RestartRequired {
+		t.Error("restoring the node identity should require a restart")
+	}
+	if key, _ := os.ReadFile(dst.NodeKey); string(key) != "super-secret-key" {
+		t.Errorf("private key not restored, got %q", key)
+	}
+}
+
+func TestBackupMasksConfigSecrets(t *testing.T) {
+	src := testBackupPaths(t)
+	writeTestFile(t, src.Miners, `{"miners": [{"minerType": "xmrig", "config": {"pool": "stratum+tcp://pool:3333", "password": "pool-pass"}}]}`)
+	writeTestFile(t, src.Profiles, `[{"id": "p1", "name": "main", "minerType": "xmrig", "config": {"httpAccessToken": "api-token"}}]`)
+
+	var masked bytes.Buffer
+	if err := createBackup(&masked, src, BackupOptions{}); err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+	entries, err := readBackupArchive(bytes.NewReader(masked.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"miners.json", "profiles.json"} {
+		if bytes.Contains(entries[name], []byte("pool-pass")) || bytes.Contains(entries[name], []byte("api-token")) {
+			t.Errorf("%s: secrets stored in plaintext: %s", name, entries[name])
+		}
+		if !bytes.Contains(entries[name], []byte(maskedSecret)) {
+			t.Errorf("%s: expected masked secrets, got %s", name, entries[name])
+		}
+	}
+
+	var archive bytes.Buffer
+	if err := createBackup(&archive, src, BackupOptions{IncludeSecrets: true, Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+	if bytes.Contains(archive.Bytes(), []byte("pool-pass")) {
+		t.Fatal("pool password stored in plaintext")
+	}
+
+	dst := testBackupPaths(t)
+	if _, err := restoreBackup(bytes.NewReader(archive.Bytes()), dst, RestoreOptions{Passphrase: "correct horse"}); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	for _, path := range []string{dst.Miners, dst.Profiles} {
+		got, _ := os.ReadFile(path)
+		if !bytes.Contains(got, []byte("pool-pass")) && !bytes.Contains(got, []byte("api-token")) {
+			t.Errorf("%s: secrets not restored, got %s", filepath.Base(path), got)
+		}
+	}
+}
+
+func TestRestoreMaskedBackupKeepsCurrentSecrets(t *testing.T) {
+	src := testBackupPaths(t)
+	writeTestFile(t, src.Miners, `{"miners": [{"minerType": "xmrig", "config": {"pool": "stratum+tcp://pool:3333", "password": "pool-pass"}}]}`)
+	writeTestFile(t, src.Profiles, `[{"id": "p1", "name": "main", "minerType": "xmrig", "config": {"httpAccessToken": "api-token"}}, {"id": "p2", "name": "new", "minerType": "xmrig", "config": {"password": "other-pass"}}]`)
+
+	var archive bytes.Buffer
+	if err := createBackup(&archive, src, BackupOptions{}); err != nil {
+		t.Fatalf("createBackup failed: %v", err)
+	}
+
+	// Restore over the same files, minus the second profile
+	dst := src
+	writeTestFile(t, dst.Profiles, `[{"id": "p1", "name": "old", "minerType": "xmrig", "config": {"httpAccessToken": "api-token"}}]`)
+	if _, err := restoreBackup(bytes.NewReader(archive.Bytes()), dst, RestoreOptions{}); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	miners, _ := os.ReadFile(dst.Miners)
+	if !bytes.Contains(miners, []byte("pool-pass")) || bytes.Contains(miners, []byte(maskedSecret)) {
+		t.Errorf("expected the current pool password to be kept, got %s", miners)
+	}
+	profiles, _ := os.ReadFile(dst.Profiles)
+	if !bytes.Contains(profiles, []byte("api-token")) || !bytes.Contains(profiles, []byte(`"name": "main"`)) {
+		t.Errorf("expected the profile restored with its current token, got %s", profiles)
+	}
+	// A profile with no current secret to keep has it cleared, not set to the mask
+	if bytes.Contains(profiles, []byte(maskedSecret)) {
+		t.Errorf("expected no masked secrets written, got %s", profiles)
+	}
+}
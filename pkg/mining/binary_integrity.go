@@ -0,0 +1,130 @@
+package mining
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// BinaryVerificationMode controls what happens when a miner binary no longer
+// matches the hash recorded at install time.
+type BinaryVerificationMode string
+
+const (
+	// BinaryVerificationWarn logs the mismatch and lets the miner start (default)
+	BinaryVerificationWarn BinaryVerificationMode = "warn"
+	// BinaryVerificationBlock refuses to start a miner whose binary has changed
+	BinaryVerificationBlock BinaryVerificationMode = "block"
+)
+
+// Binary integrity states reported by CheckBinaryIntegrity and /doctor.
+const (
+	BinaryIntegrityVerified   = "verified"
+	BinaryIntegrityMismatch   = "mismatch"
+	BinaryIntegrityUnrecorded = "unrecorded" // e.g. a binary found on PATH rather than installed by us
+)
+
+// hashFile returns the hex-encoded SHA256 of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RecordBinaryHash stores the SHA256 of an installed miner binary in the miners config.
+func RecordBinaryHash(binaryPath string) error {
+	absPath, err := filepath.Abs(binaryPath)
+	if err != nil {
+		return err
+	}
+	sum, err := hashFile(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash miner binary: %w", err)
+	}
+
+	return UpdateMinersConfig(func(cfg *MinersConfig) error {
+		if cfg.BinaryHashes == nil {
+			cfg.BinaryHashes = make(map[string]string)
+		}
+		cfg.BinaryHashes[absPath] = sum
+		return nil
+	})
+}
+
+// CheckBinaryIntegrity recomputes the hash of a miner binary and compares it
+// with the one recorded at install time. It returns one of the BinaryIntegrity
+// states along with the configured verification mode.
+func CheckBinaryIntegrity(binaryPath string) (string, BinaryVerificationMode, error) {
+	absPath, err := filepath.Abs(binaryPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	cfg, err := LoadMinersConfig()
+	if err != nil {
+		return "", "", err
+	}
+	mode := cfg.BinaryVerification
+	if mode == "" {
+		mode = BinaryVerificationWarn
+	}
+
+	expected, ok := cfg.BinaryHashes[absPath]
+	if !ok {
+		return BinaryIntegrityUnrecorded, mode, nil
+	}
+
+	actual, err := hashFile(absPath)
+	if err != nil {
+		return "", mode, fmt.Errorf("failed to hash miner binary: %w", err)
+	}
+	if actual != expected {
+		return BinaryIntegrityMismatch, mode, nil
+	}
+	return BinaryIntegrityVerified, mode, nil
+}
+
+// VerifyBinary checks the miner binary against the hash recorded at install.
+// On mismatch it logs an error and, in block mode, returns ErrBinaryIntegrity.
+// Binaries without a recorded hash are allowed to start, as are binaries that
+// can't be checked unless in block mode.
+func (b *BaseMiner) VerifyBinary() error {
+	binaryPath := b.GetBinaryPath()
+	if binaryPath == "" {
+		return nil
+	}
+
+	status, mode, err := CheckBinaryIntegrity(binaryPath)
+	if err != nil {
+		if mode == BinaryVerificationBlock {
+			return err
+		}
+		logging.Warn("could not verify miner binary", logging.Fields{"binary": binaryPath, "error": err})
+		return nil
+	}
+	if status != BinaryIntegrityMismatch {
+		return nil
+	}
+
+	logging.Error("miner binary does not match the checksum recorded at install", logging.Fields{
+		"binary": binaryPath,
+		"mode":   mode,
+	})
+	if mode == BinaryVerificationBlock {
+		return ErrBinaryIntegrity(binaryPath)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package mining
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+// setupIsolatedMinersConfig points the XDG config directory at a temp dir so
+// the miners config file can be written without touching the real one.
+func setupIsolatedMinersConfig(t *testing.T) {
+	t.Cleanup(xdg.Reload) // Runs after the env var below is restored
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+}
+
+func TestBinaryIntegrity(t *testing.T) {
+	setupIsolatedMinersConfig(t)
+
+	binary := filepath.Join(t.TempDir(), "xmrig")
+	if err := os.WriteFile(binary, []byte("original"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	status, mode, err := CheckBinaryIntegrity(binary)
+	if err != nil || status != BinaryIntegrityUnrecorded {
+		t.Fatalf("expected unrecorded before install, got %s (%v)", status, err)
+	}
+	if mode != BinaryVerificationWarn {
+		t.Errorf("expected warn mode by default, got %s", mode)
+	}
+
+	if err := RecordBinaryHash(binary); err != nil {
+		t.Fatalf("RecordBinaryHash failed: %v", err)
+	}
+	if status, _, _ := CheckBinaryIntegrity(binary); status != BinaryIntegrityVerified {
+		t.Errorf("expected verified, got %s", status)
+	}
+
+	if err := os.WriteFile(binary, []byte("swapped"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if status, _, _ := CheckBinaryIntegrity(binary); status != BinaryIntegrityMismatch {
+		t.Errorf("expected mismatch after the binary changed, got %s", status)
+	}
+}
+
+func TestVerifyBinaryEnforcement(t *testing.T) {
+	setupIsolatedMinersConfig(t)
+
+	binary := filepath.Join(t.TempDir(), "xmrig")
+	if err := os.WriteFile(binary, []byte("original"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordBinaryHash(binary); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, []byte("swapped"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	miner := &BaseMiner{MinerBinary: binary}
+
+	// Warn mode lets the miner start
+	if err := miner.VerifyBinary(); err != nil {
+		t.Errorf("warn mode should not block, got %v", err)
+	}
+
+	// Block mode refuses
+	if err := UpdateMinersConfig(func(cfg *MinersConfig) error {
+		cfg.BinaryVerification = BinaryVerificationBlock
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err := miner.VerifyBinary()
+	var miningErr *MiningError
+	if !errors.As(err, &miningErr) || miningErr.Code != ErrCodeBinaryIntegrity {
+		t.Errorf("expected binary integrity error in block mode, got %v", err)
+	}
+
+	// A binary that can't be hashed only blocks in block mode
+	if err := os.Remove(binary); err != nil {
+		t.Fatal(err)
+	}
+	if err := miner.VerifyBinary(); err == nil {
+		t.Error("expected block mode to refuse a binary it can't hash")
+	}
+	if err := UpdateMinersConfig(func(cfg *MinersConfig) error {
+		cfg.BinaryVerification = BinaryVerificationWarn
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := miner.VerifyBinary(); err != nil {
+		t.Errorf("warn mode should not block on a binary it can't hash, got %v", err)
+	}
+}
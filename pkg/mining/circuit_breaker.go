@@ -41,6 +41,9 @@ type CircuitBreakerConfig struct {
 	ResetTimeout time.Duration
 	// SuccessThreshold is the number of successes needed in half-open state to close
 	SuccessThreshold int
+	// FailureWindow, if set, only counts failures within this long of the first
+	// failure towards FailureThreshold; older failures are forgotten
+	FailureWindow time.Duration
 }
 
 // DefaultCircuitBreakerConfig returns sensible defaults
@@ -54,17 +57,18 @@ func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
 
 // CircuitBreaker implements the circuit breaker pattern
 type CircuitBreaker struct {
-	name           string
-	config         CircuitBreakerConfig
-	state          CircuitState
-	failures       int
-	successes      int
-	lastFailure    time.Time
-	mu             sync.RWMutex
-	cachedResult   interface{}
-	cachedErr      error
-	lastCacheTime  time.Time
-	cacheDuration  time.Duration
+	name          string
+	config        CircuitBreakerConfig
+	state         CircuitState
+	failures      int
+	successes     int
+	firstFailure  time.Time
+	lastFailure   time.Time
+	mu            sync.RWMutex
+	cachedResult  interface{}
+	cachedErr     error
+	lastCacheTime time.Time
+	cacheDuration time.Duration
 }
 
 // ErrCircuitOpen is returned when the circuit is open
@@ -154,8 +158,13 @@ func (cb *CircuitBreaker) recordFailure() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	now := time.Now()
+	if cb.failures == 0 || (cb.config.FailureWindow > 0 && now.Sub(cb.firstFailure) > cb.config.FailureWindow) {
+		cb.failures = 0
+		cb.firstFailure = now
+	}
 	cb.failures++
-	cb.lastFailure = time.Now()
+	cb.lastFailure = now
 
 	switch cb.state {
 	case CircuitClosed:
@@ -216,6 +225,21 @@ func (cb *CircuitBreaker) Reset() {
 	})
 }
 
+// RetryAfter returns how long until an open circuit lets a request through
+// again, or zero if it isn't open.
+func (cb *CircuitBreaker) RetryAfter() time.Duration {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	if cb.state != CircuitOpen {
+		return 0
+	}
+	if remaining := cb.config.ResetTimeout - time.Since(cb.lastFailure); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
 // GetCached returns the cached result if available
 func (cb *CircuitBreaker) GetCached() (interface{}, bool) {
 	cb.mu.RLock()
@@ -332,3 +332,27 @@ func BenchmarkCircuitBreakerConcurrent(b *testing.B) {
 		}
 	})
 }
+
+func TestCircuitBreakerFailureWindow(t *testing.T) {
+	cb := NewCircuitBreaker("test", CircuitBreakerConfig{
+		FailureThreshold: 2,
+		FailureWindow:    50 * time.Millisecond,
+		ResetTimeout:     time.Minute,
+		SuccessThreshold: 1,
+	})
+
+	cb.recordFailure()
+	time.Sleep(100 * time.Millisecond)
+	cb.recordFailure()
+	if cb.State() != CircuitClosed {
+		t.Error("failures outside the window should not open the circuit")
+	}
+
+	cb.recordFailure()
+	if cb.State() != CircuitOpen {
+		t.Error("failures within the window should open the circuit")
+	}
+	if cb.RetryAfter() <= 0 || cb.RetryAfter() > time.Minute {
+		t.Errorf("unexpected retry after %v", cb.RetryAfter())
+	}
+}
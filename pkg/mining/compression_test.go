@@ -0,0 +1,114 @@
+package mining
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := map[string]bool{
+		"":                    false,
+		"gzip":                true,
+		"deflate, gzip;q=1.0": true,
+		"br, *":               true,
+		"gzip;q=0":            false,
+		"identity":            false,
+	}
+	for header, want := range tests {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	large := strings.Repeat("hashrate ", 500)
+	router := gin.New()
+	router.Use(gzipMiddleware(gzipMinSize))
+	router.GET("/large", func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.String(http.StatusOK, large)
+	})
+	router.GET("/small", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	router.GET("/png", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte(large)) })
+	router.GET("/not-modified", func(c *gin.Context) { c.Status(http.StatusNotModified) })
+	router.GET("/stream", func(c *gin.Context) {
+		c.Writer.WriteString("first\n")
+		c.Writer.Flush()
+		c.Writer.WriteString("second\n")
+	})
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	gunzip := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		t.Helper()
+		if w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("expected a gzipped response, got headers %v", w.Header())
+		}
+		r, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("invalid gzip: %v", err)
+		}
+		body, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("invalid gzip: %v", err)
+		}
+		return string(body)
+	}
+
+	t.Run("compresses large bodies", func(t *testing.T) {
+		w := get("/large", "gzip")
+		size := w.Body.Len()
+		if body := gunzip(t, w); body != large {
+			t.Errorf("body changed by compression")
+		}
+		if size >= len(large) || w.Header().Get("Content-Length") != "" {
+			t.Errorf("expected a smaller body without Content-Length")
+		}
+		if w.Header().Get("ETag") != `W/"v1"` || w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("expected a weakened ETag and Vary, got %v", w.Header())
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+			t.Errorf("expected the content type kept, got %q", w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("leaves the rest alone", func(t *testing.T) {
+		for _, tt := range []struct{ path, accept string }{
+			{"/large", ""},
+			{"/large", "gzip;q=0"},
+			{"/small", "gzip"},
+			{"/png", "gzip"},
+		} {
+			w := get(tt.path, tt.accept)
+			if w.Header().Get("Content-Encoding") != "" || w.Code != http.StatusOK {
+				t.Errorf("%s with %q: expected no compression, got %d %v", tt.path, tt.accept, w.Code, w.Header())
+			}
+		}
+		if w := get("/small", "gzip"); w.Body.String() != "ok" {
+			t.Errorf("expected the small body as is, got %q", w.Body.String())
+		}
+		if w := get("/not-modified", "gzip"); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("expected an empty 304, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("streams flushed responses", func(t *testing.T) {
+		if body := gunzip(t, get("/stream", "gzip")); body != "first\nsecond\n" {
+			t.Errorf("unexpected streamed body %q", body)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package mining
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// ConfigFieldDiff describes a single field that differs between two configs.
+// A nil value means the field is unset on that side.
+type ConfigFieldDiff struct {
+	Field   string      `json:"field"`
+	Profile interface{} `json:"profile"`
+	Running interface{} `json:"running"`
+}
+
+// DiffConfigs compares a profile's config against a running miner's config
+// field by field, using the JSON field names. Results are sorted by field name.
+func DiffConfigs(profile, running *Config) ([]ConfigFieldDiff, error) {
+	profileFields, err := configToMap(profile)
+	if err != nil {
+		return nil, err
+	}
+	runningFields, err := configToMap(running)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]struct{}, len(profileFields)+len(runningFields))
+	for k := range profileFields {
+		fields[k] = struct{}{}
+	}
+	for k := range runningFields {
+		fields[k] = struct{}{}
+	}
+
+	diffs := make([]ConfigFieldDiff, 0)
+	for field := range fields {
+		p, r := profileFields[field], runningFields[field]
+		if !reflect.DeepEqual(p, r) {
+			diffs = append(diffs, ConfigFieldDiff{Field: field, Profile: p, Running: r})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs, nil
+}
+
+// maskDiffSecrets replaces the values of secret fields in diffs, leaving
+// unset values nil so the diff still shows which side has one.
+func maskDiffSecrets(diffs []ConfigFieldDiff) {
+	for i := range diffs {
+		if !slices.Contains(secretConfigKeys, diffs[i].Field) {
+			continue
+		}
+		for _, value := range []*interface{}{&diffs[i].Profile, &diffs[i].Running} {
+			if *value != nil {
+				*value = maskedSecret
+			}
+		}
+	}
+}
+
+// configToMap converts a config to a map keyed by JSON field name.
+// Zero values are omitted via the struct's omitempty tags.
+func configToMap(config *Config) (map[string]interface{}, error) {
+	if config == nil {
+		return map[string]interface{}{}, nil
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,219 @@
+package mining
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	profile := &Config{Pool: "pool.example.com:3333", Wallet: "wallet1", Algo: "rx/0", Threads: 4}
+	running := &Config{Pool: "pool.example.com:3333", Wallet: "wallet1", Algo: "rx/wow", RigID: "rig-1"}
+
+	diffs, err := DiffConfigs(profile, running)
+	if err != nil {
+		t.Fatalf("DiffConfigs returned error: %v", err)
+	}
+
+	got := make(map[string]ConfigFieldDiff)
+	for _, d := range diffs {
+		got[d.Field] = d
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 differences, got %d: %+v", len(got), diffs)
+	}
+	if d := got["algo"]; d.Profile != "rx/0" || d.Running != "rx/wow" {
+		t.Errorf("unexpected algo diff: %+v", d)
+	}
+	if d := got["threads"]; d.Profile != float64(4) || d.Running != float64(0) {
+		t.Errorf("unexpected threads diff: %+v", d)
+	}
+	if d, ok := got["rigId"]; !ok || d.Profile != nil || d.Running != "rig-1" {
+		t.Errorf("expected rigId set only on the running side, got %+v", d)
+	}
+	if _, ok := got["pool"]; ok {
+		t.Error("identical pool should not be reported")
+	}
+
+	// Sorted by field name
+	for i := 1; i < len(diffs); i++ {
+		if diffs[i-1].Field > diffs[i].Field {
+			t.Errorf("diffs not sorted: %s before %s", diffs[i-1].Field, diffs[i].Field)
+		}
+	}
+}
+
+func TestDiffConfigsIdentical(t *testing.T) {
+	cfg := &Config{Pool: "pool:1", Wallet: "w"}
+	diffs, err := DiffConfigs(cfg, cfg)
+	if err != nil {
+		t.Fatalf("DiffConfigs returned error: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %+v", diffs)
+	}
+}
+
+func TestMaskDiffSecrets(t *testing.T) {
+	diffs, err := DiffConfigs(&Config{Pool: "pool:1", Password: "old-secret"}, &Config{Pool: "pool:1", Password: "new-secret", HTTPAccessToken: "token"})
+	if err != nil {
+		t.Fatalf("DiffConfigs returned error: %v", err)
+	}
+	maskDiffSecrets(diffs)
+
+	if len(diffs) != 2 {
+		t.Fatalf("expected the changed password and token to be reported, got %+v", diffs)
+	}
+	for _, d := range diffs {
+		switch d.Field {
+		case "password":
+			if d.Profile != maskedSecret || d.Running != maskedSecret {
+				t.Errorf("password values not masked: %+v", d)
+			}
+		case "httpAccessToken":
+			if d.Profile != nil || d.Running != maskedSecret {
+				t.Errorf("expected an unset profile token and a masked running one, got %+v", d)
+			}
+		default:
+			t.Errorf("unexpected difference: %+v", d)
+		}
+	}
+}
+
+func TestGetMinerLaunchInfoNotFound(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	if _, err := m.GetMinerLaunchInfo("missing"); err == nil {
+		t.Error("expected error for unknown miner")
+	}
+}
+
+func TestLaunchInfoKeepsRequestedConfig(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	miner, err := m.StartMinerWithProfile(context.Background(), MinerTypeSimulated, "p1", &Config{Pool: "test:1234", Wallet: "testwallet"})
+	if err != nil {
+		t.Fatalf("StartMinerWithProfile failed: %v", err)
+	}
+	defer miner.Stop()
+
+	launch, err := m.GetMinerLaunchInfo(miner.GetName())
+	if err != nil {
+		t.Fatalf("GetMinerLaunchInfo failed: %v", err)
+	}
+	if launch.Config.HTTPPort == 0 {
+		t.Error("expected the effective config to carry the assigned API port")
+	}
+	diffs, err := DiffConfigs(&Config{Pool: "test:1234", Wallet: "testwallet"}, &launch.requested)
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("expected the requested config to match the profile, got %+v, %v", diffs, err)
+	}
+}
+
+func TestHandleMinerConfigDiff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	pm := &ProfileManager{profiles: make(map[string]*MiningProfile)}
+	profileConfig, _ := json.Marshal(Config{Pool: "pool:1", Wallet: "w", Algo: "rx/0"})
+	pm.profiles["p1"] = &MiningProfile{ID: "p1", Name: "test", MinerType: "xmrig", Config: profileConfig}
+
+	// The manager assigned the API port and donate level; only the pool was requested differently
+	m.launches["xmrig-rx_0"] = &MinerLaunchInfo{
+		MinerType: "xmrig",
+		ProfileID: "p1",
+		Config:    Config{Pool: "pool:2", Wallet: "w", Algo: "rx/0", HTTPPort: 45000, DonateLevel: 1},
+		StartedAt: time.Now(),
+		requested: Config{Pool: "pool:2", Wallet: "w", Algo: "rx/0"},
+	}
+
+	router := gin.New()
+	service := &Service{Manager: m, ProfileManager: pm, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	req, _ := http.NewRequest("GET", "/miners/xmrig-rx_0/config-diff", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp ConfigDiffResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.ProfileID != "p1" || resp.InSync {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(resp.Differences) != 1 || resp.Differences[0].Field != "pool" {
+		t.Errorf("expected a single pool difference, got %+v", resp.Differences)
+	}
+
+	// Unknown miner
+	req, _ = http.NewRequest("GET", "/miners/nope/config-diff?profile=p1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for unknown miner, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestConfigMasked(t *testing.T) {
+	cfg := Config{Pool: "pool:1", Wallet: "w", Password: "secret", HTTPAccessToken: "token"}
+	masked := cfg.Masked()
+
+	if masked.Password != maskedSecret || masked.HTTPAccessToken != maskedSecret {
+		t.Errorf("secrets not masked: %+v", masked)
+	}
+	if masked.UserPass != "" {
+		t.Error("empty secrets should stay empty")
+	}
+	if masked.Pool != "pool:1" || masked.Wallet != "w" {
+		t.Error("non-secret fields should be unchanged")
+	}
+	if cfg.Password != "secret" {
+		t.Error("Masked must not modify the original config")
+	}
+}
+
+func TestHandleMinerConfigUsed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	m.launches["xmrig-rx_0"] = &MinerLaunchInfo{
+		MinerType: "xmrig",
+		Config:    Config{Pool: "pool:1", Algo: "rx/0", Password: "x-secret"},
+		StartedAt: time.Now(),
+	}
+
+	router := gin.New()
+	service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	req, _ := http.NewRequest("GET", "/miners/xmrig-rx_0/config-used", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var info MinerLaunchInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if info.Config.Algo != "rx/0" || info.Config.Password != maskedSecret {
+		t.Errorf("unexpected config: %+v", info.Config)
+	}
+}
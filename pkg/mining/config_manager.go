@@ -23,9 +23,17 @@ type MinerAutostartConfig struct {
 // DatabaseConfig holds configuration for SQLite database persistence.
 type DatabaseConfig struct {
 	// Enabled determines if database persistence is active (default: true)
-	Enabled bool `json:"enabled"`
+	Enabled bool `json:"enabled" yaml:"enabled"`
 	// RetentionDays is how long to keep historical data (default: 30)
-	RetentionDays int `json:"retentionDays,omitempty"`
+	RetentionDays int `json:"retentionDays,omitempty" yaml:"retentionDays"`
+	// HighResRetentionDays is how long to keep 10-second points; older history
+	// is kept as 1-minute averages for RetentionDays (default: 7)
+	HighResRetentionDays int `json:"highResRetentionDays,omitempty" yaml:"highResRetentionDays"`
+	// LowResQueryHours is the history query range, in hours, above which
+	// 1-minute averages are returned instead of 10-second points (default: 6)
+	LowResQueryHours int `json:"lowResQueryHours,omitempty" yaml:"lowResQueryHours"`
+	// VacuumAfterCleanup compacts the database file after retention cleanup, at most once a day
+	VacuumAfterCleanup bool `json:"vacuumAfterCleanup,omitempty" yaml:"vacuumAfterCleanup"`
 }
 
 // defaultDatabaseConfig returns the default database configuration.
@@ -36,10 +44,42 @@ func defaultDatabaseConfig() DatabaseConfig {
 	}
 }
 
+var (
+	databaseOverride   *DatabaseConfig
+	databaseOverrideMu sync.RWMutex
+)
+
+// SetDatabaseConfig sets database settings that replace the miners config's
+// for managers created afterwards. nil restores the miners config's settings.
+func SetDatabaseConfig(config *DatabaseConfig) {
+	databaseOverrideMu.Lock()
+	defer databaseOverrideMu.Unlock()
+	databaseOverride = config
+}
+
+// getDatabaseConfigOverride returns the settings set by SetDatabaseConfig, or nil.
+func getDatabaseConfigOverride() *DatabaseConfig {
+	databaseOverrideMu.RLock()
+	defer databaseOverrideMu.RUnlock()
+	return databaseOverride
+}
+
+// Validate checks the retention and query settings aren't negative.
+func (c DatabaseConfig) Validate() error {
+	if c.RetentionDays < 0 || c.HighResRetentionDays < 0 || c.LowResQueryHours < 0 {
+		return fmt.Errorf("retention days and query hours can't be negative")
+	}
+	return nil
+}
+
 // MinersConfig represents the overall configuration for all miners, including autostart settings.
 type MinersConfig struct {
 	Miners   []MinerAutostartConfig `json:"miners"`
 	Database DatabaseConfig         `json:"database"`
+	// BinaryHashes maps installed miner binary paths to the SHA256 recorded at install time
+	BinaryHashes map[string]string `json:"binaryHashes,omitempty"`
+	// BinaryVerification is "warn" (default) or "block" when a binary's hash has changed
+	BinaryVerification BinaryVerificationMode `json:"binaryVerification,omitempty"`
 }
 
 // getMinersConfigPath returns the path to the miners configuration file.
@@ -106,6 +146,32 @@ func SaveMinersConfig(cfg *MinersConfig) error {
 	return AtomicWriteFile(configPath, data, 0600)
 }
 
+// ResetMinersConfig replaces the miners config with an empty one, first
+// copying the current file aside with BackupFile. Returns the backup path.
+func ResetMinersConfig() (string, error) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	configPath, err := getMinersConfigPath()
+	if err != nil {
+		return "", fmt.Errorf("could not determine miners config path: %w", err)
+	}
+
+	backupPath, err := BackupFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up miners config: %w", err)
+	}
+
+	data, err := json.MarshalIndent(&MinersConfig{
+		Miners:   []MinerAutostartConfig{},
+		Database: defaultDatabaseConfig(),
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal miners config: %w", err)
+	}
+	return backupPath, AtomicWriteFile(configPath, data, 0600)
+}
+
 // UpdateMinersConfig atomically loads, modifies, and saves the miners config.
 // This prevents race conditions in read-modify-write operations.
 func UpdateMinersConfig(fn func(*MinersConfig) error) error {
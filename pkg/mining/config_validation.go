@@ -0,0 +1,163 @@
+package mining
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ConfigIssue is a problem with a single config field.
+type ConfigIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ConfigValidationResult separates hard errors, which would stop a miner
+// from starting, from advisory warnings about settings that will be ignored
+// or are likely mistakes.
+type ConfigValidationResult struct {
+	MinerType string        `json:"minerType"`
+	Valid     bool          `json:"valid"`
+	Errors    []ConfigIssue `json:"errors"`
+	Warnings  []ConfigIssue `json:"warnings"`
+}
+
+// ValidateMinerConfig runs Config.Validate's checks plus checks specific to
+// the miner type. It has no side effects.
+func ValidateMinerConfig(minerType string, config *Config) *ConfigValidationResult {
+	result := &ConfigValidationResult{
+		MinerType: minerType,
+		Errors:    []ConfigIssue{},
+		Warnings:  []ConfigIssue{},
+	}
+
+	miner, err := CreateMiner(minerType)
+	if err != nil {
+		result.Errors = append(result.Errors, ConfigIssue{Field: "minerType", Message: fmt.Sprintf("unsupported miner type: %s", minerType)})
+		return result
+	}
+	result.MinerType = miner.GetType()
+
+	errs, warnings := config.issuesFor(result.MinerType)
+	result.Errors = append(result.Errors, errs...)
+	result.Warnings = append(result.Warnings, warnings...)
+
+	if config.Pool == "" || config.Wallet == "" {
+		result.Warnings = append(result.Warnings, ConfigIssue{Field: "pool", Message: "pool and wallet are not both set; the miner will only start from an existing config file"})
+	}
+	result.Warnings = append(result.Warnings, algoSupportWarnings(result.MinerType, config)...)
+	result.Warnings = append(result.Warnings, walletFormatWarnings(config)...)
+	if result.MinerType == MinerTypeXMRig {
+		result.Warnings = append(result.Warnings, privilegeWarnings(config, DetectPrivileges())...)
+	}
+	if config.Threads > runtime.NumCPU() {
+		result.Warnings = append(result.Warnings, ConfigIssue{Field: "threads", Message: fmt.Sprintf("threads (%d) exceeds available CPU cores (%d)", config.Threads, runtime.NumCPU())})
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// ValidateFor runs the generic Validate checks plus rules for the given miner
+// type, such as rejecting GPU settings on a CPU-only XMRig config. Unknown
+// miner types only get the generic checks. Returns the first error found.
+func (c *Config) ValidateFor(minerType string) error {
+	if miner, err := CreateMiner(minerType); err == nil {
+		minerType = miner.GetType()
+	}
+	if errs, _ := c.issuesFor(minerType); len(errs) > 0 {
+		return fmt.Errorf("%s: %s", errs[0].Field, errs[0].Message)
+	}
+	return nil
+}
+
+// issuesFor returns generic and miner-type-specific errors and warnings.
+// minerType must already be the canonical type name.
+func (c *Config) issuesFor(minerType string) (errs, warnings []ConfigIssue) {
+	errs = c.validationIssues()
+	addError := func(field, format string, args ...interface{}) {
+		errs = append(errs, ConfigIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	addWarning := func(field, format string, args ...interface{}) {
+		warnings = append(warnings, ConfigIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch minerType {
+	case MinerTypeTTMiner:
+		validateTTMinerConfig(c, addError, addWarning)
+	case MinerTypeXMRig:
+		validateXMRigConfig(c, addError, addWarning)
+	}
+	return errs, warnings
+}
+
+type issueFunc func(field, format string, args ...interface{})
+
+// validateTTMinerConfig checks TT-Miner, which is an NVIDIA (CUDA) GPU miner only.
+func validateTTMinerConfig(config *Config, addError, addWarning issueFunc) {
+	if runtime.GOOS != "windows" && runtime.GOOS != "linux" {
+		addError("minerType", "TT-Miner is only available for Windows and Linux")
+	}
+	if config.OpenCL {
+		addError("opencl", "TT-Miner requires CUDA (NVIDIA GPUs); OpenCL is not supported")
+	}
+	if config.Intensity > 0 && config.Devices == "" {
+		addError("devices", "intensity requires a device selection")
+	}
+	if config.Threads > 0 {
+		addWarning("threads", "CPU threads are ignored by TT-Miner")
+	}
+	if config.HugePages {
+		addWarning("hugePages", "huge pages are ignored by TT-Miner")
+	}
+	if config.CPUMaxThreadsHint > 0 {
+		addWarning("cpuMaxThreadsHint", "CPU thread hint is ignored by TT-Miner")
+	}
+	if config.GPUPool != "" {
+		addWarning("gpuPool", "TT-Miner uses the main pool; gpuPool is ignored")
+	}
+	if config.WorkerFormat == WorkerFormatRigID {
+		addError("workerFormat", "TT-Miner has no rig ID; use the %q worker format", WorkerFormatDot)
+	}
+}
+
+// validateXMRigConfig checks XMRig's optional GPU settings. GPU-only fields on a
+// CPU-only config are rejected, since XMRig would silently ignore them.
+func validateXMRigConfig(config *Config, addError, addWarning issueFunc) {
+	if !config.GPUEnabled {
+		gpuFields := []struct {
+			name string
+			set  bool
+		}{
+			{"gpuPool", config.GPUPool != ""},
+			{"gpuWallet", config.GPUWallet != ""},
+			{"gpuAlgo", config.GPUAlgo != ""},
+			{"gpuPassword", config.GPUPassword != ""},
+			{"gpuIntensity", config.GPUIntensity > 0},
+			{"gpuThreads", config.GPUThreads > 0},
+			{"devices", config.Devices != ""},
+			{"opencl", config.OpenCL},
+			{"cuda", config.CUDA},
+		}
+		for _, f := range gpuFields {
+			if f.set {
+				addError(f.name, "%s is a GPU setting but GPU mining is not enabled (set gpuEnabled)", f.name)
+			}
+		}
+		return
+	}
+
+	if config.GPUIntensity > 0 && config.Devices == "" {
+		addError("devices", "GPU intensity requires a device selection")
+	}
+	if !config.OpenCL && !config.CUDA {
+		addWarning("gpuEnabled", "GPU mining is enabled but neither OpenCL nor CUDA is selected")
+	} else if config.Devices == "" {
+		addWarning("devices", "GPU mining is enabled but no devices are selected; GPU backends will stay disabled")
+	}
+	if config.OpenCL && config.CUDA {
+		addWarning("devices", "both OpenCL and CUDA are enabled; the same device list is used for both backends")
+	}
+	if config.GPUPool != "" && config.GPUAlgo == "" {
+		addWarning("gpuAlgo", "a separate GPU pool is set without a GPU algorithm")
+	}
+}
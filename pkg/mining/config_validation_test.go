@@ -0,0 +1,163 @@
+package mining
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func hasIssue(issues []ConfigIssue, field string) bool {
+	for _, issue := range issues {
+		if issue.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateMinerConfig(t *testing.T) {
+	t.Run("valid xmrig", func(t *testing.T) {
+		result := ValidateMinerConfig("xmrig", &Config{Pool: "pool:3333", Wallet: "w"})
+		if !result.Valid || len(result.Errors) != 0 || len(result.Warnings) != 0 {
+			t.Errorf("expected clean result, got %+v", result)
+		}
+	})
+
+	t.Run("collects every field error", func(t *testing.T) {
+		result := ValidateMinerConfig("xmrig", &Config{Pool: "pool;rm", Wallet: "w", Threads: -1, DonateLevel: 200})
+		if result.Valid {
+			t.Error("expected invalid result")
+		}
+		for _, field := range []string{"pool", "threads", "donateLevel"} {
+			if !hasIssue(result.Errors, field) {
+				t.Errorf("expected error for %s, got %+v", field, result.Errors)
+			}
+		}
+	})
+
+	t.Run("unsupported miner", func(t *testing.T) {
+		result := ValidateMinerConfig("nope", &Config{})
+		if result.Valid || !hasIssue(result.Errors, "minerType") {
+			t.Errorf("expected minerType error, got %+v", result)
+		}
+	})
+
+	t.Run("xmrig GPU fields without GPU enabled", func(t *testing.T) {
+		result := ValidateMinerConfig("xmrig", &Config{Pool: "pool:3333", Wallet: "w", CUDA: true, Devices: "0"})
+		if result.Valid || !hasIssue(result.Errors, "cuda") || !hasIssue(result.Errors, "devices") {
+			t.Errorf("expected GPU field errors, got %+v", result)
+		}
+	})
+
+	t.Run("xmrig conflicting backends", func(t *testing.T) {
+		result := ValidateMinerConfig("xmrig", &Config{Pool: "pool:3333", Wallet: "w", GPUEnabled: true, CUDA: true, OpenCL: true, Devices: "0"})
+		if !result.Valid || !hasIssue(result.Warnings, "devices") {
+			t.Errorf("expected a devices warning, got %+v", result)
+		}
+	})
+
+	t.Run("tt-miner rejects OpenCL and ignores CPU fields", func(t *testing.T) {
+		result := ValidateMinerConfig("ttminer", &Config{Pool: "pool:3333", Wallet: "w", OpenCL: true, Threads: 2})
+		if result.MinerType != MinerTypeTTMiner {
+			t.Errorf("expected alias to resolve to %s, got %s", MinerTypeTTMiner, result.MinerType)
+		}
+		if !hasIssue(result.Errors, "opencl") {
+			t.Errorf("expected opencl error, got %+v", result.Errors)
+		}
+		if !hasIssue(result.Warnings, "threads") {
+			t.Errorf("expected threads warning, got %+v", result.Warnings)
+		}
+	})
+}
+
+func TestConfigValidateFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		minerType string
+		config    Config
+		wantErr   string // substring of the expected error, empty for valid
+	}{
+		// XMRig
+		{"xmrig cpu only", "xmrig", Config{Threads: 2, HugePages: true}, ""},
+		{"xmrig cuda without gpu", "xmrig", Config{CUDA: true}, "cuda"},
+		{"xmrig gpu pool without gpu", "xmrig", Config{GPUPool: "pool:1"}, "gpuPool"},
+		{"xmrig gpu intensity without devices", "xmrig", Config{GPUEnabled: true, CUDA: true, GPUIntensity: 50}, "devices"},
+		{"xmrig gpu with devices", "xmrig", Config{GPUEnabled: true, OpenCL: true, Devices: "0", GPUIntensity: 50}, ""},
+		{"xmrig generic rule", "xmrig", Config{Threads: -1}, "threads"},
+
+		// TT-Miner (alias resolves to tt-miner)
+		{"tt-miner cuda", "tt-miner", Config{CUDA: true, Devices: "0", Intensity: 20}, ""},
+		{"tt-miner opencl", "ttminer", Config{OpenCL: true}, "opencl"},
+		{"tt-miner intensity without devices", "tt-miner", Config{Intensity: 20}, "devices"},
+		{"tt-miner cpu fields only warn", "tt-miner", Config{Threads: 4, HugePages: true}, ""},
+
+		// Simulated and unknown miners only get the generic rules
+		{"simulated gpu fields", MinerTypeSimulated, Config{CUDA: true}, ""},
+		{"unknown type generic rule", "unknown", Config{DonateLevel: 101}, "donateLevel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.ValidateFor(tt.minerType)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected valid config, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error mentioning %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStartMinerRejectsTypeSpecificErrors(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	_, err := m.StartMiner(context.Background(), "xmrig", &Config{Pool: "pool:1", Wallet: "w", CUDA: true})
+	var miningErr *MiningError
+	if !errors.As(err, &miningErr) || miningErr.Code != ErrCodeInvalidConfig {
+		t.Errorf("expected invalid config error, got %v", err)
+	}
+}
+
+func TestConfigValidateUnchanged(t *testing.T) {
+	cfg := &Config{Threads: -1, DonateLevel: 200}
+	err := cfg.Validate()
+	if err == nil || err.Error() != "threads cannot be negative" {
+		t.Errorf("Validate should report the first problem, got %v", err)
+	}
+}
+
+func TestHandleValidateMinerConfig(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	body := `{"minerType":"xmrig","config":{"pool":"pool:3333","wallet":"w","intensity":150}}`
+	req, _ := http.NewRequest("POST", "/miners/validate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var result ConfigValidationResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if result.Valid || !hasIssue(result.Errors, "intensity") {
+		t.Errorf("expected intensity error, got %+v", result)
+	}
+
+	req, _ = http.NewRequest("POST", "/miners/validate", strings.NewReader(`{"config":{}}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without miner type, got %d", http.StatusBadRequest, w.Code)
+	}
+}
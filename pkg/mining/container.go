@@ -108,7 +108,7 @@ func (c *Container) Initialize(ctx context.Context) error {
 	}
 
 	// 4. Initialize node service (optional - P2P features)
-	c.nodeService, err = NewNodeService()
+	c.nodeService, err = NewNodeService(c.manager, c.profileManager)
 	if err != nil {
 		logging.Warn("node service unavailable", logging.Fields{"error": err})
 		// Continue without node service - P2P features will be unavailable
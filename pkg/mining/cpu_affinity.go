@@ -0,0 +1,70 @@
+package mining
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCPUAffinity parses a CPU affinity spec into a sorted list of CPU
+// indexes. The spec is either a list of CPUs and ranges ("0-3,6") or a hex
+// bitmask as XMRig takes it ("0x4F"). Every CPU must exist on this machine.
+func ParseCPUAffinity(spec string) ([]int, error) {
+	numCPU := runtime.NumCPU()
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("CPU affinity is empty")
+	}
+
+	seen := make(map[int]bool)
+	add := func(cpu int) error {
+		if cpu < 0 || cpu >= numCPU {
+			return fmt.Errorf("CPU %d does not exist (this machine has CPUs 0-%d)", cpu, numCPU-1)
+		}
+		seen[cpu] = true
+		return nil
+	}
+
+	if hex, ok := strings.CutPrefix(strings.ToLower(spec), "0x"); ok {
+		mask, err := strconv.ParseUint(hex, 16, 64)
+		if err != nil || mask == 0 {
+			return nil, fmt.Errorf("invalid CPU affinity mask %q", spec)
+		}
+		for cpu := 0; mask != 0; cpu, mask = cpu+1, mask>>1 {
+			if mask&1 == 1 {
+				if err := add(cpu); err != nil {
+					return nil, err
+				}
+			}
+		}
+	} else {
+		for _, part := range strings.Split(spec, ",") {
+			part = strings.TrimSpace(part)
+			lo, hi, isRange := strings.Cut(part, "-")
+			first, err := strconv.Atoi(lo)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU %q in affinity %q", part, spec)
+			}
+			last := first
+			if isRange {
+				if last, err = strconv.Atoi(hi); err != nil || last < first {
+					return nil, fmt.Errorf("invalid CPU range %q in affinity %q", part, spec)
+				}
+			}
+			for cpu := first; cpu <= last; cpu++ {
+				if err := add(cpu); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	cpus := make([]int, 0, len(seen))
+	for cpu := range seen {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
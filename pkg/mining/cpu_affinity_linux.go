@@ -0,0 +1,37 @@
+//go:build linux
+
+package mining
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyCPUAffinity pins every thread of a started process to cpus. Threads
+// created afterwards inherit the affinity of the thread that creates them.
+func applyCPUAffinity(cmd *exec.Cmd, cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+
+	pid := cmd.Process.Pid
+	tids := []int{pid}
+	if entries, err := os.ReadDir("/proc/" + strconv.Itoa(pid) + "/task"); err == nil {
+		tids = tids[:0]
+		for _, entry := range entries {
+			if tid, err := strconv.Atoi(entry.Name()); err == nil {
+				tids = append(tids, tid)
+			}
+		}
+	}
+	for _, tid := range tids {
+		if err := unix.SchedSetaffinity(tid, &set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+
+package mining
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// applyCPUAffinity is unsupported: macOS and the BSDs have no portable
+// way to pin a process to CPUs.
+func applyCPUAffinity(cmd *exec.Cmd, cpus []int) error {
+	return errors.New("CPU affinity is not supported on this platform")
+}
@@ -0,0 +1,59 @@
+package mining
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestParseCPUAffinity(t *testing.T) {
+	last := runtime.NumCPU() - 1
+	tests := []struct {
+		spec string
+		want []int
+	}{
+		{"0", []int{0}},
+		{"0x1", []int{0}},
+		{fmt.Sprintf("%d,0,0-0", last), uniqueCPUs(0, last)},
+		{fmt.Sprintf("0-%d", last), uniqueCPUs(rangeCPUs(last)...)},
+	}
+	for _, tt := range tests {
+		got, err := ParseCPUAffinity(tt.spec)
+		if err != nil {
+			t.Errorf("ParseCPUAffinity(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseCPUAffinity(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "a", "3-1", "0x0", "0xZZ", "-1", fmt.Sprint(last + 1), fmt.Sprintf("0-%d", last+1)} {
+		if _, err := ParseCPUAffinity(spec); err == nil {
+			t.Errorf("expected ParseCPUAffinity(%q) to fail", spec)
+		}
+	}
+
+	if err := (&Config{CPUAffinity: fmt.Sprint(last + 1)}).Validate(); err == nil {
+		t.Error("expected Config.Validate to reject a CPU that doesn't exist")
+	}
+}
+
+func rangeCPUs(last int) []int {
+	cpus := make([]int, 0, last+1)
+	for cpu := 0; cpu <= last; cpu++ {
+		cpus = append(cpus, cpu)
+	}
+	return cpus
+}
+
+// uniqueCPUs returns cpus without duplicates, in order.
+func uniqueCPUs(cpus ...int) []int {
+	var unique []int
+	for _, cpu := range cpus {
+		if len(unique) == 0 || unique[len(unique)-1] != cpu {
+			unique = append(unique, cpu)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package mining
+
+import (
+	"fmt"
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSetProcessAffinityMask = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetProcessAffinityMask")
+
+// applyCPUAffinity restricts a started process to cpus. Only the first 64
+// CPUs (one processor group) can be addressed.
+func applyCPUAffinity(cmd *exec.Cmd, cpus []int) error {
+	var mask uintptr
+	for _, cpu := range cpus {
+		if cpu >= 64 {
+			return fmt.Errorf("CPU %d is outside the first processor group", cpu)
+		}
+		mask |= 1 << uint(cpu)
+	}
+
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_INFORMATION|windows.PROCESS_QUERY_INFORMATION, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+
+	if ok, _, err := procSetProcessAffinityMask.Call(uintptr(handle), mask); ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package mining
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// maxCrashHistory is how many crashes are kept per miner.
+const maxCrashHistory = 20
+
+// crashRestartDelay is the delay before restarting a crashed miner; it
+// doubles for each further consecutive restart, up to maxCrashRestartDelay.
+var crashRestartDelay = time.Second
+
+const (
+	maxCrashRestartDelay = time.Minute
+	// maxConsecutiveRestarts is how many restarts in a row a crashing miner
+	// gets before it is left errored.
+	maxConsecutiveRestarts = 5
+	// crashRestartResetAfter is how long a restarted miner must run before
+	// its next crash counts as the first in a row again.
+	crashRestartResetAfter = 5 * time.Minute
+)
+
+// crashRestartBackoff returns the delay before a miner's restart after the
+// given number of consecutive restarts.
+func crashRestartBackoff(consecutive int) time.Duration {
+	delay := crashRestartDelay
+	for i := 0; i < consecutive && delay < maxCrashRestartDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxCrashRestartDelay)
+}
+
+// crashOutputLines is how many trailing stderr lines a crash reason includes.
+const crashOutputLines = 5
+
+// ProcessExit describes a miner process that exited without being stopped.
+type ProcessExit struct {
+	At       time.Time `json:"at"`
+	ExitCode int       `json:"exitCode"` // -1 if killed by a signal or unknown
+	Reason   string    `json:"reason"`   // Exit status followed by the tail of stderr
+}
+
+// exitReporter is implemented by miners that record why their process exited.
+type exitReporter interface {
+	LastExit() *ProcessExit
+}
+
+// MinerHistory is the crash and restart record of a miner since the user
+// started it. Stopping the miner discards it, so an explicit restart begins
+// with a clean record.
+type MinerHistory struct {
+	Name            string        `json:"name"`
+	StartedAt       time.Time     `json:"startedAt"`
+	RestartCount    int           `json:"restartCount"`
+	LastCrashAt     *time.Time    `json:"lastCrashAt,omitempty"`
+	LastCrashReason string        `json:"lastCrashReason,omitempty"`
+	Crashes         []ProcessExit `json:"crashes"` // Oldest first, at most maxCrashHistory
+
+	consecutiveRestarts int       // Restarts since the miner last ran for crashRestartResetAfter
+	lastRestartAt       time.Time // When the supervisor last restarted it
+}
+
+// recordCrash adds a crash, dropping the oldest beyond maxCrashHistory.
+func (h *MinerHistory) recordCrash(exit ProcessExit) {
+	h.Crashes = append(h.Crashes, exit)
+	if len(h.Crashes) > maxCrashHistory {
+		h.Crashes = h.Crashes[len(h.Crashes)-maxCrashHistory:]
+	}
+	at := exit.At
+	h.LastCrashAt = &at
+	h.LastCrashReason = exit.Reason
+}
+
+// applyTo copies the restart count and last crash into stats.
+func (h *MinerHistory) applyTo(stats *PerformanceMetrics) {
+	stats.RestartCount = h.RestartCount
+	stats.LastCrashAt = h.LastCrashAt
+	stats.LastCrashReason = h.LastCrashReason
+}
+
+// recordUnexpectedExit remembers why cmd exited, from its exit status and the
+// last lines it wrote to stderr (or to any stream if it wrote nothing to
+// stderr). Caller must hold b.mu.
+func (b *BaseMiner) recordUnexpectedExit(cmd *exec.Cmd, waitErr error) {
+	exit := &ProcessExit{At: time.Now(), ExitCode: -1, Reason: "exit status 0"}
+	if cmd.ProcessState != nil {
+		exit.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if waitErr != nil {
+		exit.Reason = waitErr.Error()
+	}
+	if b.LogBuffer != nil {
+		if tail := outputTail(b.LogBuffer.GetEntries(), crashOutputLines); tail != "" {
+			exit.Reason += ": " + tail
+		}
+	}
+	b.lastExit = exit
+}
+
+// outputTail joins the last n stderr lines of entries, falling back to the
+// last n lines of any stream.
+func outputTail(entries []LogEntry, n int) string {
+	var lines []string
+	for _, stream := range []LogStream{LogStreamStderr, ""} {
+		lines = lines[:0]
+		for i := len(entries) - 1; i >= 0 && len(lines) < n; i-- {
+			if stream != "" && entries[i].Stream != stream {
+				continue
+			}
+			if text := strings.TrimSpace(ansiEscapeRegex.ReplaceAllString(entries[i].Text, "")); text != "" {
+				lines = append([]string{text}, lines...)
+			}
+		}
+		if len(lines) > 0 {
+			break
+		}
+	}
+	return strings.Join(lines, " | ")
+}
+
+// LastExit returns how the process last exited without being stopped, or nil.
+func (b *BaseMiner) LastExit() *ProcessExit {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.lastExit == nil {
+		return nil
+	}
+	exit := *b.lastExit
+	return &exit
+}
+
+// minerHistory returns the history of a miner, creating it. Caller must hold m.mu.
+func (m *Manager) minerHistory(name string) *MinerHistory {
+	if m.histories == nil {
+		m.histories = make(map[string]*MinerHistory)
+	}
+	history, ok := m.histories[name]
+	if !ok {
+		history = &MinerHistory{Name: name, Crashes: []ProcessExit{}}
+		if launch, ok := m.launches[name]; ok {
+			history.StartedAt = launch.StartedAt
+		}
+		m.histories[name] = history
+	}
+	return history
+}
+
+// GetMinerHistory returns the crash and restart history of a miner.
+func (m *Manager) GetMinerHistory(name string) (*MinerHistory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.miners[name]; !ok {
+		return nil, ErrMinerNotFound(name)
+	}
+	history := *m.minerHistory(name)
+	history.Crashes = append([]ProcessExit(nil), history.Crashes...)
+	return &history, nil
+}
+
+// restartCount returns how many times a miner was restarted after crashing.
+func (m *Manager) restartCount(name string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if history, ok := m.histories[name]; ok {
+		return history.RestartCount
+	}
+	return 0
+}
+
+// superviseCrash records the crash of a miner whose process exited without
+// being stopped, emits an error event and, if its config asks for it,
+// restarts it with the config it was started with. Restarts back off
+// exponentially, and a miner that crashes maxConsecutiveRestarts times in a
+// row is left errored.
+func (m *Manager) superviseCrash(name string, statsErr error) {
+	m.mu.Lock()
+	miner, ok := m.miners[name]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	exit := ProcessExit{At: time.Now(), ExitCode: -1, Reason: statsErr.Error()}
+	if reporter, ok := miner.(exitReporter); ok {
+		if last := reporter.LastExit(); last != nil {
+			exit = *last
+		}
+	}
+	history := m.minerHistory(name)
+	history.recordCrash(exit)
+	launch := m.launches[name]
+	restart := launch != nil && launch.Config.RestartOnCrash
+	gaveUp := false
+	var delay time.Duration
+	if restart {
+		if !history.lastRestartAt.IsZero() && exit.At.Sub(history.lastRestartAt) >= crashRestartResetAfter {
+			history.consecutiveRestarts = 0
+		}
+		if history.consecutiveRestarts >= maxConsecutiveRestarts {
+			restart, gaveUp = false, true
+		} else {
+			delay = crashRestartBackoff(history.consecutiveRestarts)
+		}
+	}
+	m.mu.Unlock()
+
+	logging.Warn("miner process exited unexpectedly", logging.Fields{"miner": name, "exitCode": exit.ExitCode, "reason": exit.Reason})
+	m.emitEvent(EventMinerError, MinerEventData{
+		Name:   name,
+		Reason: "process exited",
+		Error:  exit.Reason,
+	})
+
+	switch {
+	case gaveUp:
+		logging.Warn("miner keeps crashing, leaving it errored", logging.Fields{"miner": name, "restarts": maxConsecutiveRestarts})
+		m.emitEvent(EventMinerError, MinerEventData{
+			Name:   name,
+			Reason: fmt.Sprintf("not restarted after %d consecutive crashes", maxConsecutiveRestarts),
+		})
+	case restart && delay <= 0:
+		m.restartCrashedMiner(name, miner)
+	case restart:
+		time.AfterFunc(delay, func() { m.restartCrashedMiner(name, miner) })
+	}
+}
+
+// restartCrashedMiner starts an errored miner again with its launch config,
+// unless it was stopped or replaced in the meantime.
+func (m *Manager) restartCrashedMiner(name string, crashed Miner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case <-m.stopChan:
+		return
+	default:
+	}
+	miner, ok := m.miners[name]
+	launch := m.launches[name]
+	if !ok || miner != crashed || launch == nil || m.states[name] != MinerStateErrored {
+		return
+	}
+
+	config := launch.Config
+	if err := miner.Start(&config); err != nil {
+		logging.Error("failed to restart crashed miner", logging.Fields{"miner": name, "error": err})
+		m.emitEvent(EventMinerError, MinerEventData{
+			Name:      name,
+			ProfileID: launch.ProfileID,
+			Reason:    "restart after crash failed",
+			Error:     err.Error(),
+		})
+		return
+	}
+	m.transitionMinerState(name, MinerStateStarting)
+	launch.Unhealthy = false
+	launch.statsFailures = 0
+	launch.hugePagesWarned = false
+
+	history := m.minerHistory(name)
+	history.RestartCount++
+	history.consecutiveRestarts++
+	history.lastRestartAt = time.Now()
+	logging.Info("restarted crashed miner", logging.Fields{"miner": name, "restarts": history.RestartCount})
+	m.emitEvent(EventMinerStarted, MinerEventData{
+		Name:      name,
+		ProfileID: launch.ProfileID,
+		Reason:    fmt.Sprintf("restarted after crash (restart %d)", history.RestartCount),
+	})
+}
@@ -0,0 +1,188 @@
+package mining
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOutputTail(t *testing.T) {
+	entries := []LogEntry{
+		{Text: "starting", Stream: LogStreamStdout},
+		{Text: "\x1b[31mbad pool address\x1b[0m", Stream: LogStreamStderr},
+		{Text: "job received", Stream: LogStreamStdout},
+		{Text: "fatal: out of memory", Stream: LogStreamStderr},
+	}
+	if got := outputTail(entries, 5); got != "bad pool address | fatal: out of memory" {
+		t.Errorf("expected the stderr tail, got %q", got)
+	}
+	if got := outputTail(entries[:1], 5); got != "starting" {
+		t.Errorf("expected to fall back to any stream, got %q", got)
+	}
+	if got := outputTail(entries, 1); got != "fatal: out of memory" {
+		t.Errorf("expected only the last line, got %q", got)
+	}
+}
+
+func TestRecordUnexpectedExit(t *testing.T) {
+	b := &BaseMiner{LogBuffer: NewLogBuffer(10)}
+	b.cmd = exec.Command("sh", "-c", "echo 'cannot connect' >&2; exit 3")
+	b.cmd.Stderr = b.LogBuffer.Stream(LogStreamStderr)
+	err := b.cmd.Run()
+
+	b.recordUnexpectedExit(b.cmd, err)
+	exit := b.LastExit()
+	if exit == nil || exit.ExitCode != 3 || exit.Reason != "exit status 3: cannot connect" {
+		t.Errorf("unexpected exit record %+v", exit)
+	}
+}
+
+func TestCrashSupervisorRestarts(t *testing.T) {
+	originalDelay, originalRestartDelay := statsRetryDelay, crashRestartDelay
+	statsRetryDelay, crashRestartDelay = time.Millisecond, 0
+	defer func() { statsRetryDelay, crashRestartDelay = originalDelay, originalRestartDelay }()
+
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	started, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet", RestartOnCrash: true})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	miner := started.(*SimulatedMiner)
+	name := miner.GetName()
+	defer miner.Stop()
+
+	for i := 1; i <= 2; i++ {
+		miner.Stop()
+		m.collectSingleMinerStats(miner, MinerTypeSimulated, time.Now(), false)
+		if _, err := miner.GetStats(context.Background()); err != nil {
+			t.Fatalf("expected the crashed miner to be restarted")
+		}
+		if state, _ := m.GetMinerState(name); state != MinerStateStarting {
+			t.Fatalf("expected a restarted miner to be starting, got %s", state)
+		}
+		history, err := m.GetMinerHistory(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if history.RestartCount != i || len(history.Crashes) != i || history.LastCrashAt == nil {
+			t.Fatalf("unexpected history after crash %d: %+v", i, history)
+		}
+		if !strings.Contains(history.LastCrashReason, "is not running") {
+			t.Errorf("expected the stats error as the reason, got %q", history.LastCrashReason)
+		}
+	}
+
+	// The stats endpoint includes the restart count
+	router := gin.New()
+	service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/miners/"+name+"/stats", nil))
+	var stats PerformanceMetrics
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil || stats.RestartCount != 2 || stats.LastCrashAt == nil {
+		t.Errorf("expected the restart count in stats, got %d %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/miners/"+name+"/history", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"restartCount":2`) {
+		t.Errorf("unexpected history response %d %s", w.Code, w.Body.String())
+	}
+
+	// An explicit stop and start begins a clean history
+	if err := m.StopMiner(context.Background(), name); err != nil {
+		t.Fatalf("StopMiner failed: %v", err)
+	}
+	restarted, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet", RestartOnCrash: true})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	defer restarted.Stop()
+	if history, err := m.GetMinerHistory(restarted.GetName()); err != nil || history.RestartCount != 0 || len(history.Crashes) != 0 {
+		t.Errorf("expected a clean history after an explicit restart, got %+v %v", history, err)
+	}
+}
+
+func TestCrashWithoutRestart(t *testing.T) {
+	originalDelay := statsRetryDelay
+	statsRetryDelay = time.Millisecond
+	defer func() { statsRetryDelay = originalDelay }()
+
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	started, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet"})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	miner := started.(*SimulatedMiner)
+	defer miner.Stop()
+	miner.Stop()
+	m.collectSingleMinerStats(miner, MinerTypeSimulated, time.Now(), false)
+
+	if state, _ := m.GetMinerState(miner.GetName()); state != MinerStateErrored {
+		t.Errorf("expected the crashed miner to stay errored, got %s", state)
+	}
+	history, _ := m.GetMinerHistory(miner.GetName())
+	if history.RestartCount != 0 || len(history.Crashes) != 1 {
+		t.Errorf("expected one crash and no restarts, got %+v", history)
+	}
+}
+
+func TestCrashRestartBackoff(t *testing.T) {
+	originalRestartDelay := crashRestartDelay
+	crashRestartDelay = time.Second
+	defer func() { crashRestartDelay = originalRestartDelay }()
+
+	for consecutive, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+		if got := crashRestartBackoff(consecutive); got != want {
+			t.Errorf("backoff after %d restarts: expected %v, got %v", consecutive, want, got)
+		}
+	}
+	if got := crashRestartBackoff(100); got != maxCrashRestartDelay {
+		t.Errorf("expected the backoff capped at %v, got %v", maxCrashRestartDelay, got)
+	}
+}
+
+func TestCrashSupervisorGivesUp(t *testing.T) {
+	originalDelay, originalRestartDelay := statsRetryDelay, crashRestartDelay
+	statsRetryDelay, crashRestartDelay = time.Millisecond, time.Millisecond
+	defer func() { statsRetryDelay, crashRestartDelay = originalDelay, originalRestartDelay }()
+
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	started, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet", RestartOnCrash: true})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	miner := started.(*SimulatedMiner)
+	name := miner.GetName()
+	defer miner.Stop()
+
+	for i := 0; i <= maxConsecutiveRestarts; i++ {
+		miner.Stop()
+		m.collectSingleMinerStats(miner, MinerTypeSimulated, time.Now(), false)
+		if i == maxConsecutiveRestarts {
+			break
+		}
+		// The restart waits out its backoff
+		deadline := time.Now().Add(5 * time.Second)
+		for state, _ := m.GetMinerState(name); state != MinerStateStarting; state, _ = m.GetMinerState(name) {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected restart %d after its backoff, still %s", i+1, state)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if state, _ := m.GetMinerState(name); state != MinerStateErrored {
+		t.Errorf("expected the miner left errored after %d restarts, got %s", maxConsecutiveRestarts, state)
+	}
+	if history, _ := m.GetMinerHistory(name); history.RestartCount != maxConsecutiveRestarts {
+		t.Errorf("expected %d restarts, got %d", maxConsecutiveRestarts, history.RestartCount)
+	}
+}
@@ -0,0 +1,74 @@
+package mining
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// installExpansionFactor estimates how much disk an extracted miner needs
+// relative to the size of its downloaded archive.
+const installExpansionFactor = 3
+
+// diskFreeBytes returns the free space on the filesystem containing path.
+// It is a variable so tests can simulate a full disk.
+var diskFreeBytes = func(path string) (uint64, error) {
+	usage, err := disk.Usage(existingAncestor(path))
+	if err != nil {
+		return 0, err
+	}
+	return usage.Free, nil
+}
+
+// checkDiskSpace returns an install error naming the path and shortfall if the
+// filesystem containing path has less than required bytes free. If free space
+// can't be determined the check is skipped rather than blocking the install.
+func checkDiskSpace(minerType, path string, required uint64) error {
+	if required == 0 {
+		return nil
+	}
+	free, err := diskFreeBytes(path)
+	if err != nil {
+		return nil
+	}
+	if free >= required {
+		return nil
+	}
+	spaceErr := ErrInstallFailed(minerType).
+		WithDetails(fmt.Sprintf("insufficient disk space at %s: need %s, %s available (short by %s)",
+			path, formatByteSize(required), formatByteSize(free), formatByteSize(required-free))).
+		WithSuggestion("Free up disk space or set MINING_INSTALL_DIR / MINING_STAGING_DIR to a disk with more room")
+	spaceErr.Retryable = false
+	return spaceErr
+}
+
+// existingAncestor returns path, or its nearest parent that exists, so free
+// space can be checked before the install directory is created.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// formatByteSize formats a byte count using binary units, e.g. "12.5 MiB".
+func formatByteSize(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,89 @@
+package mining
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{512, "512 B"},
+		{2048, "2.0 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatByteSize(tt.n); got != tt.want {
+			t.Errorf("formatByteSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	orig := diskFreeBytes
+	defer func() { diskFreeBytes = orig }()
+	diskFreeBytes = func(string) (uint64, error) { return 1024, nil }
+
+	if err := checkDiskSpace("xmrig", "/data", 512); err != nil {
+		t.Errorf("expected enough space, got %v", err)
+	}
+
+	err := checkDiskSpace("xmrig", "/data", 4096)
+	var miningErr *MiningError
+	if !errors.As(err, &miningErr) || miningErr.Code != ErrCodeInstallFailed {
+		t.Fatalf("expected install failed error, got %v", err)
+	}
+	if !strings.Contains(miningErr.Details, "/data") || !strings.Contains(miningErr.Details, "short by 3.0 KiB") {
+		t.Errorf("details should name the path and shortfall, got %q", miningErr.Details)
+	}
+	if miningErr.IsRetryable() {
+		t.Error("insufficient disk space should not be retryable")
+	}
+
+	// Unknown free space does not block installs
+	diskFreeBytes = func(string) (uint64, error) { return 0, errors.New("unsupported") }
+	if err := checkDiskSpace("xmrig", "/data", 4096); err != nil {
+		t.Errorf("expected check to be skipped, got %v", err)
+	}
+}
+
+func TestInstallFromURLInsufficientSpace(t *testing.T) {
+	orig := diskFreeBytes
+	defer func() { diskFreeBytes = orig }()
+	diskFreeBytes = func(string) (uint64, error) { return 10, nil }
+
+	SetPathsConfig(PathsConfig{InstallRoot: t.TempDir(), StagingDir: t.TempDir()})
+	defer SetPathsConfig(PathsConfig{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 1024))
+	}))
+	defer server.Close()
+
+	miner := NewXMRigMiner()
+	err := miner.InstallFromURL(server.URL + "/xmrig.tar.gz")
+
+	var miningErr *MiningError
+	if !errors.As(err, &miningErr) || miningErr.Code != ErrCodeInstallFailed {
+		t.Fatalf("expected install failed error, got %v", err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(GetPathsConfig().StagingDir, "*"))
+	if len(matches) != 0 {
+		t.Errorf("nothing should be staged when space is insufficient, found %v", matches)
+	}
+}
+
+func TestExistingAncestor(t *testing.T) {
+	dir := t.TempDir()
+	if got := existingAncestor(filepath.Join(dir, "a", "b")); got != dir {
+		t.Errorf("expected %s, got %s", dir, got)
+	}
+}
@@ -0,0 +1,37 @@
+package mining
+
+// defaultDonateLevel is passed to miners when a config doesn't set one.
+const defaultDonateLevel = 1
+
+// effectiveDonateLevel returns the donate level a miner runs with for a config value.
+func effectiveDonateLevel(level int) int {
+	if level == 0 {
+		return defaultDonateLevel
+	}
+	return level
+}
+
+// DonateLevelPolicy sets guardrails on the donate level miners are started with,
+// regardless of what a profile requests. Zero means no floor / no ceiling.
+type DonateLevelPolicy struct {
+	Min int `json:"min,omitempty"`
+	Max int `json:"max,omitempty"`
+}
+
+// Clamp returns the donate level to use for a requested value and whether the
+// policy overrode it. An unset request (0) means the miner default, so it is
+// only replaced when the default itself falls outside the policy.
+func (p DonateLevelPolicy) Clamp(requested int) (int, bool) {
+	level := effectiveDonateLevel(requested)
+	clamped := level
+	if p.Min > 0 && clamped < p.Min {
+		clamped = p.Min
+	}
+	if p.Max > 0 && clamped > p.Max {
+		clamped = p.Max
+	}
+	if clamped == level {
+		return requested, false
+	}
+	return clamped, true
+}
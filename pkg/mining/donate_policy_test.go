@@ -0,0 +1,46 @@
+package mining
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDonateLevelPolicyClamp(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     DonateLevelPolicy
+		requested  int
+		want       int
+		overridden bool
+	}{
+		{"no policy", DonateLevelPolicy{}, 5, 5, false},
+		{"unset stays unset", DonateLevelPolicy{Max: 5}, 0, 0, false},
+		{"within range", DonateLevelPolicy{Min: 1, Max: 5}, 3, 3, false},
+		{"above ceiling", DonateLevelPolicy{Max: 2}, 10, 2, true},
+		{"below floor", DonateLevelPolicy{Min: 3}, 1, 3, true},
+		{"unset below floor", DonateLevelPolicy{Min: 3}, 0, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, overridden := tt.policy.Clamp(tt.requested)
+			if got != tt.want || overridden != tt.overridden {
+				t.Errorf("Clamp(%d) = (%d, %v), want (%d, %v)", tt.requested, got, overridden, tt.want, tt.overridden)
+			}
+		})
+	}
+}
+
+func TestAddCliArgsDonateLevel(t *testing.T) {
+	var args []string
+	addCliArgs(&Config{DonateLevel: 3}, &args)
+	if !strings.Contains(strings.Join(args, " "), "--donate-level 3") {
+		t.Errorf("expected requested donate level in args, got %v", args)
+	}
+
+	args = nil
+	addCliArgs(&Config{}, &args)
+	if !strings.Contains(strings.Join(args, " "), "--donate-level 1") {
+		t.Errorf("expected default donate level in args, got %v", args)
+	}
+}
@@ -0,0 +1,248 @@
+package mining
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// downloadPartPath returns where a download of url is staged. The name is
+// derived from the URL so a failed install resumes on the next attempt.
+func downloadPartPath(dir, name, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.part", name, sum[:8]))
+}
+
+// removePartial deletes a staged download and its validator.
+func removePartial(part string) {
+	os.Remove(part)
+	os.Remove(part + ".validator")
+}
+
+// partLockStale is how old a part file lock must be before it is taken to be
+// left over from a process that died mid-install.
+const partLockStale = time.Hour
+
+// lockPartial claims a staged download so concurrent installs of the same
+// URL don't write to one part file. Call the returned func once the part
+// file is no longer needed.
+func lockPartial(part string) (func(), error) {
+	lock := part + ".lock"
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lock); attempt == 0 && statErr == nil && time.Since(info.ModTime()) > partLockStale {
+			os.Remove(lock)
+			continue
+		}
+		return nil, fmt.Errorf("another install is already downloading %s", filepath.Base(part))
+	}
+}
+
+// errDownloadFatal marks download errors that retrying won't fix.
+type errDownloadFatal struct{ err error }
+
+func (e errDownloadFatal) Error() string { return e.err.Error() }
+func (e errDownloadFatal) Unwrap() error { return e.err }
+
+// downloadResumable downloads url into the part file, keeping what was
+// fetched across dropped connections. Each retry asks for the remaining
+// bytes with a Range request; servers that ignore ranges or report that the
+// file changed send it whole and the part file is restarted. checkSpace is
+// called with the total size, when known, and the bytes still to fetch
+// before anything is written. The completed file's size is verified against
+// the size the server reported.
+func downloadResumable(ctx context.Context, url, part string, checkSpace func(total, remaining int64) error) error {
+	profile := GetHTTPClientConfig().Download
+	client := getHTTPClient()
+	backoff := profile.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := downloadAttempt(ctx, client, url, part, checkSpace)
+		if err == nil {
+			return nil
+		}
+		var fatal errDownloadFatal
+		if errors.As(err, &fatal) {
+			return fatal.err
+		}
+		if attempt >= profile.Retries || ctx.Err() != nil {
+			return err
+		}
+		logging.Debug("retrying download", logging.Fields{"url": url, "attempt": attempt + 1, "error": err, "backoff": backoff})
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > httpRetryBackoffCap {
+			backoff = httpRetryBackoffCap
+		}
+	}
+}
+
+// downloadAttempt fetches what is missing from the part file with one request.
+// A part file is only resumed if the validator of the version it holds was
+// saved; without one there is no telling whether the file has changed since.
+func downloadAttempt(ctx context.Context, client *http.Client, url, part string, checkSpace func(total, remaining int64) error) error {
+	var offset int64
+	validator, _ := os.ReadFile(part + ".validator")
+	if info, err := os.Stat(part); err == nil && len(validator) > 0 {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errDownloadFatal{fmt.Errorf("failed to create request: %w", err)}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		// Only resume if the file is unchanged; otherwise the server sends it whole
+		req.Header.Set("If-Range", string(validator))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var total int64 = -1
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			_, _ = io.Copy(io.Discard, resp.Body) // Drain body to allow connection reuse (error ignored intentionally)
+			removePartial(part)
+			return fmt.Errorf("unexpected Content-Range %q for resume at byte %d", resp.Header.Get("Content-Range"), offset)
+		}
+		// A server that ignores If-Range may send the rest of a newer version
+		if current := responseValidator(resp.Header); current != "" && current != string(validator) {
+			_, _ = io.Copy(io.Discard, resp.Body) // Drain body to allow connection reuse (error ignored intentionally)
+			removePartial(part)
+			return fmt.Errorf("file changed on the server since byte %d was fetched", offset)
+		}
+		total = size
+		flags |= os.O_APPEND
+		logging.Debug("resuming download", logging.Fields{"url": url, "offset": offset})
+	case http.StatusOK:
+		offset = 0
+		total = resp.ContentLength
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		_, _ = io.Copy(io.Discard, resp.Body) // Drain body to allow connection reuse (error ignored intentionally)
+		// "bytes */size" matching the part file means it was already complete
+		if size, err := strconv.ParseInt(strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes */"), 10, 64); err == nil && size == offset {
+			return nil
+		}
+		// The part file doesn't fit the current file; start over
+		removePartial(part)
+		return fmt.Errorf("server rejected resume at byte %d", offset)
+	default:
+		_, _ = io.Copy(io.Discard, resp.Body) // Drain body to allow connection reuse (error ignored intentionally)
+		err := fmt.Errorf("failed to download release: unexpected status code %d", resp.StatusCode)
+		if !retryableStatus(resp.StatusCode) {
+			return errDownloadFatal{err}
+		}
+		return err
+	}
+
+	if total > 0 {
+		if err := checkSpace(total, total-offset); err != nil {
+			return errDownloadFatal{err}
+		}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		if err := saveValidator(part, resp.Header); err != nil {
+			return errDownloadFatal{err}
+		}
+	}
+
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return errDownloadFatal{err}
+	}
+	written, copyErr := io.Copy(f, resp.Body)
+	if err := f.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil {
+		return fmt.Errorf("download interrupted after %d bytes: %w", offset+written, copyErr)
+	}
+
+	if total >= 0 && offset+written != total {
+		return fmt.Errorf("download incomplete: got %d of %d bytes", offset+written, total)
+	}
+	return nil
+}
+
+// responseValidator returns the ETag, or failing that Last-Modified, that
+// identifies the version of the file a response is for.
+func responseValidator(header http.Header) string {
+	validator := header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		// Weak ETags can't be used with If-Range
+		validator = header.Get("Last-Modified")
+	}
+	return validator
+}
+
+// saveValidator records the validator of a fresh download so a resume only
+// appends to the same version of the file.
+func saveValidator(part string, header http.Header) error {
+	validator := responseValidator(header)
+	if validator == "" {
+		os.Remove(part + ".validator")
+		return nil
+	}
+	return os.WriteFile(part+".validator", []byte(validator), 0644)
+}
+
+// parseContentRange parses a "bytes start-end/size" header. size is -1 if
+// the server sent "*".
+func parseContentRange(header string) (start, size int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, sizeStr, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startStr, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if sizeStr == "*" {
+		return start, -1, true
+	}
+	size, err = strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
@@ -0,0 +1,256 @@
+package mining
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setDownloadRetries makes download retries fast for the test.
+func setDownloadRetries(t *testing.T, retries int) {
+	t.Helper()
+	orig := GetHTTPClientConfig()
+	config := orig
+	config.Download.Retries = retries
+	config.Download.RetryBackoff = time.Millisecond
+	SetHTTPClientConfig(config)
+	t.Cleanup(func() { SetHTTPClientConfig(orig) })
+}
+
+func noSpaceCheck(total, remaining int64) error { return nil }
+
+// newFlakyServer serves payload, dropping the connection after cut bytes on
+// the first request. With ranges it honours Range requests via ServeContent.
+func newFlakyServer(t *testing.T, payload []byte, cut int, ranges bool) (*httptest.Server, func() []string) {
+	t.Helper()
+	var requests atomic.Int32
+	var mu sync.Mutex
+	var rangeHeaders []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		rangeHeaders = append(rangeHeaders, r.Header.Get("Range"))
+		mu.Unlock()
+		if requests.Add(1) == 1 {
+			// Promise the whole file but hang up partway through
+			w.Header().Set("Content-Length", fmt.Sprint(len(payload)))
+			w.Header().Set("ETag", `"v1"`)
+			w.Write(payload[:cut])
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		if !ranges {
+			w.Write(payload)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "miner.tar.gz", time.Time{}, bytes.NewReader(payload))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), rangeHeaders...)
+	}
+}
+
+func TestDownloadResumable_ResumesWithRange(t *testing.T) {
+	setDownloadRetries(t, 2)
+	payload := bytes.Repeat([]byte("0123456789"), 1000)
+	server, rangeHeaders := newFlakyServer(t, payload, 3000, true)
+
+	part := filepath.Join(t.TempDir(), "miner.part")
+	if err := downloadResumable(context.Background(), server.URL, part, noSpaceCheck); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(part)
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("downloaded %d bytes that don't match the %d byte payload", len(got), len(payload))
+	}
+	if headers := rangeHeaders(); len(headers) != 2 || headers[1] != "bytes=3000-" {
+		t.Errorf("expected a resume from byte 3000, got range headers %q", headers)
+	}
+}
+
+func TestDownloadResumable_ServerIgnoresRange(t *testing.T) {
+	setDownloadRetries(t, 2)
+	payload := bytes.Repeat([]byte("abcdefghij"), 1000)
+	server, _ := newFlakyServer(t, payload, 4000, false)
+
+	part := filepath.Join(t.TempDir(), "miner.part")
+	if err := downloadResumable(context.Background(), server.URL, part, noSpaceCheck); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(part)
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("expected a full re-download of %d bytes, got %d", len(payload), len(got))
+	}
+}
+
+func TestDownloadResumable_GivesUpKeepingPart(t *testing.T) {
+	setDownloadRetries(t, 0)
+	payload := bytes.Repeat([]byte("x"), 5000)
+	server, _ := newFlakyServer(t, payload, 1000, true)
+
+	part := filepath.Join(t.TempDir(), "miner.part")
+	if err := downloadResumable(context.Background(), server.URL, part, noSpaceCheck); err == nil {
+		t.Fatal("expected the interrupted download to fail without retries")
+	}
+	if info, err := os.Stat(part); err != nil || info.Size() != 1000 {
+		t.Fatalf("expected the 1000 fetched bytes to be kept, got %v %v", info, err)
+	}
+	if validator, _ := os.ReadFile(part + ".validator"); string(validator) != `"v1"` {
+		t.Errorf("expected the ETag to be saved, got %q", validator)
+	}
+
+	// A later install picks up where this one stopped
+	if err := downloadResumable(context.Background(), server.URL, part, noSpaceCheck); err != nil {
+		t.Fatalf("resumed download failed: %v", err)
+	}
+	if got, _ := os.ReadFile(part); !bytes.Equal(got, payload) {
+		t.Fatalf("resumed file doesn't match the payload")
+	}
+}
+
+func TestDownloadResumable_NotFoundIsNotRetried(t *testing.T) {
+	setDownloadRetries(t, 3)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	err := downloadResumable(context.Background(), server.URL, filepath.Join(t.TempDir(), "miner.part"), noSpaceCheck)
+	if err == nil || !strings.Contains(err.Error(), "unexpected status code 404") {
+		t.Fatalf("expected a 404 error, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", requests.Load())
+	}
+}
+
+func TestDownloadResumable_AlreadyComplete(t *testing.T) {
+	setDownloadRetries(t, 0)
+	payload := []byte("complete archive")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "miner.tar.gz", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	part := filepath.Join(t.TempDir(), "miner.part")
+	os.WriteFile(part, payload, 0644)
+	os.WriteFile(part+".validator", []byte(`"v1"`), 0644)
+	if err := downloadResumable(context.Background(), server.URL, part, noSpaceCheck); err != nil {
+		t.Fatalf("expected a complete part file to be accepted, got %v", err)
+	}
+	if got, _ := os.ReadFile(part); !bytes.Equal(got, payload) {
+		t.Errorf("part file was modified: %q", got)
+	}
+}
+
+func TestDownloadResumable_RestartsWithoutValidator(t *testing.T) {
+	setDownloadRetries(t, 0)
+	payload := []byte("new archive contents")
+	var rangeHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader = r.Header.Get("Range")
+		http.ServeContent(w, r, "miner.tar.gz", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	// Without a validator the part file may hold another version of the file
+	part := filepath.Join(t.TempDir(), "miner.part")
+	os.WriteFile(part, []byte("old arc"), 0644)
+	if err := downloadResumable(context.Background(), server.URL, part, noSpaceCheck); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if rangeHeader != "" {
+		t.Errorf("expected no resume without a validator, got Range %q", rangeHeader)
+	}
+	if got, _ := os.ReadFile(part); !bytes.Equal(got, payload) {
+		t.Errorf("expected the file downloaded whole, got %q", got)
+	}
+}
+
+func TestDownloadResumable_RestartsWhenValidatorChanges(t *testing.T) {
+	setDownloadRetries(t, 1)
+	payload := []byte("version two of the archive")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignores If-Range, as some servers do
+		r.Header.Del("If-Range")
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "miner.tar.gz", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	part := filepath.Join(t.TempDir(), "miner.part")
+	os.WriteFile(part, []byte("version one"), 0644)
+	os.WriteFile(part+".validator", []byte(`"v1"`), 0644)
+	if err := downloadResumable(context.Background(), server.URL, part, noSpaceCheck); err != nil {
+		t.Fatalf("download failed: %v", err)
+	}
+	if got, _ := os.ReadFile(part); !bytes.Equal(got, payload) {
+		t.Errorf("expected the new version downloaded whole, got %q", got)
+	}
+}
+
+func TestLockPartial(t *testing.T) {
+	part := filepath.Join(t.TempDir(), "miner.part")
+	unlock, err := lockPartial(part)
+	if err != nil {
+		t.Fatalf("lockPartial failed: %v", err)
+	}
+	if _, err := lockPartial(part); err == nil {
+		t.Error("expected a second install to be refused the part file")
+	}
+	unlock()
+	unlock, err = lockPartial(part)
+	if err != nil {
+		t.Fatalf("expected the part file free after unlock, got %v", err)
+	}
+	unlock()
+
+	// A lock left by a process that died is taken over once stale
+	os.WriteFile(part+".lock", []byte("1\n"), 0644)
+	old := time.Now().Add(-2 * partLockStale)
+	os.Chtimes(part+".lock", old, old)
+	unlock, err = lockPartial(part)
+	if err != nil {
+		t.Fatalf("expected a stale lock to be taken over, got %v", err)
+	}
+	unlock()
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header      string
+		start, size int64
+		ok          bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-9/*", 0, -1, true},
+		{"bytes */200", 0, 0, false},
+		{"items 0-9/10", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, size, ok := parseContentRange(tt.header)
+		if ok != tt.ok || (ok && (start != tt.start || size != tt.size)) {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v", tt.header, start, size, ok)
+		}
+	}
+}
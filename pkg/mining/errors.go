@@ -1,8 +1,11 @@
 package mining
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // Error codes for the mining package
@@ -23,8 +26,25 @@ const (
 	ErrCodeDatabaseError      = "DATABASE_ERROR"
 	ErrCodeProfileNotFound    = "PROFILE_NOT_FOUND"
 	ErrCodeProfileExists      = "PROFILE_EXISTS"
+	ErrCodeVersionNotFound    = "PROFILE_VERSION_NOT_FOUND"
+	ErrCodeTemplateNotFound   = "TEMPLATE_NOT_FOUND"
+	ErrCodeTemplateInUse      = "TEMPLATE_IN_USE"
+	ErrCodeBinaryIntegrity    = "BINARY_INTEGRITY_FAILED"
+	ErrCodeAdminRequired      = "ADMIN_REQUIRED"
+	ErrCodeSetupComplete      = "SETUP_COMPLETE"
+	ErrCodeConfigInvalid      = "CONFIG_INVALID"
 	ErrCodeInternalError      = "INTERNAL_ERROR"
 	ErrCodeInternal           = "INTERNAL_ERROR" // Alias for consistency
+
+	// Node/P2P error codes
+	ErrCodePeerNotFound        = "PEER_NOT_FOUND"
+	ErrCodePeerExists          = "PEER_EXISTS"
+	ErrCodePeerNotConnected    = "PEER_NOT_CONNECTED"
+	ErrCodeHandshakeFailed     = "HANDSHAKE_FAILED"
+	ErrCodeTransportNotStarted = "TRANSPORT_NOT_STARTED"
+	ErrCodeNodeNotInitialized  = "NODE_NOT_INITIALIZED"
+	ErrCodeNodeIdentityExists  = "NODE_IDENTITY_EXISTS"
+	ErrCodeRemoteCommandFailed = "REMOTE_COMMAND_FAILED"
 )
 
 // MiningError is a structured error type for the mining package
@@ -104,6 +124,17 @@ func ErrMinerNotFound(name string) *MiningError {
 	}
 }
 
+// ErrAmbiguousMinerName creates an error for a base name that matches several running miners
+func ErrAmbiguousMinerName(name string, matches []string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeInvalidInput,
+		Message:    fmt.Sprintf("'%s' matches several miners: %s", name, strings.Join(matches, ", ")),
+		Suggestion: "Use the full instance name of the miner",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
 // ErrMinerExists creates a miner already exists error
 func ErrMinerExists(name string) *MiningError {
 	return &MiningError{
@@ -170,6 +201,62 @@ func ErrInvalidConfig(reason string) *MiningError {
 	}
 }
 
+// ErrInvalidInput creates an invalid request input error
+func ErrInvalidInput(message string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeInvalidInput,
+		Message:    message,
+		Suggestion: "Verify the request body matches the expected format",
+		Retryable:  false,
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+// ErrRequestTooLarge creates an error for a request body over the size limit
+func ErrRequestTooLarge(limit int64) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeInvalidInput,
+		Message:    fmt.Sprintf("request body exceeds the %d byte limit", limit),
+		Suggestion: "Reduce the payload size, for example by splitting it into smaller requests, or raise MINING_MAX_BODY_BYTES or MINING_MAX_UPLOAD_BYTES",
+		Retryable:  false,
+		HTTPStatus: http.StatusRequestEntityTooLarge,
+	}
+}
+
+// ErrAdminRequired creates an error for an admin-only endpoint called without admin rights
+func ErrAdminRequired() *MiningError {
+	return &MiningError{
+		Code:       ErrCodeAdminRequired,
+		Message:    "this is only available locally or with API authentication",
+		Suggestion: "Complete first-run setup (POST /setup) or set MINING_API_AUTH=true with MINING_API_USER and MINING_API_PASS to manage it remotely",
+		Retryable:  false,
+		HTTPStatus: http.StatusForbidden,
+	}
+}
+
+// ErrSetupComplete creates an error for first-run setup attempted after an admin credential exists
+func ErrSetupComplete() *MiningError {
+	return &MiningError{
+		Code:       ErrCodeSetupComplete,
+		Message:    "setup has already been completed",
+		Suggestion: "Authenticate with the admin credential",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ErrConfigInvalid creates an error for a configuration that failed to load or validate
+func ErrConfigInvalid(err error) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeConfigInvalid,
+		Message:    fmt.Sprintf("invalid configuration: %v", err),
+		Suggestion: "Fix the config or secrets file and try again; the current settings are still in use",
+		Retryable:  false,
+		HTTPStatus: http.StatusInternalServerError,
+		Cause:      err,
+	}
+}
+
 // ErrUnsupportedMiner creates an unsupported miner type error
 func ErrUnsupportedMiner(minerType string) *MiningError {
 	return &MiningError{
@@ -236,6 +323,161 @@ func ErrProfileExists(name string) *MiningError {
 	}
 }
 
+// ErrProfileVersionNotFound creates an error for a profile version that isn't in its history
+func ErrProfileVersionNotFound(id string, version int) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeVersionNotFound,
+		Message:    fmt.Sprintf("profile '%s' has no version %d", id, version),
+		Suggestion: "List the profile's history for the versions still kept",
+		Retryable:  false,
+		HTTPStatus: http.StatusNotFound,
+	}
+}
+
+// ErrTemplateNotFound creates a mining template not found error
+func ErrTemplateNotFound(id string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeTemplateNotFound,
+		Message:    fmt.Sprintf("template '%s' not found", id),
+		Suggestion: "Check that the template ID is correct",
+		Retryable:  false,
+		HTTPStatus: http.StatusNotFound,
+	}
+}
+
+// ErrTemplateInUse creates an error for deleting a template profiles still reference
+func ErrTemplateInUse(id string, profiles int) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeTemplateInUse,
+		Message:    fmt.Sprintf("template '%s' is used by %d profile(s)", id, profiles),
+		Suggestion: "Remove the template from those profiles first",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ErrPeerNotFound creates a peer not found error
+func ErrPeerNotFound(id string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodePeerNotFound,
+		Message:    fmt.Sprintf("peer '%s' not found", id),
+		Suggestion: "Check the peer ID or add the peer first",
+		Retryable:  false,
+		HTTPStatus: http.StatusNotFound,
+	}
+}
+
+// ErrPeerExists creates an error for adding a peer that is already registered
+func ErrPeerExists(id string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodePeerExists,
+		Message:    fmt.Sprintf("peer '%s' already exists", id),
+		Suggestion: "Update or remove the existing peer instead",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ErrPeerNotConnected creates a peer not connected error
+func ErrPeerNotConnected(id string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodePeerNotConnected,
+		Message:    fmt.Sprintf("peer '%s' is not connected", id),
+		Suggestion: fmt.Sprintf("Connect to the peer first with POST /peers/%s/connect", id),
+		Retryable:  true,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ErrHandshakeFailed creates a handshake failed error
+func ErrHandshakeFailed(id string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeHandshakeFailed,
+		Message:    fmt.Sprintf("handshake with peer '%s' failed", id),
+		Suggestion: "Check that the peer is running a compatible version and allows this node",
+		Retryable:  false,
+		HTTPStatus: http.StatusBadGateway,
+	}
+}
+
+// ErrTransportNotStarted creates a transport not started error
+func ErrTransportNotStarted() *MiningError {
+	return &MiningError{
+		Code:       ErrCodeTransportNotStarted,
+		Message:    "P2P transport is not running",
+		Suggestion: "Start the node transport and try again",
+		Retryable:  true,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
+
+// ErrNodeNotInitialized creates a node identity not initialized error
+func ErrNodeNotInitialized() *MiningError {
+	return &MiningError{
+		Code:       ErrCodeNodeNotInitialized,
+		Message:    "node identity not initialized",
+		Suggestion: "Initialize the node identity with POST /node/init",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ErrNodeIdentityExists creates a node identity already exists error
+func ErrNodeIdentityExists() *MiningError {
+	return &MiningError{
+		Code:       ErrCodeNodeIdentityExists,
+		Message:    "node identity already exists",
+		Suggestion: "Use the existing identity",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ErrRemoteCommandFailed creates an error for a command rejected or failed by a remote peer
+func ErrRemoteCommandFailed(operation string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeRemoteCommandFailed,
+		Message:    fmt.Sprintf("remote %s failed", operation),
+		Suggestion: "Check the remote node's logs for details",
+		Retryable:  true,
+		HTTPStatus: http.StatusBadGateway,
+	}
+}
+
+// ErrBinaryIntegrity creates an error for a miner binary whose hash no longer
+// matches the one recorded at install time
+func ErrBinaryIntegrity(path string) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeBinaryIntegrity,
+		Message:    fmt.Sprintf("miner binary %s does not match the checksum recorded at install", path),
+		Suggestion: "Reinstall the miner; if the change was intentional set binaryVerification to \"warn\" in the miners config",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// ErrStartCircuitOpen creates an error for a start rejected because earlier
+// starts of the same profile or miner type kept failing
+func ErrStartCircuitOpen(key string, retryAfter time.Duration) *MiningError {
+	return &MiningError{
+		Code:       ErrCodeServiceUnavailable,
+		Message:    fmt.Sprintf("starts of %s are paused for %s after repeated failures", key, retryAfter.Round(time.Second)),
+		Suggestion: "Fix the miner config, then retry after the cooldown or reset the breaker via DELETE /miners/start-breakers/" + key,
+		Retryable:  true,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
+
+// asMiningError returns the MiningError in err's chain, or err wrapped as an
+// internal error.
+func asMiningError(err error) *MiningError {
+	var miningErr *MiningError
+	if errors.As(err, &miningErr) {
+		return miningErr
+	}
+	return ErrInternal(err.Error()).WithCause(err)
+}
+
 // ErrInternal creates a generic internal error
 func ErrInternal(message string) *MiningError {
 	return &MiningError{
@@ -115,6 +115,15 @@ func TestPredefinedErrors(t *testing.T) {
 		{"ErrProfileNotFound", ErrProfileNotFound("abc123"), ErrCodeProfileNotFound},
 		{"ErrProfileExists", ErrProfileExists("My Profile"), ErrCodeProfileExists},
 		{"ErrInternal", ErrInternal("unexpected error"), ErrCodeInternalError},
+		{"ErrPeerNotFound", ErrPeerNotFound("peer1"), ErrCodePeerNotFound},
+		{"ErrPeerExists", ErrPeerExists("peer1"), ErrCodePeerExists},
+		{"ErrPeerNotConnected", ErrPeerNotConnected("peer1"), ErrCodePeerNotConnected},
+		{"ErrHandshakeFailed", ErrHandshakeFailed("peer1"), ErrCodeHandshakeFailed},
+		{"ErrTransportNotStarted", ErrTransportNotStarted(), ErrCodeTransportNotStarted},
+		{"ErrNodeNotInitialized", ErrNodeNotInitialized(), ErrCodeNodeNotInitialized},
+		{"ErrNodeIdentityExists", ErrNodeIdentityExists(), ErrCodeNodeIdentityExists},
+		{"ErrRemoteCommandFailed", ErrRemoteCommandFailed("start"), ErrCodeRemoteCommandFailed},
+		{"ErrBinaryIntegrity", ErrBinaryIntegrity("/bin/xmrig"), ErrCodeBinaryIntegrity},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,145 @@
+package mining
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventVersion is the version of the event payloads sent on /ws/events, set
+// on every Event. The contract within a version:
+//   - fields may be added to a payload, and new event types may appear
+//   - fields are never removed, renamed or given a different type
+//
+// Anything else bumps the version. Clients should ignore unknown fields and
+// event types, and treat a version above the one they were built for as
+// incompatible, e.g. by falling back to polling the REST API.
+const EventVersion = 1
+
+// EventSchema describes every event type's payload for the current EventVersion.
+type EventSchema struct {
+	Version int               `json:"version"`
+	Events  []EventTypeSchema `json:"events"`
+}
+
+// EventTypeSchema describes one event type.
+type EventTypeSchema struct {
+	Type        EventType      `json:"type"`
+	Description string         `json:"description"`
+	Payload     []PayloadField `json:"payload"` // Empty if the event has no data
+}
+
+// PayloadField is one field of an event payload.
+type PayloadField struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // JSON type: string, integer, number, boolean, array or object
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// eventPayloads lists each event type with a value of its payload type.
+// Add new event types here so they appear in the schema.
+var eventPayloads = []struct {
+	eventType   EventType
+	description string
+	payload     interface{}
+}{
+	{EventMinerStarting, "A miner is being started", MinerEventData{}},
+	{EventMinerStarted, "A miner started or was registered", MinerEventData{}},
+	{EventMinerStopping, "A miner is being stopped", MinerEventData{}},
+	{EventMinerStopped, "A miner stopped", MinerEventData{}},
+	{EventMinerStats, "Periodic stats of a running miner", MinerStatsData{}},
+	{EventMinerError, "A miner failed to start or crashed", MinerEventData{}},
+	{EventMinerWarning, "A miner is running degraded, e.g. without huge pages", MinerEventData{}},
+	{EventMinerConnected, "A miner connected to its pool", MinerEventData{}},
+	{EventMinerUnhealthy, "A miner's stats API has failed repeatedly", MinerEventData{}},
+	{EventMinerHealthy, "A miner's stats API is answering again", MinerEventData{}},
+	{EventSettingsReset, "Settings were restored to defaults; refetch them", ResetEventData{}},
+	{EventConfigReset, "The miners config was emptied; refetch it", ResetEventData{}},
+	{EventStateSync, "Current state, sent on connect and when a resume gap is too large", stateSyncData{}},
+	{EventClientError, "A message from this client was rejected, e.g. a subscribe listing too many miners", ClientErrorData{}},
+	{EventPong, "Reply to a ping message", nil},
+}
+
+// stateSyncData documents the state.sync payload built by the service's state provider.
+type stateSyncData struct {
+	Miners []map[string]interface{} `json:"miners"`
+}
+
+// GetEventSchema returns the schema of the current event payloads.
+func GetEventSchema() EventSchema {
+	schema := EventSchema{Version: EventVersion, Events: make([]EventTypeSchema, 0, len(eventPayloads))}
+	for _, p := range eventPayloads {
+		schema.Events = append(schema.Events, EventTypeSchema{
+			Type:        p.eventType,
+			Description: p.description,
+			Payload:     payloadFields(p.payload),
+		})
+	}
+	return schema
+}
+
+// payloadFields lists the JSON fields of a payload struct.
+func payloadFields(payload interface{}) []PayloadField {
+	fields := []PayloadField{}
+	if payload == nil {
+		return fields
+	}
+	t := reflect.TypeOf(payload)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, PayloadField{
+			Name:     name,
+			Type:     jsonTypeName(field.Type),
+			Optional: strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonTypeName returns the JSON type a Go type is encoded as.
+func jsonTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// handleEventSchema godoc
+// @Summary Describe the WebSocket event payloads
+// @Description Returns the event payload version and, for each event type, its payload fields. Within a version fields are only added, never removed, renamed or retyped; clients should compare the version to the one they support.
+// @Tags websocket
+// @Produce json
+// @Success 200 {object} EventSchema
+// @Router /ws/events/schema [get]
+func (s *Service) handleEventSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, GetEventSchema())
+}
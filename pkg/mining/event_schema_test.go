@@ -0,0 +1,52 @@
+package mining
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetEventSchema(t *testing.T) {
+	schema := GetEventSchema()
+	if schema.Version != EventVersion {
+		t.Fatalf("Expected version %d, got %d", EventVersion, schema.Version)
+	}
+
+	byType := make(map[EventType]EventTypeSchema)
+	for _, e := range schema.Events {
+		byType[e.Type] = e
+	}
+	stats, ok := byType[EventMinerStats]
+	if !ok {
+		t.Fatal("miner.stats missing from schema")
+	}
+	want := map[string]PayloadField{
+		"name":      {Name: "name", Type: "string"},
+		"hashrate":  {Name: "hashrate", Type: "integer"},
+		"algorithm": {Name: "algorithm", Type: "string", Optional: true},
+	}
+	for _, field := range stats.Payload {
+		if w, ok := want[field.Name]; ok && field != w {
+			t.Errorf("Expected %+v, got %+v", w, field)
+		}
+		delete(want, field.Name)
+	}
+	if len(want) != 0 {
+		t.Errorf("fields missing from miner.stats: %v", want)
+	}
+	if pong := byType[EventPong]; pong.Payload == nil || len(pong.Payload) != 0 {
+		t.Errorf("pong should have an empty payload, got %+v", pong.Payload)
+	}
+}
+
+func TestEventVersionIsSet(t *testing.T) {
+	data, err := json.Marshal(NewEvent(EventMinerStarted, MinerEventData{Name: "xmrig-1"}))
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	var envelope struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Version != EventVersion {
+		t.Fatalf("Expected version %d in %s", EventVersion, data)
+	}
+}
@@ -0,0 +1,201 @@
+package mining
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// EventSink receives events from an EventHub in-process, alongside WebSocket clients.
+//
+// Contract: OnEvent must not block for long. Each sink is fed from its own buffered
+// queue, so a slow sink only delays itself; once its queue is full further events
+// for that sink are dropped. A panic inside OnEvent is recovered and logged.
+type EventSink interface {
+	OnEvent(event Event)
+}
+
+// EventSinkFunc adapts an ordinary function to the EventSink interface.
+type EventSinkFunc func(event Event)
+
+// OnEvent calls f(event).
+func (f EventSinkFunc) OnEvent(event Event) {
+	f(event)
+}
+
+// sinkQueueSize is the number of events buffered per sink before events are dropped
+const sinkQueueSize = 64
+
+// sinkWorker delivers queued events to a single sink on its own goroutine
+type sinkWorker struct {
+	sink      EventSink
+	events    chan Event
+	closeOnce sync.Once
+}
+
+func newSinkWorker(sink EventSink) *sinkWorker {
+	w := &sinkWorker{
+		sink:   sink,
+		events: make(chan Event, sinkQueueSize),
+	}
+	go w.run()
+	return w
+}
+
+func (w *sinkWorker) run() {
+	for event := range w.events {
+		w.deliver(event)
+	}
+}
+
+// deliver calls the sink, recovering from any panic so the worker keeps running
+func (w *sinkWorker) deliver(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("panic in event sink", logging.Fields{"panic": r, "type": event.Type})
+		}
+	}()
+	w.sink.OnEvent(event)
+}
+
+// enqueue queues an event without blocking. Returns false if the queue is full.
+func (w *sinkWorker) enqueue(event Event) bool {
+	select {
+	case w.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops the worker once its queued events are drained
+func (w *sinkWorker) close() {
+	w.closeOnce.Do(func() {
+		close(w.events)
+	})
+}
+
+// AddSink registers an in-process sink that receives every broadcast event.
+// The returned function removes the sink again and is safe to call multiple times.
+func (h *EventHub) AddSink(sink EventSink) (remove func()) {
+	w := newSinkWorker(sink)
+
+	h.mu.Lock()
+	h.sinks[w] = struct{}{}
+	h.mu.Unlock()
+
+	return func() {
+		h.mu.Lock()
+		delete(h.sinks, w)
+		h.mu.Unlock()
+		w.close()
+	}
+}
+
+// SinkCount returns the number of registered sinks
+func (h *EventHub) SinkCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.sinks)
+}
+
+// dispatchToSinks queues an event for every registered sink. Caller must hold h.mu.
+func (h *EventHub) dispatchToSinks(event Event) {
+	for w := range h.sinks {
+		if !w.enqueue(event) {
+			logging.Warn("event sink queue full, dropping event", logging.Fields{"type": event.Type})
+		}
+	}
+}
+
+// LoggingSink writes every event it receives to the application log.
+type LoggingSink struct{}
+
+// NewLoggingSink creates a sink that logs events at debug level
+func NewLoggingSink() *LoggingSink {
+	return &LoggingSink{}
+}
+
+// OnEvent logs the event type and payload
+func (s *LoggingSink) OnEvent(event Event) {
+	logging.Debug("event", logging.Fields{"type": event.Type, "data": event.Data})
+}
+
+// DefaultWebhookTimeout is the default time allowed for a webhook delivery
+const DefaultWebhookTimeout = 5 * time.Second
+
+// WebhookSink forwards events as JSON POST requests to an HTTP endpoint.
+type WebhookSink struct {
+	URL     string
+	Headers map[string]string
+	// Types restricts forwarding to the listed event types; empty forwards everything
+	Types  []EventType
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink that POSTs events to url.
+// A zero timeout uses DefaultWebhookTimeout.
+func NewWebhookSink(url string, timeout time.Duration, types ...EventType) *WebhookSink {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return &WebhookSink{
+		URL:    url,
+		Types:  types,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// OnEvent posts the event to the webhook URL. Failures are logged and dropped.
+func (s *WebhookSink) OnEvent(event Event) {
+	if !s.accepts(event.Type) {
+		return
+	}
+	if err := s.send(context.Background(), event); err != nil {
+		logging.Warn("webhook delivery failed", logging.Fields{"url": s.URL, "type": event.Type, "error": err})
+	}
+}
+
+func (s *WebhookSink) accepts(eventType EventType) bool {
+	if len(s.Types) == 0 {
+		return true
+	}
+	for _, t := range s.Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *WebhookSink) send(ctx context.Context, event Event) error {
+	body, err := MarshalJSON(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package mining
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEventHubSinkReceivesEvents(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	received := make(chan Event, 1)
+	remove := hub.AddSink(EventSinkFunc(func(e Event) {
+		received <- e
+	}))
+	defer remove()
+
+	if hub.SinkCount() != 1 {
+		t.Fatalf("Expected 1 sink, got %d", hub.SinkCount())
+	}
+
+	hub.Broadcast(NewEvent(EventMinerStarted, MinerEventData{Name: "sink-miner"}))
+
+	select {
+	case e := <-received:
+		if e.Type != EventMinerStarted {
+			t.Errorf("Expected %s, got %s", EventMinerStarted, e.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("sink did not receive event")
+	}
+
+	remove()
+	if hub.SinkCount() != 0 {
+		t.Errorf("Expected 0 sinks after remove, got %d", hub.SinkCount())
+	}
+}
+
+func TestEventHubSinkPanicIsolated(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	hub.AddSink(EventSinkFunc(func(e Event) {
+		panic("boom")
+	}))
+
+	received := make(chan Event, 2)
+	hub.AddSink(EventSinkFunc(func(e Event) {
+		received <- e
+	}))
+
+	hub.Broadcast(NewEvent(EventMinerStopped, MinerEventData{Name: "a"}))
+	hub.Broadcast(NewEvent(EventMinerStopped, MinerEventData{Name: "b"}))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatalf("healthy sink missed event %d", i)
+		}
+	}
+}
+
+func TestEventHubSlowSinkDoesNotBlock(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	block := make(chan struct{})
+	defer close(block)
+	hub.AddSink(EventSinkFunc(func(e Event) {
+		<-block
+	}))
+
+	received := make(chan Event, sinkQueueSize*4)
+	hub.AddSink(EventSinkFunc(func(e Event) {
+		received <- e
+	}))
+
+	// Overflow the blocked sink's queue, then send a marker event
+	for i := 0; i < sinkQueueSize+10; i++ {
+		hub.Broadcast(NewEvent(EventMinerStats, MinerStatsData{Name: "slow"}))
+	}
+	time.Sleep(50 * time.Millisecond)
+	hub.Broadcast(NewEvent(EventMinerStopped, MinerEventData{Name: "marker"}))
+
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-received:
+			if e.Type == EventMinerStopped {
+				return
+			}
+		case <-deadline:
+			t.Fatal("fast sink stalled behind blocked sink")
+		}
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	got := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("failed to decode webhook body: %v", err)
+		}
+		got <- e
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, time.Second, EventMinerError)
+
+	// Filtered out
+	sink.OnEvent(NewEvent(EventMinerStats, MinerStatsData{Name: "x"}))
+	select {
+	case <-got:
+		t.Fatal("webhook should not forward filtered event types")
+	default:
+	}
+
+	sink.OnEvent(NewEvent(EventMinerError, MinerEventData{Name: "x", Error: "crashed"}))
+	select {
+	case e := <-got:
+		if e.Type != EventMinerError {
+			t.Errorf("Expected %s, got %s", EventMinerError, e.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("webhook did not receive event")
+	}
+}
+
+func TestManagerSubscribeEvents(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	// No hub configured: unsubscribe is a harmless no-op
+	m.SubscribeEvents(EventSinkFunc(func(e Event) {}))()
+
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+	m.SetEventHub(hub)
+
+	received := make(chan Event, 1)
+	unsubscribe := m.SubscribeEvents(EventSinkFunc(func(e Event) {
+		received <- e
+	}))
+
+	m.emitEvent(EventMinerStarting, MinerEventData{Name: "sub-miner"})
+	select {
+	case e := <-received:
+		if e.Type != EventMinerStarting {
+			t.Errorf("Expected %s, got %s", EventMinerStarting, e.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber did not receive event")
+	}
+
+	unsubscribe()
+	if hub.SinkCount() != 0 {
+		t.Errorf("Expected 0 sinks after unsubscribe, got %d", hub.SinkCount())
+	}
+}
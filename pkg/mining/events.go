@@ -2,8 +2,13 @@ package mining
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Snider/Mining/pkg/logging"
 	"github.com/gorilla/websocket"
@@ -20,15 +25,25 @@ const (
 	EventMinerStopped   EventType = "miner.stopped"
 	EventMinerStats     EventType = "miner.stats"
 	EventMinerError     EventType = "miner.error"
+	EventMinerWarning   EventType = "miner.warning" // Degraded but still running, e.g. huge pages unavailable
 	EventMinerConnected EventType = "miner.connected"
+	EventMinerUnhealthy EventType = "miner.unhealthy" // Stats API failing repeatedly
+	EventMinerHealthy   EventType = "miner.healthy"   // Stats API answering again after being unhealthy
+
+	// Configuration events
+	EventSettingsReset EventType = "settings.reset" // Settings restored to defaults; clients should refetch
+	EventConfigReset   EventType = "config.reset"   // Miners config emptied; clients should refetch
 
 	// System events
-	EventPong      EventType = "pong"
-	EventStateSync EventType = "state.sync" // Initial state on connect/reconnect
+	EventPong        EventType = "pong"
+	EventStateSync   EventType = "state.sync"   // Initial state on connect/reconnect
+	EventClientError EventType = "client.error" // A client's message was rejected; sent to that client only
 )
 
 // Event represents a mining event that can be broadcast to clients
 type Event struct {
+	Seq       uint64      `json:"seq,omitempty"` // Broadcast sequence number; resume from the last one seen
+	Version   int         `json:"version"`       // Payload version, see EventVersion
 	Type      EventType   `json:"type"`
 	Timestamp time.Time   `json:"timestamp"`
 	Data      interface{} `json:"data,omitempty"`
@@ -36,13 +51,15 @@ type Event struct {
 
 // MinerStatsData contains stats data for a miner event
 type MinerStatsData struct {
-	Name        string `json:"name"`
-	Hashrate    int    `json:"hashrate"`
-	Shares      int    `json:"shares"`
-	Rejected    int    `json:"rejected"`
-	Uptime      int    `json:"uptime"`
-	Algorithm   string `json:"algorithm,omitempty"`
-	DiffCurrent int    `json:"diffCurrent,omitempty"`
+	Name         string     `json:"name"`
+	Hashrate     int        `json:"hashrate"`
+	Shares       int        `json:"shares"`
+	Rejected     int        `json:"rejected"`
+	Uptime       int        `json:"uptime"`
+	Algorithm    string     `json:"algorithm,omitempty"`
+	DiffCurrent  int        `json:"diffCurrent,omitempty"`
+	State        MinerState `json:"state,omitempty"`
+	RestartCount int        `json:"restartCount,omitempty"` // Times the crash supervisor restarted the miner
 }
 
 // MinerEventData contains basic miner event data
@@ -54,14 +71,119 @@ type MinerEventData struct {
 	Pool      string `json:"pool,omitempty"`
 }
 
-// wsClient represents a WebSocket client connection
+// ClientErrorData explains why a client's message was rejected
+type ClientErrorData struct {
+	Request string `json:"request"` // Type of the rejected message, e.g. "subscribe"
+	Error   string `json:"error"`
+}
+
+// ResetEventData describes a settings or config reset
+type ResetEventData struct {
+	Backup        string   `json:"backup,omitempty"` // Copy of the previous file, empty if there was none
+	StoppedMiners []string `json:"stoppedMiners,omitempty"`
+}
+
+// wsClient represents an event client connection: a WebSocket, or a
+// server-sent events stream when conn is nil (see ServeSSE)
 type wsClient struct {
-	conn      *websocket.Conn
-	send      chan []byte
-	hub       *EventHub
-	miners    map[string]bool // subscribed miners, "*" for all
-	minersMu  sync.RWMutex    // protects miners map from concurrent access
-	closeOnce sync.Once
+	conn         *websocket.Conn
+	send         chan []byte
+	hub          *EventHub
+	miners       map[string]bool    // subscribed miners, "*" for all
+	types        map[EventType]bool // subscribed event types, empty for all
+	client       string             // optional client identity (name/version) sent on subscribe
+	minersMu     sync.RWMutex       // protects miners, types and client from concurrent access
+	closeOnce    sync.Once
+	remoteAddr   string
+	connectedAt  time.Time
+	lastActivity atomic.Int64 // unix nanos of the last pong/subscribe/ping from the client
+	firstLiveSeq uint64       // seq of the first broadcast sent to the client; only used by the hub loop
+	closeMu      sync.Mutex   // protects closeCode and closeText
+	closeCode    int          // WebSocket close code the server closes with, 0 if the client went away
+	closeText    string
+	resumeFrom   uint64 // replay events after this seq on register instead of a state sync; set before registering
+}
+
+// maxClientIdentityLength bounds the client identity a WebSocket client can set, in bytes
+const maxClientIdentityLength = 128
+
+// truncateClientIdentity shortens a client identity to maxClientIdentityLength
+// bytes, cutting at a rune boundary so multi-byte characters stay whole.
+func truncateClientIdentity(client string) string {
+	if len(client) <= maxClientIdentityLength {
+		return client
+	}
+	cut := maxClientIdentityLength
+	for cut > 0 && !utf8.RuneStart(client[cut]) {
+		cut--
+	}
+	return client[:cut]
+}
+
+// WSClientInfo describes a connected WebSocket client for diagnostics
+type WSClientInfo struct {
+	Transport     string    `json:"transport"` // "websocket" or "sse"
+	Client        string    `json:"client,omitempty"`
+	RemoteAddr    string    `json:"remoteAddr"`
+	Subscriptions []string  `json:"subscriptions"`
+	ConnectedAt   time.Time `json:"connectedAt"`
+	LastActivity  time.Time `json:"lastActivity"`
+}
+
+// info returns a snapshot of the client's identity and subscriptions
+func (c *wsClient) info() WSClientInfo {
+	c.minersMu.RLock()
+	subscriptions := make([]string, 0, len(c.miners))
+	for m := range c.miners {
+		subscriptions = append(subscriptions, m)
+	}
+	client := c.client
+	c.minersMu.RUnlock()
+	sort.Strings(subscriptions)
+
+	transport := "websocket"
+	if c.conn == nil {
+		transport = "sse"
+	}
+	return WSClientInfo{
+		Transport:     transport,
+		Client:        client,
+		RemoteAddr:    c.remoteAddr,
+		Subscriptions: subscriptions,
+		ConnectedAt:   c.connectedAt,
+		LastActivity:  time.Unix(0, c.lastActivity.Load()),
+	}
+}
+
+// touch records client activity, resetting its idle timer
+func (c *wsClient) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// idleFor returns how long the client has been inactive
+func (c *wsClient) idleFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, c.lastActivity.Load()))
+}
+
+// setCloseReason records why the server is closing the connection, for the
+// close frame and the disconnect log. The first reason wins.
+func (c *wsClient) setCloseReason(code int, reason string) {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closeCode == 0 {
+		c.closeCode, c.closeText = code, reason
+	}
+}
+
+// closeReason returns the reason set by setCloseReason, or a normal closure
+// if the client disconnected by itself.
+func (c *wsClient) closeReason() (int, string) {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.closeCode == 0 {
+		return websocket.CloseNormalClosure, "client disconnected"
+	}
+	return c.closeCode, c.closeText
 }
 
 // safeClose closes the send channel exactly once to prevent panic on double close
@@ -102,11 +224,56 @@ type EventHub struct {
 
 	// State provider for sync on connect
 	stateProvider StateProvider
+
+	// Largest message a WebSocket client may send (see SetReadLimit)
+	readLimit int64
+
+	// Eviction policy (see SetEvictionPolicy)
+	idleTimeout      time.Duration
+	maxConnectionAge time.Duration
+
+	// In-process event sinks (see AddSink)
+	sinks map[*sinkWorker]struct{}
+
+	// Resume support: the last broadcast sequence number and the recent
+	// events a reconnecting client can catch up on. replayMu guards replay
+	// and replayed for long-polls, which read them outside Run.
+	seq        atomic.Uint64
+	replayMu   sync.Mutex
+	replay     []Event
+	replayed   chan struct{} // Closed and replaced each time an event is recorded
+	replaySize int
+	resume     chan resumeRequest
+}
+
+// resumeRequest asks the hub to replay events after lastSeq to a client
+type resumeRequest struct {
+	client  *wsClient
+	lastSeq uint64
 }
 
 // DefaultMaxConnections is the default maximum WebSocket connections
 const DefaultMaxConnections = 100
 
+// DefaultReplayBufferSize is how many recent events the hub keeps for clients resuming after a reconnect
+const DefaultReplayBufferSize = 256
+
+// DefaultWSReadLimit is the default largest message a WebSocket client may
+// send, enough for a subscribe listing MaxSubscribeMiners miners
+const DefaultWSReadLimit = 16 * 1024
+
+// MaxWSReadLimit bounds SetReadLimit
+const MaxWSReadLimit = 1024 * 1024
+
+// MaxSubscribeMiners is the most miners a client can subscribe to by name
+const MaxSubscribeMiners = 256
+
+// DefaultClientIdleTimeout is how long a client may go without activity before it is evicted
+const DefaultClientIdleTimeout = 5 * time.Minute
+
+// maxEvictionSweepInterval caps how often the hub checks for stale clients
+const maxEvictionSweepInterval = 30 * time.Second
+
 // NewEventHub creates a new EventHub with default settings
 func NewEventHub() *EventHub {
 	return NewEventHubWithOptions(DefaultMaxConnections)
@@ -119,25 +286,123 @@ func NewEventHubWithOptions(maxConnections int) *EventHub {
 	}
 	return &EventHub{
 		clients:        make(map[*wsClient]bool),
+		sinks:          make(map[*sinkWorker]struct{}),
 		broadcast:      make(chan Event, 256),
 		register:       make(chan *wsClient, 16),
 		unregister:     make(chan *wsClient, 16), // Buffered to prevent goroutine leaks on shutdown
+		resume:         make(chan resumeRequest, 16),
+		replayed:       make(chan struct{}),
 		stop:           make(chan struct{}),
 		maxConnections: maxConnections,
+		idleTimeout:    DefaultClientIdleTimeout,
+		readLimit:      DefaultWSReadLimit,
+		replaySize:     DefaultReplayBufferSize,
 	}
 }
 
+// SetReplayBufferSize sets how many recent events are kept for resuming
+// clients. Zero disables resume; clients then always get a full state sync.
+// Must be called before Run().
+func (h *EventHub) SetReplayBufferSize(size int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.replaySize = size
+}
+
+// SetReadLimit sets the largest message, in bytes, a WebSocket client may
+// send; larger ones close the connection. Values outside 1..MaxWSReadLimit
+// are clamped. Applies to clients connecting afterwards.
+func (h *EventHub) SetReadLimit(limit int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readLimit = max(1, min(limit, MaxWSReadLimit))
+}
+
+// SetEvictionPolicy configures when clients are evicted to free connection slots.
+// Clients idle longer than idleTimeout, or connected longer than maxAge, are closed.
+// A zero value disables that check. Must be called before Run().
+func (h *EventHub) SetEvictionPolicy(idleTimeout, maxAge time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.idleTimeout = idleTimeout
+	h.maxConnectionAge = maxAge
+}
+
+// evictionSweepInterval returns how often to check for stale clients, or 0 if eviction is disabled
+func (h *EventHub) evictionSweepInterval() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	interval := time.Duration(0)
+	for _, d := range []time.Duration{h.idleTimeout, h.maxConnectionAge} {
+		if d > 0 && (interval == 0 || d < interval) {
+			interval = d
+		}
+	}
+	interval /= 2
+	if interval > maxEvictionSweepInterval {
+		interval = maxEvictionSweepInterval
+	}
+	return interval
+}
+
+// evictStaleClients unregisters clients that exceeded the idle timeout or maximum age.
+// Eviction uses the normal unregister path so connection metrics stay correct.
+func (h *EventHub) evictStaleClients() {
+	now := time.Now()
+
+	h.mu.RLock()
+	stale := make(map[*wsClient]string)
+	for client := range h.clients {
+		switch {
+		case h.maxConnectionAge > 0 && now.Sub(client.connectedAt) > h.maxConnectionAge:
+			stale[client] = "maximum connection age reached"
+		case h.idleTimeout > 0 && client.idleFor(now) > h.idleTimeout:
+			stale[client] = "idle timeout"
+		}
+	}
+	h.mu.RUnlock()
+
+	for client, reason := range stale {
+		logging.Debug("evicting stale WebSocket client", logging.Fields{"connected_at": client.connectedAt, "reason": reason})
+		h.disconnect(client, websocket.CloseNormalClosure, reason)
+	}
+}
+
+// disconnect unregisters a client, closing its connection with code and reason.
+func (h *EventHub) disconnect(client *wsClient, code int, reason string) {
+	client.setCloseReason(code, reason)
+	go func() {
+		h.unregister <- client
+	}()
+}
+
 // Run starts the EventHub's main loop
 func (h *EventHub) Run() {
+	var sweep <-chan time.Time
+	if interval := h.evictionSweepInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		sweep = ticker.C
+	}
+
 	for {
 		select {
+		case <-sweep:
+			h.evictStaleClients()
+
 		case <-h.stop:
 			// Close all client connections
 			h.mu.Lock()
 			for client := range h.clients {
+				client.setCloseReason(websocket.CloseGoingAway, "server shutting down")
 				client.safeClose()
 				delete(h.clients, client)
 			}
+			for w := range h.sinks {
+				w.close()
+				delete(h.sinks, w)
+			}
 			h.mu.Unlock()
 			return
 
@@ -148,35 +413,16 @@ func (h *EventHub) Run() {
 			h.mu.Unlock()
 			logging.Debug("client connected", logging.Fields{"total": len(h.clients)})
 
-			// Send initial state sync if provider is set
-			if stateProvider != nil {
-				go func(c *wsClient) {
-					defer func() {
-						if r := recover(); r != nil {
-							logging.Error("panic in state sync goroutine", logging.Fields{"panic": r})
-						}
-					}()
-					state := stateProvider()
-					if state != nil {
-						event := Event{
-							Type:      EventStateSync,
-							Timestamp: time.Now(),
-							Data:      state,
-						}
-						data, err := MarshalJSON(event)
-						if err != nil {
-							logging.Error("failed to marshal state sync", logging.Fields{"error": err})
-							return
-						}
-						select {
-						case c.send <- data:
-						default:
-							// Client buffer full
-						}
-					}
-				}(client)
+			// Catch a resuming client up, or send initial state sync if provider is set
+			if client.resumeFrom > 0 {
+				h.replayTo(client, client.resumeFrom)
+			} else if stateProvider != nil {
+				go h.sendStateSync(client, stateProvider)
 			}
 
+		case req := <-h.resume:
+			h.replayTo(req.client, req.lastSeq)
+
 		case client := <-h.unregister:
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
@@ -185,10 +431,17 @@ func (h *EventHub) Run() {
 				// Decrement WebSocket connection metrics
 				RecordWSConnection(false)
 			}
+			total := len(h.clients)
 			h.mu.Unlock()
-			logging.Debug("client disconnected", logging.Fields{"total": len(h.clients)})
+			code, reason := client.closeReason()
+			logging.Debug("client disconnected", logging.Fields{"total": total, "code": code, "reason": reason})
 
 		case event := <-h.broadcast:
+			// Pongs answer one client's ping and aren't worth replaying
+			if event.Type != EventPong {
+				event.Seq = h.seq.Add(1)
+				h.recordForReplay(event)
+			}
 			data, err := MarshalJSON(event)
 			if err != nil {
 				logging.Error("failed to marshal event", logging.Fields{"error": err})
@@ -199,21 +452,142 @@ func (h *EventHub) Run() {
 			for client := range h.clients {
 				// Check if client is subscribed to this miner
 				if h.shouldSendToClient(client, event) {
+					if client.firstLiveSeq == 0 {
+						client.firstLiveSeq = event.Seq
+					}
 					select {
 					case client.send <- data:
 					default:
 						// Client buffer full, close connection
-						go func(c *wsClient) {
-							h.unregister <- c
-						}(client)
+						h.disconnect(client, websocket.CloseTryAgainLater, "too slow: send buffer full")
 					}
 				}
 			}
+			h.dispatchToSinks(event)
 			h.mu.RUnlock()
 		}
 	}
 }
 
+// sendStateSync sends a client the current state, tagged with the latest
+// sequence number so the client can resume from there after a reconnect
+func (h *EventHub) sendStateSync(c *wsClient, stateProvider StateProvider) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("panic in state sync goroutine", logging.Fields{"panic": r})
+		}
+	}()
+	event, ok := h.stateSyncEvent(stateProvider)
+	if !ok {
+		return
+	}
+	data, err := MarshalJSON(event)
+	if err != nil {
+		logging.Error("failed to marshal state sync", logging.Fields{"error": err})
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+		// Client buffer full
+	}
+}
+
+// stateSyncEvent builds a state.sync event tagged with the latest sequence
+// number, or false if the provider has no state.
+func (h *EventHub) stateSyncEvent(stateProvider StateProvider) (Event, bool) {
+	seq := h.seq.Load()
+	state := stateProvider()
+	if state == nil {
+		return Event{}, false
+	}
+	return Event{
+		Seq:       seq,
+		Version:   EventVersion,
+		Type:      EventStateSync,
+		Timestamp: time.Now(),
+		Data:      state,
+	}, true
+}
+
+// recordForReplay adds a broadcast event to the replay buffer and wakes
+// waiting long-polls. Called only from Run.
+func (h *EventHub) recordForReplay(event Event) {
+	if h.replaySize <= 0 {
+		return
+	}
+	h.replayMu.Lock()
+	defer h.replayMu.Unlock()
+	h.replay = append(h.replay, event)
+	if len(h.replay) > h.replaySize {
+		h.replay = h.replay[len(h.replay)-h.replaySize:]
+	}
+	close(h.replayed)
+	h.replayed = make(chan struct{})
+}
+
+// missedEvents returns the buffered events after lastSeq, or false if some
+// of them are no longer buffered (or lastSeq is from before a restart).
+// Caller must hold replayMu, or be Run, which is the only writer.
+func (h *EventHub) missedEvents(lastSeq uint64) ([]Event, bool) {
+	current := h.seq.Load()
+	if lastSeq > current {
+		return nil, false
+	}
+	if lastSeq == current {
+		return nil, true
+	}
+	if len(h.replay) == 0 || h.replay[0].Seq > lastSeq+1 {
+		return nil, false
+	}
+	// Sequence numbers in the buffer are contiguous
+	return h.replay[lastSeq+1-h.replay[0].Seq:], true
+}
+
+// replayTo sends a resuming client the events it missed, before any it has
+// already received live, or a full state sync if they are no longer buffered.
+// Called only from Run.
+func (h *EventHub) replayTo(client *wsClient, lastSeq uint64) {
+	h.mu.RLock()
+	_, registered := h.clients[client]
+	stateProvider := h.stateProvider
+	h.mu.RUnlock()
+	if !registered {
+		return
+	}
+
+	missed, ok := h.missedEvents(lastSeq)
+	if !ok {
+		logging.Debug("resume gap exceeds replay buffer, sending state sync", logging.Fields{"last_seq": lastSeq})
+		if stateProvider != nil {
+			go h.sendStateSync(client, stateProvider)
+		}
+		return
+	}
+
+	for _, event := range missed {
+		if client.firstLiveSeq != 0 && event.Seq >= client.firstLiveSeq {
+			break
+		}
+		if !h.shouldSendToClient(client, event) {
+			continue
+		}
+		data, err := MarshalJSON(event)
+		if err != nil {
+			logging.Error("failed to marshal event", logging.Fields{"error": err})
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+			// Client buffer full, close connection
+			h.disconnect(client, websocket.CloseTryAgainLater, "too slow: send buffer full")
+			return
+		}
+	}
+	logging.Debug("client resumed", logging.Fields{"last_seq": lastSeq, "replayed": len(missed)})
+}
+
 // shouldSendToClient checks if an event should be sent to a client
 func (h *EventHub) shouldSendToClient(client *wsClient, event Event) bool {
 	// Always send pong and system events
@@ -225,6 +599,10 @@ func (h *EventHub) shouldSendToClient(client *wsClient, event Event) bool {
 	client.minersMu.RLock()
 	defer client.minersMu.RUnlock()
 
+	if len(client.types) > 0 && event.Type != EventStateSync && !client.types[event.Type] {
+		return false
+	}
+
 	if client.miners == nil || len(client.miners) == 0 {
 		// No subscription filter, send all
 		return true
@@ -275,6 +653,9 @@ func (h *EventHub) Broadcast(event Event) {
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now()
 	}
+	if event.Version == 0 {
+		event.Version = EventVersion
+	}
 	select {
 	case h.broadcast <- event:
 	default:
@@ -282,6 +663,21 @@ func (h *EventHub) Broadcast(event Event) {
 	}
 }
 
+// Clients returns details of all connected clients, oldest connection first
+func (h *EventHub) Clients() []WSClientInfo {
+	h.mu.RLock()
+	clients := make([]WSClientInfo, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client.info())
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ConnectedAt.Before(clients[j].ConnectedAt)
+	})
+	return clients
+}
+
 // ClientCount returns the number of connected clients
 func (h *EventHub) ClientCount() int {
 	h.mu.RLock()
@@ -292,6 +688,7 @@ func (h *EventHub) ClientCount() int {
 // NewEvent creates a new event with the current timestamp
 func NewEvent(eventType EventType, data interface{}) Event {
 	return Event{
+		Version:   EventVersion,
 		Type:      eventType,
 		Timestamp: time.Now(),
 		Data:      data,
@@ -312,7 +709,8 @@ func (c *wsClient) writePump() {
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
 				// Hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				code, reason := c.closeReason()
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
 				return
 			}
 
@@ -345,9 +743,12 @@ func (c *wsClient) readPump() {
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(512)
+	c.hub.mu.RLock()
+	c.conn.SetReadLimit(c.hub.readLimit)
+	c.hub.mu.RUnlock()
 	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	c.conn.SetPongHandler(func(string) error {
+		c.touch()
 		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
@@ -355,7 +756,11 @@ func (c *wsClient) readPump() {
 	for {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				// The websocket package has already sent a message-too-big close frame
+				c.setCloseReason(websocket.CloseMessageTooBig, "message over read limit")
+				logging.Warn("WebSocket client sent a message over the read limit", logging.Fields{"remote": c.remoteAddr})
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				logging.Debug("WebSocket error", logging.Fields{"error": err})
 			}
 			break
@@ -363,25 +768,47 @@ func (c *wsClient) readPump() {
 
 		// Parse client message
 		var msg struct {
-			Type   string   `json:"type"`
-			Miners []string `json:"miners,omitempty"`
+			Type    string   `json:"type"`
+			Miners  []string `json:"miners,omitempty"`
+			Types   []string `json:"types,omitempty"`   // event types to receive, empty for all
+			Client  string   `json:"client,omitempty"`  // optional client name/version
+			LastSeq uint64   `json:"lastSeq,omitempty"` // resume: last event seq the client saw
 		}
 		if err := json.Unmarshal(message, &msg); err != nil {
-			continue
+			c.closeForPolicy("malformed message: expected a JSON object")
+			break
 		}
 
 		switch msg.Type {
 		case "subscribe":
+			c.touch()
+			if len(msg.Miners) > MaxSubscribeMiners {
+				c.sendError(msg.Type, fmt.Sprintf("too many miners: %d, at most %d; subscribe to \"*\" for all", len(msg.Miners), MaxSubscribeMiners))
+				continue
+			}
 			// Update miner subscription (protected by mutex)
 			c.minersMu.Lock()
 			c.miners = make(map[string]bool)
 			for _, m := range msg.Miners {
 				c.miners[m] = true
 			}
+			c.types = subscribedTypes(msg.Types)
+			if msg.Client != "" {
+				msg.Client = truncateClientIdentity(msg.Client)
+				c.client = msg.Client
+			}
 			c.minersMu.Unlock()
-			logging.Debug("client subscribed to miners", logging.Fields{"miners": msg.Miners})
+			logging.Debug("client subscribed to miners", logging.Fields{"miners": msg.Miners, "client": msg.Client})
+
+		case "resume":
+			c.touch()
+			select {
+			case c.hub.resume <- resumeRequest{client: c, lastSeq: msg.LastSeq}:
+			case <-c.hub.stop:
+			}
 
 		case "ping":
+			c.touch()
 			// Respond with pong
 			c.hub.Broadcast(Event{
 				Type:      EventPong,
@@ -391,6 +818,48 @@ func (c *wsClient) readPump() {
 	}
 }
 
+// closeForPolicy sends a policy-violation close frame for a message the
+// client should not have sent. readPump's exit then unregisters the client.
+func (c *wsClient) closeForPolicy(reason string) {
+	c.setCloseReason(websocket.ClosePolicyViolation, reason)
+	c.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason), time.Now().Add(time.Second))
+}
+
+// sendError tells the client one of its messages was rejected. The
+// previous subscription stays in effect.
+func (c *wsClient) sendError(request, reason string) {
+	data, err := MarshalJSON(NewEvent(EventClientError, ClientErrorData{Request: request, Error: reason}))
+	if err != nil {
+		return
+	}
+	// The hub closes send under its lock when it drops the client, so only
+	// send while the client is still registered, as broadcasts do
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+	if !c.hub.clients[c] {
+		logging.Debug("client closed before its error was sent", logging.Fields{"request": request})
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
+// subscribedTypes turns the event types a client asked for into a filter;
+// none means every type.
+func subscribedTypes(types []string) map[EventType]bool {
+	if len(types) == 0 {
+		return nil
+	}
+	subscribed := make(map[EventType]bool, len(types))
+	for _, t := range types {
+		subscribed[EventType(t)] = true
+	}
+	return subscribed
+}
+
 // ServeWs handles websocket requests from clients.
 // Returns false if the connection was rejected due to limits.
 func (h *EventHub) ServeWs(conn *websocket.Conn) bool {
@@ -408,11 +877,14 @@ func (h *EventHub) ServeWs(conn *websocket.Conn) bool {
 	}
 
 	client := &wsClient{
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		hub:    h,
-		miners: map[string]bool{"*": true}, // Subscribe to all by default
+		conn:        conn,
+		send:        make(chan []byte, 256),
+		hub:         h,
+		miners:      map[string]bool{"*": true}, // Subscribe to all by default
+		remoteAddr:  conn.RemoteAddr().String(),
+		connectedAt: time.Now(),
 	}
+	client.touch()
 
 	h.register <- client
 
@@ -0,0 +1,118 @@
+package mining
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Long-poll limits. The wait stays under DefaultRequestTimeout so the
+// request timeout middleware doesn't cut polls short.
+const (
+	DefaultEventPollWait = 25 * time.Second
+	MaxEventPollWait     = 25 * time.Second
+	MaxEventPollBatch    = 100 // Events returned per poll; More is set when some are left
+)
+
+// EventPollRequest asks for the events after a cursor.
+type EventPollRequest struct {
+	Since  uint64        // Cursor returned by the previous poll
+	Start  bool          // No cursor yet: return the current state and a cursor
+	Miners []string      // Miners to receive events of; empty for all
+	Types  []string      // Event types to receive; empty for all
+	Wait   time.Duration // How long to wait for an event before returning none
+}
+
+// EventPollResponse is a batch of events and the cursor to poll from next.
+type EventPollResponse struct {
+	Events []Event `json:"events"`
+	Cursor uint64  `json:"cursor"`          // Pass as since on the next poll
+	More   bool    `json:"more,omitempty"`  // More events are waiting; poll again right away
+	Reset  bool    `json:"reset,omitempty"` // Events were missed; Events starts with a state.sync to resync from
+}
+
+// Poll returns the events after req.Since that match the request's filters,
+// waiting up to req.Wait for one if there are none yet. When the cursor is
+// older than the replay buffer, or req.Start is set, the response is a
+// state.sync event and a fresh cursor instead.
+func (h *EventHub) Poll(ctx context.Context, req EventPollRequest) (*EventPollResponse, error) {
+	h.mu.RLock()
+	replaySize, stateProvider := h.replaySize, h.stateProvider
+	h.mu.RUnlock()
+	if replaySize <= 0 {
+		return nil, &MiningError{
+			Code:       ErrCodeNotSupported,
+			Message:    "event polling needs the replay buffer, which is disabled",
+			HTTPStatus: http.StatusServiceUnavailable,
+		}
+	}
+	if req.Wait <= 0 {
+		req.Wait = DefaultEventPollWait
+	}
+	req.Wait = min(req.Wait, MaxEventPollWait)
+	if req.Start {
+		return h.pollReset(stateProvider), nil
+	}
+
+	// The filter only reads miners and types
+	filter := &wsClient{types: subscribedTypes(req.Types)}
+	if len(req.Miners) > 0 {
+		filter.miners = make(map[string]bool, len(req.Miners))
+		for _, m := range req.Miners {
+			filter.miners[m] = true
+		}
+	}
+
+	timer := time.NewTimer(req.Wait)
+	defer timer.Stop()
+	cursor := req.Since
+	for {
+		h.replayMu.Lock()
+		missed, ok := h.missedEvents(cursor)
+		missed = append([]Event(nil), missed...)
+		wake := h.replayed
+		h.replayMu.Unlock()
+		if !ok {
+			return h.pollReset(stateProvider), nil
+		}
+
+		resp := &EventPollResponse{Events: []Event{}, Cursor: cursor}
+		for i, event := range missed {
+			if len(resp.Events) == MaxEventPollBatch {
+				resp.More = true
+				break
+			}
+			resp.Cursor = event.Seq
+			if h.shouldSendToClient(filter, event) {
+				resp.Events = append(resp.Events, missed[i])
+			}
+		}
+		// Events the filter skipped still move the cursor past them
+		cursor = resp.Cursor
+		if len(resp.Events) > 0 {
+			return resp, nil
+		}
+
+		select {
+		case <-wake:
+		case <-timer.C:
+			return resp, nil
+		case <-ctx.Done():
+			return resp, nil
+		case <-h.stop:
+			return resp, nil
+		}
+	}
+}
+
+// pollReset answers a poll that has to start over with the current state.
+func (h *EventHub) pollReset(stateProvider StateProvider) *EventPollResponse {
+	resp := &EventPollResponse{Events: []Event{}, Cursor: h.seq.Load(), Reset: true}
+	if stateProvider != nil {
+		if event, ok := h.stateSyncEvent(stateProvider); ok {
+			resp.Events = append(resp.Events, event)
+			resp.Cursor = event.Seq
+		}
+	}
+	return resp
+}
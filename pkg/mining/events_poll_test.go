@@ -0,0 +1,106 @@
+package mining
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEventHubPoll(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetStateProvider(func() interface{} { return map[string]interface{}{"miners": []string{}} })
+	go hub.Run()
+	defer hub.Stop()
+	ctx := context.Background()
+
+	start, err := hub.Poll(ctx, EventPollRequest{Start: true})
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if !start.Reset || len(start.Events) != 1 || start.Events[0].Type != EventStateSync || start.Cursor != 0 {
+		t.Fatalf("expected a state sync at cursor 0, got %+v", start)
+	}
+
+	t.Run("returns buffered events", func(t *testing.T) {
+		broadcastAndWait(t, hub, 2)
+		resp, err := hub.Poll(ctx, EventPollRequest{Since: start.Cursor})
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if len(resp.Events) != 2 || resp.Cursor != 2 || resp.Reset || resp.More {
+			t.Fatalf("expected events 1 and 2, got %+v", resp)
+		}
+	})
+
+	t.Run("waits for the next event", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			hub.Broadcast(NewEvent(EventMinerStats, MinerStatsData{Name: "a"}))
+		}()
+		resp, err := hub.Poll(ctx, EventPollRequest{Since: 2, Wait: 2 * time.Second})
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if len(resp.Events) != 1 || resp.Events[0].Seq != 3 || resp.Cursor != 3 {
+			t.Fatalf("expected event 3, got %+v", resp)
+		}
+	})
+
+	t.Run("times out empty", func(t *testing.T) {
+		resp, err := hub.Poll(ctx, EventPollRequest{Since: 3, Wait: 50 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if len(resp.Events) != 0 || resp.Cursor != 3 {
+			t.Fatalf("expected an empty batch at cursor 3, got %+v", resp)
+		}
+	})
+
+	t.Run("filters move the cursor", func(t *testing.T) {
+		broadcastAndWait(t, hub, 1) // A test-miner event
+		resp, err := hub.Poll(ctx, EventPollRequest{Since: 3, Miners: []string{"a"}, Wait: 100 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if len(resp.Events) != 0 || resp.Cursor != 4 {
+			t.Fatalf("expected test-miner's event skipped with cursor 4, got %+v", resp)
+		}
+	})
+
+	t.Run("stale cursor resets", func(t *testing.T) {
+		resp, err := hub.Poll(ctx, EventPollRequest{Since: 99})
+		if err != nil {
+			t.Fatalf("Poll failed: %v", err)
+		}
+		if !resp.Reset || resp.Events[0].Type != EventStateSync || resp.Cursor != 4 {
+			t.Fatalf("expected a reset to cursor 4, got %+v", resp)
+		}
+	})
+}
+
+func TestEventHubPollBatchLimit(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	broadcastAndWait(t, hub, MaxEventPollBatch+10)
+	resp, err := hub.Poll(context.Background(), EventPollRequest{Since: 0})
+	if err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+	if len(resp.Events) != MaxEventPollBatch || !resp.More || resp.Cursor != MaxEventPollBatch {
+		t.Fatalf("expected a full batch with more, got %d events, cursor %d, more %v", len(resp.Events), resp.Cursor, resp.More)
+	}
+	resp, _ = hub.Poll(context.Background(), EventPollRequest{Since: resp.Cursor})
+	if len(resp.Events) != 10 || resp.More {
+		t.Fatalf("expected the remaining 10 events, got %d, more %v", len(resp.Events), resp.More)
+	}
+}
+
+func TestEventHubPollReplayDisabled(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetReplayBufferSize(0)
+	if _, err := hub.Poll(context.Background(), EventPollRequest{Start: true}); err == nil {
+		t.Error("expected an error without a replay buffer")
+	}
+}
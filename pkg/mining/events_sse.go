@@ -0,0 +1,130 @@
+package mining
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// sseHeartbeatInterval is how often an SSE stream gets a comment line while
+// no events are sent, so proxies keep it open and idle eviction sees the
+// client is still reading.
+const sseHeartbeatInterval = 30 * time.Second
+
+// SSESubscription is what a server-sent events client receives. SSE is
+// one-directional, so it is fixed when the stream opens.
+type SSESubscription struct {
+	Miners  []string // Miners to receive events of; empty for all
+	Types   []string // Event types to receive; empty for all
+	Client  string   // Optional client identity (name/version)
+	LastSeq uint64   // Resume after this event seq, as with a WebSocket resume
+}
+
+// ServeSSE streams events to w as server-sent events until the request is
+// cancelled or the hub stops. Each event is sent with its seq as the SSE id
+// and its type as the SSE event name, so browsers resume through
+// Last-Event-ID. Returns false, without writing anything, if the connection
+// was rejected due to limits.
+func (h *EventHub) ServeSSE(w http.ResponseWriter, r *http.Request, sub SSESubscription) bool {
+	h.mu.RLock()
+	currentCount := len(h.clients)
+	h.mu.RUnlock()
+	if currentCount >= h.maxConnections {
+		logging.Warn("SSE connection rejected: limit reached", logging.Fields{"current": currentCount, "max": h.maxConnections})
+		return false
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logging.Error("SSE not supported by response writer", nil)
+		return false
+	}
+
+	miners := map[string]bool{"*": true}
+	if len(sub.Miners) > 0 {
+		miners = make(map[string]bool, len(sub.Miners))
+		for _, m := range sub.Miners {
+			miners[m] = true
+		}
+	}
+	sub.Client = truncateClientIdentity(sub.Client)
+	client := &wsClient{
+		send:        make(chan []byte, 256),
+		hub:         h,
+		miners:      miners,
+		types:       subscribedTypes(sub.Types),
+		client:      sub.Client,
+		remoteAddr:  r.RemoteAddr,
+		connectedAt: time.Now(),
+		resumeFrom:  sub.LastSeq,
+	}
+	client.touch()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	h.register <- client
+	defer func() {
+		select {
+		case h.unregister <- client:
+		case <-h.stop:
+		}
+	}()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return true
+
+		case message, ok := <-client.send:
+			if !ok {
+				return true // Hub stopped or evicted the client
+			}
+			if err := writeSSEEvent(w, message); err != nil {
+				logging.Debug("SSE write error", logging.Fields{"error": err})
+				return true
+			}
+
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return true
+			}
+		}
+		flusher.Flush()
+		client.touch()
+	}
+}
+
+// writeSSEEvent writes one marshaled Event in SSE framing. Pongs answer a
+// WebSocket client's ping and are skipped.
+func writeSSEEvent(w io.Writer, message []byte) error {
+	var head struct {
+		Seq  uint64    `json:"seq"`
+		Type EventType `json:"type"`
+	}
+	if err := json.Unmarshal(message, &head); err != nil {
+		return err
+	}
+	if head.Type == EventPong {
+		return nil
+	}
+
+	var frame []byte
+	if head.Seq > 0 {
+		frame = fmt.Appendf(frame, "id: %d\n", head.Seq)
+	}
+	frame = fmt.Appendf(frame, "event: %s\ndata: %s\n\n", head.Type, message)
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,164 @@
+package mining
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is one event read from an SSE stream
+type sseEvent struct {
+	id    string
+	name  string
+	event Event
+}
+
+// openTestSSE opens an SSE stream from hub with the given subscription
+func openTestSSE(t *testing.T, hub *EventHub, sub SSESubscription) *bufio.Reader {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hub.ServeSSE(w, r, sub) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+// readSSEEvent reads the next event from an SSE stream, skipping comments
+func readSSEEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	done := make(chan sseEvent, 1)
+	go func() {
+		var e sseEvent
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(done)
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "" && e.name != "":
+				done <- e
+				return
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				e.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.event)
+			}
+		}
+	}()
+	select {
+	case e, ok := <-done:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an SSE event")
+	}
+	return sseEvent{}
+}
+
+func TestEventHubServeSSE(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	stream := openTestSSE(t, hub, SSESubscription{Miners: []string{"a"}, Types: []string{string(EventMinerStats)}, Client: "kiosk/1.0"})
+	waitForClientCount(t, hub, 1)
+
+	clients := hub.Clients()
+	if clients[0].Transport != "sse" || clients[0].Client != "kiosk/1.0" {
+		t.Errorf("unexpected client info: %+v", clients[0])
+	}
+
+	hub.Broadcast(NewEvent(EventMinerStats, MinerStatsData{Name: "b", Hashrate: 1}))   // Other miner
+	hub.Broadcast(NewEvent(EventMinerStarted, MinerEventData{Name: "a"}))              // Other type
+	hub.Broadcast(NewEvent(EventPong, nil))                                            // WebSocket only
+	hub.Broadcast(NewEvent(EventMinerStats, MinerStatsData{Name: "a", Hashrate: 100})) // Subscribed
+
+	e := readSSEEvent(t, stream)
+	if e.name != string(EventMinerStats) || e.id != "3" || e.event.Seq != 3 {
+		t.Fatalf("expected miner.stats with id 3, got %+v", e)
+	}
+	if data, _ := e.event.Data.(map[string]interface{}); data["name"] != "a" {
+		t.Errorf("expected miner a's stats, got %+v", e.event.Data)
+	}
+}
+
+func TestEventHubServeSSEResume(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetStateProvider(func() interface{} { return map[string]interface{}{"miners": []string{}} })
+	go hub.Run()
+	defer hub.Stop()
+
+	broadcastAndWait(t, hub, 3)
+
+	stream := openTestSSE(t, hub, SSESubscription{LastSeq: 1})
+	for _, want := range []string{"2", "3"} {
+		if e := readSSEEvent(t, stream); e.id != want || e.name != string(EventMinerStarted) {
+			t.Fatalf("expected replayed event %s, got %+v", want, e)
+		}
+	}
+
+	broadcastAndWait(t, hub, 1)
+	if e := readSSEEvent(t, stream); e.id != "4" {
+		t.Fatalf("expected live event 4, got %+v", e)
+	}
+}
+
+func TestEventHubServeSSEDisconnect(t *testing.T) {
+	hub := NewEventHubWithOptions(1)
+	go hub.Run()
+	defer hub.Stop()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hub.ServeSSE(w, r, SSESubscription{}) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	waitForClientCount(t, hub, 1)
+
+	// The connection limit applies to SSE clients too
+	second, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	second.Body.Close()
+	if second.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected %d over the limit, got %d", http.StatusServiceUnavailable, second.StatusCode)
+	}
+
+	resp.Body.Close()
+	waitForClientCount(t, hub, 0)
+}
+
+func TestSplitQueryList(t *testing.T) {
+	got := splitQueryList([]string{"a, b", "", "c"})
+	if strings.Join(got, "|") != "a|b|c" {
+		t.Errorf("unexpected list: %v", got)
+	}
+}
@@ -2,9 +2,14 @@ package mining
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/websocket"
 )
@@ -199,3 +204,424 @@ func TestEventTypes(t *testing.T) {
 		}
 	}
 }
+
+// dialTestHub starts an HTTP server that hands WebSocket connections to hub and dials it.
+func dialTestHub(t *testing.T, hub *EventHub) *websocket.Conn {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		hub.ServeWs(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitForClientCount polls until the hub has the expected number of clients
+func waitForClientCount(t *testing.T, hub *EventHub, want int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if hub.ClientCount() == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected %d clients, got %d", want, hub.ClientCount())
+}
+
+func TestEventHubIdleEviction(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetEvictionPolicy(150*time.Millisecond, 0)
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+
+	// Idle client is evicted and the server closes the connection
+	waitForClientCount(t, hub, 0)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+}
+
+func TestEventHubActivityPreventsIdleEviction(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetEvictionPolicy(300*time.Millisecond, 0)
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+
+	// Keep the client active past the idle timeout
+	for i := 0; i < 6; i++ {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"subscribe","miners":["*"]}`)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if hub.ClientCount() != 1 {
+		t.Errorf("active client should not be evicted, got %d clients", hub.ClientCount())
+	}
+}
+
+func TestEventHubMaxConnectionAge(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetEvictionPolicy(0, 150*time.Millisecond)
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+
+	// Activity does not extend the maximum age
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ping"}`))
+	waitForClientCount(t, hub, 0)
+}
+
+func TestEventHubClientIdentity(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+
+	clients := hub.Clients()
+	if len(clients) != 1 || clients[0].Client != "" || clients[0].RemoteAddr == "" {
+		t.Fatalf("unexpected clients before subscribe: %+v", clients)
+	}
+
+	if err := conn.WriteJSON(map[string]interface{}{
+		"type":   "subscribe",
+		"miners": []string{"xmrig-rx_0"},
+		"client": "dashboard/1.2.0",
+	}); err != nil {
+		t.Fatalf("failed to send subscribe: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		clients = hub.Clients()
+		if len(clients) == 1 && clients[0].Client != "" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if clients[0].Client != "dashboard/1.2.0" {
+		t.Errorf("Expected client identity to be recorded, got %q", clients[0].Client)
+	}
+	if len(clients[0].Subscriptions) != 1 || clients[0].Subscriptions[0] != "xmrig-rx_0" {
+		t.Errorf("unexpected subscriptions: %v", clients[0].Subscriptions)
+	}
+	if clients[0].ConnectedAt.IsZero() || clients[0].LastActivity.Before(clients[0].ConnectedAt) {
+		t.Errorf("unexpected timestamps: %+v", clients[0])
+	}
+}
+
+// broadcastAndWait broadcasts n miner events and waits for the hub to number them
+func broadcastAndWait(t *testing.T, hub *EventHub, n int) {
+	t.Helper()
+	want := hub.seq.Load() + uint64(n)
+	for i := 0; i < n; i++ {
+		hub.Broadcast(NewEvent(EventMinerStarted, MinerEventData{Name: "test-miner"}))
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.seq.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected seq %d, got %d", want, hub.seq.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) Event {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var event Event
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("failed to read event: %v", err)
+	}
+	return event
+}
+
+func TestEventHubResume(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	// Events broadcast while the client was away
+	broadcastAndWait(t, hub, 3)
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+	if err := conn.WriteJSON(map[string]interface{}{"type": "resume", "lastSeq": 1}); err != nil {
+		t.Fatalf("failed to send resume: %v", err)
+	}
+	for _, want := range []uint64{2, 3} {
+		if event := readEvent(t, conn); event.Seq != want || event.Type != EventMinerStarted {
+			t.Fatalf("Expected replayed event %d, got %+v", want, event)
+		}
+	}
+
+	// The live stream continues from there
+	broadcastAndWait(t, hub, 1)
+	if event := readEvent(t, conn); event.Seq != 4 {
+		t.Fatalf("Expected live event 4, got %+v", event)
+	}
+}
+
+func TestEventHubResumeGapFallsBackToStateSync(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetReplayBufferSize(2)
+	hub.SetStateProvider(func() interface{} { return map[string]interface{}{"miners": []string{}} })
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+	if event := readEvent(t, conn); event.Type != EventStateSync || event.Seq != 0 {
+		t.Fatalf("Expected initial state sync, got %+v", event)
+	}
+
+	// Pretend the client saw seq 1 before disconnecting; 2 and 3 were missed
+	broadcastAndWait(t, hub, 5)
+	for i := 0; i < 5; i++ {
+		readEvent(t, conn)
+	}
+	if err := conn.WriteJSON(map[string]interface{}{"type": "resume", "lastSeq": 1}); err != nil {
+		t.Fatalf("failed to send resume: %v", err)
+	}
+	if event := readEvent(t, conn); event.Type != EventStateSync || event.Seq != 5 {
+		t.Fatalf("Expected state sync at seq 5, got %+v", event)
+	}
+}
+
+func TestEventHubMissedEvents(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetReplayBufferSize(2)
+	for i := 0; i < 5; i++ {
+		event := NewEvent(EventMinerStats, nil)
+		event.Seq = hub.seq.Add(1)
+		hub.recordForReplay(event)
+	}
+
+	if _, ok := hub.missedEvents(1); ok {
+		t.Error("seq 2 should no longer be buffered")
+	}
+	if missed, ok := hub.missedEvents(3); !ok || len(missed) != 2 || missed[0].Seq != 4 {
+		t.Errorf("unexpected missed events %+v (ok=%v)", missed, ok)
+	}
+	if missed, ok := hub.missedEvents(5); !ok || len(missed) != 0 {
+		t.Errorf("an up-to-date client missed nothing, got %+v (ok=%v)", missed, ok)
+	}
+	if _, ok := hub.missedEvents(10); ok {
+		t.Error("a seq from before a restart cannot be resumed")
+	}
+}
+
+// waitForSubscriptions polls until the hub's only client has n subscriptions
+func waitForSubscriptions(t *testing.T, hub *EventHub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if clients := hub.Clients(); len(clients) == 1 && len(clients[0].Subscriptions) == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Expected %d subscriptions, got %+v", n, hub.Clients())
+}
+
+func TestEventHubSubscribeLongMinerList(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+
+	// Well over the old 512 byte read limit
+	miners := make([]string, 100)
+	for i := range miners {
+		miners[i] = fmt.Sprintf("xmrig-rx_0-rig-%03d", i)
+	}
+	msg, _ := json.Marshal(map[string]interface{}{"type": "subscribe", "miners": miners})
+	if len(msg) <= 512 {
+		t.Fatalf("test subscribe is only %d bytes", len(msg))
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		t.Fatalf("failed to send subscribe: %v", err)
+	}
+	waitForSubscriptions(t, hub, len(miners))
+}
+
+func TestEventHubSubscribeTooManyMiners(t *testing.T) {
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+
+	miners := make([]string, MaxSubscribeMiners+1)
+	for i := range miners {
+		miners[i] = fmt.Sprintf("m%d", i)
+	}
+	if err := conn.WriteJSON(map[string]interface{}{"type": "subscribe", "miners": miners}); err != nil {
+		t.Fatalf("failed to send subscribe: %v", err)
+	}
+	event := readEvent(t, conn)
+	if event.Type != EventClientError {
+		t.Fatalf("Expected a client error, got %+v", event)
+	}
+	if data, _ := event.Data.(map[string]interface{}); data["request"] != "subscribe" {
+		t.Errorf("unexpected error data: %+v", event.Data)
+	}
+	// The default subscription to all miners stays
+	if clients := hub.Clients(); len(clients[0].Subscriptions) != 1 || clients[0].Subscriptions[0] != "*" {
+		t.Errorf("Expected the previous subscription kept, got %v", clients[0].Subscriptions)
+	}
+}
+
+func TestClientSendErrorAfterClose(t *testing.T) {
+	hub := NewEventHub()
+	client := &wsClient{send: make(chan []byte, 1), hub: hub}
+
+	// An unregistered client's channel may already be closed; sending must not panic
+	client.safeClose()
+	client.sendError("subscribe", "rejected")
+
+	client = &wsClient{send: make(chan []byte, 1), hub: hub}
+	hub.clients[client] = true
+	client.sendError("subscribe", "rejected")
+	if len(client.send) != 1 {
+		t.Error("expected the error sent to a registered client")
+	}
+}
+
+func TestTruncateClientIdentity(t *testing.T) {
+	if got := truncateClientIdentity("dashboard/1.2"); got != "dashboard/1.2" {
+		t.Errorf("expected a short identity unchanged, got %q", got)
+	}
+	// 127 ASCII bytes then a 3-byte rune straddling the limit
+	long := strings.Repeat("a", maxClientIdentityLength-1) + "€€"
+	got := truncateClientIdentity(long)
+	if !utf8.ValidString(got) || len(got) > maxClientIdentityLength {
+		t.Errorf("expected valid UTF-8 of at most %d bytes, got %d bytes %q", maxClientIdentityLength, len(got), got)
+	}
+	if got != strings.Repeat("a", maxClientIdentityLength-1) {
+		t.Errorf("expected the cut before the split rune, got %q", got)
+	}
+}
+
+func TestEventHubReadLimit(t *testing.T) {
+	hub := NewEventHub()
+	hub.SetReadLimit(64)
+	go hub.Run()
+	defer hub.Stop()
+
+	conn := dialTestHub(t, hub)
+	waitForClientCount(t, hub, 1)
+
+	msg, _ := json.Marshal(map[string]interface{}{"type": "subscribe", "miners": []string{strings.Repeat("x", 100)}})
+	if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		t.Fatalf("failed to send subscribe: %v", err)
+	}
+	waitForClientCount(t, hub, 0)
+
+	hub.SetReadLimit(0)
+	if hub.readLimit != 1 {
+		t.Errorf("Expected the read limit clamped to 1, got %d", hub.readLimit)
+	}
+	hub.SetReadLimit(MaxWSReadLimit * 2)
+	if hub.readLimit != MaxWSReadLimit {
+		t.Errorf("Expected the read limit clamped to %d, got %d", MaxWSReadLimit, hub.readLimit)
+	}
+}
+
+// readCloseError reads until the server closes the connection and returns its close frame
+func readCloseError(t *testing.T, conn *websocket.Conn) *websocket.CloseError {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		closeErr, ok := err.(*websocket.CloseError)
+		if !ok {
+			t.Fatalf("Expected a close frame, got %v", err)
+		}
+		return closeErr
+	}
+}
+
+func TestEventHubCloseReasons(t *testing.T) {
+	t.Run("shutdown", func(t *testing.T) {
+		hub := NewEventHub()
+		go hub.Run()
+		conn := dialTestHub(t, hub)
+		waitForClientCount(t, hub, 1)
+
+		hub.Stop()
+		if closeErr := readCloseError(t, conn); closeErr.Code != websocket.CloseGoingAway {
+			t.Errorf("Expected going-away on shutdown, got %v", closeErr)
+		}
+	})
+
+	t.Run("connection limit", func(t *testing.T) {
+		hub := NewEventHubWithOptions(1)
+		go hub.Run()
+		defer hub.Stop()
+		dialTestHub(t, hub)
+		waitForClientCount(t, hub, 1)
+
+		conn := dialTestHub(t, hub)
+		if closeErr := readCloseError(t, conn); closeErr.Code != websocket.CloseTryAgainLater {
+			t.Errorf("Expected try-again-later over the limit, got %v", closeErr)
+		}
+	})
+
+	t.Run("malformed message", func(t *testing.T) {
+		hub := NewEventHub()
+		go hub.Run()
+		defer hub.Stop()
+		conn := dialTestHub(t, hub)
+		waitForClientCount(t, hub, 1)
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`subscribe please`))
+		if closeErr := readCloseError(t, conn); closeErr.Code != websocket.ClosePolicyViolation {
+			t.Errorf("Expected policy-violation for a malformed message, got %v", closeErr)
+		}
+		waitForClientCount(t, hub, 0)
+	})
+
+	t.Run("idle eviction", func(t *testing.T) {
+		hub := NewEventHub()
+		hub.SetEvictionPolicy(150*time.Millisecond, 0)
+		go hub.Run()
+		defer hub.Stop()
+		conn := dialTestHub(t, hub)
+
+		closeErr := readCloseError(t, conn)
+		if closeErr.Code != websocket.CloseNormalClosure || closeErr.Text != "idle timeout" {
+			t.Errorf("Expected a normal closure for idle timeout, got %v", closeErr)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // AtomicWriteFile writes data to a file atomically by writing to a temp file
@@ -55,3 +56,46 @@ func AtomicWriteFile(path string, data []byte, perm os.FileMode) error {
 	success = true
 	return nil
 }
+
+// maxBackupAttempts bounds the numbered names BackupFile tries within one second.
+const maxBackupAttempts = 100
+
+// BackupFile copies path to a timestamped sibling (path.20060102-150405.bak,
+// or path.20060102-150405-N.bak if that exists) and returns the copy's path.
+// An existing backup is never overwritten. Returns "" if path doesn't exist.
+func BackupFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	stamp := fmt.Sprintf("%s.%s", path, time.Now().UTC().Format("20060102-150405"))
+	for attempt := 0; attempt < maxBackupAttempts; attempt++ {
+		backupPath := stamp + ".bak"
+		if attempt > 0 {
+			backupPath = fmt.Sprintf("%s-%d.bak", stamp, attempt)
+		}
+		f, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(data)
+		if syncErr := f.Sync(); err == nil {
+			err = syncErr
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(backupPath)
+			return "", fmt.Errorf("failed to write backup: %w", err)
+		}
+		return backupPath, nil
+	}
+	return "", fmt.Errorf("too many backups of %s this second", filepath.Base(path))
+}
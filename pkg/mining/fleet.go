@@ -0,0 +1,401 @@
+package mining
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/Snider/Mining/pkg/node"
+	"github.com/adrg/xdg"
+)
+
+const fleetStateFileName = "fleet_desired_state.json"
+
+// DefaultFleetReconcileInterval is how often the fleet reconciler converges workers.
+const DefaultFleetReconcileInterval = time.Minute
+
+// Fleet action types.
+const (
+	FleetActionStart = "start"
+	FleetActionStop  = "stop"
+)
+
+// FleetDesiredState declares which profiles should be running on which workers.
+// An entry for a worker's peer ID takes precedence over the entry for its role.
+// Workers matched by neither entry are left alone.
+type FleetDesiredState struct {
+	Workers map[string][]string `json:"workers,omitempty"` // Peer ID -> profile IDs
+	Roles   map[string][]string `json:"roles,omitempty"`   // Node role ("worker", "dual") -> profile IDs
+	DryRun  bool                `json:"dryRun"`            // Report planned actions without executing them
+}
+
+// desiredProfiles returns the profile IDs that should run on peer, and whether
+// the desired state covers it at all.
+func (s *FleetDesiredState) desiredProfiles(peer *node.Peer) ([]string, bool) {
+	if ids, ok := s.Workers[peer.ID]; ok {
+		return ids, true
+	}
+	if ids, ok := s.Roles[string(peer.Role)]; ok {
+		return ids, true
+	}
+	return nil, false
+}
+
+// FleetAction is a command issued (or planned, in dry-run) to converge a worker.
+type FleetAction struct {
+	Type      string `json:"type"` // "start" or "stop"
+	ProfileID string `json:"profileId,omitempty"`
+	MinerName string `json:"minerName,omitempty"`
+	Executed  bool   `json:"executed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// FleetWorkerStatus compares the desired and actual state of one worker.
+type FleetWorkerStatus struct {
+	PeerID  string        `json:"peerId"`
+	Name    string        `json:"name"`
+	Desired []string      `json:"desired"` // Profile IDs
+	Running []string      `json:"running"` // Miner names reported by the worker
+	InSync  bool          `json:"inSync"`
+	Actions []FleetAction `json:"actions,omitempty"`
+	Error   string        `json:"error,omitempty"` // Set when the worker's state could not be read
+}
+
+// FleetStatus reports drift across all workers covered by the desired state.
+type FleetStatus struct {
+	DryRun         bool                `json:"dryRun"`
+	InSync         bool                `json:"inSync"`
+	LastReconciled *time.Time          `json:"lastReconciled,omitempty"`
+	Workers        []FleetWorkerStatus `json:"workers"`
+}
+
+// fleetController is the subset of node.Controller the reconciler drives.
+type fleetController interface {
+	GetRemoteStats(peerID string) (*node.StatsPayload, error)
+	DeployProfile(peerID string, profileJSON []byte, name string) error
+	StartRemoteMiner(peerID, minerType, profileID string, configOverride json.RawMessage) error
+	StopRemoteMiner(peerID, minerName string) error
+}
+
+// FleetReconciler converges workers towards a desired state by comparing it
+// with the miners each worker reports and issuing deploy, start and stop commands.
+// Reconciling is idempotent: a worker that already matches gets no commands.
+type FleetReconciler struct {
+	controller fleetController
+	peers      func() []*node.Peer
+	profiles   *ProfileManager
+	interval   time.Duration
+	statePath  string
+
+	mu             sync.RWMutex
+	state          FleetDesiredState
+	lastReconciled time.Time
+
+	runMu  sync.Mutex // Serializes reconcile passes
+	loopMu sync.Mutex
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewFleetReconciler creates a reconciler, loading any previously saved desired state.
+// An interval of zero disables periodic reconciliation.
+func NewFleetReconciler(controller fleetController, peers func() []*node.Peer, profiles *ProfileManager, interval time.Duration) (*FleetReconciler, error) {
+	statePath, err := xdg.ConfigFile(filepath.Join("lethean-desktop", fleetStateFileName))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve config path: %w", err)
+	}
+	return newFleetReconciler(statePath, controller, peers, profiles, interval)
+}
+
+func newFleetReconciler(statePath string, controller fleetController, peers func() []*node.Peer, profiles *ProfileManager, interval time.Duration) (*FleetReconciler, error) {
+	r := &FleetReconciler{
+		controller: controller,
+		peers:      peers,
+		profiles:   profiles,
+		interval:   interval,
+		statePath:  statePath,
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("could not load fleet desired state: %w", err)
+		}
+		return r, nil
+	}
+	if err := json.Unmarshal(data, &r.state); err != nil {
+		return nil, fmt.Errorf("could not parse fleet desired state: %w", err)
+	}
+	return r, nil
+}
+
+// DesiredState returns the current desired state.
+func (r *FleetReconciler) DesiredState() FleetDesiredState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.state
+}
+
+// SetDesiredState validates and persists a new desired state. Every referenced
+// profile must exist on this node, since it is deployed to workers from here.
+func (r *FleetReconciler) SetDesiredState(state FleetDesiredState) error {
+	if err := r.validate(state); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := AtomicWriteFile(r.statePath, data, 0600); err != nil {
+		return fmt.Errorf("could not save fleet desired state: %w", err)
+	}
+	r.state = state
+	return nil
+}
+
+// validate checks that a desired state only names worker roles and known profiles.
+func (r *FleetReconciler) validate(state FleetDesiredState) error {
+	for role := range state.Roles {
+		if role != string(node.RoleWorker) && role != string(node.RoleDual) {
+			return fmt.Errorf("invalid role %q: must be %q or %q", role, node.RoleWorker, node.RoleDual)
+		}
+	}
+	for _, entries := range []map[string][]string{state.Workers, state.Roles} {
+		for _, ids := range entries {
+			for _, id := range ids {
+				if _, exists := r.profiles.GetProfile(id); !exists {
+					return fmt.Errorf("profile not found: %s", id)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Status reports drift and the actions a reconcile pass would take, without executing them.
+func (r *FleetReconciler) Status() FleetStatus {
+	return r.run(true)
+}
+
+// Reconcile compares desired and actual state and issues commands to converge.
+// If dryRun is set, or the desired state is in dry-run mode, actions are only planned.
+func (r *FleetReconciler) Reconcile(dryRun bool) FleetStatus {
+	return r.run(dryRun)
+}
+
+func (r *FleetReconciler) run(planOnly bool) FleetStatus {
+	state := r.DesiredState()
+	execute := !planOnly && !state.DryRun
+
+	// A status request only reads, but still serializes with a pass that executes,
+	// so it doesn't report actions that are already in flight.
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	status := FleetStatus{DryRun: state.DryRun, InSync: true, Workers: []FleetWorkerStatus{}}
+	peers := r.peers()
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+	for _, peer := range peers {
+		desired, covered := state.desiredProfiles(peer)
+		if !covered || peer.Role == node.RoleController {
+			continue
+		}
+		worker := r.reconcileWorker(peer, desired, execute)
+		if !worker.InSync {
+			status.InSync = false
+		}
+		status.Workers = append(status.Workers, worker)
+	}
+
+	r.mu.Lock()
+	if execute {
+		r.lastReconciled = time.Now()
+	}
+	if !r.lastReconciled.IsZero() {
+		last := r.lastReconciled
+		status.LastReconciled = &last
+	}
+	r.mu.Unlock()
+
+	return status
+}
+
+// reconcileWorker plans the actions that converge one worker and executes them if asked.
+func (r *FleetReconciler) reconcileWorker(peer *node.Peer, desired []string, execute bool) FleetWorkerStatus {
+	worker := FleetWorkerStatus{
+		PeerID:  peer.ID,
+		Name:    peer.Name,
+		Desired: append([]string{}, desired...),
+		Running: []string{},
+	}
+
+	stats, err := r.controller.GetRemoteStats(peer.ID)
+	if err != nil {
+		worker.Error = err.Error()
+		return worker
+	}
+
+	unmatched := make(map[string]node.MinerStatsItem, len(stats.Miners))
+	for _, miner := range stats.Miners {
+		worker.Running = append(worker.Running, miner.Name)
+		unmatched[miner.Name] = miner
+	}
+	sort.Strings(worker.Running)
+
+	for _, id := range desired {
+		profile, err := r.profiles.ResolvedProfile(id)
+		if err != nil {
+			worker.Actions = append(worker.Actions, FleetAction{Type: FleetActionStart, ProfileID: id, Error: asMiningError(err).Message})
+			continue
+		}
+		if name, ok := matchProfileMiner(profile, unmatched); ok {
+			delete(unmatched, name)
+			continue
+		}
+		worker.Actions = append(worker.Actions, FleetAction{Type: FleetActionStart, ProfileID: id})
+	}
+
+	stopNames := make([]string, 0, len(unmatched))
+	for name := range unmatched {
+		stopNames = append(stopNames, name)
+	}
+	sort.Strings(stopNames)
+	for _, name := range stopNames {
+		worker.Actions = append(worker.Actions, FleetAction{Type: FleetActionStop, MinerName: name})
+	}
+
+	worker.InSync = len(worker.Actions) == 0
+	if execute {
+		for i := range worker.Actions {
+			r.execute(peer, &worker.Actions[i])
+		}
+	}
+	return worker
+}
+
+// execute issues a single action to a worker. Profiles are deployed before
+// starting so the worker keeps a copy it can restart from by ID.
+func (r *FleetReconciler) execute(peer *node.Peer, action *FleetAction) {
+	if action.Error != "" {
+		return
+	}
+
+	var err error
+	switch action.Type {
+	case FleetActionStart:
+		// Workers get the effective config, since they don't have our templates
+		var profile *MiningProfile
+		if profile, err = r.profiles.ResolvedProfile(action.ProfileID); err != nil {
+			break
+		}
+		var data []byte
+		if data, err = json.Marshal(profile); err != nil {
+			break
+		}
+		if err = r.controller.DeployProfile(peer.ID, data, profile.Name); err != nil {
+			break
+		}
+		err = r.controller.StartRemoteMiner(peer.ID, profile.MinerType, profile.ID, nil)
+	case FleetActionStop:
+		err = r.controller.StopRemoteMiner(peer.ID, action.MinerName)
+	}
+
+	action.Executed = true
+	if err != nil {
+		action.Error = err.Error()
+		logging.Warn("fleet reconcile action failed", logging.Fields{"peer": peer.ID, "action": action.Type, "profile": action.ProfileID, "miner": action.MinerName, "error": err})
+		return
+	}
+	logging.Info("fleet reconcile action applied", logging.Fields{"peer": peer.ID, "action": action.Type, "profile": action.ProfileID, "miner": action.MinerName})
+}
+
+// matchProfileMiner finds the running miner started from profile. Miners
+// started with an algorithm have a predictable instance name; otherwise the
+// miner type and pool must match.
+func matchProfileMiner(profile *MiningProfile, running map[string]node.MinerStatsItem) (string, bool) {
+	var cfg Config
+	if len(profile.Config) > 0 {
+		if err := json.Unmarshal(profile.Config, &cfg); err != nil {
+			return "", false
+		}
+	}
+
+	if cfg.Algo != "" {
+		miner, err := CreateMiner(profile.MinerType)
+		if err != nil {
+			return "", false
+		}
+		name := algoInstanceName(miner.GetName(), cfg.Algo)
+		_, ok := running[name]
+		return name, ok
+	}
+
+	names := make([]string, 0, len(running))
+	for name := range running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if item := running[name]; item.Type == profile.MinerType && item.Pool == cfg.Pool {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Start begins periodic reconciliation. It does nothing if the interval is
+// zero or the loop is already running.
+func (r *FleetReconciler) Start() {
+	r.loopMu.Lock()
+	defer r.loopMu.Unlock()
+	if r.interval <= 0 || r.stopCh != nil {
+		return
+	}
+
+	r.stopCh = make(chan struct{})
+	r.wg.Add(1)
+	go r.loop(r.stopCh)
+}
+
+// Stop ends periodic reconciliation and waits for an in-progress pass to finish.
+func (r *FleetReconciler) Stop() {
+	r.loopMu.Lock()
+	defer r.loopMu.Unlock()
+	if r.stopCh == nil {
+		return
+	}
+	close(r.stopCh)
+	r.wg.Wait()
+	r.stopCh = nil
+}
+
+func (r *FleetReconciler) loop(stopCh chan struct{}) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			state := r.DesiredState()
+			if len(state.Workers) == 0 && len(state.Roles) == 0 {
+				continue
+			}
+			status := r.Reconcile(false)
+			if !status.InSync {
+				logging.Info("fleet reconcile pass found drift", logging.Fields{"workers": len(status.Workers), "dryRun": status.DryRun})
+			}
+		}
+	}
+}
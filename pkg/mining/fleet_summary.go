@@ -0,0 +1,204 @@
+package mining
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Snider/Mining/pkg/mining/algos"
+	"github.com/gin-gonic/gin"
+)
+
+// fleetSummaryPath is the route behind the fleet_summary MCP tool, relative to
+// the API base path.
+const fleetSummaryPath = "/fleet_summary"
+
+// recentEventsCapacity is how many notable events the fleet summary keeps.
+const recentEventsCapacity = 10
+
+// fleetStatsTimeout bounds each local miner's stats fetch for a summary.
+const fleetStatsTimeout = 2 * time.Second
+
+// FleetSummary is a compact view of the whole fleet for an assistant to read
+// in one call. Numbers are pre-formatted strings to keep it short.
+type FleetSummary struct {
+	Summary       string             `json:"summary"` // One line, e.g. "3 miners on 2 nodes, 12.35 kH/s total, 1 issue"
+	TotalHashrate string             `json:"totalHashrate"`
+	Nodes         []FleetNodeSummary `json:"nodes"`
+	Issues        []string           `json:"issues,omitempty"`
+	RecentEvents  []string           `json:"recentEvents,omitempty"` // Newest first, e.g. "14:03:05 miner.error xmrig-1: pool refused"
+}
+
+// FleetNodeSummary summarizes one node: this one ("local") or a peer.
+type FleetNodeSummary struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"` // "online", "offline" or "unreachable"
+	Hashrate string   `json:"hashrate,omitempty"`
+	Miners   []string `json:"miners,omitempty"` // e.g. "xmrig-1 4.20 kH/s up 2h13m 99% accepted"
+}
+
+// recentEvents keeps the last few notable events for the fleet summary.
+type recentEvents struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// OnEvent records everything except periodic stats and connection chatter.
+func (r *recentEvents) OnEvent(event Event) {
+	switch event.Type {
+	case EventMinerStats, EventPong, EventStateSync:
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	if len(r.events) > recentEventsCapacity {
+		r.events = r.events[len(r.events)-recentEventsCapacity:]
+	}
+}
+
+// lines formats the recorded events, newest first.
+func (r *recentEvents) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := make([]string, 0, len(r.events))
+	for i := len(r.events) - 1; i >= 0; i-- {
+		lines = append(lines, formatEventLine(r.events[i]))
+	}
+	return lines
+}
+
+func formatEventLine(event Event) string {
+	line := event.Timestamp.Format("15:04:05") + " " + string(event.Type)
+	if data, ok := event.Data.(MinerEventData); ok {
+		line += " " + data.Name
+		if data.Error != "" {
+			line += ": " + data.Error
+		} else if data.Reason != "" {
+			line += ": " + data.Reason
+		}
+	}
+	return line
+}
+
+// formatHashrate renders a hashrate in the unit of algo, if the algos
+// registry knows it, or in H/s, e.g. "12.35 kH/s".
+func formatHashrate(h float64, algo string) string {
+	if algorithm, ok := algos.Lookup(algo); ok {
+		return algorithm.FormatHashrate(h)
+	}
+	return algos.FormatHashrate(h)
+}
+
+// formatUptime renders seconds as e.g. "2h13m", dropping seconds.
+func formatUptime(seconds int) string {
+	d := (time.Duration(seconds) * time.Second).Truncate(time.Minute)
+	if d < time.Minute {
+		return strconv.Itoa(seconds) + "s"
+	}
+	return strings.TrimSuffix(d.String(), "0s")
+}
+
+// plural renders a count with its noun, e.g. "1 node" or "3 nodes".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
+
+func formatMinerLine(name, algo string, hashrate float64, uptime, shares, rejected int) string {
+	line := fmt.Sprintf("%s %s up %s", name, formatHashrate(hashrate, algo), formatUptime(uptime))
+	if total := shares + rejected; total > 0 {
+		line += fmt.Sprintf(" %d%% accepted", shares*100/total)
+	}
+	return line
+}
+
+// FleetSummary gathers local miner stats and, when P2P is enabled, the stats
+// of every connected peer into a FleetSummary.
+func (s *Service) FleetSummary(ctx context.Context) *FleetSummary {
+	summary := &FleetSummary{Nodes: []FleetNodeSummary{}}
+	var total float64
+	minerCount := 0
+
+	local := FleetNodeSummary{Name: "local", Status: "online"}
+	var localHashrate float64
+	for _, miner := range s.Manager.ListMiners() {
+		minerCount++
+		statsCtx, cancel := context.WithTimeout(ctx, fleetStatsTimeout)
+		stats, err := miner.GetStats(statsCtx)
+		cancel()
+		if err != nil || stats == nil {
+			local.Miners = append(local.Miners, miner.GetName()+" stats unavailable")
+			summary.Issues = append(summary.Issues, fmt.Sprintf("local %s: stats unavailable", miner.GetName()))
+			continue
+		}
+		localHashrate += float64(stats.Hashrate)
+		local.Miners = append(local.Miners, formatMinerLine(miner.GetName(), stats.Algorithm, float64(stats.Hashrate), stats.Uptime, stats.Shares, stats.Rejected))
+		if stats.Hashrate == 0 && stats.Uptime > 60 {
+			summary.Issues = append(summary.Issues, fmt.Sprintf("local %s: 0 H/s after %s", miner.GetName(), formatUptime(stats.Uptime)))
+		}
+	}
+	local.Hashrate = algos.FormatHashrate(localHashrate)
+	total += localHashrate
+	summary.Nodes = append(summary.Nodes, local)
+
+	if s.NodeService != nil && s.NodeService.controller != nil {
+		remote := s.NodeService.controller.GetAllStats()
+		peers := s.NodeService.peerRegistry.ListPeers()
+		sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+		for _, peer := range peers {
+			node := FleetNodeSummary{Name: peer.Name}
+			if node.Name == "" {
+				node.Name = peer.ID
+			}
+			stats, ok := remote[peer.ID]
+			switch {
+			case !peer.Connected:
+				node.Status = "offline"
+				summary.Issues = append(summary.Issues, fmt.Sprintf("%s offline, last seen %s", node.Name, peer.LastSeen.Format(time.RFC3339)))
+			case !ok:
+				node.Status = "unreachable"
+				summary.Issues = append(summary.Issues, fmt.Sprintf("%s connected but not answering stats requests", node.Name))
+			default:
+				node.Status = "online"
+				var nodeHashrate float64
+				for _, m := range stats.Miners {
+					minerCount++
+					nodeHashrate += m.Hashrate
+					node.Miners = append(node.Miners, formatMinerLine(m.Name, m.Algorithm, m.Hashrate, m.Uptime, m.Shares, m.Rejected))
+				}
+				node.Hashrate = algos.FormatHashrate(nodeHashrate)
+				total += nodeHashrate
+			}
+			summary.Nodes = append(summary.Nodes, node)
+		}
+	}
+
+	summary.TotalHashrate = algos.FormatHashrate(total)
+	summary.Summary = fmt.Sprintf("%s on %s, %s total", plural(minerCount, "miner"), plural(len(summary.Nodes), "node"), summary.TotalHashrate)
+	if len(summary.Issues) > 0 {
+		summary.Summary += ", " + plural(len(summary.Issues), "issue")
+	}
+	if s.recentEvents != nil {
+		summary.RecentEvents = s.recentEvents.lines()
+	}
+	return summary
+}
+
+// handleFleetSummary godoc
+// @Summary Summarize the mining fleet
+// @Description Returns a compact summary of this node and every known peer: total and per-node hashrate, one line per miner, offline or unreachable peers and other issues, and the most recent notable events. Numbers are pre-formatted. Exposed to AI assistants as the fleet_summary MCP tool.
+// @Tags miners
+// @Produce json
+// @Success 200 {object} FleetSummary
+// @Router /fleet_summary [get]
+func (s *Service) handleFleetSummary(c *gin.Context) {
+	c.JSON(http.StatusOK, s.FleetSummary(c.Request.Context()))
+}
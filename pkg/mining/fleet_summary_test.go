@@ -0,0 +1,71 @@
+package mining
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatHashrate(t *testing.T) {
+	tests := map[float64]string{
+		950:     "950 H/s",
+		12345:   "12.35 kH/s",
+		1200000: "1.20 MH/s",
+	}
+	for in, want := range tests {
+		if got := formatHashrate(in, ""); got != want {
+			t.Errorf("formatHashrate(%v) = %q, want %q", in, got, want)
+		}
+	}
+	if got := formatHashrate(12345, "rx/0"); got != "12.35 kH/s" {
+		t.Errorf("formatHashrate with a registered algo = %q", got)
+	}
+	if got := formatUptime(2*3600 + 13*60 + 5); got != "2h13m" {
+		t.Errorf("formatUptime = %q, want 2h13m", got)
+	}
+}
+
+func TestFleetSummaryLocal(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	mockManager.ListMinersFunc = func() []Miner {
+		return []Miner{
+			&MockMiner{
+				GetNameFunc: func() string { return "xmrig-1" },
+				GetStatsFunc: func(ctx context.Context) (*PerformanceMetrics, error) {
+					return &PerformanceMetrics{Hashrate: 4200, Uptime: 7980, Shares: 99, Rejected: 1}, nil
+				},
+			},
+			&MockMiner{
+				GetNameFunc: func() string { return "xmrig-2" },
+				GetStatsFunc: func(ctx context.Context) (*PerformanceMetrics, error) {
+					return nil, errors.New("connection refused")
+				},
+			},
+		}
+	}
+
+	service := &Service{Manager: mockManager, recentEvents: &recentEvents{}}
+	service.recentEvents.OnEvent(Event{Type: EventMinerStats, Timestamp: time.Now()})
+	service.recentEvents.OnEvent(NewEvent(EventMinerError, MinerEventData{Name: "xmrig-2", Error: "pool refused"}))
+
+	summary := service.FleetSummary(context.Background())
+	if summary.Summary != "2 miners on 1 node, 4.20 kH/s total, 1 issue" {
+		t.Errorf("unexpected summary line %q", summary.Summary)
+	}
+	if len(summary.Nodes) != 1 || summary.Nodes[0].Miners[0] != "xmrig-1 4.20 kH/s up 2h13m 99% accepted" {
+		t.Errorf("unexpected nodes %+v", summary.Nodes)
+	}
+	if len(summary.RecentEvents) != 1 || !strings.HasSuffix(summary.RecentEvents[0], "miner.error xmrig-2: pool refused") {
+		t.Errorf("unexpected recent events %v", summary.RecentEvents)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fleet_summary", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
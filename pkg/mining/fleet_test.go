@@ -0,0 +1,189 @@
+package mining
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Snider/Mining/pkg/node"
+)
+
+// fakeFleetController records commands and serves miners from an in-memory map.
+type fakeFleetController struct {
+	mu       sync.Mutex
+	miners   map[string][]node.MinerStatsItem // peer ID -> running miners
+	commands []string
+	statsErr error
+}
+
+func (f *fakeFleetController) GetRemoteStats(peerID string) (*node.StatsPayload, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.statsErr != nil {
+		return nil, f.statsErr
+	}
+	return &node.StatsPayload{NodeID: peerID, Miners: append([]node.MinerStatsItem{}, f.miners[peerID]...)}, nil
+}
+
+func (f *fakeFleetController) DeployProfile(peerID string, profileJSON []byte, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, "deploy "+peerID+" "+name)
+	return nil
+}
+
+func (f *fakeFleetController) StartRemoteMiner(peerID, minerType, profileID string, configOverride json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, "start "+peerID+" "+profileID)
+	f.miners[peerID] = append(f.miners[peerID], node.MinerStatsItem{Name: "simulated-miner-rx/0", Type: minerType})
+	return nil
+}
+
+func (f *fakeFleetController) StopRemoteMiner(peerID, minerName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, "stop "+peerID+" "+minerName)
+	var kept []node.MinerStatsItem
+	for _, m := range f.miners[peerID] {
+		if m.Name != minerName {
+			kept = append(kept, m)
+		}
+	}
+	f.miners[peerID] = kept
+	return nil
+}
+
+func (f *fakeFleetController) takeCommands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	commands := f.commands
+	f.commands = nil
+	return commands
+}
+
+func setupTestFleet(t *testing.T) (*FleetReconciler, *fakeFleetController, *MiningProfile) {
+	t.Helper()
+	profiles := &ProfileManager{
+		profiles:   make(map[string]*MiningProfile),
+		configPath: filepath.Join(t.TempDir(), "mining_profiles.json"),
+	}
+	profile := &MiningProfile{ID: "sim-rx0", Name: "Sim RX/0", MinerType: MinerTypeSimulated, Config: RawConfig(`{"pool":"stratum+tcp://pool:3333","algo":"rx/0"}`)}
+	if err := profiles.SaveProfile(profile); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	controller := &fakeFleetController{miners: map[string][]node.MinerStatsItem{
+		"peer-b": {{Name: "xmrig-stray", Type: "xmrig"}},
+	}}
+	peers := func() []*node.Peer {
+		return []*node.Peer{
+			{ID: "peer-a", Name: "a", Role: node.RoleWorker},
+			{ID: "peer-b", Name: "b", Role: node.RoleWorker},
+			{ID: "peer-c", Name: "c", Role: node.RoleController},
+		}
+	}
+
+	r, err := newFleetReconciler(filepath.Join(t.TempDir(), fleetStateFileName), controller, peers, profiles, 0)
+	if err != nil {
+		t.Fatalf("newFleetReconciler failed: %v", err)
+	}
+	return r, controller, profile
+}
+
+func TestFleetReconcilerConverges(t *testing.T) {
+	r, controller, profile := setupTestFleet(t)
+
+	if err := r.SetDesiredState(FleetDesiredState{Roles: map[string][]string{"worker": {profile.ID}}}); err != nil {
+		t.Fatalf("SetDesiredState failed: %v", err)
+	}
+
+	status := r.Status()
+	if status.InSync || len(status.Workers) != 2 {
+		t.Fatalf("expected drift on 2 workers (controller skipped), got %+v", status)
+	}
+	if len(controller.takeCommands()) != 0 {
+		t.Fatal("Status must not issue commands")
+	}
+	b := status.Workers[1]
+	if b.PeerID != "peer-b" || len(b.Actions) != 2 || b.Actions[0].Type != FleetActionStart || b.Actions[1].Type != FleetActionStop || b.Actions[1].MinerName != "xmrig-stray" {
+		t.Errorf("unexpected plan for peer-b: %+v", b.Actions)
+	}
+
+	status = r.Reconcile(false)
+	if status.LastReconciled == nil {
+		t.Error("expected lastReconciled to be set after executing")
+	}
+	want := []string{
+		"deploy peer-a Sim RX/0", "start peer-a sim-rx0",
+		"deploy peer-b Sim RX/0", "start peer-b sim-rx0", "stop peer-b xmrig-stray",
+	}
+	if got := controller.takeCommands(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected commands %v, got %v", want, got)
+	}
+
+	// A second pass finds nothing to do
+	if status = r.Reconcile(false); !status.InSync {
+		t.Errorf("expected fleet in sync, got %+v", status.Workers)
+	}
+	if got := controller.takeCommands(); len(got) != 0 {
+		t.Errorf("expected idempotent reconcile, got commands %v", got)
+	}
+}
+
+func TestFleetReconcilerDryRun(t *testing.T) {
+	r, controller, profile := setupTestFleet(t)
+
+	state := FleetDesiredState{Workers: map[string][]string{"peer-a": {profile.ID}}, DryRun: true}
+	if err := r.SetDesiredState(state); err != nil {
+		t.Fatalf("SetDesiredState failed: %v", err)
+	}
+
+	status := r.Reconcile(false)
+	if len(status.Workers) != 1 || len(status.Workers[0].Actions) != 1 || status.Workers[0].Actions[0].Executed {
+		t.Fatalf("expected one planned, unexecuted action, got %+v", status.Workers)
+	}
+	if got := controller.takeCommands(); len(got) != 0 {
+		t.Errorf("dry-run issued commands: %v", got)
+	}
+}
+
+func TestFleetReconcilerUnreachableWorker(t *testing.T) {
+	r, controller, profile := setupTestFleet(t)
+	controller.statsErr = fmt.Errorf("peer not connected")
+
+	if err := r.SetDesiredState(FleetDesiredState{Workers: map[string][]string{"peer-a": {profile.ID}}}); err != nil {
+		t.Fatalf("SetDesiredState failed: %v", err)
+	}
+	status := r.Reconcile(false)
+	if status.InSync || status.Workers[0].Error == "" {
+		t.Errorf("expected unreachable worker to be reported, got %+v", status.Workers)
+	}
+}
+
+func TestFleetDesiredStateValidationAndPersistence(t *testing.T) {
+	r, controller, profile := setupTestFleet(t)
+
+	if err := r.SetDesiredState(FleetDesiredState{Workers: map[string][]string{"peer-a": {"missing"}}}); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+	if err := r.SetDesiredState(FleetDesiredState{Roles: map[string][]string{"controller": {profile.ID}}}); err == nil {
+		t.Error("expected error for controller role")
+	}
+
+	state := FleetDesiredState{Workers: map[string][]string{"peer-a": {profile.ID}}, DryRun: true}
+	if err := r.SetDesiredState(state); err != nil {
+		t.Fatalf("SetDesiredState failed: %v", err)
+	}
+
+	reloaded, err := newFleetReconciler(r.statePath, controller, r.peers, r.profiles, 0)
+	if err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	got := reloaded.DesiredState()
+	if !got.DryRun || len(got.Workers["peer-a"]) != 1 {
+		t.Errorf("desired state not persisted, got %+v", got)
+	}
+}
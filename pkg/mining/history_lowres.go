@@ -0,0 +1,89 @@
+package mining
+
+import (
+	"context"
+	"time"
+
+	"github.com/Snider/Mining/pkg/database"
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// defaultLowResQuerySpan is the range length above which historical hashrate
+// queries read the minute-averaged low resolution history, unless
+// lowResQueryHours is set in the database config.
+const defaultLowResQuerySpan = 6 * time.Hour
+
+// lowResBucket accumulates a miner's high resolution hashrates for one
+// LowResolutionInterval, mirroring the in-memory ReduceHashrateHistory averages.
+type lowResBucket struct {
+	minerType string
+	start     time.Time
+	total     int
+	count     int
+}
+
+func (b *lowResBucket) point() database.HashratePoint {
+	return database.HashratePoint{Timestamp: b.start, Hashrate: b.total / b.count}
+}
+
+// addLowResSample adds a persisted high resolution point to the miner's
+// current minute, and returns the previous minute's average once a point
+// lands in a new minute.
+func (m *Manager) addLowResSample(minerName, minerType string, point HashratePoint) (database.HashratePoint, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lowResBuckets == nil {
+		m.lowResBuckets = make(map[string]*lowResBucket)
+	}
+	start := point.Timestamp.Truncate(LowResolutionInterval)
+	bucket, ok := m.lowResBuckets[minerName]
+	if ok && bucket.start.Equal(start) {
+		bucket.total += point.Hashrate
+		bucket.count++
+		return database.HashratePoint{}, false
+	}
+
+	m.lowResBuckets[minerName] = &lowResBucket{minerType: minerType, start: start, total: point.Hashrate, count: 1}
+	if !ok {
+		return database.HashratePoint{}, false
+	}
+	return bucket.point(), true
+}
+
+// persistLowResPoint writes a minute average to the database.
+func persistLowResPoint(minerName, minerType string, point database.HashratePoint) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsCollectionTimeout)
+	defer cancel()
+	if err := database.InsertHashratePoint(ctx, minerName, minerType, point, database.ResolutionLow); err != nil {
+		logging.Warn("failed to persist low resolution hashrate", logging.Fields{"miner": minerName, "error": err})
+	}
+}
+
+// takeLowResBucket removes a stopped miner's partial minute so it can be
+// persisted. Caller must hold m.mu.
+func (m *Manager) takeLowResBucket(minerName string) *lowResBucket {
+	bucket, ok := m.lowResBuckets[minerName]
+	if !ok {
+		return nil
+	}
+	delete(m.lowResBuckets, minerName)
+	return bucket
+}
+
+// historyResolution picks the stored resolution to answer a query over
+// [since, until]: high resolution for short ranges, or once high resolution
+// rows for the start of the range have been cleaned up, low resolution.
+func (m *Manager) historyResolution(since, until time.Time) database.Resolution {
+	span := m.dbLowResSpan
+	if span == 0 {
+		span = defaultLowResQuerySpan
+	}
+	if until.Sub(since) > span {
+		return database.ResolutionLow
+	}
+	if m.dbHighResRetention > 0 && since.Before(time.Now().AddDate(0, 0, -m.dbHighResRetention)) {
+		return database.ResolutionLow
+	}
+	return database.ResolutionHigh
+}
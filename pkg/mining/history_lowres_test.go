@@ -0,0 +1,106 @@
+package mining
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Snider/Mining/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAddLowResSampleAveragesPerMinute(t *testing.T) {
+	m := &Manager{}
+	minute := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, hashrate := range []int{100, 200, 300} {
+		point := HashratePoint{Timestamp: minute.Add(time.Duration(i*10) * time.Second), Hashrate: hashrate}
+		if _, ok := m.addLowResSample("avg", "xmrig", point); ok {
+			t.Fatalf("no minute should complete within the first minute (sample %d)", i)
+		}
+	}
+
+	lowRes, ok := m.addLowResSample("avg", "xmrig", HashratePoint{Timestamp: minute.Add(time.Minute), Hashrate: 50})
+	if !ok {
+		t.Fatal("expected the first minute to complete")
+	}
+	if !lowRes.Timestamp.Equal(minute) || lowRes.Hashrate != 200 {
+		t.Errorf("expected 200 H/s at %v, got %+v", minute, lowRes)
+	}
+
+	m.mu.Lock()
+	bucket := m.takeLowResBucket("avg")
+	m.mu.Unlock()
+	if bucket == nil || bucket.point().Hashrate != 50 || bucket.minerType != "xmrig" {
+		t.Errorf("expected the partial minute to be kept, got %+v", bucket)
+	}
+}
+
+func TestHistoryResolution(t *testing.T) {
+	m := &Manager{dbRetention: 30, dbHighResRetention: 7}
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+		want         database.Resolution
+	}{
+		{"last hour", now.Add(-time.Hour), now, database.ResolutionHigh},
+		{"last week", now.AddDate(0, 0, -7), now, database.ResolutionLow},
+		{"short range past high res retention", now.AddDate(0, 0, -10), now.AddDate(0, 0, -10).Add(time.Hour), database.ResolutionLow},
+	}
+	for _, tt := range tests {
+		if got := m.historyResolution(tt.since, tt.until); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestHandleMinerHistoricalHashrateResolution(t *testing.T) {
+	if err := database.Initialize(database.Config{Enabled: true, Path: filepath.Join(t.TempDir(), "history.db")}); err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	now := time.Now()
+	database.InsertHashratePoint(nil, "res-miner", "xmrig", database.HashratePoint{Timestamp: now.Add(-time.Hour), Hashrate: 100}, database.ResolutionHigh)
+	database.InsertHashratePoint(nil, "res-miner", "xmrig", database.HashratePoint{Timestamp: now.Add(-time.Hour), Hashrate: 90}, database.ResolutionLow)
+
+	m := &Manager{dbEnabled: true, dbRetention: 30, dbHighResRetention: 7}
+	router := gin.New()
+	service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	tests := []struct {
+		query    string
+		want     string
+		hashrate int
+	}{
+		{"?since=" + now.Add(-2*time.Hour).Format(time.RFC3339), "high", 100},
+		{"", "low", 90}, // defaults to the last 24 hours
+		{"?resolution=high", "high", 100},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/miners/res-miner/hashrate"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d: %s", tt.query, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("X-Hashrate-Resolution"); got != tt.want {
+			t.Errorf("%q: expected resolution %s, got %s", tt.query, tt.want, got)
+		}
+		var points []HashratePoint
+		if err := json.Unmarshal(w.Body.Bytes(), &points); err != nil || len(points) != 1 || points[0].Hashrate != tt.hashrate {
+			t.Errorf("%q: expected one %d H/s point, got %s", tt.query, tt.hashrate, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/miners/res-miner/hashrate?resolution=daily", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown resolution, got %d", w.Code)
+	}
+}
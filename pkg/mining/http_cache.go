@@ -0,0 +1,61 @@
+package mining
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etagEpoch sets apart the ETags of each run of the service, whose version
+// counters start again from zero.
+var etagEpoch = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// versionETag returns a weak ETag for version of the named resource. Weak
+// because the version tracks what changed, not every byte of the body.
+func versionETag(name string, version uint64) string {
+	return fmt.Sprintf(`W/"%s-%s-%d"`, name, etagEpoch, version)
+}
+
+// hashETag returns a weak ETag for the JSON of v.
+func hashETag(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// notModified sets the response's ETag and, if the request's If-None-Match
+// already has it, responds 304 Not Modified and returns true.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
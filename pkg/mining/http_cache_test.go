@@ -0,0 +1,94 @@
+package mining
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`W/"a"`, true},
+		{`"a"`, true}, // Weak comparison
+		{`"b", W/"a"`, true},
+		{`"b"`, false},
+		{"*", true},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `W/"a"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestHandleListMinersETag(t *testing.T) {
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	router := gin.New()
+	service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	list := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/miners", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := list("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected a list with an ETag, got %d %q", first.Code, etag)
+	}
+	if w := list(etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected %d with an empty body while unchanged, got %d", http.StatusNotModified, w.Code)
+	}
+
+	miner, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet"})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	defer miner.Stop()
+	started := list(etag)
+	if started.Code != http.StatusOK || started.Header().Get("ETag") == etag {
+		t.Fatalf("expected a new list after a start, got %d", started.Code)
+	}
+
+	// A state change is a change too
+	etag = started.Header().Get("ETag")
+	m.mu.Lock()
+	m.transitionMinerState(miner.GetName(), MinerStateRunning)
+	m.mu.Unlock()
+	if w := list(etag); w.Code != http.StatusOK {
+		t.Errorf("expected a new list after a state change, got %d", w.Code)
+	}
+}
+
+func TestHandleGetInfoETag(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/info", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected info with an ETag, got %d %q", w.Code, etag)
+	}
+
+	// The timestamp differs between checks but isn't a change
+	req := httptest.NewRequest(http.MethodGet, "/info", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected %d while unchanged, got %d", http.StatusNotModified, w.Code)
+	}
+}
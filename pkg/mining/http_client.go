@@ -0,0 +1,187 @@
+package mining
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// HTTPProfile tunes the HTTP client used for one kind of outbound request.
+type HTTPProfile struct {
+	// ConnectTimeout bounds establishing the TCP (and TLS) connection
+	ConnectTimeout time.Duration `json:"connectTimeout"`
+	// ResponseTimeout bounds waiting for the response headers once the request is sent
+	ResponseTimeout time.Duration `json:"responseTimeout"`
+	// Timeout bounds the whole request including reading the body; 0 means no limit
+	Timeout time.Duration `json:"timeout"`
+	// MaxIdleConnsPerHost is how many keep-alive connections are kept per host
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost"`
+	// Retries is how many times a failed GET is retried; 0 disables retries
+	Retries int `json:"retries"`
+	// RetryBackoff is the wait before the first retry, doubled for each one after
+	RetryBackoff time.Duration `json:"retryBackoff"`
+}
+
+// HTTPClientConfig holds the HTTP profiles for miner stats APIs, which are
+// local and polled often, and for downloads and release checks, which go to
+// the internet and may be large.
+type HTTPClientConfig struct {
+	Stats    HTTPProfile `json:"stats"`
+	Download HTTPProfile `json:"download"`
+}
+
+// DefaultHTTPClientConfig returns the default HTTP client configuration.
+// Stats requests fail fast so a stuck miner API can't hold up a poll;
+// downloads get long timeouts and a few retries.
+func DefaultHTTPClientConfig() HTTPClientConfig {
+	return HTTPClientConfig{
+		Stats: HTTPProfile{
+			ConnectTimeout:      2 * time.Second,
+			ResponseTimeout:     3 * time.Second,
+			Timeout:             statsTimeout,
+			MaxIdleConnsPerHost: 10,
+		},
+		Download: HTTPProfile{
+			ConnectTimeout:      10 * time.Second,
+			ResponseTimeout:     30 * time.Second,
+			Timeout:             30 * time.Minute,
+			MaxIdleConnsPerHost: 2,
+			Retries:             3,
+			RetryBackoff:        2 * time.Second,
+		},
+	}
+}
+
+// HTTPClientConfigFromEnv creates HTTP client config from environment variables.
+// MINING_HTTP_STATS_TIMEOUT and MINING_HTTP_DOWNLOAD_TIMEOUT take Go durations
+// such as "5s"; MINING_HTTP_STATS_RETRIES and MINING_HTTP_DOWNLOAD_RETRIES take counts.
+func HTTPClientConfigFromEnv() HTTPClientConfig {
+	config := DefaultHTTPClientConfig()
+
+	if d, err := time.ParseDuration(os.Getenv("MINING_HTTP_STATS_TIMEOUT")); err == nil && d > 0 {
+		config.Stats.Timeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("MINING_HTTP_DOWNLOAD_TIMEOUT")); err == nil && d >= 0 {
+		config.Download.Timeout = d
+	}
+	if v, err := strconv.Atoi(os.Getenv("MINING_HTTP_STATS_RETRIES")); err == nil && v >= 0 {
+		config.Stats.Retries = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("MINING_HTTP_DOWNLOAD_RETRIES")); err == nil && v >= 0 {
+		config.Download.Retries = v
+	}
+
+	return config
+}
+
+// newHTTPClient builds a client for a profile.
+func newHTTPClient(profile HTTPProfile) *http.Client {
+	dialer := &net.Dialer{Timeout: profile.ConnectTimeout, KeepAlive: 30 * time.Second}
+	return &http.Client{
+		Timeout: profile.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   profile.ConnectTimeout,
+			ResponseHeaderTimeout: profile.ResponseTimeout,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   profile.MaxIdleConnsPerHost,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
+
+var (
+	httpClientConfig    = DefaultHTTPClientConfig()
+	statsHTTPClient     = newHTTPClient(httpClientConfig.Stats)
+	downloadHTTPClient  = newHTTPClient(httpClientConfig.Download)
+	httpClientMu        sync.RWMutex
+	httpRetryBackoffCap = 30 * time.Second
+)
+
+// SetHTTPClientConfig replaces the stats and download HTTP clients.
+func SetHTTPClientConfig(config HTTPClientConfig) {
+	httpClientMu.Lock()
+	defer httpClientMu.Unlock()
+	httpClientConfig = config
+	statsHTTPClient = newHTTPClient(config.Stats)
+	downloadHTTPClient = newHTTPClient(config.Download)
+}
+
+// GetHTTPClientConfig returns the current HTTP client configuration.
+func GetHTTPClientConfig() HTTPClientConfig {
+	httpClientMu.RLock()
+	defer httpClientMu.RUnlock()
+	return httpClientConfig
+}
+
+// getHTTPClient returns the client for downloads and release checks
+func getHTTPClient() *http.Client {
+	httpClientMu.RLock()
+	defer httpClientMu.RUnlock()
+	return downloadHTTPClient
+}
+
+// getStatsHTTPClient returns the client for miner stats APIs
+func getStatsHTTPClient() *http.Client {
+	httpClientMu.RLock()
+	defer httpClientMu.RUnlock()
+	return statsHTTPClient
+}
+
+// setHTTPClient sets both the stats and download HTTP clients (for testing)
+func setHTTPClient(client *http.Client) {
+	httpClientMu.Lock()
+	defer httpClientMu.Unlock()
+	statsHTTPClient = client
+	downloadHTTPClient = client
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// httpGetWithRetry sends a GET, retrying network errors, 429s and 5xx
+// responses as the profile allows. The last response is returned as is, so
+// the caller still checks its status and must close its body.
+func httpGetWithRetry(ctx context.Context, client *http.Client, profile HTTPProfile, url string, header http.Header) (*http.Response, error) {
+	backoff := profile.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+
+		resp, err := client.Do(req)
+		if attempt >= profile.Retries || ctx.Err() != nil || (err == nil && !retryableStatus(resp.StatusCode)) {
+			return resp, err
+		}
+		if err == nil {
+			io.Copy(io.Discard, resp.Body) // Drain body to allow connection reuse
+			resp.Body.Close()
+			err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		logging.Debug("retrying HTTP GET", logging.Fields{"url": url, "attempt": attempt + 1, "error": err, "backoff": backoff})
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if backoff *= 2; backoff > httpRetryBackoffCap {
+			backoff = httpRetryBackoffCap
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package mining
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPGetWithRetry(t *testing.T) {
+	var requests atomic.Int32
+	failures := int32(2)
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	profile := HTTPProfile{Retries: 3, RetryBackoff: time.Millisecond}
+	resp, err := httpGetWithRetry(context.Background(), server.Client(), profile, server.URL, nil)
+	if err != nil {
+		t.Fatalf("httpGetWithRetry failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 3 {
+		t.Fatalf("Expected success on the third attempt, got %d after %d", resp.StatusCode, requests.Load())
+	}
+
+	// Client errors are not retried
+	requests.Store(0)
+	status = http.StatusNotFound
+	resp, err = httpGetWithRetry(context.Background(), server.Client(), profile, server.URL, nil)
+	if err != nil {
+		t.Fatalf("httpGetWithRetry failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || requests.Load() != 1 {
+		t.Fatalf("Expected a single 404, got %d after %d", resp.StatusCode, requests.Load())
+	}
+
+	// Without retries the first failure is returned
+	requests.Store(0)
+	status = http.StatusBadGateway
+	resp, err = httpGetWithRetry(context.Background(), server.Client(), HTTPProfile{}, server.URL, nil)
+	if err != nil {
+		t.Fatalf("httpGetWithRetry failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || requests.Load() != 1 {
+		t.Fatalf("Expected a single 502, got %d after %d", resp.StatusCode, requests.Load())
+	}
+}
+
+func TestHTTPGetWithRetryStopsOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := httpGetWithRetry(ctx, server.Client(), HTTPProfile{Retries: 10, RetryBackoff: time.Second}, server.URL, nil)
+	if err == nil || time.Since(start) > time.Second {
+		t.Fatalf("Expected the retry wait to end with the context, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestHTTPClientConfigFromEnv(t *testing.T) {
+	t.Setenv("MINING_HTTP_STATS_TIMEOUT", "1500ms")
+	t.Setenv("MINING_HTTP_DOWNLOAD_TIMEOUT", "0")
+	t.Setenv("MINING_HTTP_STATS_RETRIES", "1")
+	t.Setenv("MINING_HTTP_DOWNLOAD_RETRIES", "not-a-number")
+
+	config := HTTPClientConfigFromEnv()
+	if config.Stats.Timeout != 1500*time.Millisecond || config.Stats.Retries != 1 {
+		t.Errorf("unexpected stats profile: %+v", config.Stats)
+	}
+	if config.Download.Timeout != 0 || config.Download.Retries != DefaultHTTPClientConfig().Download.Retries {
+		t.Errorf("unexpected download profile: %+v", config.Download)
+	}
+}
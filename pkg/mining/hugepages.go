@@ -0,0 +1,80 @@
+package mining
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// HugePagesInfo reports the operating system's huge page reservation.
+type HugePagesInfo struct {
+	Supported  bool   `json:"supported"`
+	Total      int    `json:"total"`
+	Free       int    `json:"free"`
+	PageSizeKB int    `json:"pageSizeKb,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// meminfoPath is where huge page counters are read from on Linux (a var for tests).
+var meminfoPath = "/proc/meminfo"
+
+// GetHugePagesInfo returns the OS huge page availability. Only Linux exposes
+// this in a readable form; other platforms report unsupported with guidance.
+func GetHugePagesInfo() HugePagesInfo {
+	if runtime.GOOS != "linux" {
+		return HugePagesInfo{Message: "huge page reporting is only available on Linux; on Windows grant the 'Lock pages in memory' privilege"}
+	}
+
+	f, err := os.Open(meminfoPath)
+	if err != nil {
+		return HugePagesInfo{Message: "could not read " + meminfoPath + ": " + err.Error()}
+	}
+	defer f.Close()
+
+	info := parseMeminfoHugePages(f)
+	if info.Total == 0 {
+		info.Message = "no huge pages reserved; run 'sysctl -w vm.nr_hugepages=1280' or start the miner as root"
+	}
+	return info
+}
+
+// parseMeminfoHugePages extracts the huge page counters from /proc/meminfo.
+func parseMeminfoHugePages(r io.Reader) HugePagesInfo {
+	info := HugePagesInfo{Supported: true}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "HugePages_Total":
+			info.Total = n
+		case "HugePages_Free":
+			info.Free = n
+		case "Hugepagesize":
+			info.PageSizeKB = n
+		}
+	}
+	return info
+}
+
+// hugePagesInactive reports whether a miner asked for huge pages but the
+// stats show fewer allocated than it wanted.
+func hugePagesInactive(config Config, stats *PerformanceMetrics) bool {
+	if !config.HugePages || stats == nil || stats.HugePagesTotal == 0 {
+		return false
+	}
+	return stats.HugePagesAllocated < stats.HugePagesTotal
+}
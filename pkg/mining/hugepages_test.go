@@ -0,0 +1,82 @@
+package mining
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMeminfoHugePages(t *testing.T) {
+	meminfo := `MemTotal:       16314932 kB
+MemFree:         1234567 kB
+HugePages_Total:    1280
+HugePages_Free:     1200
+HugePages_Rsvd:        0
+Hugepagesize:       2048 kB
+`
+	info := parseMeminfoHugePages(strings.NewReader(meminfo))
+	if !info.Supported || info.Total != 1280 || info.Free != 1200 || info.PageSizeKB != 2048 {
+		t.Errorf("unexpected huge pages info: %+v", info)
+	}
+}
+
+func TestHugePagesInactive(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		stats *PerformanceMetrics
+		want  bool
+	}{
+		{"not requested", Config{}, &PerformanceMetrics{HugePagesTotal: 10}, false},
+		{"fully allocated", Config{HugePages: true}, &PerformanceMetrics{HugePagesAllocated: 10, HugePagesTotal: 10}, false},
+		{"partially allocated", Config{HugePages: true}, &PerformanceMetrics{HugePagesAllocated: 4, HugePagesTotal: 10}, true},
+		{"not reported", Config{HugePages: true}, &PerformanceMetrics{}, false},
+		{"no stats", Config{HugePages: true}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hugePagesInactive(tt.cfg, tt.stats); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestManagerWarnsWhenHugePagesInactive(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+	m.SetEventHub(hub)
+
+	received := make(chan Event, 4)
+	defer m.SubscribeEvents(EventSinkFunc(func(e Event) {
+		if e.Type == EventMinerWarning {
+			received <- e
+		}
+	}))()
+
+	m.launches["xmrig-rx_0"] = &MinerLaunchInfo{Config: Config{HugePages: true}}
+	stats := &PerformanceMetrics{HugePagesAllocated: 0, HugePagesTotal: 1168}
+
+	m.checkHugePages("xmrig-rx_0", stats)
+	m.checkHugePages("xmrig-rx_0", stats)
+
+	select {
+	case e := <-received:
+		data, ok := e.Data.(MinerEventData)
+		if !ok || data.Name != "xmrig-rx_0" || !strings.Contains(data.Reason, "0/1168") {
+			t.Errorf("unexpected warning data: %+v", e.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a huge pages warning event")
+	}
+
+	select {
+	case e := <-received:
+		t.Errorf("warning should only be emitted once per run, got %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
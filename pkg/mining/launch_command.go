@@ -0,0 +1,154 @@
+package mining
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LaunchCommand is how a miner process was actually executed: the binary,
+// argv and environment Start handed to the OS, with secrets masked.
+type LaunchCommand struct {
+	Binary      string            `json:"binary"`
+	Args        []string          `json:"args"`                // argv after the binary
+	CommandLine string            `json:"commandLine"`         // Shell-quoted, env overrides first
+	WorkingDir  string            `json:"workingDir"`          // cmd.Dir, or the manager's directory when unset
+	Env         map[string]string `json:"env"`                 // Variables set on top of the inherited environment
+	InheritsEnv bool              `json:"inheritsEnvironment"` // The process also received the manager's environment
+	PID         int               `json:"pid,omitempty"`
+	StartedAt   time.Time         `json:"startedAt"`
+}
+
+// launchCommandReporter is implemented by miners that run an OS process.
+type launchCommandReporter interface {
+	LaunchCommand() (*LaunchCommand, error)
+}
+
+// secretArgFlags are miner flags whose value is a credential.
+var secretArgFlags = map[string]bool{
+	"-p": true, "--pass": true, "--password": true, "--user-pass": true,
+	"--http-access-token": true, "--api-access-token": true, "--api-token": true,
+}
+
+// secretEnvKey matches environment variable names holding credentials.
+var secretEnvKey = regexp.MustCompile(`(?i)(PASS|TOKEN|SECRET|KEY)`)
+
+// maskLaunchArgs returns args with the values of secret flags, and any
+// argument containing one of the config's secrets, replaced.
+func maskLaunchArgs(args []string, config *Config) []string {
+	var secrets []string
+	if config != nil {
+		for _, s := range []string{config.Password, config.UserPass, config.GPUPassword, config.HTTPAccessToken} {
+			if s != "" {
+				secrets = append(secrets, s)
+			}
+		}
+	}
+
+	masked := make([]string, len(args))
+	for i, arg := range args {
+		masked[i] = arg
+		if i > 0 && secretArgFlags[strings.ToLower(args[i-1])] {
+			masked[i] = maskedSecret
+			continue
+		}
+		if flag, _, ok := strings.Cut(arg, "="); ok && secretArgFlags[strings.ToLower(flag)] {
+			masked[i] = flag + "=" + maskedSecret
+			continue
+		}
+		for _, s := range secrets {
+			if strings.Contains(arg, s) {
+				masked[i] = strings.ReplaceAll(arg, s, maskedSecret)
+			}
+		}
+	}
+	return masked
+}
+
+// envOverrides returns the variables in env that differ from the manager's
+// own environment, with secret values masked.
+func envOverrides(env []string) map[string]string {
+	overrides := make(map[string]string)
+	inherited := make(map[string]string)
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		inherited[k] = v
+	}
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		if old, ok := inherited[k]; ok && old == v {
+			continue
+		}
+		if secretEnvKey.MatchString(k) && v != "" {
+			v = maskedSecret
+		}
+		overrides[k] = v
+	}
+	return overrides
+}
+
+var shellSafeArg = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes arg for a POSIX shell if it needs it.
+func shellQuote(arg string) string {
+	if shellSafeArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// newLaunchCommand captures what cmd was started with.
+func newLaunchCommand(cmd *exec.Cmd, config *Config) *LaunchCommand {
+	launch := &LaunchCommand{
+		Binary:      cmd.Path,
+		Args:        maskLaunchArgs(cmd.Args[1:], config),
+		WorkingDir:  cmd.Dir,
+		InheritsEnv: cmd.Env == nil,
+		Env:         map[string]string{},
+		StartedAt:   time.Now(),
+	}
+	if launch.WorkingDir == "" {
+		launch.WorkingDir, _ = os.Getwd()
+	}
+	if cmd.Env != nil {
+		launch.Env = envOverrides(cmd.Env)
+	}
+	if cmd.Process != nil {
+		launch.PID = cmd.Process.Pid
+	}
+
+	keys := make([]string, 0, len(launch.Env))
+	for k := range launch.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, k+"="+shellQuote(launch.Env[k]))
+	}
+	parts = append(parts, shellQuote(launch.Binary))
+	for _, arg := range launch.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	launch.CommandLine = strings.Join(parts, " ")
+	return launch
+}
+
+// LaunchCommand returns how the running process was executed.
+func (b *BaseMiner) LaunchCommand() (*LaunchCommand, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if !b.Running || b.launch == nil {
+		return nil, ErrProcessNotRunning
+	}
+	launch := *b.launch
+	launch.Args = append([]string(nil), b.launch.Args...)
+	launch.Env = make(map[string]string, len(b.launch.Env))
+	for k, v := range b.launch.Env {
+		launch.Env[k] = v
+	}
+	return &launch, nil
+}
@@ -0,0 +1,101 @@
+package mining
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaskLaunchArgs(t *testing.T) {
+	config := &Config{Wallet: "4abc", Password: "hunter2", HTTPAccessToken: "tok"}
+	args := []string{"-o", "pool:3333", "-u", "4abc", "-p", "x", "--http-access-token=tok", "--rig-id=hunter2-rig"}
+	got := maskLaunchArgs(args, config)
+	want := []string{"-o", "pool:3333", "-u", "4abc", "-p", maskedSecret, "--http-access-token=" + maskedSecret, "--rig-id=" + maskedSecret + "-rig"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if args[5] != "x" {
+		t.Error("maskLaunchArgs must not modify its input")
+	}
+}
+
+func TestLaunchCommandRecordedOnStart(t *testing.T) {
+	b := &BaseMiner{Name: "proc"}
+	b.cmd = exec.Command("sleep", "5", "-p", "secret")
+	b.cmd.Env = append(b.cmd.Environ(), "GPU_FORCE_64BIT_PTR=1", "POOL_PASSWORD=secret")
+	if err := b.startProcess(&Config{}); err != nil {
+		t.Fatalf("startProcess: %v", err)
+	}
+	defer func() {
+		b.cmd.Process.Kill()
+		b.cmd.Wait()
+	}()
+
+	if _, err := b.LaunchCommand(); err == nil {
+		t.Error("expected an error before the miner is marked running")
+	}
+	b.Running = true
+
+	launch, err := b.LaunchCommand()
+	if err != nil {
+		t.Fatalf("LaunchCommand: %v", err)
+	}
+	if !strings.HasSuffix(launch.Binary, "sleep") || launch.PID != b.cmd.Process.Pid || launch.WorkingDir == "" {
+		t.Errorf("unexpected launch command: %+v", launch)
+	}
+	if strings.Join(launch.Args, " ") != "5 -p "+maskedSecret {
+		t.Errorf("expected masked args, got %q", launch.Args)
+	}
+	if launch.InheritsEnv || len(launch.Env) != 2 || launch.Env["GPU_FORCE_64BIT_PTR"] != "1" || launch.Env["POOL_PASSWORD"] != maskedSecret {
+		t.Errorf("expected only the two overrides, masked, got %v", launch.Env)
+	}
+	if !strings.HasPrefix(launch.CommandLine, "GPU_FORCE_64BIT_PTR=1 POOL_PASSWORD='"+maskedSecret+"' ") {
+		t.Errorf("unexpected command line %q", launch.CommandLine)
+	}
+}
+
+func TestHandleMinerLaunchCommand(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	m.miners["sim"] = NewSimulatedMiner(SimulatedMinerConfig{Name: "sim"})
+
+	proc := &XMRigMiner{BaseMiner: BaseMiner{Name: "xmrig-proc"}}
+	proc.cmd = exec.Command("sleep", "5")
+	if err := proc.startProcess(&Config{}); err != nil {
+		t.Fatalf("startProcess: %v", err)
+	}
+	defer func() {
+		proc.cmd.Process.Kill()
+		proc.cmd.Wait()
+	}()
+	proc.Running = true
+	m.miners["xmrig-proc"] = proc
+
+	router := gin.New()
+	service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/miners/xmrig-proc/launch-info", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var launch LaunchCommand
+	if err := json.Unmarshal(w.Body.Bytes(), &launch); err != nil {
+		t.Fatal(err)
+	}
+	if !launch.InheritsEnv || len(launch.Args) != 1 || launch.Args[0] != "5" {
+		t.Errorf("unexpected launch command: %+v", launch)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/miners/sim/launch-info", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a miner without a process, got %d", w.Code)
+	}
+}
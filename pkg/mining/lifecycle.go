@@ -0,0 +1,146 @@
+package mining
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MinerState is where a miner the manager tracks is in its lifecycle.
+type MinerState string
+
+const (
+	MinerStateStarting MinerState = "starting" // Process launched, stats API not answering yet
+	MinerStateRunning  MinerState = "running"
+	MinerStatePaused   MinerState = "paused" // Paused with the pause console command
+	MinerStateStopping MinerState = "stopping"
+	MinerStateStopped  MinerState = "stopped"
+	MinerStateErrored  MinerState = "errored" // The process exited without being stopped
+)
+
+// minerStateTransitions lists the states each state may move to. Stopped is final.
+var minerStateTransitions = map[MinerState][]MinerState{
+	MinerStateStarting: {MinerStateRunning, MinerStateStopping, MinerStateErrored},
+	MinerStateRunning:  {MinerStatePaused, MinerStateStopping, MinerStateErrored},
+	MinerStatePaused:   {MinerStateRunning, MinerStateStopping, MinerStateErrored},
+	MinerStateStopping: {MinerStateStopped, MinerStateErrored},
+	MinerStateErrored:  {MinerStateStarting, MinerStateStopping}, // Starting when the crash supervisor restarts it
+}
+
+// CanTransitionTo reports whether a miner in state s may move to next.
+func (s MinerState) CanTransitionTo(next MinerState) bool {
+	for _, allowed := range minerStateTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInvalidStateTransition creates an error for a lifecycle change a miner's current state doesn't allow
+func ErrInvalidStateTransition(name string, from, to MinerState) *MiningError {
+	allowed := make([]string, 0, len(minerStateTransitions[from]))
+	for _, s := range minerStateTransitions[from] {
+		allowed = append(allowed, string(s))
+	}
+	return &MiningError{
+		Code:       ErrCodeInvalidInput,
+		Message:    fmt.Sprintf("miner '%s' is %s and can't become %s", name, from, to),
+		Details:    "allowed next states: " + strings.Join(allowed, ", "),
+		Suggestion: "Check the miner's state with GET /miners and retry once it has changed",
+		Retryable:  false,
+		HTTPStatus: http.StatusConflict,
+	}
+}
+
+// stateReporter is implemented by miners that include their state in their JSON.
+type stateReporter interface {
+	setState(state MinerState)
+}
+
+func (b *BaseMiner) setState(state MinerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.State = state
+}
+
+func (m *SimulatedMiner) setState(state MinerState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.State = state
+}
+
+// initMinerState records the first state of a miner being added. Caller must hold m.mu.
+func (m *Manager) initMinerState(name string, miner Miner, state MinerState) {
+	if m.states == nil {
+		m.states = make(map[string]MinerState)
+	}
+	m.states[name] = state
+	m.listVersion++
+	if reporter, ok := miner.(stateReporter); ok {
+		reporter.setState(state)
+	}
+}
+
+// MinersVersion returns a number that changes whenever a miner is started,
+// stopped or changes state, for clients to tell whether the miners list
+// changed without fetching it.
+func (m *Manager) MinersVersion() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.listVersion
+}
+
+// transitionMinerState moves a miner to the next state, rejecting changes
+// its current state doesn't allow. Caller must hold m.mu.
+func (m *Manager) transitionMinerState(name string, next MinerState) error {
+	current, ok := m.states[name]
+	if !ok {
+		return ErrMinerNotFound(name)
+	}
+	if !current.CanTransitionTo(next) {
+		return ErrInvalidStateTransition(name, current, next)
+	}
+	m.states[name] = next
+	m.listVersion++
+	if reporter, ok := m.miners[name].(stateReporter); ok {
+		reporter.setState(next)
+	}
+	return nil
+}
+
+// GetMinerState returns the lifecycle state of a miner.
+func (m *Manager) GetMinerState(name string) (MinerState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	state, ok := m.states[name]
+	if !ok {
+		return "", ErrMinerNotFound(name)
+	}
+	return state, nil
+}
+
+// pausedState returns the state a pause (or resume) command moves a miner to.
+func pausedState(paused bool) MinerState {
+	if paused {
+		return MinerStatePaused
+	}
+	return MinerStateRunning
+}
+
+// SetMinerPaused records that a miner was paused or resumed with a console command.
+func (m *Manager) SetMinerPaused(name string, paused bool) error {
+	next := pausedState(paused)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.states[name] == next {
+		return nil
+	}
+	return m.transitionMinerState(name, next)
+}
+
+// processExited reports whether a stats error means the miner process is gone.
+func processExited(err error) bool {
+	return errors.Is(err, ErrProcessNotRunning)
+}
@@ -0,0 +1,114 @@
+package mining
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMinerStateTransitions(t *testing.T) {
+	tests := []struct {
+		from, to MinerState
+		allowed  bool
+	}{
+		{MinerStateStarting, MinerStateRunning, true},
+		{MinerStateStarting, MinerStatePaused, false},
+		{MinerStateRunning, MinerStatePaused, true},
+		{MinerStatePaused, MinerStateRunning, true},
+		{MinerStateRunning, MinerStateStarting, false},
+		{MinerStateRunning, MinerStateErrored, true},
+		{MinerStateErrored, MinerStateRunning, false},
+		{MinerStateErrored, MinerStateStopping, true},
+		{MinerStateErrored, MinerStateStarting, true},
+		{MinerStateStopping, MinerStateStopped, true},
+		{MinerStateStopping, MinerStateRunning, false},
+		{MinerStateStopped, MinerStateStarting, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+			t.Errorf("%s -> %s: expected allowed=%v, got %v", tt.from, tt.to, tt.allowed, got)
+		}
+	}
+}
+
+func TestManagerMinerLifecycle(t *testing.T) {
+	originalDelay := statsRetryDelay
+	statsRetryDelay = time.Millisecond
+	defer func() { statsRetryDelay = originalDelay }()
+
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+	m.SetEventHub(hub)
+	errored := make(chan MinerEventData, 1)
+	hub.AddSink(EventSinkFunc(func(e Event) {
+		if e.Type == EventMinerError {
+			errored <- e.Data.(MinerEventData)
+		}
+	}))
+
+	started, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet"})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	miner := started.(*SimulatedMiner)
+	name := miner.GetName()
+
+	expectState := func(want MinerState) {
+		t.Helper()
+		if got, _ := m.GetMinerState(name); got != want {
+			t.Fatalf("expected state %s, got %s", want, got)
+		}
+		if miner.State != want {
+			t.Fatalf("expected the miner to report %s, got %s", want, miner.State)
+		}
+	}
+	expectState(MinerStateStarting)
+
+	// Pausing needs a running miner
+	var miningErr *MiningError
+	if err := m.SetMinerPaused(name, true); !errors.As(err, &miningErr) || miningErr.StatusCode() != 409 {
+		t.Fatalf("expected pausing a starting miner to be rejected, got %v", err)
+	}
+
+	// The first successful stats collection means it is running
+	m.collectSingleMinerStats(miner, MinerTypeSimulated, time.Now(), false)
+	expectState(MinerStateRunning)
+
+	if err := m.SetMinerPaused(name, true); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	expectState(MinerStatePaused)
+	if err := m.SetMinerPaused(name, false); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	expectState(MinerStateRunning)
+
+	// The process going away behind the manager's back is a crash
+	miner.Stop()
+	m.collectSingleMinerStats(miner, MinerTypeSimulated, time.Now(), false)
+	expectState(MinerStateErrored)
+	select {
+	case data := <-errored:
+		if data.Name != name || data.Reason != "process exited" {
+			t.Errorf("unexpected error event %+v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error event for the crashed miner")
+	}
+	if err := m.SetMinerPaused(name, false); err == nil {
+		t.Error("expected resuming a crashed miner to be rejected")
+	}
+
+	if err := m.StopMiner(context.Background(), name); err != nil {
+		t.Fatalf("StopMiner failed: %v", err)
+	}
+	if miner.State != MinerStateStopped {
+		t.Errorf("expected the stopped miner to report stopped, got %s", miner.State)
+	}
+	if _, err := m.GetMinerState(name); err == nil {
+		t.Error("expected a stopped miner's state to be forgotten")
+	}
+}
@@ -0,0 +1,220 @@
+package mining
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/adrg/xdg"
+)
+
+// LogFileConfig controls persisting miner output to rotating log files.
+type LogFileConfig struct {
+	// Enabled turns on per-miner log files (default: false)
+	Enabled bool `json:"enabled"`
+	// Dir is where log files are written, one <miner>.log per miner
+	Dir string `json:"dir"`
+	// MaxSizeMB is the size at which the active log file is rotated
+	MaxSizeMB int `json:"maxSizeMB"`
+	// MaxFiles is how many compressed rotated files to keep per miner
+	MaxFiles int `json:"maxFiles"`
+}
+
+var (
+	logFileConfig   = DefaultLogFileConfig()
+	logFileConfigMu sync.RWMutex
+)
+
+// DefaultLogFileConfig returns the default log file configuration.
+// Log files are disabled by default.
+func DefaultLogFileConfig() LogFileConfig {
+	return LogFileConfig{
+		Enabled:   false,
+		Dir:       filepath.Join(xdg.StateHome, "lethean-desktop", "miners", "logs"),
+		MaxSizeMB: 10,
+		MaxFiles:  5,
+	}
+}
+
+// LogFileConfigFromEnv creates log file config from environment variables.
+// Set MINING_LOG_FILES=true to enable, with MINING_LOG_DIR, MINING_LOG_MAX_SIZE_MB
+// and MINING_LOG_MAX_FILES to override the defaults.
+func LogFileConfigFromEnv() LogFileConfig {
+	config := DefaultLogFileConfig()
+
+	if v := os.Getenv("MINING_LOG_FILES"); v == "true" || v == "1" {
+		config.Enabled = true
+	}
+	if dir := os.Getenv("MINING_LOG_DIR"); dir != "" {
+		config.Dir = dir
+	}
+	if v, err := strconv.Atoi(os.Getenv("MINING_LOG_MAX_SIZE_MB")); err == nil && v > 0 {
+		config.MaxSizeMB = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("MINING_LOG_MAX_FILES")); err == nil && v > 0 {
+		config.MaxFiles = v
+	}
+
+	return config
+}
+
+// SetLogFileConfig sets the log file configuration used by miners started from now on.
+func SetLogFileConfig(config LogFileConfig) {
+	logFileConfigMu.Lock()
+	defer logFileConfigMu.Unlock()
+	logFileConfig = config
+}
+
+// GetLogFileConfig returns the current log file configuration.
+func GetLogFileConfig() LogFileConfig {
+	logFileConfigMu.RLock()
+	defer logFileConfigMu.RUnlock()
+	return logFileConfig
+}
+
+// RotatingFile is an io.WriteCloser that rotates the file once it reaches
+// maxSize bytes, gzip-compressing old files as <path>.1.gz, <path>.2.gz, ...
+// and keeping at most maxFiles of them. If a rotation fails, writes go on to
+// the current file and rotation is retried once it grows by maxSize again.
+type RotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	rotateAt int64 // Size at which the next rotation is attempted
+	mu       sync.Mutex
+}
+
+// NewRotatingFile opens (or appends to) the log file at path.
+func NewRotatingFile(path string, maxSize int64, maxFiles int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	rf := &RotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Path returns the path of the active log file.
+func (rf *RotatingFile) Path() string {
+	return rf.path
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	rf.rotateAt = rf.maxSize
+	return nil
+}
+
+// Write implements io.Writer, rotating first if p would exceed the size limit.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.rotateAt {
+		if err := rf.rotate(); err != nil {
+			logging.Warn("failed to rotate log file, writing on to it", logging.Fields{"path": rf.path, "error": err})
+			rf.rotateAt = rf.size + rf.maxSize
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close closes the active log file.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// rotate shifts compressed backups up by one, compresses the active file
+// into <path>.1.gz and starts a fresh file. The active file stays open until
+// the fresh one is in place, so a failed rotation leaves it in use. Caller
+// must hold rf.mu.
+func (rf *RotatingFile) rotate() error {
+	// Drop the oldest backup and shift the rest
+	os.Remove(rf.backupPath(rf.maxFiles))
+	for i := rf.maxFiles - 1; i >= 1; i-- {
+		os.Rename(rf.backupPath(i), rf.backupPath(i+1)) // Missing backups are fine
+	}
+
+	if rf.maxFiles > 0 {
+		if err := compressFile(rf.path, rf.backupPath(1)); err != nil {
+			return fmt.Errorf("failed to compress rotated log: %w", err)
+		}
+	}
+
+	next := rf.path + ".next"
+	f, err := os.OpenFile(next, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(next, rf.path); err != nil {
+		f.Close()
+		os.Remove(next)
+		return err
+	}
+	rf.file.Close()
+	rf.file = f
+	rf.size = 0
+	rf.rotateAt = rf.maxSize
+	return nil
+}
+
+func (rf *RotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d.gz", rf.path, n)
+}
+
+// compressFile gzips src into dst.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		gz.Close()
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
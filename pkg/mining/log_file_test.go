@@ -0,0 +1,136 @@
+package mining
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miner.log")
+	rf, err := NewRotatingFile(path, 20, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer rf.Close()
+
+	// Each write is 15 bytes, so every write after the first rotates
+	for _, line := range []string{"first line 01\n", "second line 2\n", "third line 03\n", "fourth line 4\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "fourth line 4\n" {
+		t.Errorf("unexpected active file content: %q", current)
+	}
+
+	if got := readGzip(t, path+".1.gz"); got != "third line 03\n" {
+		t.Errorf("unexpected .1.gz content: %q", got)
+	}
+	if got := readGzip(t, path+".2.gz"); got != "second line 2\n" {
+		t.Errorf("unexpected .2.gz content: %q", got)
+	}
+	if _, err := os.Stat(path + ".3.gz"); !os.IsNotExist(err) {
+		t.Error("backups beyond maxFiles should be removed")
+	}
+}
+
+func TestRotatingFileKeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miner.log")
+	rf, err := NewRotatingFile(path, 20, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile failed: %v", err)
+	}
+	defer rf.Close()
+
+	// A directory where the fresh file is created makes rotation fail
+	blocker := filepath.Join(path+".next", "blocker")
+	if err := os.MkdirAll(blocker, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first line 01\n", "second line 2\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Write after a failed rotation failed: %v", err)
+		}
+	}
+	if current, _ := os.ReadFile(path); string(current) != "first line 01\nsecond line 2\n" {
+		t.Errorf("expected writes to go on to the current file, got %q", current)
+	}
+
+	// Rotation is retried once the file grows by another maxSize
+	os.RemoveAll(filepath.Dir(blocker))
+	for _, line := range []string{"third\n", "fourth line 4\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if current, _ := os.ReadFile(path); string(current) != "fourth line 4\n" {
+		t.Errorf("expected a fresh file after the retried rotation, got %q", current)
+	}
+}
+
+func TestLogBufferTeesToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miner.log")
+	rf, err := NewRotatingFile(path, 1024*1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lb := NewLogBuffer(10)
+	lb.SetFile(rf)
+	lb.Write([]byte("hashrate 1000 H/s\nshare accepted\n"))
+	if err := lb.CloseFile(); err != nil {
+		t.Fatalf("CloseFile failed: %v", err)
+	}
+	lb.Write([]byte("after close\n"))
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if !strings.Contains(content, "hashrate 1000 H/s") || !strings.Contains(content, "share accepted") {
+		t.Errorf("expected output in log file, got %q", content)
+	}
+	if strings.Contains(content, "after close") {
+		t.Error("output after CloseFile should not be written to the file")
+	}
+	if len(lb.GetLines()) != 3 {
+		t.Errorf("in-memory buffer should still capture all lines, got %d", len(lb.GetLines()))
+	}
+}
+
+func TestOpenLogFile(t *testing.T) {
+	dir := t.TempDir()
+	SetLogFileConfig(LogFileConfig{Enabled: true, Dir: dir, MaxSizeMB: 1, MaxFiles: 1})
+	defer SetLogFileConfig(DefaultLogFileConfig())
+
+	miner := NewXMRigMiner()
+	miner.Name = "xmrig-rx/0"
+	miner.openLogFile()
+	defer miner.closeLogFile()
+
+	if miner.LogFilePath != filepath.Join(dir, "xmrig-rx_0.log") {
+		t.Errorf("unexpected log file path: %s", miner.LogFilePath)
+	}
+	if _, err := os.Stat(miner.LogFilePath); err != nil {
+		t.Errorf("log file should exist: %v", err)
+	}
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("failed to read gzip %s: %v", path, err)
+	}
+	data, _ := io.ReadAll(gz)
+	return string(data)
+}
@@ -0,0 +1,129 @@
+package mining
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxLogPatternLength bounds user-supplied grep patterns.
+const maxLogPatternLength = 256
+
+// ansiEscapeRegex matches terminal colour/formatting sequences in miner output.
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// logLevelPatterns match lines at or above a severity. Miners don't share a log
+// format, so severity is inferred from keywords.
+var logLevelPatterns = map[string]*regexp.Regexp{
+	"error": regexp.MustCompile(`(?i)\b(error|fatal|panic|fail(ed|ure)?)\b`),
+	"warn":  regexp.MustCompile(`(?i)\b(warn(ing)?|error|fatal|panic|fail(ed|ure)?)\b`),
+}
+
+// LogFilter selects log lines by pattern, severity and position.
+type LogFilter struct {
+	pattern *regexp.Regexp
+	level   *regexp.Regexp
+	invert  bool
+	tail    int
+	stream  LogStream // Only entries from this stream, empty for all
+}
+
+// NewLogFilter builds a filter from request parameters. An empty pattern or
+// level matches everything; invert applies to the pattern only; tail keeps
+// the last N matching lines (0 for all).
+func NewLogFilter(pattern, level string, ignoreCase, invert bool, tail int) (*LogFilter, error) {
+	f := &LogFilter{invert: invert, tail: tail}
+
+	if tail < 0 {
+		return nil, fmt.Errorf("tail must not be negative")
+	}
+
+	if pattern != "" {
+		if len(pattern) > maxLogPatternLength {
+			return nil, fmt.Errorf("pattern too long (max %d chars)", maxLogPatternLength)
+		}
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		f.pattern = re
+	}
+
+	if level != "" && level != "all" && level != "info" {
+		re, ok := logLevelPatterns[strings.ToLower(level)]
+		if !ok {
+			return nil, fmt.Errorf("unknown level %q (expected error, warn, info or all)", level)
+		}
+		f.level = re
+	}
+
+	return f, nil
+}
+
+// ParseLogStream parses a stream selection: stdout, stderr, or both (or
+// empty) for every line, which is returned as "".
+func ParseLogStream(stream string) (LogStream, error) {
+	switch s := LogStream(strings.ToLower(stream)); s {
+	case "", "both":
+		return "", nil
+	case LogStreamStdout, LogStreamStderr:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown stream %q (expected stdout, stderr or both)", stream)
+	}
+}
+
+// WithStream limits ApplyEntries to entries from one stream; "" keeps all.
+// Untagged lines don't belong to either stream.
+func (f *LogFilter) WithStream(stream LogStream) *LogFilter {
+	f.stream = stream
+	return f
+}
+
+// Match reports whether a single line passes the pattern and level filters.
+func (f *LogFilter) Match(line string) bool {
+	plain := ansiEscapeRegex.ReplaceAllString(line, "")
+	if f.level != nil && !f.level.MatchString(plain) {
+		return false
+	}
+	if f.pattern != nil && f.pattern.MatchString(plain) == f.invert {
+		return false
+	}
+	return true
+}
+
+// Apply returns the lines that pass the filter, preserving order.
+func (f *LogFilter) Apply(lines []string) []string {
+	matched := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if f.Match(line) {
+			matched = append(matched, line)
+		}
+	}
+	return tailOf(matched, f.tail)
+}
+
+// ApplyEntries is Apply for timestamped log entries, matching on the text only.
+func (f *LogFilter) ApplyEntries(entries []LogEntry) []LogEntry {
+	matched := make([]LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if f.stream != "" && entry.Stream != f.stream {
+			continue
+		}
+		if f.Match(entry.Text) {
+			matched = append(matched, entry)
+		}
+	}
+	return tailOf(matched, f.tail)
+}
+
+// tailOf returns the last n items of s, or all of s if n is 0.
+func tailOf[T any](s []T, n int) []T {
+	if n > 0 && len(s) > n {
+		return s[len(s)-n:]
+	}
+	return s
+}
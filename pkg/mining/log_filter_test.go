@@ -0,0 +1,215 @@
+package mining
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testLogLines = []string{
+	"[10:00:00] \x1b[1;32mnew job\x1b[0m from pool.example.com",
+	"[10:00:01] speed 10s/60s/15m 1000.0 H/s",
+	"[10:00:02] \x1b[1;31mERROR\x1b[0m connection refused",
+	"[10:00:03] WARNING huge pages not available",
+	"[10:00:04] speed 10s/60s/15m 1100.0 H/s",
+}
+
+func TestLogFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		level      string
+		ignoreCase bool
+		invert     bool
+		tail       int
+		want       int
+	}{
+		{"no filter", "", "", false, false, 0, 5},
+		{"grep", "speed", "", false, false, 0, 2},
+		{"grep case sensitive", "SPEED", "", false, false, 0, 0},
+		{"grep ignore case", "SPEED", "", true, false, 0, 2},
+		{"invert", "speed", "", false, true, 0, 3},
+		{"error level", "", "error", false, false, 0, 1},
+		{"warn level includes errors", "", "warn", false, false, 0, 2},
+		{"tail", "", "", false, false, 2, 2},
+		{"ansi stripped before match", "ERROR connection", "", false, false, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewLogFilter(tt.pattern, tt.level, tt.ignoreCase, tt.invert, tt.tail)
+			if err != nil {
+				t.Fatalf("NewLogFilter failed: %v", err)
+			}
+			if got := f.Apply(testLogLines); len(got) != tt.want {
+				t.Errorf("expected %d lines, got %d: %q", tt.want, len(got), got)
+			}
+		})
+	}
+
+	f, _ := NewLogFilter("", "", false, false, 1)
+	if got := f.Apply(testLogLines); got[0] != testLogLines[4] {
+		t.Errorf("tail should keep the last line, got %q", got)
+	}
+}
+
+func TestLogFilterInvalid(t *testing.T) {
+	if _, err := NewLogFilter("([", "", false, false, 0); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if _, err := NewLogFilter("", "debug", false, false, 0); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if _, err := NewLogFilter("", "", false, false, -1); err == nil {
+		t.Error("expected error for negative tail")
+	}
+}
+
+func TestHandleGetMinerLogsFiltered(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	mockManager.GetMinerFunc = func(minerName string) (Miner, error) {
+		return &MockMiner{GetLogsFunc: func() []string { return testLogLines }}, nil
+	}
+
+	req, _ := http.NewRequest("GET", "/miners/xmrig/logs?grep=refused&level=error", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var encoded []string
+	if err := json.Unmarshal(w.Body.Bytes(), &encoded); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(encoded) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(encoded))
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(encoded[0])
+	if string(decoded) != testLogLines[2] {
+		t.Errorf("unexpected line: %q", decoded)
+	}
+
+	req, _ = http.NewRequest("GET", "/miners/xmrig/logs?grep=%28%5B", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for bad pattern, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestLogBufferTimestampFormat(t *testing.T) {
+	defer SetLogTimestampFormat("")
+
+	lb := NewLogBuffer(10)
+	lb.Write([]byte("share accepted\n"))
+
+	entries := lb.GetEntries()
+	if len(entries) != 1 || entries[0].Text != "share accepted" || entries[0].TS.IsZero() {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	want := "[" + entries[0].TS.Format(LogTimestampTime) + "] share accepted"
+	if got := lb.GetLines()[0]; got != want {
+		t.Errorf("default format: expected %q, got %q", want, got)
+	}
+
+	SetLogTimestampFormat("rfc3339")
+	want = "[" + entries[0].TS.Format(time.RFC3339) + "] share accepted"
+	if got := lb.GetLines()[0]; got != want {
+		t.Errorf("rfc3339 format: expected %q, got %q", want, got)
+	}
+}
+
+func TestHandleGetMinerLogsStructured(t *testing.T) {
+	router, mockManager := setupTestRouter()
+
+	miner := NewXMRigMiner()
+	miner.LogBuffer.Write([]byte("new job\nERROR connection refused\n"))
+	mockManager.GetMinerFunc = func(minerName string) (Miner, error) { return miner, nil }
+
+	req, _ := http.NewRequest("GET", "/miners/xmrig/logs?format=structured&level=error", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var entries []LogEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Text != "ERROR connection refused" || entries[0].TS.IsZero() {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestHandleGetMinerLogsStream(t *testing.T) {
+	router, mockManager := setupTestRouter()
+
+	miner := NewXMRigMiner()
+	stdout, stderr := miner.LogBuffer.Stream(LogStreamStdout), miner.LogBuffer.Stream(LogStreamStderr)
+	stdout.Write([]byte("new job\n"))
+	stderr.Write([]byte("ERROR connection refused\n"))
+	stdout.Write([]byte("speed 1000.0 H/s\n"))
+	mockManager.GetMinerFunc = func(minerName string) (Miner, error) { return miner, nil }
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/miners/xmrig/logs?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decodeLines := func(w *httptest.ResponseRecorder) []string {
+		t.Helper()
+		var encoded []string
+		if err := json.Unmarshal(w.Body.Bytes(), &encoded); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		lines := make([]string, len(encoded))
+		for i, line := range encoded {
+			decoded, _ := base64.StdEncoding.DecodeString(line)
+			lines[i] = string(decoded)
+		}
+		return lines
+	}
+
+	tests := []struct {
+		stream string
+		want   []string
+	}{
+		{"stderr", []string{"ERROR connection refused"}},
+		{"stdout", []string{"new job", "speed 1000.0 H/s"}},
+		{"both", []string{"new job", "ERROR connection refused", "speed 1000.0 H/s"}},
+	}
+	for _, tt := range tests {
+		w := get("stream=" + tt.stream)
+		if w.Code != http.StatusOK {
+			t.Fatalf("stream=%s: expected status 200, got %d: %s", tt.stream, w.Code, w.Body.String())
+		}
+		lines := decodeLines(w)
+		if len(lines) != len(tt.want) {
+			t.Fatalf("stream=%s: expected %d lines, got %q", tt.stream, len(tt.want), lines)
+		}
+		for i, want := range tt.want {
+			if !strings.HasSuffix(lines[i], "] "+want) {
+				t.Errorf("stream=%s line %d: expected %q, got %q", tt.stream, i, want, lines[i])
+			}
+		}
+	}
+
+	// Structured entries say which stream each line came from
+	var entries []LogEntry
+	json.Unmarshal(get("format=structured&stream=stderr").Body.Bytes(), &entries)
+	if len(entries) != 1 || entries[0].Stream != LogStreamStderr {
+		t.Errorf("unexpected structured entries: %+v", entries)
+	}
+
+	if w := get("stream=stdin"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown stream, got %d", w.Code)
+	}
+}
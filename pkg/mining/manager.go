@@ -3,19 +3,59 @@ package mining
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"net"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Snider/Mining/pkg/database"
 	"github.com/Snider/Mining/pkg/logging"
+	"github.com/Snider/Mining/pkg/mining/algos"
 )
 
 // sanitizeInstanceName ensures the instance name only contains safe characters.
 var instanceNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_/-]`)
 
+// algoInstanceName returns the instance name for a miner started with an
+// explicit algorithm. The algo is sanitized to prevent directory traversal or
+// invalid filenames.
+func algoInstanceName(baseName, algo string) string {
+	return fmt.Sprintf("%s-%s", baseName, instanceNameRegex.ReplaceAllString(algo, "_"))
+}
+
+// nextInstanceName returns baseName with the next unused instance number,
+// e.g. "xmrig-3". Caller must hold m.mu.
+func (m *Manager) nextInstanceName(baseName string) string {
+	for {
+		m.instanceSeq++
+		name := fmt.Sprintf("%s-%d", baseName, m.instanceSeq)
+		if _, exists := m.miners[name]; !exists {
+			return name
+		}
+	}
+}
+
+// setInstanceName renames a miner the manager created or was given,
+// reporting false for miner types that can't be renamed.
+func setInstanceName(miner Miner, name string) bool {
+	switch mnr := miner.(type) {
+	case *XMRigMiner:
+		mnr.Name = name
+	case *TTMiner:
+		mnr.Name = name
+	case *SimulatedMiner:
+		mnr.mu.Lock()
+		mnr.Name = name
+		mnr.mu.Unlock()
+	default:
+		return false
+	}
+	return true
+}
+
 // ManagerInterface defines the contract for a miner manager.
 type ManagerInterface interface {
 	StartMiner(ctx context.Context, minerType string, config *Config) (Miner, error)
@@ -30,15 +70,66 @@ type ManagerInterface interface {
 
 // Manager handles the lifecycle and operations of multiple miners.
 type Manager struct {
-	miners      map[string]Miner
-	mu          sync.RWMutex
-	stopChan    chan struct{}
-	stopOnce    sync.Once
-	waitGroup   sync.WaitGroup
-	dbEnabled   bool
-	dbRetention int
-	eventHub    *EventHub
-	eventHubMu  sync.RWMutex // Separate mutex for eventHub to avoid deadlock with main mu
+	miners       map[string]Miner
+	mu           sync.RWMutex
+	stopChan     chan struct{}
+	stopOnce     sync.Once
+	waitGroup    sync.WaitGroup
+	dbEnabled    bool
+	dbRetention  int
+	dbVacuum     bool // vacuum after retention cleanup, see DatabaseConfig.VacuumAfterCleanup
+	eventHub     *EventHub
+	eventHubMu   sync.RWMutex                // Separate mutex for eventHub to avoid deadlock with main mu
+	launches     map[string]*MinerLaunchInfo // How each running miner was started, keyed by instance name
+	donatePolicy DonateLevelPolicy           // Guardrails applied to DonateLevel at start, guarded by mu
+	processPrio  *int                        // Default ProcessPriority for configs that don't set one, guarded by mu
+	statsJitter  bool                        // Spread each miner's stats collection across the interval, guarded by mu
+	// startBreakers pause starts of a profile or miner type that keeps failing, guarded by mu
+	startBreakers map[string]*CircuitBreaker
+	// dbHighResRetention is how many days high resolution rows are kept; low resolution rows are kept dbRetention days
+	dbHighResRetention int
+	// dbLowResSpan is the query range above which low resolution history is read, 0 uses the default
+	dbLowResSpan time.Duration
+	// lowResBuckets hold the minute being averaged per miner for low resolution persistence, guarded by mu
+	lowResBuckets map[string]*lowResBucket
+	// shutdownTimeout bounds waiting for background goroutines in Stop, 0 uses ShutdownTimeout
+	shutdownTimeout time.Duration
+	// shutdownHighFirst stops miners with the highest ShutdownPriority first on Stop, guarded by mu
+	shutdownHighFirst bool
+	// states is the lifecycle state of each miner, guarded by mu
+	states map[string]MinerState
+	// histories records each miner's crashes and crash restarts, see MinerHistory, guarded by mu
+	histories map[string]*MinerHistory
+	// instanceSeq numbers miners started without an algo so their names never collide, guarded by mu
+	instanceSeq uint64
+	// listVersion changes whenever a miner is added, removed or changes state, see MinersVersion, guarded by mu
+	listVersion uint64
+}
+
+// MinerLaunchInfo records the effective config (and profile, if any) a running miner was started with.
+type MinerLaunchInfo struct {
+	MinerType string    `json:"minerType"`
+	ProfileID string    `json:"profileId,omitempty"`
+	Config    Config    `json:"config"`
+	StartedAt time.Time `json:"startedAt"`
+	// EffectiveDonateLevel is the donate level the miner actually runs with, after defaults and policy
+	EffectiveDonateLevel int `json:"effectiveDonateLevel"`
+	// RequestedDonateLevel is set when the donate level policy overrode the requested value
+	RequestedDonateLevel *int `json:"requestedDonateLevel,omitempty"`
+	// StopTimeoutSeconds is the grace period the miner gets after SIGTERM when stopped
+	StopTimeoutSeconds int `json:"stopTimeoutSeconds"`
+	// WorkerIndex is the number %n% expanded to in the wallet, rig ID or worker name
+	WorkerIndex int `json:"workerIndex,omitempty"`
+	// AppliedCPUAffinity lists the CPUs the OS pinned the process to, when CPUAffinity was applied
+	AppliedCPUAffinity []int `json:"appliedCpuAffinity,omitempty"`
+
+	// Unhealthy is set while the miner's stats API keeps failing, see statsUnhealthyThreshold
+	Unhealthy bool `json:"unhealthy,omitempty"`
+
+	requested       Config // config as requested, before manager defaults, policy and the worker template; what config-diff compares
+	workerTemplate  string // key of the unexpanded worker template, see nextWorkerIndex
+	hugePagesWarned bool   // a huge pages warning has been emitted for this run
+	statsFailures   int    // consecutive stats collections that failed after retries
 }
 
 // SetEventHub sets the event hub for broadcasting miner events
@@ -48,6 +139,51 @@ func (m *Manager) SetEventHub(hub *EventHub) {
 	m.eventHub = hub
 }
 
+// SetDonateLevelPolicy sets the floor/ceiling applied to the donate level of
+// every miner started from now on. Already running miners are unaffected.
+func (m *Manager) SetDonateLevelPolicy(policy DonateLevelPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.donatePolicy = policy
+}
+
+// SetProcessPriority sets the process priority (niceness) of miners started
+// from now on whose config doesn't set one. nil leaves them at normal priority.
+func (m *Manager) SetProcessPriority(priority *int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.processPrio = priority
+}
+
+// SetStatsJitter enables or disables random jitter on each miner's stats
+// collection. Disable it to sample all miners at the same instant.
+func (m *Manager) SetStatsJitter(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statsJitter = enabled
+}
+
+// SetShutdownTimeout sets how long Stop waits for background goroutines.
+// 0 restores the ShutdownTimeout default.
+func (m *Manager) SetShutdownTimeout(timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdownTimeout = timeout
+}
+
+// SubscribeEvents registers an in-process sink on the manager's event hub.
+// Returns a function that removes the sink; it is a no-op if no hub is configured.
+func (m *Manager) SubscribeEvents(sink EventSink) (unsubscribe func()) {
+	m.eventHubMu.RLock()
+	hub := m.eventHub
+	m.eventHubMu.RUnlock()
+
+	if hub == nil {
+		return func() {}
+	}
+	return hub.AddSink(sink)
+}
+
 // emitEvent broadcasts an event if an event hub is configured
 // Uses separate eventHubMu to avoid deadlock when called while holding m.mu
 func (m *Manager) emitEvent(eventType EventType, data interface{}) {
@@ -65,9 +201,11 @@ var _ ManagerInterface = (*Manager)(nil)
 // NewManager creates a new miner manager and autostarts miners based on config.
 func NewManager() *Manager {
 	m := &Manager{
-		miners:    make(map[string]Miner),
-		stopChan:  make(chan struct{}),
-		waitGroup: sync.WaitGroup{},
+		miners:      make(map[string]Miner),
+		launches:    make(map[string]*MinerLaunchInfo),
+		stopChan:    make(chan struct{}),
+		waitGroup:   sync.WaitGroup{},
+		statsJitter: true,
 	}
 	m.syncMinersConfig() // Ensure config file is populated
 	m.initDatabase()
@@ -80,9 +218,11 @@ func NewManager() *Manager {
 // It skips autostarting real miners and config sync, suitable for UI testing.
 func NewManagerForSimulation() *Manager {
 	m := &Manager{
-		miners:    make(map[string]Miner),
-		stopChan:  make(chan struct{}),
-		waitGroup: sync.WaitGroup{},
+		miners:      make(map[string]Miner),
+		launches:    make(map[string]*MinerLaunchInfo),
+		stopChan:    make(chan struct{}),
+		waitGroup:   sync.WaitGroup{},
+		statsJitter: true,
 	}
 	// Skip syncMinersConfig and autostartMiners for simulation
 	m.startStatsCollection()
@@ -91,17 +231,30 @@ func NewManagerForSimulation() *Manager {
 
 // initDatabase initializes the SQLite database based on config.
 func (m *Manager) initDatabase() {
-	cfg, err := LoadMinersConfig()
-	if err != nil {
-		logging.Warn("could not load config for database init", logging.Fields{"error": err})
-		return
+	dbConfig := getDatabaseConfigOverride()
+	if dbConfig == nil {
+		cfg, err := LoadMinersConfig()
+		if err != nil {
+			logging.Warn("could not load config for database init", logging.Fields{"error": err})
+			return
+		}
+		dbConfig = &cfg.Database
 	}
 
-	m.dbEnabled = cfg.Database.Enabled
-	m.dbRetention = cfg.Database.RetentionDays
+	m.dbEnabled = dbConfig.Enabled
+	m.dbRetention = dbConfig.RetentionDays
+	m.dbVacuum = dbConfig.VacuumAfterCleanup
 	if m.dbRetention == 0 {
 		m.dbRetention = 30
 	}
+	m.dbHighResRetention = dbConfig.HighResRetentionDays
+	if m.dbHighResRetention == 0 {
+		m.dbHighResRetention = 7
+	}
+	if m.dbHighResRetention > m.dbRetention {
+		m.dbHighResRetention = m.dbRetention
+	}
+	m.dbLowResSpan = time.Duration(dbConfig.LowResQueryHours) * time.Hour
 
 	if !m.dbEnabled {
 		logging.Debug("database persistence is disabled")
@@ -119,13 +272,17 @@ func (m *Manager) initDatabase() {
 		return
 	}
 
-	logging.Info("database persistence enabled", logging.Fields{"retention_days": m.dbRetention})
+	logging.Info("database persistence enabled", logging.Fields{"retention_days": m.dbRetention, "high_res_retention_days": m.dbHighResRetention})
 
 	// Start periodic cleanup
 	m.startDBCleanup()
 }
 
-// startDBCleanup starts a goroutine that periodically cleans old data.
+// dbVacuumInterval is the minimum time between vacuums run after cleanup.
+const dbVacuumInterval = 24 * time.Hour
+
+// startDBCleanup starts a goroutine that periodically cleans old data, and
+// compacts the database afterwards when vacuumAfterCleanup is set.
 func (m *Manager) startDBCleanup() {
 	m.waitGroup.Add(1)
 	go func() {
@@ -139,17 +296,31 @@ func (m *Manager) startDBCleanup() {
 		ticker := time.NewTicker(time.Hour)
 		defer ticker.Stop()
 
-		// Run initial cleanup
-		if err := database.Cleanup(m.dbRetention); err != nil {
-			logging.Warn("database cleanup failed", logging.Fields{"error": err})
+		var lastVacuum time.Time
+		cleanup := func() {
+			if err := database.Cleanup(m.dbRetention); err != nil {
+				logging.Warn("database cleanup failed", logging.Fields{"error": err})
+				return
+			}
+			if err := database.CleanupResolution(database.ResolutionHigh, m.dbHighResRetention); err != nil {
+				logging.Warn("database high resolution cleanup failed", logging.Fields{"error": err})
+				return
+			}
+			if m.dbVacuum && time.Since(lastVacuum) >= dbVacuumInterval {
+				lastVacuum = time.Now()
+				if _, err := m.VacuumDatabase(context.Background()); err != nil {
+					logging.Warn("database vacuum failed", logging.Fields{"error": err})
+				}
+			}
 		}
 
+		// Run initial cleanup
+		cleanup()
+
 		for {
 			select {
 			case <-ticker.C:
-				if err := database.Cleanup(m.dbRetention); err != nil {
-					logging.Warn("database cleanup failed", logging.Fields{"error": err})
-				}
+				cleanup()
 			case <-m.stopChan:
 				return
 			}
@@ -229,6 +400,12 @@ func findAvailablePort() (int, error) {
 // StartMiner starts a new miner and saves its configuration.
 // The context can be used to cancel the operation.
 func (m *Manager) StartMiner(ctx context.Context, minerType string, config *Config) (Miner, error) {
+	return m.StartMinerWithProfile(ctx, minerType, "", config)
+}
+
+// StartMinerWithProfile starts a new miner like StartMiner and records the
+// profile it was started from, so its config can later be compared against it.
+func (m *Manager) StartMinerWithProfile(ctx context.Context, minerType, profileID string, config *Config) (Miner, error) {
 	// Check for cancellation before acquiring lock
 	select {
 	case <-ctx.Done():
@@ -242,25 +419,67 @@ func (m *Manager) StartMiner(ctx context.Context, minerType string, config *Conf
 	if config == nil {
 		config = &Config{}
 	}
+	requested := *config
+	if config.ProcessPriority == nil && m.processPrio != nil {
+		priority := *m.processPrio
+		config.ProcessPriority = &priority
+	}
+
+	// Catch settings the miner would silently ignore before launching it
+	if err := config.ValidateFor(minerType); err != nil {
+		return nil, ErrInvalidConfig(err.Error())
+	}
+
+	// Enforce the donate level policy regardless of what the profile requested
+	var requestedDonateLevel *int
+	if level, overridden := m.donatePolicy.Clamp(config.DonateLevel); overridden {
+		requested := config.DonateLevel
+		requestedDonateLevel = &requested
+		logging.Info("donate level overridden by policy", logging.Fields{
+			"miner":     minerType,
+			"profile":   profileID,
+			"requested": requested,
+			"effective": level,
+		})
+		config.DonateLevel = level
+	}
 
 	miner, err := CreateMiner(minerType)
 	if err != nil {
 		return nil, err
 	}
 
+	// Refuse to keep spawning a profile or miner type whose starts keep failing
+	breakerKey := startBreakerKey(minerType, profileID)
+	breaker := m.startBreaker(breakerKey)
+	if !breaker.allowRequest() {
+		RecordStartRejected()
+		return nil, ErrStartCircuitOpen(breakerKey, breaker.RetryAfter())
+	}
+
 	instanceName := miner.GetName()
 	if config.Algo != "" {
-		// Sanitize algo to prevent directory traversal or invalid filenames
-		sanitizedAlgo := instanceNameRegex.ReplaceAllString(config.Algo, "_")
-		instanceName = fmt.Sprintf("%s-%s", instanceName, sanitizedAlgo)
+		instanceName = algoInstanceName(instanceName, config.Algo)
 	} else {
-		instanceName = fmt.Sprintf("%s-%d", instanceName, time.Now().UnixNano()%1000)
+		instanceName = m.nextInstanceName(instanceName)
 	}
 
 	if _, exists := m.miners[instanceName]; exists {
 		return nil, fmt.Errorf("a miner with a similar configuration is already running: %s", instanceName)
 	}
 
+	// Give each instance its own worker name, then check the wallet it ends up with
+	tmpl := templateOf(config)
+	workerIndex := 0
+	if tmpl.usesIndex() {
+		workerIndex = m.nextWorkerIndex(tmpl)
+	}
+	config.applyWorkerTemplate(instanceName, workerIndex)
+	defer tmpl.restore(config) // The caller's config keeps its template, whatever happens below
+	if err := config.ValidateFor(minerType); err != nil {
+		return nil, ErrInvalidConfig(err.Error())
+	}
+
 	// Validate user-provided HTTPPort if specified
 	if config.HTTPPort != 0 {
 		if config.HTTPPort < 1024 || config.HTTPPort > 65535 {
@@ -276,22 +495,18 @@ func (m *Manager) StartMiner(ctx context.Context, minerType string, config *Conf
 		config.HTTPPort = apiPort
 	}
 
-	if xmrigMiner, ok := miner.(*XMRigMiner); ok {
-		xmrigMiner.Name = instanceName
-		if xmrigMiner.API != nil {
-			xmrigMiner.API.ListenPort = apiPort
-		}
+	setInstanceName(miner, instanceName)
+	if xmrigMiner, ok := miner.(*XMRigMiner); ok && xmrigMiner.API != nil {
+		xmrigMiner.API.ListenPort = apiPort
 	}
-	if ttMiner, ok := miner.(*TTMiner); ok {
-		ttMiner.Name = instanceName
-		if ttMiner.API != nil {
-			ttMiner.API.ListenPort = apiPort
-		}
+	if ttMiner, ok := miner.(*TTMiner); ok && ttMiner.API != nil {
+		ttMiner.API.ListenPort = apiPort
 	}
 
 	// Emit starting event before actually starting
 	m.emitEvent(EventMinerStarting, MinerEventData{
-		Name: instanceName,
+		Name:      instanceName,
+		ProfileID: profileID,
 	})
 
 	if err := miner.Start(config); err != nil {
@@ -300,12 +515,40 @@ func (m *Manager) StartMiner(ctx context.Context, minerType string, config *Conf
 			Name:  instanceName,
 			Error: err.Error(),
 		})
+		if m.recordStartFailure(breakerKey) {
+			logging.Warn("start breaker opened after repeated start failures", logging.Fields{"key": breakerKey, "cooldown": startBreakerConfig.ResetTimeout})
+			m.emitEvent(EventMinerError, MinerEventData{
+				Name:      instanceName,
+				ProfileID: profileID,
+				Reason:    fmt.Sprintf("starts of %s paused for %s after repeated failures", breakerKey, startBreakerConfig.ResetTimeout),
+			})
+		}
 		return nil, err
 	}
+	breaker.recordSuccess(nil)
 
 	m.miners[instanceName] = miner
+	m.initMinerState(instanceName, miner, MinerStateStarting)
+	m.launches[instanceName] = &MinerLaunchInfo{
+		MinerType:            minerType,
+		ProfileID:            profileID,
+		Config:               *config,
+		StartedAt:            time.Now(),
+		EffectiveDonateLevel: effectiveDonateLevel(config.DonateLevel),
+		StopTimeoutSeconds:   int(config.StopTimeoutDuration() / time.Second),
+		RequestedDonateLevel: requestedDonateLevel,
+		WorkerIndex:          workerIndex,
+		requested:            requested,
+		workerTemplate:       tmpl.key(),
+	}
+	if pinned, ok := miner.(interface{ AppliedCPUAffinity() []int }); ok {
+		m.launches[instanceName].AppliedCPUAffinity = pinned.AppliedCPUAffinity()
+	}
 
-	if err := m.updateMinerConfig(minerType, true, config); err != nil {
+	// Autostart expands the template again for the instance it starts
+	saved := *config
+	tmpl.restore(&saved)
+	if err := m.updateMinerConfig(minerType, true, &saved); err != nil {
 		logging.Warn("failed to save miner config for autostart", logging.Fields{"error": err})
 	}
 
@@ -314,9 +557,20 @@ func (m *Manager) StartMiner(ctx context.Context, minerType string, config *Conf
 
 	// Emit started event
 	m.emitEvent(EventMinerStarted, MinerEventData{
-		Name: instanceName,
+		Name:      instanceName,
+		ProfileID: profileID,
 	})
 
+	if miner.GetType() == MinerTypeXMRig {
+		for _, warning := range privilegeWarnings(config, DetectPrivileges()) {
+			logging.Warn("miner setting needs elevated privileges", logging.Fields{"miner": instanceName, "field": warning.Field})
+			m.emitEvent(EventMinerWarning, MinerEventData{
+				Name:   instanceName,
+				Reason: warning.Field + ": " + warning.Message,
+			})
+		}
+	}
+
 	RecordMinerStart()
 	return miner, nil
 }
@@ -348,6 +602,10 @@ func (m *Manager) UninstallMiner(ctx context.Context, minerType string) error {
 	// Delete from map first, then release lock before stopping (Stop may block)
 	for _, name := range minersToDelete {
 		delete(m.miners, name)
+		delete(m.launches, name)
+	}
+	if len(minersToDelete) > 0 {
+		m.listVersion++
 	}
 	m.mu.Unlock()
 
@@ -403,9 +661,36 @@ func (m *Manager) updateMinerConfig(minerType string, autostart bool, config *Co
 	})
 }
 
+// resolveMinerName finds the running miner a name refers to. An exact
+// instance name always matches. Otherwise the name may be the base of one
+// instance name, e.g. "xmrig" for "xmrig-rx_0" or "xmrig-3": it must be
+// followed by "-" in the instance name and match only one miner. Caller must
+// hold m.mu.
+func (m *Manager) resolveMinerName(name string) (string, error) {
+	if _, exists := m.miners[name]; exists {
+		return name, nil
+	}
+	var matches []string
+	for k := range m.miners {
+		if strings.HasPrefix(k, name+"-") {
+			matches = append(matches, k)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", ErrMinerNotFound(name)
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(matches)
+		return "", ErrAmbiguousMinerName(name, matches)
+	}
+}
+
 // StopMiner stops a running miner and removes it from the manager.
 // If the miner is already stopped, it will still be removed from the manager.
-// The context can be used to cancel the operation.
+// The name is either an instance name or the unambiguous base of one (see
+// resolveMinerName). The context can be used to cancel the operation.
 func (m *Manager) StopMiner(ctx context.Context, name string) error {
 	// Check for cancellation before acquiring lock
 	select {
@@ -417,20 +702,16 @@ func (m *Manager) StopMiner(ctx context.Context, name string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	miner, exists := m.miners[name]
-	if !exists {
-		for k := range m.miners {
-			if strings.HasPrefix(k, name) {
-				miner = m.miners[k]
-				name = k
-				exists = true
-				break
-			}
-		}
+	name, err := m.resolveMinerName(name)
+	if err != nil {
+		return err
 	}
-
-	if !exists {
-		return fmt.Errorf("miner not found: %s", name)
+	miner := m.miners[name]
+	if state, ok := m.states[name]; ok && state == MinerStateStopping {
+		return ErrInvalidStateTransition(name, state, MinerStateStopping)
+	}
+	if err := m.transitionMinerState(name, MinerStateStopping); err != nil {
+		logging.Debug("stopping miner without a tracked state", logging.Fields{"miner": name, "error": err})
 	}
 
 	// Emit stopping event
@@ -438,16 +719,38 @@ func (m *Manager) StopMiner(ctx context.Context, name string) error {
 		Name: name,
 	})
 
-	// Try to stop the miner, but always remove it from the map
-	// This handles the case where a miner crashed or was killed externally
+	// Stop can wait out the miner's stop timeout, so don't hold the lock
+	// over it. The stopping state keeps other callers off the miner.
+	m.mu.Unlock()
 	stopErr := miner.Stop()
+	m.mu.Lock()
+
+	finalState := MinerStateStopped
+	if stopErr != nil && !processExited(stopErr) {
+		finalState = MinerStateErrored
+	}
+	if m.miners[name] != miner {
+		// Removed or replaced while the lock was released
+		return stopErr
+	}
+	m.transitionMinerState(name, finalState)
 
 	// Always remove from map - if it's not running, we still want to clean it up
+	// This handles the case where a miner crashed or was killed externally
 	delete(m.miners, name)
+	delete(m.launches, name)
+	delete(m.states, name)
+	delete(m.histories, name)
+	m.listVersion++
+
+	// Persist the partial minute of low resolution history
+	if bucket := m.takeLowResBucket(name); bucket != nil && m.dbEnabled {
+		persistLowResPoint(name, bucket.minerType, bucket.point())
+	}
 
 	// Emit stopped event
 	reason := "stopped"
-	if stopErr != nil && stopErr.Error() != "miner is not running" {
+	if finalState == MinerStateErrored {
 		reason = stopErr.Error()
 	}
 	m.emitEvent(EventMinerStopped, MinerEventData{
@@ -456,7 +759,7 @@ func (m *Manager) StopMiner(ctx context.Context, name string) error {
 	})
 
 	// Only return error if it wasn't just "miner is not running"
-	if stopErr != nil && stopErr.Error() != "miner is not running" {
+	if finalState == MinerStateErrored {
 		return stopErr
 	}
 
@@ -475,6 +778,18 @@ func (m *Manager) GetMiner(name string) (Miner, error) {
 	return miner, nil
 }
 
+// GetMinerLaunchInfo returns a copy of the config and profile a running miner was started with.
+func (m *Manager) GetMinerLaunchInfo(name string) (*MinerLaunchInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	info, exists := m.launches[name]
+	if !exists {
+		return nil, fmt.Errorf("miner not found: %s", name)
+	}
+	copied := *info
+	return &copied, nil
+}
+
 // ListMiners returns a slice of all running miners.
 func (m *Manager) ListMiners() []Miner {
 	m.mu.RLock()
@@ -487,23 +802,35 @@ func (m *Manager) ListMiners() []Miner {
 }
 
 // RegisterMiner registers an already-started miner with the manager.
-// This is useful for simulated miners or externally managed miners.
+// This is useful for simulated miners or externally managed miners. The
+// miner keeps its own name unless that is empty or taken, in which case it
+// is renamed like an algo-less StartMiner instance, e.g. "sim-xmrig-001-2";
+// miner types that can't be renamed fail with ErrMinerExists instead.
 func (m *Manager) RegisterMiner(miner Miner) error {
-	name := miner.GetName()
-
 	m.mu.Lock()
-	if _, exists := m.miners[name]; exists {
-		m.mu.Unlock()
-		return fmt.Errorf("miner %s is already registered", name)
+	name := miner.GetName()
+	if _, exists := m.miners[name]; exists || name == "" {
+		// Name it the way StartMiner names algo-less miners, if the type allows it
+		base := name
+		if base == "" {
+			base = miner.GetType()
+		}
+		unique := m.nextInstanceName(base)
+		if !setInstanceName(miner, unique) {
+			m.mu.Unlock()
+			return ErrMinerExists(name)
+		}
+		name = unique
 	}
 	m.miners[name] = miner
+	m.initMinerState(name, miner, MinerStateRunning)
 	m.mu.Unlock()
 
 	logging.Info("registered miner", logging.Fields{"name": name})
 
 	// Emit miner started event (outside lock)
-	m.emitEvent(EventMinerStarted, map[string]interface{}{
-		"name": name,
+	m.emitEvent(EventMinerStarted, MinerEventData{
+		Name: name,
 	})
 
 	return nil
@@ -511,7 +838,7 @@ func (m *Manager) RegisterMiner(miner Miner) error {
 
 // ListAvailableMiners returns a list of available miners that can be started.
 func (m *Manager) ListAvailableMiners() []AvailableMiner {
-	return []AvailableMiner{
+	miners := []AvailableMiner{
 		{
 			Name:        "xmrig",
 			Description: "XMRig is a high performance, open source, cross platform RandomX, KawPow, CryptoNight and AstroBWT CPU/GPU miner and RandomX benchmark.",
@@ -521,6 +848,12 @@ func (m *Manager) ListAvailableMiners() []AvailableMiner {
 			Description: "TT-Miner is a high performance NVIDIA GPU miner for various algorithms including Ethash, KawPow, ProgPow, and more. Requires CUDA.",
 		},
 	}
+	for i := range miners {
+		for _, algorithm := range algos.ForMiner(miners[i].Name) {
+			miners[i].Algorithms = append(miners[i].Algorithms, algorithm.Name)
+		}
+	}
+	return miners
 }
 
 // startStatsCollection starts a goroutine to periodically collect stats from active miners.
@@ -550,6 +883,16 @@ func (m *Manager) startStatsCollection() {
 // statsCollectionTimeout is the maximum time to wait for stats from a single miner.
 const statsCollectionTimeout = 5 * time.Second
 
+// statsJitterFraction is the largest share of the collection interval a
+// miner's collection is randomly delayed by, so miner APIs aren't all polled
+// in the same instant.
+const statsJitterFraction = 0.25
+
+// statsJitterDelay returns a random delay in [0, statsJitterFraction*interval).
+func statsJitterDelay() time.Duration {
+	return time.Duration(rand.Int63n(int64(float64(HighResolutionInterval) * statsJitterFraction)))
+}
+
 // collectMinerStats iterates through active miners and collects their stats.
 // Stats are collected in parallel to reduce overall collection time.
 func (m *Manager) collectMinerStats() {
@@ -565,14 +908,19 @@ func (m *Manager) collectMinerStats() {
 		minerType string
 	}
 	miners := make([]minerInfo, 0, len(m.miners))
-	for _, miner := range m.miners {
+	for name, miner := range m.miners {
+		// A crashed or stopping miner has no stats to collect
+		if state := m.states[name]; state == MinerStateErrored || state == MinerStateStopping {
+			continue
+		}
 		// Use the miner's GetType() method for proper type identification
 		miners = append(miners, minerInfo{miner: miner, minerType: miner.GetType()})
 	}
 	dbEnabled := m.dbEnabled // Copy to avoid holding lock
+	jitter := m.statsJitter
 	m.mu.RUnlock()
 
-	now := time.Now()
+	tick := time.Now()
 
 	// Collect stats from all miners in parallel
 	var wg sync.WaitGroup
@@ -588,7 +936,14 @@ func (m *Manager) collectMinerStats() {
 					})
 				}
 			}()
-			m.collectSingleMinerStats(miner, minerType, now, dbEnabled)
+			if jitter {
+				select {
+				case <-time.After(statsJitterDelay()):
+				case <-m.stopChan:
+					return
+				}
+			}
+			m.collectSingleMinerStats(miner, minerType, tick, dbEnabled)
 		}(mi.miner, mi.minerType)
 	}
 	wg.Wait()
@@ -597,21 +952,36 @@ func (m *Manager) collectMinerStats() {
 // statsRetryCount is the number of retries for transient stats failures.
 const statsRetryCount = 2
 
-// statsRetryDelay is the delay between stats collection retries.
-const statsRetryDelay = 500 * time.Millisecond
+// statsRetryDelay is the delay before the first stats retry; it doubles for
+// each further retry.
+var statsRetryDelay = 500 * time.Millisecond
+
+// statsUnhealthyThreshold is the number of consecutive failed collections
+// after which a miner's stats API is treated as dead rather than blipping.
+const statsUnhealthyThreshold = 3
 
 // collectSingleMinerStats collects stats from a single miner with retry logic.
-// This is called concurrently for each miner.
-func (m *Manager) collectSingleMinerStats(miner Miner, minerType string, now time.Time, dbEnabled bool) {
+// Retries back off exponentially but never run past the next collection tick.
+// Points are timestamped when the successful fetch was made, not at tick, so
+// jitter and retries don't skew history. This is called concurrently for each miner.
+func (m *Manager) collectSingleMinerStats(miner Miner, minerType string, tick time.Time, dbEnabled bool) {
 	minerName := miner.GetName()
+	deadline := tick.Add(HighResolutionInterval)
+	var now time.Time
 
 	var stats *PerformanceMetrics
 	var lastErr error
+	retried := false
 
 	// Retry loop for transient failures
 	for attempt := 0; attempt <= statsRetryCount; attempt++ {
 		// Use context with timeout to prevent hanging on unresponsive miner APIs
-		ctx, cancel := context.WithTimeout(context.Background(), statsCollectionTimeout)
+		attemptDeadline := time.Now().Add(statsCollectionTimeout)
+		if attemptDeadline.After(deadline) {
+			attemptDeadline = deadline
+		}
+		ctx, cancel := context.WithDeadline(context.Background(), attemptDeadline)
+		now = time.Now()
 		stats, lastErr = miner.GetStats(ctx)
 		cancel() // Release context immediately
 
@@ -619,29 +989,52 @@ func (m *Manager) collectSingleMinerStats(miner Miner, minerType string, now tim
 			break // Success
 		}
 
+		delay := statsRetryDelay << attempt
+		if attempt == statsRetryCount || time.Until(deadline) < delay {
+			break
+		}
+
 		// Log retry attempts at debug level
-		if attempt < statsRetryCount {
-			logging.Debug("retrying stats collection", logging.Fields{
-				"miner":   minerName,
-				"attempt": attempt + 1,
-				"error":   lastErr.Error(),
-			})
-			time.Sleep(statsRetryDelay)
+		logging.Debug("retrying stats collection", logging.Fields{
+			"miner":   minerName,
+			"attempt": attempt + 1,
+			"delay":   delay,
+			"error":   lastErr.Error(),
+		})
+		retried = true
+		select {
+		case <-time.After(delay):
+		case <-m.stopChan:
+			return
 		}
 	}
 
+	state, exited := m.updateStateFromStats(minerName, lastErr)
+	if exited {
+		return
+	}
+	if lastErr != nil && state == MinerStateStarting {
+		// Its API may not be listening yet
+		logging.Debug("miner stats API not answering yet", logging.Fields{"miner": minerName, "error": lastErr.Error()})
+	}
+
+	m.recordStatsHealth(minerName, lastErr)
+
 	if lastErr != nil {
+		if state == MinerStateStarting {
+			return
+		}
 		logging.Error("failed to get miner stats after retries", logging.Fields{
 			"miner":   minerName,
 			"error":   lastErr.Error(),
 			"retries": statsRetryCount,
 		})
-		RecordStatsCollection(true, true)
+		RecordStatsCollection(retried, true)
 		return
 	}
 
 	// Record stats collection (retried if we did any retries)
-	RecordStatsCollection(stats != nil && lastErr == nil, false)
+	RecordStatsCollection(retried, false)
 
 	point := HashratePoint{
 		Timestamp: now,
@@ -665,17 +1058,106 @@ func (m *Manager) collectSingleMinerStats(miner Miner, minerType string, now tim
 			logging.Warn("failed to persist hashrate", logging.Fields{"miner": minerName, "error": err})
 		}
 		dbCancel()
+
+		// Persist the minute average once a minute completes
+		if lowRes, ok := m.addLowResSample(minerName, minerType, point); ok {
+			persistLowResPoint(minerName, minerType, lowRes)
+		}
 	}
 
 	// Emit stats event for real-time WebSocket updates
 	m.emitEvent(EventMinerStats, MinerStatsData{
-		Name:        minerName,
-		Hashrate:    stats.Hashrate,
-		Shares:      stats.Shares,
-		Rejected:    stats.Rejected,
-		Uptime:      stats.Uptime,
-		Algorithm:   stats.Algorithm,
-		DiffCurrent: stats.DiffCurrent,
+		Name:         minerName,
+		Hashrate:     stats.Hashrate,
+		Shares:       stats.Shares,
+		Rejected:     stats.Rejected,
+		Uptime:       stats.Uptime,
+		Algorithm:    stats.Algorithm,
+		DiffCurrent:  stats.DiffCurrent,
+		State:        state,
+		RestartCount: m.restartCount(minerName),
+	})
+
+	m.checkHugePages(minerName, stats)
+}
+
+// updateStateFromStats moves a starting miner to running once its stats API
+// answers, and a live miner to errored when its process turns out to have
+// exited, emitting an error event. It returns the miner's state and whether
+// the process exited. Exits are handed to the crash supervisor.
+func (m *Manager) updateStateFromStats(minerName string, statsErr error) (MinerState, bool) {
+	m.mu.Lock()
+	state := m.states[minerName]
+	var exited bool
+	switch {
+	case statsErr == nil && state == MinerStateStarting:
+		if m.transitionMinerState(minerName, MinerStateRunning) == nil {
+			state = MinerStateRunning
+		}
+	case processExited(statsErr) && state.CanTransitionTo(MinerStateErrored) && state != MinerStateStopping:
+		if m.transitionMinerState(minerName, MinerStateErrored) == nil {
+			state, exited = MinerStateErrored, true
+		}
+	}
+	m.mu.Unlock()
+
+	if exited {
+		m.superviseCrash(minerName, statsErr)
+	}
+	return state, exited
+}
+
+// recordStatsHealth tracks consecutive stats failures for a miner and emits an
+// event when it crosses into or recovers from being unhealthy.
+func (m *Manager) recordStatsHealth(minerName string, statsErr error) {
+	m.mu.Lock()
+	launch, ok := m.launches[minerName]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	wasUnhealthy := launch.Unhealthy
+	if statsErr != nil {
+		launch.statsFailures++
+		launch.Unhealthy = launch.statsFailures >= statsUnhealthyThreshold
+	} else {
+		launch.statsFailures = 0
+		launch.Unhealthy = false
+	}
+	unhealthy, failures := launch.Unhealthy, launch.statsFailures
+	m.mu.Unlock()
+
+	switch {
+	case unhealthy && !wasUnhealthy:
+		logging.Warn("miner stats API unresponsive, marking unhealthy", logging.Fields{"miner": minerName, "failures": failures})
+		m.emitEvent(EventMinerUnhealthy, MinerEventData{
+			Name:   minerName,
+			Reason: fmt.Sprintf("stats API failed %d collections in a row", failures),
+			Error:  statsErr.Error(),
+		})
+	case !unhealthy && wasUnhealthy:
+		logging.Info("miner stats API recovered", logging.Fields{"miner": minerName})
+		m.emitEvent(EventMinerHealthy, MinerEventData{Name: minerName})
+	}
+}
+
+// checkHugePages emits a warning event, once per run, when a miner was started
+// with huge pages requested but the miner reports they aren't fully allocated.
+func (m *Manager) checkHugePages(minerName string, stats *PerformanceMetrics) {
+	m.mu.Lock()
+	launch, ok := m.launches[minerName]
+	if !ok || launch.hugePagesWarned || !hugePagesInactive(launch.Config, stats) {
+		m.mu.Unlock()
+		return
+	}
+	launch.hugePagesWarned = true
+	m.mu.Unlock()
+
+	reason := fmt.Sprintf("huge pages requested but only %d/%d allocated; hashrate will be reduced", stats.HugePagesAllocated, stats.HugePagesTotal)
+	logging.Warn("huge pages not active", logging.Fields{"miner": minerName, "allocated": stats.HugePagesAllocated, "total": stats.HugePagesTotal})
+	m.emitEvent(EventMinerWarning, MinerEventData{
+		Name:   minerName,
+		Reason: reason,
 	})
 }
 
@@ -690,20 +1172,21 @@ func (m *Manager) GetMinerHashrateHistory(name string) ([]HashratePoint, error)
 	return miner.GetHashrateHistory(), nil
 }
 
-// ShutdownTimeout is the maximum time to wait for goroutines during shutdown
+// ShutdownTimeout is the default maximum time Stop spends stopping miners,
+// and then again waiting for goroutines, see SetShutdownTimeout
 const ShutdownTimeout = 10 * time.Second
 
 // Stop stops all running miners, background goroutines, and closes resources.
 // Safe to call multiple times - subsequent calls are no-ops.
 func (m *Manager) Stop() {
 	m.stopOnce.Do(func() {
-		// Stop all running miners first
+		// Stop all running miners first, in ShutdownPriority order
 		m.mu.Lock()
-		for name, miner := range m.miners {
-			if err := miner.Stop(); err != nil {
-				logging.Warn("failed to stop miner", logging.Fields{"miner": name, "error": err})
-			}
+		shutdownTimeout := m.shutdownTimeout
+		if shutdownTimeout <= 0 {
+			shutdownTimeout = ShutdownTimeout
 		}
+		m.stopAllMiners(shutdownTimeout)
 		m.mu.Unlock()
 
 		close(m.stopChan)
@@ -718,7 +1201,7 @@ func (m *Manager) Stop() {
 		select {
 		case <-done:
 			logging.Info("all goroutines stopped gracefully")
-		case <-time.After(ShutdownTimeout):
+		case <-time.After(shutdownTimeout):
 			logging.Warn("shutdown timeout - some goroutines may not have stopped")
 		}
 
@@ -732,22 +1215,43 @@ func (m *Manager) Stop() {
 }
 
 // GetMinerHistoricalStats returns historical stats from the database for a miner.
-func (m *Manager) GetMinerHistoricalStats(minerName string) (*database.HashrateStats, error) {
+func (m *Manager) GetMinerHistoricalStats(ctx context.Context, minerName string) (*database.HashrateStats, error) {
 	if !m.dbEnabled {
 		return nil, fmt.Errorf("database persistence is disabled")
 	}
-	return database.GetHashrateStats(minerName)
+	return database.GetHashrateStats(ctx, minerName)
 }
 
 // GetMinerHistoricalHashrate returns historical hashrate data from the database.
-func (m *Manager) GetMinerHistoricalHashrate(minerName string, since, until time.Time) ([]HashratePoint, error) {
+// Long ranges are answered from minute averages, see historyResolution.
+func (m *Manager) GetMinerHistoricalHashrate(ctx context.Context, minerName string, since, until time.Time) ([]HashratePoint, error) {
+	points, _, err := m.GetMinerHistoricalHashrateAt(ctx, minerName, "", since, until)
+	return points, err
+}
+
+// GetMinerHistoricalHashrateAt returns historical hashrate data at the given
+// resolution, or an automatically chosen one when resolution is empty (see
+// historyResolution), along with the resolution actually used. An automatic
+// pick falls back to high resolution if no minute averages were recorded.
+func (m *Manager) GetMinerHistoricalHashrateAt(ctx context.Context, minerName string, resolution database.Resolution, since, until time.Time) ([]HashratePoint, database.Resolution, error) {
 	if !m.dbEnabled {
-		return nil, fmt.Errorf("database persistence is disabled")
+		return nil, "", fmt.Errorf("database persistence is disabled")
 	}
 
-	dbPoints, err := database.GetHashrateHistory(minerName, database.ResolutionHigh, since, until)
+	auto := resolution == ""
+	if auto {
+		resolution = m.historyResolution(since, until)
+	}
+	dbPoints, err := database.GetHashrateHistory(ctx, minerName, resolution, since, until)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	// History recorded before low resolution persistence existed only has high resolution rows
+	if auto && len(dbPoints) == 0 && resolution == database.ResolutionLow {
+		resolution = database.ResolutionHigh
+		if dbPoints, err = database.GetHashrateHistory(ctx, minerName, resolution, since, until); err != nil {
+			return nil, "", err
+		}
 	}
 
 	// Convert database points to mining points
@@ -758,15 +1262,72 @@ func (m *Manager) GetMinerHistoricalHashrate(minerName string, since, until time
 			Hashrate:  p.Hashrate,
 		}
 	}
-	return points, nil
+	return points, resolution, nil
+}
+
+// StreamMinerHistoricalHashrate is GetMinerHistoricalHashrateAt calling fn
+// with each point as it is read from the database instead of collecting them,
+// so a long range is never held in memory. fn is also passed the resolution
+// the point is at, as an automatic pick may fall back to high resolution
+// after the low resolution query returns nothing.
+func (m *Manager) StreamMinerHistoricalHashrate(ctx context.Context, minerName string, resolution database.Resolution, since, until time.Time, fn func(database.Resolution, HashratePoint) error) (database.Resolution, error) {
+	if !m.dbEnabled {
+		return "", fmt.Errorf("database persistence is disabled")
+	}
+
+	auto := resolution == ""
+	if auto {
+		resolution = m.historyResolution(since, until)
+	}
+	rows := 0
+	stream := func(resolution database.Resolution) error {
+		return database.StreamHashrateHistory(ctx, minerName, resolution, since, until, func(p database.HashratePoint) error {
+			rows++
+			return fn(resolution, HashratePoint{Timestamp: p.Timestamp, Hashrate: p.Hashrate})
+		})
+	}
+	if err := stream(resolution); err != nil {
+		return "", err
+	}
+	if auto && rows == 0 && resolution == database.ResolutionLow {
+		resolution = database.ResolutionHigh
+		if err := stream(resolution); err != nil {
+			return "", err
+		}
+	}
+	return resolution, nil
+}
+
+// GetDatabaseStorageStats returns row counts and disk usage of the history database.
+func (m *Manager) GetDatabaseStorageStats(ctx context.Context) (*database.StorageStats, error) {
+	if !m.dbEnabled {
+		return nil, fmt.Errorf("database persistence is disabled")
+	}
+	return database.GetStorageStats(ctx)
+}
+
+// VacuumDatabase compacts the database file and reports its size before and
+// after. Inserts wait while it runs, see database.Vacuum.
+func (m *Manager) VacuumDatabase(ctx context.Context) (*database.VacuumResult, error) {
+	if !m.dbEnabled {
+		return nil, fmt.Errorf("database persistence is disabled")
+	}
+	result, err := database.Vacuum(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		logging.Info("database vacuumed", logging.Fields{"size_before": result.SizeBefore, "size_after": result.SizeAfter, "duration_ms": result.DurationMs})
+	}
+	return result, nil
 }
 
 // GetAllMinerHistoricalStats returns historical stats for all miners from the database.
-func (m *Manager) GetAllMinerHistoricalStats() ([]database.HashrateStats, error) {
+func (m *Manager) GetAllMinerHistoricalStats(ctx context.Context) ([]database.HashrateStats, error) {
 	if !m.dbEnabled {
 		return nil, fmt.Errorf("database persistence is disabled")
 	}
-	return database.GetAllMinerStats()
+	return database.GetAllMinerStats(ctx)
 }
 
 // IsDatabaseEnabled returns whether database persistence is enabled.
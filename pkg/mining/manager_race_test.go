@@ -95,6 +95,49 @@ func TestConcurrentStartDuplicateMiner(t *testing.T) {
 	}
 }
 
+// TestConcurrentStartWithoutAlgo verifies that miners of the same type
+// started concurrently without an algo all get distinct names
+func TestConcurrentStartWithoutAlgo(t *testing.T) {
+	m := setupTestManager(t)
+	defer m.Stop()
+
+	const count = 50
+	var wg sync.WaitGroup
+	names := make(chan string, count)
+	failures := make(chan error, count)
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			miner, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet"})
+			if err != nil {
+				failures <- err
+				return
+			}
+			names <- miner.GetName()
+		}()
+	}
+
+	wg.Wait()
+	close(names)
+	close(failures)
+
+	for err := range failures {
+		t.Errorf("start failed: %v", err)
+	}
+	seen := make(map[string]bool)
+	for name := range names {
+		if seen[name] {
+			t.Errorf("instance name %s was given out twice", name)
+		}
+		seen[name] = true
+		if _, err := m.GetMiner(name); err != nil {
+			t.Errorf("miner %s is not registered: %v", name, err)
+		}
+	}
+}
+
 // TestConcurrentStartStop verifies that starting and stopping miners
 // concurrently doesn't cause race conditions
 func TestConcurrentStartStop(t *testing.T) {
@@ -2,10 +2,12 @@ package mining
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 )
 
 // setupTestManager creates a new Manager and a dummy executable for tests.
@@ -138,3 +140,210 @@ func TestListMiners_Good(t *testing.T) {
 		t.Errorf("Expected %d miners, but got %d", expectedCount, len(finalMiners))
 	}
 }
+
+func TestRegisterMinerNaming(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	first := NewSimulatedMiner(SimulatedMinerConfig{Name: "sim"})
+	second := NewSimulatedMiner(SimulatedMinerConfig{Name: "sim"})
+	unnamed := NewSimulatedMiner(SimulatedMinerConfig{})
+	for _, miner := range []*SimulatedMiner{first, second, unnamed} {
+		if err := m.RegisterMiner(miner); err != nil {
+			t.Fatalf("RegisterMiner failed: %v", err)
+		}
+	}
+
+	if first.GetName() != "sim" {
+		t.Errorf("a free name should be kept, got %s", first.GetName())
+	}
+	if second.GetName() != "sim-1" {
+		t.Errorf("a taken name should get an instance number, got %s", second.GetName())
+	}
+	if unnamed.GetName() != MinerTypeSimulated+"-2" {
+		t.Errorf("an empty name should be based on the type, got %s", unnamed.GetName())
+	}
+	if len(m.ListMiners()) != 3 {
+		t.Errorf("expected 3 miners, got %d", len(m.ListMiners()))
+	}
+}
+
+func TestStopMinerNameResolution(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	register := func(names ...string) {
+		for _, name := range names {
+			if err := m.RegisterMiner(NewSimulatedMiner(SimulatedMinerConfig{Name: name})); err != nil {
+				t.Fatalf("RegisterMiner(%s) failed: %v", name, err)
+			}
+		}
+	}
+	register("xmrig", "xmrig-rx_0", "tt-miner-kawpow", "sim-a-1", "sim-a-2")
+
+	tests := []struct {
+		name    string
+		stopped string // Instance expected to be stopped, empty for an error
+		code    string
+	}{
+		{"xmrig", "xmrig", ""},                    // Exact names win over base names
+		{"xmrig", "xmrig-rx_0", ""},               // Then a base name matches its only instance
+		{"tt-min", "", ErrCodeMinerNotFound},      // Prefixes must end at a "-"
+		{"tt-miner", "tt-miner-kawpow", ""},       // Base names may contain "-" themselves
+		{"sim-a", "", ErrCodeInvalidInput},        // Several instances are ambiguous
+		{"sim-a-2", "sim-a-2", ""},                // Full names still work
+		{"sim-a", "sim-a-1", ""},                  // Which leaves a single match
+		{"nonexistent", "", ErrCodeMinerNotFound}, // Unknown names are not found
+	}
+	for _, tt := range tests {
+		err := m.StopMiner(context.Background(), tt.name)
+		if tt.stopped == "" {
+			var miningErr *MiningError
+			if !errors.As(err, &miningErr) || miningErr.Code != tt.code {
+				t.Errorf("StopMiner(%s): expected %s, got %v", tt.name, tt.code, err)
+			}
+			continue
+		}
+		// Registered simulated miners were never started, so Stop reports that
+		if err != nil && !errors.Is(err, ErrProcessNotRunning) {
+			t.Errorf("StopMiner(%s) failed: %v", tt.name, err)
+		}
+		if _, err := m.GetMiner(tt.stopped); err == nil {
+			t.Errorf("StopMiner(%s) should have removed %s", tt.name, tt.stopped)
+		}
+	}
+}
+
+func TestCollectStatsMarksMinerUnhealthy(t *testing.T) {
+	originalDelay := statsRetryDelay
+	statsRetryDelay = time.Millisecond
+	defer func() { statsRetryDelay = originalDelay }()
+
+	hub := NewEventHub()
+	go hub.Run()
+	defer hub.Stop()
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	m.SetEventHub(hub)
+	health := make(chan EventType, 4)
+	hub.AddSink(EventSinkFunc(func(e Event) {
+		if e.Type == EventMinerUnhealthy || e.Type == EventMinerHealthy {
+			health <- e.Type
+		}
+	}))
+
+	var calls int
+	failing := true
+	miner := &MockMiner{
+		GetNameFunc: func() string { return "flaky" },
+		GetStatsFunc: func(ctx context.Context) (*PerformanceMetrics, error) {
+			calls++
+			if failing {
+				return nil, errors.New("connection refused")
+			}
+			return &PerformanceMetrics{Hashrate: 100}, nil
+		},
+		AddHashratePointFunc:      func(point HashratePoint) {},
+		ReduceHashrateHistoryFunc: func(now time.Time) {},
+	}
+	m.launches["flaky"] = &MinerLaunchInfo{}
+
+	for i := 0; i < statsUnhealthyThreshold; i++ {
+		m.collectSingleMinerStats(miner, "mock", time.Now(), false)
+	}
+	if calls != statsUnhealthyThreshold*(statsRetryCount+1) {
+		t.Errorf("expected %d attempts including retries, got %d", statsUnhealthyThreshold*(statsRetryCount+1), calls)
+	}
+	if !m.launches["flaky"].Unhealthy {
+		t.Fatal("expected miner to be unhealthy")
+	}
+
+	failing = false
+	m.collectSingleMinerStats(miner, "mock", time.Now(), false)
+	if m.launches["flaky"].Unhealthy {
+		t.Error("expected miner to recover after a successful collection")
+	}
+
+	for _, want := range []EventType{EventMinerUnhealthy, EventMinerHealthy} {
+		select {
+		case got := <-health:
+			if got != want {
+				t.Errorf("expected %s event, got %s", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
+
+func TestCollectStatsTimestampsAtFetchTime(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if d := statsJitterDelay(); d < 0 || d >= time.Duration(float64(HighResolutionInterval)*statsJitterFraction) {
+			t.Fatalf("jitter %v out of range", d)
+		}
+	}
+
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	var points []HashratePoint
+	miner := &MockMiner{
+		GetNameFunc: func() string { return "jittered" },
+		GetStatsFunc: func(ctx context.Context) (*PerformanceMetrics, error) {
+			return &PerformanceMetrics{Hashrate: 100}, nil
+		},
+		AddHashratePointFunc:      func(point HashratePoint) { points = append(points, point) },
+		ReduceHashrateHistoryFunc: func(now time.Time) {},
+	}
+
+	// A collection delayed by jitter is stamped when it ran, not at the tick
+	tick := time.Now().Add(-2 * time.Second)
+	m.collectSingleMinerStats(miner, "mock", tick, false)
+	if len(points) != 1 || points[0].Timestamp.Sub(tick) < 2*time.Second {
+		t.Errorf("expected the point stamped at fetch time, got %+v (tick %v)", points, tick)
+	}
+}
+
+func TestStopMinerReleasesLockWhileStopping(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	stopping := make(chan struct{})
+	release := make(chan struct{})
+	slow := &MockMiner{
+		GetNameFunc: func() string { return "slow" },
+		StopFunc: func() error {
+			close(stopping)
+			<-release
+			return nil
+		},
+	}
+	if err := m.RegisterMiner(slow); err != nil {
+		t.Fatalf("RegisterMiner failed: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- m.StopMiner(context.Background(), "slow") }()
+	<-stopping
+
+	// The manager stays usable while the miner waits out its stop timeout
+	listed := make(chan int, 1)
+	go func() { listed <- len(m.ListMiners()) }()
+	select {
+	case <-listed:
+	case <-time.After(time.Second):
+		t.Fatal("ListMiners blocked while a miner was stopping")
+	}
+	if state, _ := m.GetMinerState("slow"); state != MinerStateStopping {
+		t.Errorf("expected the miner to be stopping, got %s", state)
+	}
+	var miningErr *MiningError
+	if err := m.StopMiner(context.Background(), "slow"); !errors.As(err, &miningErr) || miningErr.HTTPStatus != 409 {
+		t.Errorf("expected a conflict stopping a miner twice, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("StopMiner failed: %v", err)
+	}
+	if _, err := m.GetMiner("slow"); err == nil {
+		t.Error("expected the stopped miner to be removed")
+	}
+}
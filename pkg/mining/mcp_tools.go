@@ -0,0 +1,214 @@
+package mining
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Snider/Mining/pkg/logging"
+	ginmcp "github.com/ckanthony/gin-mcp"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMCPPath is where the MCP server is mounted, relative to the API base path.
+const defaultMCPPath = "/mcp"
+
+// MCPConfig controls the MCP server that exposes the API as tools to AI assistants.
+type MCPConfig struct {
+	Enabled bool
+	Path    string // Mount path relative to the API base path
+}
+
+// MCPStatus reports the MCP server's state in /info.
+type MCPStatus struct {
+	Enabled      bool   `json:"enabled"`
+	Endpoint     string `json:"endpoint,omitempty"`
+	AuthRequired bool   `json:"authRequired"`
+}
+
+// MCPConfigFromEnv creates MCP config from environment variables. The MCP
+// server is off unless MINING_MCP_ENABLED turns it on; MINING_MCP_PATH sets
+// the mount path.
+func MCPConfigFromEnv() MCPConfig {
+	config := MCPConfig{Path: defaultMCPPath}
+	if v := os.Getenv("MINING_MCP_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			config.Enabled = enabled
+		} else {
+			logging.Warn("invalid MINING_MCP_ENABLED, leaving MCP disabled", logging.Fields{"value": v})
+		}
+	}
+	if path := os.Getenv("MINING_MCP_PATH"); path != "" {
+		config.Path = "/" + strings.Trim(path, "/")
+	}
+	return config
+}
+
+// mcpEndpoint returns the full path the MCP server is mounted at.
+func (s *Service) mcpEndpoint() string {
+	path := s.MCP.Path
+	if path == "" {
+		path = defaultMCPPath
+	}
+	return strings.TrimSuffix(s.APIBasePath, "/") + path
+}
+
+// MCPStatus reports whether the MCP server is mounted, where, and whether it requires auth.
+func (s *Service) MCPStatus() MCPStatus {
+	if !s.MCP.Enabled {
+		return MCPStatus{}
+	}
+	return MCPStatus{Enabled: true, Endpoint: s.mcpEndpoint(), AuthRequired: s.currentAuth() != nil}
+}
+
+// mountMCP mounts the MCP server, behind the API's auth when that is enabled.
+func (s *Service) mountMCP() {
+	if !s.MCP.Enabled {
+		logging.Info("MCP server disabled", logging.Fields{"hint": "set MINING_MCP_ENABLED=true to enable"})
+		return
+	}
+	endpoint := s.mcpEndpoint()
+
+	// The MCP routes are registered on the engine, outside the API group, so
+	// guard them with an engine middleware registered before they are.
+	authMiddleware := s.authMiddleware()
+	s.Router.Use(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, endpoint) {
+			authMiddleware(c)
+			return
+		}
+		c.Next()
+	})
+
+	// This exposes API endpoints as MCP tools for Claude, Cursor, etc.
+	s.mcpServer = ginmcp.New(s.Router, &ginmcp.Config{
+		Name:        "Mining API",
+		Description: "Mining dashboard API exposed via Model Context Protocol (MCP)",
+		BaseURL:     fmt.Sprintf("http://%s", s.DisplayAddr),
+	})
+	s.registerMCPTools(s.mcpServer)
+	s.mcpServer.Mount(endpoint)
+	logging.Info("MCP server enabled", logging.Fields{"endpoint": endpoint, "auth": s.currentAuth() != nil})
+}
+
+// startMiningPath is the route behind the start_mining MCP tool, relative to
+// the API base path. gin-mcp names tools after their routes, so the tool is
+// listed as POST_<base path>_start_mining.
+const startMiningPath = "/start_mining"
+
+// StartMiningRequest is the input of the start_mining tool. gin-mcp reads the
+// jsonschema tags to build the tool's input schema; commas split the tag, so
+// descriptions must not contain any.
+type StartMiningRequest struct {
+	MinerType string `json:"minerType" jsonschema:"required,description=Miner to run: xmrig or tt-miner"`
+	Pool      string `json:"pool" jsonschema:"description=Pool URL such as stratum+tcp://pool.example.com:3333; defaults to the pool in settings"`
+	Wallet    string `json:"wallet" jsonschema:"description=Wallet address to mine to; defaults to the wallet in settings"`
+	Algo      string `json:"algo,omitempty" jsonschema:"description=Mining algorithm such as rx/0; defaults to the algorithm in settings"`
+	Threads   int    `json:"threads,omitempty" jsonschema:"description=CPU threads; 0 uses the CPU hint from settings"`
+}
+
+// StartMiningResult is returned by the start_mining tool on success.
+type StartMiningResult struct {
+	MinerName string        `json:"minerName"`
+	MinerType string        `json:"minerType"`
+	Pool      string        `json:"pool"`
+	Algo      string        `json:"algo,omitempty"`
+	Threads   int           `json:"threads,omitempty"`
+	Warnings  []ConfigIssue `json:"warnings,omitempty"`
+}
+
+// registerMCPTools gives the purpose-built tool routes typed input schemas.
+// Must be called before the MCP server is mounted.
+func (s *Service) registerMCPTools(mcp *ginmcp.GinMCP) {
+	mcp.RegisterSchema(http.MethodPost, strings.TrimSuffix(s.APIBasePath, "/")+startMiningPath, nil, StartMiningRequest{})
+}
+
+// resolveStartMiningConfig fills what the request leaves out from the miner
+// defaults in settings.
+func (s *Service) resolveStartMiningConfig(req *StartMiningRequest) *Config {
+	config := &Config{
+		Pool:    req.Pool,
+		Wallet:  req.Wallet,
+		Algo:    req.Algo,
+		Threads: req.Threads,
+	}
+	if s.SettingsManager == nil {
+		return config
+	}
+	defaults := s.SettingsManager.Get().MinerDefaults
+	if config.Pool == "" {
+		config.Pool = defaults.DefaultPool
+	}
+	if config.Wallet == "" {
+		config.Wallet = defaults.DefaultWallet
+	}
+	if config.Algo == "" {
+		config.Algo = defaults.DefaultAlgorithm
+	}
+	if config.Threads == 0 {
+		config.CPUMaxThreadsHint = defaults.CPUMaxThreadsHint
+	}
+	return config
+}
+
+// handleStartMining godoc
+// @Summary Start mining in one step
+// @Description Starts a miner from a pool, wallet and optional algorithm and thread count, without creating a profile first. Missing values fall back to the miner defaults in settings. The config is validated before starting; the response names the running miner. Exposed to AI assistants as the start_mining MCP tool.
+// @Tags miners
+// @Accept json
+// @Produce json
+// @Param request body StartMiningRequest true "What to mine"
+// @Success 200 {object} StartMiningResult
+// @Failure 400 {object} APIError "Invalid miner type or config"
+// @Router /start_mining [post]
+func (s *Service) handleStartMining(c *gin.Context) {
+	var req StartMiningRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+
+	miner, err := CreateMiner(req.MinerType)
+	if err != nil {
+		respondWithMiningError(c, ErrUnsupportedMiner(req.MinerType).WithSuggestion("Use one of: "+strings.Join(ListMinerTypes(), ", ")))
+		return
+	}
+	minerType := miner.GetType()
+
+	config := s.resolveStartMiningConfig(&req)
+	if config.Pool == "" || config.Wallet == "" {
+		respondWithMiningError(c, ErrInvalidConfig("pool and wallet are required").
+			WithSuggestion("Pass pool and wallet, or set defaultPool and defaultWallet in the miner defaults via PUT /settings"))
+		return
+	}
+	validation := ValidateMinerConfig(minerType, config)
+	if !validation.Valid {
+		issue := validation.Errors[0]
+		respondWithMiningError(c, ErrInvalidConfig(issue.Field+": "+issue.Message))
+		return
+	}
+
+	started, err := s.Manager.StartMiner(c.Request.Context(), minerType, config)
+	if err != nil {
+		var miningErr *MiningError
+		if errors.As(err, &miningErr) {
+			respondWithMiningError(c, miningErr)
+			return
+		}
+		respondWithMiningError(c, ErrStartFailed(minerType).WithCause(err).
+			WithSuggestion("Check the miner is installed (POST /miners/"+minerType+"/install) and see its logs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, StartMiningResult{
+		MinerName: started.GetName(),
+		MinerType: minerType,
+		Pool:      config.Pool,
+		Algo:      config.Algo,
+		Threads:   config.Threads,
+		Warnings:  validation.Warnings,
+	})
+}
@@ -0,0 +1,132 @@
+package mining
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleStartMining(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	var gotType string
+	var gotConfig *Config
+	mockManager.StartMinerFunc = func(ctx context.Context, minerType string, config *Config) (Miner, error) {
+		gotType, gotConfig = minerType, config
+		return &MockMiner{GetNameFunc: func() string { return "xmrig-123" }}, nil
+	}
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"minerType": "cgminer", "pool": "stratum+tcp://pool.example.com:3333", "wallet": "w"}`, http.StatusBadRequest},
+		{`{"minerType": "xmrig", "pool": "stratum+tcp://pool.example.com:3333"}`, http.StatusBadRequest},
+		{`{"minerType": "xmrig", "pool": "stratum+tcp://pool.example.com:3333", "wallet": "w", "threads": -1}`, http.StatusBadRequest},
+		{`{"minerType": "xmrig", "pool": "stratum+tcp://pool.example.com:3333", "wallet": "wallet1", "algo": "rx/0", "threads": 2}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		gotConfig = nil
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/start_mining", strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d: %s", tt.body, tt.code, w.Code, w.Body.String())
+		}
+		if tt.code != http.StatusOK && gotConfig != nil {
+			t.Errorf("%s: miner should not be started", tt.body)
+		}
+	}
+
+	if gotType != "xmrig" || gotConfig == nil || gotConfig.Wallet != "wallet1" || gotConfig.Threads != 2 {
+		t.Fatalf("unexpected start: type %q config %+v", gotType, gotConfig)
+	}
+}
+
+func TestHandleStartMiningUsesSettingsDefaults(t *testing.T) {
+	_, mockManager := setupTestRouter()
+	var gotConfig *Config
+	mockManager.StartMinerFunc = func(ctx context.Context, minerType string, config *Config) (Miner, error) {
+		gotConfig = config
+		return &MockMiner{GetNameFunc: func() string { return "xmrig-1" }}, nil
+	}
+
+	settings := &SettingsManager{settings: DefaultSettings(), settingsPath: filepath.Join(t.TempDir(), "settings.json")}
+	settings.settings.MinerDefaults.DefaultPool = "stratum+tcp://default.example.com:3333"
+	settings.settings.MinerDefaults.DefaultWallet = "default-wallet"
+
+	service := &Service{Manager: mockManager, SettingsManager: settings}
+	router, _ := setupTestRouter()
+	router.POST("/start_mining_defaults", service.handleStartMining)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/start_mining_defaults", strings.NewReader(`{"minerType": "xmrig"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result StartMiningResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.MinerName != "xmrig-1" || result.Pool != "stratum+tcp://default.example.com:3333" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if gotConfig.Wallet != "default-wallet" || gotConfig.CPUMaxThreadsHint != 50 {
+		t.Errorf("expected settings defaults in config, got %+v", gotConfig)
+	}
+}
+
+func TestMCPConfigFromEnv(t *testing.T) {
+	t.Setenv("MINING_MCP_ENABLED", "")
+	t.Setenv("MINING_MCP_PATH", "")
+	if config := MCPConfigFromEnv(); config.Enabled || config.Path != "/mcp" {
+		t.Fatalf("unexpected default: %+v", config)
+	}
+
+	t.Setenv("MINING_MCP_ENABLED", "true")
+	t.Setenv("MINING_MCP_PATH", "tools/")
+	if config := MCPConfigFromEnv(); !config.Enabled || config.Path != "/tools" {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+
+	t.Setenv("MINING_MCP_ENABLED", "not-a-bool")
+	if config := MCPConfigFromEnv(); config.Enabled {
+		t.Fatal("an invalid value should leave MCP disabled")
+	}
+}
+
+func TestMountMCP(t *testing.T) {
+	newService := func(config MCPConfig, auth *DigestAuth) *Service {
+		router, _ := setupTestRouter()
+		return &Service{Router: router, APIBasePath: "/api/v1/mining", DisplayAddr: "localhost:9090", MCP: config, auth: auth}
+	}
+
+	service := newService(MCPConfig{}, nil)
+	service.mountMCP()
+	w := httptest.NewRecorder()
+	service.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/mining/mcp", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("disabled MCP should not be mounted, got %d", w.Code)
+	}
+	if status := service.MCPStatus(); status.Enabled || status.Endpoint != "" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	auth := NewDigestAuth(AuthConfig{Enabled: true, Username: "admin", Password: "secret", Realm: "Test", NonceExpiry: time.Minute})
+	defer auth.Stop()
+	service = newService(MCPConfig{Enabled: true, Path: "/tools"}, auth)
+	service.mountMCP()
+	w = httptest.NewRecorder()
+	service.Router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/mining/tools", strings.NewReader(`{}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("MCP should require auth, got %d", w.Code)
+	}
+	status := service.MCPStatus()
+	if !status.Enabled || status.Endpoint != "/api/v1/mining/tools" || !status.AuthRequired {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
@@ -19,6 +19,10 @@ type Metrics struct {
 	MinersStopped atomic.Int64
 	MinersErrored atomic.Int64
 
+	// Start breaker metrics
+	StartBreakerTrips atomic.Int64
+	StartsRejected    atomic.Int64
+
 	// Stats collection metrics
 	StatsCollected atomic.Int64
 	StatsRetried   atomic.Int64
@@ -114,6 +118,16 @@ func RecordMinerError() {
 	DefaultMetrics.MinersErrored.Add(1)
 }
 
+// RecordStartBreakerTrip records a start breaker opening after repeated failed starts.
+func RecordStartBreakerTrip() {
+	DefaultMetrics.StartBreakerTrips.Add(1)
+}
+
+// RecordStartRejected records a start refused by an open start breaker.
+func RecordStartRejected() {
+	DefaultMetrics.StartsRejected.Add(1)
+}
+
 // RecordStatsCollection records a stats collection event.
 func RecordStatsCollection(retried bool, failed bool) {
 	DefaultMetrics.StatsCollected.Add(1)
@@ -158,6 +172,8 @@ func GetMetricsSnapshot() map[string]interface{} {
 		"miners_started":          DefaultMetrics.MinersStarted.Load(),
 		"miners_stopped":          DefaultMetrics.MinersStopped.Load(),
 		"miners_errored":          DefaultMetrics.MinersErrored.Load(),
+		"start_breaker_trips":     DefaultMetrics.StartBreakerTrips.Load(),
+		"starts_rejected":         DefaultMetrics.StartsRejected.Load(),
 		"stats_collected":         DefaultMetrics.StatsCollected.Load(),
 		"stats_retried":           DefaultMetrics.StatsRetried.Load(),
 		"stats_failed":            DefaultMetrics.StatsFailed.Load(),
@@ -5,10 +5,10 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -21,20 +21,76 @@ import (
 	"time"
 
 	"github.com/Snider/Mining/pkg/logging"
-	"github.com/adrg/xdg"
 )
 
+// Log timestamp formats for LogBuffer.GetLines.
+const (
+	LogTimestampTime    = "15:04:05"   // Local time of day only (default)
+	LogTimestampRFC3339 = time.RFC3339 // Full date, time and timezone offset
+)
+
+// ErrProcessNotRunning is returned by a miner's Stop, GetStats and other
+// process queries when its process isn't running, including after it exited.
+var ErrProcessNotRunning = errors.New("miner is not running")
+
+var (
+	logTimestampLayout   = LogTimestampTime
+	logTimestampLayoutMu sync.RWMutex
+)
+
+// SetLogTimestampFormat sets how GetLines formats timestamps: "time" (default)
+// or "rfc3339". Any other value is used as a Go time layout.
+func SetLogTimestampFormat(format string) {
+	layout := format
+	switch strings.ToLower(format) {
+	case "", "time":
+		layout = LogTimestampTime
+	case "rfc3339":
+		layout = LogTimestampRFC3339
+	}
+	logTimestampLayoutMu.Lock()
+	defer logTimestampLayoutMu.Unlock()
+	logTimestampLayout = layout
+}
+
+func getLogTimestampLayout() string {
+	logTimestampLayoutMu.RLock()
+	defer logTimestampLayoutMu.RUnlock()
+	return logTimestampLayout
+}
+
+// LogStream is the output stream a log line was written to.
+type LogStream string
+
+const (
+	LogStreamStdout LogStream = "stdout"
+	LogStreamStderr LogStream = "stderr"
+)
+
+// LogEntry is a captured line of miner output with the time it was received.
+type LogEntry struct {
+	TS     time.Time `json:"ts"`
+	Text   string    `json:"text"`
+	Stream LogStream `json:"stream,omitempty"` // Empty if the writer didn't say
+}
+
+// format renders the entry as GetLines does, with its timestamp in layout.
+func (e LogEntry) format(layout string) string {
+	return fmt.Sprintf("[%s] %s", e.TS.Format(layout), e.Text)
+}
+
 // LogBuffer is a thread-safe ring buffer for capturing miner output.
 type LogBuffer struct {
-	lines    []string
+	entries  []LogEntry
 	maxLines int
+	file     io.WriteCloser // Optional persistent copy of the output
 	mu       sync.RWMutex
 }
 
 // NewLogBuffer creates a new log buffer with the specified max lines.
 func NewLogBuffer(maxLines int) *LogBuffer {
 	return &LogBuffer{
-		lines:    make([]string, 0, maxLines),
+		entries:  make([]LogEntry, 0, maxLines),
 		maxLines: maxLines,
 	}
 }
@@ -42,8 +98,29 @@ func NewLogBuffer(maxLines int) *LogBuffer {
 // maxLineLength is the maximum length of a single log line to prevent memory bloat.
 const maxLineLength = 2000
 
-// Write implements io.Writer for capturing output.
+// Write implements io.Writer for capturing output. Lines aren't tagged with
+// a stream; use Stream for that.
 func (lb *LogBuffer) Write(p []byte) (n int, err error) {
+	return lb.write(p, "")
+}
+
+// Stream returns a writer that captures output tagged as coming from stream.
+// Give a process's stdout and stderr one each to tell them apart; their lines
+// stay in the order they arrived.
+func (lb *LogBuffer) Stream(stream LogStream) io.Writer {
+	return logStreamWriter{lb: lb, stream: stream}
+}
+
+type logStreamWriter struct {
+	lb     *LogBuffer
+	stream LogStream
+}
+
+func (w logStreamWriter) Write(p []byte) (int, error) {
+	return w.lb.write(p, w.stream)
+}
+
+func (lb *LogBuffer) write(p []byte, stream LogStream) (n int, err error) {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
 
@@ -59,48 +136,88 @@ func (lb *LogBuffer) Write(p []byte) (n int, err error) {
 		if len(line) > maxLineLength {
 			line = line[:maxLineLength] + "... [truncated]"
 		}
-		// Add timestamp prefix
-		timestampedLine := fmt.Sprintf("[%s] %s", time.Now().Format("15:04:05"), line)
-		lb.lines = append(lb.lines, timestampedLine)
+		now := time.Now()
+		lb.entries = append(lb.entries, LogEntry{TS: now, Text: line, Stream: stream})
+
+		// Tee to the log file with the full date and zone so it stands on its own
+		if lb.file != nil {
+			fmt.Fprintf(lb.file, "[%s] %s\n", now.Format(time.RFC3339), line)
+		}
 
 		// Trim if over max - force reallocation to release memory
-		if len(lb.lines) > lb.maxLines {
-			newSlice := make([]string, lb.maxLines)
-			copy(newSlice, lb.lines[len(lb.lines)-lb.maxLines:])
-			lb.lines = newSlice
+		if len(lb.entries) > lb.maxLines {
+			newSlice := make([]LogEntry, lb.maxLines)
+			copy(newSlice, lb.entries[len(lb.entries)-lb.maxLines:])
+			lb.entries = newSlice
 		}
 	}
 	return len(p), nil
 }
 
-// GetLines returns all captured log lines.
+// GetLines returns all captured log lines, prefixed with a timestamp in the
+// configured format (see SetLogTimestampFormat).
 func (lb *LogBuffer) GetLines() []string {
+	layout := getLogTimestampLayout()
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	result := make([]string, len(lb.entries))
+	for i, entry := range lb.entries {
+		result[i] = entry.format(layout)
+	}
+	return result
+}
+
+// GetEntries returns all captured log lines with their raw timestamps.
+func (lb *LogBuffer) GetEntries() []LogEntry {
 	lb.mu.RLock()
 	defer lb.mu.RUnlock()
-	result := make([]string, len(lb.lines))
-	copy(result, lb.lines)
+	result := make([]LogEntry, len(lb.entries))
+	copy(result, lb.entries)
 	return result
 }
 
+// SetFile tees all future output to w, closing any previously attached file.
+func (lb *LogBuffer) SetFile(w io.WriteCloser) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if lb.file != nil {
+		lb.file.Close()
+	}
+	lb.file = w
+}
+
+// CloseFile closes and detaches the log file, if any.
+func (lb *LogBuffer) CloseFile() error {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if lb.file == nil {
+		return nil
+	}
+	err := lb.file.Close()
+	lb.file = nil
+	return err
+}
+
 // Clear clears the log buffer.
 func (lb *LogBuffer) Clear() {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
-	lb.lines = lb.lines[:0]
+	lb.entries = lb.entries[:0]
 }
 
 // BaseMiner provides a foundation for specific miner implementations.
 type BaseMiner struct {
-	Name                  string `json:"name"`
-	MinerType             string `json:"miner_type"` // Type identifier (e.g., "xmrig", "tt-miner")
-	Version               string `json:"version"`
-	URL                   string `json:"url"`
-	Path                  string `json:"path"`
-	MinerBinary           string `json:"miner_binary"`
-	ExecutableName        string `json:"-"`
-	Running               bool   `json:"running"`
-	ConfigPath            string `json:"configPath"`
-	API                   *API   `json:"api"`
+	Name                  string     `json:"name"`
+	MinerType             string     `json:"miner_type"` // Type identifier (e.g., "xmrig", "tt-miner")
+	Version               string     `json:"version"`
+	URL                   string     `json:"url"`
+	Path                  string     `json:"path"`
+	MinerBinary           string     `json:"miner_binary"`
+	ExecutableName        string     `json:"-"`
+	Running               bool       `json:"running"`
+	State                 MinerState `json:"state,omitempty"` // Set by the manager, see MinerState
+	ConfigPath            string     `json:"configPath"`
+	API                   *API       `json:"api"`
 	mu                    sync.RWMutex
 	cmd                   *exec.Cmd
 	stdinPipe             io.WriteCloser  `json:"-"`
@@ -108,6 +225,70 @@ type BaseMiner struct {
 	LowResHashrateHistory []HashratePoint `json:"lowResHashrateHistory"`
 	LastLowResAggregation time.Time       `json:"-"`
 	LogBuffer             *LogBuffer      `json:"-"`
+	LogFilePath           string          `json:"logFilePath,omitempty"` // Set when output is persisted to a log file
+	appliedAffinity       []int           // CPUs the process was pinned to, nil if not pinned
+	stopTimeout           time.Duration   // Grace period Stop gives the running process after SIGTERM
+	stopping              *os.Process     // Process a Stop in progress is waiting on, see Kill
+	launch                *LaunchCommand  // How the running process was executed, see LaunchCommand
+	lastExit              *ProcessExit    // How the process last exited without being stopped
+}
+
+// startProcess starts b.cmd, then applies the config's resource limits, OS
+// process priority and CPU affinity. A process whose limits can't be applied
+// is killed, since the limits protect the host; failing to apply priority or
+// affinity is only logged. Caller must hold b.mu.
+func (b *BaseMiner) startProcess(config *Config) error {
+	b.stopTimeout = config.StopTimeoutDuration()
+	if config.ProcessPriority != nil {
+		prepareProcessPriority(b.cmd, *config.ProcessPriority)
+	}
+	if err := b.cmd.Start(); err != nil {
+		return err
+	}
+	b.launch = newLaunchCommand(b.cmd, config)
+
+	if err := applyResourceLimits(b.cmd, config); err != nil {
+		b.cmd.Process.Kill()
+		b.cmd.Wait()
+		return fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+
+	if config.ProcessPriority != nil {
+		if err := applyProcessPriority(b.cmd, *config.ProcessPriority); err != nil {
+			logging.Warn("failed to set miner process priority", logging.Fields{"miner": b.Name, "priority": *config.ProcessPriority, "error": err})
+		}
+	}
+	b.appliedAffinity = nil
+	if config.CPUAffinity != "" {
+		cpus, err := ParseCPUAffinity(config.CPUAffinity)
+		if err == nil {
+			err = applyCPUAffinity(b.cmd, cpus)
+		}
+		if err != nil {
+			logging.Warn("failed to set miner CPU affinity", logging.Fields{"miner": b.Name, "affinity": config.CPUAffinity, "error": err})
+		} else {
+			b.appliedAffinity = cpus
+		}
+	}
+	return nil
+}
+
+// AppliedCPUAffinity returns the CPUs the running process was pinned to, or
+// nil if no affinity was applied.
+func (b *BaseMiner) AppliedCPUAffinity() []int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]int(nil), b.appliedAffinity...)
+}
+
+// ResourceLimits returns the OS resource limits of the running process.
+func (b *BaseMiner) ResourceLimits() (ResourceLimits, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if !b.Running || b.cmd == nil || b.cmd.Process == nil {
+		return ResourceLimits{}, ErrProcessNotRunning
+	}
+	return readResourceLimits(b.cmd.Process.Pid)
 }
 
 // GetType returns the miner type identifier.
@@ -125,15 +306,39 @@ func (b *BaseMiner) GetName() string {
 // GetPath returns the base installation directory for the miner type.
 // It uses the stable ExecutableName field to ensure the correct path.
 func (b *BaseMiner) GetPath() string {
-	dataPath, err := xdg.DataFile(fmt.Sprintf("lethean-desktop/miners/%s", b.ExecutableName))
+	installRoot := GetPathsConfig().InstallRoot
+	if installRoot == "" {
+		return ""
+	}
+	return filepath.Join(installRoot, b.ExecutableName)
+}
+
+// openLogFile attaches a rotating log file to the log buffer when log files
+// are enabled. Failures are logged and mining continues without a file.
+// Caller must hold b.mu.
+func (b *BaseMiner) openLogFile() {
+	cfg := GetLogFileConfig()
+	if !cfg.Enabled || b.LogBuffer == nil {
+		return
+	}
+
+	// Instance names may contain '/', which must not create subdirectories
+	fileName := strings.ReplaceAll(instanceNameRegex.ReplaceAllString(b.Name, "_"), "/", "_") + ".log"
+	path := filepath.Join(cfg.Dir, fileName)
+	rf, err := NewRotatingFile(path, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxFiles)
 	if err != nil {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return ""
-		}
-		return filepath.Join(home, ".lethean-desktop", "miners", b.ExecutableName)
+		logging.Warn("failed to open miner log file", logging.Fields{"miner": b.Name, "path": path, "error": err})
+		return
+	}
+	b.LogBuffer.SetFile(rf)
+	b.LogFilePath = path
+}
+
+// closeLogFile closes the miner's log file, if one is attached.
+func (b *BaseMiner) closeLogFile() {
+	if b.LogBuffer != nil {
+		b.LogBuffer.CloseFile()
 	}
-	return dataPath
 }
 
 // GetBinaryPath returns the full path to the miner's executable file.
@@ -143,14 +348,46 @@ func (b *BaseMiner) GetBinaryPath() string {
 	return b.MinerBinary
 }
 
+// DefaultStopTimeout is how long Stop waits for a miner to exit after
+// SIGTERM when its config doesn't set StopTimeout.
+const DefaultStopTimeout = 3 * time.Second
+
+// Bounds of Config.StopTimeout, in seconds
+const (
+	minStopTimeout = 1
+	maxStopTimeout = 30
+)
+
+// StopTimeoutDuration returns the grace period a miner started with this
+// config gets to exit after SIGTERM.
+func (c *Config) StopTimeoutDuration() time.Duration {
+	if c.StopTimeout <= 0 {
+		return DefaultStopTimeout
+	}
+	return time.Duration(c.StopTimeout) * time.Second
+}
+
+// StopTimeout returns the grace period Stop gives the running process.
+func (b *BaseMiner) StopTimeout() time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.stopTimeout == 0 {
+		return DefaultStopTimeout
+	}
+	return b.stopTimeout
+}
+
 // Stop terminates the miner process gracefully.
-// It first tries SIGTERM to allow cleanup, then SIGKILL if needed.
+// It first tries SIGTERM to allow cleanup, then SIGKILL once the stop
+// timeout (see Config.StopTimeout) has passed.
 func (b *BaseMiner) Stop() error {
+	timeout := b.StopTimeout()
+
 	b.mu.Lock()
 
 	if !b.Running || b.cmd == nil {
 		b.mu.Unlock()
-		return errors.New("miner is not running")
+		return ErrProcessNotRunning
 	}
 
 	// Close stdin pipe if open
@@ -166,12 +403,18 @@ func (b *BaseMiner) Stop() error {
 	// Mark as not running immediately to prevent concurrent Stop() calls
 	b.Running = false
 	b.cmd = nil
+	b.stopping = process
 	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.stopping = nil
+		b.mu.Unlock()
+	}()
 
 	// Try graceful shutdown with SIGTERM first (Unix only)
 	if runtime.GOOS != "windows" {
 		if err := process.Signal(syscall.SIGTERM); err == nil {
-			// Wait up to 3 seconds for graceful shutdown
+			// Wait up to the stop timeout for graceful shutdown
 			done := make(chan struct{})
 			go func() {
 				process.Wait()
@@ -181,7 +424,7 @@ func (b *BaseMiner) Stop() error {
 			select {
 			case <-done:
 				return nil
-			case <-time.After(3 * time.Second):
+			case <-time.After(timeout):
 				// Process didn't exit gracefully, force kill below
 			}
 		}
@@ -197,6 +440,25 @@ func (b *BaseMiner) Stop() error {
 	return nil
 }
 
+// Kill force-kills the miner process without waiting for a clean exit,
+// including one a Stop in progress is still giving its stop timeout.
+func (b *BaseMiner) Kill() error {
+	b.mu.Lock()
+	process := b.stopping
+	if b.Running && b.cmd != nil {
+		process = b.cmd.Process
+	}
+	b.mu.Unlock()
+
+	if process == nil {
+		return ErrProcessNotRunning
+	}
+	if err := process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
 // stdinWriteTimeout is the maximum time to wait for stdin write to complete.
 const stdinWriteTimeout = 5 * time.Second
 
@@ -244,39 +506,53 @@ func (b *BaseMiner) Uninstall() error {
 
 // InstallFromURL handles the generic download and extraction process for a miner.
 func (b *BaseMiner) InstallFromURL(url string) error {
-	tmpfile, err := os.CreateTemp("", b.ExecutableName+"-")
+	paths := GetPathsConfig()
+	baseInstallPath := b.GetPath()
+
+	// Stage the download in the configured directory so large archives
+	// don't have to fit in a small system /tmp. A partial download is kept
+	// there and resumed if the install is retried.
+	if err := os.MkdirAll(paths.StagingDir, 0755); err != nil {
+		return err
+	}
+	part := downloadPartPath(paths.StagingDir, b.ExecutableName, url)
+	unlock, err := lockPartial(part)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(tmpfile.Name())
-	defer tmpfile.Close()
+	defer unlock()
 
-	resp, err := getHTTPClient().Get(url)
-	if err != nil {
+	// Refuse early if the download or the extracted miner won't fit
+	checkSpace := func(total, remaining int64) error {
+		if err := checkDiskSpace(b.ExecutableName, paths.StagingDir, uint64(remaining)); err != nil {
+			return err
+		}
+		return checkDiskSpace(b.ExecutableName, baseInstallPath, uint64(total)*installExpansionFactor)
+	}
+	if err := downloadResumable(context.Background(), url, part, checkSpace); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	// The archive is complete; whether extraction works or not it isn't needed again
+	defer removePartial(part)
 
-	if resp.StatusCode != http.StatusOK {
-		_, _ = io.Copy(io.Discard, resp.Body) // Drain body to allow connection reuse (error ignored intentionally)
-		return fmt.Errorf("failed to download release: unexpected status code %d", resp.StatusCode)
+	info, err := os.Stat(part)
+	if err != nil {
+		return err
 	}
 
-	if _, err := io.Copy(tmpfile, resp.Body); err != nil {
-		// Drain remaining body to allow connection reuse (error ignored intentionally)
-		_, _ = io.Copy(io.Discard, resp.Body)
+	// Recheck with the actual size now the archive itself is taking up space
+	if err := checkDiskSpace(b.ExecutableName, baseInstallPath, uint64(info.Size())*installExpansionFactor); err != nil {
 		return err
 	}
 
-	baseInstallPath := b.GetPath()
 	if err := os.MkdirAll(baseInstallPath, 0755); err != nil {
 		return err
 	}
 
 	if strings.HasSuffix(url, ".zip") {
-		err = b.unzip(tmpfile.Name(), baseInstallPath)
+		err = b.unzip(part, baseInstallPath)
 	} else {
-		err = b.untar(tmpfile.Name(), baseInstallPath)
+		err = b.untar(part, baseInstallPath)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to extract miner: %w", err)
@@ -458,6 +734,38 @@ func (b *BaseMiner) GetLogs() []string {
 	return logBuffer.GetLines()
 }
 
+// attachOutput captures the process's stdout and stderr in the log buffer,
+// tagged with their stream, and echoes them to the console if logOutput is set.
+// Caller must hold b.mu.
+func (b *BaseMiner) attachOutput(logOutput bool) {
+	var stdout, stderr []io.Writer
+	if b.LogBuffer != nil {
+		stdout = append(stdout, b.LogBuffer.Stream(LogStreamStdout))
+		stderr = append(stderr, b.LogBuffer.Stream(LogStreamStderr))
+	}
+	if logOutput {
+		stdout = append(stdout, os.Stdout)
+		stderr = append(stderr, os.Stderr)
+	}
+	if len(stdout) > 0 {
+		b.cmd.Stdout = io.MultiWriter(stdout...)
+		b.cmd.Stderr = io.MultiWriter(stderr...)
+	}
+}
+
+// GetLogEntries returns the captured output with raw timestamps, for clients
+// that want to format or correlate times themselves.
+func (b *BaseMiner) GetLogEntries() []LogEntry {
+	b.mu.RLock()
+	logBuffer := b.LogBuffer
+	b.mu.RUnlock()
+
+	if logBuffer == nil {
+		return []LogEntry{}
+	}
+	return logBuffer.GetEntries()
+}
+
 // ReduceHashrateHistory aggregates and trims hashrate data.
 func (b *BaseMiner) ReduceHashrateHistory(now time.Time) {
 	b.mu.Lock()
@@ -0,0 +1,62 @@
+package mining
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MinerCommand is a high-level console command that maps to a miner-specific
+// stdin control sequence.
+type MinerCommand string
+
+const (
+	CommandHashrate   MinerCommand = "hashrate"
+	CommandPause      MinerCommand = "pause"
+	CommandResume     MinerCommand = "resume"
+	CommandConnection MinerCommand = "connection"
+	CommandResults    MinerCommand = "results"
+)
+
+// maxStdinInputLength bounds raw input sent to a miner's stdin.
+const maxStdinInputLength = 256
+
+// xmrigCommands are XMRig's single-key console controls.
+var xmrigCommands = map[MinerCommand]string{
+	CommandHashrate:   "h",
+	CommandPause:      "p",
+	CommandResume:     "r",
+	CommandConnection: "c",
+	CommandResults:    "s",
+}
+
+// minerCommandVocabulary maps each miner type to the commands it understands.
+// Miner types without an entry don't support typed commands.
+var minerCommandVocabulary = map[string]map[MinerCommand]string{
+	MinerTypeXMRig:     xmrigCommands,
+	MinerTypeSimulated: xmrigCommands, // Simulated miners mimic XMRig's console
+}
+
+// SupportedMinerCommands returns the typed commands a miner type accepts, sorted by name.
+func SupportedMinerCommands(minerType string) []MinerCommand {
+	vocabulary := minerCommandVocabulary[strings.ToLower(minerType)]
+	commands := make([]MinerCommand, 0, len(vocabulary))
+	for cmd := range vocabulary {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i] < commands[j] })
+	return commands
+}
+
+// ResolveMinerCommand returns the stdin input for a typed command on a miner type.
+func ResolveMinerCommand(minerType string, command MinerCommand) (string, error) {
+	vocabulary, ok := minerCommandVocabulary[strings.ToLower(minerType)]
+	if !ok {
+		return "", fmt.Errorf("miner type %s does not support console commands", minerType)
+	}
+	input, ok := vocabulary[command]
+	if !ok {
+		return "", fmt.Errorf("unknown command %q for %s", command, minerType)
+	}
+	return input, nil
+}
@@ -0,0 +1,94 @@
+package mining
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveMinerCommand(t *testing.T) {
+	input, err := ResolveMinerCommand(MinerTypeXMRig, CommandPause)
+	if err != nil || input != "p" {
+		t.Errorf("expected xmrig pause to map to 'p', got %q (%v)", input, err)
+	}
+
+	if _, err := ResolveMinerCommand(MinerTypeXMRig, "reboot"); err == nil {
+		t.Error("expected error for unknown command")
+	}
+	if _, err := ResolveMinerCommand(MinerTypeTTMiner, CommandPause); err == nil {
+		t.Error("expected error for miner without a command vocabulary")
+	}
+
+	if got := SupportedMinerCommands(MinerTypeXMRig); len(got) != 5 || got[0] != CommandConnection {
+		t.Errorf("unexpected xmrig commands: %v", got)
+	}
+	if got := SupportedMinerCommands(MinerTypeTTMiner); len(got) != 0 {
+		t.Errorf("expected no commands for tt-miner, got %v", got)
+	}
+}
+
+func TestHandleMinerCommand(t *testing.T) {
+	router, mockManager := setupTestRouter()
+
+	var sent string
+	mockManager.GetMinerFunc = func(minerName string) (Miner, error) {
+		return &MockMiner{
+			GetTypeFunc:    func() string { return MinerTypeXMRig },
+			WriteStdinFunc: func(input string) error { sent = input; return nil },
+			GetStatsFunc: func(ctx context.Context) (*PerformanceMetrics, error) {
+				return &PerformanceMetrics{Hashrate: 1234}, nil
+			},
+		}, nil
+	}
+
+	req, _ := http.NewRequest("POST", "/miners/xmrig-rx_0/command", strings.NewReader(`{"command":"hashrate"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if sent != "h" {
+		t.Errorf("expected 'h' on stdin, got %q", sent)
+	}
+	var resp MinerCommandResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Stats == nil || resp.Stats.Hashrate != 1234 {
+		t.Errorf("expected refreshed stats, got %+v", resp)
+	}
+
+	// Unknown commands never reach stdin
+	sent = ""
+	req, _ = http.NewRequest("POST", "/miners/xmrig-rx_0/command", strings.NewReader(`{"command":"rm -rf"}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || sent != "" {
+		t.Errorf("expected 400 without writing stdin, got %d (sent %q)", w.Code, sent)
+	}
+}
+
+func TestHandleMinerStdinTooLong(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	mockManager.GetMinerFunc = func(minerName string) (Miner, error) {
+		return &MockMiner{
+			WriteStdinFunc: func(input string) error {
+				t.Error("oversized input should not be written")
+				return nil
+			},
+		}, nil
+	}
+
+	body, _ := json.Marshal(StdinInput{Input: strings.Repeat("x", maxStdinInputLength+1)})
+	req, _ := http.NewRequest("POST", "/miners/xmrig-rx_0/stdin", strings.NewReader(string(body)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
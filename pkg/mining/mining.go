@@ -2,6 +2,7 @@ package mining
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -66,6 +67,7 @@ type InstallationDetails struct {
 	Path        string `json:"path"`
 	MinerBinary string `json:"miner_binary"`
 	ConfigPath  string `json:"config_path,omitempty"` // Add path to the miner-specific config
+	Integrity   string `json:"integrity,omitempty"`   // Binary checksum status: verified, mismatch or unrecorded
 }
 
 // SystemInfo provides general system and miner installation information.
@@ -77,6 +79,10 @@ type SystemInfo struct {
 	AvailableCPUCores   int                    `json:"available_cpu_cores"`
 	TotalSystemRAMGB    float64                `json:"total_system_ram_gb"`
 	InstalledMinersInfo []*InstallationDetails `json:"installed_miners_info"`
+	Paths               PathsConfig            `json:"paths"`
+	Privileges          PrivilegeInfo          `json:"privileges"`
+	MCP                 *MCPStatus             `json:"mcp,omitempty"` // Reported by /info, not cached
+	SetupComplete       bool                   `json:"setupComplete"` // Whether API auth is enabled, by setup or the environment; reported by /info, not cached
 }
 
 // Config represents the configuration for a miner.
@@ -95,6 +101,9 @@ type Config struct {
 	Keepalive         bool   `json:"keepalive,omitempty"`
 	Nicehash          bool   `json:"nicehash,omitempty"`
 	RigID             string `json:"rigId,omitempty"`
+	WorkerName        string `json:"workerName,omitempty"`   // Worker name added to the wallet or rig ID per WorkerFormat; may use %name%, %n% and %host%
+	WorkerFormat      string `json:"workerFormat,omitempty"` // How the pool takes the worker name: "dot" (default) or "rigid"
+	FixedDiff         int    `json:"fixedDiff,omitempty"`    // Fixed share difficulty appended to the wallet as +DIFF
 	TLSSingerprint    string `json:"tlsFingerprint,omitempty"`
 	Retries           int    `json:"retries,omitempty"`
 	RetryPause        int    `json:"retryPause,omitempty"`
@@ -102,9 +111,15 @@ type Config struct {
 	DonateLevel       int    `json:"donateLevel,omitempty"`
 	DonateOverProxy   bool   `json:"donateOverProxy,omitempty"`
 	NoCPU             bool   `json:"noCpu,omitempty"`
-	CPUAffinity       string `json:"cpuAffinity,omitempty"`
+	CPUAffinity       string `json:"cpuAffinity,omitempty"` // CPUs to pin the miner process to: "0-3,6" or a hex mask "0x4F"
 	AV                int    `json:"av,omitempty"`
 	CPUPriority       int    `json:"cpuPriority,omitempty"`
+	ProcessPriority   *int   `json:"processPriority,omitempty"`  // OS niceness of the miner process, -20 (highest) to 19 (lowest); nil uses the manager default
+	MaxMemoryMB       int    `json:"maxMemoryMB,omitempty"`      // Address space limit for the miner process (Linux), applied just after it starts
+	MaxOpenFiles      int    `json:"maxOpenFiles,omitempty"`     // Open file descriptor limit for the miner process (Linux), applied just after it starts
+	StopTimeout       int    `json:"stopTimeout,omitempty"`      // Seconds to wait for a clean exit after SIGTERM before killing, 1-30; 0 uses DefaultStopTimeout
+	ShutdownPriority  int    `json:"shutdownPriority,omitempty"` // Order miners are stopped in on shutdown, lowest first by default; equal priorities stop together
+	RestartOnCrash    bool   `json:"restartOnCrash,omitempty"`   // Restart the miner when its process exits without being stopped
 	CPUMaxThreadsHint int    `json:"cpuMaxThreadsHint,omitempty"`
 	CPUMemoryPool     int    `json:"cpuMemoryPool,omitempty"`
 	CPUNoYield        bool   `json:"cpuNoYield,omitempty"`
@@ -159,68 +174,122 @@ type Config struct {
 	CLIArgs      string `json:"cliArgs,omitempty"`      // Additional CLI arguments
 }
 
+// maskedSecret replaces secret values in configs returned by the API
+const maskedSecret = "********"
+
+// Masked returns a copy of the config with passwords and access tokens replaced,
+// suitable for returning from the API or writing to logs.
+func (c Config) Masked() Config {
+	for _, field := range c.secretFields() {
+		if *field != "" {
+			*field = maskedSecret
+		}
+	}
+	return c
+}
+
+// secretFields returns the fields Masked replaces, in secretConfigKeys order.
+func (c *Config) secretFields() []*string {
+	return []*string{&c.Password, &c.UserPass, &c.GPUPassword, &c.HTTPAccessToken}
+}
+
 // Validate checks the Config for common errors and security issues.
 // Returns nil if valid, otherwise returns a descriptive error.
 func (c *Config) Validate() error {
+	if issues := c.validationIssues(); len(issues) > 0 {
+		return errors.New(issues[0].Message)
+	}
+	return nil
+}
+
+// validationIssues returns every field that fails validation, in field order.
+func (c *Config) validationIssues() []ConfigIssue {
+	var issues []ConfigIssue
+	add := func(field, message string) {
+		issues = append(issues, ConfigIssue{Field: field, Message: message})
+	}
+
 	// Pool URL validation
 	if c.Pool != "" {
 		// Block shell metacharacters in pool URL
 		if containsShellChars(c.Pool) {
-			return fmt.Errorf("pool URL contains invalid characters")
+			add("pool", "pool URL contains invalid characters")
 		}
 	}
 
 	// Wallet validation (basic alphanumeric + special chars allowed in addresses)
 	if c.Wallet != "" {
 		if containsShellChars(c.Wallet) {
-			return fmt.Errorf("wallet address contains invalid characters")
+			add("wallet", "wallet address contains invalid characters")
 		}
 		// Most wallet addresses are 40-128 chars
 		if len(c.Wallet) > 256 {
-			return fmt.Errorf("wallet address too long (max 256 chars)")
+			add("wallet", "wallet address too long (max 256 chars)")
 		}
 	}
+	c.workerTemplateIssues(add)
 
 	// Thread count validation
 	if c.Threads < 0 {
-		return fmt.Errorf("threads cannot be negative")
+		add("threads", "threads cannot be negative")
 	}
 	if c.Threads > 1024 {
-		return fmt.Errorf("threads value too high (max 1024)")
+		add("threads", "threads value too high (max 1024)")
 	}
 
 	// Algorithm validation (alphanumeric, dash, slash)
 	if c.Algo != "" {
 		if !isValidAlgo(c.Algo) {
-			return fmt.Errorf("algorithm name contains invalid characters")
+			add("algo", "algorithm name contains invalid characters")
 		}
 	}
 
 	// Intensity validation
 	if c.Intensity < 0 || c.Intensity > 100 {
-		return fmt.Errorf("intensity must be between 0 and 100")
+		add("intensity", "intensity must be between 0 and 100")
 	}
 	if c.GPUIntensity < 0 || c.GPUIntensity > 100 {
-		return fmt.Errorf("GPU intensity must be between 0 and 100")
+		add("gpuIntensity", "GPU intensity must be between 0 and 100")
 	}
 
 	// Donate level validation
 	if c.DonateLevel < 0 || c.DonateLevel > 100 {
-		return fmt.Errorf("donate level must be between 0 and 100")
+		add("donateLevel", "donate level must be between 0 and 100")
+	}
+
+	// CPU affinity must name CPUs that exist here
+	if c.CPUAffinity != "" {
+		if _, err := ParseCPUAffinity(c.CPUAffinity); err != nil {
+			add("cpuAffinity", err.Error())
+		}
+	}
+
+	// Process priority range depends on the platform
+	if c.ProcessPriority != nil {
+		if err := validateProcessPriority(*c.ProcessPriority); err != nil {
+			add("processPriority", err.Error())
+		}
+	}
+
+	// Resource limits must be sane and supported here
+	c.resourceLimitIssues(add)
+
+	if c.StopTimeout != 0 && (c.StopTimeout < minStopTimeout || c.StopTimeout > maxStopTimeout) {
+		add("stopTimeout", fmt.Sprintf("stop timeout must be between %d and %d seconds", minStopTimeout, maxStopTimeout))
 	}
 
 	// CLIArgs validation - check for shell metacharacters
 	if c.CLIArgs != "" {
 		if containsShellChars(c.CLIArgs) {
-			return fmt.Errorf("CLI arguments contain invalid characters")
+			add("cliArgs", "CLI arguments contain invalid characters")
 		}
 		// Limit length to prevent abuse
 		if len(c.CLIArgs) > 1024 {
-			return fmt.Errorf("CLI arguments too long (max 1024 chars)")
+			add("cliArgs", "CLI arguments too long (max 1024 chars)")
 		}
 	}
 
-	return nil
+	return issues
 }
 
 // containsShellChars checks for shell metacharacters that could enable injection
@@ -246,15 +315,21 @@ func isValidAlgo(algo string) bool {
 
 // PerformanceMetrics represents the performance metrics for a miner.
 type PerformanceMetrics struct {
-	Hashrate      int                    `json:"hashrate"`
-	Shares        int                    `json:"shares"`
-	Rejected      int                    `json:"rejected"`
-	Uptime        int                    `json:"uptime"`
-	LastShare     int64                  `json:"lastShare"`
-	Algorithm     string                 `json:"algorithm"`
-	AvgDifficulty int                    `json:"avgDifficulty"` // Average difficulty per accepted share (HashesTotal/SharesGood)
-	DiffCurrent   int                    `json:"diffCurrent"`   // Current job difficulty from pool
-	ExtraData     map[string]interface{} `json:"extraData,omitempty"`
+	Hashrate           int                    `json:"hashrate"`
+	Shares             int                    `json:"shares"`
+	Rejected           int                    `json:"rejected"`
+	Uptime             int                    `json:"uptime"`
+	LastShare          int64                  `json:"lastShare"`
+	Algorithm          string                 `json:"algorithm"`
+	AvgDifficulty      int                    `json:"avgDifficulty"`    // Average difficulty per accepted share (HashesTotal/SharesGood)
+	DiffCurrent        int                    `json:"diffCurrent"`      // Current job difficulty from pool
+	HugePagesEnabled   bool                   `json:"hugePagesEnabled"` // True when the miner reports any huge pages allocated
+	HugePagesAllocated int                    `json:"hugePagesAllocated,omitempty"`
+	HugePagesTotal     int                    `json:"hugePagesTotal,omitempty"`
+	RestartCount       int                    `json:"restartCount"` // Crash restarts since the user started the miner, see MinerHistory
+	LastCrashAt        *time.Time             `json:"lastCrashAt,omitempty"`
+	LastCrashReason    string                 `json:"lastCrashReason,omitempty"`
+	ExtraData          map[string]interface{} `json:"extraData,omitempty"`
 }
 
 // HashratePoint represents a single hashrate measurement at a specific time.
@@ -350,6 +425,7 @@ type XMRigSummary struct {
 
 // AvailableMiner represents a miner that is available for use.
 type AvailableMiner struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Algorithms  []string `json:"algorithms,omitempty"` // Algorithms the algos registry lists for the miner
 }
@@ -1,6 +1,7 @@
 package mining
 
 import (
+	"encoding/json"
 	"errors"
 )
 
@@ -12,10 +13,11 @@ type RawConfig []byte
 // MiningProfile represents a saved configuration for running a specific miner.
 // It decouples the UI from the underlying miner's specific config structure.
 type MiningProfile struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	MinerType string    `json:"minerType"`                   // e.g., "xmrig", "ttminer"
-	Config    RawConfig `json:"config" swaggertype:"object"` // The raw JSON config for the specific miner
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	MinerType  string    `json:"minerType"`                   // e.g., "xmrig", "ttminer"
+	TemplateID string    `json:"templateId,omitempty"`        // MiningTemplate whose tuning Config is merged over
+	Config     RawConfig `json:"config" swaggertype:"object"` // The raw JSON config for the specific miner
 }
 
 // MarshalJSON returns m as the JSON encoding of m.
@@ -34,3 +36,29 @@ func (m *RawConfig) UnmarshalJSON(data []byte) error {
 	*m = append((*m)[0:0], data...)
 	return nil
 }
+
+// secretConfigKeys are the Config JSON fields replaced by Config.Masked.
+var secretConfigKeys = []string{"password", "userPass", "gpuPassword", "httpAccessToken"}
+
+// Masked returns a copy of the profile with secrets in its config replaced,
+// leaving any other fields of the raw config untouched.
+func (p *MiningProfile) Masked() *MiningProfile {
+	masked := *p
+	var fields map[string]interface{}
+	if err := json.Unmarshal(p.Config, &fields); err != nil {
+		masked.Config = nil // Unparseable config could hide anything; don't expose it
+		return &masked
+	}
+	for _, key := range secretConfigKeys {
+		if value, ok := fields[key].(string); ok && value != "" {
+			fields[key] = maskedSecret
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		masked.Config = nil
+		return &masked
+	}
+	masked.Config = data
+	return &masked
+}
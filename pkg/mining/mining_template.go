@@ -0,0 +1,300 @@
+package mining
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const templateConfigFileName = "mining_templates.json"
+
+// Where a field of an effective config came from.
+const (
+	ConfigSourceTemplate = "template"
+	ConfigSourceProfile  = "profile"
+)
+
+// poolConfigKeys are the Config JSON fields that say where and for whom to
+// mine. Templates hold hardware tuning only, so they can't set these.
+var poolConfigKeys = []string{
+	"miner", "pool", "wallet", "tls", "algo", "coin", "password", "userPass", "proxy",
+	"keepalive", "nicehash", "rigId", "workerName", "workerFormat", "fixedDiff", "tlsFingerprint",
+	"gpuPool", "gpuWallet", "gpuAlgo", "gpuPassword",
+}
+
+// MiningTemplate is reusable hardware tuning (threads, affinity, huge pages,
+// GPU intensity...) without a pool or wallet. Profiles reference a template
+// by TemplateID and add their own pool and wallet, so one tuning can be
+// applied to many pools.
+type MiningTemplate struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	MinerType   string    `json:"minerType,omitempty"`         // Restricts the template to one miner type; empty for any
+	Config      RawConfig `json:"config" swaggertype:"object"` // Tuning fields of Config
+}
+
+// EffectiveConfig is the config a profile starts with: its template's config
+// with the profile's fields merged over it, the profile winning.
+type EffectiveConfig struct {
+	ProfileID  string            `json:"profileId"`
+	TemplateID string            `json:"templateId,omitempty"`
+	Config     RawConfig         `json:"config" swaggertype:"object"`
+	Sources    map[string]string `json:"sources"` // Field -> ConfigSourceTemplate or ConfigSourceProfile
+}
+
+// Validate checks the template has a name and a tuning-only config object.
+func (t *MiningTemplate) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	fields, err := configFields(t.Config)
+	if err != nil {
+		return err
+	}
+	var pool []string
+	for _, key := range poolConfigKeys {
+		if _, ok := fields[key]; ok {
+			pool = append(pool, key)
+		}
+	}
+	if len(pool) > 0 {
+		return fmt.Errorf("templates hold tuning only; move %s to the profile", strings.Join(pool, ", "))
+	}
+	return nil
+}
+
+// configFields decodes a config object's top-level fields; empty is an empty object.
+func configFields(config RawConfig) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(config) == 0 || string(config) == "null" {
+		return fields, nil
+	}
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return nil, fmt.Errorf("config must be a JSON object: %w", err)
+	}
+	return fields, nil
+}
+
+// mergeConfigs returns base with every top-level field of overlay replacing
+// base's, and which of the two each resulting field came from.
+func mergeConfigs(base, overlay RawConfig) (RawConfig, map[string]string, error) {
+	merged, err := configFields(base)
+	if err != nil {
+		return nil, nil, fmt.Errorf("template %w", err)
+	}
+	fields, err := configFields(overlay)
+	if err != nil {
+		return nil, nil, fmt.Errorf("profile %w", err)
+	}
+
+	sources := make(map[string]string, len(merged)+len(fields))
+	for key := range merged {
+		sources[key] = ConfigSourceTemplate
+	}
+	for key, value := range fields {
+		merged[key] = value
+		sources[key] = ConfigSourceProfile
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, sources, nil
+}
+
+// templatesPath is where templates are saved, next to the profiles.
+func (pm *ProfileManager) templatesPath() string {
+	return filepath.Join(filepath.Dir(pm.configPath), templateConfigFileName)
+}
+
+// loadTemplates reads the templates file into memory.
+func (pm *ProfileManager) loadTemplates() error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	data, err := os.ReadFile(pm.templatesPath())
+	if err != nil {
+		return err
+	}
+	var templates []*MiningTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return err
+	}
+	pm.templates = make(map[string]*MiningTemplate, len(templates))
+	for _, t := range templates {
+		pm.templates[t.ID] = t
+	}
+	return nil
+}
+
+// saveTemplates writes the templates to disk. Caller must hold pm.mu.
+func (pm *ProfileManager) saveTemplates() error {
+	templates := make([]*MiningTemplate, 0, len(pm.templates))
+	for _, t := range pm.templates {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return AtomicWriteFile(pm.templatesPath(), data, 0600)
+}
+
+// CreateTemplate validates and saves a new template, assigning its ID.
+func (pm *ProfileManager) CreateTemplate(template *MiningTemplate) (*MiningTemplate, error) {
+	if err := template.Validate(); err != nil {
+		return nil, ErrInvalidConfig(err.Error())
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.templates == nil {
+		pm.templates = make(map[string]*MiningTemplate)
+	}
+	template.ID = uuid.New().String()
+	pm.templates[template.ID] = template
+	if err := pm.saveTemplates(); err != nil {
+		delete(pm.templates, template.ID)
+		return nil, ErrInternal("failed to save template").WithCause(err)
+	}
+	return template, nil
+}
+
+// GetTemplate retrieves a template by its ID.
+func (pm *ProfileManager) GetTemplate(id string) (*MiningTemplate, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	template, exists := pm.templates[id]
+	return template, exists
+}
+
+// GetAllTemplates returns every template, sorted by name.
+func (pm *ProfileManager) GetAllTemplates() []*MiningTemplate {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	templates := make([]*MiningTemplate, 0, len(pm.templates))
+	for _, t := range pm.templates {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+// UpdateTemplate replaces an existing template. Profiles using it pick up the
+// change the next time they start.
+func (pm *ProfileManager) UpdateTemplate(template *MiningTemplate) error {
+	if err := template.Validate(); err != nil {
+		return ErrInvalidConfig(err.Error())
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	old, exists := pm.templates[template.ID]
+	if !exists {
+		return ErrTemplateNotFound(template.ID)
+	}
+	pm.templates[template.ID] = template
+	if err := pm.saveTemplates(); err != nil {
+		pm.templates[template.ID] = old
+		return ErrInternal("failed to save template").WithCause(err)
+	}
+	return nil
+}
+
+// DeleteTemplate removes a template no profile references.
+func (pm *ProfileManager) DeleteTemplate(id string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	template, exists := pm.templates[id]
+	if !exists {
+		return ErrTemplateNotFound(id)
+	}
+	users := 0
+	for _, p := range pm.profiles {
+		if p.TemplateID == id {
+			users++
+		}
+	}
+	if users > 0 {
+		return ErrTemplateInUse(id, users)
+	}
+
+	delete(pm.templates, id)
+	if err := pm.saveTemplates(); err != nil {
+		pm.templates[id] = template
+		return ErrInternal("failed to delete template").WithCause(err)
+	}
+	return nil
+}
+
+// CheckTemplate validates a profile's template reference: the template must
+// exist and, if it is for one miner type, match the profile's.
+func (pm *ProfileManager) CheckTemplate(profile *MiningProfile) error {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.checkTemplateLocked(profile)
+}
+
+// checkTemplateLocked is CheckTemplate for callers holding pm.mu.
+func (pm *ProfileManager) checkTemplateLocked(profile *MiningProfile) error {
+	if profile.TemplateID == "" {
+		return nil
+	}
+	template, exists := pm.templates[profile.TemplateID]
+	if !exists {
+		return ErrTemplateNotFound(profile.TemplateID)
+	}
+	if template.MinerType != "" && template.MinerType != profile.MinerType {
+		return ErrInvalidConfig(fmt.Sprintf("template '%s' is for %s, not %s", template.Name, template.MinerType, profile.MinerType))
+	}
+	return nil
+}
+
+// EffectiveConfig merges the profile's config over its template's.
+func (pm *ProfileManager) EffectiveConfig(profile *MiningProfile) (*EffectiveConfig, error) {
+	effective := &EffectiveConfig{ProfileID: profile.ID, TemplateID: profile.TemplateID}
+
+	var base RawConfig
+	if profile.TemplateID != "" {
+		template, exists := pm.GetTemplate(profile.TemplateID)
+		if !exists {
+			return nil, ErrTemplateNotFound(profile.TemplateID)
+		}
+		base = template.Config
+	}
+	config, sources, err := mergeConfigs(base, profile.Config)
+	if err != nil {
+		return nil, ErrInvalidConfig(err.Error())
+	}
+	effective.Config, effective.Sources = config, sources
+	return effective, nil
+}
+
+// ResolvedProfile returns a copy of a profile with its config replaced by the
+// effective config, ready to start or deploy to a worker that doesn't have
+// the template. Profiles without a template are returned as stored.
+func (pm *ProfileManager) ResolvedProfile(id string) (*MiningProfile, error) {
+	profile, exists := pm.GetProfile(id)
+	if !exists {
+		return nil, ErrProfileNotFound(id)
+	}
+	if profile.TemplateID == "" {
+		return profile, nil
+	}
+	effective, err := pm.EffectiveConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *profile
+	resolved.Config = effective.Config
+	return &resolved, nil
+}
@@ -0,0 +1,106 @@
+package mining
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMiningTemplateValidate(t *testing.T) {
+	tuning := &MiningTemplate{Name: "8 threads", Config: RawConfig(`{"threads":8,"hugePages":true,"cpuAffinity":"0-7"}`)}
+	if err := tuning.Validate(); err != nil {
+		t.Errorf("expected tuning template to be valid: %v", err)
+	}
+	if err := (&MiningTemplate{Config: RawConfig(`{}`)}).Validate(); err == nil {
+		t.Error("expected a template without a name to be rejected")
+	}
+	withPool := &MiningTemplate{Name: "bad", Config: RawConfig(`{"threads":8,"pool":"pool:3333","wallet":"w"}`)}
+	if err := withPool.Validate(); err == nil {
+		t.Error("expected a template with pool fields to be rejected")
+	}
+	if err := (&MiningTemplate{Name: "bad", Config: RawConfig(`[1]`)}).Validate(); err == nil {
+		t.Error("expected a non-object config to be rejected")
+	}
+}
+
+func TestProfileManagerTemplates(t *testing.T) {
+	pm, cleanup := setupTestProfileManager(t)
+	defer cleanup()
+
+	template, err := pm.CreateTemplate(&MiningTemplate{
+		Name:      "Ryzen 16 threads",
+		MinerType: "xmrig",
+		Config:    RawConfig(`{"threads":16,"hugePages":true,"cpuPriority":3}`),
+	})
+	if err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+	profile, err := pm.CreateProfile(&MiningProfile{
+		Name:       "Pool A",
+		MinerType:  "xmrig",
+		TemplateID: template.ID,
+		Config:     RawConfig(`{"pool":"pool-a:3333","wallet":"wallet-a","cpuPriority":5}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.CheckTemplate(profile); err != nil {
+		t.Errorf("expected the profile's template to check out: %v", err)
+	}
+	if err := pm.CheckTemplate(&MiningProfile{MinerType: "tt-miner", TemplateID: template.ID}); err == nil {
+		t.Error("expected a template for another miner type to be rejected")
+	}
+
+	// The profile wins over the template
+	effective, err := pm.EffectiveConfig(profile)
+	if err != nil {
+		t.Fatalf("EffectiveConfig failed: %v", err)
+	}
+	var config Config
+	if err := json.Unmarshal(effective.Config, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Threads != 16 || !config.HugePages || config.CPUPriority != 5 || config.Pool != "pool-a:3333" {
+		t.Errorf("unexpected effective config %+v", config)
+	}
+	wantSources := map[string]string{
+		"threads": ConfigSourceTemplate, "hugePages": ConfigSourceTemplate,
+		"cpuPriority": ConfigSourceProfile, "pool": ConfigSourceProfile, "wallet": ConfigSourceProfile,
+	}
+	if !reflect.DeepEqual(effective.Sources, wantSources) {
+		t.Errorf("expected sources %v, got %v", wantSources, effective.Sources)
+	}
+
+	resolved, err := pm.ResolvedProfile(profile.ID)
+	if err != nil || string(resolved.Config) != string(effective.Config) {
+		t.Errorf("expected the resolved profile to carry the effective config, got %s (%v)", resolved.Config, err)
+	}
+	if stored, _ := pm.GetProfile(profile.ID); string(stored.Config) == string(resolved.Config) {
+		t.Error("resolving should not modify the stored profile")
+	}
+
+	// Templates persist next to the profiles
+	reloaded := &ProfileManager{configPath: pm.configPath}
+	if err := reloaded.loadTemplates(); err != nil {
+		t.Fatalf("failed to reload templates: %v", err)
+	}
+	if got, ok := reloaded.GetTemplate(template.ID); !ok || got.Name != template.Name {
+		t.Errorf("template not persisted, got %+v", got)
+	}
+
+	// A template in use can't be deleted
+	var miningErr *MiningError
+	if err := pm.DeleteTemplate(template.ID); !errors.As(err, &miningErr) || miningErr.Code != ErrCodeTemplateInUse {
+		t.Fatalf("expected TEMPLATE_IN_USE, got %v", err)
+	}
+	if err := pm.DeleteProfile(profile.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.DeleteTemplate(template.ID); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if _, err := pm.EffectiveConfig(profile); !errors.As(err, &miningErr) || miningErr.Code != ErrCodeTemplateNotFound {
+		t.Errorf("expected TEMPLATE_NOT_FOUND for a dangling reference, got %v", err)
+	}
+}
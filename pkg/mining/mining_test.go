@@ -50,6 +50,11 @@ func TestListAvailableMiners(t *testing.T) {
 	if len(miners) == 0 {
 		t.Error("Expected at least one available miner")
 	}
+	for _, miner := range miners {
+		if len(miner.Algorithms) == 0 {
+			t.Errorf("Expected %s to list its algorithms from the registry", miner.Name)
+		}
+	}
 }
 
 func TestGetVersion(t *testing.T) {
@@ -0,0 +1,102 @@
+package mining
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON: one JSON
+// value per line. Send it in Accept to have list and history endpoints
+// stream their items instead of returning one JSON array.
+const NDJSONContentType = "application/x-ndjson"
+
+// ndjsonFlushEvery is how many lines are written between flushes, so rows
+// reach the client as they're read without a flush per line.
+const ndjsonFlushEvery = 100
+
+// wantsNDJSON reports whether the request's Accept header asks for NDJSON.
+// It marks the response as varying by Accept either way.
+func wantsNDJSON(c *gin.Context) bool {
+	c.Writer.Header().Add("Vary", "Accept")
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case NDJSONContentType, "application/ndjson":
+			return true
+		}
+	}
+	return false
+}
+
+// ndjsonStream writes a response one JSON value per line. The response only
+// starts with the first line, so an error before it still gets a normal
+// error response; see fail.
+type ndjsonStream struct {
+	c       *gin.Context
+	enc     *json.Encoder
+	lines   int
+	started bool
+}
+
+func newNDJSONStream(c *gin.Context) *ndjsonStream {
+	return &ndjsonStream{c: c}
+}
+
+// start writes the status and headers. Set any other headers before the first write.
+func (s *ndjsonStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.c.Header("Content-Type", NDJSONContentType)
+	s.c.Status(http.StatusOK)
+	s.enc = json.NewEncoder(s.c.Writer)
+}
+
+// write sends v as the next line.
+func (s *ndjsonStream) write(v interface{}) error {
+	s.start()
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.lines++
+	if s.lines%ndjsonFlushEvery == 0 {
+		s.c.Writer.Flush()
+	}
+	return nil
+}
+
+// close ends a successful stream, sending an empty body if nothing was written.
+func (s *ndjsonStream) close() {
+	s.start()
+	s.c.Writer.Flush()
+}
+
+// fail responds with err if nothing was sent yet. Once lines are out the
+// status can't change, so a final {"error": ...} line tells the client the
+// stream is incomplete instead.
+func (s *ndjsonStream) fail(err *MiningError) {
+	if !s.started {
+		respondWithMiningError(s.c, err)
+		return
+	}
+	logging.Warn("NDJSON stream failed part way", logging.Fields{"path": s.c.Request.URL.Path, "lines": s.lines, "error": err.Error()})
+	s.write(gin.H{"error": APIError{Code: err.Code, Message: err.Message, Retryable: err.Retryable}})
+	s.c.Writer.Flush()
+}
+
+// respondNDJSON writes items as NDJSON, one per line.
+func respondNDJSON[T any](c *gin.Context, items []T) {
+	stream := newNDJSONStream(c)
+	for _, item := range items {
+		if err := stream.write(item); err != nil {
+			logging.Debug("NDJSON write failed", logging.Fields{"path": c.Request.URL.Path, "error": err})
+			return
+		}
+	}
+	stream.close()
+}
@@ -0,0 +1,109 @@
+package mining
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Snider/Mining/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// ndjsonLines splits an NDJSON body into its lines
+func ndjsonLines(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != NDJSONContentType {
+		t.Fatalf("expected %s, got %d %q: %s", NDJSONContentType, w.Code, ct, w.Body.String())
+	}
+	var lines []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestWantsNDJSON(t *testing.T) {
+	tests := map[string]bool{
+		"":                     false,
+		"application/json":     false,
+		"application/x-ndjson": true,
+		"application/json, application/ndjson;q=0.9": true,
+	}
+	for accept, want := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept", accept)
+		if got := wantsNDJSON(c); got != want {
+			t.Errorf("wantsNDJSON(%q) = %v, want %v", accept, got, want)
+		}
+	}
+}
+
+func TestHandleListMinersNDJSON(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	mockManager.ListMinersFunc = func() []Miner {
+		return []Miner{
+			&XMRigMiner{BaseMiner: BaseMiner{Name: "miner-1"}},
+			&XMRigMiner{BaseMiner: BaseMiner{Name: "miner-2"}},
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/miners", nil)
+	req.Header.Set("Accept", NDJSONContentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	lines := ndjsonLines(t, w)
+	if len(lines) != 2 || !strings.Contains(lines[0], `"name":"miner-1"`) || !strings.Contains(lines[1], `"name":"miner-2"`) {
+		t.Errorf("expected one miner per line, got %q", lines)
+	}
+}
+
+func TestHandleMinerHistoricalHashrateNDJSON(t *testing.T) {
+	if err := database.Initialize(database.Config{Enabled: true, Path: filepath.Join(t.TempDir(), "history.db")}); err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	now := time.Now()
+	for i := 0; i < ndjsonFlushEvery+5; i++ {
+		point := database.HashratePoint{Timestamp: now.Add(time.Duration(i-200) * 10 * time.Second), Hashrate: i}
+		database.InsertHashratePoint(nil, "nd-miner", "xmrig", point, database.ResolutionHigh)
+	}
+
+	m := &Manager{dbEnabled: true, dbRetention: 30, dbHighResRetention: 7}
+	router := gin.New()
+	service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/history/miners/nd-miner/hashrate"+query, nil)
+		req.Header.Set("Accept", NDJSONContentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The auto pick falls back to high resolution as there are no minute averages
+	w := get("?since=" + now.Add(-7*time.Hour).Format(time.RFC3339))
+	lines := ndjsonLines(t, w)
+	if len(lines) != ndjsonFlushEvery+5 || w.Header().Get("X-Hashrate-Resolution") != "high" {
+		t.Fatalf("expected %d high resolution points, got %d at %q", ndjsonFlushEvery+5, len(lines), w.Header().Get("X-Hashrate-Resolution"))
+	}
+	var first HashratePoint
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.Hashrate != 0 {
+		t.Errorf("expected the oldest point first, got %q", lines[0])
+	}
+
+	// No points is an empty body, not an error
+	w = get("?resolution=low")
+	if lines := ndjsonLines(t, w); len(lines) != 0 || w.Code != http.StatusOK {
+		t.Errorf("expected an empty stream, got %d %q", w.Code, lines)
+	}
+}
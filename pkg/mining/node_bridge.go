@@ -0,0 +1,260 @@
+package mining
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/Snider/Mining/pkg/node"
+)
+
+// nodeOperationTimeout bounds miner operations requested by remote controllers.
+const nodeOperationTimeout = 30 * time.Second
+
+// NodeMinerManager adapts a ManagerInterface to node.MinerManager so a worker
+// node can drive the real mining manager for remote start/stop/stats/logs.
+type NodeMinerManager struct {
+	manager ManagerInterface
+}
+
+// NewNodeMinerManager creates a node.MinerManager backed by manager.
+func NewNodeMinerManager(manager ManagerInterface) *NodeMinerManager {
+	return &NodeMinerManager{manager: manager}
+}
+
+// StartMiner starts a miner from a remote request. config may be a *Config,
+// a *MiningProfile, or raw JSON for either.
+func (n *NodeMinerManager) StartMiner(minerType string, config interface{}) (node.MinerInstance, error) {
+	cfg, err := nodeConfigToConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), nodeOperationTimeout)
+	defer cancel()
+
+	miner, err := n.manager.StartMiner(ctx, minerType, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return n.instance(miner), nil
+}
+
+// StopMiner stops a running miner by name.
+func (n *NodeMinerManager) StopMiner(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeOperationTimeout)
+	defer cancel()
+	return n.manager.StopMiner(ctx, name)
+}
+
+// ListMiners returns the running miners.
+func (n *NodeMinerManager) ListMiners() []node.MinerInstance {
+	miners := n.manager.ListMiners()
+	instances := make([]node.MinerInstance, 0, len(miners))
+	for _, miner := range miners {
+		instances = append(instances, n.instance(miner))
+	}
+	return instances
+}
+
+// GetMiner returns a running miner by name.
+func (n *NodeMinerManager) GetMiner(name string) (node.MinerInstance, error) {
+	miner, err := n.manager.GetMiner(name)
+	if err != nil {
+		return nil, err
+	}
+	return n.instance(miner), nil
+}
+
+// InstallMiner installs a miner type on this node, reporting stages to progress.
+func (n *NodeMinerManager) InstallMiner(minerType string, progress func(stage, message string)) (string, error) {
+	miner, err := CreateMiner(minerType)
+	if err != nil {
+		return "", err
+	}
+	return installWithProgress(miner, progress)
+}
+
+// UninstallMiner removes a miner type from this node, stopping its instances first.
+func (n *NodeMinerManager) UninstallMiner(minerType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeOperationTimeout)
+	defer cancel()
+	return n.manager.UninstallMiner(ctx, minerType)
+}
+
+// UpdateMiner installs the latest version of a miner type if it is newer than
+// the installed one. Running instances keep the old binary until restarted.
+func (n *NodeMinerManager) UpdateMiner(minerType string, progress func(stage, message string)) (string, error) {
+	miner, err := CreateMiner(minerType)
+	if err != nil {
+		return "", err
+	}
+
+	progress("checking", "checking for a newer version")
+	details, err := miner.CheckInstallation()
+	if err != nil || !details.IsInstalled {
+		return "", fmt.Errorf("%s is not installed", minerType)
+	}
+	latest, err := miner.GetLatestVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to check latest version: %w", err)
+	}
+	latestVersion, err := semver.NewVersion(latest)
+	if err != nil {
+		return "", fmt.Errorf("invalid latest version %q: %w", latest, err)
+	}
+	if installedVersion, err := semver.NewVersion(details.Version); err == nil && !latestVersion.GreaterThan(installedVersion) {
+		progress("up_to_date", fmt.Sprintf("%s %s is the latest version", minerType, details.Version))
+		return details.Version, nil
+	}
+
+	return installWithProgress(miner, progress)
+}
+
+// installWithProgress runs miner.Install and verifies the result.
+func installWithProgress(miner Miner, progress func(stage, message string)) (string, error) {
+	progress("installing", fmt.Sprintf("downloading and installing %s", miner.GetName()))
+	if err := miner.Install(); err != nil {
+		return "", err
+	}
+
+	progress("verifying", "verifying installation")
+	details, err := miner.CheckInstallation()
+	if err != nil {
+		return "", fmt.Errorf("failed to verify installation: %w", err)
+	}
+	if !details.IsInstalled {
+		return "", fmt.Errorf("%s is not installed after install completed", miner.GetName())
+	}
+	return details.Version, nil
+}
+
+func (n *NodeMinerManager) instance(miner Miner) *nodeMinerInstance {
+	return &nodeMinerInstance{miner: miner, manager: n.manager}
+}
+
+// nodeMinerInstance adapts a Miner to node.MinerInstance.
+type nodeMinerInstance struct {
+	miner   Miner
+	manager ManagerInterface
+}
+
+func (i *nodeMinerInstance) GetName() string { return i.miner.GetName() }
+
+func (i *nodeMinerInstance) GetType() string { return i.miner.GetType() }
+
+// GetStats returns stats in the map shape the node protocol converts from.
+func (i *nodeMinerInstance) GetStats() (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	stats, err := i.miner.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"hashrate":  float64(stats.Hashrate),
+		"shares":    stats.Shares,
+		"rejected":  stats.Rejected,
+		"uptime":    stats.Uptime,
+		"algorithm": stats.Algorithm,
+	}
+	if mgr, ok := i.manager.(*Manager); ok {
+		if launch, err := mgr.GetMinerLaunchInfo(i.miner.GetName()); err == nil && launch.Config.Pool != "" {
+			result["pool"] = launch.Config.Pool
+		}
+	}
+	return result, nil
+}
+
+// GetConsoleHistory returns the last lines of miner output.
+func (i *nodeMinerInstance) GetConsoleHistory(lines int) []string {
+	return tailOf(i.miner.GetLogs(), lines)
+}
+
+// NodeProfileManager adapts a ProfileManager to node.ProfileManager so
+// profiles deployed to a worker are persisted and usable by ID.
+type NodeProfileManager struct {
+	profiles *ProfileManager
+}
+
+// NewNodeProfileManager creates a node.ProfileManager backed by profiles.
+func NewNodeProfileManager(profiles *ProfileManager) *NodeProfileManager {
+	return &NodeProfileManager{profiles: profiles}
+}
+
+// GetProfile returns the *MiningProfile with the given ID, with its
+// template merged into its config.
+func (n *NodeProfileManager) GetProfile(id string) (interface{}, error) {
+	profile, err := n.profiles.ResolvedProfile(id)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// ListProfiles returns all profiles with secrets masked.
+func (n *NodeProfileManager) ListProfiles() ([]interface{}, error) {
+	profiles := n.profiles.GetAllProfiles()
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].ID < profiles[j].ID })
+	result := make([]interface{}, 0, len(profiles))
+	for _, profile := range profiles {
+		result = append(result, profile.Masked())
+	}
+	return result, nil
+}
+
+// GetMaskedProfile returns a profile with secrets masked.
+func (n *NodeProfileManager) GetMaskedProfile(id string) (interface{}, error) {
+	profile, exists := n.profiles.GetProfile(id)
+	if !exists {
+		return nil, fmt.Errorf("profile not found: %s", id)
+	}
+	return profile.Masked(), nil
+}
+
+// SaveProfile stores a deployed profile, which arrives as decoded JSON.
+func (n *NodeProfileManager) SaveProfile(profile interface{}) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("invalid profile: %w", err)
+	}
+	var mp MiningProfile
+	if err := json.Unmarshal(data, &mp); err != nil {
+		return fmt.Errorf("invalid profile: %w", err)
+	}
+	if mp.MinerType == "" {
+		return fmt.Errorf("profile miner type is required")
+	}
+	return n.profiles.SaveProfile(&mp)
+}
+
+// nodeConfigToConfig converts the config forms a worker may receive into a *Config.
+func nodeConfigToConfig(config interface{}) (*Config, error) {
+	switch c := config.(type) {
+	case *Config:
+		return c, nil
+	case Config:
+		return &c, nil
+	case *MiningProfile:
+		return nodeConfigToConfig(json.RawMessage(c.Config))
+	case json.RawMessage:
+		var cfg Config
+		if err := json.Unmarshal(c, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid miner config: %w", err)
+		}
+		return &cfg, nil
+	case nil:
+		return nil, fmt.Errorf("miner config is required")
+	default:
+		data, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid miner config: %w", err)
+		}
+		return nodeConfigToConfig(json.RawMessage(data))
+	}
+}
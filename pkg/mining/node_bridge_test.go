@@ -0,0 +1,378 @@
+package mining
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Snider/Mining/pkg/node"
+)
+
+func TestNodeMinerManager(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	bridge := NewNodeMinerManager(m)
+
+	instance, err := bridge.StartMiner(MinerTypeSimulated, json.RawMessage(`{"pool":"stratum+tcp://pool.example.com:3333","wallet":"wallet"}`))
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	if instance.GetType() != MinerTypeSimulated {
+		t.Errorf("expected type %s, got %s", MinerTypeSimulated, instance.GetType())
+	}
+
+	if got := bridge.ListMiners(); len(got) != 1 || got[0].GetName() != instance.GetName() {
+		t.Fatalf("expected the started miner to be listed, got %v", got)
+	}
+
+	stats, err := instance.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	statsMap, ok := stats.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected stats map, got %T", stats)
+	}
+	if _, ok := statsMap["hashrate"].(float64); !ok {
+		t.Errorf("hashrate should be a float64 for the node protocol, got %T", statsMap["hashrate"])
+	}
+	if statsMap["pool"] != "stratum+tcp://pool.example.com:3333" {
+		t.Errorf("expected pool from launch config, got %v", statsMap["pool"])
+	}
+
+	if err := bridge.StopMiner(instance.GetName()); err != nil {
+		t.Fatalf("StopMiner failed: %v", err)
+	}
+	if _, err := bridge.GetMiner(instance.GetName()); err == nil {
+		t.Error("expected stopped miner to be gone")
+	}
+}
+
+func TestNodeConfigToConfig(t *testing.T) {
+	profile := &MiningProfile{MinerType: MinerTypeXMRig, Config: RawConfig(`{"pool":"pool:3333","threads":2}`)}
+	cfg, err := nodeConfigToConfig(profile)
+	if err != nil || cfg.Pool != "pool:3333" || cfg.Threads != 2 {
+		t.Errorf("profile config not converted: %+v, %v", cfg, err)
+	}
+
+	cfg, err = nodeConfigToConfig(map[string]interface{}{"wallet": "w"})
+	if err != nil || cfg.Wallet != "w" {
+		t.Errorf("map config not converted: %+v, %v", cfg, err)
+	}
+
+	if _, err := nodeConfigToConfig(json.RawMessage(`{`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	if _, err := nodeConfigToConfig(nil); err == nil {
+		t.Error("expected error for missing config")
+	}
+}
+
+// newTestNode creates a node with its own identity, registry and transport.
+func newTestNode(t *testing.T, name string, role node.NodeRole, listenAddr string) (*node.NodeManager, *node.PeerRegistry, *node.Transport) {
+	t.Helper()
+	dir := t.TempDir()
+	nm, err := node.NewNodeManagerWithPaths(filepath.Join(dir, "private.key"), filepath.Join(dir, "node.json"))
+	if err != nil {
+		t.Fatalf("failed to create node manager: %v", err)
+	}
+	if err := nm.GenerateIdentity(name, role); err != nil {
+		t.Fatalf("failed to generate identity: %v", err)
+	}
+	pr, err := node.NewPeerRegistryWithPath(filepath.Join(dir, "peers.json"))
+	if err != nil {
+		t.Fatalf("failed to create peer registry: %v", err)
+	}
+	config := node.DefaultTransportConfig()
+	config.ListenAddr = listenAddr
+	transport := node.NewTransport(nm, pr, config)
+	t.Cleanup(func() {
+		transport.Stop()
+		pr.Close()
+	})
+	return nm, pr, transport
+}
+
+// startTestWorkerAndController starts a worker node backed by m and profiles,
+// and returns a controller that knows it as a peer.
+func startTestWorkerAndController(t *testing.T, m ManagerInterface, profiles *ProfileManager) (*node.Controller, string) {
+	t.Helper()
+	port, err := findAvailablePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerAddr := fmt.Sprintf("127.0.0.1:%d", port)
+
+	workerNM, _, workerTransport := newTestNode(t, "worker", node.RoleWorker, workerAddr)
+	worker := node.NewWorker(workerNM, workerTransport)
+	worker.SetMinerManager(NewNodeMinerManager(m))
+	if profiles != nil {
+		worker.SetProfileManager(NewNodeProfileManager(profiles))
+	}
+	worker.RegisterWithTransport()
+	if err := workerTransport.Start(); err != nil {
+		t.Fatalf("failed to start worker transport: %v", err)
+	}
+
+	controllerNM, controllerPeers, controllerTransport := newTestNode(t, "controller", node.RoleController, "127.0.0.1:0")
+	controller := node.NewController(controllerNM, controllerPeers, controllerTransport)
+
+	workerIdentity := workerNM.GetIdentity()
+	if err := controllerPeers.AddPeer(&node.Peer{
+		ID:        workerIdentity.ID,
+		Name:      "worker",
+		PublicKey: workerIdentity.PublicKey,
+		Address:   workerAddr,
+		Role:      node.RoleWorker,
+	}); err != nil {
+		t.Fatalf("failed to add peer: %v", err)
+	}
+	return controller, workerIdentity.ID
+}
+
+func TestRemoteStartLaunchesMiner(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	controller, workerID := startTestWorkerAndController(t, m, nil)
+
+	config := json.RawMessage(`{"pool":"stratum+tcp://pool.example.com:3333","wallet":"wallet"}`)
+	if err := controller.StartRemoteMiner(workerID, MinerTypeSimulated, "", config); err != nil {
+		t.Fatalf("StartRemoteMiner failed: %v", err)
+	}
+
+	miners := m.ListMiners()
+	if len(miners) != 1 || miners[0].GetType() != MinerTypeSimulated {
+		t.Fatalf("expected a simulated miner running on the worker, got %v", miners)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats, err := controller.GetRemoteStats(workerID)
+		if err != nil {
+			t.Fatalf("GetRemoteStats failed: %v", err)
+		}
+		if len(stats.Miners) == 1 && stats.Miners[0].Name == miners[0].GetName() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("remote stats never reported the miner: %+v", stats.Miners)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := controller.StopRemoteMiner(workerID, miners[0].GetName()); err != nil {
+		t.Fatalf("StopRemoteMiner failed: %v", err)
+	}
+	if len(m.ListMiners()) != 0 {
+		t.Error("expected the remote stop to stop the miner")
+	}
+}
+
+func TestRemoteDeployProfileThenStart(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	profiles := &ProfileManager{
+		profiles:   make(map[string]*MiningProfile),
+		configPath: filepath.Join(t.TempDir(), profileConfigFileName),
+	}
+	controller, workerID := startTestWorkerAndController(t, m, profiles)
+
+	profile := MiningProfile{
+		ID:        "remote-profile",
+		Name:      "Remote",
+		MinerType: MinerTypeSimulated,
+		Config:    RawConfig(`{"pool":"stratum+tcp://pool.example.com:3333","wallet":"wallet","algo":"rx/0","password":"secret"}`),
+	}
+	profileJSON, _ := json.Marshal(profile)
+	if err := controller.DeployProfile(workerID, profileJSON, profile.Name); err != nil {
+		t.Fatalf("DeployProfile failed: %v", err)
+	}
+
+	saved, exists := profiles.GetProfile("remote-profile")
+	if !exists || saved.MinerType != MinerTypeSimulated {
+		t.Fatalf("expected deployed profile to be saved under its ID, got %+v", saved)
+	}
+	if _, err := os.Stat(profiles.configPath); err != nil {
+		t.Errorf("expected deployed profile to be persisted: %v", err)
+	}
+
+	if err := controller.StartRemoteMiner(workerID, MinerTypeSimulated, "remote-profile", nil); err != nil {
+		t.Fatalf("StartRemoteMiner by profile failed: %v", err)
+	}
+	miners := m.ListMiners()
+	if len(miners) != 1 || miners[0].GetName() != "simulated-miner-rx/0" {
+		t.Fatalf("expected a miner started from the deployed profile, got %v", miners)
+	}
+}
+
+func TestRemoteListProfiles(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	profiles := &ProfileManager{
+		profiles:   make(map[string]*MiningProfile),
+		configPath: filepath.Join(t.TempDir(), profileConfigFileName),
+	}
+	profiles.SaveProfile(&MiningProfile{ID: "b", Name: "B", MinerType: MinerTypeXMRig, Config: RawConfig(`{"pool":"pool:3333","password":"secret"}`)})
+	profiles.SaveProfile(&MiningProfile{ID: "a", Name: "A", MinerType: MinerTypeXMRig, Config: RawConfig(`{"pool":"pool:3333"}`)})
+	controller, workerID := startTestWorkerAndController(t, m, profiles)
+
+	raw, err := controller.ListRemoteProfiles(workerID)
+	if err != nil {
+		t.Fatalf("ListRemoteProfiles failed: %v", err)
+	}
+	if len(raw) != 2 {
+		t.Fatalf("expected 2 profiles, got %d", len(raw))
+	}
+	var first MiningProfile
+	json.Unmarshal(raw[0], &first)
+	if first.ID != "a" {
+		t.Errorf("expected profiles sorted by ID, got %s first", first.ID)
+	}
+
+	single, err := controller.GetRemoteProfile(workerID, "b")
+	if err != nil {
+		t.Fatalf("GetRemoteProfile failed: %v", err)
+	}
+	var profile MiningProfile
+	json.Unmarshal(single, &profile)
+	var cfg Config
+	json.Unmarshal(profile.Config, &cfg)
+	if cfg.Password != maskedSecret || cfg.Pool != "pool:3333" {
+		t.Errorf("expected masked password and intact pool, got %+v", cfg)
+	}
+
+	if _, err := controller.GetRemoteProfile(workerID, "missing"); err == nil {
+		t.Error("expected error for missing remote profile")
+	}
+}
+
+func TestMiningProfileMasked(t *testing.T) {
+	profile := &MiningProfile{ID: "p", Config: RawConfig(`{"pool":"pool:3333","userPass":"u:p","custom":1}`)}
+	masked := profile.Masked()
+
+	var fields map[string]interface{}
+	json.Unmarshal(masked.Config, &fields)
+	if fields["userPass"] != maskedSecret || fields["pool"] != "pool:3333" || fields["custom"] != float64(1) {
+		t.Errorf("unexpected masked config: %s", masked.Config)
+	}
+	if string(profile.Config) != `{"pool":"pool:3333","userPass":"u:p","custom":1}` {
+		t.Error("Masked must not modify the original profile")
+	}
+	if (&MiningProfile{Config: RawConfig(`not json`)}).Masked().Config != nil {
+		t.Error("unparseable config should be dropped")
+	}
+}
+
+func TestRemoteInstallUpdateUninstall(t *testing.T) {
+	setupIsolatedMinersConfig(t)
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	controller, workerID := startTestWorkerAndController(t, m, nil)
+
+	var mu sync.Mutex
+	var stages []string
+	recordStage := func(p node.InstallProgressPayload) {
+		mu.Lock()
+		stages = append(stages, p.Stage)
+		mu.Unlock()
+	}
+
+	version, err := controller.InstallRemoteMiner(workerID, MinerTypeSimulated, recordStage)
+	if err != nil {
+		t.Fatalf("InstallRemoteMiner failed: %v", err)
+	}
+	if version != "1.0.0-simulated" {
+		t.Errorf("expected installed version, got %q", version)
+	}
+	mu.Lock()
+	if strings.Join(stages, ",") != "installing,verifying" {
+		t.Errorf("expected installing and verifying progress, got %v", stages)
+	}
+	stages = nil
+	mu.Unlock()
+
+	if _, err := controller.UpdateRemoteMiner(workerID, MinerTypeSimulated, recordStage); err != nil {
+		t.Fatalf("UpdateRemoteMiner failed: %v", err)
+	}
+	mu.Lock()
+	if strings.Join(stages, ",") != "checking,up_to_date" {
+		t.Errorf("expected up-to-date progress, got %v", stages)
+	}
+	mu.Unlock()
+
+	if err := controller.UninstallRemoteMiner(workerID, MinerTypeSimulated); err != nil {
+		t.Fatalf("UninstallRemoteMiner failed: %v", err)
+	}
+
+	if _, err := controller.InstallRemoteMiner(workerID, "no-such-miner", nil); err == nil {
+		t.Error("expected error for unsupported miner type")
+	}
+}
+
+func TestRemoteStartQueuedUntilReconnect(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	port, err := findAvailablePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerAddr := fmt.Sprintf("127.0.0.1:%d", port)
+	workerNM, _, workerTransport := newTestNode(t, "worker", node.RoleWorker, workerAddr)
+	worker := node.NewWorker(workerNM, workerTransport)
+	worker.SetMinerManager(NewNodeMinerManager(m))
+	worker.RegisterWithTransport()
+
+	controllerNM, controllerPeers, controllerTransport := newTestNode(t, "controller", node.RoleController, "127.0.0.1:0")
+	controller := node.NewController(controllerNM, controllerPeers, controllerTransport)
+	outbox, err := node.NewOutboxWithPath(filepath.Join(t.TempDir(), "outbox.json"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller.SetOutbox(outbox)
+
+	workerIdentity := workerNM.GetIdentity()
+	if err := controllerPeers.AddPeer(&node.Peer{
+		ID:        workerIdentity.ID,
+		Name:      "worker",
+		PublicKey: workerIdentity.PublicKey,
+		Address:   workerAddr,
+		Role:      node.RoleWorker,
+	}); err != nil {
+		t.Fatalf("failed to add peer: %v", err)
+	}
+
+	// The worker isn't listening yet, so the start is queued
+	config := json.RawMessage(`{"pool":"stratum+tcp://pool.example.com:3333","wallet":"wallet","algo":"rx/0"}`)
+	err = controller.StartRemoteMiner(workerIdentity.ID, MinerTypeSimulated, "", config)
+	var queued *node.CommandQueuedError
+	if !errors.As(err, &queued) {
+		t.Fatalf("expected CommandQueuedError, got %v", err)
+	}
+	// Repeating the same command doesn't queue it twice
+	controller.StartRemoteMiner(workerIdentity.ID, MinerTypeSimulated, "", config)
+	if counts := outbox.Counts(); counts[workerIdentity.ID] != 1 {
+		t.Fatalf("expected 1 queued command, got %v", counts)
+	}
+
+	if err := workerTransport.Start(); err != nil {
+		t.Fatalf("failed to start worker transport: %v", err)
+	}
+	if err := controller.ConnectToPeer(workerIdentity.ID); err != nil {
+		t.Fatalf("ConnectToPeer failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(m.ListMiners()) != 1 || len(outbox.Counts()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("queued start was not delivered: miners=%d outbox=%v", len(m.ListMiners()), outbox.Counts())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -2,10 +2,19 @@ package mining
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Snider/Mining/pkg/logging"
 	"github.com/Snider/Mining/pkg/node"
 	"github.com/gin-gonic/gin"
 )
@@ -17,10 +26,122 @@ type NodeService struct {
 	transport    *node.Transport
 	controller   *node.Controller
 	worker       *node.Worker
+	fleet        *FleetReconciler // nil when no profile manager is available
+	hopProber    *node.HopProber
+	settings     *SettingsManager // Persists selection weights; nil if settings can't be loaded
+	config       NodeServiceConfig
+
+	transportMu  sync.RWMutex
+	transportErr error // Last error from StartTransport, if any
+
+	geoMu sync.RWMutex
+	geo   *node.GeoDistance // nil when peer distance measurement is disabled
+
+	apiAuth atomic.Bool // Whether API auth is enabled; without it peer auth settings only accept local requests
+}
+
+// NodeServiceConfig configures the P2P node service.
+type NodeServiceConfig struct {
+	// ListenAddr is the address the P2P transport listens on for incoming peers
+	ListenAddr string `yaml:"listenAddr"`
+	// AutoStart starts the P2P transport on service startup when a node identity exists
+	AutoStart bool `yaml:"autoStart"`
+	// ReconcileInterval is how often the fleet reconciler converges workers; zero disables it
+	ReconcileInterval time.Duration `yaml:"reconcileInterval"`
+	// GeoIPDatabase is a CSV of network,latitude,longitude used to compute peer
+	// distances (GeoLite2 City blocks CSVs work as-is); empty leaves GeoKM at zero
+	GeoIPDatabase string `yaml:"geoipDatabase"`
+	// GeoLocation is this node's "lat,lon"; if empty it is derived by locating GeoPublicIP
+	GeoLocation string `yaml:"geoLocation"`
+	// GeoPublicIP is this node's public IP, used to derive GeoLocation
+	GeoPublicIP string `yaml:"geoPublicIP"`
+	// HopProbeInterval is how often peer hop counts are measured; zero disables probing
+	HopProbeInterval time.Duration `yaml:"hopProbeInterval"`
+	// TLSAutoCert serves the transport over wss:// with a self-signed certificate
+	// generated for the node identity
+	TLSAutoCert bool `yaml:"tlsAutoCert"`
+	// StatsPushInterval opts in to pushed stats: as a worker the node offers to
+	// push, and as a controller it asks workers to push at this interval; zero polls
+	StatsPushInterval time.Duration `yaml:"statsPushInterval"`
+}
+
+// DefaultNodeServiceConfig returns the default node service configuration.
+func DefaultNodeServiceConfig() NodeServiceConfig {
+	return NodeServiceConfig{
+		ListenAddr:        node.DefaultTransportConfig().ListenAddr,
+		AutoStart:         true,
+		ReconcileInterval: DefaultFleetReconcileInterval,
+		HopProbeInterval:  node.DefaultHopProbeInterval,
+	}
 }
 
-// NewNodeService creates a new NodeService instance.
-func NewNodeService() (*NodeService, error) {
+// NodeServiceConfigFromEnv creates node service config from environment variables.
+// MINING_P2P_LISTEN sets the listen address, MINING_P2P_AUTOSTART=false disables auto-start
+// and MINING_FLEET_RECONCILE_INTERVAL sets the fleet reconcile interval (e.g. "5m", "0" to disable).
+// MINING_GEOIP_DB, MINING_GEO_LOCATION and MINING_GEO_PUBLIC_IP enable peer distance measurement,
+// and MINING_HOP_PROBE_INTERVAL sets the hop probe interval ("0" to disable).
+// MINING_P2P_TLS_AUTO=true serves the transport over TLS with a generated certificate,
+// and MINING_P2P_STATS_PUSH_INTERVAL (e.g. "10s") enables pushed stats.
+func NodeServiceConfigFromEnv() NodeServiceConfig {
+	return nodeServiceConfigWithEnv(DefaultNodeServiceConfig())
+}
+
+// nodeServiceConfigWithEnv overrides config with any P2P environment variables set.
+func nodeServiceConfigWithEnv(config NodeServiceConfig) NodeServiceConfig {
+	if addr := os.Getenv("MINING_P2P_LISTEN"); addr != "" {
+		config.ListenAddr = addr
+	}
+	if autoStart := os.Getenv("MINING_P2P_AUTOSTART"); autoStart != "" {
+		config.AutoStart = autoStart == "true" || autoStart == "1"
+	}
+	if interval := os.Getenv("MINING_FLEET_RECONCILE_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d >= 0 {
+			config.ReconcileInterval = d
+		} else {
+			logging.Warn("invalid MINING_FLEET_RECONCILE_INTERVAL, using default", logging.Fields{"value": interval})
+		}
+	}
+	if interval := os.Getenv("MINING_HOP_PROBE_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d >= 0 {
+			config.HopProbeInterval = d
+		} else {
+			logging.Warn("invalid MINING_HOP_PROBE_INTERVAL, using default", logging.Fields{"value": interval})
+		}
+	}
+	if tlsAuto := os.Getenv("MINING_P2P_TLS_AUTO"); tlsAuto != "" {
+		config.TLSAutoCert = tlsAuto == "true" || tlsAuto == "1"
+	}
+	if interval := os.Getenv("MINING_P2P_STATS_PUSH_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d >= 0 {
+			config.StatsPushInterval = d
+		} else {
+			logging.Warn("invalid MINING_P2P_STATS_PUSH_INTERVAL, stats push disabled", logging.Fields{"value": interval})
+		}
+	}
+	if path := os.Getenv("MINING_GEOIP_DB"); path != "" {
+		config.GeoIPDatabase = path
+	}
+	if location := os.Getenv("MINING_GEO_LOCATION"); location != "" {
+		config.GeoLocation = location
+	}
+	if ip := os.Getenv("MINING_GEO_PUBLIC_IP"); ip != "" {
+		config.GeoPublicIP = ip
+	}
+
+	return config
+}
+
+// NewNodeService creates a new NodeService instance configured from the environment.
+// Remote miner requests from controllers operate on manager, and deployed
+// profiles are stored in profiles.
+func NewNodeService(manager ManagerInterface, profiles *ProfileManager) (*NodeService, error) {
+	return NewNodeServiceWithConfig(NodeServiceConfigFromEnv(), manager, profiles)
+}
+
+// NewNodeServiceWithConfig creates a new NodeService instance with the given config.
+// manager and profiles may be nil, in which case the worker rejects the
+// corresponding operations.
+func NewNodeServiceWithConfig(cfg NodeServiceConfig, manager ManagerInterface, profiles *ProfileManager) (*NodeService, error) {
 	nm, err := node.NewNodeManager()
 	if err != nil {
 		return nil, err
@@ -32,28 +153,151 @@ func NewNodeService() (*NodeService, error) {
 	}
 
 	config := node.DefaultTransportConfig()
+	if cfg.ListenAddr != "" {
+		config.ListenAddr = cfg.ListenAddr
+	}
+	config.TLSAutoCert = cfg.TLSAutoCert
 	transport := node.NewTransport(nm, pr, config)
 
 	ns := &NodeService{
 		nodeManager:  nm,
 		peerRegistry: pr,
 		transport:    transport,
+		config:       cfg,
 	}
 
 	// Initialize controller and worker
 	ns.controller = node.NewController(nm, pr, transport)
+	outbox, err := node.NewOutbox()
+	if err != nil {
+		return nil, err
+	}
+	ns.controller.SetOutbox(outbox)
 	ns.worker = node.NewWorker(nm, transport)
+	if cfg.StatsPushInterval > 0 {
+		ns.worker.EnableStatsPush()
+		ns.controller.EnableStatsPush(cfg.StatsPushInterval)
+	}
+	if manager != nil {
+		ns.worker.SetMinerManager(NewNodeMinerManager(manager))
+	}
+	if profiles != nil {
+		ns.worker.SetProfileManager(NewNodeProfileManager(profiles))
+
+		ns.fleet, err = NewFleetReconciler(ns.controller, pr.ListPeers, profiles, cfg.ReconcileInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ns.hopProber = node.NewHopProber(pr, cfg.HopProbeInterval)
+
+	// Apply the operator's peer selection weights from settings
+	if ns.settings, err = NewSettingsManager(); err != nil {
+		logging.Warn("failed to load settings, using default peer selection weights", logging.Fields{"error": err})
+	} else if weights := ns.settings.Get().PeerSelectionWeights; weights != nil {
+		if err := pr.SetSelectionWeights(*weights); err != nil {
+			logging.Warn("invalid peer selection weights in settings, using defaults", logging.Fields{"error": err})
+		}
+	}
+
+	// Measure peer distances when peers connect, since the handshake may change their ID
+	geo, err := newGeoDistance(cfg)
+	if err != nil {
+		logging.Warn("peer distance measurement disabled", logging.Fields{"error": err})
+	}
+	ns.SetGeoDistance(geo)
+	transport.OnConnect(ns.updatePeerGeo)
+
+	// The transport has a single handler: replies and pushed stats go to the
+	// controller, requests from remote controllers go to the worker
+	transport.OnMessage(func(conn *node.PeerConnection, msg *node.Message) {
+		if msg.ReplyTo != "" || msg.Type == node.MsgStats {
+			ns.controller.HandleResponse(conn, msg)
+			return
+		}
+		ns.worker.HandleMessage(conn, msg)
+	})
 
 	return ns, nil
 }
 
+// newGeoDistance builds peer distance measurement from cfg, returning nil
+// when no GeoIP database is configured.
+func newGeoDistance(cfg NodeServiceConfig) (*node.GeoDistance, error) {
+	if cfg.GeoIPDatabase == "" {
+		return nil, nil
+	}
+	locator, err := node.LoadGeoLocatorCSV(cfg.GeoIPDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	var origin node.GeoPoint
+	switch {
+	case cfg.GeoLocation != "":
+		if origin, err = node.ParseGeoPoint(cfg.GeoLocation); err != nil {
+			return nil, err
+		}
+	case cfg.GeoPublicIP != "":
+		ip := net.ParseIP(cfg.GeoPublicIP)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid public IP %q", cfg.GeoPublicIP)
+		}
+		var ok bool
+		if origin, ok = locator.Locate(ip); !ok {
+			return nil, fmt.Errorf("public IP %s not found in geo database", cfg.GeoPublicIP)
+		}
+	default:
+		return nil, fmt.Errorf("this node's location is unknown: set MINING_GEO_LOCATION or MINING_GEO_PUBLIC_IP")
+	}
+	return node.NewGeoDistance(locator, origin), nil
+}
+
+// SetGeoDistance sets how peer distances are measured, replacing the GeoIP
+// database from config. nil disables measurement and leaves GeoKM unchanged.
+func (ns *NodeService) SetGeoDistance(geo *node.GeoDistance) {
+	ns.geoMu.Lock()
+	ns.geo = geo
+	ns.geoMu.Unlock()
+}
+
+// updatePeerGeo refreshes a peer's GeoKM. Peers that can't be located (e.g.
+// on a private network) keep their current value.
+func (ns *NodeService) updatePeerGeo(peerID string) {
+	ns.geoMu.RLock()
+	geo := ns.geo
+	ns.geoMu.RUnlock()
+	if geo == nil {
+		return
+	}
+	if err := geo.UpdatePeer(ns.peerRegistry, peerID); err != nil {
+		logging.Debug("could not measure peer distance", logging.Fields{"peer_id": peerID, "error": err})
+	}
+}
+
 // SetupRoutes configures all node-related API routes.
 func (ns *NodeService) SetupRoutes(router *gin.RouterGroup) {
+	// Peer auth settings and the node's keys decide who may connect and who
+	// the node is, so they need the admin
+	admin := requireAdminMiddleware(ns.apiAuth.Load)
+
 	// Node identity endpoints
 	nodeGroup := router.Group("/node")
 	{
 		nodeGroup.GET("/info", ns.handleNodeInfo)
+		nodeGroup.GET("/health", ns.handleNodeHealth)
 		nodeGroup.POST("/init", ns.handleNodeInit)
+		nodeGroup.POST("/rotate-key", admin, ns.handleRotateKey)
+		nodeGroup.GET("/identity/export", admin, ns.handleExportIdentity)
+		nodeGroup.POST("/identity/import", admin, ns.handleImportIdentity)
+		nodeGroup.GET("/selection-weights", ns.handleGetSelectionWeights)
+		nodeGroup.PUT("/selection-weights", ns.handleSetSelectionWeights)
+		nodeGroup.GET("/auth-mode", admin, ns.handleGetAuthMode)
+		nodeGroup.PUT("/auth-mode", admin, ns.handleSetAuthMode)
+		nodeGroup.GET("/allowlist", admin, ns.handleListAllowlist)
+		nodeGroup.POST("/allowlist", admin, ns.handleAddToAllowlist)
+		nodeGroup.DELETE("/allowlist", admin, ns.handleRevokeFromAllowlist)
 	}
 
 	// Peer management endpoints
@@ -61,47 +305,209 @@ func (ns *NodeService) SetupRoutes(router *gin.RouterGroup) {
 	{
 		peerGroup.GET("", ns.handleListPeers)
 		peerGroup.POST("", ns.handleAddPeer)
+		peerGroup.GET("/optimal", ns.handleOptimalPeer)
+		peerGroup.GET("/nearest", ns.handleNearestPeers)
+		peerGroup.GET("/tags", ns.handleListTags)
 		peerGroup.GET("/:id", ns.handleGetPeer)
 		peerGroup.DELETE("/:id", ns.handleRemovePeer)
 		peerGroup.POST("/:id/ping", ns.handlePingPeer)
 		peerGroup.POST("/:id/connect", ns.handleConnectPeer)
 		peerGroup.POST("/:id/disconnect", ns.handleDisconnectPeer)
+		peerGroup.PUT("/:id/tags", ns.handleSetPeerTags)
+		peerGroup.POST("/:id/tags", ns.handleAddPeerTag)
+		peerGroup.DELETE("/:id/tags/:tag", ns.handleRemovePeerTag)
 
 		// Allowlist management
-		peerGroup.GET("/auth/mode", ns.handleGetAuthMode)
-		peerGroup.PUT("/auth/mode", ns.handleSetAuthMode)
-		peerGroup.GET("/auth/allowlist", ns.handleListAllowlist)
-		peerGroup.POST("/auth/allowlist", ns.handleAddToAllowlist)
-		peerGroup.DELETE("/auth/allowlist/:key", ns.handleRemoveFromAllowlist)
+		peerGroup.GET("/auth/mode", admin, ns.handleGetAuthMode)
+		peerGroup.PUT("/auth/mode", admin, ns.handleSetAuthMode)
+		peerGroup.GET("/auth/allowlist", admin, ns.handleListAllowlist)
+		peerGroup.POST("/auth/allowlist", admin, ns.handleAddToAllowlist)
+		peerGroup.DELETE("/auth/allowlist/:key", admin, ns.handleRemoveFromAllowlist)
 	}
 
 	// Remote operations endpoints
 	remoteGroup := router.Group("/remote")
 	{
 		remoteGroup.GET("/stats", ns.handleRemoteStats)
+		remoteGroup.GET("/outbox", ns.handleOutboxCounts)
+		remoteGroup.POST("/tags/:tag/start", ns.handleTagStart)
+		remoteGroup.POST("/tags/:tag/stop", ns.handleTagStop)
+		remoteGroup.GET("/:peerId/outbox", ns.handlePeerOutbox)
+		remoteGroup.DELETE("/:peerId/outbox", ns.handleClearPeerOutbox)
 		remoteGroup.GET("/:peerId/stats", ns.handlePeerStats)
+		remoteGroup.PUT("/:peerId/stats-push", ns.handleSetStatsPush)
 		remoteGroup.POST("/:peerId/start", ns.handleRemoteStart)
 		remoteGroup.POST("/:peerId/stop", ns.handleRemoteStop)
 		remoteGroup.GET("/:peerId/logs/:miner", ns.handleRemoteLogs)
+		remoteGroup.POST("/:peerId/install", ns.handleRemoteInstall)
+		remoteGroup.POST("/:peerId/uninstall", ns.handleRemoteUninstall)
+		remoteGroup.POST("/:peerId/update", ns.handleRemoteUpdate)
+		remoteGroup.GET("/:peerId/profiles", ns.handleRemoteProfiles)
+		remoteGroup.GET("/:peerId/profiles/:id", ns.handleRemoteProfile)
+	}
+
+	// Fleet desired-state endpoints
+	fleetGroup := router.Group("/fleet")
+	{
+		fleetGroup.GET("/desired-state", ns.handleGetFleetDesiredState)
+		fleetGroup.PUT("/desired-state", ns.handleSetFleetDesiredState)
+		fleetGroup.GET("/status", ns.handleFleetStatus)
+		fleetGroup.POST("/reconcile", ns.handleFleetReconcile)
 	}
 }
 
 // StartTransport starts the P2P transport server.
+// Periodic fleet reconciliation and hop probing run while the transport is up.
 func (ns *NodeService) StartTransport() error {
-	return ns.transport.Start()
+	err := ns.transport.Start()
+
+	ns.transportMu.Lock()
+	ns.transportErr = err
+	ns.transportMu.Unlock()
+
+	if err == nil {
+		if ns.fleet != nil {
+			ns.fleet.Start()
+		}
+		ns.hopProber.Start()
+	}
+	return err
+}
+
+// AutoStartTransport starts the transport if auto-start is enabled and a node
+// identity exists. Returns nil when there is nothing to start.
+func (ns *NodeService) AutoStartTransport() error {
+	if !ns.config.AutoStart || !ns.nodeManager.HasIdentity() {
+		return nil
+	}
+	if err := ns.StartTransport(); err != nil {
+		return err
+	}
+	logging.Info("P2P transport listening", logging.Fields{"addr": ns.transport.ListenAddr()})
+	return nil
+}
+
+// TransportStatus describes the state of the P2P transport listener.
+type TransportStatus struct {
+	Listening  bool   `json:"listening"`
+	ListenAddr string `json:"listenAddr"`
+	AutoStart  bool   `json:"autoStart"`
+	Error      string `json:"error,omitempty"`
+	// TLSFingerprint is the SHA-256 of the served certificate, for peers to
+	// pin; empty when the transport isn't using TLS
+	TLSFingerprint string `json:"tlsFingerprint,omitempty"`
+}
+
+// TransportStatus returns the current P2P transport listener status.
+func (ns *NodeService) TransportStatus() TransportStatus {
+	status := TransportStatus{
+		Listening:  ns.transport.IsListening(),
+		ListenAddr: ns.transport.ListenAddr(),
+		AutoStart:  ns.config.AutoStart,
+	}
+	if status.Listening {
+		status.TLSFingerprint = ns.transport.TLSFingerprint()
+	}
+
+	ns.transportMu.RLock()
+	if ns.transportErr != nil {
+		status.Error = ns.transportErr.Error()
+	}
+	ns.transportMu.RUnlock()
+
+	return status
 }
 
 // StopTransport stops the P2P transport server.
 func (ns *NodeService) StopTransport() error {
+	if ns.fleet != nil {
+		ns.fleet.Stop()
+	}
+	ns.hopProber.Stop()
 	return ns.transport.Stop()
 }
 
+// nodeError maps an error from the node controller or registry to a structured
+// MiningError so node endpoints return the same error format as the mining API.
+func nodeError(err error, peerID, operation string) *MiningError {
+	var protoErr *node.ProtocolError
+	if errors.As(err, &protoErr) {
+		return ErrRemoteCommandFailed(operation).WithCause(err)
+	}
+
+	var notConnected *node.PeerNotConnectedError
+	if errors.As(err, &notConnected) {
+		return peerNotConnectedError(notConnected)
+	}
+
+	var nodeErr *node.NodeError
+	if errors.As(err, &nodeErr) {
+		return nodeCodeError(err, peerID, operation)
+	}
+
+	// Remote command failures reported in an ack are still plain errors
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "start failed"), strings.Contains(msg, "stop failed"),
+		strings.Contains(msg, "install failed"), strings.Contains(msg, "update failed"):
+		return ErrRemoteCommandFailed(operation).WithCause(err)
+	case strings.Contains(msg, "timeout"):
+		return ErrTimeout(operation).WithCause(err)
+	default:
+		return ErrInternal(operation + " failed").WithCause(err)
+	}
+}
+
+// nodeCodeError maps a node.NodeError by its code. A send failure wraps the
+// reason the send failed, so the more specific codes are checked first.
+func nodeCodeError(err error, peerID, operation string) *MiningError {
+	switch {
+	case errors.Is(err, node.ErrIdentityNotInitialized):
+		return ErrNodeNotInitialized().WithCause(err)
+	case errors.Is(err, node.ErrTransportNotRunning):
+		return ErrTransportNotStarted().WithCause(err)
+	case errors.Is(err, node.ErrHandshakeFailed):
+		return ErrHandshakeFailed(peerID).WithCause(err)
+	case errors.Is(err, node.ErrPeerNotFound):
+		return ErrPeerNotFound(peerID).WithCause(err)
+	case errors.Is(err, node.ErrPeerNotConnected):
+		return ErrPeerNotConnected(peerID).WithCause(err)
+	case errors.Is(err, node.ErrConnectFailed), errors.Is(err, node.ErrSendFailed):
+		return ErrConnectionFailed(peerID).WithCause(err)
+	case errors.Is(err, node.ErrRequestTimeout):
+		return ErrTimeout(operation).WithCause(err)
+	case errors.Is(err, node.ErrDuplicateMessage):
+		return ErrRemoteCommandFailed(operation).WithCause(err).WithDetails("the peer dropped the request as a duplicate")
+	default:
+		return ErrInternal(operation + " failed").WithCause(err)
+	}
+}
+
+// peerNotConnectedError distinguishes "the peer isn't connected" from a genuine
+// connection failure and includes when the peer was last seen.
+func peerNotConnectedError(err *node.PeerNotConnectedError) *MiningError {
+	switch {
+	case errors.Is(err.Cause, node.ErrTransportNotRunning):
+		return ErrTransportNotStarted().WithCause(err)
+	case errors.Is(err.Cause, node.ErrHandshakeFailed):
+		return ErrHandshakeFailed(err.PeerID).WithCause(err)
+	}
+
+	lastSeen := "peer has never been seen"
+	if !err.LastSeen.IsZero() {
+		lastSeen = "last seen " + err.LastSeen.UTC().Format(time.RFC3339)
+	}
+	return ErrPeerNotConnected(err.PeerID).WithCause(err).WithDetails(lastSeen)
+}
+
 // Node Info Response
 type NodeInfoResponse struct {
 	HasIdentity     bool               `json:"hasIdentity"`
 	Identity        *node.NodeIdentity `json:"identity,omitempty"`
 	RegisteredPeers int                `json:"registeredPeers"`
 	ConnectedPeers  int                `json:"connectedPeers"`
+	Transport       TransportStatus    `json:"transport"`
+	AuthMode        string             `json:"authMode"` // Who may connect: open or allowlist
 }
 
 // handleNodeInfo godoc
@@ -116,6 +522,8 @@ func (ns *NodeService) handleNodeInfo(c *gin.Context) {
 		HasIdentity:     ns.nodeManager.HasIdentity(),
 		RegisteredPeers: ns.peerRegistry.Count(),
 		ConnectedPeers:  len(ns.peerRegistry.GetConnectedPeers()),
+		Transport:       ns.TransportStatus(),
+		AuthMode:        ns.peerRegistry.GetAuthMode().String(),
 	}
 
 	if ns.nodeManager.HasIdentity() {
@@ -125,6 +533,158 @@ func (ns *NodeService) handleNodeInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// RotateKeyRequest is the request body for rotating the node key.
+type RotateKeyRequest struct {
+	GracePeriod string `json:"gracePeriod"` // How long peers accept the old key, e.g. "24h" (default 24h, max 720h)
+}
+
+// RotateKeyResponse is the response from rotating the node key.
+type RotateKeyResponse struct {
+	Identity    *node.NodeIdentity `json:"identity"`
+	AnnouncedTo int                `json:"announcedTo"` // Connected peers told about the new key
+}
+
+// handleRotateKey godoc
+// @Summary Rotate the node key
+// @Description Replace the node's keypair, keeping its ID, and announce the new key to connected peers. Peers that allowlisted the old key allow the new one and keep accepting the old key for the grace period.
+// @Tags node
+// @Accept json
+// @Produce json
+// @Param request body RotateKeyRequest false "Grace period for the old key"
+// @Success 200 {object} RotateKeyResponse
+// @Failure 400 {object} APIError "Invalid grace period"
+// @Failure 409 {object} APIError "Node identity not initialized"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /node/rotate-key [post]
+func (ns *NodeService) handleRotateKey(c *gin.Context) {
+	var req RotateKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+			return
+		}
+	}
+
+	grace := node.DefaultKeyRotationGrace
+	if req.GracePeriod != "" {
+		parsed, err := time.ParseDuration(req.GracePeriod)
+		if err != nil || parsed <= 0 || parsed > node.MaxKeyRotationGrace {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid grace period",
+				fmt.Sprintf("gracePeriod must be a duration up to %s", node.MaxKeyRotationGrace))
+			return
+		}
+		grace = parsed
+	}
+
+	if !ns.nodeManager.HasIdentity() {
+		respondWithMiningError(c, ErrNodeNotInitialized())
+		return
+	}
+	if err := ns.nodeManager.RotateIdentity(grace); err != nil {
+		respondWithMiningError(c, ErrInternal("failed to rotate node key").WithCause(err))
+		return
+	}
+
+	// Peers that miss the announcement still connect, since they match us by ID
+	announced, err := ns.transport.AnnounceKeyRotation()
+	if err != nil {
+		logging.Warn("failed to announce key rotation", logging.Fields{"error": err})
+	}
+
+	c.JSON(http.StatusOK, RotateKeyResponse{Identity: ns.nodeManager.GetIdentity(), AnnouncedTo: announced})
+}
+
+// exportPassphraseHeader carries the export passphrase, keeping it out of URLs and access logs.
+const exportPassphraseHeader = "X-Export-Passphrase"
+
+// handleExportIdentity godoc
+// @Summary Export the node identity
+// @Description Export the node identity and private keys encrypted with a passphrase, to move the node to another machine without re-approving it on its peers
+// @Tags node
+// @Produce json
+// @Param X-Export-Passphrase header string true "Passphrase to encrypt the export with (at least 12 characters)"
+// @Success 200 {object} node.IdentityExport
+// @Failure 400 {object} APIError "Missing or short passphrase"
+// @Failure 409 {object} APIError "Node identity not initialized"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /node/identity/export [get]
+func (ns *NodeService) handleExportIdentity(c *gin.Context) {
+	passphrase := c.GetHeader(exportPassphraseHeader)
+	if len(passphrase) < node.MinExportPassphraseLength {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "passphrase required",
+			fmt.Sprintf("send a passphrase of at least %d characters in the %s header", node.MinExportPassphraseLength, exportPassphraseHeader))
+		return
+	}
+	if !ns.nodeManager.HasIdentity() {
+		respondWithMiningError(c, ErrNodeNotInitialized())
+		return
+	}
+
+	export, err := ns.nodeManager.ExportIdentity(passphrase)
+	if err != nil {
+		respondWithMiningError(c, ErrInternal("failed to export node identity").WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, export)
+}
+
+// ImportIdentityRequest is the request body for importing a node identity.
+type ImportIdentityRequest struct {
+	Export     *node.IdentityExport `json:"export" binding:"required"`
+	Passphrase string               `json:"passphrase" binding:"required"`
+	Force      bool                 `json:"force"` // Replace an existing identity
+}
+
+// handleImportIdentity godoc
+// @Summary Import a node identity
+// @Description Replace this node's identity with one exported from another machine. Refused when an identity exists unless force is set. Restart the transport so existing connections pick up the new identity.
+// @Tags node
+// @Accept json
+// @Produce json
+// @Param request body ImportIdentityRequest true "Exported identity and its passphrase"
+// @Success 200 {object} node.NodeIdentity
+// @Failure 400 {object} APIError "Invalid export or wrong passphrase"
+// @Failure 409 {object} APIError "Node identity already exists"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Failure 500 {object} APIError "Failed to save the imported identity"
+// @Router /node/identity/import [post]
+func (ns *NodeService) handleImportIdentity(c *gin.Context) {
+	var req ImportIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	if ns.nodeManager.HasIdentity() && !req.Force {
+		respondWithMiningError(c, ErrNodeIdentityExists().WithSuggestion("Set force to replace the existing identity"))
+		return
+	}
+
+	if err := ns.nodeManager.ImportIdentity(req.Export, req.Passphrase, req.Force); err != nil {
+		switch {
+		case errors.Is(err, node.ErrInvalidIdentityExport):
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "failed to import node identity", err.Error())
+		case errors.Is(err, node.ErrIdentityExists):
+			respondWithMiningError(c, ErrNodeIdentityExists().WithSuggestion("Set force to replace the existing identity"))
+		default:
+			respondWithMiningError(c, ErrInternal("failed to import node identity").WithCause(err))
+		}
+		return
+	}
+	logging.Info("node identity imported", logging.Fields{"node_id": req.Export.NodeID})
+	c.JSON(http.StatusOK, ns.nodeManager.GetIdentity())
+}
+
+// handleNodeHealth godoc
+// @Summary Get P2P transport health
+// @Description Get the P2P transport's running state, connection counts, message totals, dropped messages and per-peer connection age and activity
+// @Tags node
+// @Produce json
+// @Success 200 {object} node.TransportHealth
+// @Router /node/health [get]
+func (ns *NodeService) handleNodeHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, ns.transport.Health())
+}
+
 // NodeInitRequest is the request body for node initialization.
 type NodeInitRequest struct {
 	Name string `json:"name" binding:"required"`
@@ -143,12 +703,12 @@ type NodeInitRequest struct {
 func (ns *NodeService) handleNodeInit(c *gin.Context) {
 	var req NodeInitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
 		return
 	}
 
 	if ns.nodeManager.HasIdentity() {
-		c.JSON(http.StatusConflict, gin.H{"error": "node identity already exists"})
+		respondWithMiningError(c, ErrNodeIdentityExists())
 		return
 	}
 
@@ -161,34 +721,85 @@ func (ns *NodeService) handleNodeInit(c *gin.Context) {
 	case "dual", "":
 		role = node.RoleDual
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid role", "role must be 'controller', 'worker' or 'dual'")
 		return
 	}
 
 	if err := ns.nodeManager.GenerateIdentity(req.Name, role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithMiningError(c, ErrInternal("failed to generate node identity").WithCause(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, ns.nodeManager.GetIdentity())
 }
 
+// handleGetSelectionWeights godoc
+// @Summary Get peer selection weights
+// @Description Get the weights applied to ping, hops, distance and score when selecting peers
+// @Tags node
+// @Produce json
+// @Success 200 {object} node.SelectionWeights
+// @Router /node/selection-weights [get]
+func (ns *NodeService) handleGetSelectionWeights(c *gin.Context) {
+	c.JSON(http.StatusOK, ns.peerRegistry.GetSelectionWeights())
+}
+
+// handleSetSelectionWeights godoc
+// @Summary Set peer selection weights
+// @Description Set the weights applied to ping, hops, distance and score when selecting peers. Weights must be non-negative.
+// @Tags node
+// @Accept json
+// @Produce json
+// @Param weights body node.SelectionWeights true "Selection weights"
+// @Success 200 {object} node.SelectionWeights
+// @Router /node/selection-weights [put]
+func (ns *NodeService) handleSetSelectionWeights(c *gin.Context) {
+	var weights node.SelectionWeights
+	if err := c.ShouldBindJSON(&weights); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	if err := ns.peerRegistry.SetSelectionWeights(weights); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid selection weights", err.Error())
+		return
+	}
+
+	if ns.settings != nil {
+		if err := ns.settings.Update(func(s *AppSettings) { s.PeerSelectionWeights = &weights }); err != nil {
+			respondWithMiningError(c, ErrInternal("failed to save selection weights").WithCause(err))
+			return
+		}
+	}
+	c.JSON(http.StatusOK, weights)
+}
+
 // handleListPeers godoc
 // @Summary List registered peers
 // @Description Get a list of all registered peers with their status
 // @Tags peers
 // @Produce json
+// @Param tag query string false "Only list peers with this tag"
 // @Success 200 {array} node.Peer
 // @Router /peers [get]
 func (ns *NodeService) handleListPeers(c *gin.Context) {
+	if tag := c.Query("tag"); tag != "" {
+		c.JSON(http.StatusOK, ns.peerRegistry.ListPeersByTag(tag))
+		return
+	}
 	peers := ns.peerRegistry.ListPeers()
 	c.JSON(http.StatusOK, peers)
 }
 
+// tlsFingerprintRegex matches a normalized SHA-256 certificate fingerprint.
+var tlsFingerprintRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
 // AddPeerRequest is the request body for adding a peer.
 type AddPeerRequest struct {
-	Address string `json:"address" binding:"required"`
-	Name    string `json:"name"`
+	Address string   `json:"address" binding:"required"`
+	Name    string   `json:"name"`
+	Tags    []string `json:"tags"`
+	// TLSFingerprint pins the peer's TLS certificate (hex SHA-256, from its node info)
+	TLSFingerprint string `json:"tlsFingerprint"`
 }
 
 // handleAddPeer godoc
@@ -199,28 +810,211 @@ type AddPeerRequest struct {
 // @Produce json
 // @Param request body AddPeerRequest true "Peer information"
 // @Success 201 {object} node.Peer
+// @Failure 409 {object} APIError "Peer already exists"
 // @Router /peers [post]
 func (ns *NodeService) handleAddPeer(c *gin.Context) {
 	var req AddPeerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	tags, err := node.NormalizePeerTags(req.Tags)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid tags", err.Error())
+		return
+	}
+
+	fingerprint := node.NormalizeFingerprint(req.TLSFingerprint)
+	if fingerprint != "" && !tlsFingerprintRegex.MatchString(fingerprint) {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid TLS fingerprint", "tlsFingerprint must be the 64 hex character SHA-256 of the peer's certificate")
 		return
 	}
 
 	peer := &node.Peer{
-		ID:      "pending-" + req.Address, // Will be updated on handshake
-		Name:    req.Name,
-		Address: req.Address,
-		Role:    node.RoleDual,
-		Score:   50,
+		ID:             "pending-" + req.Address, // Will be updated on handshake
+		Name:           req.Name,
+		Address:        req.Address,
+		Role:           node.RoleDual,
+		Tags:           tags,
+		TLSFingerprint: fingerprint,
+		Score:          50,
 	}
 
 	if err := ns.peerRegistry.AddPeer(peer); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if strings.Contains(err.Error(), "already exists") {
+			respondWithMiningError(c, ErrPeerExists(peer.ID))
+			return
+		}
+		respondWithMiningError(c, ErrInternal("failed to add peer").WithCause(err))
 		return
 	}
 
 	c.JSON(http.StatusCreated, peer)
+	go ns.updatePeerGeo(peer.ID)
+	if ns.config.HopProbeInterval > 0 {
+		go ns.hopProber.ProbePeer(peer.ID)
+	}
+}
+
+// PeerSelectionResponse lists the peers chosen for a job, best first, with the
+// weights their distances were computed with.
+type PeerSelectionResponse struct {
+	Weights node.SelectionWeights `json:"weights"`
+	Peers   []node.PeerSelection  `json:"peers"`
+}
+
+// selectWorkers picks up to n connected, approved worker peers.
+func (ns *NodeService) selectWorkers(n int) PeerSelectionResponse {
+	peers := ns.peerRegistry.SelectPeers(n, func(peer *node.Peer) bool {
+		return peer.Connected &&
+			(peer.Role == node.RoleWorker || peer.Role == node.RoleDual) &&
+			ns.peerRegistry.IsPeerAllowed(peer.ID, peer.PublicKey)
+	})
+	if peers == nil {
+		peers = []node.PeerSelection{}
+	}
+	return PeerSelectionResponse{Weights: ns.peerRegistry.GetSelectionWeights(), Peers: peers}
+}
+
+// handleOptimalPeer godoc
+// @Summary Select the best worker
+// @Description Select the connected, approved worker closest to the ideal by weighted ping, hops, distance and score
+// @Tags peers
+// @Produce json
+// @Success 200 {object} PeerSelectionResponse
+// @Failure 404 {object} APIError "No connected workers"
+// @Router /peers/optimal [get]
+func (ns *NodeService) handleOptimalPeer(c *gin.Context) {
+	selection := ns.selectWorkers(1)
+	if len(selection.Peers) == 0 {
+		respondWithError(c, http.StatusNotFound, ErrCodePeerNotFound, "no connected worker peers available", "")
+		return
+	}
+	c.JSON(http.StatusOK, selection)
+}
+
+// handleNearestPeers godoc
+// @Summary Select the best workers
+// @Description Select up to n connected, approved workers ranked by weighted ping, hops, distance and score
+// @Tags peers
+// @Produce json
+// @Param n query int false "Number of peers (max 100)" default(3)
+// @Success 200 {object} PeerSelectionResponse
+// @Router /peers/nearest [get]
+func (ns *NodeService) handleNearestPeers(c *gin.Context) {
+	n := 3
+	if v := c.Query("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 100 {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "n must be between 1 and 100", "")
+			return
+		}
+		n = parsed
+	}
+	c.JSON(http.StatusOK, ns.selectWorkers(n))
+}
+
+// handleListTags godoc
+// @Summary List peer tags
+// @Description List every tag in use with the number of peers carrying it
+// @Tags peers
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Router /peers/tags [get]
+func (ns *NodeService) handleListTags(c *gin.Context) {
+	c.JSON(http.StatusOK, ns.peerRegistry.TagCounts())
+}
+
+// PeerTagsRequest is the request body for replacing a peer's tags.
+type PeerTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// PeerTagRequest is the request body for adding a tag to a peer.
+type PeerTagRequest struct {
+	Tag string `json:"tag" binding:"required"`
+}
+
+// handleSetPeerTags godoc
+// @Summary Set peer tags
+// @Description Replace the tags (groups) a peer belongs to
+// @Tags peers
+// @Accept json
+// @Produce json
+// @Param id path string true "Peer ID"
+// @Param request body PeerTagsRequest true "Tags"
+// @Success 200 {object} node.Peer
+// @Failure 400 {object} APIError "Invalid tag"
+// @Failure 404 {object} APIError "Peer not found"
+// @Router /peers/{id}/tags [put]
+func (ns *NodeService) handleSetPeerTags(c *gin.Context) {
+	var req PeerTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	ns.updatePeerTags(c, func(peerID string) error {
+		return ns.peerRegistry.SetTags(peerID, req.Tags)
+	})
+}
+
+// handleAddPeerTag godoc
+// @Summary Tag a peer
+// @Description Add a peer to a group
+// @Tags peers
+// @Accept json
+// @Produce json
+// @Param id path string true "Peer ID"
+// @Param request body PeerTagRequest true "Tag"
+// @Success 200 {object} node.Peer
+// @Failure 400 {object} APIError "Invalid tag"
+// @Failure 404 {object} APIError "Peer not found"
+// @Router /peers/{id}/tags [post]
+func (ns *NodeService) handleAddPeerTag(c *gin.Context) {
+	var req PeerTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	ns.updatePeerTags(c, func(peerID string) error {
+		return ns.peerRegistry.AddTag(peerID, req.Tag)
+	})
+}
+
+// handleRemovePeerTag godoc
+// @Summary Untag a peer
+// @Description Remove a peer from a group
+// @Tags peers
+// @Produce json
+// @Param id path string true "Peer ID"
+// @Param tag path string true "Tag"
+// @Success 200 {object} node.Peer
+// @Failure 404 {object} APIError "Peer not found"
+// @Router /peers/{id}/tags/{tag} [delete]
+func (ns *NodeService) handleRemovePeerTag(c *gin.Context) {
+	tag := c.Param("tag")
+	ns.updatePeerTags(c, func(peerID string) error {
+		return ns.peerRegistry.RemoveTag(peerID, tag)
+	})
+}
+
+// updatePeerTags applies a tag change to the peer in the path and responds
+// with the updated peer.
+func (ns *NodeService) updatePeerTags(c *gin.Context, update func(peerID string) error) {
+	peerID := c.Param("id")
+	if ns.peerRegistry.GetPeer(peerID) == nil {
+		respondWithMiningError(c, ErrPeerNotFound(peerID))
+		return
+	}
+	if err := update(peerID); err != nil {
+		if strings.Contains(err.Error(), "invalid tag") {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid tag", err.Error())
+			return
+		}
+		respondWithMiningError(c, nodeError(err, peerID, "update tags"))
+		return
+	}
+	c.JSON(http.StatusOK, ns.peerRegistry.GetPeer(peerID))
 }
 
 // handleGetPeer godoc
@@ -235,7 +1029,7 @@ func (ns *NodeService) handleGetPeer(c *gin.Context) {
 	peerID := c.Param("id")
 	peer := ns.peerRegistry.GetPeer(peerID)
 	if peer == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "peer not found"})
+		respondWithMiningError(c, ErrPeerNotFound(peerID))
 		return
 	}
 	c.JSON(http.StatusOK, peer)
@@ -252,7 +1046,7 @@ func (ns *NodeService) handleGetPeer(c *gin.Context) {
 func (ns *NodeService) handleRemovePeer(c *gin.Context) {
 	peerID := c.Param("id")
 	if err := ns.peerRegistry.RemovePeer(peerID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithMiningError(c, nodeError(err, peerID, "remove peer"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "peer removed"})
@@ -271,11 +1065,7 @@ func (ns *NodeService) handlePingPeer(c *gin.Context) {
 	peerID := c.Param("id")
 	rtt, err := ns.controller.PingPeer(peerID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not connected") {
-			respondWithError(c, http.StatusNotFound, "PEER_NOT_FOUND", "peer not found or not connected", err.Error())
-			return
-		}
-		respondWithError(c, http.StatusInternalServerError, ErrCodeInternal, "ping failed", err.Error())
+		respondWithMiningError(c, nodeError(err, peerID, "ping"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"rtt_ms": rtt})
@@ -293,11 +1083,7 @@ func (ns *NodeService) handlePingPeer(c *gin.Context) {
 func (ns *NodeService) handleConnectPeer(c *gin.Context) {
 	peerID := c.Param("id")
 	if err := ns.controller.ConnectToPeer(peerID); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondWithError(c, http.StatusNotFound, "PEER_NOT_FOUND", "peer not found", err.Error())
-			return
-		}
-		respondWithError(c, http.StatusInternalServerError, ErrCodeConnectionFailed, "connection failed", err.Error())
+		respondWithMiningError(c, nodeError(err, peerID, "connect"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "connected"})
@@ -315,11 +1101,11 @@ func (ns *NodeService) handleDisconnectPeer(c *gin.Context) {
 	peerID := c.Param("id")
 	if err := ns.controller.DisconnectFromPeer(peerID); err != nil {
 		// Make disconnect idempotent - if peer not connected, still return success
-		if strings.Contains(err.Error(), "not connected") {
+		if errors.Is(err, node.ErrPeerNotConnected) {
 			c.JSON(http.StatusOK, gin.H{"status": "disconnected"})
 			return
 		}
-		respondWithError(c, http.StatusInternalServerError, ErrCodeInternal, "disconnect failed", err.Error())
+		respondWithMiningError(c, nodeError(err, peerID, "disconnect"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "disconnected"})
@@ -327,7 +1113,7 @@ func (ns *NodeService) handleDisconnectPeer(c *gin.Context) {
 
 // handleRemoteStats godoc
 // @Summary Get stats from all remote peers
-// @Description Fetch mining statistics from all connected peers
+// @Description Fetch mining statistics from all connected peers; workers pushing stats are answered from the cache
 // @Tags remote
 // @Produce json
 // @Success 200 {object} map[string]node.StatsPayload
@@ -349,12 +1135,50 @@ func (ns *NodeService) handlePeerStats(c *gin.Context) {
 	peerID := c.Param("peerId")
 	stats, err := ns.controller.GetRemoteStats(peerID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithMiningError(c, nodeError(err, peerID, "get stats"))
 		return
 	}
 	c.JSON(http.StatusOK, stats)
 }
 
+// StatsPushRequest is the request body for adjusting a worker's stats push.
+type StatsPushRequest struct {
+	// IntervalSeconds is how often the worker pushes its stats; 0 stops pushing
+	IntervalSeconds int `json:"intervalSeconds"`
+}
+
+// handleSetStatsPush godoc
+// @Summary Set a peer's stats push interval
+// @Description Ask a worker that supports pushed stats to send them at a new interval, or to stop so it is polled again
+// @Tags remote
+// @Accept json
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Param request body StatsPushRequest true "Push interval"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} APIError "Invalid interval"
+// @Router /remote/{peerId}/stats-push [put]
+func (ns *NodeService) handleSetStatsPush(c *gin.Context) {
+	peerID := c.Param("peerId")
+	var req StatsPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	maxSeconds := int(node.MaxStatsPushInterval / time.Second)
+	if req.IntervalSeconds < 0 || req.IntervalSeconds > maxSeconds {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid interval",
+			fmt.Sprintf("intervalSeconds must be between 0 and %d", maxSeconds))
+		return
+	}
+
+	if err := ns.controller.SetStatsPushInterval(peerID, time.Duration(req.IntervalSeconds)*time.Second); err != nil {
+		respondWithMiningError(c, nodeError(err, peerID, "set stats push"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"peerId": peerID, "intervalSeconds": req.IntervalSeconds})
+}
+
 // RemoteStartRequest is the request body for starting a remote miner.
 type RemoteStartRequest struct {
 	MinerType string          `json:"minerType" binding:"required"`
@@ -371,17 +1195,21 @@ type RemoteStartRequest struct {
 // @Param peerId path string true "Peer ID"
 // @Param request body RemoteStartRequest true "Start parameters"
 // @Success 200 {object} map[string]string
+// @Success 202 {object} RemoteQueuedResponse "Peer offline; command queued for delivery on reconnect"
 // @Router /remote/{peerId}/start [post]
 func (ns *NodeService) handleRemoteStart(c *gin.Context) {
 	peerID := c.Param("peerId")
 	var req RemoteStartRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
 		return
 	}
 
 	if err := ns.controller.StartRemoteMiner(peerID, req.MinerType, req.ProfileID, req.Config); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if respondQueued(c, err) {
+			return
+		}
+		respondWithMiningError(c, nodeError(err, peerID, "start miner"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "miner started"})
@@ -401,52 +1229,359 @@ type RemoteStopRequest struct {
 // @Param peerId path string true "Peer ID"
 // @Param request body RemoteStopRequest true "Stop parameters"
 // @Success 200 {object} map[string]string
+// @Success 202 {object} RemoteQueuedResponse "Peer offline; command queued for delivery on reconnect"
 // @Router /remote/{peerId}/stop [post]
 func (ns *NodeService) handleRemoteStop(c *gin.Context) {
 	peerID := c.Param("peerId")
 	var req RemoteStopRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
 		return
 	}
 
 	if err := ns.controller.StopRemoteMiner(peerID, req.MinerName); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if respondQueued(c, err) {
+			return
+		}
+		respondWithMiningError(c, nodeError(err, peerID, "stop miner"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "miner stopped"})
 }
 
+// TagOperationResponse reports the per-peer outcome of a group operation.
+type TagOperationResponse struct {
+	Tag     string             `json:"tag"`
+	Results []node.GroupResult `json:"results"`
+}
+
+// handleTagStart godoc
+// @Summary Start miner across a tag
+// @Description Start a miner on every peer with the tag. Offline peers are queued for delivery on reconnect.
+// @Tags remote
+// @Accept json
+// @Produce json
+// @Param tag path string true "Peer tag"
+// @Param request body RemoteStartRequest true "Start parameters"
+// @Success 200 {object} TagOperationResponse
+// @Failure 404 {object} APIError "No peers with the tag"
+// @Router /remote/tags/{tag}/start [post]
+func (ns *NodeService) handleTagStart(c *gin.Context) {
+	var req RemoteStartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	ns.runTagOperation(c, func(tag string) []node.GroupResult {
+		return ns.controller.StartMinerOnTag(tag, req.MinerType, req.ProfileID, req.Config)
+	})
+}
+
+// handleTagStop godoc
+// @Summary Stop miner across a tag
+// @Description Stop a miner on every peer with the tag. Offline peers are queued for delivery on reconnect.
+// @Tags remote
+// @Accept json
+// @Produce json
+// @Param tag path string true "Peer tag"
+// @Param request body RemoteStopRequest true "Stop parameters"
+// @Success 200 {object} TagOperationResponse
+// @Failure 404 {object} APIError "No peers with the tag"
+// @Router /remote/tags/{tag}/stop [post]
+func (ns *NodeService) handleTagStop(c *gin.Context) {
+	var req RemoteStopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	ns.runTagOperation(c, func(tag string) []node.GroupResult {
+		return ns.controller.StopMinerOnTag(tag, req.MinerName)
+	})
+}
+
+// runTagOperation runs op against the tag in the path. Individual peer
+// failures are reported in the results rather than failing the request.
+func (ns *NodeService) runTagOperation(c *gin.Context, op func(tag string) []node.GroupResult) {
+	tag := c.Param("tag")
+	results := op(tag)
+	if len(results) == 0 {
+		respondWithError(c, http.StatusNotFound, ErrCodePeerNotFound, "no peers with tag "+tag, "")
+		return
+	}
+	c.JSON(http.StatusOK, TagOperationResponse{Tag: tag, Results: results})
+}
+
+// RemoteQueuedResponse is returned when a command was queued for an offline peer.
+type RemoteQueuedResponse struct {
+	Status    string    `json:"status"` // Always "queued"
+	CommandID string    `json:"commandId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// respondQueued responds 202 Accepted if err reports a command queued in the
+// outbox, returning whether it handled the response.
+func respondQueued(c *gin.Context, err error) bool {
+	var queued *node.CommandQueuedError
+	if !errors.As(err, &queued) {
+		return false
+	}
+	c.JSON(http.StatusAccepted, RemoteQueuedResponse{
+		Status:    "queued",
+		CommandID: queued.Command.ID,
+		ExpiresAt: queued.Command.ExpiresAt,
+	})
+	return true
+}
+
+// handleOutboxCounts godoc
+// @Summary Get queued command counts
+// @Description Get the number of commands queued for each offline peer
+// @Tags remote
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Router /remote/outbox [get]
+func (ns *NodeService) handleOutboxCounts(c *gin.Context) {
+	c.JSON(http.StatusOK, ns.controller.Outbox().Counts())
+}
+
+// handlePeerOutbox godoc
+// @Summary List queued commands for a peer
+// @Description List the unexpired commands waiting for a peer to reconnect
+// @Tags remote
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Success 200 {array} node.QueuedCommand
+// @Router /remote/{peerId}/outbox [get]
+func (ns *NodeService) handlePeerOutbox(c *gin.Context) {
+	c.JSON(http.StatusOK, ns.controller.Outbox().Pending(c.Param("peerId")))
+}
+
+// handleClearPeerOutbox godoc
+// @Summary Discard queued commands for a peer
+// @Description Discard all commands waiting for a peer to reconnect
+// @Tags remote
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Success 200 {object} map[string]int
+// @Router /remote/{peerId}/outbox [delete]
+func (ns *NodeService) handleClearPeerOutbox(c *gin.Context) {
+	cleared, err := ns.controller.Outbox().Clear(c.Param("peerId"))
+	if err != nil {
+		respondWithMiningError(c, ErrInternal("failed to clear outbox").WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cleared": cleared})
+}
+
 // handleRemoteLogs godoc
 // @Summary Get logs from remote miner
-// @Description Retrieve console logs from a miner on a remote peer
+// @Description Retrieve a page of console logs from a miner on a remote peer, newest last. Lines are filtered on the worker; X-Has-More and X-Next-Offset headers point to the next, older page.
 // @Tags remote
 // @Produce json
 // @Param peerId path string true "Peer ID"
 // @Param miner path string true "Miner Name"
 // @Param lines query int false "Number of lines (max 10000)" default(100)
+// @Param offset query int false "Newest matching lines to skip, from a previous X-Next-Offset"
+// @Param grep query string false "Regular expression lines must match"
+// @Param ignore_case query bool false "Match the grep pattern case-insensitively"
+// @Param invert query bool false "Return lines that do NOT match the grep pattern"
 // @Success 200 {array} string
+// @Header 200 {boolean} X-Has-More "Older matching lines are available"
+// @Header 200 {integer} X-Next-Offset "Offset of the next, older page"
+// @Failure 400 {object} APIError "Invalid filter or offset"
 // @Router /remote/{peerId}/logs/{miner} [get]
 func (ns *NodeService) handleRemoteLogs(c *gin.Context) {
 	peerID := c.Param("peerId")
-	minerName := c.Param("miner")
-	lines := 100
-	const maxLines = 10000 // Prevent resource exhaustion
+	req := node.GetLogsPayload{
+		MinerName:  c.Param("miner"),
+		Lines:      100,
+		Grep:       c.Query("grep"),
+		IgnoreCase: queryBool(c, "ignore_case"),
+		Invert:     queryBool(c, "invert"),
+	}
 	if l := c.Query("lines"); l != "" {
 		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
-			lines = parsed
-			if lines > maxLines {
-				lines = maxLines
-			}
+			req.Lines = min(parsed, node.MaxRemoteLogLines)
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		offset, err := strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid offset", "offset must be a non-negative integer")
+			return
+		}
+		req.Offset = offset
+	}
+	// Reject bad patterns here rather than as a failed remote command
+	if _, err := NewLogFilter(req.Grep, "", req.IgnoreCase, req.Invert, 0); err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid log filter", err.Error())
+		return
+	}
+
+	logs, err := ns.controller.GetRemoteLogsPage(peerID, req)
+	if err != nil {
+		respondWithMiningError(c, nodeError(err, peerID, "get logs"))
+		return
+	}
+	c.Header("X-Has-More", strconv.FormatBool(logs.HasMore))
+	if logs.HasMore {
+		c.Header("X-Next-Offset", strconv.Itoa(logs.NextOffset))
+	}
+	if logs.Lines == nil {
+		logs.Lines = []string{}
+	}
+	c.JSON(http.StatusOK, logs.Lines)
+}
+
+// RemoteInstallRequest is the request body for provisioning a miner on a remote peer.
+type RemoteInstallRequest struct {
+	MinerType string `json:"minerType" binding:"required"`
+}
+
+// RemoteInstallResponse reports the result of a remote install or update.
+type RemoteInstallResponse struct {
+	Status    string                        `json:"status"`
+	MinerType string                        `json:"minerType"`
+	Version   string                        `json:"version,omitempty"`
+	Progress  []node.InstallProgressPayload `json:"progress,omitempty"`
+}
+
+// handleRemoteInstall godoc
+// @Summary Install miner on remote peer
+// @Description Install a miner type on a remote peer. Blocks until the worker finishes; the stages it reported are returned in progress.
+// @Tags remote
+// @Accept json
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Param request body RemoteInstallRequest true "Miner type"
+// @Success 200 {object} RemoteInstallResponse
+// @Router /remote/{peerId}/install [post]
+func (ns *NodeService) handleRemoteInstall(c *gin.Context) {
+	ns.provisionRemote(c, "install", "installed", ns.controller.InstallRemoteMiner)
+}
+
+// handleRemoteUpdate godoc
+// @Summary Update miner on remote peer
+// @Description Update a miner type on a remote peer to the latest version, if newer.
+// @Tags remote
+// @Accept json
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Param request body RemoteInstallRequest true "Miner type"
+// @Success 200 {object} RemoteInstallResponse
+// @Router /remote/{peerId}/update [post]
+func (ns *NodeService) handleRemoteUpdate(c *gin.Context) {
+	ns.provisionRemote(c, "update", "updated", ns.controller.UpdateRemoteMiner)
+}
+
+// provisionRemote runs a remote install or update, collecting and logging progress.
+func (ns *NodeService) provisionRemote(c *gin.Context, operation, status string, provision func(peerID, minerType string, progress func(node.InstallProgressPayload)) (string, error)) {
+	peerID := c.Param("peerId")
+	var req RemoteInstallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+
+	var mu sync.Mutex
+	var progress []node.InstallProgressPayload
+	version, err := provision(peerID, req.MinerType, func(p node.InstallProgressPayload) {
+		logging.Info("remote "+operation+" progress", logging.Fields{"peer": peerID, "miner": p.MinerType, "stage": p.Stage})
+		mu.Lock()
+		progress = append(progress, p)
+		mu.Unlock()
+	})
+	if err != nil {
+		respondWithMiningError(c, nodeError(err, peerID, operation+" miner"))
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	c.JSON(http.StatusOK, RemoteInstallResponse{
+		Status:    status,
+		MinerType: req.MinerType,
+		Version:   version,
+		Progress:  progress,
+	})
+}
+
+// handleRemoteUninstall godoc
+// @Summary Uninstall miner on remote peer
+// @Description Stop and remove a miner type on a remote peer
+// @Tags remote
+// @Accept json
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Param request body RemoteInstallRequest true "Miner type"
+// @Success 200 {object} map[string]string
+// @Router /remote/{peerId}/uninstall [post]
+func (ns *NodeService) handleRemoteUninstall(c *gin.Context) {
+	peerID := c.Param("peerId")
+	var req RemoteInstallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+
+	if err := ns.controller.UninstallRemoteMiner(peerID, req.MinerType); err != nil {
+		respondWithMiningError(c, nodeError(err, peerID, "uninstall miner"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "uninstalled"})
+}
+
+// handleRemoteProfiles godoc
+// @Summary List profiles on remote peer
+// @Description List the mining profiles stored on a remote peer. Secrets in the configs are masked.
+// @Tags remote
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Success 200 {array} MiningProfile
+// @Router /remote/{peerId}/profiles [get]
+func (ns *NodeService) handleRemoteProfiles(c *gin.Context) {
+	peerID := c.Param("peerId")
+	raw, err := ns.controller.ListRemoteProfiles(peerID)
+	if err != nil {
+		respondWithMiningError(c, nodeError(err, peerID, "list profiles"))
+		return
+	}
+
+	profiles := make([]MiningProfile, 0, len(raw))
+	for _, data := range raw {
+		var profile MiningProfile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			respondWithMiningError(c, ErrRemoteCommandFailed("list profiles").WithCause(err))
+			return
 		}
+		profiles = append(profiles, profile)
 	}
+	c.JSON(http.StatusOK, profiles)
+}
 
-	logs, err := ns.controller.GetRemoteLogs(peerID, minerName, lines)
+// handleRemoteProfile godoc
+// @Summary Get profile from remote peer
+// @Description Get a single mining profile stored on a remote peer. Secrets in the config are masked.
+// @Tags remote
+// @Produce json
+// @Param peerId path string true "Peer ID"
+// @Param id path string true "Profile ID"
+// @Success 200 {object} MiningProfile
+// @Router /remote/{peerId}/profiles/{id} [get]
+func (ns *NodeService) handleRemoteProfile(c *gin.Context) {
+	peerID := c.Param("peerId")
+	raw, err := ns.controller.GetRemoteProfile(peerID, c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWithMiningError(c, nodeError(err, peerID, "get profile"))
+		return
+	}
+
+	var profile MiningProfile
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		respondWithMiningError(c, ErrRemoteCommandFailed("get profile").WithCause(err))
 		return
 	}
-	c.JSON(http.StatusOK, logs)
+	c.JSON(http.StatusOK, profile)
 }
 
 // AuthModeResponse is the response for auth mode endpoints.
@@ -454,20 +1589,99 @@ type AuthModeResponse struct {
 	Mode string `json:"mode"`
 }
 
+// requireFleet responds with an error and returns false if fleet management is unavailable.
+func (ns *NodeService) requireFleet(c *gin.Context) bool {
+	if ns.fleet == nil {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "fleet management not available", "no profile manager is configured")
+		return false
+	}
+	return true
+}
+
+// handleGetFleetDesiredState godoc
+// @Summary Get fleet desired state
+// @Description Get which profiles should be running on each worker or role
+// @Tags fleet
+// @Produce json
+// @Success 200 {object} FleetDesiredState
+// @Router /fleet/desired-state [get]
+func (ns *NodeService) handleGetFleetDesiredState(c *gin.Context) {
+	if !ns.requireFleet(c) {
+		return
+	}
+	c.JSON(http.StatusOK, ns.fleet.DesiredState())
+}
+
+// handleSetFleetDesiredState godoc
+// @Summary Set fleet desired state
+// @Description Replace which profiles should be running on each worker (by peer ID) or role. Referenced profiles must exist on this node.
+// @Tags fleet
+// @Accept json
+// @Produce json
+// @Param state body FleetDesiredState true "Desired state"
+// @Success 200 {object} FleetDesiredState
+// @Router /fleet/desired-state [put]
+func (ns *NodeService) handleSetFleetDesiredState(c *gin.Context) {
+	if !ns.requireFleet(c) {
+		return
+	}
+	var state FleetDesiredState
+	if err := c.ShouldBindJSON(&state); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	if err := ns.fleet.validate(state); err != nil {
+		respondWithMiningError(c, ErrInvalidConfig(err.Error()))
+		return
+	}
+	if err := ns.fleet.SetDesiredState(state); err != nil {
+		respondWithMiningError(c, ErrInternal("failed to save fleet desired state").WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, state)
+}
+
+// handleFleetStatus godoc
+// @Summary Get fleet status
+// @Description Compare desired and actual state of each worker and list the actions needed to converge, without executing them
+// @Tags fleet
+// @Produce json
+// @Success 200 {object} FleetStatus
+// @Router /fleet/status [get]
+func (ns *NodeService) handleFleetStatus(c *gin.Context) {
+	if !ns.requireFleet(c) {
+		return
+	}
+	c.JSON(http.StatusOK, ns.fleet.Status())
+}
+
+// handleFleetReconcile godoc
+// @Summary Reconcile fleet now
+// @Description Run a reconcile pass immediately, issuing deploy/start/stop commands to converge workers
+// @Tags fleet
+// @Produce json
+// @Param dryRun query bool false "Only report planned actions"
+// @Success 200 {object} FleetStatus
+// @Router /fleet/reconcile [post]
+func (ns *NodeService) handleFleetReconcile(c *gin.Context) {
+	if !ns.requireFleet(c) {
+		return
+	}
+	dryRun := c.Query("dryRun") == "true" || c.Query("dryRun") == "1"
+	c.JSON(http.StatusOK, ns.fleet.Reconcile(dryRun))
+}
+
 // handleGetAuthMode godoc
 // @Summary Get peer authentication mode
 // @Description Get the current peer authentication mode (open or allowlist)
 // @Tags peers
 // @Produce json
 // @Success 200 {object} AuthModeResponse
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /node/auth-mode [get]
 // @Router /peers/auth/mode [get]
 func (ns *NodeService) handleGetAuthMode(c *gin.Context) {
-	mode := ns.peerRegistry.GetAuthMode()
-	modeStr := "open"
-	if mode == node.PeerAuthAllowlist {
-		modeStr = "allowlist"
-	}
-	c.JSON(http.StatusOK, AuthModeResponse{Mode: modeStr})
+	c.JSON(http.StatusOK, AuthModeResponse{Mode: ns.peerRegistry.GetAuthMode().String()})
 }
 
 // SetAuthModeRequest is the request for setting auth mode.
@@ -484,26 +1698,26 @@ type SetAuthModeRequest struct {
 // @Param request body SetAuthModeRequest true "Auth mode (open or allowlist)"
 // @Success 200 {object} AuthModeResponse
 // @Failure 400 {object} APIError "Invalid mode"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /node/auth-mode [put]
 // @Router /peers/auth/mode [put]
 func (ns *NodeService) handleSetAuthMode(c *gin.Context) {
 	var req SetAuthModeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
 		return
 	}
 
-	var mode node.PeerAuthMode
-	switch req.Mode {
-	case "open":
-		mode = node.PeerAuthOpen
-	case "allowlist":
-		mode = node.PeerAuthAllowlist
-	default:
+	mode, err := node.ParsePeerAuthMode(req.Mode)
+	if err != nil {
 		respondWithError(c, http.StatusBadRequest, "INVALID_MODE", "mode must be 'open' or 'allowlist'", "")
 		return
 	}
 
-	ns.peerRegistry.SetAuthMode(mode)
+	if err := ns.peerRegistry.SetAuthMode(mode); err != nil {
+		respondWithMiningError(c, ErrInternal("auth mode changed but could not be saved").WithCause(err))
+		return
+	}
 	c.JSON(http.StatusOK, AuthModeResponse{Mode: req.Mode})
 }
 
@@ -518,6 +1732,8 @@ type AllowlistResponse struct {
 // @Tags peers
 // @Produce json
 // @Success 200 {object} AllowlistResponse
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /node/allowlist [get]
 // @Router /peers/auth/allowlist [get]
 func (ns *NodeService) handleListAllowlist(c *gin.Context) {
 	keys := ns.peerRegistry.ListAllowedPublicKeys()
@@ -531,27 +1747,32 @@ type AddAllowlistRequest struct {
 
 // handleAddToAllowlist godoc
 // @Summary Add public key to allowlist
-// @Description Add a public key to the peer allowlist
+// @Description Add a base64 X25519 public key, as shown in a node's identity, to the peer allowlist
 // @Tags peers
 // @Accept json
 // @Produce json
 // @Param request body AddAllowlistRequest true "Public key to allow"
 // @Success 201 {object} map[string]string
-// @Failure 400 {object} APIError "Invalid request"
+// @Failure 400 {object} APIError "Invalid public key"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /node/allowlist [post]
 // @Router /peers/auth/allowlist [post]
 func (ns *NodeService) handleAddToAllowlist(c *gin.Context) {
 	var req AddAllowlistRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
 		return
 	}
 
-	if len(req.PublicKey) < 16 {
-		respondWithError(c, http.StatusBadRequest, "INVALID_KEY", "public key too short", "")
+	if err := node.ValidatePublicKey(req.PublicKey); err != nil {
+		respondWithError(c, http.StatusBadRequest, "INVALID_KEY", err.Error(), "")
 		return
 	}
 
-	ns.peerRegistry.AllowPublicKey(req.PublicKey)
+	if err := ns.peerRegistry.AllowPublicKey(req.PublicKey); err != nil {
+		respondWithMiningError(c, ErrInternal("public key allowed but could not be saved").WithCause(err))
+		return
+	}
 	c.JSON(http.StatusCreated, gin.H{"status": "added"})
 }
 
@@ -570,6 +1791,33 @@ func (ns *NodeService) handleRemoveFromAllowlist(c *gin.Context) {
 		return
 	}
 
-	ns.peerRegistry.RevokePublicKey(key)
+	ns.revokePublicKey(c, key)
+}
+
+// handleRevokeFromAllowlist godoc
+// @Summary Remove public key from allowlist
+// @Description Remove a public key from the peer allowlist. The key is a query parameter as base64 keys may contain '/'.
+// @Tags node
+// @Produce json
+// @Param publicKey query string true "Public key to remove"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} APIError "Missing public key"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /node/allowlist [delete]
+func (ns *NodeService) handleRevokeFromAllowlist(c *gin.Context) {
+	key := c.Query("publicKey")
+	if key == "" {
+		respondWithError(c, http.StatusBadRequest, "MISSING_KEY", "public key required", "")
+		return
+	}
+	ns.revokePublicKey(c, key)
+}
+
+// revokePublicKey removes key from the allowlist and responds.
+func (ns *NodeService) revokePublicKey(c *gin.Context, key string) {
+	if err := ns.peerRegistry.RevokePublicKey(key); err != nil {
+		respondWithMiningError(c, ErrInternal("public key revoked but could not be saved").WithCause(err))
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"status": "removed"})
 }
@@ -0,0 +1,269 @@
+package mining
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Snider/Mining/pkg/node"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{"peer not found", &node.NodeError{Code: node.CodePeerNotFound, Message: "peer abc not found"}, ErrCodePeerNotFound, http.StatusNotFound},
+		{"peer not connected", &node.NodeError{Code: node.CodePeerNotConnected, Message: "peer abc not connected"}, ErrCodePeerNotConnected, http.StatusConflict},
+		{"handshake", &node.NodeError{Code: node.CodeHandshakeFailed, Message: "handshake failed", Cause: errors.New("rejected")}, ErrCodeHandshakeFailed, http.StatusBadGateway},
+		{"dial failure", &node.NodeError{Code: node.CodeConnectFailed, Message: "failed to connect to peer", Cause: errors.New("dial tcp: refused")}, ErrCodeConnectionFailed, http.StatusServiceUnavailable},
+		{"transport", fmt.Errorf("connect: %w", node.ErrTransportNotRunning), ErrCodeTransportNotStarted, http.StatusServiceUnavailable},
+		{"identity", node.ErrIdentityNotInitialized, ErrCodeNodeNotInitialized, http.StatusConflict},
+		{"timeout", &node.NodeError{Code: node.CodeTimeout, Message: "request timeout"}, ErrCodeTimeout, http.StatusGatewayTimeout},
+		{"send failure", &node.NodeError{Code: node.CodeSendFailed, Message: "failed to send message", Cause: errors.New("broken pipe")}, ErrCodeConnectionFailed, http.StatusServiceUnavailable},
+		{"send to a dropped peer", &node.NodeError{Code: node.CodeSendFailed, Message: "failed to send message", Cause: node.ErrPeerNotConnected}, ErrCodePeerNotConnected, http.StatusConflict},
+		{"protocol error", &node.ProtocolError{Code: node.ErrCodeNotFound, Message: "miner not found"}, ErrCodeRemoteCommandFailed, http.StatusBadGateway},
+		{"ack failure", errors.New("miner start failed: no such profile"), ErrCodeRemoteCommandFailed, http.StatusBadGateway},
+		{"other", errors.New("boom"), ErrCodeInternalError, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nodeError(tt.err, "abc", "test")
+			if got.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, got.Code)
+			}
+			if got.StatusCode() != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, got.StatusCode())
+			}
+			if !errors.Is(got, tt.err) {
+				t.Error("cause should be preserved")
+			}
+		})
+	}
+}
+
+func TestNodeErrorPeerNotConnected(t *testing.T) {
+	lastSeen := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := &node.PeerNotConnectedError{
+		PeerID:   "abc",
+		LastSeen: lastSeen,
+		Cause:    &node.NodeError{Code: node.CodeConnectFailed, Message: "failed to connect to peer", Cause: errors.New("dial tcp: connection refused")},
+	}
+
+	got := nodeError(fmt.Errorf("wrapped: %w", err), "abc", "get stats")
+	if got.Code != ErrCodePeerNotConnected {
+		t.Fatalf("Expected code %s, got %s", ErrCodePeerNotConnected, got.Code)
+	}
+	if got.StatusCode() != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, got.StatusCode())
+	}
+	if !strings.Contains(got.Details, "2025-01-02T03:04:05Z") {
+		t.Errorf("Expected last seen in details, got %q", got.Details)
+	}
+	if !strings.Contains(got.Suggestion, "/peers/abc/connect") {
+		t.Errorf("Expected connect suggestion, got %q", got.Suggestion)
+	}
+
+	// Never seen
+	err.LastSeen = time.Time{}
+	if got := nodeError(err, "abc", "get stats"); !strings.Contains(got.Details, "never") {
+		t.Errorf("Expected never-seen details, got %q", got.Details)
+	}
+
+	// Handshake failures are still reported as such
+	err.Cause = &node.NodeError{Code: node.CodeHandshakeFailed, Message: "handshake failed", Cause: errors.New("rejected")}
+	if got := nodeError(err, "abc", "get stats"); got.Code != ErrCodeHandshakeFailed {
+		t.Errorf("Expected code %s, got %s", ErrCodeHandshakeFailed, got.Code)
+	}
+}
+
+func TestNodeServiceConfigFromEnv(t *testing.T) {
+	t.Setenv("MINING_P2P_LISTEN", "")
+	t.Setenv("MINING_P2P_AUTOSTART", "")
+
+	cfg := NodeServiceConfigFromEnv()
+	if cfg.ListenAddr != node.DefaultTransportConfig().ListenAddr {
+		t.Errorf("Expected default listen addr, got %s", cfg.ListenAddr)
+	}
+	if !cfg.AutoStart {
+		t.Error("Expected auto-start enabled by default")
+	}
+
+	t.Setenv("MINING_P2P_LISTEN", "127.0.0.1:19091")
+	t.Setenv("MINING_P2P_AUTOSTART", "false")
+
+	cfg = NodeServiceConfigFromEnv()
+	if cfg.ListenAddr != "127.0.0.1:19091" {
+		t.Errorf("Expected listen addr from env, got %s", cfg.ListenAddr)
+	}
+	if cfg.AutoStart {
+		t.Error("Expected auto-start disabled from env")
+	}
+	if cfg.StatsPushInterval != 0 {
+		t.Errorf("Expected stats push disabled by default, got %v", cfg.StatsPushInterval)
+	}
+
+	t.Setenv("MINING_P2P_STATS_PUSH_INTERVAL", "15s")
+	if cfg = NodeServiceConfigFromEnv(); cfg.StatsPushInterval != 15*time.Second {
+		t.Errorf("Expected stats push interval from env, got %v", cfg.StatsPushInterval)
+	}
+}
+
+func TestNodeServiceAuthEndpoints(t *testing.T) {
+	dir := t.TempDir()
+	nm, err := node.NewNodeManagerWithPaths(filepath.Join(dir, "private.key"), filepath.Join(dir, "node.json"))
+	if err != nil {
+		t.Fatalf("failed to create node manager: %v", err)
+	}
+	if err := nm.GenerateIdentity("peer", node.RoleWorker); err != nil {
+		t.Fatalf("failed to generate identity: %v", err)
+	}
+	key := nm.GetIdentity().PublicKey
+	pr, err := node.NewPeerRegistryWithPath(filepath.Join(dir, "peers.json"))
+	if err != nil {
+		t.Fatalf("failed to create peer registry: %v", err)
+	}
+	defer pr.Close()
+
+	ns := &NodeService{peerRegistry: pr}
+	router := gin.New()
+	ns.SetupRoutes(router.Group(""))
+	do := func(method, target, body, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	const local = "127.0.0.1:1234"
+
+	if w := do("PUT", "/node/auth-mode", `{"mode":"allowlist"}`, "192.0.2.1:1234"); w.Code != http.StatusForbidden {
+		t.Fatalf("expected remote callers to be refused without API auth, got %d", w.Code)
+	}
+	if w := do("PUT", "/node/auth-mode", `{"mode":"allowlist"}`, local); w.Code != http.StatusOK {
+		t.Fatalf("expected mode to be set, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do("POST", "/node/allowlist", `{"publicKey":"c2hvcnQ="}`, local); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a malformed key to be rejected, got %d", w.Code)
+	}
+	if w := do("POST", "/node/allowlist", `{"publicKey":"`+key+`"}`, local); w.Code != http.StatusCreated {
+		t.Fatalf("expected key to be added, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var list AllowlistResponse
+	w := do("GET", "/node/allowlist", "", local)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.PublicKeys) != 1 || list.PublicKeys[0] != key {
+		t.Errorf("expected the added key to be listed, got %s", w.Body.String())
+	}
+
+	if w := do("DELETE", "/node/allowlist?publicKey="+url.QueryEscape(key), "", local); w.Code != http.StatusOK {
+		t.Errorf("expected key to be revoked, got %d", w.Code)
+	}
+	if keys := pr.ListAllowedPublicKeys(); len(keys) != 0 {
+		t.Errorf("expected an empty allowlist, got %v", keys)
+	}
+	if pr.GetAuthMode() != node.PeerAuthAllowlist {
+		t.Errorf("expected allowlist mode, got %s", pr.GetAuthMode())
+	}
+}
+
+func TestNodeServiceIdentityRoutesRequireAdmin(t *testing.T) {
+	dir := t.TempDir()
+	nm, err := node.NewNodeManagerWithPaths(filepath.Join(dir, "private.key"), filepath.Join(dir, "node.json"))
+	if err != nil {
+		t.Fatalf("failed to create node manager: %v", err)
+	}
+	if err := nm.GenerateIdentity("rig", node.RoleWorker); err != nil {
+		t.Fatalf("failed to generate identity: %v", err)
+	}
+	before := nm.GetIdentity().PublicKey
+
+	ns := &NodeService{nodeManager: nm}
+	router := gin.New()
+	ns.SetupRoutes(router.Group(""))
+
+	routes := []struct {
+		method, path string
+	}{
+		{"GET", "/node/identity/export"},
+		{"POST", "/node/identity/import?force=true"},
+		{"POST", "/node/rotate-key"},
+	}
+	for _, route := range routes {
+		req := httptest.NewRequest(route.method, route.path, strings.NewReader(`{}`))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(exportPassphraseHeader, "attacker-chosen-passphrase")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected remote callers to be refused without API auth, got %d", route.method, route.path, w.Code)
+		}
+	}
+	if nm.GetIdentity().PublicKey != before {
+		t.Error("expected the node identity to be unchanged")
+	}
+}
+
+func TestNodeServiceImportIdentityErrors(t *testing.T) {
+	dir := t.TempDir()
+	source, err := node.NewNodeManagerWithPaths(filepath.Join(dir, "source.key"), filepath.Join(dir, "source.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := source.GenerateIdentity("controller", node.RoleController); err != nil {
+		t.Fatal(err)
+	}
+	export, err := source.ExportIdentity("long enough passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nm, err := node.NewNodeManagerWithPaths(filepath.Join(dir, "private.key"), filepath.Join(dir, "node.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns := &NodeService{nodeManager: nm}
+	router := gin.New()
+	ns.SetupRoutes(router.Group(""))
+	importIdentity := func(passphrase string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(ImportIdentityRequest{Export: export, Passphrase: passphrase})
+		req := httptest.NewRequest("POST", "/node/identity/import", strings.NewReader(string(body)))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := importIdentity("wrong passphrase!"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a wrong passphrase to be a bad request, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Saving fails with a directory in place of the identity file
+	if err := os.MkdirAll(filepath.Join(dir, "node.json", "blocker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w := importIdentity("long enough passphrase")
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), ErrCodeInternalError) {
+		t.Errorf("expected a save failure to be an internal error, got %d %s", w.Code, w.Body.String())
+	}
+	if nm.HasIdentity() {
+		t.Error("expected no identity after a failed import")
+	}
+}
@@ -0,0 +1,112 @@
+package mining
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/adrg/xdg"
+)
+
+// PathsConfig controls where miners are installed and where downloads are
+// staged before extraction. Empty fields fall back to the defaults.
+type PathsConfig struct {
+	// InstallRoot is the directory miners are installed under, one subdirectory per miner.
+	InstallRoot string `json:"installRoot"`
+	// StagingDir holds downloaded archives until they are extracted.
+	StagingDir string `json:"stagingDir"`
+}
+
+var (
+	pathsConfig   PathsConfig
+	pathsConfigMu sync.RWMutex
+)
+
+// DefaultPathsConfig returns the default install and staging locations:
+// the XDG data directory and the system temp directory.
+func DefaultPathsConfig() PathsConfig {
+	return PathsConfig{
+		InstallRoot: defaultInstallRoot(),
+		StagingDir:  os.TempDir(),
+	}
+}
+
+// PathsConfigFromEnv creates a paths config from environment variables.
+// Set MINING_INSTALL_DIR to change the install root and MINING_STAGING_DIR
+// to stage downloads somewhere other than the system temp directory.
+func PathsConfigFromEnv() PathsConfig {
+	config := DefaultPathsConfig()
+
+	if dir := os.Getenv("MINING_INSTALL_DIR"); dir != "" {
+		config.InstallRoot = dir
+	}
+	if dir := os.Getenv("MINING_STAGING_DIR"); dir != "" {
+		config.StagingDir = dir
+	}
+
+	return config
+}
+
+// Validate creates the configured directories if needed and checks they are writable.
+func (p PathsConfig) Validate() error {
+	if err := checkWritableDir(p.InstallRoot); err != nil {
+		return fmt.Errorf("install root %q is not usable: %w", p.InstallRoot, err)
+	}
+	if err := checkWritableDir(p.StagingDir); err != nil {
+		return fmt.Errorf("staging directory %q is not usable: %w", p.StagingDir, err)
+	}
+	return nil
+}
+
+// SetPathsConfig sets the install and staging directories used by all miners.
+func SetPathsConfig(config PathsConfig) {
+	pathsConfigMu.Lock()
+	defer pathsConfigMu.Unlock()
+	pathsConfig = config
+}
+
+// GetPathsConfig returns the effective install and staging directories.
+func GetPathsConfig() PathsConfig {
+	pathsConfigMu.RLock()
+	config := pathsConfig
+	pathsConfigMu.RUnlock()
+
+	if config.InstallRoot == "" {
+		config.InstallRoot = defaultInstallRoot()
+	}
+	if config.StagingDir == "" {
+		config.StagingDir = os.TempDir()
+	}
+	return config
+}
+
+// defaultInstallRoot returns the XDG data directory for miners, falling back
+// to ~/.lethean-desktop/miners if it cannot be resolved.
+func defaultInstallRoot() string {
+	if xdg.DataHome != "" {
+		return filepath.Join(xdg.DataHome, "lethean-desktop", "miners")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".lethean-desktop", "miners")
+}
+
+// checkWritableDir ensures dir exists and a file can be created in it.
+func checkWritableDir(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("path is empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
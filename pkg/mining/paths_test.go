@@ -0,0 +1,66 @@
+package mining
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPathsConfigFromEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("MINING_INSTALL_DIR", filepath.Join(tmpDir, "install"))
+	t.Setenv("MINING_STAGING_DIR", filepath.Join(tmpDir, "staging"))
+
+	cfg := PathsConfigFromEnv()
+	if cfg.InstallRoot != filepath.Join(tmpDir, "install") {
+		t.Errorf("unexpected install root: %s", cfg.InstallRoot)
+	}
+	if cfg.StagingDir != filepath.Join(tmpDir, "staging") {
+		t.Errorf("unexpected staging dir: %s", cfg.StagingDir)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	for _, dir := range []string{cfg.InstallRoot, cfg.StagingDir} {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("expected %s to be created", dir)
+		}
+	}
+
+	cfg = PathsConfig{InstallRoot: "", StagingDir: t.TempDir()}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for empty install root")
+	}
+}
+
+func TestPathsConfigValidateNotWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permission checks are not enforced for this user")
+	}
+
+	readOnly := filepath.Join(t.TempDir(), "readonly")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := PathsConfig{InstallRoot: t.TempDir(), StagingDir: readOnly}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for read-only staging dir")
+	}
+}
+
+func TestGetPathUsesInstallRoot(t *testing.T) {
+	installRoot := t.TempDir()
+	SetPathsConfig(PathsConfig{InstallRoot: installRoot})
+	defer SetPathsConfig(PathsConfig{})
+
+	miner := NewXMRigMiner()
+	if got, want := miner.GetPath(), filepath.Join(installRoot, miner.ExecutableName); got != want {
+		t.Errorf("expected path %s, got %s", want, got)
+	}
+	if GetPathsConfig().StagingDir != os.TempDir() {
+		t.Error("unset staging dir should fall back to the system temp dir")
+	}
+}
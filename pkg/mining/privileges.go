@@ -0,0 +1,96 @@
+package mining
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Linux capabilities relevant to miner tuning, by bit number in CapEff.
+var minerCapabilities = map[string]uint{
+	"CAP_IPC_LOCK":  14, // lock huge pages in memory
+	"CAP_SYS_RAWIO": 17, // write MSRs via /dev/cpu/*/msr
+	"CAP_SYS_ADMIN": 21, // reserve 1GB huge pages
+	"CAP_SYS_NICE":  23, // raise priority
+}
+
+// PrivilegeInfo describes what the current process is allowed to do.
+type PrivilegeInfo struct {
+	Elevated     bool     `json:"elevated"`               // root on Unix, elevated token on Windows
+	Capabilities []string `json:"capabilities,omitempty"` // relevant effective Linux capabilities
+}
+
+// Has reports whether the process holds a Linux capability or is elevated.
+func (p PrivilegeInfo) Has(capability string) bool {
+	if p.Elevated {
+		return true
+	}
+	for _, c := range p.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// procStatusPath is where effective capabilities are read from (a var for tests).
+var procStatusPath = "/proc/self/status"
+
+// detectPrivileges inspects the current process (a var for tests).
+var detectPrivileges = func() PrivilegeInfo {
+	return PrivilegeInfo{
+		Elevated:     isElevated(),
+		Capabilities: effectiveCapabilities(procStatusPath),
+	}
+}
+
+// DetectPrivileges returns the current process's privileges.
+func DetectPrivileges() PrivilegeInfo {
+	return detectPrivileges()
+}
+
+// effectiveCapabilities returns the relevant capabilities in the CapEff mask
+// of a /proc status file. Returns nil where /proc isn't available.
+func effectiveCapabilities(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "CapEff:")
+		if !ok {
+			continue
+		}
+		mask, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		if err != nil {
+			return nil
+		}
+		var caps []string
+		for name, bit := range minerCapabilities {
+			if mask&(1<<bit) != 0 {
+				caps = append(caps, name)
+			}
+		}
+		sort.Strings(caps)
+		return caps
+	}
+	return nil
+}
+
+// privilegeWarnings lists settings in an XMRig config that need privileges
+// the process doesn't have. XMRig silently skips them in that case.
+func privilegeWarnings(config *Config, priv PrivilegeInfo) []ConfigIssue {
+	var warnings []ConfigIssue
+	if wrmsr := strings.ToLower(config.RandomXWrmsr); wrmsr != "" && wrmsr != "false" && wrmsr != "-1" && !priv.Has("CAP_SYS_RAWIO") {
+		warnings = append(warnings, ConfigIssue{Field: "randomXWrmsr", Message: "MSR writes need root/Administrator (or CAP_SYS_RAWIO); the RandomX MSR mod will be skipped"})
+	}
+	if config.RandomX1GBPages && !priv.Has("CAP_SYS_ADMIN") {
+		warnings = append(warnings, ConfigIssue{Field: "randomX1GBPages", Message: "1GB huge pages need root to reserve; RandomX will fall back to regular huge pages"})
+	}
+	return warnings
+}
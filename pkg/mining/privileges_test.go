@@ -0,0 +1,60 @@
+package mining
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEffectiveCapabilities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status")
+	// CAP_SYS_RAWIO (17) and CAP_SYS_NICE (23)
+	os.WriteFile(path, []byte("Name:\tmining\nCapInh:\t0000000000000000\nCapEff:\t0000000000820000\n"), 0644)
+
+	caps := effectiveCapabilities(path)
+	if len(caps) != 2 || caps[0] != "CAP_SYS_NICE" || caps[1] != "CAP_SYS_RAWIO" {
+		t.Errorf("unexpected capabilities: %v", caps)
+	}
+	if effectiveCapabilities(filepath.Join(t.TempDir(), "missing")) != nil {
+		t.Error("expected nil capabilities when /proc is unavailable")
+	}
+}
+
+func TestPrivilegeWarnings(t *testing.T) {
+	config := &Config{RandomXWrmsr: "true", RandomX1GBPages: true}
+
+	warnings := privilegeWarnings(config, PrivilegeInfo{})
+	if len(warnings) != 2 || warnings[0].Field != "randomXWrmsr" || warnings[1].Field != "randomX1GBPages" {
+		t.Errorf("expected MSR and 1GB page warnings, got %+v", warnings)
+	}
+
+	if got := privilegeWarnings(config, PrivilegeInfo{Capabilities: []string{"CAP_SYS_RAWIO"}}); len(got) != 1 {
+		t.Errorf("CAP_SYS_RAWIO should satisfy wrmsr, got %+v", got)
+	}
+	if got := privilegeWarnings(config, PrivilegeInfo{Elevated: true}); len(got) != 0 {
+		t.Errorf("expected no warnings when elevated, got %+v", got)
+	}
+	if got := privilegeWarnings(&Config{RandomXWrmsr: "false"}, PrivilegeInfo{}); len(got) != 0 {
+		t.Errorf("disabled wrmsr should not warn, got %+v", got)
+	}
+}
+
+func TestValidateMinerConfigPrivilegeWarnings(t *testing.T) {
+	original := detectPrivileges
+	detectPrivileges = func() PrivilegeInfo { return PrivilegeInfo{} }
+	defer func() { detectPrivileges = original }()
+
+	result := ValidateMinerConfig(MinerTypeXMRig, &Config{Pool: "stratum+tcp://pool.example.com:3333", Wallet: "wallet", RandomXWrmsr: "true"})
+	if !result.Valid {
+		t.Fatalf("privilege issues must not make a config invalid: %+v", result.Errors)
+	}
+	found := false
+	for _, w := range result.Warnings {
+		if w.Field == "randomXWrmsr" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected randomXWrmsr warning, got %+v", result.Warnings)
+	}
+}
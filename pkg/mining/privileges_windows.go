@@ -0,0 +1,10 @@
+//go:build windows
+
+package mining
+
+import "golang.org/x/sys/windows"
+
+// isElevated reports whether the process runs with an elevated (Administrator) token.
+func isElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
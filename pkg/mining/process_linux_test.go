@@ -0,0 +1,56 @@
+//go:build linux
+
+package mining
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestStartProcessAppliesOSSettings(t *testing.T) {
+	priority := 7
+	cmd := exec.Command("sleep", "5")
+	b := &BaseMiner{Name: "test", cmd: cmd}
+	if err := b.startProcess(&Config{ProcessPriority: &priority, CPUAffinity: "0", MaxMemoryMB: 512, MaxOpenFiles: 128}); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	// The raw syscall returns 20 - nice on Linux
+	got, err := syscall.Getpriority(syscall.PRIO_PROCESS, cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nice := 20 - got; nice != priority {
+		t.Errorf("expected niceness %d, got %d", priority, nice)
+	}
+
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(cmd.Process.Pid, &set); err != nil {
+		t.Fatal(err)
+	}
+	if set.Count() != 1 || !set.IsSet(0) {
+		t.Errorf("expected the process pinned to CPU 0, got %d CPUs", set.Count())
+	}
+	if cpus := b.AppliedCPUAffinity(); len(cpus) != 1 || cpus[0] != 0 {
+		t.Errorf("expected applied affinity [0], got %v", cpus)
+	}
+
+	b.Running = true
+	limits, err := b.ResourceLimits()
+	if err != nil {
+		t.Fatalf("ResourceLimits failed: %v", err)
+	}
+	if !limits.Supported || limits.Memory.Hard == nil || *limits.Memory.Hard != 512<<20 {
+		t.Errorf("expected a 512MB memory limit, got %+v", limits.Memory)
+	}
+	if limits.OpenFiles.Soft == nil || *limits.OpenFiles.Soft != 128 {
+		t.Errorf("expected an open files limit of 128, got %+v", limits.OpenFiles)
+	}
+}
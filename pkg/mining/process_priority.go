@@ -0,0 +1,10 @@
+package mining
+
+// Process priority uses the Unix niceness scale on every platform: -20 is the
+// highest priority, 0 normal and 19 the lowest. On Windows it is mapped onto
+// the nearest priority class. This is the OS scheduling priority of the whole
+// miner process, unlike CPUPriority which XMRig applies to its own threads.
+const (
+	minProcessPriority = -20
+	maxProcessPriority = 19
+)
@@ -0,0 +1,56 @@
+package mining
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateProcessPriority(t *testing.T) {
+	for _, priority := range []int{0, 10, maxProcessPriority} {
+		if err := validateProcessPriority(priority); err != nil {
+			t.Errorf("expected %d to be valid, got %v", priority, err)
+		}
+	}
+	for _, priority := range []int{minProcessPriority - 1, maxProcessPriority + 1} {
+		if err := validateProcessPriority(priority); err == nil {
+			t.Errorf("expected %d to be rejected", priority)
+		}
+	}
+
+	tooHigh := maxProcessPriority + 1
+	if err := (&Config{ProcessPriority: &tooHigh}).Validate(); err == nil {
+		t.Error("expected Config.Validate to reject an out-of-range process priority")
+	}
+}
+
+func TestManagerDefaultProcessPriority(t *testing.T) {
+	setupIsolatedMinersConfig(t)
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	background := 10
+	m.SetProcessPriority(&background)
+
+	miner, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "stratum+tcp://pool:3333", Wallet: "w", Algo: "rx/0"})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	launch, err := m.GetMinerLaunchInfo(miner.GetName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if launch.Config.ProcessPriority == nil || *launch.Config.ProcessPriority != background {
+		t.Errorf("expected the default priority %d to be applied, got %v", background, launch.Config.ProcessPriority)
+	}
+
+	// A profile's own priority wins over the default
+	normal := 0
+	miner, err = m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "stratum+tcp://pool:3333", Wallet: "w", Algo: "rx/wow", ProcessPriority: &normal})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	launch, _ = m.GetMinerLaunchInfo(miner.GetName())
+	if launch.Config.ProcessPriority == nil || *launch.Config.ProcessPriority != 0 {
+		t.Errorf("expected the config's priority 0 to be kept, got %v", launch.Config.ProcessPriority)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package mining
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// validateProcessPriority checks a niceness value. Only root may go below 0.
+func validateProcessPriority(priority int) error {
+	if priority < minProcessPriority || priority > maxProcessPriority {
+		return fmt.Errorf("process priority must be between %d and %d", minProcessPriority, maxProcessPriority)
+	}
+	if priority < 0 && !isElevated() {
+		return fmt.Errorf("process priority below 0 requires root")
+	}
+	return nil
+}
+
+// prepareProcessPriority is a no-op: Unix niceness is set once the process exists.
+func prepareProcessPriority(cmd *exec.Cmd, priority int) {}
+
+// applyProcessPriority sets the niceness of a started process. Threads the
+// miner creates afterwards inherit it.
+func applyProcessPriority(cmd *exec.Cmd, priority int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, cmd.Process.Pid, priority)
+}
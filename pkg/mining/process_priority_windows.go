@@ -0,0 +1,56 @@
+//go:build windows
+
+package mining
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// Windows process creation flags for priority classes.
+const (
+	idlePriorityClass        = 0x00000040
+	belowNormalPriorityClass = 0x00004000
+	normalPriorityClass      = 0x00000020
+	aboveNormalPriorityClass = 0x00008000
+	highPriorityClass        = 0x00000080
+)
+
+// validateProcessPriority checks a niceness value. Realtime priority is never
+// used, so the whole range is available without elevation.
+func validateProcessPriority(priority int) error {
+	if priority < minProcessPriority || priority > maxProcessPriority {
+		return fmt.Errorf("process priority must be between %d and %d", minProcessPriority, maxProcessPriority)
+	}
+	return nil
+}
+
+// priorityClass maps a niceness value onto the nearest priority class.
+func priorityClass(priority int) uint32 {
+	switch {
+	case priority >= 15:
+		return idlePriorityClass
+	case priority >= 5:
+		return belowNormalPriorityClass
+	case priority > -5:
+		return normalPriorityClass
+	case priority > -15:
+		return aboveNormalPriorityClass
+	default:
+		return highPriorityClass
+	}
+}
+
+// prepareProcessPriority creates the process in the matching priority class.
+func prepareProcessPriority(cmd *exec.Cmd, priority int) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= priorityClass(priority)
+}
+
+// applyProcessPriority is a no-op: the priority class is set at creation.
+func applyProcessPriority(cmd *exec.Cmd, priority int) error {
+	return nil
+}
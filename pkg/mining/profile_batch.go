@@ -0,0 +1,142 @@
+package mining
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/google/uuid"
+)
+
+// Operations of a profile batch.
+const (
+	ProfileOpCreate = "create"
+	ProfileOpUpdate = "update"
+	ProfileOpDelete = "delete"
+)
+
+// MaxProfileBatchSize is the most operations ApplyProfileBatch accepts at once.
+const MaxProfileBatchSize = 100
+
+// ProfileOperation is one change in a profile batch. Create takes Profile,
+// update takes ID and Profile, delete takes ID.
+type ProfileOperation struct {
+	Op      string         `json:"op" binding:"required"`
+	ID      string         `json:"id,omitempty"`
+	Profile *MiningProfile `json:"profile,omitempty"`
+}
+
+// ProfileOperationResult is the outcome of one applied operation.
+type ProfileOperationResult struct {
+	Op      string         `json:"op"`
+	ID      string         `json:"id"`
+	Profile *MiningProfile `json:"profile,omitempty"` // The saved profile; empty for deletes
+}
+
+// ProfileBatchError reports the operation that stopped a batch. Nothing in
+// the batch was applied.
+type ProfileBatchError struct {
+	Index int    // Position of the failing operation in the batch
+	Op    string // Its operation
+	ID    string // The profile it targeted, if any
+	Err   error
+}
+
+func (e *ProfileBatchError) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %v", e.Index, e.Op, e.ID, e.Err)
+}
+
+func (e *ProfileBatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyProfileBatch applies creates, updates and deletes as one change: the
+// profiles are saved once, after every operation has been checked, and if
+// anything fails none of them are applied. Operations see the results of
+// earlier ones, so a batch can update a profile it created.
+func (pm *ProfileManager) ApplyProfileBatch(ops []ProfileOperation) ([]ProfileOperationResult, error) {
+	if len(ops) == 0 {
+		return nil, ErrInvalidConfig("batch has no operations")
+	}
+	if len(ops) > MaxProfileBatchSize {
+		return nil, ErrInvalidConfig(fmt.Sprintf("batch has %d operations, at most %d are allowed", len(ops), MaxProfileBatchSize))
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	working := maps.Clone(pm.profiles)
+	if working == nil {
+		working = make(map[string]*MiningProfile)
+	}
+	results := make([]ProfileOperationResult, 0, len(ops))
+	for i, op := range ops {
+		result, err := pm.applyProfileOperation(working, op)
+		if err != nil {
+			return nil, &ProfileBatchError{Index: i, Op: op.Op, ID: op.ID, Err: err}
+		}
+		results = append(results, result)
+	}
+
+	previous := pm.profiles
+	pm.profiles = working
+	if err := pm.saveProfiles(); err != nil {
+		pm.profiles = previous
+		return nil, ErrInternal("failed to save profiles").WithCause(err)
+	}
+
+	for id, old := range previous {
+		if current, exists := working[id]; !exists {
+			delete(pm.history, id)
+		} else {
+			pm.appendVersion(old, current)
+		}
+	}
+	if err := pm.saveHistory(); err != nil {
+		logging.Warn("failed to save profile history", logging.Fields{"error": err})
+	}
+	return results, nil
+}
+
+// applyProfileOperation checks op and applies it to profiles, a working copy
+// of pm.profiles. Caller must hold pm.mu.
+func (pm *ProfileManager) applyProfileOperation(profiles map[string]*MiningProfile, op ProfileOperation) (ProfileOperationResult, error) {
+	result := ProfileOperationResult{Op: op.Op, ID: op.ID}
+
+	switch op.Op {
+	case ProfileOpCreate, ProfileOpUpdate:
+		if op.Profile == nil {
+			return result, ErrInvalidConfig("profile is required")
+		}
+		profile := *op.Profile
+		if op.Op == ProfileOpCreate {
+			profile.ID = uuid.New().String()
+		} else {
+			if _, exists := profiles[op.ID]; !exists {
+				return result, ErrProfileNotFound(op.ID)
+			}
+			profile.ID = op.ID
+		}
+		if profile.Name == "" {
+			return result, ErrInvalidConfig("profile name is required")
+		}
+		if profile.MinerType == "" {
+			return result, ErrInvalidConfig("miner type is required")
+		}
+		if err := pm.checkTemplateLocked(&profile); err != nil {
+			return result, err
+		}
+		profiles[profile.ID] = &profile
+		result.ID, result.Profile = profile.ID, &profile
+
+	case ProfileOpDelete:
+		if _, exists := profiles[op.ID]; !exists {
+			return result, ErrProfileNotFound(op.ID)
+		}
+		delete(profiles, op.ID)
+
+	default:
+		return result, ErrInvalidConfig(fmt.Sprintf("unknown operation '%s'", op.Op))
+	}
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package mining
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestApplyProfileBatch(t *testing.T) {
+	pm, cleanup := setupTestProfileManager(t)
+	defer cleanup()
+
+	a, _ := pm.CreateProfile(&MiningProfile{Name: "a", MinerType: "xmrig", Config: RawConfig(`{"pool":"old:3333"}`)})
+	b, _ := pm.CreateProfile(&MiningProfile{Name: "b", MinerType: "xmrig", Config: RawConfig(`{"pool":"old:3333"}`)})
+
+	t.Run("applies every operation", func(t *testing.T) {
+		results, err := pm.ApplyProfileBatch([]ProfileOperation{
+			{Op: ProfileOpUpdate, ID: a.ID, Profile: &MiningProfile{Name: "a", MinerType: "xmrig", Config: RawConfig(`{"pool":"new:3333"}`)}},
+			{Op: ProfileOpCreate, Profile: &MiningProfile{Name: "c", MinerType: "xmrig", Config: RawConfig(`{"pool":"new:3333"}`)}},
+			{Op: ProfileOpDelete, ID: b.ID},
+		})
+		if err != nil {
+			t.Fatalf("ApplyProfileBatch failed: %v", err)
+		}
+		if len(results) != 3 || results[1].ID == "" || results[2].Profile != nil {
+			t.Fatalf("unexpected results: %+v", results)
+		}
+		if profile, _ := pm.GetProfile(a.ID); string(profile.Config) != `{"pool":"new:3333"}` {
+			t.Errorf("expected a updated, got %s", profile.Config)
+		}
+		if _, exists := pm.GetProfile(b.ID); exists {
+			t.Error("expected b deleted")
+		}
+		if _, exists := pm.GetProfile(results[1].ID); !exists {
+			t.Error("expected c created")
+		}
+		if history, _ := pm.GetProfileHistory(a.ID); len(history) != 1 {
+			t.Errorf("expected the update recorded in a's history, got %d versions", len(history))
+		}
+	})
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		before := len(pm.GetAllProfiles())
+		data, _ := os.ReadFile(pm.configPath)
+
+		_, err := pm.ApplyProfileBatch([]ProfileOperation{
+			{Op: ProfileOpUpdate, ID: a.ID, Profile: &MiningProfile{Name: "a", MinerType: "xmrig", Config: RawConfig(`{"pool":"newer:3333"}`)}},
+			{Op: ProfileOpCreate, Profile: &MiningProfile{Name: "d", MinerType: "xmrig"}},
+			{Op: ProfileOpDelete, ID: "missing"},
+		})
+		var batchErr *ProfileBatchError
+		if !errors.As(err, &batchErr) || batchErr.Index != 2 {
+			t.Fatalf("expected operation 2 to fail, got %v", err)
+		}
+		var miningErr *MiningError
+		if !errors.As(err, &miningErr) || miningErr.Code != ErrCodeProfileNotFound {
+			t.Errorf("expected %s, got %v", ErrCodeProfileNotFound, err)
+		}
+
+		if profile, _ := pm.GetProfile(a.ID); string(profile.Config) != `{"pool":"new:3333"}` {
+			t.Errorf("expected a unchanged, got %s", profile.Config)
+		}
+		if len(pm.GetAllProfiles()) != before {
+			t.Errorf("expected %d profiles, got %d", before, len(pm.GetAllProfiles()))
+		}
+		if after, _ := os.ReadFile(pm.configPath); !bytes.Equal(data, after) {
+			t.Error("expected the saved profiles untouched")
+		}
+	})
+
+	t.Run("rejects invalid operations", func(t *testing.T) {
+		for name, ops := range map[string][]ProfileOperation{
+			"empty":        nil,
+			"unknown op":   {{Op: "rename", ID: a.ID}},
+			"no profile":   {{Op: ProfileOpCreate}},
+			"no name":      {{Op: ProfileOpCreate, Profile: &MiningProfile{MinerType: "xmrig"}}},
+			"bad template": {{Op: ProfileOpCreate, Profile: &MiningProfile{Name: "e", MinerType: "xmrig", TemplateID: "missing"}}},
+		} {
+			if _, err := pm.ApplyProfileBatch(ops); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
+
+func TestHandleProfileBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pm, cleanup := setupTestProfileManager(t)
+	defer cleanup()
+	existing, _ := pm.CreateProfile(&MiningProfile{Name: "a", MinerType: "xmrig"})
+
+	router := gin.New()
+	service := &Service{ProfileManager: pm, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/profiles/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"operations":[{"op":"create","profile":{"name":"b","minerType":"xmrig"}},{"op":"update","id":"missing","profile":{"name":"c","minerType":"xmrig"}}]}`)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+	var failure ProfileBatchFailure
+	if err := json.Unmarshal(w.Body.Bytes(), &failure); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if failure.Operation != 1 || !failure.RolledBack || failure.Error.Code != ErrCodeProfileNotFound {
+		t.Errorf("unexpected failure: %+v", failure)
+	}
+	if len(pm.GetAllProfiles()) != 1 {
+		t.Errorf("expected the create rolled back, got %d profiles", len(pm.GetAllProfiles()))
+	}
+
+	w = post(`{"operations":[{"op":"delete","id":"` + existing.ID + `"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp ProfileBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].ID != existing.ID {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+}
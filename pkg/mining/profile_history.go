@@ -0,0 +1,195 @@
+package mining
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+const profileHistoryFileName = "mining_profile_history.json"
+
+// MaxProfileVersions is how many previous versions are kept per profile; the
+// oldest are dropped first.
+const MaxProfileVersions = 20
+
+// ProfileVersion is a previous state of a profile, saved when it was replaced.
+type ProfileVersion struct {
+	Version  int            `json:"version"`
+	Replaced time.Time      `json:"replaced"`          // When this version stopped being current
+	Changed  []string       `json:"changed,omitempty"` // Fields the replacement changed, config fields as "config.<name>"
+	Profile  *MiningProfile `json:"profile"`
+}
+
+// historyPath is where profile versions are saved, next to the profiles.
+func (pm *ProfileManager) historyPath() string {
+	return filepath.Join(filepath.Dir(pm.configPath), profileHistoryFileName)
+}
+
+// loadHistory reads the profile versions into memory.
+func (pm *ProfileManager) loadHistory() error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	data, err := os.ReadFile(pm.historyPath())
+	if err != nil {
+		return err
+	}
+	var history map[string][]ProfileVersion
+	if err := json.Unmarshal(data, &history); err != nil {
+		return err
+	}
+	pm.history = history
+	return nil
+}
+
+// saveHistory writes the profile versions to disk. Caller must hold pm.mu.
+func (pm *ProfileManager) saveHistory() error {
+	data, err := json.MarshalIndent(pm.history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return AtomicWriteFile(pm.historyPath(), data, 0600)
+}
+
+// recordVersion keeps old, which current replaced, as the profile's newest
+// previous version. The profile itself is already saved, so failing to save
+// the history is only logged. Caller must hold pm.mu.
+func (pm *ProfileManager) recordVersion(old, current *MiningProfile) {
+	pm.appendVersion(old, current)
+	if err := pm.saveHistory(); err != nil {
+		logging.Warn("failed to save profile history", logging.Fields{"profile": old.ID, "error": err})
+	}
+}
+
+// appendVersion is recordVersion without saving, for callers recording
+// several versions at once. Caller must hold pm.mu.
+func (pm *ProfileManager) appendVersion(old, current *MiningProfile) {
+	if old == current {
+		return // Modified in place; the previous state is already gone
+	}
+	if pm.history == nil {
+		pm.history = make(map[string][]ProfileVersion)
+	}
+	versions := pm.history[old.ID]
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1].Version + 1
+	}
+	versions = append(versions, ProfileVersion{
+		Version:  next,
+		Replaced: time.Now(),
+		Changed:  changedProfileFields(old, current),
+		Profile:  old,
+	})
+	if len(versions) > MaxProfileVersions {
+		versions = versions[len(versions)-MaxProfileVersions:]
+	}
+	pm.history[old.ID] = versions
+}
+
+// forgetHistory drops a deleted profile's versions. Caller must hold pm.mu.
+func (pm *ProfileManager) forgetHistory(id string) {
+	if _, ok := pm.history[id]; !ok {
+		return
+	}
+	delete(pm.history, id)
+	if err := pm.saveHistory(); err != nil {
+		logging.Warn("failed to save profile history", logging.Fields{"profile": id, "error": err})
+	}
+}
+
+// changedProfileFields lists the fields that differ between two versions of
+// a profile, sorted.
+func changedProfileFields(old, current *MiningProfile) []string {
+	var changed []string
+	if old.Name != current.Name {
+		changed = append(changed, "name")
+	}
+	if old.MinerType != current.MinerType {
+		changed = append(changed, "minerType")
+	}
+	if old.TemplateID != current.TemplateID {
+		changed = append(changed, "templateId")
+	}
+
+	oldFields, err := configFields(old.Config)
+	if err != nil {
+		return append(changed, "config")
+	}
+	newFields, err := configFields(current.Config)
+	if err != nil {
+		return append(changed, "config")
+	}
+	for key, value := range oldFields {
+		if other, ok := newFields[key]; !ok || !bytes.Equal(compactJSON(value), compactJSON(other)) {
+			changed = append(changed, "config."+key)
+		}
+	}
+	for key := range newFields {
+		if _, ok := oldFields[key]; !ok {
+			changed = append(changed, "config."+key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func compactJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// GetProfileHistory returns a profile's previous versions, newest first.
+func (pm *ProfileManager) GetProfileHistory(id string) ([]ProfileVersion, error) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	if _, exists := pm.profiles[id]; !exists {
+		return nil, ErrProfileNotFound(id)
+	}
+
+	versions := pm.history[id]
+	history := make([]ProfileVersion, len(versions))
+	for i, v := range versions {
+		history[len(versions)-1-i] = v
+	}
+	return history, nil
+}
+
+// RevertProfile makes a previous version the profile's current state. The
+// state it replaces becomes the newest version, so a revert can be undone.
+func (pm *ProfileManager) RevertProfile(id string, version int) (*MiningProfile, error) {
+	pm.mu.RLock()
+	var target *MiningProfile
+	for _, v := range pm.history[id] {
+		if v.Version == version {
+			target = v.Profile
+		}
+	}
+	_, exists := pm.profiles[id]
+	pm.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrProfileNotFound(id)
+	}
+	if target == nil {
+		return nil, ErrProfileVersionNotFound(id, version)
+	}
+
+	restored := *target
+	restored.ID = id
+	if err := pm.CheckTemplate(&restored); err != nil {
+		return nil, err
+	}
+	if err := pm.UpdateProfile(&restored); err != nil {
+		return nil, ErrInternal("failed to revert profile").WithCause(err)
+	}
+	return &restored, nil
+}
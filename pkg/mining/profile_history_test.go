@@ -0,0 +1,103 @@
+package mining
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProfileHistory(t *testing.T) {
+	pm, cleanup := setupTestProfileManager(t)
+	defer cleanup()
+
+	created, err := pm.CreateProfile(&MiningProfile{Name: "rig", MinerType: "xmrig", Config: RawConfig(`{"threads": 4, "pool": "a:3333"}`)})
+	if err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	if err := pm.UpdateProfile(&MiningProfile{ID: created.ID, Name: "rig", MinerType: "xmrig", Config: RawConfig(`{"threads":8,"pool":"a:3333"}`)}); err != nil {
+		t.Fatalf("UpdateProfile failed: %v", err)
+	}
+	if err := pm.UpdateProfile(&MiningProfile{ID: created.ID, Name: "rig 2", MinerType: "xmrig", Config: RawConfig(`{"threads":8,"pool":"b:3333","hugePages":true}`)}); err != nil {
+		t.Fatalf("UpdateProfile failed: %v", err)
+	}
+
+	history, err := pm.GetProfileHistory(created.ID)
+	if err != nil {
+		t.Fatalf("GetProfileHistory failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Version != 2 || history[1].Version != 1 {
+		t.Fatalf("expected versions 2 and 1, newest first, got %+v", history)
+	}
+	if want := []string{"config.threads"}; !reflect.DeepEqual(history[1].Changed, want) {
+		t.Errorf("version 1: expected changed %v, got %v", want, history[1].Changed)
+	}
+	if want := []string{"config.hugePages", "config.pool", "name"}; !reflect.DeepEqual(history[0].Changed, want) {
+		t.Errorf("version 2: expected changed %v, got %v", want, history[0].Changed)
+	}
+
+	t.Run("revert", func(t *testing.T) {
+		restored, err := pm.RevertProfile(created.ID, 1)
+		if err != nil {
+			t.Fatalf("RevertProfile failed: %v", err)
+		}
+		current, _ := pm.GetProfile(created.ID)
+		if current != restored || string(current.Config) != `{"threads": 4, "pool": "a:3333"}` {
+			t.Errorf("expected version 1 restored, got %+v", current)
+		}
+		history, _ := pm.GetProfileHistory(created.ID)
+		if len(history) != 3 || history[0].Profile.Name != "rig 2" {
+			t.Errorf("expected the reverted state kept as version 3, got %+v", history[0])
+		}
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		var miningErr *MiningError
+		if _, err := pm.RevertProfile(created.ID, 99); !errors.As(err, &miningErr) || miningErr.Code != ErrCodeVersionNotFound {
+			t.Errorf("expected %s, got %v", ErrCodeVersionNotFound, err)
+		}
+		if _, err := pm.GetProfileHistory("missing"); !errors.As(err, &miningErr) || miningErr.Code != ErrCodeProfileNotFound {
+			t.Errorf("expected %s, got %v", ErrCodeProfileNotFound, err)
+		}
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		reloaded := &ProfileManager{configPath: pm.configPath}
+		if err := reloaded.loadHistory(); err != nil {
+			t.Fatalf("loadHistory failed: %v", err)
+		}
+		if len(reloaded.history[created.ID]) != 3 {
+			t.Errorf("expected 3 saved versions, got %d", len(reloaded.history[created.ID]))
+		}
+	})
+
+	t.Run("delete forgets history", func(t *testing.T) {
+		if err := pm.DeleteProfile(created.ID); err != nil {
+			t.Fatalf("DeleteProfile failed: %v", err)
+		}
+		if _, ok := pm.history[created.ID]; ok {
+			t.Error("expected history dropped with the profile")
+		}
+	})
+}
+
+func TestProfileHistoryCap(t *testing.T) {
+	pm, cleanup := setupTestProfileManager(t)
+	defer cleanup()
+
+	created, _ := pm.CreateProfile(&MiningProfile{Name: "rig", MinerType: "xmrig"})
+	for i := 0; i < MaxProfileVersions+5; i++ {
+		update := &MiningProfile{ID: created.ID, Name: "rig", MinerType: "xmrig", Config: RawConfig(fmt.Sprintf(`{"threads":%d}`, i))}
+		if err := pm.UpdateProfile(update); err != nil {
+			t.Fatalf("UpdateProfile failed: %v", err)
+		}
+	}
+
+	history, _ := pm.GetProfileHistory(created.ID)
+	if len(history) != MaxProfileVersions {
+		t.Fatalf("expected %d versions kept, got %d", MaxProfileVersions, len(history))
+	}
+	if history[0].Version != MaxProfileVersions+5 || history[len(history)-1].Version != 6 {
+		t.Errorf("expected versions %d..6, got %d..%d", MaxProfileVersions+5, history[0].Version, history[len(history)-1].Version)
+	}
+}
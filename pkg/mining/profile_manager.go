@@ -17,6 +17,8 @@ const profileConfigFileName = "mining_profiles.json"
 type ProfileManager struct {
 	mu         sync.RWMutex
 	profiles   map[string]*MiningProfile
+	templates  map[string]*MiningTemplate  // Saved next to the profiles, see mining_template.go
+	history    map[string][]ProfileVersion // Previous versions by profile ID, see profile_history.go
 	configPath string
 }
 
@@ -29,6 +31,8 @@ func NewProfileManager() (*ProfileManager, error) {
 
 	pm := &ProfileManager{
 		profiles:   make(map[string]*MiningProfile),
+		templates:  make(map[string]*MiningTemplate),
+		history:    make(map[string][]ProfileVersion),
 		configPath: configPath,
 	}
 
@@ -38,6 +42,12 @@ func NewProfileManager() (*ProfileManager, error) {
 			return nil, fmt.Errorf("could not load profiles: %w", err)
 		}
 	}
+	if err := pm.loadTemplates(); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not load templates: %w", err)
+	}
+	if err := pm.loadHistory(); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not load profile history: %w", err)
+	}
 
 	return pm, nil
 }
@@ -99,6 +109,36 @@ func (pm *ProfileManager) CreateProfile(profile *MiningProfile) (*MiningProfile,
 	return profile, nil
 }
 
+// SaveProfile creates or replaces a profile, keeping its ID so a profile
+// deployed from another node can be referenced by the same ID. Profiles
+// without an ID are assigned one.
+func (pm *ProfileManager) SaveProfile(profile *MiningProfile) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if profile.ID == "" {
+		profile.ID = uuid.New().String()
+	}
+
+	oldProfile, existed := pm.profiles[profile.ID]
+	pm.profiles[profile.ID] = profile
+
+	if err := pm.saveProfiles(); err != nil {
+		// Rollback
+		if existed {
+			pm.profiles[profile.ID] = oldProfile
+		} else {
+			delete(pm.profiles, profile.ID)
+		}
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	if existed {
+		pm.recordVersion(oldProfile, profile)
+	}
+
+	return nil
+}
+
 // GetProfile retrieves a profile by its ID.
 func (pm *ProfileManager) GetProfile(id string) (*MiningProfile, bool) {
 	pm.mu.RLock()
@@ -119,7 +159,8 @@ func (pm *ProfileManager) GetAllProfiles() []*MiningProfile {
 	return profileList
 }
 
-// UpdateProfile modifies an existing profile.
+// UpdateProfile modifies an existing profile, keeping the previous state in
+// its history.
 func (pm *ProfileManager) UpdateProfile(profile *MiningProfile) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
@@ -138,6 +179,7 @@ func (pm *ProfileManager) UpdateProfile(profile *MiningProfile) error {
 		pm.profiles[profile.ID] = oldProfile
 		return fmt.Errorf("failed to save profile: %w", err)
 	}
+	pm.recordVersion(oldProfile, profile)
 
 	return nil
 }
@@ -159,6 +201,7 @@ func (pm *ProfileManager) DeleteProfile(id string) error {
 		pm.profiles[id] = profile
 		return fmt.Errorf("failed to delete profile: %w", err)
 	}
+	pm.forgetHistory(id)
 
 	return nil
 }
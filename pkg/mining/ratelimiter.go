@@ -1,13 +1,65 @@
 package mining
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/Snider/Mining/pkg/logging"
 	"github.com/gin-gonic/gin"
 )
 
+// RateLimitConfig sets the per-client request rate limit.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained rate allowed per client IP
+	RequestsPerSecond int `yaml:"requestsPerSecond"`
+	// Burst is how many requests a client may make at once
+	Burst int `yaml:"burst"`
+}
+
+// DefaultRateLimitConfig returns the default limit of 10 requests/second with a burst of 20.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{RequestsPerSecond: 10, Burst: 20}
+}
+
+// rateLimitConfigWithEnv overrides config with MINING_RATE_LIMIT_RPS and
+// MINING_RATE_LIMIT_BURST. Values that don't parse are logged and ignored.
+func rateLimitConfigWithEnv(config RateLimitConfig) RateLimitConfig {
+	limits := []struct {
+		env    string
+		target *int
+	}{
+		{"MINING_RATE_LIMIT_RPS", &config.RequestsPerSecond},
+		{"MINING_RATE_LIMIT_BURST", &config.Burst},
+	}
+	for _, l := range limits {
+		v := os.Getenv(l.env)
+		if v == "" {
+			continue
+		}
+		if parsed, err := strconv.Atoi(v); err == nil {
+			*l.target = parsed
+		} else {
+			logging.Warn("invalid rate limit, ignoring", logging.Fields{"env": l.env, "value": v, "default": *l.target})
+		}
+	}
+	return config
+}
+
+// Validate checks the limit lets requests through.
+func (c RateLimitConfig) Validate() error {
+	if c.RequestsPerSecond <= 0 {
+		return fmt.Errorf("requests per second must be positive, got %d", c.RequestsPerSecond)
+	}
+	if c.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1, got %d", c.Burst)
+	}
+	return nil
+}
+
 // RateLimiter provides token bucket rate limiting per IP address
 type RateLimiter struct {
 	requestsPerSecond int
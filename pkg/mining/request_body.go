@@ -0,0 +1,53 @@
+package mining
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestBodyError describes an error from reading or decoding a JSON request
+// body: a body over the size limit is a 413, and malformed or truncated JSON
+// is a 400 whose message says where parsing stopped (details are hidden in
+// release mode). It returns nil for anything else, such as a field failing
+// validation, which the handler reports its own way.
+func requestBodyError(err error) *MiningError {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return ErrRequestTooLarge(tooLarge.Limit)
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return ErrInvalidInput(fmt.Sprintf("malformed JSON at byte offset %d: %s", syntaxErr.Offset, syntaxErr.Error()))
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "request body"
+		}
+		return ErrInvalidInput(fmt.Sprintf("malformed JSON at byte offset %d: %s should be %s, got %s", typeErr.Offset, field, typeErr.Type, typeErr.Value))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrInvalidInput("malformed JSON: the request body ends before the JSON is complete").
+			WithSuggestion("Check the request body wasn't cut off")
+	case errors.Is(err, io.EOF):
+		return ErrInvalidInput("empty request body").
+			WithSuggestion("Send a JSON request body")
+	}
+	return nil
+}
+
+// respondWithBindError responds to a failed ShouldBindJSON or body read,
+// using requestBodyError if it recognises err and fallback otherwise.
+func respondWithBindError(c *gin.Context, err error, fallback *MiningError) {
+	if bodyErr := requestBodyError(err); bodyErr != nil {
+		respondWithMiningError(c, bodyErr)
+		return
+	}
+	respondWithMiningError(c, fallback.WithCause(err))
+}
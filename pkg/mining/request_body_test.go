@@ -0,0 +1,57 @@
+package mining
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondWithBindError(t *testing.T) {
+	router := gin.New()
+	router.Use(bodyLimitMiddleware(minBodyBytes, minBodyBytes, nil))
+	router.POST("/profiles", func(c *gin.Context) {
+		var profile struct {
+			Name string `json:"name" binding:"required"`
+			Port int    `json:"port"`
+		}
+		if err := c.ShouldBindJSON(&profile); err != nil {
+			respondWithBindError(c, err, ErrInvalidInput("invalid profile data"))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	post := func(body string) (int, APIError) {
+		req := httptest.NewRequest(http.MethodPost, "/profiles", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var apiErr APIError
+		json.Unmarshal(w.Body.Bytes(), &apiErr)
+		return w.Code, apiErr
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		message string
+	}{
+		{"oversized", `{"name": "` + strings.Repeat("x", 2*int(minBodyBytes)) + `"}`, http.StatusRequestEntityTooLarge, "exceeds the 1024 byte limit"},
+		{"syntax error", `{"name": "a",}`, http.StatusBadRequest, "at byte offset 14"},
+		{"wrong type", `{"name": "a", "port": "80"}`, http.StatusBadRequest, "port should be int"},
+		{"truncated", `{"name": "a"`, http.StatusBadRequest, "ends before the JSON is complete"},
+		{"empty", ``, http.StatusBadRequest, "empty request body"},
+		{"invalid", `{"port": 80}`, http.StatusBadRequest, "invalid profile data"},
+	}
+	for _, tt := range tests {
+		status, apiErr := post(tt.body)
+		if status != tt.status || apiErr.Code != ErrCodeInvalidInput || !strings.Contains(apiErr.Message, tt.message) {
+			t.Errorf("%s: expected %d with %q, got %d %+v", tt.name, tt.status, tt.message, status, apiErr)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package mining
+
+import "fmt"
+
+// Bounds for per-miner resource limits. Anything lower stops a miner from
+// starting at all: RandomX alone maps over 2GB in fast mode.
+const (
+	minMemoryLimitMB  = 256
+	minOpenFilesLimit = 64
+	maxOpenFilesLimit = 1 << 20
+)
+
+// ResourceLimit is a soft/hard limit pair. nil means unlimited.
+type ResourceLimit struct {
+	Soft *uint64 `json:"soft"`
+	Hard *uint64 `json:"hard"`
+}
+
+// ResourceLimits reports the OS resource limits of a running miner process.
+type ResourceLimits struct {
+	Supported bool           `json:"supported"`
+	Memory    *ResourceLimit `json:"memory,omitempty"`    // Address space in bytes
+	OpenFiles *ResourceLimit `json:"openFiles,omitempty"` // File descriptors
+	Message   string         `json:"message,omitempty"`
+}
+
+// resourceLimiter is implemented by miners that run an OS process.
+type resourceLimiter interface {
+	ResourceLimits() (ResourceLimits, error)
+}
+
+// resourceLimitIssues validates a config's memory and open file limits.
+func (c *Config) resourceLimitIssues(add func(field, message string)) {
+	if c.MaxMemoryMB == 0 && c.MaxOpenFiles == 0 {
+		return
+	}
+	if !resourceLimitsSupported {
+		add("maxMemoryMB", "resource limits are not supported on this platform")
+		return
+	}
+	if c.MaxMemoryMB < 0 || (c.MaxMemoryMB > 0 && c.MaxMemoryMB < minMemoryLimitMB) {
+		add("maxMemoryMB", fmt.Sprintf("memory limit must be at least %dMB (it caps address space, so allow ~2.3GB for RandomX fast mode)", minMemoryLimitMB))
+	}
+	if c.MaxOpenFiles < 0 || (c.MaxOpenFiles > 0 && (c.MaxOpenFiles < minOpenFilesLimit || c.MaxOpenFiles > maxOpenFilesLimit)) {
+		add("maxOpenFiles", fmt.Sprintf("open files limit must be between %d and %d", minOpenFilesLimit, maxOpenFilesLimit))
+	} else if c.MaxOpenFiles > 0 {
+		if ceiling, ok := openFilesCeiling(); ok && uint64(c.MaxOpenFiles) > ceiling && !isElevated() {
+			add("maxOpenFiles", fmt.Sprintf("open files limit %d exceeds this process's hard limit %d; only root can raise it", c.MaxOpenFiles, ceiling))
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build linux
+
+package mining
+
+import (
+	"os/exec"
+
+	"golang.org/x/sys/unix"
+)
+
+// resourceLimitsSupported reports whether limits can be applied to miner processes.
+const resourceLimitsSupported = true
+
+// openFilesCeiling returns the hard open files limit a child inherits.
+func openFilesCeiling() (uint64, bool) {
+	var rlim unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rlim); err != nil || rlim.Max == unix.RLIM_INFINITY {
+		return 0, false
+	}
+	return rlim.Max, true
+}
+
+// applyResourceLimits sets the config's limits on a started process with
+// prlimit, as both the soft and hard limit so the miner can't raise them.
+// Memory is capped as address space (RLIMIT_AS): Linux doesn't enforce RLIMIT_RSS.
+// exec.Cmd has no hook between fork and exec, so the process runs unlimited
+// for the moment between Start and this call; allocations and files it holds
+// by then are kept, but it can't grow past the limits afterwards.
+func applyResourceLimits(cmd *exec.Cmd, config *Config) error {
+	pid := cmd.Process.Pid
+	if config.MaxMemoryMB > 0 {
+		limit := uint64(config.MaxMemoryMB) << 20
+		if err := unix.Prlimit(pid, unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit}, nil); err != nil {
+			return err
+		}
+	}
+	if config.MaxOpenFiles > 0 {
+		limit := uint64(config.MaxOpenFiles)
+		if err := unix.Prlimit(pid, unix.RLIMIT_NOFILE, &unix.Rlimit{Cur: limit, Max: limit}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readResourceLimits returns the current limits of a process.
+func readResourceLimits(pid int) (ResourceLimits, error) {
+	read := func(resource int) (*ResourceLimit, error) {
+		var rlim unix.Rlimit
+		if err := unix.Prlimit(pid, resource, nil, &rlim); err != nil {
+			return nil, err
+		}
+		limit := &ResourceLimit{}
+		if rlim.Cur != unix.RLIM_INFINITY {
+			limit.Soft = &rlim.Cur
+		}
+		if rlim.Max != unix.RLIM_INFINITY {
+			limit.Hard = &rlim.Max
+		}
+		return limit, nil
+	}
+
+	memory, err := read(unix.RLIMIT_AS)
+	if err != nil {
+		return ResourceLimits{}, err
+	}
+	openFiles, err := read(unix.RLIMIT_NOFILE)
+	if err != nil {
+		return ResourceLimits{}, err
+	}
+	return ResourceLimits{Supported: true, Memory: memory, OpenFiles: openFiles}, nil
+}
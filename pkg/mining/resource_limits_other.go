@@ -0,0 +1,27 @@
+//go:build !linux
+
+package mining
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// resourceLimitsSupported reports whether limits can be applied to miner
+// processes. Setting another process's limits needs Linux's prlimit.
+const resourceLimitsSupported = false
+
+func openFilesCeiling() (uint64, bool) {
+	return 0, false
+}
+
+func applyResourceLimits(cmd *exec.Cmd, config *Config) error {
+	if config.MaxMemoryMB > 0 || config.MaxOpenFiles > 0 {
+		return errors.New("resource limits are not supported on this platform")
+	}
+	return nil
+}
+
+func readResourceLimits(pid int) (ResourceLimits, error) {
+	return ResourceLimits{Message: "resource limits are only available on Linux"}, nil
+}
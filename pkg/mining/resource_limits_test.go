@@ -0,0 +1,53 @@
+package mining
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResourceLimitValidation(t *testing.T) {
+	if !resourceLimitsSupported {
+		if err := (&Config{MaxOpenFiles: 1024}).Validate(); err == nil {
+			t.Error("expected limits to be rejected where unsupported")
+		}
+		return
+	}
+
+	valid := []Config{{}, {MaxMemoryMB: 4096}, {MaxOpenFiles: minOpenFilesLimit}}
+	for _, cfg := range valid {
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", cfg, err)
+		}
+	}
+	invalid := []Config{{MaxMemoryMB: 16}, {MaxMemoryMB: -1}, {MaxOpenFiles: 8}, {MaxOpenFiles: maxOpenFilesLimit + 1}}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestHandleGetMinerLimitsNotSupported(t *testing.T) {
+	m := NewManagerForSimulation()
+	defer m.Stop()
+	m.miners["sim"] = NewSimulatedMiner(SimulatedMinerConfig{Name: "sim"})
+
+	router := gin.New()
+	service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger"}
+	service.SetupRoutes()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/miners/sim/limits", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a miner without a process, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/miners/missing/limits", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown miner, got %d", w.Code)
+	}
+}
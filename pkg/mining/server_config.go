@@ -0,0 +1,175 @@
+package mining
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// Request body limits. Uploads (backup restores, profile batches and fleet
+// deployments) get their own, larger limit so a big but legitimate payload
+// doesn't need the limit raised for every endpoint.
+const (
+	DefaultMaxBodyBytes   int64 = 1 << 20
+	DefaultMaxUploadBytes int64 = maxBackupSize
+	minBodyBytes          int64 = 1 << 10
+)
+
+// ServerConfig holds the HTTP server timeouts and request and WebSocket message limits.
+type ServerConfig struct {
+	// ReadTimeout bounds reading a whole request, body included
+	ReadTimeout time.Duration `json:"readTimeout" yaml:"readTimeout"`
+	// ReadHeaderTimeout bounds reading the request headers
+	ReadHeaderTimeout time.Duration `json:"readHeaderTimeout" yaml:"readHeaderTimeout"`
+	// WriteTimeout bounds writing the response; raise it for slow remote clients
+	WriteTimeout time.Duration `json:"writeTimeout" yaml:"writeTimeout"`
+	// IdleTimeout is how long a keep-alive connection waits for the next request
+	IdleTimeout time.Duration `json:"idleTimeout" yaml:"idleTimeout"`
+	// MaxBodyBytes is the largest request body accepted by most endpoints
+	MaxBodyBytes int64 `json:"maxBodyBytes" yaml:"maxBodyBytes"`
+	// MaxUploadBytes is the largest request body accepted by the upload endpoints
+	MaxUploadBytes int64 `json:"maxUploadBytes" yaml:"maxUploadBytes"`
+	// WSReadLimit is the largest message a WebSocket event client may send
+	WSReadLimit int64 `json:"wsReadLimit" yaml:"wsReadLimit"`
+	// WSIdleTimeout evicts event clients idle this long; zero disables it
+	WSIdleTimeout time.Duration `json:"wsIdleTimeout" yaml:"wsIdleTimeout"`
+	// WSMaxConnectionAge evicts event clients connected this long; zero disables it
+	WSMaxConnectionAge time.Duration `json:"wsMaxConnectionAge" yaml:"wsMaxConnectionAge"`
+}
+
+// DefaultServerConfig returns the default server configuration.
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		MaxBodyBytes:      DefaultMaxBodyBytes,
+		MaxUploadBytes:    DefaultMaxUploadBytes,
+		WSReadLimit:       DefaultWSReadLimit,
+		WSIdleTimeout:     DefaultClientIdleTimeout,
+	}
+}
+
+// ServerConfigFromEnv creates server config from environment variables.
+// MINING_SERVER_READ_TIMEOUT, MINING_SERVER_READ_HEADER_TIMEOUT,
+// MINING_SERVER_WRITE_TIMEOUT, MINING_SERVER_IDLE_TIMEOUT,
+// MINING_WS_IDLE_TIMEOUT and MINING_WS_MAX_AGE take Go durations
+// such as "2m"; MINING_MAX_BODY_BYTES, MINING_MAX_UPLOAD_BYTES and
+// MINING_WS_READ_LIMIT take byte counts.
+// Values that don't parse are logged and left at their defaults.
+func ServerConfigFromEnv() ServerConfig {
+	return serverConfigWithEnv(DefaultServerConfig())
+}
+
+// serverConfigWithEnv overrides config with any server environment variables set.
+func serverConfigWithEnv(config ServerConfig) ServerConfig {
+	durations := []struct {
+		env    string
+		target *time.Duration
+	}{
+		{"MINING_SERVER_READ_TIMEOUT", &config.ReadTimeout},
+		{"MINING_SERVER_READ_HEADER_TIMEOUT", &config.ReadHeaderTimeout},
+		{"MINING_SERVER_WRITE_TIMEOUT", &config.WriteTimeout},
+		{"MINING_SERVER_IDLE_TIMEOUT", &config.IdleTimeout},
+		{"MINING_WS_IDLE_TIMEOUT", &config.WSIdleTimeout},
+		{"MINING_WS_MAX_AGE", &config.WSMaxConnectionAge},
+	}
+	for _, d := range durations {
+		v := os.Getenv(d.env)
+		if v == "" {
+			continue
+		}
+		if parsed, err := time.ParseDuration(v); err == nil {
+			*d.target = parsed
+		} else {
+			logging.Warn("invalid duration, using default", logging.Fields{"env": d.env, "value": v, "default": d.target.String()})
+		}
+	}
+
+	sizes := []struct {
+		env    string
+		target *int64
+	}{
+		{"MINING_MAX_BODY_BYTES", &config.MaxBodyBytes},
+		{"MINING_MAX_UPLOAD_BYTES", &config.MaxUploadBytes},
+		{"MINING_WS_READ_LIMIT", &config.WSReadLimit},
+	}
+	for _, s := range sizes {
+		v := os.Getenv(s.env)
+		if v == "" {
+			continue
+		}
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			*s.target = parsed
+		} else {
+			logging.Warn("invalid byte count, using default", logging.Fields{"env": s.env, "value": v, "default": *s.target})
+		}
+	}
+
+	return config
+}
+
+// Validate checks the timeouts and limits are usable. A zero read or write
+// timeout means no limit, as with http.Server, but the header timeout must be
+// set so a client can't hold a connection open by trickling headers.
+func (c ServerConfig) Validate() error {
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 {
+		return fmt.Errorf("server timeouts can't be negative")
+	}
+	if c.ReadHeaderTimeout <= 0 {
+		return fmt.Errorf("server read header timeout must be positive, got %s", c.ReadHeaderTimeout)
+	}
+	if c.MaxBodyBytes < minBodyBytes {
+		return fmt.Errorf("max body size must be at least %d bytes, got %d", minBodyBytes, c.MaxBodyBytes)
+	}
+	if c.MaxUploadBytes < c.MaxBodyBytes {
+		return fmt.Errorf("max upload size (%d bytes) can't be below the max body size (%d bytes)", c.MaxUploadBytes, c.MaxBodyBytes)
+	}
+	if c.WSIdleTimeout < 0 || c.WSMaxConnectionAge < 0 {
+		return fmt.Errorf("WebSocket eviction timeouts can't be negative")
+	}
+	if c.WSReadLimit < 1 || c.WSReadLimit > MaxWSReadLimit {
+		return fmt.Errorf("WebSocket read limit must be between 1 and %d bytes, got %d", MaxWSReadLimit, c.WSReadLimit)
+	}
+	return nil
+}
+
+// apply sets the timeouts on an HTTP server.
+func (c ServerConfig) apply(server *http.Server) {
+	server.ReadTimeout = c.ReadTimeout
+	server.ReadHeaderTimeout = c.ReadHeaderTimeout
+	server.WriteTimeout = c.WriteTimeout
+	server.IdleTimeout = c.IdleTimeout
+}
+
+// uploadRoutes returns the routes that accept MaxUploadBytes: backup restores,
+// profile batches and fleet deployments.
+func (s *Service) uploadRoutes() map[string]bool {
+	base := strings.TrimSuffix(s.APIBasePath, "/")
+	return map[string]bool{
+		base + "/restore":             true,
+		base + "/profiles/batch":      true,
+		base + "/fleet/desired-state": true,
+	}
+}
+
+// bodyLimitMiddleware caps request bodies at maxBody, or at maxUpload for the
+// given routes. Routing has happened by the time middleware runs, so the
+// route is matched on its pattern.
+func bodyLimitMiddleware(maxBody, maxUpload int64, uploadRoutes map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxBody
+		if uploadRoutes[c.FullPath()] {
+			limit = maxUpload
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,87 @@
+package mining
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServerConfigFromEnv(t *testing.T) {
+	t.Setenv("MINING_SERVER_WRITE_TIMEOUT", "2m")
+	t.Setenv("MINING_SERVER_IDLE_TIMEOUT", "soon")
+	t.Setenv("MINING_MAX_BODY_BYTES", "4194304")
+	t.Setenv("MINING_WS_READ_LIMIT", "65536")
+	t.Setenv("MINING_WS_MAX_AGE", "1h")
+
+	config := ServerConfigFromEnv()
+	if config.WriteTimeout != 2*time.Minute || config.MaxBodyBytes != 4<<20 || config.WSReadLimit != 64<<10 {
+		t.Errorf("expected the env values, got %+v", config)
+	}
+	if config.WSMaxConnectionAge != time.Hour || config.WSIdleTimeout != DefaultClientIdleTimeout {
+		t.Errorf("expected the WebSocket max age from the env and the default idle timeout, got %+v", config)
+	}
+	if config.IdleTimeout != DefaultServerConfig().IdleTimeout {
+		t.Errorf("expected an unparseable value to keep the default, got %s", config.IdleTimeout)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("expected a valid config, got %v", err)
+	}
+}
+
+func TestServerConfigValidate(t *testing.T) {
+	if err := DefaultServerConfig().Validate(); err != nil {
+		t.Fatalf("expected the defaults to be valid, got %v", err)
+	}
+	tests := map[string]func(*ServerConfig){
+		"negative timeout":      func(c *ServerConfig) { c.WriteTimeout = -time.Second },
+		"no header timeout":     func(c *ServerConfig) { c.ReadHeaderTimeout = 0 },
+		"tiny body limit":       func(c *ServerConfig) { c.MaxBodyBytes = 10 },
+		"upload below the body": func(c *ServerConfig) { c.MaxUploadBytes = c.MaxBodyBytes - 1 },
+		"no WS read limit":      func(c *ServerConfig) { c.WSReadLimit = 0 },
+		"huge WS read limit":    func(c *ServerConfig) { c.WSReadLimit = MaxWSReadLimit + 1 },
+		"negative WS max age":   func(c *ServerConfig) { c.WSMaxConnectionAge = -time.Minute },
+	}
+	for name, mutate := range tests {
+		config := DefaultServerConfig()
+		mutate(&config)
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(bodyLimitMiddleware(2048, 8192, map[string]bool{"/restore": true}))
+	read := func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	router.POST("/profiles", read)
+	router.POST("/restore", read)
+
+	post := func(path string, size int) int {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(make([]byte, size)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("/profiles", 4096); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected the body limit on a normal route, got %d", code)
+	}
+	if code := post("/restore", 4096); code != http.StatusOK {
+		t.Errorf("expected the upload limit on an upload route, got %d", code)
+	}
+	if code := post("/restore", 10000); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected the upload limit to still apply, got %d", code)
+	}
+}
@@ -2,22 +2,31 @@ package mining
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/Snider/Mining/docs"
+	"github.com/Snider/Mining/pkg/database"
 	"github.com/Snider/Mining/pkg/logging"
+	"github.com/Snider/Mining/pkg/mining/algos"
+	"github.com/Snider/Mining/pkg/node"
 	"github.com/adrg/xdg"
 	ginmcp "github.com/ckanthony/gin-mcp"
 	"github.com/gin-contrib/cors"
@@ -34,6 +43,7 @@ import (
 type Service struct {
 	Manager             ManagerInterface
 	ProfileManager      *ProfileManager
+	SettingsManager     *SettingsManager // nil if settings can't be loaded
 	NodeService         *NodeService
 	EventHub            *EventHub
 	Router              *gin.Engine
@@ -42,8 +52,20 @@ type Service struct {
 	SwaggerInstanceName string
 	APIBasePath         string
 	SwaggerUIPath       string
+	MCP                 MCPConfig       // Set before InitRouter to change whether and where MCP is mounted
+	Simulation          bool            // Set before InitRouter to mount the simulated miner fault injection routes
+	Limits              ServerConfig    // Set before InitRouter to change the request body limits
+	ConfigPath          string          // The config file ReloadAuth re-reads; empty for defaults and environment only
+	CORS                CORSConfig      // Set before InitRouter to allow more browser origins
+	RateLimit           RateLimitConfig // Set before InitRouter to change the per-client rate limit
 	rateLimiter         *RateLimiter
-	auth                *DigestAuth
+	authMu              sync.RWMutex
+	auth                *DigestAuth // nil while auth is disabled; swapped by setup
+	authConfig          AuthConfig  // The active auth settings; guarded by setupMu once serving
+	setupMu             sync.Mutex  // Serializes setup and credential reloads
+	credentialsPath     string      // Where setup stores the admin credential
+	audit               *AuditLog
+	recentEvents        *recentEvents // Feeds the fleet summary
 	mcpServer           *ginmcp.GinMCP
 }
 
@@ -203,9 +225,9 @@ func requestIDMiddleware() gin.HandlerFunc {
 
 // generateRequestID creates a unique request ID using timestamp and random bytes
 func generateRequestID() string {
-	b := make([]byte, 8)
-	_, _ = base64.StdEncoding.Decode(b, []byte(fmt.Sprintf("%d", time.Now().UnixNano())))
-	return fmt.Sprintf("%d-%x", time.Now().UnixMilli(), b[:4])
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%d-%x", time.Now().UnixMilli(), b)
 }
 
 // getRequestID extracts the request ID from gin context
@@ -332,7 +354,7 @@ func requestTimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 			c.Next()
 			return
 		}
-		if strings.HasSuffix(c.Request.URL.Path, "/events") {
+		if strings.HasSuffix(c.Request.URL.Path, "/events") || strings.HasSuffix(c.Request.URL.Path, "/events/stream") {
 			c.Next()
 			return
 		}
@@ -396,6 +418,27 @@ var wsUpgrader = websocket.Upgrader{
 
 // NewService creates a new mining service
 func NewService(manager ManagerInterface, listenAddr string, displayAddr string, swaggerNamespace string) (*Service, error) {
+	path := ServiceConfigPath()
+	config, err := LoadServiceConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	service, err := NewServiceWithConfig(config, manager, listenAddr, displayAddr, swaggerNamespace)
+	if err != nil {
+		return nil, err
+	}
+	service.ConfigPath = path
+	return service, nil
+}
+
+// NewServiceWithConfig creates a new Service from a loaded config. The
+// config's database settings aren't applied here as manager already has its
+// database; see SetDatabaseConfig.
+func NewServiceWithConfig(config ServiceConfig, manager ManagerInterface, listenAddr string, displayAddr string, swaggerNamespace string) (*Service, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	apiBasePath := "/" + strings.Trim(swaggerNamespace, "/")
 	swaggerUIPath := apiBasePath + "/swagger"
 
@@ -406,6 +449,21 @@ func NewService(manager ManagerInterface, listenAddr string, displayAddr string,
 	instanceName := "swagger_" + strings.ReplaceAll(strings.Trim(swaggerNamespace, "/"), "/", "_")
 	swag.Register(instanceName, docs.SwaggerInfo)
 
+	// Resolve install and staging directories, failing early if they can't be written
+	pathsConfig := PathsConfigFromEnv()
+	if err := pathsConfig.Validate(); err != nil {
+		return nil, err
+	}
+	SetPathsConfig(pathsConfig)
+	SetLogFileConfig(LogFileConfigFromEnv())
+	SetHTTPClientConfig(HTTPClientConfigFromEnv())
+	SetLogTimestampFormat(os.Getenv("MINING_LOG_TIMESTAMP_FORMAT"))
+
+	logging.Info("miner paths configured", logging.Fields{
+		"install_root": pathsConfig.InstallRoot,
+		"staging_dir":  pathsConfig.StagingDir,
+	})
+
 	profileManager, err := NewProfileManager()
 	if err != nil {
 		logging.Warn("failed to initialize profile manager", logging.Fields{"error": err})
@@ -416,20 +474,38 @@ func NewService(manager ManagerInterface, listenAddr string, displayAddr string,
 		}
 	}
 
+	settingsManager, err := NewSettingsManager()
+	if err != nil {
+		logging.Warn("failed to load settings, donate level policy, process priority and stats sampling not applied", logging.Fields{"error": err})
+		settingsManager = nil
+	}
+
 	// Initialize node service (optional - only fails if XDG paths are broken)
-	nodeService, err := NewNodeService()
+	nodeService, err := NewNodeServiceWithConfig(config.P2P, manager, profileManager)
 	if err != nil {
 		logging.Warn("failed to initialize node service", logging.Fields{"error": err})
 		// Continue without node service - P2P features will be unavailable
+	} else if settingsManager != nil {
+		// Share one settings manager so updates from both services are serialized
+		nodeService.settings = settingsManager
 	}
 
 	// Initialize event hub for WebSocket real-time updates
 	eventHub := NewEventHub()
+	eventHub.SetReadLimit(config.Server.WSReadLimit)
+	eventHub.SetEvictionPolicy(config.Server.WSIdleTimeout, config.Server.WSMaxConnectionAge)
 	go eventHub.Run()
+	recent := &recentEvents{}
+	eventHub.AddSink(recent)
 
 	// Wire up event hub to manager for miner events
 	if mgr, ok := manager.(*Manager); ok {
 		mgr.SetEventHub(eventHub)
+
+		// Apply the admin's donate level guardrails, default process priority and stats sampling from settings
+		if settingsManager != nil {
+			applyManagerSettings(mgr, settingsManager.Get())
+		}
 	}
 
 	// Set up state provider for WebSocket state sync on reconnect
@@ -459,34 +535,69 @@ func NewService(manager ManagerInterface, listenAddr string, displayAddr string,
 		}
 	})
 
-	// Initialize authentication from environment
-	authConfig := AuthConfigFromEnv()
+	// Initialize authentication from the config file and environment
+	authConfig := config.Auth
 	var auth *DigestAuth
+	credentialsPath, err := defaultCredentialsPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve admin credentials path: %w", err)
+	}
+	// Environment and config file credentials take precedence over the one stored by setup
+	if authConfig, err = resolveAuthConfig(authConfig, credentialsPath); err != nil {
+		return nil, err
+	}
 	if authConfig.Enabled {
 		auth = NewDigestAuth(authConfig)
 		logging.Info("API authentication enabled", logging.Fields{"realm": authConfig.Realm})
+	} else {
+		logging.Warn("API authentication disabled", logging.Fields{"hint": "complete first-run setup with POST " + apiBasePath + "/setup"})
+	}
+	if nodeService != nil {
+		nodeService.apiAuth.Store(authConfig.Enabled)
+	}
+
+	// Audit log of administrative actions (optional - the API works without it)
+	audit, err := NewAuditLog(AuditLogPathFromEnv())
+	if err != nil {
+		logging.Warn("failed to open audit log, administrative actions will not be recorded", logging.Fields{"error": err})
+		audit = nil
 	}
 
+	server := &http.Server{Addr: listenAddr}
+	config.Server.apply(server)
+
 	return &Service{
-		Manager:        manager,
-		ProfileManager: profileManager,
-		NodeService:    nodeService,
-		EventHub:       eventHub,
-		Server: &http.Server{
-			Addr:              listenAddr,
-			ReadTimeout:       30 * time.Second,
-			WriteTimeout:      30 * time.Second,
-			IdleTimeout:       60 * time.Second,
-			ReadHeaderTimeout: 10 * time.Second,
-		},
+		Manager:             manager,
+		ProfileManager:      profileManager,
+		SettingsManager:     settingsManager,
+		NodeService:         nodeService,
+		EventHub:            eventHub,
+		Server:              server,
 		DisplayAddr:         displayAddr,
 		SwaggerInstanceName: instanceName,
 		APIBasePath:         apiBasePath,
 		SwaggerUIPath:       swaggerUIPath,
+		MCP:                 MCPConfigFromEnv(),
+		Limits:              config.Server,
+		CORS:                config.CORS,
+		RateLimit:           config.RateLimit,
 		auth:                auth,
+		authConfig:          authConfig,
+		credentialsPath:     credentialsPath,
+		audit:               audit,
+		recentEvents:        recent,
 	}, nil
 }
 
+// applyManagerSettings pushes the settings the manager acts on at runtime.
+func applyManagerSettings(mgr *Manager, settings *AppSettings) {
+	mgr.SetDonateLevelPolicy(settings.DonateLevelPolicy)
+	mgr.SetProcessPriority(settings.MinerDefaults.ProcessPriority)
+	mgr.SetStatsJitter(!settings.SynchronizedStatsSampling)
+	mgr.SetShutdownTimeout(time.Duration(settings.ShutdownTimeout) * time.Second)
+	mgr.SetShutdownOrder(settings.ShutdownHighPriorityFirst)
+}
+
 // InitRouter initializes the Gin router and sets up all routes without starting an HTTP server.
 // Use this when embedding the mining service in another application (e.g., Wails).
 // After calling InitRouter, you can use the Router field directly as an http.Handler.
@@ -512,12 +623,13 @@ func (s *Service) InitRouter() {
 			"http://127.0.0.1:" + serverPort,
 			"http://wails.localhost", // Wails desktop app (uses localhost origin)
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "X-Request-ID"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID", "X-Requested-With", "If-None-Match", exportPassphraseHeader, backupPassphraseHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "X-Request-ID", "X-Hashrate-Resolution", "X-Has-More", "X-Next-Offset"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
+	corsConfig.AllowOrigins = append(corsConfig.AllowOrigins, s.CORS.AllowedOrigins...)
 	s.Router.Use(cors.New(corsConfig))
 
 	// Add security headers (SEC-LOW-4)
@@ -526,16 +638,20 @@ func (s *Service) InitRouter() {
 	// Add Content-Type validation for POST/PUT (API-MED-8)
 	s.Router.Use(contentTypeValidationMiddleware())
 
-	// Add request body size limit middleware (1MB max)
-	s.Router.Use(func(c *gin.Context) {
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 1<<20) // 1MB
-		c.Next()
-	})
+	// Add request body size limit middleware, with a larger limit for uploads
+	limits := s.Limits
+	if limits.MaxBodyBytes == 0 {
+		limits = DefaultServerConfig()
+	}
+	s.Router.Use(bodyLimitMiddleware(limits.MaxBodyBytes, limits.MaxUploadBytes, s.uploadRoutes()))
 
 	// Add CSRF protection for browser requests (SEC-MED-3)
 	// Requires X-Requested-With or Authorization header for state-changing methods
 	s.Router.Use(csrfMiddleware())
 
+	// Compress larger responses for clients that accept gzip
+	s.Router.Use(gzipMiddleware(gzipMinSize))
+
 	// Add request timeout middleware (RESIL-MED-8)
 	s.Router.Use(requestTimeoutMiddleware(DefaultRequestTimeout))
 
@@ -545,8 +661,12 @@ func (s *Service) InitRouter() {
 	// Add X-Request-ID middleware for request tracing
 	s.Router.Use(requestIDMiddleware())
 
-	// Add rate limiting (10 requests/second with burst of 20)
-	s.rateLimiter = NewRateLimiter(10, 20)
+	// Add rate limiting (10 requests/second with burst of 20 by default)
+	rateLimit := s.RateLimit
+	if rateLimit.RequestsPerSecond == 0 {
+		rateLimit = DefaultRateLimitConfig()
+	}
+	s.rateLimiter = NewRateLimiter(rateLimit.RequestsPerSecond, rateLimit.Burst)
 	s.Router.Use(s.rateLimiter.Middleware())
 
 	s.SetupRoutes()
@@ -560,8 +680,13 @@ func (s *Service) Stop() {
 	if s.EventHub != nil {
 		s.EventHub.Stop()
 	}
-	if s.auth != nil {
-		s.auth.Stop()
+	if auth := s.currentAuth(); auth != nil {
+		auth.Stop()
+	}
+	if s.audit != nil {
+		if err := s.audit.Close(); err != nil {
+			logging.Warn("failed to close audit log", logging.Fields{"error": err})
+		}
 	}
 	if s.NodeService != nil {
 		if err := s.NodeService.StopTransport(); err != nil {
@@ -576,6 +701,14 @@ func (s *Service) ServiceStartup(ctx context.Context) error {
 	s.InitRouter()
 	s.Server.Handler = s.Router
 
+	// Start accepting P2P connections if a node identity exists.
+	// A failure here (e.g. port in use) degrades P2P but doesn't stop the API.
+	if s.NodeService != nil {
+		if err := s.NodeService.AutoStartTransport(); err != nil {
+			logging.Error("failed to start P2P transport", logging.Fields{"error": err})
+		}
+	}
+
 	// Channel to capture server startup errors
 	errChan := make(chan error, 1)
 
@@ -631,34 +764,78 @@ func (s *Service) SetupRoutes() {
 	apiGroup.GET("/health", s.handleHealth)
 	apiGroup.GET("/ready", s.handleReady)
 
-	// Apply authentication middleware if enabled
-	if s.auth != nil {
-		apiGroup.Use(s.auth.Middleware())
+	// Record administrative actions, including those rejected by auth
+	if s.audit != nil {
+		apiGroup.Use(s.audit.Middleware(s.APIBasePath))
 	}
 
+	// Endpoints that expose secrets or wipe state also need the admin: while
+	// auth is disabled they only accept requests from this machine
+	admin := requireAdminMiddleware(s.authEnabled)
+
+	// First-run setup answers 409 rather than 401 once auth is enabled. Until
+	// then only this machine may claim the node
+	apiGroup.POST("/setup", admin, s.handleSetup)
+
+	// Apply authentication middleware, which passes while auth is disabled
+	apiGroup.Use(s.authMiddleware())
+	apiGroup.POST("/auth/reload", admin, s.handleReloadAuth)
+
 	{
 		apiGroup.GET("/info", s.handleGetInfo)
+		apiGroup.GET("/audit", admin, s.handleGetAudit)
 		apiGroup.GET("/metrics", s.handleMetrics)
 		apiGroup.POST("/doctor", s.handleDoctor)
+		apiGroup.GET("/doctor/system", s.handleSystemDoctor)
 		apiGroup.POST("/update", s.handleUpdateCheck)
+		apiGroup.GET("/system/hugepages", s.handleGetHugePages)
+		apiGroup.GET("/algorithms", s.handleListAlgorithms)
+		apiGroup.POST(startMiningPath, s.handleStartMining)
+		apiGroup.GET(fleetSummaryPath, s.handleFleetSummary)
+		apiGroup.GET("/settings", s.handleGetSettings)
+		apiGroup.PUT("/settings", s.handleUpdateSettings)
+		apiGroup.PATCH("/settings", s.handleUpdateSettings)
+		apiGroup.GET("/settings/start-on-boot", s.handleGetStartOnBoot)
+		apiGroup.POST("/settings/reset", admin, s.handleResetSettings)
+		apiGroup.POST("/config/reset", admin, s.handleResetConfig)
+		apiGroup.GET("/backup", admin, s.handleBackup)
+		apiGroup.POST("/restore", admin, s.handleRestore)
 
 		minersGroup := apiGroup.Group("/miners")
 		{
 			minersGroup.GET("", s.handleListMiners)
 			minersGroup.GET("/available", s.handleListAvailableMiners)
+			minersGroup.GET("/stats", s.handleGetAllMinerStats)
+			minersGroup.POST("/validate", s.handleValidateMinerConfig)
+			minersGroup.GET("/start-breakers", s.handleListStartBreakers)
+			minersGroup.DELETE("/start-breakers/:key", s.handleResetStartBreaker)
 			minersGroup.POST("/:miner_name/install", s.handleInstallMiner)
 			minersGroup.DELETE("/:miner_name/uninstall", s.handleUninstallMiner)
 			minersGroup.DELETE("/:miner_name", s.handleStopMiner)
 			minersGroup.GET("/:miner_name/stats", s.handleGetMinerStats)
 			minersGroup.GET("/:miner_name/hashrate-history", s.handleGetMinerHashrateHistory)
+			minersGroup.GET("/:miner_name/history", s.handleGetMinerHistory)
 			minersGroup.GET("/:miner_name/logs", s.handleGetMinerLogs)
+			minersGroup.GET("/:miner_name/config-used", s.handleMinerConfigUsed)
+			minersGroup.GET("/:miner_name/launch-info", s.handleMinerLaunchCommand)
+			minersGroup.GET("/:miner_name/config-diff", s.handleMinerConfigDiff)
+			minersGroup.GET("/:miner_name/limits", s.handleGetMinerLimits)
 			minersGroup.POST("/:miner_name/stdin", s.handleMinerStdin)
+			minersGroup.GET("/:miner_name/commands", s.handleListMinerCommands)
+			minersGroup.POST("/:miner_name/command", s.handleMinerCommand)
+			if s.Simulation {
+				minersGroup.POST("/:miner_name/simulate/fault", s.handleInjectSimulatedFault)
+				minersGroup.GET("/:miner_name/simulate/faults", s.handleGetSimulatedFaults)
+				minersGroup.PUT("/:miner_name/simulate/faults", s.handleSetSimulatedFaults)
+			}
 		}
 
 		// Historical data endpoints (database-backed)
 		historyGroup := apiGroup.Group("/history")
 		{
 			historyGroup.GET("/status", s.handleHistoryStatus)
+			historyGroup.GET("/stats", s.handleHistoryStorageStats)
+			historyGroup.POST("/vacuum", admin, s.handleVacuumHistory)
 			historyGroup.GET("/miners", s.handleAllMinersHistoricalStats)
 			historyGroup.GET("/miners/:miner_name", s.handleMinerHistoricalStats)
 			historyGroup.GET("/miners/:miner_name/hashrate", s.handleMinerHistoricalHashrate)
@@ -668,16 +845,35 @@ func (s *Service) SetupRoutes() {
 		{
 			profilesGroup.GET("", s.handleListProfiles)
 			profilesGroup.POST("", s.handleCreateProfile)
+			profilesGroup.POST("/batch", s.handleProfileBatch)
 			profilesGroup.GET("/:id", s.handleGetProfile)
 			profilesGroup.PUT("/:id", s.handleUpdateProfile)
 			profilesGroup.DELETE("/:id", s.handleDeleteProfile)
 			profilesGroup.POST("/:id/start", s.handleStartMinerWithProfile)
+			profilesGroup.GET("/:id/effective-config", s.handleProfileEffectiveConfig)
+			profilesGroup.GET("/:id/history", s.handleProfileHistory)
+			profilesGroup.POST("/:id/revert/:version", s.handleRevertProfile)
+		}
+
+		templatesGroup := apiGroup.Group("/templates")
+		{
+			templatesGroup.GET("", s.handleListTemplates)
+			templatesGroup.POST("", s.handleCreateTemplate)
+			templatesGroup.GET("/:id", s.handleGetTemplate)
+			templatesGroup.PUT("/:id", s.handleUpdateTemplate)
+			templatesGroup.DELETE("/:id", s.handleDeleteTemplate)
 		}
 
+		// Server-sent events fallback for clients that can't use WebSocket
+		apiGroup.GET("/events/stream", s.handleEventStream)
+		apiGroup.GET("/events/poll", s.handleEventPoll)
+
 		// WebSocket endpoint for real-time events
 		wsGroup := apiGroup.Group("/ws")
 		{
 			wsGroup.GET("/events", s.handleWebSocketEvents)
+			wsGroup.GET("/events/schema", s.handleEventSchema)
+			wsGroup.GET("/clients", admin, s.handleListWebSocketClients)
 		}
 
 		// Add P2P node endpoints if node service is available
@@ -695,15 +891,8 @@ func (s *Service) SetupRoutes() {
 	swaggerURL := ginSwagger.URL(fmt.Sprintf("http://%s%s/doc.json", s.DisplayAddr, s.SwaggerUIPath))
 	s.Router.GET(s.SwaggerUIPath+"/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, swaggerURL, ginSwagger.InstanceName(s.SwaggerInstanceName)))
 
-	// Initialize MCP server for AI assistant integration
-	// This exposes API endpoints as MCP tools for Claude, Cursor, etc.
-	s.mcpServer = ginmcp.New(s.Router, &ginmcp.Config{
-		Name:        "Mining API",
-		Description: "Mining dashboard API exposed via Model Context Protocol (MCP)",
-		BaseURL:     fmt.Sprintf("http://%s", s.DisplayAddr),
-	})
-	s.mcpServer.Mount(s.APIBasePath + "/mcp")
-	logging.Info("MCP server enabled", logging.Fields{"endpoint": s.APIBasePath + "/mcp"})
+	// Mount the MCP server for AI assistant integration if enabled
+	s.mountMCP()
 }
 
 // HealthResponse represents the health check response
@@ -712,6 +901,329 @@ type HealthResponse struct {
 	Components map[string]string `json:"components,omitempty"`
 }
 
+// handleGetSettings godoc
+// @Summary Get application settings
+// @Description Returns the persisted application settings, including window state and start-on-boot.
+// @Tags system
+// @Produce json
+// @Success 200 {object} AppSettings
+// @Failure 503 {object} APIError "Settings unavailable"
+// @Router /settings [get]
+func (s *Service) handleGetSettings(c *gin.Context) {
+	if s.SettingsManager == nil {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "settings are not available", "")
+		return
+	}
+	c.JSON(http.StatusOK, s.SettingsManager.Get())
+}
+
+// handleUpdateSettings godoc
+// @Summary Update application settings
+// @Description Merges the given fields into the current settings; fields left out keep their values. The result is validated as a whole and saved; changing startOnBoot also registers or removes the OS login entry, and settings the running service acts on (donate level policy, process priority, stats sampling, peer selection weights) take effect immediately. Also available as PATCH.
+// @Tags system
+// @Accept json
+// @Produce json
+// @Param settings body AppSettings true "Settings to change"
+// @Success 200 {object} AppSettings
+// @Failure 400 {object} APIError "Invalid settings"
+// @Failure 503 {object} APIError "Settings unavailable"
+// @Router /settings [put]
+func (s *Service) handleUpdateSettings(c *gin.Context) {
+	if s.SettingsManager == nil {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "settings are not available", "")
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("failed to read request body"))
+		return
+	}
+
+	settings, err := s.SettingsManager.Patch(body)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSettings) {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid settings", err.Error())
+			return
+		}
+		if errors.Is(err, ErrStartOnBootUnsupported) {
+			respondWithError(c, http.StatusBadRequest, ErrCodeNotSupported, "start on boot is not supported on this platform", "")
+			return
+		}
+		respondWithMiningError(c, ErrInternal("failed to save settings").WithCause(err))
+		return
+	}
+
+	if mgr, ok := s.Manager.(*Manager); ok {
+		applyManagerSettings(mgr, settings)
+	}
+	if s.NodeService != nil {
+		weights := node.DefaultSelectionWeights()
+		if settings.PeerSelectionWeights != nil {
+			weights = *settings.PeerSelectionWeights
+		}
+		// Already validated by Patch
+		_ = s.NodeService.peerRegistry.SetSelectionWeights(weights)
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// handleGetStartOnBoot godoc
+// @Summary Get start-on-boot status
+// @Description Reports whether the app is registered to start at login at the OS level (systemd user unit, LaunchAgent or Run registry key), alongside the stored preference.
+// @Tags system
+// @Produce json
+// @Success 200 {object} StartOnBootStatus
+// @Failure 503 {object} APIError "Settings unavailable"
+// @Router /settings/start-on-boot [get]
+func (s *Service) handleGetStartOnBoot(c *gin.Context) {
+	if s.SettingsManager == nil {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "settings are not available", "")
+		return
+	}
+	c.JSON(http.StatusOK, s.SettingsManager.GetStartOnBootStatus())
+}
+
+// Confirmation tokens required in the body of the reset endpoints
+const (
+	settingsResetConfirmation = "reset-settings"
+	configResetConfirmation   = "reset-config"
+)
+
+// ResetRequest confirms a reset
+type ResetRequest struct {
+	Confirm string `json:"confirm" binding:"required"`
+}
+
+// bindResetConfirmation checks the reset request carries the expected token.
+func bindResetConfirmation(c *gin.Context, token string) bool {
+	var req ResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Confirm != token {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "reset not confirmed", fmt.Sprintf("send {\"confirm\": %q} to confirm", token))
+		return false
+	}
+	return true
+}
+
+// handleResetSettings godoc
+// @Summary Reset settings to defaults
+// @Description Restores the default application settings after copying the current settings file to a timestamped .bak file. Requires {"confirm": "reset-settings"} in the body. Broadcasts a settings.reset event.
+// @Tags system
+// @Accept json
+// @Produce json
+// @Param request body ResetRequest true "Confirmation"
+// @Success 200 {object} ResetEventData
+// @Failure 400 {object} APIError "Not confirmed"
+// @Failure 503 {object} APIError "Settings unavailable"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /settings/reset [post]
+func (s *Service) handleResetSettings(c *gin.Context) {
+	if !bindResetConfirmation(c, settingsResetConfirmation) {
+		return
+	}
+	if s.SettingsManager == nil {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "settings are not available", "")
+		return
+	}
+
+	backupPath, err := s.SettingsManager.Reset()
+	if err != nil {
+		respondWithMiningError(c, ErrInternal("failed to reset settings").WithCause(err))
+		return
+	}
+	if mgr, ok := s.Manager.(*Manager); ok {
+		applyManagerSettings(mgr, s.SettingsManager.Get())
+	}
+	if s.NodeService != nil {
+		_ = s.NodeService.peerRegistry.SetSelectionWeights(node.DefaultSelectionWeights())
+	}
+
+	result := ResetEventData{Backup: backupPath}
+	logging.Info("settings reset to defaults", logging.Fields{"backup": backupPath})
+	if s.EventHub != nil {
+		s.EventHub.Broadcast(NewEvent(EventSettingsReset, result))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleResetConfig godoc
+// @Summary Reset miners config
+// @Description Stops every running miner, then replaces the miners config (autostart entries, database options, recorded binary hashes) with an empty one after copying it to a timestamped .bak file. Requires {"confirm": "reset-config"} in the body. Broadcasts a config.reset event.
+// @Tags system
+// @Accept json
+// @Produce json
+// @Param request body ResetRequest true "Confirmation"
+// @Success 200 {object} ResetEventData
+// @Failure 400 {object} APIError "Not confirmed"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /config/reset [post]
+func (s *Service) handleResetConfig(c *gin.Context) {
+	if !bindResetConfirmation(c, configResetConfirmation) {
+		return
+	}
+
+	var result ResetEventData
+	for _, miner := range s.Manager.ListMiners() {
+		name := miner.GetName()
+		if err := s.Manager.StopMiner(c.Request.Context(), name); err != nil {
+			respondWithMiningError(c, ErrStopFailed(name).WithCause(err).WithSuggestion("Stop the miner manually, then retry the reset"))
+			return
+		}
+		result.StoppedMiners = append(result.StoppedMiners, name)
+	}
+
+	backupPath, err := ResetMinersConfig()
+	if err != nil {
+		respondWithMiningError(c, ErrInternal("failed to reset miners config").WithCause(err))
+		return
+	}
+	result.Backup = backupPath
+
+	logging.Info("miners config reset", logging.Fields{"backup": backupPath, "stopped": len(result.StoppedMiners)})
+	if s.EventHub != nil {
+		s.EventHub.Broadcast(NewEvent(EventConfigReset, result))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// backupPassphraseHeader carries the backup passphrase, keeping it out of URLs and access logs.
+const backupPassphraseHeader = "X-Backup-Passphrase"
+
+// handleBackup godoc
+// @Summary Back up app configuration
+// @Description Streams a tar.gz of settings, miner config, profiles and peers for moving to another machine. Pool passwords and access tokens are masked. With secrets=true they, the node identity and private key are included, encrypted with the passphrase given in the X-Backup-Passphrase header.
+// @Tags system
+// @Produce application/gzip
+// @Param secrets query bool false "Include config secrets, the node identity and private key"
+// @Param X-Backup-Passphrase header string false "Passphrase encrypting the secrets; required with secrets=true"
+// @Success 200 {file} file "Backup archive"
+// @Failure 400 {object} APIError "Passphrase missing"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /backup [get]
+func (s *Service) handleBackup(c *gin.Context) {
+	opts := BackupOptions{
+		IncludeSecrets: c.Query("secrets") == "true",
+		Passphrase:     c.GetHeader(backupPassphraseHeader),
+	}
+	if opts.IncludeSecrets && opts.Passphrase == "" {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "a passphrase is required to include secrets", "set the "+backupPassphraseHeader+" header")
+		return
+	}
+
+	filename := fmt.Sprintf("mining-backup-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := CreateBackup(c.Writer, opts); err != nil {
+		// Headers are already sent, so the client sees a truncated archive
+		logging.Error("failed to write backup", logging.Fields{"error": err})
+	}
+}
+
+// handleRestore godoc
+// @Summary Restore app configuration
+// @Description Validates every component of a backup archive produced by GET /backup and writes those that differ from the current files. Nothing is written if any component is invalid. With dryRun=true only reports what would change. Settings and profiles take effect immediately; miner config, peers and node identity after a restart.
+// @Tags system
+// @Accept application/gzip
+// @Produce json
+// @Param dryRun query bool false "Report changes without applying them"
+// @Param X-Backup-Passphrase header string false "Passphrase for the secrets in the backup"
+// @Success 200 {object} RestoreReport
+// @Failure 400 {object} APIError "Invalid backup"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /restore [post]
+func (s *Service) handleRestore(c *gin.Context) {
+	opts := RestoreOptions{
+		DryRun:     c.Query("dryRun") == "true",
+		Passphrase: c.GetHeader(backupPassphraseHeader),
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupSize)
+
+	report, err := RestoreBackup(body, opts)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithMiningError(c, ErrRequestTooLarge(tooLarge.Limit))
+			return
+		}
+		if errors.Is(err, ErrInvalidBackup) {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid backup", err.Error())
+			return
+		}
+		respondWithMiningError(c, ErrInternal("failed to restore backup").WithCause(err))
+		return
+	}
+
+	if !opts.DryRun {
+		s.reloadRestoredConfig()
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// reloadRestoredConfig picks up restored settings, profiles and templates without a restart.
+func (s *Service) reloadRestoredConfig() {
+	if s.SettingsManager != nil {
+		if err := s.SettingsManager.Load(); err != nil {
+			logging.Warn("failed to reload restored settings", logging.Fields{"error": err})
+		} else if mgr, ok := s.Manager.(*Manager); ok {
+			applyManagerSettings(mgr, s.SettingsManager.Get())
+		}
+	}
+	if s.ProfileManager != nil {
+		if err := s.ProfileManager.loadProfiles(); err != nil && !os.IsNotExist(err) {
+			logging.Warn("failed to reload restored profiles", logging.Fields{"error": err})
+		}
+		if err := s.ProfileManager.loadTemplates(); err != nil && !os.IsNotExist(err) {
+			logging.Warn("failed to reload restored templates", logging.Fields{"error": err})
+		}
+	}
+}
+
+// handleGetAudit godoc
+// @Summary Query the audit log
+// @Description Returns recorded administrative actions (mutating API requests), oldest first. While API auth is disabled only local callers may read it.
+// @Tags system
+// @Produce json
+// @Param since query string false "Only entries at or after this RFC3339 time"
+// @Param action query string false "Only entries whose action contains this text, e.g. install or POST /miners"
+// @Param limit query int false "Maximum entries, most recent kept (max 10000)" default(1000)
+// @Success 200 {array} AuditEntry
+// @Failure 400 {object} APIError "Invalid query"
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Failure 503 {object} APIError "Audit log unavailable"
+// @Router /audit [get]
+func (s *Service) handleGetAudit(c *gin.Context) {
+	if s.audit == nil {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "audit log is not available", "")
+		return
+	}
+
+	var since time.Time
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "since must be an RFC3339 time", err.Error())
+			return
+		}
+		since = t
+	}
+	limit := 1000
+	if v := c.Query("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 10000 {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "limit must be between 1 and 10000", "")
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := s.audit.Query(since, c.Query("action"), limit)
+	if err != nil {
+		respondWithMiningError(c, ErrInternal("failed to read audit log").WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
 // handleHealth godoc
 // @Summary Health check endpoint
 // @Description Returns service health status. Used for liveness probes.
@@ -761,9 +1273,17 @@ func (s *Service) handleReady(c *gin.Context) {
 		allReady = false
 	}
 
-	// Check node service (optional)
+	// Check node service (optional, never fails readiness)
 	if s.NodeService != nil {
-		components["p2p"] = "ready"
+		transport := s.NodeService.TransportStatus()
+		switch {
+		case transport.Listening:
+			components["p2p"] = "listening on " + transport.ListenAddr
+		case transport.Error != "":
+			components["p2p"] = "degraded: " + transport.Error
+		default:
+			components["p2p"] = "ready (not listening)"
+		}
 	} else {
 		components["p2p"] = "disabled"
 	}
@@ -783,10 +1303,12 @@ func (s *Service) handleReady(c *gin.Context) {
 
 // handleGetInfo godoc
 // @Summary Get live miner installation information
-// @Description Retrieves live installation details for all miners, along with system information.
+// @Description Retrieves live installation details for all miners, along with system information. The ETag changes with anything but the timestamp; send it as If-None-Match to get 304 Not Modified while nothing changed.
 // @Tags system
 // @Produce  json
+// @Param If-None-Match header string false "ETag of the info the client has"
 // @Success 200 {object} SystemInfo
+// @Success 304 "Nothing changed"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /info [get]
 func (s *Service) handleGetInfo(c *gin.Context) {
@@ -795,9 +1317,30 @@ func (s *Service) handleGetInfo(c *gin.Context) {
 		respondWithMiningError(c, ErrInternal("failed to get system info").WithCause(err))
 		return
 	}
+	mcp := s.MCPStatus()
+	systemInfo.MCP = &mcp
+	systemInfo.SetupComplete = s.currentAuth() != nil
+
+	// The timestamp changes on every check, so leave it out of the ETag
+	untimed := *systemInfo
+	untimed.Timestamp = time.Time{}
+	if etag, err := hashETag(untimed); err == nil && notModified(c, etag) {
+		return
+	}
 	c.JSON(http.StatusOK, systemInfo)
 }
 
+// handleGetHugePages godoc
+// @Summary Get OS huge pages availability
+// @Description Reports how many huge pages the operating system has reserved, so the UI can guide setup before mining with hugePages enabled.
+// @Tags system
+// @Produce  json
+// @Success 200 {object} HugePagesInfo
+// @Router /system/hugepages [get]
+func (s *Service) handleGetHugePages(c *gin.Context) {
+	c.JSON(http.StatusOK, GetHugePagesInfo())
+}
+
 // updateInstallationCache performs a live check and updates the cache file.
 func (s *Service) updateInstallationCache() (*SystemInfo, error) {
 	// Always create a complete SystemInfo object
@@ -808,6 +1351,8 @@ func (s *Service) updateInstallationCache() (*SystemInfo, error) {
 		GoVersion:           runtime.Version(),
 		AvailableCPUCores:   runtime.NumCPU(),
 		InstalledMinersInfo: []*InstallationDetails{}, // Initialize as empty slice
+		Paths:               GetPathsConfig(),
+		Privileges:          DetectPrivileges(),
 	}
 
 	vMem, err := mem.VirtualMemory()
@@ -824,6 +1369,13 @@ func (s *Service) updateInstallationCache() (*SystemInfo, error) {
 		if err != nil {
 			logging.Warn("failed to check installation", logging.Fields{"miner": availableMiner.Name, "error": err})
 		}
+		if details != nil && details.IsInstalled {
+			if status, _, err := CheckBinaryIntegrity(details.MinerBinary); err == nil {
+				details.Integrity = status
+			} else {
+				logging.Warn("failed to verify miner binary", logging.Fields{"miner": availableMiner.Name, "error": err})
+			}
+		}
 		systemInfo.InstalledMinersInfo = append(systemInfo.InstalledMinersInfo, details)
 	}
 
@@ -864,6 +1416,17 @@ func (s *Service) handleDoctor(c *gin.Context) {
 	c.JSON(http.StatusOK, systemInfo)
 }
 
+// handleSystemDoctor godoc
+// @Summary Check mining prerequisites
+// @Description Checks host prerequisites that affect hashrate (huge pages, MSR access, CPU governor, NUMA balancing, privileges) and returns each with pass/warn/fail/skip and a remediation hint.
+// @Tags system
+// @Produce  json
+// @Success 200 {object} SystemDoctorReport
+// @Router /doctor/system [get]
+func (s *Service) handleSystemDoctor(c *gin.Context) {
+	c.JSON(http.StatusOK, RunSystemChecks())
+}
+
 // handleUpdateCheck godoc
 // @Summary Check for miner updates
 // @Description Checks if any installed miners have a new version available for download.
@@ -934,13 +1497,35 @@ func (s *Service) handleUninstallMiner(c *gin.Context) {
 
 // handleListMiners godoc
 // @Summary List all running miners
-// @Description Get a list of all running miners
+// @Description Get a list of all running miners. Each reports its lifecycle state: starting, running, paused or errored.
+// @Description The ETag changes when a miner starts, stops or changes state; send it as If-None-Match to get 304 Not Modified until then. A 304 doesn't mean stats are unchanged: follow those with /miners/stats or the miner.stats events.
+// @Tags miners
+// @Produce  json
+// @Description Send Accept: application/x-ndjson to receive one miner per line instead of an array.
 // @Tags miners
 // @Produce  json
+// @Produce  application/x-ndjson
+// @Param If-None-Match header string false "ETag of the list the client has"
 // @Success 200 {array} XMRigMiner
+// @Success 304 "The list hasn't changed"
 // @Router /miners [get]
 func (s *Service) handleListMiners(c *gin.Context) {
+	ndjson := wantsNDJSON(c)
+	// Read the version first, so a change while listing gives a stale ETag, never a stale body
+	if manager, ok := s.Manager.(*Manager); ok {
+		name := "miners"
+		if ndjson {
+			name = "miners-ndjson"
+		}
+		if notModified(c, versionETag(name, manager.MinersVersion())) {
+			return
+		}
+	}
 	miners := s.Manager.ListMiners()
+	if ndjson {
+		respondNDJSON(c, miners)
+		return
+	}
 	c.JSON(http.StatusOK, miners)
 }
 
@@ -956,6 +1541,30 @@ func (s *Service) handleListAvailableMiners(c *gin.Context) {
 	c.JSON(http.StatusOK, miners)
 }
 
+// handleListAlgorithms godoc
+// @Summary List known mining algorithms
+// @Description Lists the algorithms in the algos registry with their coins, hashrate unit, typical hashrate, wallet address format and the miners that support them, for building algorithm pickers.
+// @Tags miners
+// @Produce  json
+// @Param miner query string false "Only algorithms this miner type supports"
+// @Param hardware query string false "Only algorithms mined on this hardware (cpu or gpu)"
+// @Success 200 {array} algos.Algorithm
+// @Router /algorithms [get]
+func (s *Service) handleListAlgorithms(c *gin.Context) {
+	minerType, hardware := c.Query("miner"), c.Query("hardware")
+	list := []algos.Algorithm{}
+	for _, algorithm := range algos.All() {
+		if minerType != "" && !algorithm.SupportedBy(minerType) {
+			continue
+		}
+		if hardware != "" && !algorithm.MinedOn(hardware) {
+			continue
+		}
+		list = append(list, algorithm)
+	}
+	c.JSON(http.StatusOK, list)
+}
+
 // handleInstallMiner godoc
 // @Summary Install or update a miner
 // @Description Install a new miner or update an existing one.
@@ -973,6 +1582,12 @@ func (s *Service) handleInstallMiner(c *gin.Context) {
 	}
 
 	if err := miner.Install(); err != nil {
+		// Preserve specific install errors such as insufficient disk space
+		var miningErr *MiningError
+		if errors.As(err, &miningErr) {
+			respondWithMiningError(c, miningErr)
+			return
+		}
 		respondWithMiningError(c, ErrInstallFailed(minerType).WithCause(err))
 		return
 	}
@@ -990,9 +1605,34 @@ func (s *Service) handleInstallMiner(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "installed", "version": details.Version, "path": details.Path})
 }
 
+// ValidateConfigRequest is a miner config to check without starting anything
+type ValidateConfigRequest struct {
+	MinerType string `json:"minerType" binding:"required"`
+	Config    Config `json:"config"`
+}
+
+// handleValidateMinerConfig godoc
+// @Summary Validate a miner config
+// @Description Checks a config for a miner type without side effects, returning field-level errors (which would prevent starting) and advisory warnings, including wallets that don't match the address format of the configured coin or algo
+// @Tags miners
+// @Accept json
+// @Produce json
+// @Param request body ValidateConfigRequest true "Miner type and config"
+// @Success 200 {object} ConfigValidationResult
+// @Failure 400 {object} APIError "Invalid request body"
+// @Router /miners/validate [post]
+func (s *Service) handleValidateMinerConfig(c *gin.Context) {
+	var req ValidateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+	c.JSON(http.StatusOK, ValidateMinerConfig(req.MinerType, &req.Config))
+}
+
 // handleStartMinerWithProfile godoc
 // @Summary Start a new miner using a profile
-// @Description Start a new miner with the configuration from a saved profile
+// @Description Start a new miner with the configuration from a saved profile, merged over its template if it has one
 // @Tags profiles
 // @Produce  json
 // @Param id path string true "Profile ID"
@@ -1000,9 +1640,9 @@ func (s *Service) handleInstallMiner(c *gin.Context) {
 // @Router /profiles/{id}/start [post]
 func (s *Service) handleStartMinerWithProfile(c *gin.Context) {
 	profileID := c.Param("id")
-	profile, exists := s.ProfileManager.GetProfile(profileID)
-	if !exists {
-		respondWithMiningError(c, ErrProfileNotFound(profileID))
+	profile, err := s.ProfileManager.ResolvedProfile(profileID)
+	if err != nil {
+		respondWithMiningError(c, asMiningError(err))
 		return
 	}
 
@@ -1018,25 +1658,81 @@ func (s *Service) handleStartMinerWithProfile(c *gin.Context) {
 		return
 	}
 
-	miner, err := s.Manager.StartMiner(c.Request.Context(), profile.MinerType, &config)
+	var miner Miner
+	if manager, ok := s.Manager.(*Manager); ok {
+		miner, err = manager.StartMinerWithProfile(c.Request.Context(), profile.MinerType, profile.ID, &config)
+	} else {
+		miner, err = s.Manager.StartMiner(c.Request.Context(), profile.MinerType, &config)
+	}
 	if err != nil {
+		var miningErr *MiningError
+		if errors.As(err, &miningErr) {
+			respondWithMiningError(c, miningErr)
+			return
+		}
 		respondWithMiningError(c, ErrStartFailed(profile.Name).WithCause(err))
 		return
 	}
 	c.JSON(http.StatusOK, miner)
 }
 
+// handleListStartBreakers godoc
+// @Summary List start breakers
+// @Description Lists profiles and miner types whose starts have recently failed. An open breaker rejects starts with SERVICE_UNAVAILABLE until its cooldown passes or it is reset.
+// @Tags miners
+// @Produce  json
+// @Success 200 {array} StartBreakerStatus
+// @Router /miners/start-breakers [get]
+func (s *Service) handleListStartBreakers(c *gin.Context) {
+	manager, ok := s.Manager.(*Manager)
+	if !ok {
+		respondWithMiningError(c, ErrInternal("manager type not supported"))
+		return
+	}
+	c.JSON(http.StatusOK, manager.StartBreakers())
+}
+
+// handleResetStartBreaker godoc
+// @Summary Reset a start breaker
+// @Description Closes a start breaker so the profile or miner type can be started again immediately, e.g. after fixing its config.
+// @Tags miners
+// @Produce  json
+// @Param key path string true "Breaker key, e.g. profile:<id> or miner:xmrig"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} APIError
+// @Router /miners/start-breakers/{key} [delete]
+func (s *Service) handleResetStartBreaker(c *gin.Context) {
+	manager, ok := s.Manager.(*Manager)
+	if !ok {
+		respondWithMiningError(c, ErrInternal("manager type not supported"))
+		return
+	}
+	if err := manager.ResetStartBreaker(c.Param("key")); err != nil {
+		respondWithError(c, http.StatusNotFound, ErrCodeInvalidInput, "start breaker not found", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "reset"})
+}
+
 // handleStopMiner godoc
 // @Summary Stop a running miner
-// @Description Stop a running miner by its name
+// @Description Stop a running miner by its instance name, or by a base name such as "xmrig" that matches exactly one instance
 // @Tags miners
 // @Produce  json
 // @Param miner_name path string true "Miner Name"
 // @Success 200 {object} map[string]string
+// @Failure 404 {object} APIError "No miner matches the name"
+// @Failure 409 {object} APIError "The base name matches several miners"
 // @Router /miners/{miner_name} [delete]
 func (s *Service) handleStopMiner(c *gin.Context) {
 	minerName := c.Param("miner_name")
 	if err := s.Manager.StopMiner(c.Request.Context(), minerName); err != nil {
+		// An unknown or ambiguous name isn't a failure to stop
+		var miningErr *MiningError
+		if errors.As(err, &miningErr) && (miningErr.Code == ErrCodeMinerNotFound || miningErr.Code == ErrCodeInvalidInput) {
+			respondWithMiningError(c, miningErr)
+			return
+		}
 		respondWithMiningError(c, ErrStopFailed(minerName).WithCause(err))
 		return
 	}
@@ -1063,14 +1759,109 @@ func (s *Service) handleGetMinerStats(c *gin.Context) {
 		respondWithMiningError(c, ErrInternal("failed to get miner stats").WithCause(err))
 		return
 	}
-	c.JSON(http.StatusOK, stats)
-}
+	if manager, ok := s.Manager.(*Manager); ok {
+		if history, err := manager.GetMinerHistory(minerName); err == nil {
+			history.applyTo(stats)
+		}
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// MinerStatsResult is one miner's stats, or why they couldn't be collected
+type MinerStatsResult struct {
+	Name  string              `json:"name"`
+	Stats *PerformanceMetrics `json:"stats,omitempty"`
+	Error string              `json:"error,omitempty"`
+}
+
+// AllMinerStatsResponse is the stats of every miner
+type AllMinerStatsResponse struct {
+	Miners []MinerStatsResult `json:"miners"`
+}
+
+// handleGetAllMinerStats godoc
+// @Summary Get all miners' stats
+// @Description Get the stats of every miner in one request, collected in parallel with a per-miner timeout. A miner whose stats couldn't be collected has an error instead. Use it for a dashboard's initial load, then follow the miner.stats events.
+// @Tags miners
+// @Produce  json
+// @Success 200 {object} AllMinerStatsResponse
+// @Router /miners/stats [get]
+func (s *Service) handleGetAllMinerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, AllMinerStatsResponse{Miners: s.collectAllMinerStats(c.Request.Context())})
+}
+
+// collectAllMinerStats gets every miner's stats in parallel, sorted by name.
+// Each miner gets statsCollectionTimeout, so one hung API doesn't hold up the rest.
+func (s *Service) collectAllMinerStats(ctx context.Context) []MinerStatsResult {
+	miners := s.Manager.ListMiners()
+	manager, _ := s.Manager.(*Manager)
+	results := make([]MinerStatsResult, len(miners))
+
+	var wg sync.WaitGroup
+	for i, miner := range miners {
+		wg.Add(1)
+		go func(result *MinerStatsResult, miner Miner) {
+			defer wg.Done()
+			result.Name = miner.GetName()
+			defer func() {
+				if r := recover(); r != nil {
+					logging.Error("panic in miner stats collection", logging.Fields{"panic": r, "miner": result.Name})
+					result.Stats, result.Error = nil, "stats collection failed"
+				}
+			}()
+
+			statsCtx, cancel := context.WithTimeout(ctx, statsCollectionTimeout)
+			defer cancel()
+			stats, err := miner.GetStats(statsCtx)
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+			if manager != nil {
+				if history, err := manager.GetMinerHistory(result.Name); err == nil {
+					history.applyTo(stats)
+				}
+			}
+			result.Stats = stats
+		}(&results[i], miner)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// handleGetMinerHistory godoc
+// @Summary Get a miner's crash and restart history
+// @Description Returns how many times the crash supervisor restarted the miner, and when and why its process exited without being stopped (exit status and the tail of stderr). Set restartOnCrash in the config to restart crashed miners. Stopping the miner clears its history.
+// @Tags miners
+// @Produce  json
+// @Param miner_name path string true "Miner Name"
+// @Success 200 {object} MinerHistory
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/history [get]
+func (s *Service) handleGetMinerHistory(c *gin.Context) {
+	minerName := c.Param("miner_name")
+	manager, ok := s.Manager.(*Manager)
+	if !ok {
+		respondWithMiningError(c, ErrInternal("manager type not supported"))
+		return
+	}
+
+	history, err := manager.GetMinerHistory(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
 
 // handleGetMinerHashrateHistory godoc
 // @Summary Get miner hashrate history
-// @Description Get historical hashrate data for a running miner
+// @Description Get historical hashrate data for a running miner. Send Accept: application/x-ndjson to receive one point per line instead of an array.
 // @Tags miners
 // @Produce  json
+// @Produce  application/x-ndjson
 // @Param miner_name path string true "Miner Name"
 // @Success 200 {array} HashratePoint
 // @Router /miners/{miner_name}/hashrate-history [get]
@@ -1081,16 +1872,30 @@ func (s *Service) handleGetMinerHashrateHistory(c *gin.Context) {
 		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
 		return
 	}
+	if wantsNDJSON(c) {
+		respondNDJSON(c, history)
+		return
+	}
 	c.JSON(http.StatusOK, history)
 }
 
 // handleGetMinerLogs godoc
 // @Summary Get miner log output
 // @Description Get the captured stdout/stderr output from a running miner. Log lines are base64 encoded to preserve ANSI escape codes and special characters.
+// @Description Lines can be filtered server-side; patterns are matched against the decoded text with ANSI codes removed.
 // @Tags miners
 // @Produce  json
 // @Param miner_name path string true "Miner Name"
+// @Param grep query string false "Regular expression lines must match"
+// @Param level query string false "Minimum severity: error, warn, info or all"
+// @Param tail query int false "Return only the last N matching lines"
+// @Param ignore_case query bool false "Match the grep pattern case-insensitively"
+// @Param invert query bool false "Return lines that do NOT match the grep pattern"
+// @Param format query string false "Set to 'structured' for {ts, text, stream} entries with raw timestamps instead of base64 lines"
+// @Param stream query string false "Only lines from stdout or stderr; both (default) keeps them interleaved in arrival order"
 // @Success 200 {array} string "Base64 encoded log lines"
+// @Failure 400 {object} APIError "Invalid filter"
+// @Failure 404 {object} APIError "Miner not found"
 // @Router /miners/{miner_name}/logs [get]
 func (s *Service) handleGetMinerLogs(c *gin.Context) {
 	minerName := c.Param("miner_name")
@@ -1099,7 +1904,51 @@ func (s *Service) handleGetMinerLogs(c *gin.Context) {
 		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
 		return
 	}
-	logs := miner.GetLogs()
+
+	tail := 0
+	if v := c.Query("tail"); v != "" {
+		if tail, err = strconv.Atoi(v); err != nil {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid tail value", err.Error())
+			return
+		}
+	}
+	filter, err := NewLogFilter(c.Query("grep"), c.Query("level"), queryBool(c, "ignore_case"), queryBool(c, "invert"), tail)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid log filter", err.Error())
+		return
+	}
+	stream, err := ParseLogStream(c.Query("stream"))
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid log filter", err.Error())
+		return
+	}
+	filter.WithStream(stream)
+	entryMiner, hasEntries := miner.(interface{ GetLogEntries() []LogEntry })
+
+	// Structured entries carry the raw timestamp; JSON escaping preserves ANSI codes
+	if c.Query("format") == "structured" {
+		if !hasEntries {
+			respondWithError(c, http.StatusBadRequest, ErrCodeNotSupported, "structured logs not supported by this miner", "")
+			return
+		}
+		c.JSON(http.StatusOK, filter.ApplyEntries(entryMiner.GetLogEntries()))
+		return
+	}
+
+	var logs []string
+	if stream != "" {
+		// Only entries know which stream they came from
+		if !hasEntries {
+			respondWithError(c, http.StatusBadRequest, ErrCodeNotSupported, "stream selection not supported by this miner", "")
+			return
+		}
+		layout := getLogTimestampLayout()
+		for _, entry := range filter.ApplyEntries(entryMiner.GetLogEntries()) {
+			logs = append(logs, entry.format(layout))
+		}
+	} else {
+		logs = filter.Apply(miner.GetLogs())
+	}
 	// Base64 encode each log line to preserve ANSI escape codes and special characters
 	encodedLogs := make([]string, len(logs))
 	for i, line := range logs {
@@ -1108,6 +1957,174 @@ func (s *Service) handleGetMinerLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, encodedLogs)
 }
 
+// queryBool reports whether a query parameter is set to a true value ("true", "1").
+func queryBool(c *gin.Context, key string) bool {
+	v, err := strconv.ParseBool(c.Query(key))
+	return err == nil && v
+}
+
+// handleGetMinerLimits godoc
+// @Summary Get a miner's resource limits
+// @Description Returns the current memory (address space) and open files limits of the running miner process. Set them per profile with maxMemoryMB and maxOpenFiles. They are applied right after the process starts, so memory and files it takes in its first moments are not held to them. supported is false on platforms without per-process limits.
+// @Tags miners
+// @Produce  json
+// @Param miner_name path string true "Miner Name"
+// @Success 200 {object} ResourceLimits
+// @Failure 400 {object} APIError "Miner has no OS process"
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/limits [get]
+func (s *Service) handleGetMinerLimits(c *gin.Context) {
+	minerName := c.Param("miner_name")
+	miner, err := s.Manager.GetMiner(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return
+	}
+
+	limited, ok := miner.(resourceLimiter)
+	if !ok {
+		respondWithError(c, http.StatusBadRequest, ErrCodeNotSupported, "resource limits not available for this miner", "")
+		return
+	}
+	limits, err := limited.ResourceLimits()
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotRunning(minerName).WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// handleMinerConfigUsed godoc
+// @Summary Get the config a running miner was started with
+// @Description Returns the effective config the manager launched the miner with (secrets masked), and the profile it came from if any. This is distinct from the on-disk miner config.
+// @Tags miners
+// @Produce  json
+// @Param miner_name path string true "Miner Name"
+// @Success 200 {object} MinerLaunchInfo
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/config-used [get]
+func (s *Service) handleMinerConfigUsed(c *gin.Context) {
+	minerName := c.Param("miner_name")
+	manager, ok := s.Manager.(*Manager)
+	if !ok {
+		respondWithMiningError(c, ErrInternal("manager type not supported"))
+		return
+	}
+
+	launch, err := manager.GetMinerLaunchInfo(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return
+	}
+	launch.Config = launch.Config.Masked()
+	c.JSON(http.StatusOK, launch)
+}
+
+// handleMinerLaunchCommand godoc
+// @Summary Get the command a running miner was executed with
+// @Description Returns the binary path, full argv, working directory and environment overrides the miner process was started with, as recorded by Start. Secrets are masked. Use config-used for the miner config.
+// @Tags miners
+// @Produce  json
+// @Param miner_name path string true "Miner Name"
+// @Success 200 {object} LaunchCommand
+// @Failure 400 {object} APIError "Miner has no OS process"
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/launch-info [get]
+func (s *Service) handleMinerLaunchCommand(c *gin.Context) {
+	minerName := c.Param("miner_name")
+	miner, err := s.Manager.GetMiner(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return
+	}
+
+	reporter, ok := miner.(launchCommandReporter)
+	if !ok {
+		respondWithError(c, http.StatusBadRequest, ErrCodeNotSupported, "launch command not available for this miner", "")
+		return
+	}
+	launch, err := reporter.LaunchCommand()
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotRunning(minerName).WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, launch)
+}
+
+// ConfigDiffResponse is the result of comparing a running miner's config with a profile
+type ConfigDiffResponse struct {
+	Miner       string            `json:"miner"`
+	ProfileID   string            `json:"profileId"`
+	InSync      bool              `json:"inSync"`
+	Differences []ConfigFieldDiff `json:"differences"`
+}
+
+// handleMinerConfigDiff godoc
+// @Summary Compare a running miner's config with a profile
+// @Description Field-by-field diff between the config a miner was requested with and a profile's config. Values the manager assigned at start, such as the API port, donate level policy and expanded worker names, are not compared. Secret values are masked. Defaults to the profile the miner was started from.
+// @Tags miners
+// @Produce  json
+// @Param miner_name path string true "Miner Name"
+// @Param profile query string false "Profile ID (defaults to the profile the miner was started from)"
+// @Success 200 {object} ConfigDiffResponse
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/config-diff [get]
+func (s *Service) handleMinerConfigDiff(c *gin.Context) {
+	minerName := c.Param("miner_name")
+	manager, ok := s.Manager.(*Manager)
+	if !ok {
+		respondWithMiningError(c, ErrInternal("manager type not supported"))
+		return
+	}
+
+	launch, err := manager.GetMinerLaunchInfo(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return
+	}
+
+	profileID := c.Query("profile")
+	if profileID == "" {
+		profileID = launch.ProfileID
+	}
+	if profileID == "" {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput,
+			"profile query parameter is required", "miner was not started from a profile")
+		return
+	}
+
+	profile, err := s.ProfileManager.ResolvedProfile(profileID)
+	if err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+
+	var profileConfig Config
+	if len(profile.Config) > 0 {
+		if err := json.Unmarshal(profile.Config, &profileConfig); err != nil {
+			respondWithMiningError(c, ErrInvalidConfig("failed to parse profile config").WithCause(err))
+			return
+		}
+	}
+
+	// Diff the real values so a changed password is still reported, then
+	// hide the secrets in the result
+	diffs, err := DiffConfigs(&profileConfig, &launch.requested)
+	if err != nil {
+		respondWithMiningError(c, ErrInternal("failed to diff configs").WithCause(err))
+		return
+	}
+	maskDiffSecrets(diffs)
+
+	c.JSON(http.StatusOK, ConfigDiffResponse{
+		Miner:       minerName,
+		ProfileID:   profileID,
+		InSync:      len(diffs) == 0,
+		Differences: diffs,
+	})
+}
+
 // StdinInput represents input to send to miner's stdin
 type StdinInput struct {
 	Input string `json:"input" binding:"required"`
@@ -1135,7 +2152,12 @@ func (s *Service) handleMinerStdin(c *gin.Context) {
 
 	var input StdinInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		respondWithMiningError(c, ErrInvalidConfig("invalid input format").WithCause(err))
+		respondWithBindError(c, err, ErrInvalidConfig("invalid input format"))
+		return
+	}
+	if len(input.Input) > maxStdinInputLength {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "input too long",
+			fmt.Sprintf("stdin input is limited to %d characters", maxStdinInputLength))
 		return
 	}
 
@@ -1147,6 +2169,216 @@ func (s *Service) handleMinerStdin(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "sent", "input": input.Input})
 }
 
+// simulatedMiner returns the named simulated miner, responding with an
+// error if there is none.
+func (s *Service) simulatedMiner(c *gin.Context) (*SimulatedMiner, bool) {
+	minerName := c.Param("miner_name")
+	miner, err := s.Manager.GetMiner(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return nil, false
+	}
+	sim, ok := miner.(*SimulatedMiner)
+	if !ok {
+		respondWithMiningError(c, &MiningError{
+			Code:       ErrCodeNotSupported,
+			Message:    fmt.Sprintf("miner %s is not simulated", minerName),
+			HTTPStatus: http.StatusBadRequest,
+		})
+		return nil, false
+	}
+	return sim, true
+}
+
+// handleInjectSimulatedFault godoc
+// @Summary Inject a fault into a simulated miner
+// @Description Make a simulated miner crash, stall, lose its pool or fail its stats API, now or after a delay, to exercise crash restarts and error events. Only mounted in simulation mode.
+// @Tags simulation
+// @Accept json
+// @Produce json
+// @Param miner_name path string true "Miner Name"
+// @Param fault body SimulatedFaultInjection true "Fault to inject"
+// @Success 202 {object} SimulatedFaultInjection
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/simulate/fault [post]
+func (s *Service) handleInjectSimulatedFault(c *gin.Context) {
+	miner, ok := s.simulatedMiner(c)
+	if !ok {
+		return
+	}
+	var inj SimulatedFaultInjection
+	if err := c.ShouldBindJSON(&inj); err != nil {
+		respondWithBindError(c, err, ErrInvalidConfig("invalid fault"))
+		return
+	}
+	if err := miner.InjectFault(inj); err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, inj)
+}
+
+// handleGetSimulatedFaults godoc
+// @Summary Get a simulated miner's fault chances
+// @Description Get the chances of a simulated miner failing on its own. Only mounted in simulation mode.
+// @Tags simulation
+// @Produce json
+// @Param miner_name path string true "Miner Name"
+// @Success 200 {object} SimulatedFaults
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/simulate/faults [get]
+func (s *Service) handleGetSimulatedFaults(c *gin.Context) {
+	miner, ok := s.simulatedMiner(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, miner.Faults())
+}
+
+// handleSetSimulatedFaults godoc
+// @Summary Set a simulated miner's fault chances
+// @Description Set the chances of a simulated miner crashing, stalling or losing its pool on each hashrate update, and of each stats request failing. Only mounted in simulation mode.
+// @Tags simulation
+// @Accept json
+// @Produce json
+// @Param miner_name path string true "Miner Name"
+// @Param faults body SimulatedFaults true "Fault chances, 0-1"
+// @Success 200 {object} SimulatedFaults
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/simulate/faults [put]
+func (s *Service) handleSetSimulatedFaults(c *gin.Context) {
+	miner, ok := s.simulatedMiner(c)
+	if !ok {
+		return
+	}
+	var faults SimulatedFaults
+	if err := c.ShouldBindJSON(&faults); err != nil {
+		respondWithBindError(c, err, ErrInvalidConfig("invalid fault chances"))
+		return
+	}
+	if err := miner.SetFaults(faults); err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	c.JSON(http.StatusOK, faults)
+}
+
+// minerCommandRefreshDelay gives the miner time to act on a command before stats are re-read
+const minerCommandRefreshDelay = 500 * time.Millisecond
+
+// MinerCommandRequest is a typed console command for a running miner
+type MinerCommandRequest struct {
+	Command MinerCommand `json:"command" binding:"required"`
+}
+
+// MinerCommandResponse reports a sent command and the miner's refreshed stats
+type MinerCommandResponse struct {
+	Miner   string              `json:"miner"`
+	Command MinerCommand        `json:"command"`
+	Stats   *PerformanceMetrics `json:"stats,omitempty"`
+}
+
+// handleListMinerCommands godoc
+// @Summary List console commands for a miner
+// @Description Lists the typed commands accepted by POST /miners/{miner_name}/command for this miner's type
+// @Tags miners
+// @Produce json
+// @Param miner_name path string true "Miner Name"
+// @Success 200 {array} string
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/commands [get]
+func (s *Service) handleListMinerCommands(c *gin.Context) {
+	minerName := c.Param("miner_name")
+	miner, err := s.Manager.GetMiner(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, SupportedMinerCommands(miner.GetType()))
+}
+
+// handleMinerCommand godoc
+// @Summary Send a typed command to a miner
+// @Description Sends a high-level command (hashrate, pause, resume, connection, results) mapped to the miner's console control key, then returns refreshed stats
+// @Tags miners
+// @Accept json
+// @Produce json
+// @Param miner_name path string true "Miner Name"
+// @Param command body MinerCommandRequest true "Command to send"
+// @Success 200 {object} MinerCommandResponse
+// @Failure 400 {object} APIError
+// @Failure 404 {object} APIError
+// @Router /miners/{miner_name}/command [post]
+func (s *Service) handleMinerCommand(c *gin.Context) {
+	minerName := c.Param("miner_name")
+	miner, err := s.Manager.GetMiner(minerName)
+	if err != nil {
+		respondWithMiningError(c, ErrMinerNotFound(minerName).WithCause(err))
+		return
+	}
+
+	var req MinerCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid request body"))
+		return
+	}
+
+	input, err := ResolveMinerCommand(miner.GetType(), req.Command)
+	if err != nil {
+		commands := SupportedMinerCommands(miner.GetType())
+		details := "this miner does not support typed commands"
+		if len(commands) > 0 {
+			names := make([]string, len(commands))
+			for i, cmd := range commands {
+				names[i] = string(cmd)
+			}
+			details = "supported commands: " + strings.Join(names, ", ")
+		}
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, err.Error(), details)
+		return
+	}
+
+	// Pause and resume change the miner's state, so refuse them where the state doesn't allow it
+	mgr, tracked := s.Manager.(*Manager)
+	pauseCommand := tracked && (req.Command == CommandPause || req.Command == CommandResume)
+	if pauseCommand {
+		if state, err := mgr.GetMinerState(minerName); err == nil {
+			if next := pausedState(req.Command == CommandPause); state != next && !state.CanTransitionTo(next) {
+				respondWithMiningError(c, ErrInvalidStateTransition(minerName, state, next))
+				return
+			}
+		}
+	}
+
+	if err := miner.WriteStdin(input); err != nil {
+		respondWithMiningError(c, ErrInternal("failed to send command").WithCause(err))
+		return
+	}
+	if pauseCommand {
+		if err := mgr.SetMinerPaused(minerName, req.Command == CommandPause); err != nil {
+			logging.Warn("failed to record miner pause state", logging.Fields{"miner": minerName, "error": err})
+		}
+	}
+
+	// Give the miner a moment to act on the command before reading stats
+	select {
+	case <-time.After(minerCommandRefreshDelay):
+	case <-c.Request.Context().Done():
+		return
+	}
+
+	resp := MinerCommandResponse{Miner: minerName, Command: req.Command}
+	if stats, err := miner.GetStats(c.Request.Context()); err == nil {
+		resp.Stats = stats
+	} else {
+		logging.Debug("failed to refresh stats after command", logging.Fields{"miner": minerName, "error": err})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // handleListProfiles godoc
 // @Summary List all mining profiles
 // @Description Get a list of all saved mining profiles
@@ -1172,7 +2404,7 @@ func (s *Service) handleListProfiles(c *gin.Context) {
 func (s *Service) handleCreateProfile(c *gin.Context) {
 	var profile MiningProfile
 	if err := c.ShouldBindJSON(&profile); err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid profile data", err.Error())
+		respondWithBindError(c, err, ErrInvalidInput("invalid profile data"))
 		return
 	}
 
@@ -1185,6 +2417,10 @@ func (s *Service) handleCreateProfile(c *gin.Context) {
 		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "miner type is required", "")
 		return
 	}
+	if err := s.ProfileManager.CheckTemplate(&profile); err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
 
 	createdProfile, err := s.ProfileManager.CreateProfile(&profile)
 	if err != nil {
@@ -1228,10 +2464,14 @@ func (s *Service) handleUpdateProfile(c *gin.Context) {
 	profileID := c.Param("id")
 	var profile MiningProfile
 	if err := c.ShouldBindJSON(&profile); err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid profile data", err.Error())
+		respondWithBindError(c, err, ErrInvalidInput("invalid profile data"))
 		return
 	}
 	profile.ID = profileID
+	if err := s.ProfileManager.CheckTemplate(&profile); err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
 
 	if err := s.ProfileManager.UpdateProfile(&profile); err != nil {
 		// Check if error is "not found"
@@ -1267,6 +2507,240 @@ func (s *Service) handleDeleteProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "profile deleted"})
 }
 
+// handleProfileEffectiveConfig godoc
+// @Summary Get a profile's effective config
+// @Description Returns the config the profile starts with: its template's tuning with the profile's fields merged over it, the profile winning. sources names where each field came from. Secrets are masked.
+// @Tags profiles
+// @Produce  json
+// @Param id path string true "Profile ID"
+// @Success 200 {object} EffectiveConfig
+// @Failure 404 {object} APIError "Profile or template not found"
+// @Router /profiles/{id}/effective-config [get]
+func (s *Service) handleProfileEffectiveConfig(c *gin.Context) {
+	profileID := c.Param("id")
+	profile, exists := s.ProfileManager.GetProfile(profileID)
+	if !exists {
+		respondWithMiningError(c, ErrProfileNotFound(profileID))
+		return
+	}
+	effective, err := s.ProfileManager.EffectiveConfig(profile)
+	if err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	effective.Config = (&MiningProfile{Config: effective.Config}).Masked().Config
+	c.JSON(http.StatusOK, effective)
+}
+
+// ProfileBatchRequest is the body of POST /profiles/batch.
+type ProfileBatchRequest struct {
+	Operations []ProfileOperation `json:"operations" binding:"required"`
+}
+
+// ProfileBatchResponse reports a batch that was applied in full.
+type ProfileBatchResponse struct {
+	Results []ProfileOperationResult `json:"results"`
+}
+
+// ProfileBatchFailure reports the operation that stopped a batch. The batch
+// was rolled back, so no operation in it was applied.
+type ProfileBatchFailure struct {
+	Error      APIError `json:"error"`
+	Operation  int      `json:"operation"` // Index of the failing operation
+	Op         string   `json:"op"`
+	ID         string   `json:"id,omitempty"`
+	RolledBack bool     `json:"rolledBack"`
+}
+
+// handleProfileBatch godoc
+// @Summary Apply several profile changes atomically
+// @Description Applies up to 100 create, update and delete operations as one change, e.g. moving every profile to a new pool. Operations are checked in order and see the results of earlier ones; if any fails, none are applied and the failing operation is reported.
+// @Tags profiles
+// @Accept  json
+// @Produce  json
+// @Param batch body ProfileBatchRequest true "Operations"
+// @Success 200 {object} ProfileBatchResponse
+// @Failure 400 {object} ProfileBatchFailure "An operation was invalid; nothing was applied"
+// @Failure 404 {object} ProfileBatchFailure "An operation targeted a missing profile; nothing was applied"
+// @Router /profiles/batch [post]
+func (s *Service) handleProfileBatch(c *gin.Context) {
+	var req ProfileBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid batch"))
+		return
+	}
+
+	results, err := s.ProfileManager.ApplyProfileBatch(req.Operations)
+	if err != nil {
+		var batchErr *ProfileBatchError
+		if !errors.As(err, &batchErr) {
+			respondWithMiningError(c, asMiningError(err))
+			return
+		}
+		miningErr := asMiningError(batchErr.Err)
+		c.JSON(miningErr.StatusCode(), ProfileBatchFailure{
+			Error: APIError{
+				Code:       miningErr.Code,
+				Message:    miningErr.Message,
+				Suggestion: miningErr.Suggestion,
+				Retryable:  miningErr.Retryable,
+			},
+			Operation:  batchErr.Index,
+			Op:         batchErr.Op,
+			ID:         batchErr.ID,
+			RolledBack: true,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, ProfileBatchResponse{Results: results})
+}
+
+// handleProfileHistory godoc
+// @Summary List a profile's previous versions
+// @Description Returns the versions a profile had before each update, newest first, with the fields each update changed. Up to 20 versions are kept. Secrets are masked.
+// @Tags profiles
+// @Produce  json
+// @Param id path string true "Profile ID"
+// @Success 200 {array} ProfileVersion
+// @Failure 404 {object} APIError "Profile not found"
+// @Router /profiles/{id}/history [get]
+func (s *Service) handleProfileHistory(c *gin.Context) {
+	history, err := s.ProfileManager.GetProfileHistory(c.Param("id"))
+	if err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	for i := range history {
+		history[i].Profile = history[i].Profile.Masked()
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// handleRevertProfile godoc
+// @Summary Revert a profile to a previous version
+// @Description Restores a version from the profile's history. The state it replaces is kept as a new version, so the revert can itself be undone.
+// @Tags profiles
+// @Produce  json
+// @Param id path string true "Profile ID"
+// @Param version path int true "Version from the profile's history"
+// @Success 200 {object} MiningProfile
+// @Failure 400 {object} APIError "Invalid version"
+// @Failure 404 {object} APIError "Profile or version not found"
+// @Router /profiles/{id}/revert/{version} [post]
+func (s *Service) handleRevertProfile(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "version must be a positive integer", "")
+		return
+	}
+	profile, err := s.ProfileManager.RevertProfile(c.Param("id"), version)
+	if err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// handleListTemplates godoc
+// @Summary List mining templates
+// @Description Get all saved tuning templates, sorted by name
+// @Tags templates
+// @Produce  json
+// @Success 200 {array} MiningTemplate
+// @Router /templates [get]
+func (s *Service) handleListTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, s.ProfileManager.GetAllTemplates())
+}
+
+// handleCreateTemplate godoc
+// @Summary Create a mining template
+// @Description Save hardware tuning (threads, affinity, huge pages...) for profiles to reference by templateId. Pool and wallet fields are rejected; they belong in the profile.
+// @Tags templates
+// @Accept  json
+// @Produce  json
+// @Param template body MiningTemplate true "Mining Template"
+// @Success 201 {object} MiningTemplate
+// @Failure 400 {object} APIError "Invalid template"
+// @Router /templates [post]
+func (s *Service) handleCreateTemplate(c *gin.Context) {
+	var template MiningTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid template data"))
+		return
+	}
+	created, err := s.ProfileManager.CreateTemplate(&template)
+	if err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// handleGetTemplate godoc
+// @Summary Get a mining template
+// @Description Get a tuning template by its ID
+// @Tags templates
+// @Produce  json
+// @Param id path string true "Template ID"
+// @Success 200 {object} MiningTemplate
+// @Failure 404 {object} APIError "Template not found"
+// @Router /templates/{id} [get]
+func (s *Service) handleGetTemplate(c *gin.Context) {
+	templateID := c.Param("id")
+	template, exists := s.ProfileManager.GetTemplate(templateID)
+	if !exists {
+		respondWithMiningError(c, ErrTemplateNotFound(templateID))
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// handleUpdateTemplate godoc
+// @Summary Update a mining template
+// @Description Replace a tuning template. Profiles using it get the new tuning the next time they start.
+// @Tags templates
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Template ID"
+// @Param template body MiningTemplate true "Updated Mining Template"
+// @Success 200 {object} MiningTemplate
+// @Failure 400 {object} APIError "Invalid template"
+// @Failure 404 {object} APIError "Template not found"
+// @Router /templates/{id} [put]
+func (s *Service) handleUpdateTemplate(c *gin.Context) {
+	var template MiningTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("invalid template data"))
+		return
+	}
+	template.ID = c.Param("id")
+	if err := s.ProfileManager.UpdateTemplate(&template); err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// handleDeleteTemplate godoc
+// @Summary Delete a mining template
+// @Description Delete a tuning template. Idempotent, but templates still referenced by profiles are kept.
+// @Tags templates
+// @Produce  json
+// @Param id path string true "Template ID"
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} APIError "Template in use"
+// @Router /templates/{id} [delete]
+func (s *Service) handleDeleteTemplate(c *gin.Context) {
+	if err := s.ProfileManager.DeleteTemplate(c.Param("id")); err != nil {
+		miningErr := asMiningError(err)
+		if miningErr.Code != ErrCodeTemplateNotFound {
+			respondWithMiningError(c, miningErr)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "template deleted"})
+}
+
 // handleHistoryStatus godoc
 // @Summary Get database history status
 // @Description Get the status of database persistence for historical data
@@ -1277,19 +2751,76 @@ func (s *Service) handleDeleteProfile(c *gin.Context) {
 func (s *Service) handleHistoryStatus(c *gin.Context) {
 	if manager, ok := s.Manager.(*Manager); ok {
 		c.JSON(http.StatusOK, gin.H{
-			"enabled":       manager.IsDatabaseEnabled(),
-			"retentionDays": manager.dbRetention,
+			"enabled":              manager.IsDatabaseEnabled(),
+			"retentionDays":        manager.dbRetention,
+			"highResRetentionDays": manager.dbHighResRetention,
 		})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"enabled": false, "error": "manager type not supported"})
 }
 
+// handleHistoryStorageStats godoc
+// @Summary Get history storage usage
+// @Description Returns the database file size, total rows, rows per miner and per resolution, and the oldest/newest stored point, to help tune retention. Cached for 30 seconds.
+// @Tags history
+// @Produce  json
+// @Success 200 {object} database.StorageStats
+// @Failure 503 {object} APIError "Database persistence is disabled"
+// @Router /history/stats [get]
+func (s *Service) handleHistoryStorageStats(c *gin.Context) {
+	manager, ok := s.Manager.(*Manager)
+	if !ok {
+		respondWithMiningError(c, ErrInternal("manager type not supported"))
+		return
+	}
+	if !manager.IsDatabaseEnabled() {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "database persistence is disabled", "")
+		return
+	}
+
+	stats, err := manager.GetDatabaseStorageStats(c.Request.Context())
+	if err != nil {
+		respondWithMiningError(c, ErrDatabaseError("get storage stats").WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// handleVacuumHistory godoc
+// @Summary Compact the history database
+// @Description Rebuilds the database file to release space freed by retention cleanup and truncates the WAL. Inserts and queries wait until it finishes, roughly the time it takes to copy the file once. Set vacuumAfterCleanup in the database config to run it daily after cleanup.
+// @Tags history
+// @Produce  json
+// @Success 200 {object} database.VacuumResult
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Failure 503 {object} APIError "Database persistence is disabled"
+// @Router /history/vacuum [post]
+func (s *Service) handleVacuumHistory(c *gin.Context) {
+	manager, ok := s.Manager.(*Manager)
+	if !ok {
+		respondWithMiningError(c, ErrInternal("manager type not supported"))
+		return
+	}
+	if !manager.IsDatabaseEnabled() {
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "database persistence is disabled", "")
+		return
+	}
+
+	result, err := manager.VacuumDatabase(c.Request.Context())
+	if err != nil {
+		respondWithMiningError(c, ErrDatabaseError("vacuum").WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // handleAllMinersHistoricalStats godoc
 // @Summary Get historical stats for all miners
-// @Description Get aggregated historical statistics for all miners from the database
+// @Description Get aggregated historical statistics for all miners from the database. Send Accept: application/x-ndjson to receive one miner per line instead of an array.
 // @Tags history
 // @Produce  json
+// @Produce  application/x-ndjson
 // @Success 200 {array} database.HashrateStats
 // @Router /history/miners [get]
 func (s *Service) handleAllMinersHistoricalStats(c *gin.Context) {
@@ -1299,12 +2830,16 @@ func (s *Service) handleAllMinersHistoricalStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := manager.GetAllMinerHistoricalStats()
+	stats, err := manager.GetAllMinerHistoricalStats(c.Request.Context())
 	if err != nil {
 		respondWithMiningError(c, ErrDatabaseError("get historical stats").WithCause(err))
 		return
 	}
 
+	if wantsNDJSON(c) {
+		respondNDJSON(c, stats)
+		return
+	}
 	c.JSON(http.StatusOK, stats)
 }
 
@@ -1324,7 +2859,7 @@ func (s *Service) handleMinerHistoricalStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := manager.GetMinerHistoricalStats(minerName)
+	stats, err := manager.GetMinerHistoricalStats(c.Request.Context(), minerName)
 	if err != nil {
 		respondWithMiningError(c, ErrDatabaseError("get miner stats").WithCause(err))
 		return
@@ -1340,13 +2875,20 @@ func (s *Service) handleMinerHistoricalStats(c *gin.Context) {
 
 // handleMinerHistoricalHashrate godoc
 // @Summary Get historical hashrate data for a specific miner
-// @Description Get detailed historical hashrate data for a specific miner from the database
+// @Description Get detailed historical hashrate data for a specific miner from the database.
+// @Description With resolution=auto (the default), ranges up to lowResQueryHours (6 hours by default) return 10-second points and longer ranges, or ranges starting before high resolution retention, return 1-minute averages; if a miner has no minute averages yet, 10-second points are returned instead.
+// @Description The resolution used is returned in the X-Hashrate-Resolution header.
+// @Description Send Accept: application/x-ndjson to stream one point per line as rows are read, rather than one array, keeping memory flat for long ranges. If reading fails part way, the last line is {"error": {...}}.
 // @Tags history
 // @Produce  json
+// @Produce  application/x-ndjson
 // @Param miner_name path string true "Miner Name"
 // @Param since query string false "Start time (RFC3339 format)"
 // @Param until query string false "End time (RFC3339 format)"
+// @Param resolution query string false "high, low or auto" default(auto)
 // @Success 200 {array} HashratePoint
+// @Header 200 {string} X-Hashrate-Resolution "Resolution used: high or low"
+// @Failure 400 {object} APIError "Invalid resolution"
 // @Router /history/miners/{miner_name}/hashrate [get]
 func (s *Service) handleMinerHistoricalHashrate(c *gin.Context) {
 	minerName := c.Param("miner_name")
@@ -1371,19 +2913,58 @@ func (s *Service) handleMinerHistoricalHashrate(c *gin.Context) {
 		}
 	}
 
-	history, err := manager.GetMinerHistoricalHashrate(minerName, since, until)
+	var resolution database.Resolution
+	switch r := c.DefaultQuery("resolution", "auto"); r {
+	case "auto":
+	case string(database.ResolutionHigh), string(database.ResolutionLow):
+		resolution = database.Resolution(r)
+	default:
+		respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid resolution", "resolution must be high, low or auto")
+		return
+	}
+
+	if wantsNDJSON(c) {
+		s.streamMinerHistoricalHashrate(c, manager, minerName, resolution, since, until)
+		return
+	}
+
+	history, used, err := manager.GetMinerHistoricalHashrateAt(c.Request.Context(), minerName, resolution, since, until)
 	if err != nil {
 		respondWithMiningError(c, ErrDatabaseError("get hashrate history").WithCause(err))
 		return
 	}
 
+	c.Header("X-Hashrate-Resolution", string(used))
 	c.JSON(http.StatusOK, history)
 }
 
+// streamMinerHistoricalHashrate writes a miner's hashrate history as NDJSON
+// as it is read from the database.
+func (s *Service) streamMinerHistoricalHashrate(c *gin.Context, manager *Manager, minerName string, resolution database.Resolution, since, until time.Time) {
+	stream := newNDJSONStream(c)
+	used, err := manager.StreamMinerHistoricalHashrate(c.Request.Context(), minerName, resolution, since, until, func(used database.Resolution, point HashratePoint) error {
+		if stream.lines == 0 {
+			c.Header("X-Hashrate-Resolution", string(used))
+		}
+		return stream.write(point)
+	})
+	if err != nil {
+		stream.fail(ErrDatabaseError("get hashrate history").WithCause(err))
+		return
+	}
+	if stream.lines == 0 {
+		c.Header("X-Hashrate-Resolution", string(used))
+	}
+	stream.close()
+}
+
 // handleWebSocketEvents godoc
 // @Summary WebSocket endpoint for real-time mining events
 // @Description Upgrade to WebSocket for real-time mining stats and events.
 // @Description Events include: miner.starting, miner.started, miner.stopping, miner.stopped, miner.stats, miner.error
+// @Description Every event carries a payload version; GET /ws/events/schema describes the payloads.
+// @Description Broadcast events carry an increasing seq. After reconnecting, send {"type":"resume","lastSeq":N} to receive the events missed since seq N; if they are no longer buffered a fresh state.sync is sent instead.
+// @Description The server closes with 1001 (going away) on shutdown, 1013 (try again later) at the connection limit or when the client is too slow to keep up, 1008 (policy violation) for malformed messages, 1009 for messages over the read limit and 1000 when evicting an idle or too old connection. Reconnect after all of them; back off longer after 1013.
 // @Tags websocket
 // @Success 101 {string} string "Switching Protocols"
 // @Router /ws/events [get]
@@ -1403,6 +2984,122 @@ func (s *Service) handleWebSocketEvents(c *gin.Context) {
 	}
 }
 
+// handleEventStream godoc
+// @Summary Server-sent events stream of real-time mining events
+// @Description Streams the same events as GET /ws/events as text/event-stream, for networks whose proxies break WebSockets. Each event's SSE id is its seq and its SSE event name its type. Reconnecting with Last-Event-ID (sent automatically by EventSource) or lastSeq replays the missed events, like a WebSocket resume.
+// @Tags websocket
+// @Produce text/event-stream
+// @Param miners query string false "Comma-separated miners to receive events of (default all)"
+// @Param types query string false "Comma-separated event types to receive, e.g. miner.stats,miner.error (default all)"
+// @Param client query string false "Client identity (name/version) shown in /ws/clients"
+// @Param lastSeq query int false "Resume after this event seq"
+// @Success 200 {string} string "Event stream"
+// @Failure 503 {object} APIError "Connection limit reached"
+// @Router /events/stream [get]
+func (s *Service) handleEventStream(c *gin.Context) {
+	sub := SSESubscription{
+		Miners: splitQueryList(c.QueryArray("miners")),
+		Types:  splitQueryList(c.QueryArray("types")),
+		Client: c.Query("client"),
+	}
+	lastSeq := c.GetHeader("Last-Event-ID")
+	if lastSeq == "" {
+		lastSeq = c.Query("lastSeq")
+	}
+	if lastSeq != "" {
+		seq, err := strconv.ParseUint(lastSeq, 10, 64)
+		if err != nil {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid lastSeq", err.Error())
+			return
+		}
+		sub.LastSeq = seq
+	}
+
+	logging.Info("new SSE connection", logging.Fields{"remote": c.Request.RemoteAddr})
+	RecordWSConnection(true) // Decremented when the hub unregisters the client
+	if !s.EventHub.ServeSSE(c.Writer, c.Request, sub) {
+		RecordWSConnection(false)
+		respondWithError(c, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "connection limit reached", "")
+	}
+}
+
+// handleEventPoll godoc
+// @Summary Long-poll for real-time mining events
+// @Description Lowest-common-denominator event transport that works through any HTTP proxy. Returns the events after since, waiting up to wait seconds (default and maximum 25) for one if there are none yet; an empty batch means nothing happened, not an error.
+// @Description Client loop: call once without since to get a state.sync event and a cursor, then repeatedly call with since set to the last cursor. When more is set, poll again right away. When reset is set the cursor was too old to catch up from (or the server restarted): replace local state with the state.sync event in the batch. On network errors, back off briefly and retry with the same cursor.
+// @Tags websocket
+// @Produce json
+// @Param since query int false "Cursor from the previous poll; omit to start"
+// @Param miners query string false "Comma-separated miners to receive events of (default all)"
+// @Param types query string false "Comma-separated event types to receive (default all)"
+// @Param wait query int false "Seconds to wait for an event (default and maximum 25)"
+// @Success 200 {object} EventPollResponse
+// @Failure 400 {object} APIError "Invalid parameters"
+// @Router /events/poll [get]
+func (s *Service) handleEventPoll(c *gin.Context) {
+	req := EventPollRequest{
+		Miners: splitQueryList(c.QueryArray("miners")),
+		Types:  splitQueryList(c.QueryArray("types")),
+	}
+	if since, ok := c.GetQuery("since"); ok {
+		seq, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "invalid since", err.Error())
+			return
+		}
+		req.Since = seq
+	} else {
+		req.Start = true
+	}
+	if wait := c.Query("wait"); wait != "" {
+		seconds, err := strconv.Atoi(wait)
+		if err != nil || seconds < 0 {
+			respondWithError(c, http.StatusBadRequest, ErrCodeInvalidInput, "wait must be a number of seconds", "")
+			return
+		}
+		req.Wait = time.Duration(seconds) * time.Second
+		if seconds == 0 {
+			req.Wait = time.Millisecond // Don't wait, just return what's buffered
+		}
+	}
+
+	resp, err := s.EventHub.Poll(c.Request.Context(), req)
+	if err != nil {
+		respondWithMiningError(c, asMiningError(err))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// splitQueryList flattens repeated and comma-separated query values.
+func splitQueryList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// handleListWebSocketClients godoc
+// @Summary List connected WebSocket clients
+// @Description Lists connected event clients with their identity, subscriptions, remote address, connect time and last activity
+// @Tags websocket
+// @Produce json
+// @Success 200 {array} WSClientInfo
+// @Failure 403 {object} APIError "Not local and API auth is disabled"
+// @Router /ws/clients [get]
+func (s *Service) handleListWebSocketClients(c *gin.Context) {
+	if s.EventHub == nil {
+		c.JSON(http.StatusOK, []WSClientInfo{})
+		return
+	}
+	c.JSON(http.StatusOK, s.EventHub.Clients())
+}
+
 // handleMetrics godoc
 // @Summary Get internal metrics
 // @Description Returns internal metrics for monitoring and debugging
@@ -0,0 +1,197 @@
+package mining
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/adrg/xdg"
+	"go.yaml.in/yaml/v3"
+)
+
+// serviceConfigFileName is the config file looked for in the config
+// directory when MINING_CONFIG_FILE isn't set.
+const serviceConfigFileName = "config.yaml"
+
+// ServiceConfig gathers the settings otherwise read from environment
+// variables, so a deployment can be described in one reviewable file.
+// Sections left out of the file keep their defaults.
+type ServiceConfig struct {
+	Auth      AuthConfig        `yaml:"auth"`
+	CORS      CORSConfig        `yaml:"cors"`
+	RateLimit RateLimitConfig   `yaml:"rateLimit"`
+	Server    ServerConfig      `yaml:"server"`
+	Database  *DatabaseConfig   `yaml:"-"` // nil keeps the miners config's database settings
+	P2P       NodeServiceConfig `yaml:"p2p"`
+}
+
+// serviceConfigFile is the on-disk form of ServiceConfig. The database
+// section is held back so a missing section can be told from an empty one.
+type serviceConfigFile struct {
+	ServiceConfig `yaml:",inline"`
+	Database      yaml.Node `yaml:"database"`
+}
+
+// CORSConfig adds browser origins allowed to call the API. Local origins
+// and the desktop app are always allowed.
+type CORSConfig struct {
+	// AllowedOrigins are extra origins, such as "https://dashboard.example.com"
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+}
+
+// corsConfigWithEnv replaces the extra origins with MINING_CORS_ORIGINS, a
+// comma-separated list, if set.
+func corsConfigWithEnv(config CORSConfig) CORSConfig {
+	if v := os.Getenv("MINING_CORS_ORIGINS"); v != "" {
+		config.AllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				config.AllowedOrigins = append(config.AllowedOrigins, origin)
+			}
+		}
+	}
+	return config
+}
+
+// Validate checks each origin is a bare http(s) scheme and host. A wildcard
+// isn't accepted as the API allows credentials.
+func (c CORSConfig) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "" {
+			return fmt.Errorf("invalid origin %q: must be a scheme and host such as https://dashboard.example.com", origin)
+		}
+	}
+	return nil
+}
+
+// Validate checks the listen address and intervals are usable.
+func (c NodeServiceConfig) Validate() error {
+	if _, _, err := net.SplitHostPort(c.ListenAddr); err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", c.ListenAddr, err)
+	}
+	if c.ReconcileInterval < 0 || c.HopProbeInterval < 0 || c.StatsPushInterval < 0 {
+		return fmt.Errorf("intervals can't be negative")
+	}
+	return nil
+}
+
+// DefaultServiceConfig returns the settings used when nothing is configured.
+func DefaultServiceConfig() ServiceConfig {
+	return ServiceConfig{
+		Auth:      DefaultAuthConfig(),
+		RateLimit: DefaultRateLimitConfig(),
+		Server:    DefaultServerConfig(),
+		P2P:       DefaultNodeServiceConfig(),
+	}
+}
+
+// ServiceConfigPath returns the config file to load: MINING_CONFIG_FILE if
+// set, otherwise config.yaml in the config directory if it exists, or "".
+func ServiceConfigPath() string {
+	if path := os.Getenv("MINING_CONFIG_FILE"); path != "" {
+		return path
+	}
+	path, err := xdg.SearchConfigFile(filepath.Join("lethean-desktop", serviceConfigFileName))
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// LoadServiceConfig reads the config file at path, YAML or JSON, over the
+// defaults and then applies environment variables, which take precedence.
+// An empty path uses the defaults and environment only. Unknown keys and
+// invalid values are errors, so a typo doesn't silently fall back to a default.
+func LoadServiceConfig(path string) (ServiceConfig, error) {
+	config := DefaultServiceConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return config, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := decodeServiceConfig(data, &config); err != nil {
+			return config, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		if info, err := os.Stat(path); err == nil && config.Auth.Password != "" && info.Mode().Perm()&0077 != 0 {
+			logging.Warn("config file holds the API password but is readable by others", logging.Fields{"path": path, "hint": "chmod 600 " + path})
+		}
+	}
+
+	config.Auth = authConfigWithEnv(config.Auth)
+	config.CORS = corsConfigWithEnv(config.CORS)
+	config.RateLimit = rateLimitConfigWithEnv(config.RateLimit)
+	config.Server = serverConfigWithEnv(config.Server)
+	config.P2P = nodeServiceConfigWithEnv(config.P2P)
+	if err := config.Auth.readPasswordFile(); err != nil {
+		return config, fmt.Errorf("invalid configuration: auth: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		if path != "" {
+			return config, fmt.Errorf("invalid configuration (file %s and environment): %w", path, err)
+		}
+		return config, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, nil
+}
+
+// decodeServiceConfig decodes data over config, rejecting unknown keys.
+func decodeServiceConfig(data []byte, config *ServiceConfig) error {
+	file := serviceConfigFile{ServiceConfig: *config}
+	if err := decodeStrict(data, &file); err != nil {
+		return err
+	}
+	*config = file.ServiceConfig
+
+	if file.Database.Kind != 0 {
+		database := defaultDatabaseConfig()
+		raw, err := yaml.Marshal(&file.Database)
+		if err != nil {
+			return fmt.Errorf("database: %w", err)
+		}
+		if err := decodeStrict(raw, &database); err != nil {
+			return fmt.Errorf("database: %w", err)
+		}
+		config.Database = &database
+	}
+	return nil
+}
+
+// decodeStrict decodes a YAML or JSON document into out, rejecting unknown
+// keys. An empty document leaves out unchanged.
+func decodeStrict(data []byte, out interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// Validate checks every section, reporting all problems at once.
+func (c ServiceConfig) Validate() error {
+	var errs []error
+	check := func(section string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", section, err))
+		}
+	}
+	check("auth", c.Auth.Validate())
+	check("cors", c.CORS.Validate())
+	check("rateLimit", c.RateLimit.Validate())
+	check("server", c.Server.Validate())
+	if c.Database != nil {
+		check("database", c.Database.Validate())
+	}
+	check("p2p", c.P2P.Validate())
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package mining
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeServiceConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadServiceConfig(t *testing.T) {
+	path := writeServiceConfig(t, "config.yaml", `
+auth:
+  enabled: true
+  username: admin
+  password: secret
+cors:
+  allowedOrigins: ["https://dashboard.example.com"]
+rateLimit:
+  requestsPerSecond: 50
+  burst: 100
+server:
+  writeTimeout: 2m
+database:
+  retentionDays: 90
+p2p:
+  listenAddr: ":9200"
+  hopProbeInterval: 0s
+`)
+	t.Setenv("MINING_P2P_LISTEN", ":9300")
+
+	config, err := LoadServiceConfig(path)
+	if err != nil {
+		t.Fatalf("LoadServiceConfig failed: %v", err)
+	}
+	if !config.Auth.Enabled || config.Auth.Username != "admin" || config.Auth.Realm != "Mining API" {
+		t.Errorf("expected auth from the file over the defaults, got %+v", config.Auth)
+	}
+	if len(config.CORS.AllowedOrigins) != 1 || config.RateLimit.Burst != 100 {
+		t.Errorf("expected CORS and rate limit from the file, got %+v, %+v", config.CORS, config.RateLimit)
+	}
+	if config.Server.WriteTimeout != 2*time.Minute || config.Server.ReadHeaderTimeout != DefaultServerConfig().ReadHeaderTimeout {
+		t.Errorf("expected only the write timeout changed, got %+v", config.Server)
+	}
+	// A partial section keeps the defaults for the rest
+	if config.Database == nil || !config.Database.Enabled || config.Database.RetentionDays != 90 {
+		t.Errorf("expected database enabled with 90 days retention, got %+v", config.Database)
+	}
+	if config.P2P.ListenAddr != ":9300" {
+		t.Errorf("expected the environment to override the file, got %s", config.P2P.ListenAddr)
+	}
+	if config.P2P.HopProbeInterval != 0 || !config.P2P.AutoStart {
+		t.Errorf("expected hop probing disabled and auto-start left on, got %+v", config.P2P)
+	}
+}
+
+func TestLoadServiceConfigJSON(t *testing.T) {
+	path := writeServiceConfig(t, "config.json", `{"rateLimit": {"requestsPerSecond": 5, "burst": 5}}`)
+	config, err := LoadServiceConfig(path)
+	if err != nil {
+		t.Fatalf("LoadServiceConfig failed: %v", err)
+	}
+	if config.RateLimit.RequestsPerSecond != 5 || config.Database != nil {
+		t.Errorf("expected the rate limit set and database left to the miners config, got %+v", config)
+	}
+}
+
+func TestLoadServiceConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"unknown key", "server:\n  writeTimeuot: 2m\n", []string{"writeTimeuot"}},
+		{"unknown database key", "database:\n  retention: 5\n", []string{"database", "retention"}},
+		{"bad duration", "server:\n  writeTimeout: soon\n", []string{"soon"}},
+		{
+			"every bad section reported",
+			"auth:\n  enabled: true\ncors:\n  allowedOrigins: ['*']\nrateLimit:\n  requestsPerSecond: 0\np2p:\n  listenAddr: nowhere\n",
+			[]string{"auth:", "cors:", "rateLimit:", "p2p:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadServiceConfig(writeServiceConfig(t, "config.yaml", tt.content))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in error, got %v", want, err)
+				}
+			}
+		})
+	}
+
+	if _, err := LoadServiceConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected a missing config file to fail")
+	}
+}
+
+func TestLoadServiceConfigDefaults(t *testing.T) {
+	config, err := LoadServiceConfig("")
+	if err != nil {
+		t.Fatalf("LoadServiceConfig failed: %v", err)
+	}
+	if config.RateLimit != DefaultRateLimitConfig() || config.Server != DefaultServerConfig() {
+		t.Errorf("expected defaults without a file, got %+v", config)
+	}
+}
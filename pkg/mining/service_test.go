@@ -2,8 +2,12 @@ package mining
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -190,6 +194,29 @@ func TestHandleStopMiner(t *testing.T) {
 	}
 }
 
+func TestHandleStopMinerUnknownName(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{ErrMinerNotFound("test-miner"), http.StatusNotFound},
+		{ErrAmbiguousMinerName("test", []string{"test-1", "test-2"}), http.StatusConflict},
+		{errors.New("kill failed"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		mockManager.StopMinerFunc = func(ctx context.Context, minerName string) error { return tt.err }
+
+		req, _ := http.NewRequest("DELETE", "/miners/test-miner", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.status, w.Code)
+		}
+	}
+}
+
 func TestHandleGetMinerStats(t *testing.T) {
 	router, mockManager := setupTestRouter()
 	mockManager.GetMinerFunc = func(minerName string) (Miner, error) {
@@ -210,6 +237,51 @@ func TestHandleGetMinerStats(t *testing.T) {
 	}
 }
 
+func TestHandleGetAllMinerStats(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	newMiner := func(name string, getStats func(ctx context.Context) (*PerformanceMetrics, error)) Miner {
+		return &MockMiner{GetNameFunc: func() string { return name }, GetStatsFunc: getStats}
+	}
+	mockManager.ListMinersFunc = func() []Miner {
+		return []Miner{
+			newMiner("b-hung", func(ctx context.Context) (*PerformanceMetrics, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}),
+			newMiner("a-ok", func(ctx context.Context) (*PerformanceMetrics, error) {
+				return &PerformanceMetrics{Hashrate: 100}, nil
+			}),
+			newMiner("c-failed", func(ctx context.Context) (*PerformanceMetrics, error) {
+				return nil, errors.New("connection refused")
+			}),
+		}
+	}
+
+	// A hung miner holds the response up only until the request is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/miners/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp AllMinerStatsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Miners) != 3 {
+		t.Fatalf("expected 3 miners, got %+v", resp.Miners)
+	}
+	if ok := resp.Miners[0]; ok.Name != "a-ok" || ok.Stats == nil || ok.Stats.Hashrate != 100 || ok.Error != "" {
+		t.Errorf("unexpected result for a-ok: %+v", ok)
+	}
+	if hung := resp.Miners[1]; hung.Name != "b-hung" || hung.Stats != nil || hung.Error == "" {
+		t.Errorf("expected an error for b-hung, got %+v", hung)
+	}
+	if failed := resp.Miners[2]; failed.Name != "c-failed" || failed.Error != "connection refused" {
+		t.Errorf("expected an error for c-failed, got %+v", failed)
+	}
+}
+
 func TestHandleGetMinerHashrateHistory(t *testing.T) {
 	router, mockManager := setupTestRouter()
 	mockManager.GetMinerHashrateHistoryFunc = func(minerName string) ([]HashratePoint, error) {
@@ -224,3 +296,123 @@ func TestHandleGetMinerHashrateHistory(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
 }
+
+func TestHandleSettings(t *testing.T) {
+	router, _ := setupTestRouter()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d without a settings manager, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	m := &Manager{}
+	router = gin.New()
+	service := &Service{
+		Manager:         m,
+		SettingsManager: &SettingsManager{settings: DefaultSettings(), settingsPath: filepath.Join(t.TempDir(), "settings.json")},
+		Router:          router,
+		APIBasePath:     "/",
+		SwaggerUIPath:   "/swagger",
+	}
+	service.SetupRoutes()
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/settings", strings.NewReader(`{"donateLevelPolicy": {"min": 1}, "synchronizedStatsSampling": true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := m.donatePolicy; got.Min != 1 {
+		t.Errorf("expected donate policy applied to the manager, got min %d", got.Min)
+	}
+	if m.statsJitter {
+		t.Error("expected synchronized sampling to disable stats jitter")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/settings", strings.NewReader(`{"theme": "neon"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid theme, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"theme":"system"`) {
+		t.Errorf("expected unchanged settings, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleResetRequiresConfirmation(t *testing.T) {
+	router, mockManager := setupTestRouter()
+	stopped := false
+	mockManager.StopMinerFunc = func(ctx context.Context, minerName string) error { stopped = true; return nil }
+	mockManager.ListMinersFunc = func() []Miner {
+		return []Miner{&XMRigMiner{BaseMiner: BaseMiner{Name: "test-miner"}}}
+	}
+
+	for _, body := range []string{``, `{"confirm": "yes"}`, `{"confirm": "reset-settings"}`} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/config/reset", strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:1234"
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %q: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	// Without API auth, only local callers may reset, even with the confirmation
+	for _, path := range []string{"/config/reset", "/settings/reset"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"confirm": "reset-config"}`)))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 for a remote caller, got %d", path, w.Code)
+		}
+	}
+	if stopped {
+		t.Error("miners should not be stopped without confirmation")
+	}
+}
+
+func TestBackupRestoreRequireAdmin(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/backup"},
+		{http.MethodPost, "/restore"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, route.path, nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403 for a remote caller, got %d", route.method, route.path, w.Code)
+		}
+	}
+}
+
+func TestAuditRequiresAdmin(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a remote caller, got %d", w.Code)
+	}
+}
+
+func TestVacuumHistoryRequiresAdmin(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/history/vacuum", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a remote caller, got %d", w.Code)
+	}
+}
+
+func TestListWebSocketClientsRequiresAdmin(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws/clients", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a remote caller, got %d", w.Code)
+	}
+}
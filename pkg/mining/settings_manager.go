@@ -1,12 +1,15 @@
 package mining
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 
+	"github.com/Snider/Mining/pkg/node"
 	"github.com/adrg/xdg"
 )
 
@@ -28,6 +31,7 @@ type MinerDefaults struct {
 	DefaultAlgorithm     string `json:"defaultAlgorithm,omitempty"`
 	CPUMaxThreadsHint    int    `json:"cpuMaxThreadsHint,omitempty"`    // Default CPU throttle percentage
 	CPUThrottleThreshold int    `json:"cpuThrottleThreshold,omitempty"` // Throttle when CPU exceeds this %
+	ProcessPriority      *int   `json:"processPriority,omitempty"`      // Default miner niceness, -20 to 19; nil runs miners at normal priority
 }
 
 // AppSettings stores application-wide settings
@@ -41,18 +45,28 @@ type AppSettings struct {
 	StartMinimized    bool `json:"startMinimized"`
 	AutostartMiners   bool `json:"autostartMiners"`
 	ShowNotifications bool `json:"showNotifications"`
+	// ShutdownTimeout is how many seconds shutdown waits for background work; 0 uses the default of 10
+	ShutdownTimeout int `json:"shutdownTimeout,omitempty"`
+	// ShutdownHighPriorityFirst stops miners with the highest shutdownPriority first instead of last
+	ShutdownHighPriorityFirst bool `json:"shutdownHighPriorityFirst,omitempty"`
 
 	// Mining settings
-	MinerDefaults          MinerDefaults `json:"minerDefaults"`
-	PauseOnBattery         bool          `json:"pauseOnBattery"`
-	PauseOnUserActive      bool          `json:"pauseOnUserActive"`
-	PauseOnUserActiveDelay int           `json:"pauseOnUserActiveDelay"` // Seconds of inactivity before resuming
+	MinerDefaults          MinerDefaults     `json:"minerDefaults"`
+	PauseOnBattery         bool              `json:"pauseOnBattery"`
+	PauseOnUserActive      bool              `json:"pauseOnUserActive"`
+	PauseOnUserActiveDelay int               `json:"pauseOnUserActiveDelay"` // Seconds of inactivity before resuming
+	DonateLevelPolicy      DonateLevelPolicy `json:"donateLevelPolicy"`      // Floor/ceiling applied to every miner start
+
+	// P2P settings
+	PeerSelectionWeights *node.SelectionWeights `json:"peerSelectionWeights,omitempty"` // nil uses the defaults
 
 	// Performance settings
 	EnableCPUThrottle      bool `json:"enableCpuThrottle"`
 	CPUThrottlePercent     int  `json:"cpuThrottlePercent"`     // Target max CPU % when throttling
 	CPUMonitorInterval     int  `json:"cpuMonitorInterval"`     // Seconds between CPU checks
 	AutoThrottleOnHighTemp bool `json:"autoThrottleOnHighTemp"` // Throttle when CPU temp is high
+	// SynchronizedStatsSampling polls every miner's stats at the same instant instead of spreading them out
+	SynchronizedStatsSampling bool `json:"synchronizedStatsSampling,omitempty"`
 
 	// Theme
 	Theme string `json:"theme"` // "light", "dark", "system"
@@ -85,11 +99,66 @@ func DefaultSettings() *AppSettings {
 	}
 }
 
+// Validate checks that settings are within sane ranges.
+func (s *AppSettings) Validate() error {
+	percent := []struct {
+		name  string
+		value int
+	}{
+		{"cpuThrottlePercent", s.CPUThrottlePercent},
+		{"minerDefaults.cpuMaxThreadsHint", s.MinerDefaults.CPUMaxThreadsHint},
+		{"minerDefaults.cpuThrottleThreshold", s.MinerDefaults.CPUThrottleThreshold},
+	}
+	for _, p := range percent {
+		if p.value < 0 || p.value > 100 {
+			return fmt.Errorf("%s must be between 0 and 100, got %d", p.name, p.value)
+		}
+	}
+	if s.CPUMonitorInterval < 0 {
+		return fmt.Errorf("cpuMonitorInterval must not be negative")
+	}
+	if s.PauseOnUserActiveDelay < 0 {
+		return fmt.Errorf("pauseOnUserActiveDelay must not be negative")
+	}
+	if s.Window.Width < 0 || s.Window.Height < 0 {
+		return fmt.Errorf("window size must not be negative")
+	}
+	switch s.Theme {
+	case "", "light", "dark", "system":
+	default:
+		return fmt.Errorf("theme must be light, dark or system, got %q", s.Theme)
+	}
+	if s.ShutdownTimeout < 0 || s.ShutdownTimeout > 600 {
+		return fmt.Errorf("shutdownTimeout must be between 0 and 600 seconds, got %d", s.ShutdownTimeout)
+	}
+	if p := s.MinerDefaults.ProcessPriority; p != nil && (*p < -20 || *p > 19) {
+		return fmt.Errorf("minerDefaults.processPriority must be between -20 and 19, got %d", *p)
+	}
+	policy := s.DonateLevelPolicy
+	if policy.Min < 0 || policy.Max < 0 || policy.Min > 100 || policy.Max > 100 {
+		return fmt.Errorf("donateLevelPolicy levels must be between 0 and 100")
+	}
+	if policy.Min > 0 && policy.Max > 0 && policy.Min > policy.Max {
+		return fmt.Errorf("donateLevelPolicy min %d exceeds max %d", policy.Min, policy.Max)
+	}
+	if s.PeerSelectionWeights != nil {
+		if err := s.PeerSelectionWeights.Validate(); err != nil {
+			return fmt.Errorf("peerSelectionWeights: %w", err)
+		}
+	}
+	return nil
+}
+
+// ErrInvalidSettings wraps errors from Patch caused by the patch itself
+// rather than by saving it.
+var ErrInvalidSettings = errors.New("invalid settings")
+
 // SettingsManager handles loading and saving app settings
 type SettingsManager struct {
-	mu           sync.RWMutex
-	settings     *AppSettings
-	settingsPath string
+	mu              sync.RWMutex
+	settings        *AppSettings
+	settingsPath    string
+	startOnBootArgs []string // Arguments the executable is started with at login
 }
 
 // NewSettingsManager creates a new settings manager
@@ -175,6 +244,50 @@ func (sm *SettingsManager) Update(fn func(*AppSettings)) error {
 	return os.WriteFile(sm.settingsPath, data, 0600)
 }
 
+// Patch merges a partial JSON settings document into the current settings,
+// validates the result and saves it. Fields absent from patch keep their
+// values; unknown fields are rejected. A change to startOnBoot is applied to
+// the OS first. Returns the updated settings.
+func (sm *SettingsManager) Patch(patch []byte) (*AppSettings, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	// Decode onto a deep copy so a rejected patch leaves the settings untouched
+	current, err := json.Marshal(sm.settings)
+	if err != nil {
+		return nil, err
+	}
+	var updated AppSettings
+	if err := json.Unmarshal(current, &updated); err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&updated); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if err := updated.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if updated.StartOnBoot != sm.settings.StartOnBoot {
+		if err := applyStartOnBoot(updated.StartOnBoot, sm.startOnBootArgs); err != nil {
+			return nil, fmt.Errorf("failed to update start on boot: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(&updated, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(sm.settingsPath, data, 0600); err != nil {
+		return nil, err
+	}
+	sm.settings = &updated
+
+	copy := updated
+	return &copy, nil
+}
+
 // UpdateWindowState saves the current window state
 func (sm *SettingsManager) UpdateWindowState(x, y, width, height int, maximized bool) error {
 	return sm.Update(func(s *AppSettings) {
@@ -193,11 +306,66 @@ func (sm *SettingsManager) GetWindowState() WindowState {
 	return sm.settings.Window
 }
 
-// SetStartOnBoot enables/disables start on boot
+// SetStartOnBootArgs sets the arguments the executable is registered with
+// when start on boot is enabled, such as "serve" and its flags for the CLI.
+// The desktop app registers the bare executable and needn't call it.
+func (sm *SettingsManager) SetStartOnBootArgs(args []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.startOnBootArgs = append([]string(nil), args...)
+}
+
+// SetStartOnBoot registers or removes the OS login entry, then stores the
+// preference. The preference is left unchanged if the OS update fails.
 func (sm *SettingsManager) SetStartOnBoot(enabled bool) error {
-	return sm.Update(func(s *AppSettings) {
-		s.StartOnBoot = enabled
-	})
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if err := applyStartOnBoot(enabled, sm.startOnBootArgs); err != nil {
+		return err
+	}
+	sm.settings.StartOnBoot = enabled
+
+	data, err := json.MarshalIndent(sm.settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sm.settingsPath, data, 0600)
+}
+
+// Reset restores DefaultSettings, first copying the current file aside with
+// BackupFile and removing the start on boot entry if one was registered.
+// Returns the backup path.
+func (sm *SettingsManager) Reset() (string, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	backupPath, err := BackupFile(sm.settingsPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up settings: %w", err)
+	}
+
+	defaults := DefaultSettings()
+	if sm.settings.StartOnBoot != defaults.StartOnBoot {
+		if err := applyStartOnBoot(defaults.StartOnBoot, sm.startOnBootArgs); err != nil {
+			return backupPath, fmt.Errorf("failed to update start on boot: %w", err)
+		}
+	}
+	sm.settings = defaults
+
+	data, err := json.MarshalIndent(sm.settings, "", "  ")
+	if err != nil {
+		return backupPath, err
+	}
+	return backupPath, os.WriteFile(sm.settingsPath, data, 0600)
+}
+
+// GetStartOnBootStatus reports whether start on boot is enabled at the OS
+// level, alongside the stored preference.
+func (sm *SettingsManager) GetStartOnBootStatus() StartOnBootStatus {
+	status := startOnBootStatus()
+	status.Preference = sm.Get().StartOnBoot
+	return status
 }
 
 // SetAutostartMiners enables/disables miner autostart
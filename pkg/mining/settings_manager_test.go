@@ -1,8 +1,10 @@
 package mining
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -209,3 +211,127 @@ func TestSettingsManager_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Unexpected width after concurrent access: %d", state.Width)
 	}
 }
+
+func TestSettingsManager_Patch(t *testing.T) {
+	var applied []bool
+	orig := applyStartOnBoot
+	applyStartOnBoot = func(enabled bool, args []string) error { applied = append(applied, enabled); return nil }
+	defer func() { applyStartOnBoot = orig }()
+
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+
+	sm := &SettingsManager{
+		settings:     DefaultSettings(),
+		settingsPath: settingsPath,
+	}
+
+	settings, err := sm.Patch([]byte(`{"startOnBoot": true, "window": {"width": 1024, "height": 768}}`))
+	if err != nil {
+		t.Fatalf("Failed to patch settings: %v", err)
+	}
+	if !settings.StartOnBoot {
+		t.Error("Expected StartOnBoot to be true")
+	}
+	if len(applied) != 1 || !applied[0] {
+		t.Errorf("Expected start on boot to be applied to the OS once, got %v", applied)
+	}
+	if settings.Window.Width != 1024 || settings.Window.Height != 768 {
+		t.Errorf("Expected window 1024x768, got %dx%d", settings.Window.Width, settings.Window.Height)
+	}
+	// Fields left out of the patch keep their values
+	if settings.Theme != "system" {
+		t.Errorf("Expected theme to stay system, got %s", settings.Theme)
+	}
+	if settings.MinerDefaults.CPUMaxThreadsHint != 50 {
+		t.Errorf("Expected CPUMaxThreadsHint to stay 50, got %d", settings.MinerDefaults.CPUMaxThreadsHint)
+	}
+
+	// Saved to disk
+	sm2 := &SettingsManager{settings: DefaultSettings(), settingsPath: settingsPath}
+	if err := sm2.Load(); err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if !sm2.Get().StartOnBoot {
+		t.Error("Expected patched settings to be saved")
+	}
+}
+
+func TestSettingsManager_PatchRejectsInvalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := &SettingsManager{
+		settings:     DefaultSettings(),
+		settingsPath: filepath.Join(tmpDir, "settings.json"),
+	}
+
+	for _, patch := range []string{
+		`{"theme": "purple"}`,
+		`{"cpuThrottlePercent": 150}`,
+		`{"donateLevelPolicy": {"min": 5, "max": 2}}`,
+		`{"minerDefaults": {"processPriority": 40}}`,
+		`{"unknownField": true}`,
+		`not json`,
+	} {
+		if _, err := sm.Patch([]byte(patch)); !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("Patch(%s): expected ErrInvalidSettings, got %v", patch, err)
+		}
+	}
+
+	// A rejected patch leaves settings untouched
+	if got := sm.Get(); got.Theme != "system" || got.CPUThrottlePercent != 70 {
+		t.Errorf("Expected settings unchanged, got theme %s throttle %d", got.Theme, got.CPUThrottlePercent)
+	}
+	if _, err := os.Stat(sm.settingsPath); !os.IsNotExist(err) {
+		t.Error("Expected a rejected patch not to write the settings file")
+	}
+}
+
+func TestSettingsManager_Reset(t *testing.T) {
+	var applied []bool
+	orig := applyStartOnBoot
+	applyStartOnBoot = func(enabled bool, args []string) error { applied = append(applied, enabled); return nil }
+	defer func() { applyStartOnBoot = orig }()
+
+	tmpDir := t.TempDir()
+	sm := &SettingsManager{settings: DefaultSettings(), settingsPath: filepath.Join(tmpDir, "settings.json")}
+	sm.settings.Theme = "dark"
+	sm.settings.StartOnBoot = true
+	if err := sm.Save(); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+
+	backupPath, err := sm.Reset()
+	if err != nil {
+		t.Fatalf("Failed to reset settings: %v", err)
+	}
+	if got := sm.Get(); got.Theme != "system" || got.StartOnBoot {
+		t.Errorf("Expected default settings, got theme %s startOnBoot %v", got.Theme, got.StartOnBoot)
+	}
+	if len(applied) != 1 || applied[0] {
+		t.Errorf("Expected start on boot to be removed, got %v", applied)
+	}
+
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("Expected a backup of the previous settings: %v", err)
+	}
+	if !strings.Contains(string(data), `"theme": "dark"`) {
+		t.Errorf("Backup does not hold the previous settings: %s", data)
+	}
+
+	// A second reset, typically within the same second, keeps the first backup
+	sm.settings.Theme = "light"
+	if err := sm.Save(); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+	secondPath, err := sm.Reset()
+	if err != nil {
+		t.Fatalf("Failed to reset settings again: %v", err)
+	}
+	if secondPath == backupPath {
+		t.Fatalf("Expected a new backup path, got %s twice", backupPath)
+	}
+	if data, _ := os.ReadFile(backupPath); !strings.Contains(string(data), `"theme": "dark"`) {
+		t.Errorf("First backup was overwritten: %s", data)
+	}
+}
@@ -0,0 +1,216 @@
+package mining
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/Snider/Mining/pkg/node"
+	"github.com/adrg/xdg"
+	"github.com/gin-gonic/gin"
+)
+
+// credentialsFileName holds the admin credential set by first-run setup.
+const credentialsFileName = "credentials.json"
+
+// minSetupPasswordLength is the shortest admin password setup accepts.
+const minSetupPasswordLength = 8
+
+// adminCredentials is the on-disk form of the admin credential. Only the
+// digest HA1 is stored, which is tied to the realm; it still authenticates
+// on its own, so the file is only readable by its owner.
+type adminCredentials struct {
+	Username  string    `json:"username"`
+	Realm     string    `json:"realm"`
+	HA1       string    `json:"ha1"` // MD5(username:realm:password)
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// defaultCredentialsPath returns where setup stores the admin credential.
+func defaultCredentialsPath() (string, error) {
+	return xdg.ConfigFile(filepath.Join("lethean-desktop", credentialsFileName))
+}
+
+// loadAdminCredentials reads the stored credential, or returns nil if setup
+// hasn't been run.
+func loadAdminCredentials(path string) (*adminCredentials, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admin credentials: %w", err)
+	}
+	var creds adminCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse admin credentials: %w", err)
+	}
+	if creds.Username == "" || creds.Realm == "" || creds.HA1 == "" {
+		return nil, fmt.Errorf("admin credentials in %s are incomplete", path)
+	}
+	return &creds, nil
+}
+
+// saveAdminCredentials writes the credential, refusing to replace an existing
+// one so setup can only ever run once.
+func saveAdminCredentials(path string, creds adminCredentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal admin credentials: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create admin credentials: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to write admin credentials: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write admin credentials: %w", err)
+	}
+	return nil
+}
+
+// currentAuth returns the active digest auth, or nil while auth is disabled.
+func (s *Service) currentAuth() *DigestAuth {
+	s.authMu.RLock()
+	defer s.authMu.RUnlock()
+	return s.auth
+}
+
+// setAuth enables auth with da. Requests already past the auth middleware
+// complete; later ones must authenticate.
+func (s *Service) setAuth(da *DigestAuth) {
+	s.authMu.Lock()
+	old := s.auth
+	s.auth = da
+	s.authMu.Unlock()
+	if old != nil {
+		old.Stop()
+	}
+	if s.NodeService != nil {
+		s.NodeService.apiAuth.Store(da != nil)
+	}
+}
+
+// authEnabled reports whether API auth is currently enabled.
+func (s *Service) authEnabled() bool {
+	return s.currentAuth() != nil
+}
+
+// authMiddleware enforces whichever auth is active when the request arrives,
+// so auth enabled by setup applies without re-registering routes.
+func (s *Service) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if da := s.currentAuth(); da != nil {
+			da.Middleware()(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetupRequest is the request body for first-run setup
+type SetupRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	NodeName string `json:"nodeName,omitempty"` // Name for the node identity if one doesn't exist; defaults to the hostname
+}
+
+// SetupResponse is the response from first-run setup
+type SetupResponse struct {
+	Status   string `json:"status"`
+	Username string `json:"username"`
+	NodeID   string `json:"nodeId,omitempty"` // Empty if P2P is unavailable
+}
+
+// handleSetup godoc
+// @Summary Complete first-run setup
+// @Description Sets the initial admin credential, generates the node identity if there isn't one and enables API auth. Only accepted from this machine, and only while no credential exists; afterwards it returns 409.
+// @Tags system
+// @Accept json
+// @Produce json
+// @Param request body SetupRequest true "Admin credential and optional node name"
+// @Success 201 {object} SetupResponse
+// @Failure 400 {object} APIError "Invalid credential"
+// @Failure 403 {object} APIError "Not a local request"
+// @Failure 409 {object} APIError "Setup already complete"
+// @Router /setup [post]
+func (s *Service) handleSetup(c *gin.Context) {
+	// Serialize setups so two callers can't both claim the node
+	s.setupMu.Lock()
+	defer s.setupMu.Unlock()
+
+	if s.currentAuth() != nil {
+		respondWithMiningError(c, ErrSetupComplete())
+		return
+	}
+
+	var req SetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, ErrInvalidInput("username and password are required"))
+		return
+	}
+	if len(req.Password) < minSetupPasswordLength {
+		respondWithMiningError(c, ErrInvalidInput(fmt.Sprintf("password must be at least %d characters", minSetupPasswordLength)))
+		return
+	}
+
+	// Generate the identity before storing the credential, so a failure
+	// leaves setup available to retry
+	var nodeID string
+	if s.NodeService != nil {
+		nm := s.NodeService.nodeManager
+		if !nm.HasIdentity() {
+			name := req.NodeName
+			if name == "" {
+				name, _ = os.Hostname()
+			}
+			if err := nm.GenerateIdentity(name, node.RoleDual); err != nil {
+				respondWithMiningError(c, ErrInternal("failed to generate node identity").WithCause(err))
+				return
+			}
+		}
+		nodeID = nm.GetIdentity().ID
+	}
+
+	config := s.authConfig
+	if config.Realm == "" {
+		config = DefaultAuthConfig()
+	}
+	creds := adminCredentials{
+		Username:  req.Username,
+		Realm:     config.Realm,
+		HA1:       digestHA1(req.Username, config.Realm, req.Password),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := saveAdminCredentials(s.credentialsPath, creds); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			respondWithMiningError(c, ErrSetupComplete())
+			return
+		}
+		respondWithMiningError(c, ErrInternal("failed to store admin credential").WithCause(err))
+		return
+	}
+
+	config.Enabled = true
+	config.Username = creds.Username
+	config.Password = ""
+	config.PasswordHash = creds.HA1
+	s.setAuth(NewDigestAuth(config))
+	s.authConfig = config
+	logging.Info("first-run setup complete, API authentication enabled", logging.Fields{"user": creds.Username})
+
+	c.JSON(http.StatusCreated, SetupResponse{Status: "complete", Username: creds.Username, NodeID: nodeID})
+}
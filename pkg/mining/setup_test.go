@@ -0,0 +1,130 @@
+package mining
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Snider/Mining/pkg/node"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleSetup(t *testing.T) {
+	dir := t.TempDir()
+	nm, err := node.NewNodeManagerWithPaths(filepath.Join(dir, "private.key"), filepath.Join(dir, "node.json"))
+	if err != nil {
+		t.Fatalf("failed to create node manager: %v", err)
+	}
+	mockManager := &MockManager{ListMinersFunc: func() []Miner { return []Miner{} }}
+	service := &Service{
+		Manager:         mockManager,
+		NodeService:     &NodeService{nodeManager: nm},
+		Router:          gin.New(),
+		APIBasePath:     "/api",
+		SwaggerUIPath:   "/api/swagger",
+		credentialsPath: filepath.Join(dir, credentialsFileName),
+	}
+	service.SetupRoutes()
+	defer func() {
+		if auth := service.currentAuth(); auth != nil {
+			auth.Stop()
+		}
+	}()
+
+	do := func(method, path, body string, withAuth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		if withAuth {
+			req.SetBasicAuth("admin", "correct horse")
+		}
+		w := httptest.NewRecorder()
+		service.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("GET", "/api/miners", "", false); w.Code != http.StatusOK {
+		t.Fatalf("expected the API to be open before setup, got %d", w.Code)
+	}
+	if w := do("POST", "/api/setup", `{"username":"admin","password":"short"}`, false); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a short password to be rejected, got %d", w.Code)
+	}
+
+	remote := httptest.NewRequest("POST", "/api/setup", strings.NewReader(`{"username":"intruder","password":"correct horse"}`))
+	remote.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	service.Router.ServeHTTP(w, remote)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected setup from a remote address to be rejected, got %d", w.Code)
+	}
+
+	w = do("POST", "/api/setup", `{"username":"admin","password":"correct horse","nodeName":"rig-1"}`, false)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected setup to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp SetupResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.NodeID == "" || nm.GetIdentity().Name != "rig-1" {
+		t.Errorf("expected a node identity named rig-1, got %+v", nm.GetIdentity())
+	}
+	if !service.NodeService.apiAuth.Load() {
+		t.Error("expected node admin routes to see API auth as enabled")
+	}
+
+	info, err := os.Stat(service.credentialsPath)
+	if err != nil {
+		t.Fatalf("expected the credential to be stored: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected credential file mode 0600, got %o", perm)
+	}
+	if creds, err := loadAdminCredentials(service.credentialsPath); err != nil || creds.Username != "admin" {
+		t.Errorf("expected the stored credential to load, got %+v, %v", creds, err)
+	}
+	if data, _ := os.ReadFile(service.credentialsPath); strings.Contains(string(data), "correct horse") {
+		t.Error("expected the password not to be stored")
+	}
+
+	if w := do("GET", "/api/miners", "", false); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected auth to be required after setup, got %d", w.Code)
+	}
+	if w := do("GET", "/api/miners", "", true); w.Code != http.StatusOK {
+		t.Errorf("expected the admin credential to be accepted, got %d", w.Code)
+	}
+
+	// Reloading restores auth from the stored hash alone
+	service.setAuth(nil)
+	if err := service.ReloadAuth(); err != nil {
+		t.Fatalf("ReloadAuth failed: %v", err)
+	}
+	if w := do("GET", "/api/miners", "", true); w.Code != http.StatusOK {
+		t.Errorf("expected the admin credential to be accepted after reload, got %d", w.Code)
+	}
+	if w := do("POST", "/api/setup", `{"username":"other","password":"another pass"}`, false); w.Code != http.StatusConflict {
+		t.Errorf("expected a second setup to conflict, got %d", w.Code)
+	}
+}
+
+func TestLoadAdminCredentials(t *testing.T) {
+	dir := t.TempDir()
+	if creds, err := loadAdminCredentials(filepath.Join(dir, "missing.json")); creds != nil || err != nil {
+		t.Errorf("expected no credential before setup, got %+v, %v", creds, err)
+	}
+
+	path := filepath.Join(dir, credentialsFileName)
+	if err := os.WriteFile(path, []byte(`{"username":"admin"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAdminCredentials(path); err == nil {
+		t.Error("expected an incomplete credential to fail loudly")
+	}
+	if err := saveAdminCredentials(path, adminCredentials{Username: "a", Realm: "r", HA1: "h"}); err == nil {
+		t.Error("expected an existing credential not to be replaced")
+	}
+}
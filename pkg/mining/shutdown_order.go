@@ -0,0 +1,141 @@
+package mining
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// SetShutdownOrder sets which miners Stop shuts down first when their
+// configs set ShutdownPriority: the lowest priority first (the default), so
+// a proxy other miners connect through can be given a high one and go last,
+// or the highest first.
+func (m *Manager) SetShutdownOrder(highestFirst bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdownHighFirst = highestFirst
+}
+
+// shutdownGroups groups the running miners by ShutdownPriority in the order
+// they should be stopped. Caller must hold m.mu.
+func (m *Manager) shutdownGroups() [][]string {
+	byPriority := make(map[int][]string)
+	for name := range m.miners {
+		priority := 0
+		if launch, ok := m.launches[name]; ok {
+			priority = launch.Config.ShutdownPriority
+		}
+		byPriority[priority] = append(byPriority[priority], name)
+	}
+
+	priorities := make([]int, 0, len(byPriority))
+	for priority := range byPriority {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+	if m.shutdownHighFirst {
+		sort.Sort(sort.Reverse(sort.IntSlice(priorities)))
+	}
+
+	groups := make([][]string, 0, len(priorities))
+	for _, priority := range priorities {
+		group := byPriority[priority]
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// stopKillGrace is added to a group's largest StopTimeout when sizing its
+// shutdown window, leaving Stop time to send SIGKILL and reap the process.
+const stopKillGrace = time.Second
+
+// groupStopTimeout returns the largest StopTimeout among a group's miners.
+// Caller must hold m.mu.
+func (m *Manager) groupStopTimeout(group []string) time.Duration {
+	var longest time.Duration
+	for _, name := range group {
+		if launch, ok := m.launches[name]; ok {
+			if t := time.Duration(launch.StopTimeoutSeconds) * time.Second; t > longest {
+				longest = t
+			}
+		}
+	}
+	return longest
+}
+
+// stopAllMiners stops every miner for shutdown, one priority group at a
+// time. Miners within a group are stopped concurrently; the next group
+// starts when they are done or once the group's window has passed. The
+// window is the group's share of timeout, but never less than its largest
+// StopTimeout, so a miner is not cut off before Stop would kill it itself.
+// Miners still running when the window closes are force-killed and marked
+// errored. Without priorities all miners form one group.
+// Caller must hold m.mu.
+func (m *Manager) stopAllMiners(timeout time.Duration) {
+	groups := m.shutdownGroups()
+	deadline := time.Now().Add(timeout)
+
+	type stopResult struct {
+		index int
+		err   error
+	}
+
+	for i, group := range groups {
+		for _, name := range group {
+			m.transitionMinerState(name, MinerStateStopping)
+		}
+
+		results := make(chan stopResult, len(group))
+		for j, name := range group {
+			go func(j int, miner Miner) {
+				results <- stopResult{index: j, err: miner.Stop()}
+			}(j, m.miners[name])
+		}
+
+		// Split what is left of the timeout between the remaining groups
+		wait := time.Until(deadline) / time.Duration(len(groups)-i)
+		if longest := m.groupStopTimeout(group); longest > 0 && wait < longest+stopKillGrace {
+			wait = longest + stopKillGrace
+		}
+		timer := time.NewTimer(wait)
+
+		stopped := make([]bool, len(group))
+		for pending := len(group); pending > 0; {
+			select {
+			case result := <-results:
+				pending--
+				stopped[result.index] = true
+				name := group[result.index]
+				finalState := MinerStateStopped
+				if err := result.err; err != nil && !processExited(err) {
+					logging.Warn("failed to stop miner", logging.Fields{"miner": name, "error": err})
+					finalState = MinerStateErrored
+				}
+				m.transitionMinerState(name, finalState)
+			case <-timer.C:
+				for j, name := range group {
+					if !stopped[j] {
+						m.killMiner(name, wait)
+					}
+				}
+				pending = 0
+			}
+		}
+		timer.Stop()
+	}
+}
+
+// killMiner force-kills a miner that outlasted its shutdown window and marks
+// it errored. Caller must hold m.mu.
+func (m *Manager) killMiner(name string, window time.Duration) {
+	fields := logging.Fields{"miner": name, "window": window}
+	if killer, ok := m.miners[name].(interface{ Kill() error }); ok {
+		if err := killer.Kill(); err != nil && !processExited(err) {
+			fields["error"] = err
+		}
+	}
+	logging.Warn("miner still running after its shutdown window, killed it", fields)
+	m.transitionMinerState(name, MinerStateErrored)
+}
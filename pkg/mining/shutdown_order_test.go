@@ -0,0 +1,147 @@
+package mining
+
+import (
+	"bufio"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newShutdownTestManager returns a manager running one mock miner per
+// priority, recording the order they are stopped in.
+func newShutdownTestManager(priorities map[string]int, stopDelay time.Duration) (*Manager, func() []string) {
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	var mu sync.Mutex
+	var stopped []string
+	for name, priority := range priorities {
+		name := name
+		m.miners[name] = &MockMiner{StopFunc: func() error {
+			time.Sleep(stopDelay)
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, name)
+			return nil
+		}}
+		m.launches[name] = &MinerLaunchInfo{Config: Config{ShutdownPriority: priority}}
+	}
+	return m, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), stopped...)
+	}
+}
+
+func TestShutdownGroups(t *testing.T) {
+	m, _ := newShutdownTestManager(map[string]int{"proxy": 10, "rig-b": 0, "rig-a": 0, "gpu": 5}, 0)
+
+	want := [][]string{{"rig-a", "rig-b"}, {"gpu"}, {"proxy"}}
+	if got := m.shutdownGroups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected lowest priority first %v, got %v", want, got)
+	}
+
+	m.SetShutdownOrder(true)
+	want = [][]string{{"proxy"}, {"gpu"}, {"rig-a", "rig-b"}}
+	if got := m.shutdownGroups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected highest priority first %v, got %v", want, got)
+	}
+
+	// Without priorities every miner is stopped at once, as before
+	m, _ = newShutdownTestManager(map[string]int{"a": 0, "b": 0, "c": 0}, 0)
+	if got := m.shutdownGroups(); len(got) != 1 || len(got[0]) != 3 {
+		t.Errorf("expected a single group, got %v", got)
+	}
+}
+
+func TestStopAllMinersInPriorityOrder(t *testing.T) {
+	m, stopped := newShutdownTestManager(map[string]int{"proxy": 10, "rig": 0, "gpu": 5}, 10*time.Millisecond)
+	m.SetShutdownTimeout(5 * time.Second)
+	m.Stop()
+
+	if got, want := stopped(), []string{"rig", "gpu", "proxy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected stop order %v, got %v", want, got)
+	}
+}
+
+func TestStopAllMinersMovesOnFromHungGroup(t *testing.T) {
+	m, stopped := newShutdownTestManager(map[string]int{"proxy": 10}, 0)
+	release := make(chan struct{})
+	defer close(release)
+	m.miners["hung"] = &MockMiner{StopFunc: func() error { <-release; return nil }}
+	m.launches["hung"] = &MinerLaunchInfo{}
+
+	start := time.Now()
+	m.mu.Lock()
+	m.stopAllMiners(200 * time.Millisecond)
+	m.mu.Unlock()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected shutdown to stay within its timeout, took %v", elapsed)
+	}
+	if got := stopped(); !reflect.DeepEqual(got, []string{"proxy"}) {
+		t.Errorf("expected the next group to be stopped after the hung one, got %v", got)
+	}
+}
+
+func TestStopAllMinersKillsMinerIgnoringSIGTERM(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not used on Windows")
+	}
+	cmd := exec.Command("sh", "-c", "trap '' TERM; echo ready; exec sleep 30")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sh: %v", err)
+	}
+	defer cmd.Process.Kill()
+	// Wait until the trap is set so SIGTERM is really ignored
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	miner := &XMRigMiner{BaseMiner: BaseMiner{Name: "stubborn", Running: true, cmd: cmd, stopTimeout: time.Minute}}
+	m := &Manager{miners: map[string]Miner{"stubborn": miner}, launches: map[string]*MinerLaunchInfo{"stubborn": {}}, stopChan: make(chan struct{})}
+	m.initMinerState("stubborn", miner, MinerStateRunning)
+
+	start := time.Now()
+	m.mu.Lock()
+	m.stopAllMiners(200 * time.Millisecond)
+	m.mu.Unlock()
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the miner to be killed when its window closed, took %v", elapsed)
+	}
+	if state, _ := m.GetMinerState("stubborn"); state != MinerStateErrored {
+		t.Errorf("expected a killed miner to be errored, got %s", state)
+	}
+	// Stop reaps the process once the kill lands
+	deadline := time.Now().Add(5 * time.Second)
+	for cmd.Process.Signal(syscall.Signal(0)) == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cmd.Process.Signal(syscall.Signal(0)) == nil {
+		t.Error("expected the miner process to have been killed")
+	}
+}
+
+func TestStopAllMinersWindowCoversStopTimeout(t *testing.T) {
+	m, stopped := newShutdownTestManager(map[string]int{"proxy": 10, "rig": 0}, 100*time.Millisecond)
+	for _, launch := range m.launches {
+		launch.StopTimeoutSeconds = 1
+	}
+
+	// The budget is far below each group's stop timeout, so the windows
+	// stretch to fit it and the order still holds
+	m.mu.Lock()
+	m.stopAllMiners(10 * time.Millisecond)
+	m.mu.Unlock()
+
+	if got, want := stopped(), []string{"rig", "proxy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected stop order %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,207 @@
+package mining
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// SimulatedFault is a failure a simulated miner can be made to have, so the
+// crash supervisor, health tracking and error events can be exercised
+// without real hardware.
+type SimulatedFault string
+
+const (
+	SimulatedCrash          SimulatedFault = "crash"           // The process exits; the manager sees it as a crash
+	SimulatedStall          SimulatedFault = "stall"           // Hashrate drops to zero and no shares are found
+	SimulatedPoolDisconnect SimulatedFault = "pool-disconnect" // The pool connection drops: no jobs, no hashrate
+	SimulatedAPIError       SimulatedFault = "api-error"       // The stats API fails every request
+)
+
+// DefaultSimulatedFaultDuration is how long a stall, pool disconnect or API
+// failure lasts when no duration is given.
+const DefaultSimulatedFaultDuration = 30 * time.Second
+
+// simulatedExitCode is the exit code a simulated crash reports.
+const simulatedExitCode = 134
+
+// SimulatedFaults are the chances of a simulated miner failing on its own.
+// Crashes, stalls and disconnects are rolled on each hashrate update, every
+// HighResolutionInterval; API errors on each stats request.
+type SimulatedFaults struct {
+	CrashChance          float64 `json:"crashChance,omitempty"`
+	StallChance          float64 `json:"stallChance,omitempty"`
+	PoolDisconnectChance float64 `json:"poolDisconnectChance,omitempty"`
+	APIErrorChance       float64 `json:"apiErrorChance,omitempty"`
+	DurationSeconds      int     `json:"durationSeconds,omitempty"` // How long a stall or disconnect lasts; 0 for DefaultSimulatedFaultDuration
+}
+
+// Validate checks that every chance is between 0 and 1.
+func (f SimulatedFaults) Validate() error {
+	for name, chance := range map[string]float64{
+		"crashChance":          f.CrashChance,
+		"stallChance":          f.StallChance,
+		"poolDisconnectChance": f.PoolDisconnectChance,
+		"apiErrorChance":       f.APIErrorChance,
+	} {
+		if chance < 0 || chance > 1 {
+			return ErrInvalidConfig(fmt.Sprintf("%s must be between 0 and 1", name))
+		}
+	}
+	if f.DurationSeconds < 0 {
+		return ErrInvalidConfig("durationSeconds must not be negative")
+	}
+	return nil
+}
+
+// duration returns how long a randomly rolled fault lasts.
+func (f SimulatedFaults) duration() time.Duration {
+	if f.DurationSeconds > 0 {
+		return time.Duration(f.DurationSeconds) * time.Second
+	}
+	return DefaultSimulatedFaultDuration
+}
+
+// SimulatedFaultInjection is a fault to inject now or after a delay.
+type SimulatedFaultInjection struct {
+	Fault           SimulatedFault `json:"fault" binding:"required"`
+	AfterSeconds    int            `json:"afterSeconds,omitempty"`    // Delay before the fault; 0 for now
+	DurationSeconds int            `json:"durationSeconds,omitempty"` // How long it lasts, for all but crashes; 0 for DefaultSimulatedFaultDuration
+}
+
+// simulatedFaultState is when each injected fault ends. Guarded by the miner's mu.
+type simulatedFaultState struct {
+	stallUntil        time.Time
+	disconnectedUntil time.Time
+	apiErrorsUntil    time.Time
+	lastExit          *ProcessExit
+}
+
+// SetFaults replaces the miner's fault chances.
+func (m *SimulatedMiner) SetFaults(faults SimulatedFaults) error {
+	if err := faults.Validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.faults = faults
+	m.mu.Unlock()
+	return nil
+}
+
+// Faults returns the miner's fault chances.
+func (m *SimulatedMiner) Faults() SimulatedFaults {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.faults
+}
+
+// InjectFault makes the running miner fail as inj describes. A delayed fault
+// is dropped if the miner is stopped or restarted before it is due.
+func (m *SimulatedMiner) InjectFault(inj SimulatedFaultInjection) error {
+	switch inj.Fault {
+	case SimulatedCrash, SimulatedStall, SimulatedPoolDisconnect, SimulatedAPIError:
+	default:
+		return ErrInvalidConfig(fmt.Sprintf("unknown fault %q", inj.Fault))
+	}
+	if inj.AfterSeconds < 0 || inj.DurationSeconds < 0 {
+		return ErrInvalidConfig("afterSeconds and durationSeconds must not be negative")
+	}
+	duration := DefaultSimulatedFaultDuration
+	if inj.DurationSeconds > 0 {
+		duration = time.Duration(inj.DurationSeconds) * time.Second
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.Running {
+		return ErrMinerNotRunning(m.Name)
+	}
+	if inj.AfterSeconds == 0 {
+		m.applyFault(inj.Fault, duration)
+		return nil
+	}
+
+	run := m.stopChan
+	time.AfterFunc(time.Duration(inj.AfterSeconds)*time.Second, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.Running && m.stopChan == run {
+			m.applyFault(inj.Fault, duration)
+		}
+	})
+	return nil
+}
+
+// rollFaults applies each fault whose chance comes up.
+func (m *SimulatedMiner) rollFaults() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.Running {
+		return
+	}
+	duration := m.faults.duration()
+	switch {
+	case rand.Float64() < m.faults.CrashChance:
+		m.applyFault(SimulatedCrash, 0)
+	case rand.Float64() < m.faults.StallChance:
+		m.applyFault(SimulatedStall, duration)
+	case rand.Float64() < m.faults.PoolDisconnectChance:
+		m.applyFault(SimulatedPoolDisconnect, duration)
+	}
+}
+
+// applyFault starts a fault lasting duration. Caller must hold m.mu and the
+// miner must be running.
+func (m *SimulatedMiner) applyFault(fault SimulatedFault, duration time.Duration) {
+	now := time.Now()
+	stamp := now.Format("15:04:05")
+	switch fault {
+	case SimulatedCrash:
+		// Like a process exit: the run ends without Stop, and GetStats
+		// reports the miner not running until the supervisor restarts it
+		close(m.stopChan)
+		m.Running = false
+		m.fault.lastExit = &ProcessExit{
+			At:       now,
+			ExitCode: simulatedExitCode,
+			Reason:   fmt.Sprintf("exit status %d: simulated crash", simulatedExitCode),
+		}
+		m.logs = append(m.logs, fmt.Sprintf("[%s] Simulated crash (exit status %d)", stamp, simulatedExitCode))
+	case SimulatedStall:
+		m.fault.stallUntil = now.Add(duration)
+		m.logs = append(m.logs, fmt.Sprintf("[%s] Simulated stall for %s", stamp, duration))
+	case SimulatedPoolDisconnect:
+		m.fault.disconnectedUntil = now.Add(duration)
+		m.logs = append(m.logs, fmt.Sprintf("[%s] net %s connection lost, retrying in %s", stamp, m.poolName, duration))
+	case SimulatedAPIError:
+		m.fault.apiErrorsUntil = now.Add(duration)
+		m.logs = append(m.logs, fmt.Sprintf("[%s] Simulated stats API failure for %s", stamp, duration))
+	}
+	m.trimLogs()
+}
+
+// idle reports whether a stall or pool disconnect stops the miner hashing
+// at now. Caller must hold m.mu.
+func (m *SimulatedMiner) idle(now time.Time) bool {
+	return now.Before(m.fault.stallUntil) || now.Before(m.fault.disconnectedUntil)
+}
+
+// apiError returns the error a failing stats API gives, or nil. Caller must
+// hold m.mu. The message mustn't read as the process having exited.
+func (m *SimulatedMiner) apiError(now time.Time) error {
+	if now.Before(m.fault.apiErrorsUntil) || rand.Float64() < m.faults.APIErrorChance {
+		return fmt.Errorf("simulated miner %s stats API: connection refused", m.Name)
+	}
+	return nil
+}
+
+// LastExit returns how the miner last crashed, or nil.
+func (m *SimulatedMiner) LastExit() *ProcessExit {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.fault.lastExit == nil {
+		return nil
+	}
+	exit := *m.fault.lastExit
+	return &exit
+}
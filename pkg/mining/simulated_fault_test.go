@@ -0,0 +1,150 @@
+package mining
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startFaultTestMiner starts a simulated miner through a manager, as the
+// simulate command's API would
+func startFaultTestMiner(t *testing.T, restart bool) (*Manager, *SimulatedMiner) {
+	t.Helper()
+	originalDelay, originalRestartDelay := statsRetryDelay, crashRestartDelay
+	statsRetryDelay, crashRestartDelay = time.Millisecond, 0
+	t.Cleanup(func() { statsRetryDelay, crashRestartDelay = originalDelay, originalRestartDelay })
+
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	started, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "testwallet", RestartOnCrash: restart})
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	miner := started.(*SimulatedMiner)
+	t.Cleanup(func() { miner.Stop() })
+	return m, miner
+}
+
+func TestSimulatedFaultCrash(t *testing.T) {
+	m, miner := startFaultTestMiner(t, true)
+
+	if err := miner.InjectFault(SimulatedFaultInjection{Fault: SimulatedCrash}); err != nil {
+		t.Fatalf("InjectFault failed: %v", err)
+	}
+	if _, err := miner.GetStats(context.Background()); !processExited(err) {
+		t.Fatalf("expected the crashed miner to report not running, got %v", err)
+	}
+
+	m.collectSingleMinerStats(miner, MinerTypeSimulated, time.Now(), false)
+	if state, _ := m.GetMinerState(miner.GetName()); state != MinerStateStarting {
+		t.Fatalf("expected the supervisor to restart the miner, got %s", state)
+	}
+	history, _ := m.GetMinerHistory(miner.GetName())
+	if history.RestartCount != 1 || len(history.Crashes) != 1 || history.Crashes[0].ExitCode != simulatedExitCode {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if !strings.Contains(history.LastCrashReason, "simulated crash") {
+		t.Errorf("expected the simulated exit as the reason, got %q", history.LastCrashReason)
+	}
+}
+
+func TestSimulatedFaultStall(t *testing.T) {
+	_, miner := startFaultTestMiner(t, false)
+
+	for _, fault := range []SimulatedFault{SimulatedStall, SimulatedPoolDisconnect} {
+		if err := miner.InjectFault(SimulatedFaultInjection{Fault: fault, DurationSeconds: 60}); err != nil {
+			t.Fatalf("InjectFault %s failed: %v", fault, err)
+		}
+	}
+	miner.mu.Lock()
+	miner.startTime = time.Now().Add(-time.Minute) // Past the ramp up
+	miner.mu.Unlock()
+	miner.updateHashrate()
+	miner.simulateShare()
+
+	stats, err := miner.GetStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.Hashrate != 0 || stats.Shares != 0 || stats.ExtraData["poolConnected"] != false {
+		t.Errorf("expected a stalled, disconnected miner, got %+v", stats)
+	}
+}
+
+func TestSimulatedFaultAPIError(t *testing.T) {
+	m, miner := startFaultTestMiner(t, false)
+	name := miner.GetName()
+
+	if err := miner.InjectFault(SimulatedFaultInjection{Fault: SimulatedAPIError}); err != nil {
+		t.Fatalf("InjectFault failed: %v", err)
+	}
+	for i := 0; i < statsUnhealthyThreshold; i++ {
+		m.collectSingleMinerStats(miner, MinerTypeSimulated, time.Now(), false)
+	}
+
+	m.mu.RLock()
+	unhealthy := m.launches[name].Unhealthy
+	m.mu.RUnlock()
+	if !unhealthy {
+		t.Error("expected failing stats to mark the miner unhealthy")
+	}
+	if state, _ := m.GetMinerState(name); state == MinerStateErrored {
+		t.Error("expected API errors not to be taken for a crash")
+	}
+}
+
+func TestSimulatedFaultScheduled(t *testing.T) {
+	_, miner := startFaultTestMiner(t, false)
+
+	if err := miner.InjectFault(SimulatedFaultInjection{Fault: SimulatedAPIError, AfterSeconds: 1}); err != nil {
+		t.Fatalf("InjectFault failed: %v", err)
+	}
+	if _, err := miner.GetStats(context.Background()); err != nil {
+		t.Fatalf("expected no fault before it is due, got %v", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := miner.GetStats(context.Background()); err == nil {
+		t.Error("expected the scheduled fault once due")
+	}
+}
+
+func TestSimulatedFaultValidation(t *testing.T) {
+	miner := NewSimulatedMiner(SimulatedMinerConfig{Name: "sim-fault"})
+	if err := miner.InjectFault(SimulatedFaultInjection{Fault: SimulatedCrash}); err == nil {
+		t.Error("expected an error injecting into a stopped miner")
+	}
+	if err := miner.InjectFault(SimulatedFaultInjection{Fault: "meltdown"}); err == nil {
+		t.Error("expected an error for an unknown fault")
+	}
+	if err := miner.SetFaults(SimulatedFaults{CrashChance: 1.5}); err == nil {
+		t.Error("expected an error for a chance over 1")
+	}
+	if err := miner.SetFaults(SimulatedFaults{StallChance: 0.5}); err != nil || miner.Faults().StallChance != 0.5 {
+		t.Errorf("expected the chances set, got %+v %v", miner.Faults(), err)
+	}
+}
+
+func TestSimulatedFaultRoutes(t *testing.T) {
+	m, miner := startFaultTestMiner(t, false)
+	path := "/miners/" + miner.GetName() + "/simulate/fault"
+
+	for _, simulation := range []bool{false, true} {
+		router := gin.New()
+		service := &Service{Manager: m, Router: router, APIBasePath: "/", SwaggerUIPath: "/swagger", Simulation: simulation}
+		service.SetupRoutes()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{"fault":"stall"}`)))
+		if simulation && w.Code != http.StatusAccepted {
+			t.Errorf("expected %d in simulation mode, got %d %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		if !simulation && w.Code != http.StatusNotFound {
+			t.Errorf("expected the route unmounted outside simulation mode, got %d", w.Code)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package mining
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Simulated load limits.
+const (
+	MaxSimulatedLogRate      = 10000 // Log lines per second per miner
+	DefaultSimulatedLogBytes = 120
+	MaxSimulatedLogBytes     = maxLineLength
+	simulatedLoadTick        = 100 * time.Millisecond
+)
+
+// SimulatedLoad is the real work a simulated miner does alongside its fake
+// stats, so many of them put realistic pressure on the event hub, log
+// readers and history inserts. Only the simulate command sets it.
+type SimulatedLoad struct {
+	LogLinesPerSecond float64 // Extra log lines written per second
+	LogLineBytes      int     // Length of each extra line; DefaultSimulatedLogBytes if zero
+	CPUPercent        float64 // CPU to burn while running, in percent of one core
+}
+
+// normalized clamps the load to what the host can do.
+func (l SimulatedLoad) normalized() SimulatedLoad {
+	l.LogLinesPerSecond = math.Max(0, math.Min(l.LogLinesPerSecond, MaxSimulatedLogRate))
+	if l.LogLineBytes <= 0 {
+		l.LogLineBytes = DefaultSimulatedLogBytes
+	}
+	l.LogLineBytes = min(l.LogLineBytes, MaxSimulatedLogBytes)
+	l.CPUPercent = math.Max(0, math.Min(l.CPUPercent, float64(runtime.NumCPU()*100)))
+	return l
+}
+
+// startLoad starts the configured log and CPU load until stop is closed.
+func (m *SimulatedMiner) startLoad(stop <-chan struct{}) {
+	if m.load.LogLinesPerSecond > 0 {
+		go m.generateLogs(stop)
+	}
+	if m.load.CPUPercent > 0 {
+		// Spread over whole cores, each busy for its share of every tick
+		cores := int(math.Ceil(m.load.CPUPercent / 100))
+		duty := m.load.CPUPercent / 100 / float64(cores)
+		for i := 0; i < cores; i++ {
+			go burnCPU(stop, duty)
+		}
+	}
+}
+
+// generateLogs writes filler log lines at the configured rate, carrying the
+// fractional lines of each tick over to the next.
+func (m *SimulatedMiner) generateLogs(stop <-chan struct{}) {
+	ticker := time.NewTicker(simulatedLoadTick)
+	defer ticker.Stop()
+
+	perTick := m.load.LogLinesPerSecond * simulatedLoadTick.Seconds()
+	owed := 0.0
+	seq := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			owed += perTick
+			n := int(owed)
+			owed -= float64(n)
+			if n == 0 {
+				continue
+			}
+
+			stamp := time.Now().Format("15:04:05")
+			lines := make([]string, n)
+			for i := range lines {
+				seq++
+				lines[i] = simulatedLogLine(stamp, seq, m.load.LogLineBytes)
+			}
+			m.mu.Lock()
+			m.logs = append(m.logs, lines...)
+			m.trimLogs()
+			m.mu.Unlock()
+		}
+	}
+}
+
+// simulatedLogLine returns a miner-like log line of exactly size bytes.
+func simulatedLogLine(stamp string, seq, size int) string {
+	line := fmt.Sprintf("[%s] net job #%d from sim-pool diff %d algo rx/0 height %d ", stamp, seq, 10000+rand.Intn(5000), 3000000+seq)
+	if len(line) >= size {
+		return line[:size]
+	}
+	return line + strings.Repeat("x", size-len(line))
+}
+
+// burnCPU keeps one core busy for duty of every tick until stop is closed.
+func burnCPU(stop <-chan struct{}, duty float64) {
+	busy := time.Duration(float64(simulatedLoadTick) * duty)
+	for {
+		start := time.Now()
+		x := 0.0
+		for time.Since(start) < busy {
+			for i := 0; i < 1000; i++ {
+				x += math.Sqrt(float64(i))
+			}
+		}
+		_ = x
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(simulatedLoadTick - time.Since(start)):
+		}
+	}
+}
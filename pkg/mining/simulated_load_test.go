@@ -0,0 +1,56 @@
+package mining
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSimulatedLoadNormalized(t *testing.T) {
+	l := SimulatedLoad{LogLinesPerSecond: -1, CPUPercent: 1e9}.normalized()
+	if l.LogLinesPerSecond != 0 || l.LogLineBytes != DefaultSimulatedLogBytes {
+		t.Errorf("unexpected log load: %+v", l)
+	}
+	if l.CPUPercent != float64(runtime.NumCPU()*100) {
+		t.Errorf("expected CPU capped at %d%%, got %v", runtime.NumCPU()*100, l.CPUPercent)
+	}
+
+	l = SimulatedLoad{LogLinesPerSecond: 1e9, LogLineBytes: 1e9}.normalized()
+	if l.LogLinesPerSecond != MaxSimulatedLogRate || l.LogLineBytes != MaxSimulatedLogBytes {
+		t.Errorf("expected log load capped, got %+v", l)
+	}
+}
+
+func TestSimulatedMinerLogLoad(t *testing.T) {
+	miner := NewSimulatedMiner(SimulatedMinerConfig{
+		Name:         "sim-load",
+		Algorithm:    "rx/0",
+		BaseHashrate: 1000,
+		Load:         SimulatedLoad{LogLinesPerSecond: 1000, LogLineBytes: 64, CPUPercent: 10},
+	})
+	if err := miner.Start(&Config{}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(miner.GetLogs()) < simulatedLogLines && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	logs := miner.GetLogs()
+	if len(logs) != simulatedLogLines {
+		t.Fatalf("expected the log capped at %d lines, got %d", simulatedLogLines, len(logs))
+	}
+	if line := logs[len(logs)-1]; len(line) != 64 {
+		t.Errorf("expected 64-byte lines, got %d: %q", len(line), line)
+	}
+
+	if err := miner.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	time.Sleep(2 * simulatedLoadTick)
+	last := miner.GetLogs()[simulatedLogLines-1]
+	time.Sleep(2 * simulatedLoadTick)
+	if miner.GetLogs()[simulatedLogLines-1] != last {
+		t.Error("expected no more log lines after Stop")
+	}
+}
@@ -12,6 +12,9 @@ import (
 // MinerTypeSimulated is the type identifier for simulated miners.
 const MinerTypeSimulated = "simulated"
 
+// simulatedLogLines is how many log lines a simulated miner keeps.
+const simulatedLogLines = 100
+
 // SimulatedMiner is a mock miner that generates realistic-looking stats for UI testing.
 type SimulatedMiner struct {
 	// Exported fields for JSON serialization
@@ -22,6 +25,7 @@ type SimulatedMiner struct {
 	Path            string              `json:"path"`
 	MinerBinary     string              `json:"miner_binary"`
 	Running         bool                `json:"running"`
+	State           MinerState          `json:"state,omitempty"` // Set by the manager, see MinerState
 	Algorithm       string              `json:"algorithm"`
 	HashrateHistory []HashratePoint     `json:"hashrateHistory"`
 	LowResHistory   []HashratePoint     `json:"lowResHashrateHistory"`
@@ -40,6 +44,9 @@ type SimulatedMiner struct {
 	stopChan       chan struct{}
 	poolName       string
 	difficultyBase int
+	load           SimulatedLoad
+	faults         SimulatedFaults
+	fault          simulatedFaultState
 }
 
 // SimulatedMinerConfig holds configuration for creating a simulated miner.
@@ -50,6 +57,14 @@ type SimulatedMinerConfig struct {
 	Variance     float64 // Variance as percentage (0.0-0.2 for 20% variance)
 	PoolName     string  // Simulated pool name
 	Difficulty   int     // Base difficulty
+
+	// Load makes the miner use real resources, for load-testing the
+	// service with many simulated miners. Zero for none.
+	Load SimulatedLoad
+
+	// Faults are the chances of the miner failing on its own, for testing
+	// crash restarts and error handling. Zero for none.
+	Faults SimulatedFaults
 }
 
 // NewSimulatedMiner creates a new simulated miner instance.
@@ -79,6 +94,8 @@ func NewSimulatedMiner(config SimulatedMinerConfig) *SimulatedMiner {
 		poolName:        config.PoolName,
 		difficultyBase:  config.Difficulty,
 		logs:            make([]string, 0),
+		load:            config.Load.normalized(),
+		faults:          config.Faults,
 	}
 }
 
@@ -110,8 +127,10 @@ func (m *SimulatedMiner) Start(config *Config) error {
 	m.shares = 0
 	m.rejected = 0
 	m.stopChan = make(chan struct{})
+	stop := m.stopChan
 	m.HashrateHistory = make([]HashratePoint, 0)
 	m.LowResHistory = make([]HashratePoint, 0)
+	m.fault = simulatedFaultState{lastExit: m.fault.lastExit} // Injected faults end with the run
 	m.logs = []string{
 		fmt.Sprintf("[%s] Simulated miner starting...", time.Now().Format("15:04:05")),
 		fmt.Sprintf("[%s] Connecting to %s", time.Now().Format("15:04:05"), m.poolName),
@@ -120,7 +139,8 @@ func (m *SimulatedMiner) Start(config *Config) error {
 	m.mu.Unlock()
 
 	// Start background simulation
-	go m.runSimulation()
+	go m.runSimulation(stop)
+	m.startLoad(stop)
 
 	return nil
 }
@@ -131,7 +151,7 @@ func (m *SimulatedMiner) Stop() error {
 	defer m.mu.Unlock()
 
 	if !m.Running {
-		return fmt.Errorf("simulated miner %s is not running", m.Name)
+		return fmt.Errorf("simulated miner %s: %w", m.Name, ErrProcessNotRunning)
 	}
 
 	close(m.stopChan)
@@ -141,8 +161,9 @@ func (m *SimulatedMiner) Stop() error {
 	return nil
 }
 
-// runSimulation runs the background simulation loop.
-func (m *SimulatedMiner) runSimulation() {
+// runSimulation runs the background simulation loop until stop is closed.
+// The channel is passed in so a restart can't swap it out from under the loop.
+func (m *SimulatedMiner) runSimulation(stop <-chan struct{}) {
 	ticker := time.NewTicker(HighResolutionInterval)
 	defer ticker.Stop()
 
@@ -151,10 +172,11 @@ func (m *SimulatedMiner) runSimulation() {
 
 	for {
 		select {
-		case <-m.stopChan:
+		case <-stop:
 			return
 		case <-ticker.C:
 			m.updateHashrate()
+			m.rollFaults()
 		case <-shareTicker.C:
 			m.simulateShare()
 			// Randomize next share time
@@ -183,7 +205,7 @@ func (m *SimulatedMiner) updateHashrate() {
 
 	// Calculate final hashrate
 	hashrate := int(float64(m.baseHashrate) * rampFactor * (1.0 + sineVariation + noise))
-	if hashrate < 0 {
+	if hashrate < 0 || m.idle(now) {
 		hashrate = 0
 	}
 
@@ -228,7 +250,9 @@ func (m *SimulatedMiner) updateHashrate() {
 	m.FullStats.Results.DiffCurrent = diffCurrent
 	m.FullStats.Results.AvgTime = 15 + rand.Intn(10) // Simulated avg share time
 	m.FullStats.Results.HashesTotal = m.shares * diffCurrent
-	m.FullStats.Connection.Pool = m.poolName
+	if now.After(m.fault.disconnectedUntil) {
+		m.FullStats.Connection.Pool = m.poolName
+	}
 	m.FullStats.Connection.Uptime = uptimeInt
 	m.FullStats.Connection.Diff = diffCurrent
 	m.FullStats.Connection.Accepted = m.shares
@@ -248,6 +272,10 @@ func (m *SimulatedMiner) simulateShare() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.idle(time.Now()) {
+		return
+	}
+
 	// 2% chance of rejected share
 	if rand.Float64() < 0.02 {
 		m.rejected++
@@ -258,9 +286,13 @@ func (m *SimulatedMiner) simulateShare() {
 		m.logs = append(m.logs, fmt.Sprintf("[%s] Share accepted (%d/%d) diff %d", time.Now().Format("15:04:05"), m.shares, m.rejected, diff))
 	}
 
-	// Keep last 100 log lines
-	if len(m.logs) > 100 {
-		m.logs = m.logs[len(m.logs)-100:]
+	m.trimLogs()
+}
+
+// trimLogs keeps the last simulatedLogLines log lines. Caller must hold m.mu.
+func (m *SimulatedMiner) trimLogs() {
+	if len(m.logs) > simulatedLogLines {
+		m.logs = m.logs[len(m.logs)-simulatedLogLines:]
 	}
 }
 
@@ -270,7 +302,11 @@ func (m *SimulatedMiner) GetStats(ctx context.Context) (*PerformanceMetrics, err
 	defer m.mu.RUnlock()
 
 	if !m.Running {
-		return nil, fmt.Errorf("simulated miner %s is not running", m.Name)
+		return nil, fmt.Errorf("simulated miner %s: %w", m.Name, ErrProcessNotRunning)
+	}
+	now := time.Now()
+	if err := m.apiError(now); err != nil {
+		return nil, err
 	}
 
 	// Calculate current hashrate from recent history
@@ -297,8 +333,9 @@ func (m *SimulatedMiner) GetStats(ctx context.Context) (*PerformanceMetrics, err
 		AvgDifficulty: avgDiff,
 		DiffCurrent:   m.difficultyBase + rand.Intn(m.difficultyBase/2),
 		ExtraData: map[string]interface{}{
-			"pool":      m.poolName,
-			"simulated": true,
+			"pool":          m.poolName,
+			"poolConnected": now.After(m.fault.disconnectedUntil),
+			"simulated":     true,
 		},
 	}, nil
 }
@@ -410,7 +447,7 @@ func (m *SimulatedMiner) WriteStdin(input string) error {
 	defer m.mu.Unlock()
 
 	if !m.Running {
-		return fmt.Errorf("simulated miner %s is not running", m.Name)
+		return fmt.Errorf("simulated miner %s: %w", m.Name, ErrProcessNotRunning)
 	}
 
 	m.logs = append(m.logs, fmt.Sprintf("[%s] stdin: %s", time.Now().Format("15:04:05"), input))
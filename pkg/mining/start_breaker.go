@@ -0,0 +1,104 @@
+package mining
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// startBreakerConfig trips a start breaker after 3 failed starts within 5
+// minutes and pauses starts for 2 minutes, enough to stop a client retrying a
+// broken profile from spawning processes in a tight loop.
+var startBreakerConfig = CircuitBreakerConfig{
+	FailureThreshold: 3,
+	FailureWindow:    5 * time.Minute,
+	ResetTimeout:     2 * time.Minute,
+	SuccessThreshold: 1,
+}
+
+// StartBreakerStatus reports the state of the breaker guarding starts of one
+// profile or miner type.
+type StartBreakerStatus struct {
+	Key               string `json:"key"`
+	State             string `json:"state"`
+	Failures          int    `json:"failures"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds,omitempty"`
+}
+
+// startBreakerKey identifies the breaker for a start: the profile when the
+// miner is started from one, otherwise the miner type.
+func startBreakerKey(minerType, profileID string) string {
+	if profileID != "" {
+		return "profile:" + profileID
+	}
+	return "miner:" + minerType
+}
+
+// startBreaker returns the breaker for key, creating it on first use.
+// Caller must hold m.mu.
+func (m *Manager) startBreaker(key string) *CircuitBreaker {
+	if m.startBreakers == nil {
+		m.startBreakers = make(map[string]*CircuitBreaker)
+	}
+	cb, ok := m.startBreakers[key]
+	if !ok {
+		cb = NewCircuitBreaker("start "+key, startBreakerConfig)
+		m.startBreakers[key] = cb
+	}
+	return cb
+}
+
+// recordStartFailure counts a failed start against key's breaker and reports
+// whether it tripped the breaker open. Caller must hold m.mu.
+func (m *Manager) recordStartFailure(key string) bool {
+	cb := m.startBreaker(key)
+	wasOpen := cb.State() == CircuitOpen
+	cb.recordFailure()
+	if wasOpen || cb.State() != CircuitOpen {
+		return false
+	}
+	RecordStartBreakerTrip()
+	return true
+}
+
+// StartBreakers returns the state of every start breaker that has seen a failure.
+func (m *Manager) StartBreakers() []StartBreakerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]StartBreakerStatus, 0, len(m.startBreakers))
+	for key, cb := range m.startBreakers {
+		cb.mu.RLock()
+		state, failures := cb.state, cb.failures
+		cb.mu.RUnlock()
+		if state == CircuitClosed && failures == 0 {
+			continue
+		}
+		statuses = append(statuses, StartBreakerStatus{
+			Key:               key,
+			State:             state.String(),
+			Failures:          failures,
+			RetryAfterSeconds: int(cb.RetryAfter().Round(time.Second).Seconds()),
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Key < statuses[j].Key })
+	return statuses
+}
+
+// ResetStartBreaker closes the start breaker for key so starts are allowed
+// again immediately, e.g. after the user has fixed a broken profile.
+func (m *Manager) ResetStartBreaker(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cb, ok := m.startBreakers[key]
+	if !ok {
+		return fmt.Errorf("no start breaker for %s", key)
+	}
+	cb.Reset()
+	delete(m.startBreakers, key)
+	logging.Info("start breaker reset", logging.Fields{"key": key})
+	return nil
+}
@@ -0,0 +1,54 @@
+package mining
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestStartBreakerOpensAfterRepeatedFailures(t *testing.T) {
+	RegisterMinerType("failing-start", func() Miner {
+		return &MockMiner{
+			GetNameFunc:       func() string { return "failing-start" },
+			GetTypeFunc:       func() string { return "failing-start" },
+			StartFunc:         func(config *Config) error { return errors.New("binary crashed") },
+			GetBinaryPathFunc: func() string { return "" },
+		}
+	})
+
+	m := NewManagerForSimulation()
+	defer m.Stop()
+
+	for i := 0; i < startBreakerConfig.FailureThreshold; i++ {
+		if _, err := m.StartMinerWithProfile(context.Background(), "failing-start", "broken", &Config{}); err == nil {
+			t.Fatal("expected start to fail")
+		}
+	}
+
+	_, err := m.StartMinerWithProfile(context.Background(), "failing-start", "broken", &Config{})
+	var miningErr *MiningError
+	if !errors.As(err, &miningErr) || miningErr.Code != ErrCodeServiceUnavailable || miningErr.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("expected a SERVICE_UNAVAILABLE rejection once the breaker opened, got %v", err)
+	}
+
+	// Other profiles of the same miner type are unaffected
+	if _, err := m.StartMinerWithProfile(context.Background(), "failing-start", "other", &Config{}); errors.As(err, &miningErr) && miningErr.Code == ErrCodeServiceUnavailable {
+		t.Error("expected a different profile not to be blocked")
+	}
+
+	statuses := m.StartBreakers()
+	if len(statuses) != 2 || statuses[0].Key != "profile:broken" || statuses[0].State != "open" || statuses[0].RetryAfterSeconds == 0 {
+		t.Fatalf("unexpected breaker statuses: %+v", statuses)
+	}
+
+	if err := m.ResetStartBreaker("profile:broken"); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if _, err := m.StartMinerWithProfile(context.Background(), "failing-start", "broken", &Config{}); errors.As(err, &miningErr) && miningErr.Code == ErrCodeServiceUnavailable {
+		t.Error("expected starts to be allowed after a reset")
+	}
+	if err := m.ResetStartBreaker("profile:missing"); err == nil {
+		t.Error("expected an error resetting an unknown breaker")
+	}
+}
@@ -0,0 +1,78 @@
+package mining
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrStartOnBootUnsupported is returned when the platform has no supported
+// way to start the app at login.
+var ErrStartOnBootUnsupported = errors.New("start on boot is not supported on this platform")
+
+// StartOnBootStatus reports whether the app is registered to start at login at
+// the OS level. Preference is the stored setting, which can drift from the OS
+// state when the entry is removed by hand or registering it failed.
+type StartOnBootStatus struct {
+	Supported  bool   `json:"supported"`
+	Enabled    bool   `json:"enabled"`
+	Preference bool   `json:"preference"`
+	Method     string `json:"method,omitempty"`   // "systemd", "launchd" or "registry"
+	Location   string `json:"location,omitempty"` // Unit file, plist or registry value
+	Error      string `json:"error,omitempty"`
+}
+
+// startOnBootCommand returns the command registered to run at login: this
+// executable with args, which are none for the desktop app.
+var startOnBootCommand = func(args []string) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return append([]string{exe}, args...), nil
+}
+
+// applyStartOnBoot registers or removes the login entry. A variable so tests
+// can leave the OS untouched.
+var applyStartOnBoot = setStartOnBoot
+
+// setStartOnBoot registers the executable to start at login with args, or
+// removes the entry.
+func setStartOnBoot(enabled bool, args []string) error {
+	var err error
+	if enabled {
+		var command []string
+		if command, err = startOnBootCommand(args); err == nil {
+			err = enableStartOnBoot(command)
+		}
+	} else {
+		err = disableStartOnBoot()
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("no permission to update %s: %w", startOnBootLocation(), err)
+	}
+	return err
+}
+
+// startOnBootStatus checks the OS for the login entry.
+func startOnBootStatus() StartOnBootStatus {
+	status := StartOnBootStatus{
+		Supported: true,
+		Method:    startOnBootMethod,
+		Location:  startOnBootLocation(),
+	}
+	enabled, err := startOnBootEnabled()
+	switch {
+	case errors.Is(err, ErrStartOnBootUnsupported):
+		status.Supported = false
+	case err != nil:
+		status.Error = err.Error()
+	}
+	status.Enabled = enabled
+	return status
+}
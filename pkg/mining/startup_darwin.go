@@ -0,0 +1,72 @@
+//go:build darwin
+
+package mining
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+)
+
+const (
+	startOnBootMethod = "launchd"
+	launchAgentLabel  = "com.lethean.mining"
+)
+
+// launchAgentsDir is where per-user launch agents live; launchd loads them at login.
+var launchAgentsDir = func() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "LaunchAgents")
+}
+
+func startOnBootLocation() string {
+	return filepath.Join(launchAgentsDir(), launchAgentLabel+".plist")
+}
+
+// enableStartOnBoot writes a LaunchAgent that runs command once at login.
+func enableStartOnBoot(command []string) error {
+	var args bytes.Buffer
+	for _, arg := range command {
+		args.WriteString("\t\t<string>")
+		if err := xml.EscapeText(&args, []byte(arg)); err != nil {
+			return err
+		}
+		args.WriteString("</string>\n")
+	}
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + launchAgentLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+` + args.String() + `	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`
+	if err := os.MkdirAll(launchAgentsDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(startOnBootLocation(), []byte(plist), 0644)
+}
+
+func disableStartOnBoot() error {
+	if err := os.Remove(startOnBootLocation()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func startOnBootEnabled() (bool, error) {
+	if _, err := os.Stat(startOnBootLocation()); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,115 @@
+//go:build linux
+
+package mining
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+)
+
+const (
+	startOnBootMethod = "systemd"
+	systemdUnitName   = "mining.service"
+)
+
+// systemdUserDir is where user units live; a variable so tests can redirect it.
+var systemdUserDir = func() string {
+	return filepath.Join(xdg.ConfigHome, "systemd", "user")
+}
+
+// systemctl runs systemctl against the user's service manager.
+var systemctl = func(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func startOnBootLocation() string {
+	return filepath.Join(systemdUserDir(), systemdUnitName)
+}
+
+// enableStartOnBoot writes a user unit wanted by default.target and enables it,
+// so it starts when the user's service manager does: at login, or at boot if
+// lingering is enabled for the user.
+func enableStartOnBoot(command []string) error {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	unit := fmt.Sprintf(`[Unit]
+Description=Lethean Mining
+After=network-online.target
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`, strings.Join(quoted, " "))
+
+	if err := os.MkdirAll(systemdUserDir(), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(startOnBootLocation(), []byte(unit), 0644); err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", systemdUnitName)
+}
+
+// disableStartOnBoot disables and removes the unit. The wants link is removed
+// directly as well, so a missing or failing systemctl can't leave it enabled.
+func disableStartOnBoot() error {
+	if _, err := os.Stat(startOnBootLocation()); os.IsNotExist(err) {
+		return nil
+	}
+	_ = systemctl("disable", systemdUnitName)
+	if err := os.Remove(systemdWantsLink()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(startOnBootLocation()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = systemctl("daemon-reload")
+	return nil
+}
+
+// startOnBootEnabled reports whether the unit exists and is linked into
+// default.target, which is what `systemctl enable` does for it.
+func startOnBootEnabled() (bool, error) {
+	if _, err := os.Stat(startOnBootLocation()); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := os.Lstat(systemdWantsLink()); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func systemdWantsLink() string {
+	return filepath.Join(systemdUserDir(), "default.target.wants", systemdUnitName)
+}
+
+// systemdQuote quotes an ExecStart argument, escaping specifiers and quotes.
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	return `"` + arg + `"`
+}
@@ -0,0 +1,108 @@
+//go:build linux
+
+package mining
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubSystemd redirects the user unit directory to a temp dir and replaces
+// systemctl with a fake that links the unit on enable, as systemctl does.
+func stubSystemd(t *testing.T) (dir string, calls *[]string) {
+	t.Helper()
+	dir = t.TempDir()
+	calls = &[]string{}
+	origDir, origCtl := systemdUserDir, systemctl
+	systemdUserDir = func() string { return dir }
+	systemctl = func(args ...string) error {
+		*calls = append(*calls, strings.Join(args, " "))
+		if args[0] == "enable" {
+			if err := os.MkdirAll(filepath.Dir(systemdWantsLink()), 0755); err != nil {
+				return err
+			}
+			return os.Symlink(startOnBootLocation(), systemdWantsLink())
+		}
+		return nil
+	}
+	t.Cleanup(func() { systemdUserDir, systemctl = origDir, origCtl })
+	return dir, calls
+}
+
+func TestStartOnBootSystemd(t *testing.T) {
+	_, calls := stubSystemd(t)
+
+	if err := enableStartOnBoot([]string{"/opt/my miner/mining", "50%"}); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	unit, err := os.ReadFile(startOnBootLocation())
+	if err != nil {
+		t.Fatalf("unit not written: %v", err)
+	}
+	if !strings.Contains(string(unit), `ExecStart="/opt/my miner/mining" "50%%"`) {
+		t.Errorf("unexpected ExecStart in unit:\n%s", unit)
+	}
+	if got := strings.Join(*calls, ","); got != "daemon-reload,enable mining.service" {
+		t.Errorf("unexpected systemctl calls: %s", got)
+	}
+
+	status := startOnBootStatus()
+	if !status.Supported || !status.Enabled || status.Method != "systemd" {
+		t.Errorf("expected enabled systemd status, got %+v", status)
+	}
+
+	if err := disableStartOnBoot(); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if status := startOnBootStatus(); status.Enabled {
+		t.Error("expected start on boot to be disabled")
+	}
+	if _, err := os.Lstat(systemdWantsLink()); !os.IsNotExist(err) {
+		t.Error("expected the wants link to be removed")
+	}
+}
+
+func TestStartOnBootStatusIgnoresPreference(t *testing.T) {
+	stubSystemd(t)
+
+	sm := &SettingsManager{settings: DefaultSettings(), settingsPath: filepath.Join(t.TempDir(), "settings.json")}
+	sm.settings.StartOnBoot = true
+
+	status := sm.GetStartOnBootStatus()
+	if !status.Preference || status.Enabled {
+		t.Errorf("expected preference on but OS entry missing, got %+v", status)
+	}
+}
+
+func TestSetStartOnBootKeepsPreferenceOnFailure(t *testing.T) {
+	stubSystemd(t)
+	systemctl = func(args ...string) error { return errors.New("no user service manager") }
+
+	sm := &SettingsManager{settings: DefaultSettings(), settingsPath: filepath.Join(t.TempDir(), "settings.json")}
+	if err := sm.SetStartOnBoot(true); err == nil {
+		t.Fatal("expected an error when systemctl fails")
+	}
+	if sm.Get().StartOnBoot {
+		t.Error("expected the preference to stay off after a failed enable")
+	}
+}
+
+func TestSetStartOnBootRegistersArgs(t *testing.T) {
+	stubSystemd(t)
+
+	sm := &SettingsManager{settings: DefaultSettings(), settingsPath: filepath.Join(t.TempDir(), "settings.json")}
+	sm.SetStartOnBootArgs([]string{"serve", "--port", "9090"})
+	if err := sm.SetStartOnBoot(true); err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	unit, err := os.ReadFile(startOnBootLocation())
+	if err != nil {
+		t.Fatalf("unit not written: %v", err)
+	}
+	if !strings.Contains(string(unit), `" "serve" "--port" "9090"`) {
+		t.Errorf("expected the serve command registered, got:\n%s", unit)
+	}
+}
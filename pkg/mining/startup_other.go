@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package mining
+
+const startOnBootMethod = ""
+
+func startOnBootLocation() string { return "" }
+
+func enableStartOnBoot(command []string) error { return ErrStartOnBootUnsupported }
+
+func disableStartOnBoot() error { return nil }
+
+func startOnBootEnabled() (bool, error) { return false, ErrStartOnBootUnsupported }
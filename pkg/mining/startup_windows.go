@@ -0,0 +1,72 @@
+//go:build windows
+
+package mining
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	startOnBootMethod = "registry"
+	runKeyPath        = `Software\Microsoft\Windows\CurrentVersion\Run`
+	runValueName      = "LetheanMining"
+)
+
+func startOnBootLocation() string {
+	return `HKCU\` + runKeyPath + `\` + runValueName
+}
+
+// enableStartOnBoot adds command to the current user's Run key, which Windows
+// runs at login without needing elevation.
+func enableStartOnBoot(command []string) error {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = syscall.EscapeArg(arg)
+	}
+	return key.SetStringValue(runValueName, strings.Join(quoted, " "))
+}
+
+func disableStartOnBoot() error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer key.Close()
+
+	if err := key.DeleteValue(runValueName); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func startOnBootEnabled() (bool, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.QUERY_VALUE)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer key.Close()
+
+	if _, _, err := key.GetStringValue(runValueName); err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 // StatsCollector defines the interface for collecting miner statistics.
@@ -21,24 +22,24 @@ type HTTPStatsConfig struct {
 	Host     string
 	Port     int
 	Endpoint string // e.g., "/2/summary" for XMRig, "/summary" for TT-Miner
+	BaseURL  string // If set, used instead of Host and Port, e.g. "http://127.0.0.1:41234"
 }
 
 // FetchJSONStats performs an HTTP GET request and decodes the JSON response.
 // This is a common helper for HTTP-based miner stats collection.
 // The caller must provide the target struct to decode into.
 func FetchJSONStats[T any](ctx context.Context, config HTTPStatsConfig, target *T) error {
-	if config.Port == 0 {
-		return fmt.Errorf("API port is zero")
+	var url string
+	if config.BaseURL != "" {
+		url = strings.TrimSuffix(config.BaseURL, "/") + config.Endpoint
+	} else {
+		if config.Port == 0 {
+			return fmt.Errorf("API port is zero")
+		}
+		url = fmt.Sprintf("http://%s:%d%s", config.Host, config.Port, config.Endpoint)
 	}
 
-	url := fmt.Sprintf("http://%s:%d%s", config.Host, config.Port, config.Endpoint)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := getHTTPClient().Do(req)
+	resp, err := httpGetWithRetry(ctx, getStatsHTTPClient(), GetHTTPClientConfig().Stats, url, nil)
 	if err != nil {
 		return fmt.Errorf("HTTP request failed: %w", err)
 	}
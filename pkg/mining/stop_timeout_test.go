@@ -0,0 +1,81 @@
+package mining
+
+import (
+	"bufio"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStopTimeoutHonored(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Stop kills immediately on Windows")
+	}
+
+	for _, tt := range []struct {
+		stopTimeout int
+		want        time.Duration
+	}{
+		{1, time.Second}, // Well under DefaultStopTimeout
+	} {
+		b := &BaseMiner{Name: "stubborn"}
+		// Ignore SIGTERM so only the kill after the timeout ends it
+		b.cmd = exec.Command("sh", "-c", "trap '' TERM; echo ready; exec sleep 30")
+		stdout, _ := b.cmd.StdoutPipe()
+		if err := b.startProcess(&Config{StopTimeout: tt.stopTimeout}); err != nil {
+			t.Fatalf("failed to start process: %v", err)
+		}
+		b.Running = true
+		// Don't signal before the trap is in place
+		if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+			t.Fatalf("process didn't start: %v", err)
+		}
+		if got := b.StopTimeout(); got != tt.want {
+			t.Errorf("expected stop timeout %v, got %v", tt.want, got)
+		}
+
+		start := time.Now()
+		if err := b.Stop(); err != nil {
+			t.Fatalf("Stop failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < tt.want || elapsed > tt.want+time.Second {
+			t.Errorf("StopTimeout %ds: expected Stop to take about %v, took %v", tt.stopTimeout, tt.want, elapsed)
+		}
+	}
+}
+
+func TestStopTimeoutDefaultAndBounds(t *testing.T) {
+	if got := (&Config{}).StopTimeoutDuration(); got != DefaultStopTimeout {
+		t.Errorf("expected the default %v, got %v", DefaultStopTimeout, got)
+	}
+	if got := (&BaseMiner{}).StopTimeout(); got != DefaultStopTimeout {
+		t.Errorf("expected a miner never started to report the default, got %v", got)
+	}
+
+	for _, seconds := range []int{0, 1, 30} {
+		if err := (&Config{StopTimeout: seconds}).Validate(); err != nil {
+			t.Errorf("stopTimeout %d should be valid: %v", seconds, err)
+		}
+	}
+	for _, seconds := range []int{-1, 31} {
+		if err := (&Config{StopTimeout: seconds}).Validate(); err == nil {
+			t.Errorf("stopTimeout %d should be rejected", seconds)
+		}
+	}
+}
+
+func TestManagerShutdownTimeout(t *testing.T) {
+	m := &Manager{miners: map[string]Miner{}, stopChan: make(chan struct{})}
+	m.SetShutdownTimeout(50 * time.Millisecond)
+
+	// A goroutine that never finishes holds shutdown for the whole timeout
+	m.waitGroup.Add(1)
+	defer m.waitGroup.Done()
+
+	start := time.Now()
+	m.Stop()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > ShutdownTimeout/2 {
+		t.Errorf("expected shutdown to give up after about 50ms, took %v", elapsed)
+	}
+}
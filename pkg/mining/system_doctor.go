@@ -0,0 +1,174 @@
+package mining
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// CheckStatus is the outcome of a single system check.
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+	CheckSkip CheckStatus = "skip" // Not applicable or not determinable on this platform
+)
+
+// checkSeverity orders statuses so a report can surface the worst one.
+var checkSeverity = map[CheckStatus]int{CheckSkip: 0, CheckPass: 1, CheckWarn: 2, CheckFail: 3}
+
+// SystemCheck is one item of the system doctor checklist.
+type SystemCheck struct {
+	Name        string      `json:"name"`
+	Status      CheckStatus `json:"status"`
+	Message     string      `json:"message"`
+	Remediation string      `json:"remediation,omitempty"`
+}
+
+// SystemDoctorReport is the result of checking mining prerequisites on this host.
+type SystemDoctorReport struct {
+	OS     string        `json:"os"`
+	Status CheckStatus   `json:"status"` // Worst status across all checks
+	Checks []SystemCheck `json:"checks"`
+}
+
+// systemCheckPaths are the kernel interfaces read by the Linux checks (vars for tests).
+var systemCheckPaths = struct {
+	msr           string
+	governor      string
+	numaBalancing string
+}{
+	msr:           "/dev/cpu/0/msr",
+	governor:      "/sys/devices/system/cpu/cpu0/cpufreq/scaling_governor",
+	numaBalancing: "/proc/sys/kernel/numa_balancing",
+}
+
+// RunSystemChecks checks the host for mining prerequisites that affect hashrate:
+// huge pages, MSR access, CPU governor, NUMA balancing and privileges. Checks
+// that don't apply to the current platform are reported as skipped.
+func RunSystemChecks() SystemDoctorReport {
+	report := SystemDoctorReport{
+		OS:     runtime.GOOS,
+		Status: CheckSkip,
+		Checks: []SystemCheck{
+			checkHugePagesReserved(),
+			checkMSRAccess(),
+			checkCPUGovernor(),
+			checkNUMABalancing(),
+			checkPrivileges(),
+		},
+	}
+	for _, check := range report.Checks {
+		if checkSeverity[check.Status] > checkSeverity[report.Status] {
+			report.Status = check.Status
+		}
+	}
+	return report
+}
+
+func checkHugePagesReserved() SystemCheck {
+	check := SystemCheck{Name: "huge_pages"}
+	info := GetHugePagesInfo()
+	switch {
+	case !info.Supported:
+		check.Status = CheckSkip
+		check.Message = info.Message
+	case info.Total == 0:
+		check.Status = CheckWarn
+		check.Message = "no huge pages reserved; RandomX will run 20-30% slower"
+		check.Remediation = "sudo sysctl -w vm.nr_hugepages=1280 (add to /etc/sysctl.conf to persist)"
+	default:
+		check.Status = CheckPass
+		check.Message = fmt.Sprintf("%d huge pages reserved, %d free", info.Total, info.Free)
+	}
+	return check
+}
+
+func checkMSRAccess() SystemCheck {
+	check := SystemCheck{Name: "msr"}
+	if runtime.GOOS != "linux" {
+		check.Status = CheckSkip
+		check.Message = "MSR check is only available on Linux"
+		return check
+	}
+	if _, err := os.Stat(systemCheckPaths.msr); err != nil {
+		check.Status = CheckWarn
+		check.Message = "msr kernel module is not loaded; RandomX MSR tuning is unavailable"
+		check.Remediation = "sudo modprobe msr"
+		return check
+	}
+	f, err := os.OpenFile(systemCheckPaths.msr, os.O_WRONLY, 0)
+	if err != nil {
+		check.Status = CheckWarn
+		check.Message = "MSR registers are not writable by this process"
+		check.Remediation = "run the miner as root to apply the RandomX MSR mod"
+		return check
+	}
+	f.Close()
+	check.Status = CheckPass
+	check.Message = "MSR registers are writable"
+	return check
+}
+
+func checkCPUGovernor() SystemCheck {
+	check := SystemCheck{Name: "cpu_governor"}
+	data, err := os.ReadFile(systemCheckPaths.governor)
+	if err != nil {
+		check.Status = CheckSkip
+		check.Message = "CPU frequency governor is not exposed on this system"
+		return check
+	}
+	governor := strings.TrimSpace(string(data))
+	if governor == "performance" {
+		check.Status = CheckPass
+		check.Message = "CPU governor is set to performance"
+		return check
+	}
+	check.Status = CheckWarn
+	check.Message = fmt.Sprintf("CPU governor is %q; clocks may scale down under load", governor)
+	check.Remediation = "sudo cpupower frequency-set -g performance"
+	return check
+}
+
+func checkNUMABalancing() SystemCheck {
+	check := SystemCheck{Name: "numa_balancing"}
+	data, err := os.ReadFile(systemCheckPaths.numaBalancing)
+	if err != nil {
+		check.Status = CheckSkip
+		check.Message = "NUMA balancing is not available on this system"
+		return check
+	}
+	if strings.TrimSpace(string(data)) == "0" {
+		check.Status = CheckPass
+		check.Message = "automatic NUMA balancing is disabled"
+		return check
+	}
+	check.Status = CheckWarn
+	check.Message = "automatic NUMA balancing is enabled and can migrate RandomX datasets between nodes"
+	check.Remediation = "sudo sysctl -w kernel.numa_balancing=0"
+	return check
+}
+
+func checkPrivileges() SystemCheck {
+	check := SystemCheck{Name: "privileges"}
+	priv := DetectPrivileges()
+	if priv.Elevated {
+		check.Status = CheckPass
+		check.Message = "running with elevated privileges"
+		return check
+	}
+	check.Status = CheckWarn
+	check.Message = "not running elevated; 1GB huge pages and MSR tuning need root/Administrator"
+	if len(priv.Capabilities) > 0 {
+		check.Message += " (capabilities: " + strings.Join(priv.Capabilities, ", ") + ")"
+	}
+	if runtime.GOOS == "windows" {
+		check.Remediation = "run the miner as Administrator"
+	} else {
+		check.Remediation = "pre-configure huge pages and MSR as root, or run the miner with sudo"
+	}
+	return check
+}
@@ -0,0 +1,105 @@
+package mining
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func withSystemCheckPaths(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	original := systemCheckPaths
+	t.Cleanup(func() { systemCheckPaths = original })
+	systemCheckPaths.msr = filepath.Join(dir, "msr")
+	systemCheckPaths.governor = filepath.Join(dir, "scaling_governor")
+	systemCheckPaths.numaBalancing = filepath.Join(dir, "numa_balancing")
+	return dir
+}
+
+func TestCheckCPUGovernor(t *testing.T) {
+	withSystemCheckPaths(t)
+
+	if got := checkCPUGovernor().Status; got != CheckSkip {
+		t.Errorf("expected skip without cpufreq, got %s", got)
+	}
+
+	os.WriteFile(systemCheckPaths.governor, []byte("powersave\n"), 0644)
+	check := checkCPUGovernor()
+	if check.Status != CheckWarn || check.Remediation == "" {
+		t.Errorf("expected warn with remediation for powersave, got %+v", check)
+	}
+
+	os.WriteFile(systemCheckPaths.governor, []byte("performance\n"), 0644)
+	if got := checkCPUGovernor().Status; got != CheckPass {
+		t.Errorf("expected pass for performance governor, got %s", got)
+	}
+}
+
+func TestCheckNUMABalancing(t *testing.T) {
+	withSystemCheckPaths(t)
+
+	if got := checkNUMABalancing().Status; got != CheckSkip {
+		t.Errorf("expected skip without numa_balancing, got %s", got)
+	}
+	os.WriteFile(systemCheckPaths.numaBalancing, []byte("1\n"), 0644)
+	if got := checkNUMABalancing().Status; got != CheckWarn {
+		t.Errorf("expected warn when enabled, got %s", got)
+	}
+	os.WriteFile(systemCheckPaths.numaBalancing, []byte("0\n"), 0644)
+	if got := checkNUMABalancing().Status; got != CheckPass {
+		t.Errorf("expected pass when disabled, got %s", got)
+	}
+}
+
+func TestCheckMSRAccess(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		if got := checkMSRAccess().Status; got != CheckSkip {
+			t.Errorf("expected skip on %s, got %s", runtime.GOOS, got)
+		}
+		return
+	}
+	withSystemCheckPaths(t)
+
+	check := checkMSRAccess()
+	if check.Status != CheckWarn || check.Remediation != "sudo modprobe msr" {
+		t.Errorf("expected modprobe hint when msr is missing, got %+v", check)
+	}
+	os.WriteFile(systemCheckPaths.msr, nil, 0644)
+	if got := checkMSRAccess().Status; got != CheckPass {
+		t.Errorf("expected pass for writable msr, got %s", got)
+	}
+}
+
+func TestRunSystemChecksWorstStatus(t *testing.T) {
+	withSystemCheckPaths(t)
+	os.WriteFile(systemCheckPaths.governor, []byte("powersave\n"), 0644)
+
+	report := RunSystemChecks()
+	if len(report.Checks) != 5 {
+		t.Fatalf("expected 5 checks, got %d", len(report.Checks))
+	}
+	if report.Status != CheckWarn && report.Status != CheckFail {
+		t.Errorf("expected overall status to reflect the governor warning, got %s", report.Status)
+	}
+	for _, check := range report.Checks {
+		if check.Name == "" || check.Status == "" || check.Message == "" {
+			t.Errorf("incomplete check: %+v", check)
+		}
+	}
+}
+
+func TestHandleSystemDoctor(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	req, _ := http.NewRequest("GET", "/doctor/system", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
 )
 
 // TTMiner represents a TT-Miner (GPU miner), embedding the BaseMiner for common functionality.
@@ -116,11 +118,16 @@ func (m *TTMiner) Install() error {
 	}
 
 	// After installation, verify it.
-	_, err = m.CheckInstallation()
+	details, err := m.CheckInstallation()
 	if err != nil {
 		return fmt.Errorf("failed to verify installation after extraction: %w", err)
 	}
 
+	// Record the binary's checksum so later starts can detect tampering
+	if err := RecordBinaryHash(details.MinerBinary); err != nil {
+		logging.Warn("failed to record miner binary checksum", logging.Fields{"binary": details.MinerBinary, "error": err})
+	}
+
 	return nil
 }
 
@@ -3,8 +3,6 @@ package mining
 import (
 	"errors"
 	"fmt"
-	"io"
-	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -25,6 +23,11 @@ func (m *TTMiner) Start(config *Config) error {
 		}
 	}
 
+	// Refuse (or warn about) a binary that changed since install
+	if err := m.VerifyBinary(); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -52,19 +55,15 @@ func (m *TTMiner) Start(config *Config) error {
 	}
 	m.stdinPipe = stdinPipe
 
-	// Always capture output to LogBuffer
-	if m.LogBuffer != nil {
-		m.cmd.Stdout = m.LogBuffer
-		m.cmd.Stderr = m.LogBuffer
-	}
-	// Also output to console if requested
-	if config.LogOutput {
-		m.cmd.Stdout = io.MultiWriter(m.LogBuffer, os.Stdout)
-		m.cmd.Stderr = io.MultiWriter(m.LogBuffer, os.Stderr)
-	}
+	// Persist output to a rotating log file if enabled
+	m.openLogFile()
+
+	// Always capture output to LogBuffer, and to the console if requested
+	m.attachOutput(config.LogOutput)
 
-	if err := m.cmd.Start(); err != nil {
+	if err := m.startProcess(config); err != nil {
 		stdinPipe.Close()
+		m.closeLogFile()
 		return fmt.Errorf("failed to start TT-Miner: %w", err)
 	}
 
@@ -103,9 +102,14 @@ func (m *TTMiner) Start(config *Config) error {
 		m.mu.Lock()
 		// Only clear if this is still the same command (not restarted)
 		if m.cmd == cmd {
+			m.recordUnexpectedExit(cmd, err)
 			m.Running = false
 			m.cmd = nil
 		}
+		// Close the log file unless a restart already attached a new one
+		if m.cmd == nil {
+			m.closeLogFile()
+		}
 		m.mu.Unlock()
 		if err != nil {
 			logging.Debug("TT-Miner exited with error", logging.Fields{"error": err})
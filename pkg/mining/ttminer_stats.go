@@ -11,7 +11,7 @@ func (m *TTMiner) GetStats(ctx context.Context) (*PerformanceMetrics, error) {
 	m.mu.RLock()
 	if !m.Running {
 		m.mu.RUnlock()
-		return nil, errors.New("miner is not running")
+		return nil, ErrProcessNotRunning
 	}
 	if m.API == nil || m.API.ListenPort == 0 {
 		m.mu.RUnlock()
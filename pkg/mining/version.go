@@ -1,6 +1,7 @@
 package mining
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -69,7 +70,7 @@ func FetchLatestGitHubVersion(owner, repo string) (string, error) {
 func fetchGitHubVersionDirect(owner, repo string) (string, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
 
-	resp, err := getHTTPClient().Get(url)
+	resp, err := httpGetWithRetry(context.Background(), getHTTPClient(), GetHTTPClientConfig().Download, url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch version: %w", err)
 	}
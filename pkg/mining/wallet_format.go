@@ -0,0 +1,71 @@
+package mining
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Snider/Mining/pkg/mining/algos"
+)
+
+// algorithmFor returns the registered algorithm of a coin or, when no coin is
+// set, of an algorithm name.
+func algorithmFor(coin, algo string) (algos.Algorithm, bool) {
+	if coin != "" {
+		return algos.ForCoin(coin)
+	}
+	return algos.Lookup(algo)
+}
+
+// walletAddress strips the worker name and fixed difficulty suffixes pools
+// accept after an address ("ADDRESS.worker+diff").
+func walletAddress(wallet string) string {
+	if i := strings.IndexAny(wallet, ".+"); i >= 0 {
+		return wallet[:i]
+	}
+	return wallet
+}
+
+// walletFormatWarnings warns about wallets that don't look like addresses of
+// the configured coin or algorithm, per the algos registry. These are
+// warnings rather than errors, since some pools log in with account names or
+// exchange addresses instead.
+func walletFormatWarnings(config *Config) []ConfigIssue {
+	var warnings []ConfigIssue
+	check := func(field, wallet, coin, algo string) {
+		algorithm, ok := algorithmFor(coin, algo)
+		address := walletAddress(wallet)
+		if !ok || algorithm.Address == nil || address == "" || placeholderRegex.MatchString(address) || algorithm.Address.Matches(address) {
+			return
+		}
+		warnings = append(warnings, ConfigIssue{
+			Field:   field,
+			Message: fmt.Sprintf("%s doesn't look like a %s address (expected %s)", field, algorithm.Address.Name, algorithm.Address.Description),
+		})
+	}
+
+	check("wallet", config.Wallet, config.Coin, config.Algo)
+	if config.GPUEnabled && config.GPUWallet != "" {
+		check("gpuWallet", config.GPUWallet, "", config.GPUAlgo)
+	}
+	return warnings
+}
+
+// algoSupportWarnings warns about algorithms the registry doesn't list for
+// the miner type. Unregistered algorithms aren't flagged, since miners
+// support more than the registry knows about.
+func algoSupportWarnings(minerType string, config *Config) []ConfigIssue {
+	var warnings []ConfigIssue
+	check := func(field, algo string) {
+		if algorithm, ok := algos.Lookup(algo); ok && !algorithm.SupportedBy(minerType) {
+			warnings = append(warnings, ConfigIssue{
+				Field:   field,
+				Message: fmt.Sprintf("%s is not supported by %s (supported by %s)", algorithm.DisplayName, minerType, strings.Join(algorithm.Miners, ", ")),
+			})
+		}
+	}
+	check("algo", config.Algo)
+	if config.GPUEnabled {
+		check("gpuAlgo", config.GPUAlgo)
+	}
+	return warnings
+}
@@ -0,0 +1,85 @@
+package mining
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Snider/Mining/pkg/mining/algos"
+)
+
+func TestWalletFormatWarnings(t *testing.T) {
+	monero := "4" + strings.Repeat("A", 94)
+	tests := []struct {
+		name   string
+		config Config
+		warn   string // field warned about, empty for none
+	}{
+		{"monero address", Config{Wallet: monero, Algo: "rx/0"}, ""},
+		{"monero with worker and diff", Config{Wallet: monero + ".rig1+50000", Coin: "monero"}, ""},
+		{"truncated monero", Config{Wallet: monero[:90], Algo: "rx/0"}, "wallet"},
+		{"base58 excludes 0", Config{Wallet: "4" + strings.Repeat("0", 94), Coin: "XMR"}, "wallet"},
+		{"eth address", Config{Wallet: "0x" + strings.Repeat("ab", 20), Algo: "etchash"}, ""},
+		{"eth without prefix", Config{Wallet: strings.Repeat("ab", 20), Algo: "ethash"}, "wallet"},
+		{"coin takes precedence", Config{Wallet: monero, Coin: "rvn", Algo: "rx/0"}, "wallet"},
+		{"unknown algo", Config{Wallet: "anything", Algo: "cn/r"}, ""},
+		{"no algo", Config{Wallet: "anything"}, ""},
+		{"template", Config{Wallet: "%name%", Algo: "rx/0"}, ""},
+		{"gpu wallet", Config{Wallet: monero, Algo: "rx/0", GPUEnabled: true, GPUWallet: "R123", GPUAlgo: "kawpow"}, "gpuWallet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := walletFormatWarnings(&tt.config)
+			if tt.warn == "" && len(warnings) > 0 {
+				t.Errorf("expected no warnings, got %+v", warnings)
+			}
+			if tt.warn != "" && (len(warnings) != 1 || warnings[0].Field != tt.warn) {
+				t.Errorf("expected a %s warning, got %+v", tt.warn, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateMinerConfigWalletFormat(t *testing.T) {
+	result := ValidateMinerConfig(MinerTypeXMRig, &Config{Pool: "pool:3333", Wallet: "not-a-wallet", Algo: "rx/0"})
+	if !result.Valid {
+		t.Fatalf("expected a wallet format mismatch not to fail validation, got %+v", result.Errors)
+	}
+	found := false
+	for _, w := range result.Warnings {
+		found = found || (w.Field == "wallet" && strings.Contains(w.Message, "Monero"))
+	}
+	if !found {
+		t.Errorf("expected a Monero address warning, got %+v", result.Warnings)
+	}
+}
+
+func TestAlgoSupportWarnings(t *testing.T) {
+	if w := algoSupportWarnings(MinerTypeTTMiner, &Config{Algo: "rx/0"}); len(w) != 1 || w[0].Field != "algo" {
+		t.Errorf("expected TT-Miner to be warned about RandomX, got %+v", w)
+	}
+	if w := algoSupportWarnings(MinerTypeXMRig, &Config{Algo: "randomx"}); len(w) != 0 {
+		t.Errorf("expected no warning for an alias XMRig supports, got %+v", w)
+	}
+	if w := algoSupportWarnings(MinerTypeXMRig, &Config{Algo: "cn/half"}); len(w) != 0 {
+		t.Errorf("expected unregistered algorithms not to be flagged, got %+v", w)
+	}
+}
+
+func TestHandleListAlgorithms(t *testing.T) {
+	router, _ := setupTestRouter()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/algorithms?miner=xmrig&hardware=gpu", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var list []algos.Algorithm
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "kawpow" || list[0].Address == nil || list[0].Address.Pattern == "" {
+		t.Errorf("expected only KawPow, got %+v", list)
+	}
+}
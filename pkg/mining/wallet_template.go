@@ -0,0 +1,118 @@
+package mining
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Ways of telling a pool which worker a share came from, for Config.WorkerFormat.
+const (
+	WorkerFormatDot   = "dot"   // WALLET.WORKER+DIFF, used by most pools (2Miners, Nanopool, HeroMiners)
+	WorkerFormatRigID = "rigid" // WALLET+DIFF with the worker sent as the rig ID, used by nodejs-pool pools (SupportXMR, MoneroOcean)
+)
+
+// Placeholders expanded in wallet, rigId and workerName when a miner starts.
+const (
+	placeholderName  = "%name%" // The miner's instance name
+	placeholderIndex = "%n%"    // The lowest number not used by another running miner with the same template, from 1
+	placeholderHost  = "%host%" // This machine's hostname
+)
+
+var placeholderRegex = regexp.MustCompile(`%[a-zA-Z]+%`)
+
+// workerNameUnsafe matches characters pools don't accept in worker names;
+// '.' and '+' would be read as the worker and difficulty separators.
+var workerNameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// workerTemplate is the part of a config that is expanded per instance.
+type workerTemplate struct {
+	Wallet     string
+	RigID      string
+	WorkerName string
+}
+
+func templateOf(c *Config) workerTemplate {
+	return workerTemplate{Wallet: c.Wallet, RigID: c.RigID, WorkerName: c.WorkerName}
+}
+
+// key identifies miners started from the same template, for numbering them.
+func (t workerTemplate) key() string {
+	return t.Wallet + "|" + t.RigID + "|" + t.WorkerName
+}
+
+// restore puts the unexpanded template back into c.
+func (t workerTemplate) restore(c *Config) {
+	c.Wallet, c.RigID, c.WorkerName = t.Wallet, t.RigID, t.WorkerName
+}
+
+// usesIndex reports whether the template needs an instance number.
+func (t workerTemplate) usesIndex() bool {
+	return strings.Contains(t.key(), placeholderIndex)
+}
+
+// workerTemplateIssues checks the placeholders and worker settings of c.
+func (c *Config) workerTemplateIssues(add func(field, message string)) {
+	for _, f := range []struct{ field, value string }{{"wallet", c.Wallet}, {"rigId", c.RigID}, {"workerName", c.WorkerName}} {
+		for _, p := range placeholderRegex.FindAllString(f.value, -1) {
+			if p != placeholderName && p != placeholderIndex && p != placeholderHost {
+				add(f.field, fmt.Sprintf("unknown placeholder %s (use %s, %s or %s)", p, placeholderName, placeholderIndex, placeholderHost))
+			}
+		}
+	}
+	switch c.WorkerFormat {
+	case "", WorkerFormatDot, WorkerFormatRigID:
+	default:
+		add("workerFormat", fmt.Sprintf("worker format must be %q or %q", WorkerFormatDot, WorkerFormatRigID))
+	}
+	if c.FixedDiff < 0 {
+		add("fixedDiff", "fixed difficulty cannot be negative")
+	}
+	if (c.WorkerName != "" || c.FixedDiff > 0) && c.Wallet == "" {
+		add("wallet", "workerName and fixedDiff need a wallet to be appended to")
+	}
+}
+
+// applyWorkerTemplate expands the placeholders in c for one miner instance and
+// folds the worker name and fixed difficulty into the wallet (or rig ID) the
+// way the worker format's pools expect.
+func (c *Config) applyWorkerTemplate(instanceName string, index int) {
+	host, _ := os.Hostname()
+	replacer := strings.NewReplacer(
+		placeholderName, workerNameUnsafe.ReplaceAllString(instanceName, "_"),
+		placeholderIndex, strconv.Itoa(index),
+		placeholderHost, workerNameUnsafe.ReplaceAllString(host, "_"),
+	)
+	c.Wallet = replacer.Replace(c.Wallet)
+	c.RigID = replacer.Replace(c.RigID)
+	c.WorkerName = workerNameUnsafe.ReplaceAllString(replacer.Replace(c.WorkerName), "_")
+
+	if c.WorkerName != "" {
+		if c.WorkerFormat == WorkerFormatRigID {
+			c.RigID = c.WorkerName
+		} else {
+			c.Wallet += "." + c.WorkerName
+		}
+	}
+	if c.FixedDiff > 0 {
+		c.Wallet += "+" + strconv.Itoa(c.FixedDiff)
+	}
+}
+
+// nextWorkerIndex returns the lowest instance number, from 1, not used by a
+// running miner started from the same template. Caller must hold m.mu.
+func (m *Manager) nextWorkerIndex(tmpl workerTemplate) int {
+	used := make(map[int]bool)
+	for _, launch := range m.launches {
+		if launch.workerTemplate == tmpl.key() {
+			used[launch.WorkerIndex] = true
+		}
+	}
+	index := 1
+	for used[index] {
+		index++
+	}
+	return index
+}
@@ -0,0 +1,118 @@
+package mining
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestApplyWorkerTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		wantWallet string
+		wantRigID  string
+	}{
+		{"no template", Config{Wallet: "4abc"}, "4abc", ""},
+		{"dot worker", Config{Wallet: "4abc", WorkerName: "rig%n%"}, "4abc.rig2", ""},
+		{"dot worker and diff", Config{Wallet: "4abc", WorkerName: "%name%", FixedDiff: 50000}, "4abc.xmrig-rx_0+50000", ""},
+		{"rig id worker", Config{Wallet: "4abc", WorkerName: "%name%", WorkerFormat: WorkerFormatRigID, FixedDiff: 1000}, "4abc+1000", "xmrig-rx_0"},
+		{"placeholder in wallet", Config{Wallet: "4abc.w%n%", RigID: "%name%"}, "4abc.w2", "xmrig-rx_0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.applyWorkerTemplate("xmrig-rx/0", 2)
+			if config.Wallet != tt.wantWallet || config.RigID != tt.wantRigID {
+				t.Errorf("got wallet %q rig ID %q, want %q %q", config.Wallet, config.RigID, tt.wantWallet, tt.wantRigID)
+			}
+		})
+	}
+}
+
+func TestWorkerTemplateValidation(t *testing.T) {
+	invalid := map[string]Config{
+		"wallet":       {Wallet: "4abc.%worker%"},
+		"workerFormat": {Wallet: "4abc", WorkerFormat: "slash"},
+		"fixedDiff":    {Wallet: "4abc", FixedDiff: -1},
+	}
+	for field, config := range invalid {
+		issues := config.validationIssues()
+		if len(issues) == 0 || issues[0].Field != field {
+			t.Errorf("expected an issue for %s, got %+v", field, issues)
+		}
+	}
+	if err := (&Config{Wallet: "4abc", WorkerName: "%host%-%n%"}).Validate(); err != nil {
+		t.Errorf("expected a valid template, got %v", err)
+	}
+
+	errs, _ := (&Config{Wallet: "4abc", WorkerName: "rig", WorkerFormat: WorkerFormatRigID}).issuesFor(MinerTypeTTMiner)
+	found := false
+	for _, issue := range errs {
+		found = found || issue.Field == "workerFormat"
+	}
+	if !found {
+		t.Errorf("expected TT-Miner to reject the rig ID worker format, got %+v", errs)
+	}
+}
+
+func TestStartMinerWorkerNumbering(t *testing.T) {
+	m := &Manager{miners: map[string]Miner{}, launches: map[string]*MinerLaunchInfo{}, stopChan: make(chan struct{})}
+	start := func() Miner {
+		t.Helper()
+		miner, err := m.StartMiner(context.Background(), MinerTypeSimulated, &Config{Pool: "test:1234", Wallet: "4abc", WorkerName: "rig%n%"})
+		if err != nil {
+			t.Fatalf("StartMiner failed: %v", err)
+		}
+		return miner
+	}
+	wallet := func(miner Miner) string {
+		launch, err := m.GetMinerLaunchInfo(miner.GetName())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return launch.Config.Wallet
+	}
+
+	first, second := start(), start()
+	if wallet(first) != "4abc.rig1" || wallet(second) != "4abc.rig2" {
+		t.Fatalf("expected numbered workers, got %q and %q", wallet(first), wallet(second))
+	}
+
+	// A freed number is reused
+	if err := m.StopMiner(context.Background(), first.GetName()); err != nil {
+		t.Fatal(err)
+	}
+	third := start()
+	defer m.StopMiner(context.Background(), second.GetName())
+	defer m.StopMiner(context.Background(), third.GetName())
+	if wallet(third) != "4abc.rig1" {
+		t.Errorf("expected the freed worker number to be reused, got %q", wallet(third))
+	}
+
+	// The expanded wallet must still pass the wallet checks
+	long := &Config{Pool: "test:1234", Wallet: strings.Repeat("4", 250), WorkerName: "%name%"}
+	if _, err := m.StartMiner(context.Background(), MinerTypeSimulated, long); err == nil {
+		t.Error("expected a wallet too long after expansion to be rejected")
+	}
+	if long.Wallet != strings.Repeat("4", 250) || long.WorkerName != "%name%" {
+		t.Errorf("expected the template to be restored after a rejected start, got %q %q", long.Wallet, long.WorkerName)
+	}
+
+	// Also when a later check fails, or the start succeeds
+	badPort := &Config{Pool: "test:1234", Wallet: "4abc", WorkerName: "%name%", HTTPPort: 80}
+	if _, err := m.StartMiner(context.Background(), MinerTypeSimulated, badPort); err == nil {
+		t.Error("expected a privileged HTTP port to be rejected")
+	}
+	started := &Config{Pool: "test:1234", Wallet: "4abc", WorkerName: "%name%", Algo: "rx/wow"}
+	miner, err := m.StartMiner(context.Background(), MinerTypeSimulated, started)
+	if err != nil {
+		t.Fatalf("StartMiner failed: %v", err)
+	}
+	defer m.StopMiner(context.Background(), miner.GetName())
+	for _, cfg := range []*Config{badPort, started} {
+		if cfg.Wallet != "4abc" || cfg.WorkerName != "%name%" {
+			t.Errorf("expected the template to be restored, got %q %q", cfg.Wallet, cfg.WorkerName)
+		}
+	}
+}
@@ -4,48 +4,22 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
-	"sync"
 	"time"
 
+	"github.com/Snider/Mining/pkg/logging"
 	"github.com/adrg/xdg"
 )
 
 // XMRigMiner represents an XMRig miner, embedding the BaseMiner for common functionality.
 type XMRigMiner struct {
 	BaseMiner
-	FullStats *XMRigSummary `json:"-"` // Excluded from JSON to prevent race during marshaling
-}
-
-var (
-	httpClient = &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
-	}
-	httpClientMu sync.RWMutex
-)
-
-// getHTTPClient returns the HTTP client with proper synchronization
-func getHTTPClient() *http.Client {
-	httpClientMu.RLock()
-	defer httpClientMu.RUnlock()
-	return httpClient
-}
-
-// setHTTPClient sets the HTTP client (for testing)
-func setHTTPClient(client *http.Client) {
-	httpClientMu.Lock()
-	defer httpClientMu.Unlock()
-	httpClient = client
+	FullStats    *XMRigSummary `json:"-"` // Excluded from JSON to prevent race during marshaling
+	statsBaseURL string        // Overrides the API host and port for stats, see SetStatsBaseURL
 }
 
 // MinerTypeXMRig is the type identifier for XMRig miners.
@@ -127,11 +101,16 @@ func (m *XMRigMiner) Install() error {
 	}
 
 	// After installation, verify it.
-	_, err = m.CheckInstallation()
+	details, err := m.CheckInstallation()
 	if err != nil {
 		return fmt.Errorf("failed to verify installation after extraction: %w", err)
 	}
 
+	// Record the binary's checksum so later starts can detect tampering
+	if err := RecordBinaryHash(details.MinerBinary); err != nil {
+		logging.Warn("failed to record miner binary checksum", logging.Fields{"binary": details.MinerBinary, "error": err})
+	}
+
 	return nil
 }
 
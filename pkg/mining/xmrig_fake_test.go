@@ -0,0 +1,164 @@
+package mining
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeXMRigAPI serves XMRig's /2/summary from a canned response so GetStats
+// can be tested without a running miner. Fixtures live in testdata/xmrig.
+type fakeXMRigAPI struct {
+	*httptest.Server
+	mu     sync.Mutex
+	status int
+	body   []byte
+	delay  time.Duration
+}
+
+func newFakeXMRigAPI(t *testing.T) *fakeXMRigAPI {
+	t.Helper()
+	f := &fakeXMRigAPI{status: http.StatusOK, body: []byte(`{}`)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		status, body, delay := f.status, f.body, f.delay
+		f.mu.Unlock()
+
+		if r.URL.Path != "/2/summary" {
+			http.NotFound(w, r)
+			return
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// serveFixture answers with testdata/xmrig/<name>.json.
+func (f *fakeXMRigAPI) serveFixture(t *testing.T, name string) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "xmrig", name+".json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	f.respond(http.StatusOK, string(body))
+}
+
+// respond answers with the given status and body.
+func (f *fakeXMRigAPI) respond(status int, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status, f.body = status, []byte(body)
+}
+
+// newMiner returns a running XMRig miner whose stats come from the fake.
+func (f *fakeXMRigAPI) newMiner() *XMRigMiner {
+	miner := NewXMRigMiner()
+	miner.Running = true
+	miner.SetStatsBaseURL(f.URL)
+	return miner
+}
+
+func TestXMRigMiner_GetStats_Fixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    PerformanceMetrics
+	}{
+		{"summary_rx0", PerformanceMetrics{
+			Hashrate: 4213, Shares: 57, Rejected: 1, Uptime: 7260, Algorithm: "rx/0",
+			AvgDifficulty: 240149, DiffCurrent: 240123,
+			HugePagesEnabled: true, HugePagesAllocated: 1168, HugePagesTotal: 1168,
+		}},
+		{"summary_ghostrider", PerformanceMetrics{
+			Hashrate: 1532, Shares: 3, Rejected: 0, Uptime: 45, Algorithm: "gr",
+			AvgDifficulty: 1500, DiffCurrent: 1500,
+			HugePagesEnabled: false, HugePagesAllocated: 0, HugePagesTotal: 1168,
+		}},
+		{"summary_kawpow", PerformanceMetrics{
+			Hashrate: 18432112, Shares: 412, Rejected: 3, Uptime: 86400, Algorithm: "kawpow",
+			AvgDifficulty: 3864526291, DiffCurrent: 2147483647,
+		}},
+		// Just started: hashrate totals are null until XMRig has a full window
+		{"summary_starting", PerformanceMetrics{Uptime: 3, Algorithm: "rx/0"}},
+	}
+
+	fake := newFakeXMRigAPI(t)
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			fake.serveFixture(t, tt.fixture)
+			miner := fake.newMiner()
+
+			stats, err := miner.GetStats(context.Background())
+			if err != nil {
+				t.Fatalf("GetStats() returned an error: %v", err)
+			}
+			if !reflect.DeepEqual(*stats, tt.want) {
+				t.Errorf("unexpected stats\n got: %+v\nwant: %+v", *stats, tt.want)
+			}
+			if miner.FullStats == nil || miner.FullStats.Algo != tt.want.Algorithm {
+				t.Errorf("full summary not stored: %+v", miner.FullStats)
+			}
+		})
+	}
+}
+
+func TestXMRigMiner_GetStats_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"status":401,"error":"Unauthorized"}`, "unexpected status code 401"},
+		{"server error", http.StatusInternalServerError, `{"status":500}`, "unexpected status code 500"},
+		{"malformed JSON", http.StatusOK, `{"uptime": "soon"}`, "failed to decode response"},
+		{"truncated body", http.StatusOK, `{"uptime": 12, "hashrate": {"total": [1`, "failed to decode response"},
+	}
+
+	fake := newFakeXMRigAPI(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.respond(tt.status, tt.body)
+			_, err := fake.newMiner().GetStats(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		fake.serveFixture(t, "summary_rx0")
+		fake.mu.Lock()
+		fake.delay = time.Second
+		fake.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := fake.newMiner().GetStats(ctx); err == nil {
+			t.Fatal("Expected a timeout error")
+		}
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		miner := fake.newMiner()
+		miner.Running = false
+		if _, err := miner.GetStats(context.Background()); err == nil {
+			t.Fatal("Expected an error for a stopped miner")
+		}
+	})
+}
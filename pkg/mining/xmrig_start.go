@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -27,6 +26,11 @@ func (m *XMRigMiner) Start(config *Config) error {
 		}
 	}
 
+	// Refuse (or warn about) a binary that changed since install
+	if err := m.VerifyBinary(); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -75,19 +79,15 @@ func (m *XMRigMiner) Start(config *Config) error {
 	}
 	m.stdinPipe = stdinPipe
 
-	// Always capture output to LogBuffer
-	if m.LogBuffer != nil {
-		m.cmd.Stdout = m.LogBuffer
-		m.cmd.Stderr = m.LogBuffer
-	}
-	// Also output to console if requested
-	if config.LogOutput {
-		m.cmd.Stdout = io.MultiWriter(m.LogBuffer, os.Stdout)
-		m.cmd.Stderr = io.MultiWriter(m.LogBuffer, os.Stderr)
-	}
+	// Persist output to a rotating log file if enabled
+	m.openLogFile()
+
+	// Always capture output to LogBuffer, and to the console if requested
+	m.attachOutput(config.LogOutput)
 
-	if err := m.cmd.Start(); err != nil {
+	if err := m.startProcess(config); err != nil {
 		stdinPipe.Close()
+		m.closeLogFile()
 		// Clean up config file on failed start
 		if m.ConfigPath != "" {
 			os.Remove(m.ConfigPath)
@@ -141,9 +141,14 @@ func (m *XMRigMiner) Start(config *Config) error {
 		m.mu.Lock()
 		// Only clear if this is still the same command (not restarted)
 		if m.cmd == cmd {
+			m.recordUnexpectedExit(cmd, waitErr)
 			m.Running = false
 			m.cmd = nil
 		}
+		// Close the log file unless a restart already attached a new one
+		if m.cmd == nil {
+			m.closeLogFile()
+		}
 		m.mu.Unlock()
 	}()
 
@@ -182,7 +187,7 @@ func addCliArgs(config *Config, args *[]string) {
 	if config.TLS {
 		*args = append(*args, "--tls")
 	}
-	*args = append(*args, "--donate-level", "1")
+	*args = append(*args, "--donate-level", fmt.Sprintf("%d", effectiveDonateLevel(config.DonateLevel)))
 }
 
 // createConfig creates a JSON configuration file for the XMRig miner.
@@ -227,6 +232,9 @@ func (m *XMRigMiner) createConfig(config *Config) error {
 		"keepalive": true,
 		"tls":       config.TLS,
 	}
+	if config.RigID != "" {
+		cpuPool["rig-id"] = config.RigID
+	}
 	// Add algo or coin (coin takes precedence for algorithm auto-detection)
 	if config.Coin != "" {
 		cpuPool["coin"] = config.Coin
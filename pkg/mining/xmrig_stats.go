@@ -9,22 +9,30 @@ import (
 // statsTimeout is the timeout for stats HTTP requests (shorter than general timeout)
 const statsTimeout = 5 * time.Second
 
+// SetStatsBaseURL makes GetStats query the XMRig API at baseURL, e.g.
+// "http://127.0.0.1:41234", instead of the configured API host and port.
+// An empty baseURL restores the default.
+func (m *XMRigMiner) SetStatsBaseURL(baseURL string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statsBaseURL = baseURL
+}
+
 // GetStats retrieves the performance statistics from the running XMRig miner.
 func (m *XMRigMiner) GetStats(ctx context.Context) (*PerformanceMetrics, error) {
 	// Read state under RLock, then release before HTTP call
 	m.mu.RLock()
 	if !m.Running {
 		m.mu.RUnlock()
-		return nil, errors.New("miner is not running")
-	}
-	if m.API == nil || m.API.ListenPort == 0 {
-		m.mu.RUnlock()
-		return nil, errors.New("miner API not configured or port is zero")
+		return nil, ErrProcessNotRunning
 	}
-	config := HTTPStatsConfig{
-		Host:     m.API.ListenHost,
-		Port:     m.API.ListenPort,
-		Endpoint: "/2/summary",
+	config := HTTPStatsConfig{Endpoint: "/2/summary", BaseURL: m.statsBaseURL}
+	if config.BaseURL == "" {
+		if m.API == nil || m.API.ListenPort == 0 {
+			m.mu.RUnlock()
+			return nil, errors.New("miner API not configured or port is zero")
+		}
+		config.Host, config.Port = m.API.ListenHost, m.API.ListenPort
 	}
 	m.mu.RUnlock()
 
@@ -54,7 +62,7 @@ func (m *XMRigMiner) GetStats(ctx context.Context) (*PerformanceMetrics, error)
 		avgDifficulty = summary.Results.HashesTotal / summary.Results.SharesGood
 	}
 
-	return &PerformanceMetrics{
+	metrics := &PerformanceMetrics{
 		Hashrate:      hashrate,
 		Shares:        summary.Results.SharesGood,
 		Rejected:      summary.Results.SharesTotal - summary.Results.SharesGood,
@@ -62,5 +70,14 @@ func (m *XMRigMiner) GetStats(ctx context.Context) (*PerformanceMetrics, error)
 		Algorithm:     summary.Algo,
 		AvgDifficulty: avgDifficulty,
 		DiffCurrent:   summary.Results.DiffCurrent,
-	}, nil
+	}
+
+	// XMRig reports huge pages as [allocated, total]
+	if len(summary.Hugepages) >= 2 {
+		metrics.HugePagesAllocated = summary.Hugepages[0]
+		metrics.HugePagesTotal = summary.Hugepages[1]
+		metrics.HugePagesEnabled = summary.Hugepages[0] > 0
+	}
+
+	return metrics, nil
 }
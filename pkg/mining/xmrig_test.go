@@ -179,8 +179,9 @@ func TestXMRigMiner_GetStats_Good(t *testing.T) {
 				HashesTotal int   `json:"hashes_total"`
 				Best        []int `json:"best"`
 			}{SharesGood: 10, SharesTotal: 12},
-			Uptime: 600,
-			Algo:   "rx/0",
+			Uptime:    600,
+			Algo:      "rx/0",
+			Hugepages: []int{0, 1168},
 		}
 		json.NewEncoder(w).Encode(summary)
 	}))
@@ -217,6 +218,9 @@ func TestXMRigMiner_GetStats_Good(t *testing.T) {
 	if stats.Algorithm != "rx/0" {
 		t.Errorf("Expected algorithm 'rx/0', got '%s'", stats.Algorithm)
 	}
+	if stats.HugePagesEnabled || stats.HugePagesAllocated != 0 || stats.HugePagesTotal != 1168 {
+		t.Errorf("Unexpected huge pages stats: enabled=%v %d/%d", stats.HugePagesEnabled, stats.HugePagesAllocated, stats.HugePagesTotal)
+	}
 }
 
 func TestXMRigMiner_GetStats_Bad(t *testing.T) {
@@ -2,8 +2,12 @@ package node
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,8 +23,22 @@ type Controller struct {
 
 	// Pending requests awaiting responses
 	pending map[string]chan *Message // message ID -> response channel
+
+	// Progress callbacks for in-flight provisioning requests
+	progress map[string]func(*Message) // message ID -> progress handler
+
+	// Commands for offline peers, delivered on reconnect (nil disables queuing)
+	outbox *Outbox
+
+	// Stats pushed by workers with CapabilityStatsPush
+	statsMu      sync.RWMutex
+	pushed       map[string]*pushedStats // peer ID -> latest stats
+	pushInterval time.Duration           // Requested from workers on connect; 0 disables
 }
 
+// remoteInstallTimeout bounds a remote install or update, which includes a download.
+const remoteInstallTimeout = 10 * time.Minute
+
 // NewController creates a new Controller instance.
 func NewController(node *NodeManager, peers *PeerRegistry, transport *Transport) *Controller {
 	c := &Controller{
@@ -28,20 +46,39 @@ func NewController(node *NodeManager, peers *PeerRegistry, transport *Transport)
 		peers:     peers,
 		transport: transport,
 		pending:   make(map[string]chan *Message),
+		progress:  make(map[string]func(*Message)),
+		pushed:    make(map[string]*pushedStats),
 	}
 
 	// Register message handler for responses
-	transport.OnMessage(c.handleResponse)
+	transport.OnMessage(c.HandleResponse)
 
 	return c
 }
 
-// handleResponse processes incoming messages that are responses to our requests.
-func (c *Controller) handleResponse(conn *PeerConnection, msg *Message) {
+// HandleResponse processes incoming messages that are responses to our requests.
+// Nodes acting as both controller and worker route replies here and requests
+// to Worker.HandleMessage.
+func (c *Controller) HandleResponse(conn *PeerConnection, msg *Message) {
+	if msg.Type == MsgStats && msg.ReplyTo == "" {
+		c.handlePushedStats(conn, msg)
+		return
+	}
 	if msg.ReplyTo == "" {
 		return // Not a response, let worker handle it
 	}
 
+	// Progress updates and log chunks precede the final response and don't complete the request
+	if msg.Type == MsgInstallProgress || msg.Type == MsgLogsChunk {
+		c.mu.RLock()
+		handler := c.progress[msg.ReplyTo]
+		c.mu.RUnlock()
+		if handler != nil {
+			handler(msg)
+		}
+		return
+	}
+
 	c.mu.Lock()
 	ch, exists := c.pending[msg.ReplyTo]
 	if exists {
@@ -58,25 +95,143 @@ func (c *Controller) handleResponse(conn *PeerConnection, msg *Message) {
 	}
 }
 
-// sendRequest sends a message and waits for a response.
-func (c *Controller) sendRequest(peerID string, msg *Message, timeout time.Duration) (*Message, error) {
-	actualPeerID := peerID
+// SetOutbox enables queuing of start/stop commands for offline peers. Queued
+// commands are delivered when the peer next connects.
+func (c *Controller) SetOutbox(outbox *Outbox) {
+	c.mu.Lock()
+	c.outbox = outbox
+	c.mu.Unlock()
+
+	c.transport.OnConnect(c.deliverQueued)
+}
+
+// Outbox returns the command outbox, or nil if queuing is disabled.
+func (c *Controller) Outbox() *Outbox {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.outbox
+}
+
+// sendOrQueue sends a control command, queuing it in the outbox instead of
+// failing if the peer is offline. A queued command returns *CommandQueuedError.
+func (c *Controller) sendOrQueue(peerID string, msg *Message, timeout time.Duration) (*Message, error) {
+	resp, err := c.sendRequest(peerID, msg, timeout)
+	var notConnected *PeerNotConnectedError
+	outbox := c.Outbox()
+	if err == nil || outbox == nil || !errors.As(err, &notConnected) {
+		return resp, err
+	}
+
+	cmd, queueErr := outbox.Enqueue(peerID, msg)
+	if queueErr != nil {
+		logging.Warn("failed to queue command for offline peer", logging.Fields{"peer_id": peerID, "type": msg.Type, "error": queueErr})
+		return nil, err
+	}
+	logging.Info("queued command for offline peer", logging.Fields{"peer_id": peerID, "type": msg.Type, "expires": cmd.ExpiresAt})
+	return nil, &CommandQueuedError{Command: *cmd}
+}
+
+// deliverQueued sends a reconnected peer's queued commands in order. A command
+// is removed once the peer replies; if delivery fails the rest stay queued
+// for the next connection.
+func (c *Controller) deliverQueued(peerID string) {
+	outbox := c.Outbox()
+	if outbox == nil || !outbox.beginDelivery(peerID) {
+		return
+	}
+	defer outbox.endDelivery(peerID)
 
-	// Auto-connect if not already connected
-	if c.transport.GetConnection(peerID) == nil {
-		peer := c.peers.GetPeer(peerID)
-		if peer == nil {
-			return nil, fmt.Errorf("peer not found: %s", peerID)
+	identity := c.node.GetIdentity()
+	if identity == nil {
+		return
+	}
+
+	for _, cmd := range outbox.Pending(peerID) {
+		// Reuse the queued message ID so the worker drops a duplicate redelivery
+		msg := &Message{
+			ID:        cmd.ID,
+			Type:      cmd.Type,
+			From:      identity.ID,
+			To:        peerID,
+			Timestamp: time.Now(),
+			Payload:   cmd.Payload,
 		}
-		conn, err := c.transport.Connect(peer)
+		resp, err := c.sendRequest(peerID, msg, 30*time.Second)
 		if err != nil {
-			return nil, fmt.Errorf("failed to connect to peer: %w", err)
+			logging.Warn("failed to deliver queued command", logging.Fields{"peer_id": peerID, "type": cmd.Type, "error": err})
+			return
+		}
+
+		var ack MinerAckPayload
+		if err := ParseResponse(resp, MsgMinerAck, &ack); err != nil || !ack.Success {
+			logging.Warn("queued command rejected by peer", logging.Fields{"peer_id": peerID, "type": cmd.Type, "error": ack.Error})
+		} else {
+			logging.Info("delivered queued command", logging.Fields{"peer_id": peerID, "type": cmd.Type})
+		}
+		if err := outbox.Remove(peerID, cmd.ID); err != nil {
+			logging.Warn("failed to remove delivered command from outbox", logging.Fields{"peer_id": peerID, "error": err})
 		}
-		// Use the real peer ID after handshake (it may have changed)
-		actualPeerID = conn.Peer.ID
-		// Update the message destination
-		msg.To = actualPeerID
 	}
+}
+
+// PeerNotConnectedError is returned when a request cannot be sent because the
+// peer is not connected and an automatic connection attempt failed.
+type PeerNotConnectedError struct {
+	PeerID   string
+	LastSeen time.Time // Zero if the peer has never been seen
+	Cause    error
+}
+
+func (e *PeerNotConnectedError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("peer not connected: %s: %v", e.PeerID, e.Cause)
+	}
+	return fmt.Sprintf("peer not connected: %s", e.PeerID)
+}
+
+// Unwrap returns the underlying connection error
+func (e *PeerNotConnectedError) Unwrap() error {
+	return e.Cause
+}
+
+// Is makes errors.Is(err, ErrPeerNotConnected) hold for a PeerNotConnectedError.
+func (e *PeerNotConnectedError) Is(target error) bool {
+	t, ok := target.(*NodeError)
+	return ok && t.Code == CodePeerNotConnected
+}
+
+// ensureConnected is the precondition for every request to a peer. If the peer
+// is not connected it attempts to connect, returning a *PeerNotConnectedError if
+// that fails. Returns the peer ID to address, which may change after handshake.
+func (c *Controller) ensureConnected(peerID string) (string, error) {
+	if c.transport.GetConnection(peerID) != nil {
+		return peerID, nil
+	}
+
+	peer := c.peers.GetPeer(peerID)
+	if peer == nil {
+		return "", errPeerNotFound(peerID)
+	}
+
+	conn, err := c.transport.Connect(peer)
+	if err != nil {
+		return "", &PeerNotConnectedError{
+			PeerID:   peerID,
+			LastSeen: peer.LastSeen,
+			Cause:    err,
+		}
+	}
+	return conn.Peer.ID, nil
+}
+
+// sendRequest sends a message and waits for a response.
+func (c *Controller) sendRequest(peerID string, msg *Message, timeout time.Duration) (*Message, error) {
+	actualPeerID, err := c.ensureConnected(peerID)
+	if err != nil {
+		return nil, err
+	}
+	// Use the real peer ID after handshake (it may have changed)
+	msg.To = actualPeerID
 
 	// Create response channel
 	respCh := make(chan *Message, 1)
@@ -95,7 +250,7 @@ func (c *Controller) sendRequest(peerID string, msg *Message, timeout time.Durat
 
 	// Send the message
 	if err := c.transport.Send(actualPeerID, msg); err != nil {
-		return nil, fmt.Errorf("failed to send message: %w", err)
+		return nil, newNodeError(CodeSendFailed, actualPeerID, "failed to send message", err)
 	}
 
 	// Wait for response
@@ -106,7 +261,7 @@ func (c *Controller) sendRequest(peerID string, msg *Message, timeout time.Durat
 	case resp := <-respCh:
 		return resp, nil
 	case <-ctx.Done():
-		return nil, fmt.Errorf("request timeout")
+		return nil, newNodeError(CodeTimeout, actualPeerID, "request timeout", nil)
 	}
 }
 
@@ -114,7 +269,7 @@ func (c *Controller) sendRequest(peerID string, msg *Message, timeout time.Durat
 func (c *Controller) GetRemoteStats(peerID string) (*StatsPayload, error) {
 	identity := c.node.GetIdentity()
 	if identity == nil {
-		return nil, fmt.Errorf("node identity not initialized")
+		return nil, ErrIdentityNotInitialized
 	}
 
 	msg, err := NewMessage(MsgGetStats, identity.ID, peerID, nil)
@@ -136,10 +291,12 @@ func (c *Controller) GetRemoteStats(peerID string) (*StatsPayload, error) {
 }
 
 // StartRemoteMiner requests a remote peer to start a miner with a given profile.
+// If the peer is offline and an outbox is set, the command is queued and a
+// *CommandQueuedError is returned.
 func (c *Controller) StartRemoteMiner(peerID, minerType, profileID string, configOverride json.RawMessage) error {
 	identity := c.node.GetIdentity()
 	if identity == nil {
-		return fmt.Errorf("node identity not initialized")
+		return ErrIdentityNotInitialized
 	}
 
 	if minerType == "" {
@@ -157,7 +314,7 @@ func (c *Controller) StartRemoteMiner(peerID, minerType, profileID string, confi
 		return fmt.Errorf("failed to create message: %w", err)
 	}
 
-	resp, err := c.sendRequest(peerID, msg, 30*time.Second)
+	resp, err := c.sendOrQueue(peerID, msg, 30*time.Second)
 	if err != nil {
 		return err
 	}
@@ -174,11 +331,12 @@ func (c *Controller) StartRemoteMiner(peerID, minerType, profileID string, confi
 	return nil
 }
 
-// StopRemoteMiner requests a remote peer to stop a miner.
+// StopRemoteMiner requests a remote peer to stop a miner. Like StartRemoteMiner,
+// it queues the command if the peer is offline and an outbox is set.
 func (c *Controller) StopRemoteMiner(peerID, minerName string) error {
 	identity := c.node.GetIdentity()
 	if identity == nil {
-		return fmt.Errorf("node identity not initialized")
+		return ErrIdentityNotInitialized
 	}
 
 	payload := StopMinerPayload{
@@ -190,7 +348,7 @@ func (c *Controller) StopRemoteMiner(peerID, minerName string) error {
 		return fmt.Errorf("failed to create message: %w", err)
 	}
 
-	resp, err := c.sendRequest(peerID, msg, 30*time.Second)
+	resp, err := c.sendOrQueue(peerID, msg, 30*time.Second)
 	if err != nil {
 		return err
 	}
@@ -207,19 +365,144 @@ func (c *Controller) StopRemoteMiner(peerID, minerName string) error {
 	return nil
 }
 
-// GetRemoteLogs requests console logs from a remote miner.
+// DeployProfile sends a mining profile to a remote peer, encrypted with the
+// connection's shared secret. The worker stores it under the profile's ID so
+// it can later be used with StartRemoteMiner.
+func (c *Controller) DeployProfile(peerID string, profileJSON []byte, name string) error {
+	identity := c.node.GetIdentity()
+	if identity == nil {
+		return ErrIdentityNotInitialized
+	}
+
+	actualPeerID, err := c.ensureConnected(peerID)
+	if err != nil {
+		return err
+	}
+	conn := c.transport.GetConnection(actualPeerID)
+	if conn == nil {
+		return errPeerNotConnected(peerID)
+	}
+
+	bundle, err := CreateProfileBundle(profileJSON, name, base64.StdEncoding.EncodeToString(conn.SharedSecret))
+	if err != nil {
+		return fmt.Errorf("failed to create profile bundle: %w", err)
+	}
+
+	payload := DeployPayload{
+		BundleType: string(bundle.Type),
+		Data:       bundle.Data,
+		Checksum:   bundle.Checksum,
+		Name:       bundle.Name,
+	}
+
+	msg, err := NewMessage(MsgDeploy, identity.ID, actualPeerID, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+
+	resp, err := c.sendRequest(actualPeerID, msg, 30*time.Second)
+	if err != nil {
+		return err
+	}
+
+	var ack DeployAckPayload
+	if err := ParseResponse(resp, MsgDeployAck, &ack); err != nil {
+		return err
+	}
+
+	if !ack.Success {
+		return fmt.Errorf("profile deploy failed: %s", ack.Error)
+	}
+
+	return nil
+}
+
+// GetRemoteLogs requests the last lines of a remote miner's console logs.
 func (c *Controller) GetRemoteLogs(peerID, minerName string, lines int) ([]string, error) {
+	logs, err := c.GetRemoteLogsPage(peerID, GetLogsPayload{MinerName: minerName, Lines: lines})
+	if err != nil {
+		return nil, err
+	}
+	return logs.Lines, nil
+}
+
+// InstallRemoteMiner asks a remote peer to install a miner type, calling
+// progress (if non-nil) for each stage the worker reports. Returns the
+// installed version.
+func (c *Controller) InstallRemoteMiner(peerID, minerType string, progress func(InstallProgressPayload)) (string, error) {
+	return c.provisionRemoteMiner(MsgInstallMiner, peerID, minerType, progress)
+}
+
+// UpdateRemoteMiner asks a remote peer to update a miner type to the latest
+// version. Returns the version installed after the update.
+func (c *Controller) UpdateRemoteMiner(peerID, minerType string, progress func(InstallProgressPayload)) (string, error) {
+	return c.provisionRemoteMiner(MsgUpdateMiner, peerID, minerType, progress)
+}
+
+// UninstallRemoteMiner asks a remote peer to uninstall a miner type.
+func (c *Controller) UninstallRemoteMiner(peerID, minerType string) error {
+	_, err := c.provisionRemoteMiner(MsgUninstallMiner, peerID, minerType, nil)
+	return err
+}
+
+// provisionRemoteMiner sends an install/update/uninstall request and waits for its ack.
+func (c *Controller) provisionRemoteMiner(msgType MessageType, peerID, minerType string, progress func(InstallProgressPayload)) (string, error) {
 	identity := c.node.GetIdentity()
 	if identity == nil {
-		return nil, fmt.Errorf("node identity not initialized")
+		return "", ErrIdentityNotInitialized
 	}
 
-	payload := GetLogsPayload{
-		MinerName: minerName,
-		Lines:     lines,
+	if minerType == "" {
+		return "", fmt.Errorf("miner type is required")
+	}
+
+	msg, err := NewMessage(msgType, identity.ID, peerID, InstallMinerPayload{MinerType: minerType})
+	if err != nil {
+		return "", fmt.Errorf("failed to create message: %w", err)
+	}
+
+	if progress != nil {
+		c.mu.Lock()
+		c.progress[msg.ID] = func(update *Message) {
+			var payload InstallProgressPayload
+			if err := update.ParsePayload(&payload); err == nil {
+				progress(payload)
+			}
+		}
+		c.mu.Unlock()
+		defer func() {
+			c.mu.Lock()
+			delete(c.progress, msg.ID)
+			c.mu.Unlock()
+		}()
+	}
+
+	resp, err := c.sendRequest(peerID, msg, remoteInstallTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	var ack InstallAckPayload
+	if err := ParseResponse(resp, MsgInstallAck, &ack); err != nil {
+		return "", err
+	}
+
+	if !ack.Success {
+		return "", fmt.Errorf("miner %s failed: %s", strings.TrimSuffix(string(msgType), "_miner"), ack.Error)
+	}
+
+	return ack.Version, nil
+}
+
+// ListRemoteProfiles requests the profiles stored on a remote peer. Secrets
+// in the returned profiles are masked by the worker.
+func (c *Controller) ListRemoteProfiles(peerID string) ([]json.RawMessage, error) {
+	identity := c.node.GetIdentity()
+	if identity == nil {
+		return nil, ErrIdentityNotInitialized
 	}
 
-	msg, err := NewMessage(MsgGetLogs, identity.ID, peerID, payload)
+	msg, err := NewMessage(MsgListProfiles, identity.ID, peerID, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create message: %w", err)
 	}
@@ -229,15 +512,41 @@ func (c *Controller) GetRemoteLogs(peerID, minerName string, lines int) ([]strin
 		return nil, err
 	}
 
-	var logs LogsPayload
-	if err := ParseResponse(resp, MsgLogs, &logs); err != nil {
+	var profiles ProfilesPayload
+	if err := ParseResponse(resp, MsgProfiles, &profiles); err != nil {
 		return nil, err
 	}
 
-	return logs.Lines, nil
+	return profiles.Profiles, nil
+}
+
+// GetRemoteProfile requests a single profile from a remote peer, with secrets masked.
+func (c *Controller) GetRemoteProfile(peerID, profileID string) (json.RawMessage, error) {
+	identity := c.node.GetIdentity()
+	if identity == nil {
+		return nil, ErrIdentityNotInitialized
+	}
+
+	msg, err := NewMessage(MsgGetProfile, identity.ID, peerID, GetProfilePayload{ProfileID: profileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	resp, err := c.sendRequest(peerID, msg, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile ProfilePayload
+	if err := ParseResponse(resp, MsgProfile, &profile); err != nil {
+		return nil, err
+	}
+
+	return profile.Profile, nil
 }
 
-// GetAllStats fetches stats from all connected peers.
+// GetAllStats returns stats from all connected peers. Peers pushing stats
+// are answered from the cache; the rest are polled.
 func (c *Controller) GetAllStats() map[string]*StatsPayload {
 	peers := c.peers.GetConnectedPeers()
 	results := make(map[string]*StatsPayload)
@@ -245,6 +554,12 @@ func (c *Controller) GetAllStats() map[string]*StatsPayload {
 	var wg sync.WaitGroup
 
 	for _, peer := range peers {
+		if stats, ok := c.CachedStats(peer.ID); ok {
+			mu.Lock()
+			results[peer.ID] = stats
+			mu.Unlock()
+			continue
+		}
 		wg.Add(1)
 		go func(p *Peer) {
 			defer wg.Done()
@@ -267,11 +582,63 @@ func (c *Controller) GetAllStats() map[string]*StatsPayload {
 	return results
 }
 
+// GroupResult is the outcome of a group operation on one peer.
+type GroupResult struct {
+	PeerID   string `json:"peerId"`
+	PeerName string `json:"peerName"`
+	Queued   bool   `json:"queued,omitempty"` // Peer was offline; the command is in the outbox
+	Error    string `json:"error,omitempty"`
+}
+
+// StartMinerOnTag starts a miner on every peer tagged tag. Peers are
+// commanded concurrently; offline peers are queued when an outbox is set.
+func (c *Controller) StartMinerOnTag(tag, minerType, profileID string, configOverride json.RawMessage) []GroupResult {
+	return c.forEachTagged(tag, func(peerID string) error {
+		return c.StartRemoteMiner(peerID, minerType, profileID, configOverride)
+	})
+}
+
+// StopMinerOnTag stops a miner on every peer tagged tag.
+func (c *Controller) StopMinerOnTag(tag, minerName string) []GroupResult {
+	return c.forEachTagged(tag, func(peerID string) error {
+		return c.StopRemoteMiner(peerID, minerName)
+	})
+}
+
+// forEachTagged runs op against every peer tagged tag concurrently and
+// returns the per-peer outcomes sorted by peer ID.
+func (c *Controller) forEachTagged(tag string, op func(peerID string) error) []GroupResult {
+	peers := c.peers.ListPeersByTag(tag)
+	results := make([]GroupResult, len(peers))
+	var wg sync.WaitGroup
+
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, p *Peer) {
+			defer wg.Done()
+			result := GroupResult{PeerID: p.ID, PeerName: p.Name}
+			if err := op(p.ID); err != nil {
+				var queued *CommandQueuedError
+				if errors.As(err, &queued) {
+					result.Queued = true
+				} else {
+					result.Error = err.Error()
+				}
+			}
+			results[i] = result
+		}(i, peer)
+	}
+
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].PeerID < results[j].PeerID })
+	return results
+}
+
 // PingPeer sends a ping to a peer and updates metrics.
 func (c *Controller) PingPeer(peerID string) (float64, error) {
 	identity := c.node.GetIdentity()
 	if identity == nil {
-		return 0, fmt.Errorf("node identity not initialized")
+		return 0, ErrIdentityNotInitialized
 	}
 	sentAt := time.Now()
 
@@ -309,7 +676,7 @@ func (c *Controller) PingPeer(peerID string) (float64, error) {
 func (c *Controller) ConnectToPeer(peerID string) error {
 	peer := c.peers.GetPeer(peerID)
 	if peer == nil {
-		return fmt.Errorf("peer not found: %s", peerID)
+		return errPeerNotFound(peerID)
 	}
 
 	_, err := c.transport.Connect(peer)
@@ -320,7 +687,7 @@ func (c *Controller) ConnectToPeer(peerID string) error {
 func (c *Controller) DisconnectFromPeer(peerID string) error {
 	conn := c.transport.GetConnection(peerID)
 	if conn == nil {
-		return fmt.Errorf("peer not connected: %s", peerID)
+		return errPeerNotConnected(peerID)
 	}
 
 	return conn.Close()
@@ -0,0 +1,82 @@
+package node
+
+import (
+	"fmt"
+)
+
+// NodeErrorCode identifies the kind of P2P failure behind a NodeError.
+type NodeErrorCode string
+
+// NodeError codes. They match the mining API's error codes where one exists.
+const (
+	CodePeerNotFound        NodeErrorCode = "PEER_NOT_FOUND"
+	CodePeerNotConnected    NodeErrorCode = "PEER_NOT_CONNECTED"
+	CodeConnectFailed       NodeErrorCode = "CONNECTION_FAILED"
+	CodeHandshakeFailed     NodeErrorCode = "HANDSHAKE_FAILED"
+	CodeSendFailed          NodeErrorCode = "SEND_FAILED"
+	CodeTimeout             NodeErrorCode = "TIMEOUT"
+	CodeTransportNotRunning NodeErrorCode = "TRANSPORT_NOT_STARTED"
+	CodeNodeNotInitialized  NodeErrorCode = "NODE_NOT_INITIALIZED"
+	CodeDuplicateMessage    NodeErrorCode = "DUPLICATE_MESSAGE"
+	CodeInvalidInput        NodeErrorCode = "INVALID_INPUT"
+	CodeIdentityExists      NodeErrorCode = "NODE_IDENTITY_EXISTS"
+)
+
+// NodeError is a P2P failure with a code callers can act on, in the same
+// spirit as mining.MiningError. Match it by code with errors.Is against the
+// sentinels below, or use errors.As to get the peer and cause.
+type NodeError struct {
+	Code    NodeErrorCode
+	Message string
+	PeerID  string // Empty if the error isn't about one peer
+	Cause   error
+}
+
+// Sentinels for errors.Is. Any NodeError with the same code matches, so
+// errors.Is(err, ErrHandshakeFailed) holds for every handshake failure.
+var (
+	ErrPeerNotFound           = &NodeError{Code: CodePeerNotFound, Message: "peer not found"}
+	ErrPeerNotConnected       = &NodeError{Code: CodePeerNotConnected, Message: "peer not connected"}
+	ErrConnectFailed          = &NodeError{Code: CodeConnectFailed, Message: "failed to connect to peer"}
+	ErrHandshakeFailed        = &NodeError{Code: CodeHandshakeFailed, Message: "handshake failed"}
+	ErrSendFailed             = &NodeError{Code: CodeSendFailed, Message: "failed to send message"}
+	ErrRequestTimeout         = &NodeError{Code: CodeTimeout, Message: "request timeout"}
+	ErrTransportNotRunning    = &NodeError{Code: CodeTransportNotRunning, Message: "transport is not running"}
+	ErrIdentityNotInitialized = &NodeError{Code: CodeNodeNotInitialized, Message: "node identity not initialized"}
+	ErrDuplicateMessage       = &NodeError{Code: CodeDuplicateMessage, Message: "duplicate message dropped"}
+	ErrInvalidIdentityExport  = &NodeError{Code: CodeInvalidInput, Message: "invalid identity export"}
+	ErrIdentityExists         = &NodeError{Code: CodeIdentityExists, Message: "node identity already exists"}
+)
+
+func (e *NodeError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *NodeError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a NodeError with the same code.
+func (e *NodeError) Is(target error) bool {
+	t, ok := target.(*NodeError)
+	return ok && t.Code == e.Code
+}
+
+// newNodeError creates a NodeError about peerID.
+func newNodeError(code NodeErrorCode, peerID, message string, cause error) *NodeError {
+	return &NodeError{Code: code, Message: message, PeerID: peerID, Cause: cause}
+}
+
+// errPeerNotFound creates the error for a peer missing from the registry.
+func errPeerNotFound(peerID string) *NodeError {
+	return newNodeError(CodePeerNotFound, peerID, fmt.Sprintf("peer %s not found", peerID), nil)
+}
+
+// errPeerNotConnected creates the error for a peer without a connection.
+func errPeerNotConnected(peerID string) *NodeError {
+	return newNodeError(CodePeerNotConnected, peerID, fmt.Sprintf("peer %s not connected", peerID), nil)
+}
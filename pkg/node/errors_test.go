@@ -0,0 +1,75 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNodeError_IsAndAs(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	err := fmt.Errorf("get stats: %w", newNodeError(CodeConnectFailed, "abc", "failed to connect to peer", cause))
+
+	if !errors.Is(err, ErrConnectFailed) {
+		t.Error("expected errors.Is to match the sentinel with the same code")
+	}
+	if errors.Is(err, ErrHandshakeFailed) {
+		t.Error("expected errors.Is not to match a different code")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected the cause to be reachable")
+	}
+
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.PeerID != "abc" || nodeErr.Code != CodeConnectFailed {
+		t.Errorf("expected errors.As to find the NodeError, got %+v", nodeErr)
+	}
+	if got := nodeErr.Error(); got != "failed to connect to peer: dial tcp: connection refused" {
+		t.Errorf("unexpected message %q", got)
+	}
+
+	// A send failure keeps the reason it failed
+	sendErr := newNodeError(CodeSendFailed, "abc", "failed to send message", errPeerNotConnected("abc"))
+	if !errors.Is(sendErr, ErrSendFailed) || !errors.Is(sendErr, ErrPeerNotConnected) {
+		t.Error("expected a send failure to match both codes")
+	}
+}
+
+func TestPeerNotConnectedError_Is(t *testing.T) {
+	err := &PeerNotConnectedError{PeerID: "abc", Cause: newNodeError(CodeHandshakeFailed, "abc", "handshake failed", nil)}
+	if !errors.Is(err, ErrPeerNotConnected) || !errors.Is(err, ErrHandshakeFailed) {
+		t.Error("expected the peer not connected code and the handshake cause to match")
+	}
+}
+
+func TestTransport_CheckDuplicate(t *testing.T) {
+	transport := &Transport{dedup: NewMessageDeduplicator(time.Minute, 10)}
+	msg := &Message{ID: "msg-1"}
+
+	if err := transport.checkDuplicate("abc", msg); err != nil {
+		t.Fatalf("expected the first delivery to pass, got %v", err)
+	}
+	err := transport.checkDuplicate("abc", msg)
+	if !errors.Is(err, ErrDuplicateMessage) || err.PeerID != "abc" {
+		t.Errorf("expected a duplicate message error, got %v", err)
+	}
+	if got := transport.counters.duplicates.Load(); got != 1 {
+		t.Errorf("expected 1 duplicate counted, got %d", got)
+	}
+}
+
+func TestTransport_ConnectErrorCodes(t *testing.T) {
+	transport, cleanup := setupTestTransport(t, "127.0.0.1:0")
+	defer cleanup()
+
+	_, err := transport.Connect(&Peer{ID: "peer", Address: freeAddr(t)})
+	if !errors.Is(err, ErrConnectFailed) {
+		t.Errorf("expected a connection failure, got %v", err)
+	}
+
+	transport.Stop()
+	if _, err := transport.Connect(&Peer{ID: "peer", Address: "127.0.0.1:1"}); !errors.Is(err, ErrTransportNotRunning) {
+		t.Errorf("expected transport not running, got %v", err)
+	}
+}
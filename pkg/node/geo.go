@@ -0,0 +1,218 @@
+package node
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusKM is the mean Earth radius used for great-circle distances.
+const earthRadiusKM = 6371.0
+
+// GeoPoint is a latitude/longitude pair in degrees.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// ParseGeoPoint parses "lat,lon" in degrees.
+func ParseGeoPoint(s string) (GeoPoint, error) {
+	latStr, lonStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return GeoPoint{}, fmt.Errorf("invalid location %q: expected \"lat,lon\"", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return GeoPoint{}, fmt.Errorf("invalid latitude in %q", s)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return GeoPoint{}, fmt.Errorf("invalid longitude in %q", s)
+	}
+	return GeoPoint{Lat: lat, Lon: lon}, nil
+}
+
+// HaversineKM returns the great-circle distance between two points in kilometers.
+func HaversineKM(a, b GeoPoint) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLon := toRad(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// GeoLocator resolves an IP address to coordinates.
+type GeoLocator interface {
+	Locate(ip net.IP) (GeoPoint, bool)
+}
+
+// GeoLocatorFunc adapts a function to GeoLocator.
+type GeoLocatorFunc func(ip net.IP) (GeoPoint, bool)
+
+// Locate calls f(ip).
+func (f GeoLocatorFunc) Locate(ip net.IP) (GeoPoint, bool) {
+	return f(ip)
+}
+
+// CIDRGeoLocator locates IPs by longest-prefix match against a table of networks.
+type CIDRGeoLocator struct {
+	// networks maps prefix length -> masked network address -> location
+	networks map[int]map[string]GeoPoint
+	maxBits  int
+}
+
+// LoadGeoLocatorCSV loads a CIDRGeoLocator from a CSV file with a header row
+// containing "network", "latitude" and "longitude" columns. GeoLite2 City
+// blocks CSVs use this layout and can be loaded as-is.
+func LoadGeoLocatorCSV(path string) (*CIDRGeoLocator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geo database: %w", err)
+	}
+	defer f.Close()
+	return NewCIDRGeoLocator(f)
+}
+
+// NewCIDRGeoLocator reads a network/latitude/longitude CSV table. Rows without
+// coordinates are skipped.
+func NewCIDRGeoLocator(r io.Reader) (*CIDRGeoLocator, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geo database header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.TrimSpace(name)] = i
+	}
+	netCol, ok1 := cols["network"]
+	latCol, ok2 := cols["latitude"]
+	lonCol, ok3 := cols["longitude"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("geo database must have network, latitude and longitude columns")
+	}
+
+	l := &CIDRGeoLocator{networks: make(map[int]map[string]GeoPoint)}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read geo database: %w", err)
+		}
+		if len(record) <= max(netCol, latCol, lonCol) {
+			continue
+		}
+		_, network, err := net.ParseCIDR(record[netCol])
+		if err != nil {
+			continue
+		}
+		point, err := ParseGeoPoint(record[latCol] + "," + record[lonCol])
+		if err != nil {
+			continue
+		}
+		l.add(network, point)
+	}
+	return l, nil
+}
+
+func (l *CIDRGeoLocator) add(network *net.IPNet, point GeoPoint) {
+	ones, bits := network.Mask.Size()
+	if bits == 32 {
+		ones += 96 // Index IPv4 networks in the IPv4-mapped IPv6 space
+	}
+	if l.networks[ones] == nil {
+		l.networks[ones] = make(map[string]GeoPoint)
+	}
+	l.networks[ones][string(network.IP.To16().Mask(net.CIDRMask(ones, 128)))] = point
+	if ones > l.maxBits {
+		l.maxBits = ones
+	}
+}
+
+// Len returns the number of networks in the table.
+func (l *CIDRGeoLocator) Len() int {
+	n := 0
+	for _, networks := range l.networks {
+		n += len(networks)
+	}
+	return n
+}
+
+// Locate returns the location of the most specific network containing ip.
+func (l *CIDRGeoLocator) Locate(ip net.IP) (GeoPoint, bool) {
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return GeoPoint{}, false
+	}
+	for ones := l.maxBits; ones >= 0; ones-- {
+		networks := l.networks[ones]
+		if networks == nil {
+			continue
+		}
+		if point, ok := networks[string(ip16.Mask(net.CIDRMask(ones, 128)))]; ok {
+			return point, true
+		}
+	}
+	return GeoPoint{}, false
+}
+
+// GeoDistance computes the distance from this node to peers by locating
+// their addresses.
+type GeoDistance struct {
+	locator GeoLocator
+	origin  GeoPoint
+	lookup  func(host string) ([]net.IP, error) // DNS resolution (a field for tests)
+}
+
+// NewGeoDistance creates a GeoDistance measuring from origin.
+func NewGeoDistance(locator GeoLocator, origin GeoPoint) *GeoDistance {
+	return &GeoDistance{locator: locator, origin: origin, lookup: net.LookupIP}
+}
+
+// DistanceKM locates a peer address ("host:port" or a bare host) and returns
+// its distance from this node.
+func (g *GeoDistance) DistanceKM(address string) (float64, error) {
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		var err error
+		if ips, err = g.lookup(host); err != nil {
+			return 0, fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+	}
+	for _, ip := range ips {
+		if point, ok := g.locator.Locate(ip); ok {
+			return HaversineKM(g.origin, point), nil
+		}
+	}
+	return 0, fmt.Errorf("no location found for %s", host)
+}
+
+// UpdatePeer measures a peer's distance and stores it via UpdateMetrics,
+// keeping its current ping and hop count. Peers that can't be located keep GeoKM.
+func (g *GeoDistance) UpdatePeer(registry *PeerRegistry, peerID string) error {
+	peer := registry.GetPeer(peerID)
+	if peer == nil {
+		return errPeerNotFound(peerID)
+	}
+	km, err := g.DistanceKM(peer.Address)
+	if err != nil {
+		return err
+	}
+	return registry.UpdateMetrics(peerID, peer.PingMS, km, peer.Hops)
+}
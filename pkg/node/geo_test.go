@@ -0,0 +1,106 @@
+package node
+
+import (
+	"math"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testGeoCSV = `network,geoname_id,registered_country_geoname_id,represented_country_geoname_id,is_anonymous_proxy,is_satellite_provider,postal_code,latitude,longitude,accuracy_radius
+203.0.113.0/24,1,1,,0,0,,48.8566,2.3522,100
+203.0.113.128/25,2,2,,0,0,,40.7128,-74.0060,100
+2001:db8::/32,3,3,,0,0,,35.6762,139.6503,100
+198.51.100.0/24,4,4,,0,0,,,,100
+`
+
+func TestHaversineKM(t *testing.T) {
+	london := GeoPoint{Lat: 51.5074, Lon: -0.1278}
+	paris := GeoPoint{Lat: 48.8566, Lon: 2.3522}
+	if d := HaversineKM(london, paris); math.Abs(d-344) > 5 {
+		t.Errorf("expected London-Paris ~344km, got %.1f", d)
+	}
+	if d := HaversineKM(paris, paris); d != 0 {
+		t.Errorf("expected zero distance, got %f", d)
+	}
+}
+
+func TestParseGeoPoint(t *testing.T) {
+	point, err := ParseGeoPoint("51.5, -0.12")
+	if err != nil || point.Lat != 51.5 || point.Lon != -0.12 {
+		t.Errorf("unexpected point %+v, %v", point, err)
+	}
+	for _, bad := range []string{"", "51.5", "91,0", "0,181", "a,b"} {
+		if _, err := ParseGeoPoint(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCIDRGeoLocator(t *testing.T) {
+	locator, err := NewCIDRGeoLocator(strings.NewReader(testGeoCSV))
+	if err != nil {
+		t.Fatalf("NewCIDRGeoLocator failed: %v", err)
+	}
+	if locator.Len() != 3 {
+		t.Errorf("expected 3 networks (row without coordinates skipped), got %d", locator.Len())
+	}
+
+	tests := []struct {
+		ip    string
+		lat   float64
+		found bool
+	}{
+		{"203.0.113.10", 48.8566, true},  // /24 only
+		{"203.0.113.200", 40.7128, true}, // Longest prefix wins
+		{"2001:db8::1", 35.6762, true},
+		{"198.51.100.1", 0, false},
+		{"192.0.2.1", 0, false},
+	}
+	for _, tt := range tests {
+		point, ok := locator.Locate(net.ParseIP(tt.ip))
+		if ok != tt.found || point.Lat != tt.lat {
+			t.Errorf("Locate(%s) = %+v, %v; want lat %v, %v", tt.ip, point, ok, tt.lat, tt.found)
+		}
+	}
+
+	if _, err := NewCIDRGeoLocator(strings.NewReader("cidr,lat,lon\n")); err == nil {
+		t.Error("expected error for missing columns")
+	}
+}
+
+func TestGeoDistance_UpdatePeer(t *testing.T) {
+	locator, err := NewCIDRGeoLocator(strings.NewReader(testGeoCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	london := GeoPoint{Lat: 51.5074, Lon: -0.1278}
+	geo := NewGeoDistance(locator, london)
+	geo.lookup = func(host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("203.0.113.10")}, nil
+	}
+
+	registry, err := NewPeerRegistryWithPath(filepath.Join(t.TempDir(), "peers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registry.Close()
+	registry.AddPeer(&Peer{ID: "paris", Name: "paris", Address: "paris.example.com:9091", PingMS: 12, Hops: 4})
+	registry.AddPeer(&Peer{ID: "lan", Name: "lan", Address: "192.168.1.5:9091"})
+
+	if err := geo.UpdatePeer(registry, "paris"); err != nil {
+		t.Fatalf("UpdatePeer failed: %v", err)
+	}
+	peer := registry.GetPeer("paris")
+	if math.Abs(peer.GeoKM-344) > 5 || peer.PingMS != 12 || peer.Hops != 4 {
+		t.Errorf("expected GeoKM ~344 with ping and hops kept, got %+v", peer)
+	}
+
+	if err := geo.UpdatePeer(registry, "lan"); err == nil {
+		t.Error("expected error for an address without a location")
+	}
+	if registry.GetPeer("lan").GeoKM != 0 {
+		t.Error("unlocated peer should keep GeoKM")
+	}
+}
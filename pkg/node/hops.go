@@ -0,0 +1,181 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// DefaultHopProbeInterval is how often peer hop counts are re-measured.
+const DefaultHopProbeInterval = 30 * time.Minute
+
+const (
+	maxProbeTTL        = 32              // Peers further than this are reported as unreachable
+	hopProbeTTLTimeout = 1 * time.Second // Per-attempt wait; a TTL that is too low never completes
+)
+
+// errTTLSetUnsupported is returned when the platform can't set the TTL for an address family.
+var errTTLSetUnsupported = errors.New("setting TTL is not supported for this address")
+
+// MeasureHops estimates the number of routers between this node and a TCP
+// address. It connects with increasing IP TTLs, which needs no privileges
+// unlike a raw-socket traceroute: the smallest TTL at which the connection
+// completes is one more than the hop count. A refused connection still
+// counts as reaching the host.
+func MeasureHops(address string) (int, error) {
+	return measureHops(address, dialWithTTL)
+}
+
+func measureHops(address string, dial func(address string, ttl int, timeout time.Duration) error) (int, error) {
+	reaches := func(ttl int) (bool, error) {
+		err := dial(address, ttl, hopProbeTTLTimeout)
+		switch {
+		case err == nil, errors.Is(err, syscall.ECONNREFUSED):
+			return true, nil
+		case errors.Is(err, errTTLSetUnsupported):
+			return false, err
+		default:
+			return false, nil
+		}
+	}
+
+	ok, err := reaches(maxProbeTTL)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("%s is unreachable within %d hops", address, maxProbeTTL)
+	}
+
+	// Binary search for the smallest TTL that reaches the host
+	lo, hi := 1, maxProbeTTL
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if ok, _ := reaches(mid); ok {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo - 1, nil
+}
+
+// dialWithTTL opens and immediately closes a TCP connection sent with the given TTL.
+func dialWithTTL(address string, ttl int, timeout time.Duration) error {
+	var setErr error
+	dialer := net.Dialer{
+		Timeout: timeout,
+		Control: func(network, _ string, c syscall.RawConn) error {
+			if err := c.Control(func(fd uintptr) { setErr = setSocketTTL(fd, network, ttl) }); err != nil {
+				return err
+			}
+			return setErr
+		},
+	}
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		if setErr != nil {
+			return fmt.Errorf("%w: %v", errTTLSetUnsupported, setErr)
+		}
+		return err
+	}
+	return conn.Close()
+}
+
+// HopProber periodically measures the hop count to every known peer and
+// stores it via UpdateMetrics. Probes run in the background one peer at a
+// time, so a slow or unreachable peer never blocks other operations.
+type HopProber struct {
+	registry *PeerRegistry
+	interval time.Duration
+	measure  func(address string) (int, error)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewHopProber creates a prober for the peers in registry. An interval of
+// zero disables periodic probing.
+func NewHopProber(registry *PeerRegistry, interval time.Duration) *HopProber {
+	return &HopProber{registry: registry, interval: interval, measure: MeasureHops}
+}
+
+// Start begins probing immediately and then every interval. It does nothing
+// if probing is disabled or already running.
+func (p *HopProber) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.interval <= 0 || p.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.wg.Add(1)
+	go p.loop(ctx)
+}
+
+// Stop ends probing and waits for the current probe to finish.
+func (p *HopProber) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.wg.Wait()
+	p.cancel = nil
+}
+
+func (p *HopProber) loop(ctx context.Context) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.ProbeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll measures every peer once. Peers that can't be measured keep their
+// previous hop count.
+func (p *HopProber) ProbeAll(ctx context.Context) {
+	for _, peer := range p.registry.ListPeers() {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := p.ProbePeer(peer.ID); err != nil {
+			logging.Debug("could not measure peer hops", logging.Fields{"peer_id": peer.ID, "error": err})
+		}
+	}
+}
+
+// ProbePeer measures one peer's hop count and stores it, keeping its ping and distance.
+func (p *HopProber) ProbePeer(peerID string) error {
+	peer := p.registry.GetPeer(peerID)
+	if peer == nil {
+		return errPeerNotFound(peerID)
+	}
+	hops, err := p.measure(peer.Address)
+	if err != nil {
+		return err
+	}
+
+	// Re-read so a ping or distance update during the probe isn't overwritten
+	if current := p.registry.GetPeer(peerID); current != nil {
+		peer = current
+	}
+	return p.registry.UpdateMetrics(peerID, peer.PingMS, peer.GeoKM, hops)
+}
@@ -0,0 +1,85 @@
+package node
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestMeasureHops_BinarySearch(t *testing.T) {
+	// A peer 5 routers away needs TTL 6
+	var attempts int
+	dial := func(address string, ttl int, timeout time.Duration) error {
+		attempts++
+		if ttl >= 6 {
+			return syscall.ECONNREFUSED // Reached the host, port closed
+		}
+		return errors.New("i/o timeout")
+	}
+
+	hops, err := measureHops("peer:9091", dial)
+	if err != nil || hops != 5 {
+		t.Errorf("expected 5 hops, got %d, %v", hops, err)
+	}
+	if attempts > 7 {
+		t.Errorf("expected a binary search, took %d attempts", attempts)
+	}
+
+	unreachable := func(string, int, time.Duration) error { return errors.New("i/o timeout") }
+	if _, err := measureHops("peer:9091", unreachable); err == nil {
+		t.Error("expected error for unreachable peer")
+	}
+
+	unsupported := func(string, int, time.Duration) error { return errTTLSetUnsupported }
+	if _, err := measureHops("peer:9091", unsupported); !errors.Is(err, errTTLSetUnsupported) {
+		t.Errorf("expected unsupported error, got %v", err)
+	}
+}
+
+func TestMeasureHops_Loopback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	hops, err := MeasureHops(listener.Addr().String())
+	if err != nil || hops != 0 {
+		t.Errorf("expected 0 hops to loopback, got %d, %v", hops, err)
+	}
+}
+
+func TestHopProber_ProbePeer(t *testing.T) {
+	registry, err := NewPeerRegistryWithPath(filepath.Join(t.TempDir(), "peers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer registry.Close()
+	registry.AddPeer(&Peer{ID: "p1", Name: "p1", Address: "10.0.0.1:9091", PingMS: 20, GeoKM: 100})
+
+	prober := NewHopProber(registry, 0)
+	prober.measure = func(address string) (int, error) { return 7, nil }
+	if err := prober.ProbePeer("p1"); err != nil {
+		t.Fatalf("ProbePeer failed: %v", err)
+	}
+	peer := registry.GetPeer("p1")
+	if peer.Hops != 7 || peer.PingMS != 20 || peer.GeoKM != 100 {
+		t.Errorf("expected hops updated with ping and distance kept, got %+v", peer)
+	}
+
+	// Disabled prober doesn't start
+	prober.Start()
+	prober.Stop()
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package node
+
+import "syscall"
+
+// setSocketTTL sets the TTL (IPv4) or hop limit (IPv6) of outgoing packets.
+func setSocketTTL(fd uintptr, network string, ttl int) error {
+	if network == "tcp6" {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	}
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package node
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// setSocketTTL sets the TTL (IPv4) or hop limit (IPv6) of outgoing packets.
+func setSocketTTL(fd uintptr, network string, ttl int) error {
+	if network == "tcp6" {
+		return windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IPV6, windows.IPV6_UNICAST_HOPS, ttl)
+	}
+	return syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
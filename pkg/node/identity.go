@@ -44,6 +44,32 @@ func VerifyChallenge(challenge, response, sharedSecret []byte) bool {
 	return hmac.Equal(response, expected)
 }
 
+// handshakeConfirmContext prefixes the signed handshake confirmation, so it
+// can't be confused with any other HMAC over the shared secret.
+const handshakeConfirmContext = "mining-p2p-handshake-confirm-v1"
+
+// SignHandshakeConfirm signs the client's confirmation of a handshake. The
+// signature is HMAC-SHA256 keyed with the shared secret over the bytes
+//
+//	"mining-p2p-handshake-confirm-v1" || clientChallenge || serverNonce
+//
+// where the challenge and nonce are the raw ChallengeSize-byte values from the
+// handshake and its ack. The server's nonce is fresh for every upgrade, so a
+// recorded handshake replayed to a new connection can't be confirmed without
+// the private key.
+func SignHandshakeConfirm(challenge, nonce, sharedSecret []byte) []byte {
+	mac := hmac.New(sha256.New, sharedSecret)
+	mac.Write([]byte(handshakeConfirmContext))
+	mac.Write(challenge)
+	mac.Write(nonce)
+	return mac.Sum(nil)
+}
+
+// VerifyHandshakeConfirm verifies a signature from SignHandshakeConfirm.
+func VerifyHandshakeConfirm(challenge, nonce, signature, sharedSecret []byte) bool {
+	return hmac.Equal(signature, SignHandshakeConfirm(challenge, nonce, sharedSecret))
+}
+
 // NodeRole defines the operational mode of a node.
 type NodeRole string
 
@@ -58,21 +84,27 @@ const (
 
 // NodeIdentity represents the public identity of a node.
 type NodeIdentity struct {
-	ID        string    `json:"id"`        // Derived from public key (first 16 bytes hex)
+	ID        string    `json:"id"`        // Derived from the first public key (first 16 bytes hex), kept across rotations
 	Name      string    `json:"name"`      // Human-friendly name
 	PublicKey string    `json:"publicKey"` // X25519 base64
 	CreatedAt time.Time `json:"createdAt"`
 	Role      NodeRole  `json:"role"`
+
+	// Set after RotateIdentity: the replaced key, accepted by peers until it expires
+	PreviousPublicKey    string     `json:"previousPublicKey,omitempty"`
+	PreviousKeyExpiresAt *time.Time `json:"previousKeyExpiresAt,omitempty"`
+	KeyRotatedAt         *time.Time `json:"keyRotatedAt,omitempty"`
 }
 
 // NodeManager handles node identity operations including key generation and storage.
 type NodeManager struct {
-	identity   *NodeIdentity
-	privateKey []byte // Never serialized to JSON
-	keyPair    *stmf.KeyPair
-	keyPath    string // ~/.local/share/lethean-desktop/node/private.key
-	configPath string // ~/.config/lethean-desktop/node.json
-	mu         sync.RWMutex
+	identity    *NodeIdentity
+	privateKey  []byte // Never serialized to JSON
+	keyPair     *stmf.KeyPair
+	previousKey []byte // Private key replaced by RotateIdentity, kept for the grace period
+	keyPath     string // ~/.local/share/lethean-desktop/node/private.key
+	configPath  string // ~/.config/lethean-desktop/node.json
+	mu          sync.RWMutex
 }
 
 // NewNodeManager creates a new NodeManager, loading existing identity if available.
@@ -173,9 +205,12 @@ func (n *NodeManager) DeriveSharedSecret(peerPubKeyBase64 string) ([]byte, error
 	defer n.mu.RUnlock()
 
 	if n.privateKey == nil {
-		return nil, fmt.Errorf("node identity not initialized")
+		return nil, ErrIdentityNotInitialized
 	}
+	return deriveSharedSecret(n.privateKey, peerPubKeyBase64)
+}
 
+func deriveSharedSecret(ourPrivateKey []byte, peerPubKeyBase64 string) ([]byte, error) {
 	// Load peer's public key
 	peerPubKey, err := stmf.LoadPublicKeyBase64(peerPubKeyBase64)
 	if err != nil {
@@ -183,7 +218,7 @@ func (n *NodeManager) DeriveSharedSecret(peerPubKeyBase64 string) ([]byte, error
 	}
 
 	// Load our private key
-	privateKey, err := ecdh.X25519().NewPrivateKey(n.privateKey)
+	privateKey, err := ecdh.X25519().NewPrivateKey(ourPrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load private key: %w", err)
 	}
@@ -235,6 +270,65 @@ func (n *NodeManager) saveIdentity() error {
 	return nil
 }
 
+// identityFile is one file written by writeIdentityFiles.
+type identityFile struct {
+	path    string
+	data    []byte // nil removes the file
+	perm    os.FileMode
+	dirPerm os.FileMode
+}
+
+// writeIdentityFiles replaces several identity files as one change: each is
+// written to a temp file first and only renamed into place once all were
+// written. If a rename fails, the files already replaced are put back.
+func writeIdentityFiles(files []identityFile) error {
+	for _, f := range files {
+		if f.data == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(f.path), f.dirPerm); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", filepath.Base(f.path), err)
+		}
+		if err := os.WriteFile(f.path+".tmp", f.data, f.perm); err != nil {
+			for _, written := range files {
+				os.Remove(written.path + ".tmp")
+			}
+			return fmt.Errorf("failed to write %s: %w", filepath.Base(f.path), err)
+		}
+	}
+
+	originals := make([][]byte, len(files)) // nil for files that didn't exist
+	for i, f := range files {
+		originals[i], _ = os.ReadFile(f.path)
+	}
+	for i, f := range files {
+		var err error
+		if f.data == nil {
+			if err = os.Remove(f.path); os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.Rename(f.path+".tmp", f.path)
+		}
+		if err == nil {
+			continue
+		}
+
+		for j := i; j < len(files); j++ {
+			os.Remove(files[j].path + ".tmp")
+		}
+		for j := 0; j < i; j++ {
+			if originals[j] == nil {
+				os.Remove(files[j].path)
+			} else {
+				os.WriteFile(files[j].path, originals[j], files[j].perm)
+			}
+		}
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(f.path), err)
+	}
+	return nil
+}
+
 // loadIdentity loads the node identity from disk.
 func (n *NodeManager) loadIdentity() error {
 	// Load identity config
@@ -264,6 +358,13 @@ func (n *NodeManager) loadIdentity() error {
 	n.privateKey = privateKey
 	n.keyPair = keyPair
 
+	// The previous key is only needed during a rotation's grace period
+	if identity.PreviousPublicKey != "" {
+		if previousKey, err := os.ReadFile(n.previousKeyPath()); err == nil {
+			n.previousKey = previousKey
+		}
+	}
+
 	return nil
 }
 
@@ -282,9 +383,14 @@ func (n *NodeManager) Delete() error {
 		return fmt.Errorf("failed to remove identity: %w", err)
 	}
 
+	if err := os.Remove(n.previousKeyPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove previous private key: %w", err)
+	}
+
 	n.identity = nil
 	n.privateKey = nil
 	n.keyPair = nil
+	n.previousKey = nil
 
 	return nil
 }
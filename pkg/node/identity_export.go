@@ -0,0 +1,163 @@
+package node
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Snider/Borg/pkg/smsg"
+	"github.com/Snider/Borg/pkg/stmf"
+)
+
+// Identity export parameters. smsg hashes its password once, so the
+// passphrase is stretched with PBKDF2 first to slow down guessing.
+const (
+	IdentityExportVersion       = 1
+	MinExportPassphraseLength   = 12
+	identityExportKDF           = "pbkdf2-sha256"
+	identityExportIterations    = 600000
+	identityExportSaltSize      = 16
+	identityExportDerivedKeyLen = 32
+)
+
+// IdentityExport is a node identity and its private keys encrypted with a
+// passphrase, for moving a node to another machine. The public fields are
+// informational; Data holds everything needed to restore the identity.
+type IdentityExport struct {
+	Version    int    `json:"version"`
+	NodeID     string `json:"nodeId"`
+	Name       string `json:"name"`
+	PublicKey  string `json:"publicKey"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Data       []byte `json:"data"` // SMSG container
+}
+
+// exportedIdentity is the encrypted content of an IdentityExport.
+type exportedIdentity struct {
+	Identity    NodeIdentity `json:"identity"`
+	PrivateKey  []byte       `json:"privateKey"`
+	PreviousKey []byte       `json:"previousKey,omitempty"` // During a key rotation's grace period
+}
+
+func exportPassword(passphrase string, salt []byte, iterations int) (string, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, identityExportDerivedKeyLen)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ExportIdentity encrypts the node identity and private keys with passphrase.
+func (n *NodeManager) ExportIdentity(passphrase string) (*IdentityExport, error) {
+	if len(passphrase) < MinExportPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinExportPassphraseLength)
+	}
+
+	n.mu.RLock()
+	if n.identity == nil {
+		n.mu.RUnlock()
+		return nil, ErrIdentityNotInitialized
+	}
+	content := exportedIdentity{Identity: *n.identity, PrivateKey: n.privateKey, PreviousKey: n.previousKey}
+	n.mu.RUnlock()
+
+	body, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal identity: %w", err)
+	}
+	salt := make([]byte, identityExportSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	password, err := exportPassword(passphrase, salt, identityExportIterations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	data, err := smsg.Encrypt(smsg.NewMessage(string(body)), password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt identity: %w", err)
+	}
+
+	return &IdentityExport{
+		Version:    IdentityExportVersion,
+		NodeID:     content.Identity.ID,
+		Name:       content.Identity.Name,
+		PublicKey:  content.Identity.PublicKey,
+		KDF:        identityExportKDF,
+		Iterations: identityExportIterations,
+		Salt:       salt,
+		Data:       data,
+	}, nil
+}
+
+// errInvalidExport creates the error for an export that can't be imported.
+func errInvalidExport(format string, args ...interface{}) *NodeError {
+	return newNodeError(CodeInvalidInput, "", fmt.Sprintf(format, args...), nil)
+}
+
+// ImportIdentity decrypts an exported identity and makes it this node's
+// identity. An existing identity is only replaced when force is set. The
+// export is checked and written to disk before the node switches to it, so a
+// failed import leaves the current identity in place. Problems with the
+// export match ErrInvalidIdentityExport.
+func (n *NodeManager) ImportIdentity(export *IdentityExport, passphrase string, force bool) error {
+	if n.HasIdentity() && !force {
+		return ErrIdentityExists
+	}
+	if export.Version != IdentityExportVersion || export.KDF != identityExportKDF {
+		return errInvalidExport("unsupported identity export (version %d, kdf %q)", export.Version, export.KDF)
+	}
+	if export.Iterations < 1 || export.Iterations > 10*identityExportIterations {
+		return errInvalidExport("invalid key derivation iterations %d", export.Iterations)
+	}
+	password, err := exportPassword(passphrase, export.Salt, export.Iterations)
+	if err != nil {
+		return errInvalidExport("failed to derive key: %v", err)
+	}
+	msg, err := smsg.Decrypt(export.Data, password)
+	if err != nil {
+		return errInvalidExport("failed to decrypt identity: wrong passphrase or corrupted export")
+	}
+	var content exportedIdentity
+	if err := json.Unmarshal([]byte(msg.Body), &content); err != nil {
+		return errInvalidExport("failed to unmarshal identity: %v", err)
+	}
+
+	// The private key must belong to the identity's public key
+	keyPair, err := stmf.LoadKeyPair(content.PrivateKey)
+	if err != nil {
+		return errInvalidExport("failed to load keypair: %v", err)
+	}
+	if keyPair.PublicKeyBase64() != content.Identity.PublicKey {
+		return errInvalidExport("private key does not match the identity's public key")
+	}
+	identity := content.Identity
+	data, err := json.MarshalIndent(&identity, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.identity != nil && !force {
+		return ErrIdentityExists
+	}
+	if err := writeIdentityFiles([]identityFile{
+		{path: n.keyPath, data: content.PrivateKey, perm: 0600, dirPerm: 0700},
+		{path: n.previousKeyPath(), data: content.PreviousKey, perm: 0600, dirPerm: 0700},
+		{path: n.configPath, data: data, perm: 0644, dirPerm: 0755},
+	}); err != nil {
+		return fmt.Errorf("failed to save imported identity: %w", err)
+	}
+
+	n.identity = &identity
+	n.keyPair = keyPair
+	n.privateKey = content.PrivateKey
+	n.previousKey = content.PreviousKey
+	return nil
+}
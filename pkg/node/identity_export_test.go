@@ -0,0 +1,106 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNodeManager_ExportImportIdentity(t *testing.T) {
+	source, sourceCleanup := setupTestNodeManager(t)
+	defer sourceCleanup()
+	if _, err := source.ExportIdentity("long enough passphrase"); err == nil {
+		t.Error("expected exporting without an identity to fail")
+	}
+	if err := source.GenerateIdentity("controller", RoleController); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.RotateIdentity(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.ExportIdentity("short"); err == nil {
+		t.Error("expected a short passphrase to be rejected")
+	}
+	export, err := source.ExportIdentity("long enough passphrase")
+	if err != nil {
+		t.Fatalf("ExportIdentity failed: %v", err)
+	}
+	if export.NodeID != source.GetIdentity().ID || strings.Contains(string(export.Data), "privateKey") {
+		t.Errorf("unexpected export %+v", export)
+	}
+
+	target, targetCleanup := setupTestNodeManager(t)
+	defer targetCleanup()
+	if err := target.ImportIdentity(export, "wrong passphrase!", false); !errors.Is(err, ErrInvalidIdentityExport) {
+		t.Errorf("expected a wrong passphrase to be rejected as an invalid export, got %v", err)
+	}
+	if err := target.ImportIdentity(export, "long enough passphrase", false); err != nil {
+		t.Fatalf("ImportIdentity failed: %v", err)
+	}
+
+	// The imported identity derives the same secrets, from disk too
+	peer, peerCleanup := setupTestNodeManager(t)
+	defer peerCleanup()
+	if err := peer.GenerateIdentity("peer", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewNodeManagerWithPaths(target.keyPath, target.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := source.DeriveSharedSecret(peer.GetIdentity().PublicKey)
+	got, err := reloaded.DeriveSharedSecret(peer.GetIdentity().PublicKey)
+	if err != nil || string(got) != string(want) || reloaded.GetIdentity().ID != source.GetIdentity().ID {
+		t.Errorf("expected the imported identity to match the source, got %v", err)
+	}
+	if _, err := reloaded.DerivePreviousSharedSecret(peer.GetIdentity().PublicKey); err != nil {
+		t.Errorf("expected the previous key to be imported: %v", err)
+	}
+
+	// An existing identity is only replaced with force
+	if err := peer.ImportIdentity(export, "long enough passphrase", false); !errors.Is(err, ErrIdentityExists) {
+		t.Errorf("expected importing over an existing identity to fail without force, got %v", err)
+	}
+	if err := peer.ImportIdentity(export, "long enough passphrase", true); err != nil || peer.GetIdentity().ID != export.NodeID {
+		t.Errorf("expected a forced import to replace the identity, got %v", err)
+	}
+}
+
+func TestNodeManager_ImportIdentitySaveFailureKeepsIdentity(t *testing.T) {
+	source, sourceCleanup := setupTestNodeManager(t)
+	defer sourceCleanup()
+	if err := source.GenerateIdentity("controller", RoleController); err != nil {
+		t.Fatal(err)
+	}
+	export, err := source.ExportIdentity("long enough passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, targetCleanup := setupTestNodeManager(t)
+	defer targetCleanup()
+	if err := target.GenerateIdentity("worker", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	before := target.GetIdentity()
+	oldKey, _ := os.ReadFile(target.keyPath)
+
+	// A directory in place of the identity file makes saving it fail
+	os.Remove(target.configPath)
+	if err := os.MkdirAll(filepath.Join(target.configPath, "blocker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err = target.ImportIdentity(export, "long enough passphrase", true)
+	if err == nil || errors.Is(err, ErrInvalidIdentityExport) {
+		t.Fatalf("expected a save error, got %v", err)
+	}
+	if after := target.GetIdentity(); after.ID != before.ID || after.PublicKey != before.PublicKey {
+		t.Errorf("expected the current identity kept in memory, got %+v", after)
+	}
+	if key, _ := os.ReadFile(target.keyPath); string(key) != string(oldKey) {
+		t.Error("expected the current private key kept on disk")
+	}
+}
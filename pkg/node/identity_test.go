@@ -351,3 +351,21 @@ func TestChallengeResponse(t *testing.T) {
 		}
 	})
 }
+
+func TestHandshakeConfirm(t *testing.T) {
+	secret := []byte("shared-secret")
+	challenge, _ := GenerateChallenge()
+	nonce, _ := GenerateChallenge()
+	signature := SignHandshakeConfirm(challenge, nonce, secret)
+
+	if !VerifyHandshakeConfirm(challenge, nonce, signature, secret) {
+		t.Error("expected a valid confirmation to verify")
+	}
+	otherNonce, _ := GenerateChallenge()
+	if VerifyHandshakeConfirm(challenge, otherNonce, signature, secret) {
+		t.Error("expected a confirmation for another nonce to be rejected")
+	}
+	if VerifyHandshakeConfirm(challenge, nonce, SignChallenge(append(challenge, nonce...), secret), secret) {
+		t.Error("expected a plain challenge signature not to pass as a confirmation")
+	}
+}
@@ -0,0 +1,190 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Snider/Borg/pkg/stmf"
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// Key rotation grace periods: how long peers keep accepting the replaced key.
+const (
+	DefaultKeyRotationGrace = 24 * time.Hour
+	MaxKeyRotationGrace     = 30 * 24 * time.Hour
+)
+
+// previousKeyPath is where the private key replaced by RotateIdentity is kept.
+func (n *NodeManager) previousKeyPath() string {
+	return n.keyPath + ".previous"
+}
+
+// RotateIdentity replaces the node's keypair, keeping its ID so peers and
+// allowlists keyed by ID are unaffected. The replaced key is persisted
+// alongside the new one until grace has passed, so it can still prove the
+// rotation to peers that haven't heard about it yet.
+func (n *NodeManager) RotateIdentity(grace time.Duration) error {
+	if grace <= 0 || grace > MaxKeyRotationGrace {
+		return fmt.Errorf("grace period must be between 0 and %s", MaxKeyRotationGrace)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.identity == nil {
+		return ErrIdentityNotInitialized
+	}
+
+	keyPair, err := stmf.GenerateKeyPair()
+	if err != nil {
+		return fmt.Errorf("failed to generate keypair: %w", err)
+	}
+
+	identity := *n.identity
+	now := time.Now()
+	expires := now.Add(grace)
+	identity.PreviousPublicKey = identity.PublicKey
+	identity.PreviousKeyExpiresAt = &expires
+	identity.KeyRotatedAt = &now
+	identity.PublicKey = keyPair.PublicKeyBase64()
+	data, err := json.MarshalIndent(&identity, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	// Persist the outgoing key, the new key and the identity together, and
+	// only then switch to them, so a failed rotation leaves the node as it was
+	if err := writeIdentityFiles([]identityFile{
+		{path: n.previousKeyPath(), data: n.privateKey, perm: 0600, dirPerm: 0700},
+		{path: n.keyPath, data: keyPair.PrivateKey(), perm: 0600, dirPerm: 0700},
+		{path: n.configPath, data: data, perm: 0644, dirPerm: 0755},
+	}); err != nil {
+		return fmt.Errorf("failed to save rotated identity: %w", err)
+	}
+
+	n.identity = &identity
+	n.previousKey = n.privateKey
+	n.keyPair = keyPair
+	n.privateKey = keyPair.PrivateKey()
+
+	logging.Info("node key rotated", logging.Fields{"node_id": n.identity.ID, "grace_until": expires})
+	return nil
+}
+
+// DerivePreviousSharedSecret derives a shared secret with a peer using the
+// key replaced by the last rotation, while its grace period lasts.
+func (n *NodeManager) DerivePreviousSharedSecret(peerPubKeyBase64 string) ([]byte, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.identity == nil || n.previousKey == nil || n.identity.PreviousKeyExpiresAt == nil {
+		return nil, fmt.Errorf("no previous key")
+	}
+	if time.Now().After(*n.identity.PreviousKeyExpiresAt) {
+		return nil, fmt.Errorf("previous key expired")
+	}
+	return deriveSharedSecret(n.previousKey, peerPubKeyBase64)
+}
+
+// RotatePeerKey records a peer's new public key. If the previous key was
+// allowlisted, the new key is allowlisted too and the previous one stays
+// allowed until graceUntil.
+func (r *PeerRegistry) RotatePeerKey(id, publicKey, previousPublicKey string, graceUntil time.Time) error {
+	r.mu.Lock()
+	existing, ok := r.peers[id]
+	if !ok {
+		r.mu.Unlock()
+		return errPeerNotFound(id)
+	}
+	if existing.PublicKey != previousPublicKey {
+		r.mu.Unlock()
+		return fmt.Errorf("peer %s previous key does not match the registered key", id)
+	}
+	// Copy-on-write: connections may hold the old *Peer
+	peer := *existing
+	peer.PublicKey = publicKey
+	peer.PreviousPublicKey = previousPublicKey
+	peer.PreviousKeyExpiresAt = &graceUntil
+	r.peers[id] = &peer
+	r.mu.Unlock()
+
+	r.allowedPublicKeyMu.Lock()
+	if r.allowedPublicKeys[previousPublicKey] {
+		r.allowedPublicKeys[publicKey] = true
+		r.allowedKeyExpiry[previousPublicKey] = graceUntil
+		if err := r.saveAuthLocked(); err != nil {
+			logging.Warn("failed to save rotated key to the allowlist", logging.Fields{"peer_id": id, "error": err})
+		}
+	}
+	r.allowedPublicKeyMu.Unlock()
+
+	return r.save()
+}
+
+// AnnounceKeyRotation tells connected peers about the node's rotated key,
+// returning how many peers were notified. Each announcement is proven with a
+// secret derived from the previous key, which the peer already trusts.
+func (t *Transport) AnnounceKeyRotation() (int, error) {
+	identity := t.node.GetIdentity()
+	if identity == nil {
+		return 0, ErrIdentityNotInitialized
+	}
+	if identity.PreviousPublicKey == "" || identity.PreviousKeyExpiresAt == nil {
+		return 0, fmt.Errorf("node key has not been rotated")
+	}
+
+	t.mu.RLock()
+	conns := make([]*PeerConnection, 0, len(t.conns))
+	for _, pc := range t.conns {
+		conns = append(conns, pc)
+	}
+	t.mu.RUnlock()
+
+	announced := 0
+	for _, pc := range conns {
+		secret, err := t.node.DerivePreviousSharedSecret(pc.Peer.PublicKey)
+		if err != nil {
+			return announced, err
+		}
+		msg, err := NewMessage(MsgKeyRotation, identity.ID, pc.Peer.ID, KeyRotationPayload{
+			PublicKey:         identity.PublicKey,
+			PreviousPublicKey: identity.PreviousPublicKey,
+			GraceUntil:        *identity.PreviousKeyExpiresAt,
+			Proof:             SignChallenge([]byte(identity.PublicKey), secret),
+		})
+		if err != nil {
+			return announced, err
+		}
+		if err := pc.Send(msg); err != nil {
+			logging.Warn("failed to announce key rotation", logging.Fields{"peer_id": pc.Peer.ID, "error": err})
+			continue
+		}
+		announced++
+	}
+	return announced, nil
+}
+
+// handleKeyRotation applies a peer's key rotation announcement once the proof
+// shows the holder of its registered key endorsed the new one.
+func (t *Transport) handleKeyRotation(pc *PeerConnection, msg *Message) {
+	var payload KeyRotationPayload
+	if err := msg.ParsePayload(&payload); err != nil {
+		logging.Warn("invalid key rotation announcement", logging.Fields{"peer_id": pc.Peer.ID, "error": err})
+		return
+	}
+	secret, err := t.node.DeriveSharedSecret(payload.PreviousPublicKey)
+	if err != nil || !VerifyChallenge([]byte(payload.PublicKey), payload.Proof, secret) {
+		logging.Warn("rejected key rotation with an invalid proof", logging.Fields{"peer_id": pc.Peer.ID})
+		return
+	}
+	graceUntil := payload.GraceUntil
+	if limit := time.Now().Add(MaxKeyRotationGrace); graceUntil.After(limit) {
+		graceUntil = limit
+	}
+	if err := t.registry.RotatePeerKey(pc.Peer.ID, payload.PublicKey, payload.PreviousPublicKey, graceUntil); err != nil {
+		logging.Warn("rejected key rotation", logging.Fields{"peer_id": pc.Peer.ID, "error": err})
+		return
+	}
+	logging.Info("peer rotated its key", logging.Fields{"peer_id": pc.Peer.ID, "grace_until": graceUntil})
+}
@@ -0,0 +1,167 @@
+package node
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNodeManager_RotateIdentity(t *testing.T) {
+	nm, cleanup := setupTestNodeManager(t)
+	defer cleanup()
+	if err := nm.RotateIdentity(time.Hour); err == nil {
+		t.Error("expected rotating without an identity to fail")
+	}
+	if err := nm.GenerateIdentity("rotating", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	before := nm.GetIdentity()
+	peer, peerCleanup := setupTestNodeManager(t)
+	defer peerCleanup()
+	if err := peer.GenerateIdentity("peer", RoleController); err != nil {
+		t.Fatal(err)
+	}
+	oldSecret, _ := nm.DeriveSharedSecret(peer.GetIdentity().PublicKey)
+
+	if err := nm.RotateIdentity(MaxKeyRotationGrace + time.Hour); err == nil {
+		t.Error("expected a grace period over the maximum to be rejected")
+	}
+	if err := nm.RotateIdentity(time.Hour); err != nil {
+		t.Fatalf("RotateIdentity failed: %v", err)
+	}
+	after := nm.GetIdentity()
+	if after.ID != before.ID || after.PublicKey == before.PublicKey || after.PreviousPublicKey != before.PublicKey || after.PreviousKeyExpiresAt == nil {
+		t.Fatalf("unexpected identity after rotation %+v", after)
+	}
+
+	// The previous key survives a reload and still derives the old secret
+	reloaded, err := NewNodeManagerWithPaths(nm.keyPath, nm.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.GetIdentity().PublicKey != after.PublicKey {
+		t.Error("expected the new key to be persisted")
+	}
+	secret, err := reloaded.DerivePreviousSharedSecret(peer.GetIdentity().PublicKey)
+	if err != nil || string(secret) != string(oldSecret) {
+		t.Errorf("expected the previous key to derive the old secret, got %v", err)
+	}
+}
+
+func TestNodeManager_RotateIdentityFailureKeepsKey(t *testing.T) {
+	nm, cleanup := setupTestNodeManager(t)
+	defer cleanup()
+	if err := nm.GenerateIdentity("rotating", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	before := nm.GetIdentity()
+	oldKey, err := os.ReadFile(nm.keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in place of the identity file makes its rename fail
+	// after the new key was already moved into place
+	if err := os.Remove(nm.configPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(nm.configPath, "blocker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := nm.RotateIdentity(time.Hour); err == nil {
+		t.Fatal("expected the rotation to fail")
+	}
+
+	if key, _ := os.ReadFile(nm.keyPath); !bytes.Equal(key, oldKey) {
+		t.Error("expected the old private key restored on disk")
+	}
+	if _, err := os.Stat(nm.previousKeyPath()); !os.IsNotExist(err) {
+		t.Error("expected no previous key left from the failed rotation")
+	}
+	if after := nm.GetIdentity(); after.PublicKey != before.PublicKey || after.PreviousPublicKey != "" {
+		t.Errorf("expected the identity unchanged in memory, got %+v", after)
+	}
+	if _, err := nm.DeriveSharedSecret(before.PublicKey); err != nil {
+		t.Errorf("expected the old key still in use: %v", err)
+	}
+}
+
+func TestPeerRegistry_RotatePeerKey(t *testing.T) {
+	pr, cleanup := setupTestPeerRegistry(t)
+	defer cleanup()
+	pr.SetAuthMode(PeerAuthAllowlist)
+	pr.AllowPublicKey("old-key")
+	if err := pr.AddPeer(&Peer{ID: "peer-1", Name: "peer", PublicKey: "old-key"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pr.RotatePeerKey("peer-1", "new-key", "other-key", time.Now().Add(time.Hour)); err == nil {
+		t.Error("expected a rotation from an unregistered key to be rejected")
+	}
+	if err := pr.RotatePeerKey("peer-1", "new-key", "old-key", time.Now().Add(50*time.Millisecond)); err != nil {
+		t.Fatalf("RotatePeerKey failed: %v", err)
+	}
+	if peer := pr.GetPeer("peer-1"); peer.PublicKey != "new-key" || peer.PreviousPublicKey != "old-key" {
+		t.Errorf("unexpected peer after rotation %+v", peer)
+	}
+	if !pr.IsPublicKeyAllowed("new-key") || !pr.IsPublicKeyAllowed("old-key") {
+		t.Error("expected both keys to be allowed during the grace period")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if pr.IsPublicKeyAllowed("old-key") || !pr.IsPublicKeyAllowed("new-key") {
+		t.Error("expected only the new key to be allowed after the grace period")
+	}
+	if keys := pr.ListAllowedPublicKeys(); len(keys) != 1 || keys[0] != "new-key" {
+		t.Errorf("expected the expired key to be left out of the allowlist, got %v", keys)
+	}
+}
+
+func TestTransport_AnnounceKeyRotation(t *testing.T) {
+	addr := freeAddr(t)
+	server, serverCleanup := setupTestTransport(t, addr)
+	defer serverCleanup()
+	client, clientCleanup := setupTestTransport(t, "127.0.0.1:0")
+	defer clientCleanup()
+	if err := server.node.GenerateIdentity("rotation-server", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.node.GenerateIdentity("rotation-client", RoleController); err != nil {
+		t.Fatal(err)
+	}
+	clientID := client.node.GetIdentity().ID
+	oldKey := client.node.GetIdentity().PublicKey
+	server.registry.SetAuthMode(PeerAuthAllowlist)
+	server.registry.AllowPublicKey(oldKey)
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+	if _, err := client.Connect(&Peer{ID: "server", Address: addr}); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	if _, err := client.AnnounceKeyRotation(); err == nil {
+		t.Error("expected announcing without a rotation to fail")
+	}
+	if err := client.node.RotateIdentity(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	announced, err := client.AnnounceKeyRotation()
+	if err != nil || announced != 1 {
+		t.Fatalf("expected one announcement, got %d %v", announced, err)
+	}
+
+	newKey := client.node.GetIdentity().PublicKey
+	deadline := time.Now().Add(2 * time.Second)
+	for peer := server.registry.GetPeer(clientID); peer == nil || peer.PublicKey != newKey; peer = server.registry.GetPeer(clientID) {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not apply the key rotation")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !server.registry.IsPublicKeyAllowed(newKey) || !server.registry.IsPublicKeyAllowed(oldKey) {
+		t.Error("expected the server to allow both keys during the grace period")
+	}
+}
@@ -10,14 +10,15 @@ import (
 // Protocol version constants
 const (
 	// ProtocolVersion is the current protocol version
-	ProtocolVersion = "1.0"
+	ProtocolVersion = "1.1"
 	// MinProtocolVersion is the minimum supported version
-	MinProtocolVersion = "1.0"
+	MinProtocolVersion = "1.1"
 )
 
 // SupportedProtocolVersions lists all protocol versions this node supports.
-// Used for version negotiation during handshake.
-var SupportedProtocolVersions = []string{"1.0"}
+// Used for version negotiation during handshake. 1.0 peers are not supported
+// since they don't send the handshake confirmation that prevents replays.
+var SupportedProtocolVersions = []string{"1.1"}
 
 // IsProtocolVersionSupported checks if a given version is supported.
 func IsProtocolVersionSupported(version string) bool {
@@ -34,26 +35,43 @@ type MessageType string
 
 const (
 	// Connection lifecycle
-	MsgHandshake    MessageType = "handshake"
-	MsgHandshakeAck MessageType = "handshake_ack"
-	MsgPing         MessageType = "ping"
-	MsgPong         MessageType = "pong"
-	MsgDisconnect   MessageType = "disconnect"
+	MsgHandshake        MessageType = "handshake"
+	MsgHandshakeAck     MessageType = "handshake_ack"
+	MsgHandshakeConfirm MessageType = "handshake_confirm"
+	MsgKeyRotation      MessageType = "key_rotation"
+	MsgPing             MessageType = "ping"
+	MsgPong             MessageType = "pong"
+	MsgDisconnect       MessageType = "disconnect"
 
 	// Miner operations
-	MsgGetStats   MessageType = "get_stats"
-	MsgStats      MessageType = "stats"
-	MsgStartMiner MessageType = "start_miner"
-	MsgStopMiner  MessageType = "stop_miner"
-	MsgMinerAck   MessageType = "miner_ack"
+	MsgGetStats    MessageType = "get_stats"
+	MsgStats       MessageType = "stats"
+	MsgStartMiner  MessageType = "start_miner"
+	MsgStopMiner   MessageType = "stop_miner"
+	MsgMinerAck    MessageType = "miner_ack"
+	MsgStatsConfig MessageType = "stats_config" // Start, retune or stop pushed stats
 
 	// Deployment
 	MsgDeploy    MessageType = "deploy"
 	MsgDeployAck MessageType = "deploy_ack"
 
 	// Logs
-	MsgGetLogs MessageType = "get_logs"
-	MsgLogs    MessageType = "logs"
+	MsgGetLogs   MessageType = "get_logs"
+	MsgLogs      MessageType = "logs"
+	MsgLogsChunk MessageType = "logs_chunk" // Part of a large page, ahead of its final MsgLogs
+
+	// Provisioning
+	MsgInstallMiner    MessageType = "install_miner"
+	MsgUninstallMiner  MessageType = "uninstall_miner"
+	MsgUpdateMiner     MessageType = "update_miner"
+	MsgInstallProgress MessageType = "install_progress"
+	MsgInstallAck      MessageType = "install_ack"
+
+	// Profiles
+	MsgListProfiles MessageType = "list_profiles"
+	MsgGetProfile   MessageType = "get_profile"
+	MsgProfiles     MessageType = "profiles"
+	MsgProfile      MessageType = "profile"
 
 	// Error response
 	MsgError MessageType = "error"
@@ -113,22 +131,40 @@ func (m *Message) ParsePayload(v interface{}) error {
 
 // HandshakePayload is sent during connection establishment.
 type HandshakePayload struct {
-	Identity  NodeIdentity `json:"identity"`
-	Challenge []byte       `json:"challenge,omitempty"` // Random bytes for auth
-	Version   string       `json:"version"`             // Protocol version
+	Identity     NodeIdentity `json:"identity"`
+	Challenge    []byte       `json:"challenge,omitempty"`    // Random bytes for auth
+	Version      string       `json:"version"`                // Protocol version
+	Capabilities []string     `json:"capabilities,omitempty"` // Optional features the node supports
 }
 
 // HandshakeAckPayload is the response to a handshake.
 type HandshakeAckPayload struct {
 	Identity          NodeIdentity `json:"identity"`
 	ChallengeResponse []byte       `json:"challengeResponse,omitempty"`
+	Nonce             []byte       `json:"nonce,omitempty"` // Fresh per upgrade, signed in the confirmation
 	Accepted          bool         `json:"accepted"`
 	Reason            string       `json:"reason,omitempty"` // If not accepted
+	Capabilities      []string     `json:"capabilities,omitempty"`
+}
+
+// HandshakeConfirmPayload completes a handshake: the client proves it derived
+// the shared secret for this upgrade by signing the server's nonce.
+type HandshakeConfirmPayload struct {
+	Signature []byte `json:"signature"` // SignHandshakeConfirm(challenge, nonce, sharedSecret)
+}
+
+// KeyRotationPayload announces a node's new public key to its peers.
+type KeyRotationPayload struct {
+	PublicKey         string    `json:"publicKey"`
+	PreviousPublicKey string    `json:"previousPublicKey"`
+	GraceUntil        time.Time `json:"graceUntil"` // Until when the previous key is still accepted
+	Proof             []byte    `json:"proof"`      // SignChallenge(PublicKey, secret derived with the previous key)
 }
 
 // PingPayload for keepalive/latency measurement.
 type PingPayload struct {
-	SentAt int64 `json:"sentAt"` // Unix timestamp in milliseconds
+	SentAt    int64 `json:"sentAt"`              // Unix timestamp in milliseconds
+	Keepalive bool  `json:"keepalive,omitempty"` // Answered by the transport rather than the worker
 }
 
 // PongPayload response to ping.
@@ -177,18 +213,31 @@ type StatsPayload struct {
 	Uptime   int64            `json:"uptime"` // Node uptime in seconds
 }
 
+// StatsConfigPayload asks a worker advertising CapabilityStatsPush to send
+// unsolicited MsgStats every IntervalMS milliseconds, or to stop when
+// Enabled is false. The worker replies with its current stats.
+type StatsConfigPayload struct {
+	Enabled    bool  `json:"enabled"`
+	IntervalMS int64 `json:"intervalMs,omitempty"` // 0 uses the worker's default
+}
+
 // GetLogsPayload requests console logs from a miner.
 type GetLogsPayload struct {
-	MinerName string `json:"minerName"`
-	Lines     int    `json:"lines"`           // Number of lines to fetch
-	Since     int64  `json:"since,omitempty"` // Unix timestamp, logs after this time
+	MinerName  string `json:"minerName"`
+	Lines      int    `json:"lines"`                // Number of lines to fetch (max MaxRemoteLogLines)
+	Since      int64  `json:"since,omitempty"`      // Unix timestamp, logs after this time
+	Offset     int    `json:"offset,omitempty"`     // Newest matching lines to skip, from the previous page's NextOffset
+	Grep       string `json:"grep,omitempty"`       // Regular expression lines must match, applied by the worker
+	IgnoreCase bool   `json:"ignoreCase,omitempty"` // Match Grep case-insensitively
+	Invert     bool   `json:"invert,omitempty"`     // Return lines that do NOT match Grep
 }
 
 // LogsPayload contains console log lines.
 type LogsPayload struct {
-	MinerName string   `json:"minerName"`
-	Lines     []string `json:"lines"`
-	HasMore   bool     `json:"hasMore"` // More logs available
+	MinerName  string   `json:"minerName"`
+	Lines      []string `json:"lines"`
+	HasMore    bool     `json:"hasMore"`              // Older matching lines are available
+	NextOffset int      `json:"nextOffset,omitempty"` // Offset of the next, older page
 }
 
 // DeployPayload contains a deployment bundle.
@@ -199,6 +248,42 @@ type DeployPayload struct {
 	Name       string `json:"name"`     // Profile or miner name
 }
 
+// InstallMinerPayload requests installing, uninstalling or updating a miner type.
+type InstallMinerPayload struct {
+	MinerType string `json:"minerType"`
+}
+
+// InstallProgressPayload reports a provisioning stage. It is sent as a reply
+// to the install/update request before the final InstallAckPayload.
+type InstallProgressPayload struct {
+	MinerType string `json:"minerType"`
+	Stage     string `json:"stage"` // e.g. "installing", "verifying", "up_to_date"
+	Message   string `json:"message,omitempty"`
+}
+
+// InstallAckPayload is the final result of a provisioning request.
+type InstallAckPayload struct {
+	Success   bool   `json:"success"`
+	MinerType string `json:"minerType"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// GetProfilePayload requests a single profile from a worker.
+type GetProfilePayload struct {
+	ProfileID string `json:"profileId"`
+}
+
+// ProfilesPayload contains a worker's profiles, with secrets masked.
+type ProfilesPayload struct {
+	Profiles []json.RawMessage `json:"profiles"`
+}
+
+// ProfilePayload contains a single profile, with secrets masked.
+type ProfilePayload struct {
+	Profile json.RawMessage `json:"profile"`
+}
+
 // DeployAckPayload acknowledges a deployment.
 type DeployAckPayload struct {
 	Success bool   `json:"success"`
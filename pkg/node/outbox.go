@@ -0,0 +1,270 @@
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+	"github.com/adrg/xdg"
+)
+
+// DefaultOutboxTTL is how long a queued command stays deliverable. Older
+// commands are dropped rather than fired long after they were issued.
+const DefaultOutboxTTL = 10 * time.Minute
+
+// maxQueuedPerPeer bounds the outbox for a peer that never comes back.
+const maxQueuedPerPeer = 100
+
+// QueuedCommand is a control command waiting for its peer to reconnect.
+type QueuedCommand struct {
+	ID        string          `json:"id"`  // Message ID used on delivery, so the worker deduplicates retries
+	Key       string          `json:"key"` // Idempotency key: identical commands are queued once, at their latest position
+	PeerID    string          `json:"peerId"`
+	Type      MessageType     `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	QueuedAt  time.Time       `json:"queuedAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// CommandQueuedError is returned by controller commands that were accepted
+// into the outbox because the peer was offline.
+type CommandQueuedError struct {
+	Command QueuedCommand
+}
+
+func (e *CommandQueuedError) Error() string {
+	return fmt.Sprintf("peer %s is offline: %s queued until %s", e.Command.PeerID, e.Command.Type, e.Command.ExpiresAt.Format(time.RFC3339))
+}
+
+// Outbox persists control commands for offline peers and hands them back
+// for delivery when the peer reconnects.
+type Outbox struct {
+	mu         sync.Mutex
+	commands   map[string][]QueuedCommand // peer ID -> commands in queue order
+	delivering map[string]bool            // peer IDs with a delivery in progress
+	ttl        time.Duration
+	path       string
+}
+
+// NewOutbox creates an Outbox, loading commands queued before a restart.
+func NewOutbox() (*Outbox, error) {
+	outboxPath, err := xdg.ConfigFile("lethean-desktop/outbox.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox path: %w", err)
+	}
+
+	return NewOutboxWithPath(outboxPath, DefaultOutboxTTL)
+}
+
+// NewOutboxWithPath creates an Outbox stored at a custom path with the given TTL.
+func NewOutboxWithPath(outboxPath string, ttl time.Duration) (*Outbox, error) {
+	o := &Outbox{
+		commands:   make(map[string][]QueuedCommand),
+		delivering: make(map[string]bool),
+		ttl:        ttl,
+		path:       outboxPath,
+	}
+
+	data, err := os.ReadFile(outboxPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return o, nil
+		}
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	var commands []QueuedCommand
+	if err := json.Unmarshal(data, &commands); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal outbox: %w", err)
+	}
+	for _, cmd := range commands {
+		o.commands[cmd.PeerID] = append(o.commands[cmd.PeerID], cmd)
+	}
+	return o, nil
+}
+
+// Enqueue queues msg for peerID. A command identical to the last one queued
+// for the peer is not queued twice; the existing entry is returned instead.
+// An identical command further back is moved to the tail, so the peer still
+// applies commands in the order they were last given (start, stop, start
+// leaves stop then start).
+func (o *Outbox) Enqueue(peerID string, msg *Message) (*QueuedCommand, error) {
+	sum := sha256.Sum256(append([]byte(msg.Type+":"), msg.Payload...))
+	key := hex.EncodeToString(sum[:8])
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.pruneLocked(peerID, time.Now())
+	queue := o.commands[peerID]
+	if n := len(queue); n > 0 && queue[n-1].Key == key {
+		cmd := queue[n-1]
+		return &cmd, nil
+	}
+
+	now := time.Now()
+	cmd := QueuedCommand{
+		ID:        msg.ID,
+		Key:       key,
+		PeerID:    peerID,
+		Type:      msg.Type,
+		Payload:   msg.Payload,
+		QueuedAt:  now,
+		ExpiresAt: now.Add(o.ttl),
+	}
+	updated := make([]QueuedCommand, 0, len(queue)+1)
+	for _, queued := range queue {
+		if queued.Key == key {
+			cmd.ID = queued.ID // Keep the ID callers already hold
+			continue
+		}
+		updated = append(updated, queued)
+	}
+	if len(updated) >= maxQueuedPerPeer {
+		return nil, fmt.Errorf("outbox for peer %s is full", peerID)
+	}
+
+	o.commands[peerID] = append(updated, cmd)
+	if err := o.saveLocked(); err != nil {
+		o.commands[peerID] = queue // Rollback
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+// Pending returns the unexpired commands for peerID in queue order,
+// discarding any that have expired.
+func (o *Outbox) Pending(peerID string) []QueuedCommand {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.pruneLocked(peerID, time.Now()) {
+		if err := o.saveLocked(); err != nil {
+			logging.Warn("failed to save outbox", logging.Fields{"error": err})
+		}
+	}
+	return append([]QueuedCommand(nil), o.commands[peerID]...)
+}
+
+// Remove deletes a delivered command.
+func (o *Outbox) Remove(peerID, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queue := o.commands[peerID]
+	for i := range queue {
+		if queue[i].ID == id {
+			o.commands[peerID] = append(queue[:i:i], queue[i+1:]...)
+			if len(o.commands[peerID]) == 0 {
+				delete(o.commands, peerID)
+			}
+			return o.saveLocked()
+		}
+	}
+	return nil
+}
+
+// Clear discards all commands queued for peerID and returns how many were dropped.
+func (o *Outbox) Clear(peerID string) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	n := len(o.commands[peerID])
+	if n == 0 {
+		return 0, nil
+	}
+	delete(o.commands, peerID)
+	return n, o.saveLocked()
+}
+
+// Counts returns the number of unexpired queued commands per peer.
+func (o *Outbox) Counts() map[string]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	counts := make(map[string]int, len(o.commands))
+	for peerID, queue := range o.commands {
+		for _, cmd := range queue {
+			if now.Before(cmd.ExpiresAt) {
+				counts[peerID]++
+			}
+		}
+	}
+	return counts
+}
+
+// beginDelivery marks peerID as being delivered to. It returns false if a
+// delivery is already in progress, so a reconnect storm delivers once.
+func (o *Outbox) beginDelivery(peerID string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.delivering[peerID] {
+		return false
+	}
+	o.delivering[peerID] = true
+	return true
+}
+
+func (o *Outbox) endDelivery(peerID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.delivering, peerID)
+}
+
+// pruneLocked drops expired commands for peerID, reporting whether any were dropped.
+// Must be called with o.mu held.
+func (o *Outbox) pruneLocked(peerID string, now time.Time) bool {
+	queue := o.commands[peerID]
+	kept := queue[:0:0]
+	for _, cmd := range queue {
+		if now.Before(cmd.ExpiresAt) {
+			kept = append(kept, cmd)
+		}
+	}
+	if len(kept) == len(queue) {
+		return false
+	}
+	if len(kept) == 0 {
+		delete(o.commands, peerID)
+	} else {
+		o.commands[peerID] = kept
+	}
+	return true
+}
+
+// saveLocked persists the outbox. Must be called with o.mu held.
+func (o *Outbox) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+		return fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	commands := make([]QueuedCommand, 0)
+	for _, queue := range o.commands {
+		commands = append(commands, queue...)
+	}
+	sort.SliceStable(commands, func(i, j int) bool { return commands[i].QueuedAt.Before(commands[j].QueuedAt) })
+
+	data, err := json.MarshalIndent(commands, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox: %w", err)
+	}
+
+	// Use atomic write pattern: write to temp file, then rename
+	tmpPath := o.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write outbox temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, o.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename outbox file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package node
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutbox_EnqueueDedupAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	o, err := NewOutboxWithPath(path, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create outbox: %v", err)
+	}
+
+	start, _ := NewMessage(MsgStartMiner, "controller", "peer-1", StartMinerPayload{MinerType: "xmrig", ProfileID: "p1"})
+	cmd, err := o.Enqueue("peer-1", start)
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if cmd.ID != start.ID {
+		t.Errorf("expected queued command to keep message ID %s, got %s", start.ID, cmd.ID)
+	}
+
+	// Same command with a new message ID is deduplicated by its idempotency key
+	again, _ := NewMessage(MsgStartMiner, "controller", "peer-1", StartMinerPayload{MinerType: "xmrig", ProfileID: "p1"})
+	if dup, err := o.Enqueue("peer-1", again); err != nil || dup.ID != start.ID {
+		t.Errorf("expected duplicate to return the queued command, got %+v, %v", dup, err)
+	}
+
+	stop, _ := NewMessage(MsgStopMiner, "controller", "peer-1", StopMinerPayload{MinerName: "xmrig-rx_0"})
+	if _, err := o.Enqueue("peer-1", stop); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if counts := o.Counts(); counts["peer-1"] != 2 {
+		t.Errorf("expected 2 queued commands, got %v", counts)
+	}
+
+	reloaded, err := NewOutboxWithPath(path, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to reload outbox: %v", err)
+	}
+	pending := reloaded.Pending("peer-1")
+	if len(pending) != 2 || pending[0].Type != MsgStartMiner || pending[1].Type != MsgStopMiner {
+		t.Fatalf("expected start then stop after reload, got %+v", pending)
+	}
+
+	if err := reloaded.Remove("peer-1", pending[0].ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if n, err := reloaded.Clear("peer-1"); err != nil || n != 1 {
+		t.Errorf("expected Clear to drop 1 command, got %d, %v", n, err)
+	}
+	if len(reloaded.Counts()) != 0 {
+		t.Errorf("expected empty outbox, got %v", reloaded.Counts())
+	}
+}
+
+func TestOutbox_RepeatedCommandKeepsOrder(t *testing.T) {
+	o, err := NewOutboxWithPath(filepath.Join(t.TempDir(), "outbox.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create outbox: %v", err)
+	}
+
+	start, _ := NewMessage(MsgStartMiner, "controller", "peer-1", StartMinerPayload{MinerType: "xmrig", ProfileID: "p1"})
+	stop, _ := NewMessage(MsgStopMiner, "controller", "peer-1", StopMinerPayload{MinerName: "xmrig-rx_0"})
+	startAgain, _ := NewMessage(MsgStartMiner, "controller", "peer-1", StartMinerPayload{MinerType: "xmrig", ProfileID: "p1"})
+	for _, msg := range []*Message{start, stop, startAgain} {
+		if _, err := o.Enqueue("peer-1", msg); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	// The peer must end up mining, so the repeated start goes after the stop
+	pending := o.Pending("peer-1")
+	if len(pending) != 2 || pending[0].Type != MsgStopMiner || pending[1].Type != MsgStartMiner {
+		t.Fatalf("expected stop then start, got %+v", pending)
+	}
+	if pending[1].ID != start.ID {
+		t.Errorf("expected the moved command to keep ID %s, got %s", start.ID, pending[1].ID)
+	}
+}
+
+func TestOutbox_ExpiredCommandsDropped(t *testing.T) {
+	o, err := NewOutboxWithPath(filepath.Join(t.TempDir(), "outbox.json"), time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create outbox: %v", err)
+	}
+
+	msg, _ := NewMessage(MsgStopMiner, "controller", "peer-1", StopMinerPayload{MinerName: "m"})
+	if _, err := o.Enqueue("peer-1", msg); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if counts := o.Counts(); counts["peer-1"] != 0 {
+		t.Errorf("expired command still counted: %v", counts)
+	}
+	if pending := o.Pending("peer-1"); len(pending) != 0 {
+		t.Errorf("expired command still pending: %+v", pending)
+	}
+}
+
+func TestController_StartMinerOnTagQueuesOfflinePeers(t *testing.T) {
+	nm, nmCleanup := setupTestNodeManager(t)
+	defer nmCleanup()
+	if err := nm.GenerateIdentity("controller", RoleController); err != nil {
+		t.Fatalf("failed to generate identity: %v", err)
+	}
+	pr, prCleanup := setupTestPeerRegistry(t)
+	defer prCleanup()
+	transport := NewTransport(nm, pr, DefaultTransportConfig())
+
+	controller := NewController(nm, pr, transport)
+	outbox, err := NewOutboxWithPath(filepath.Join(t.TempDir(), "outbox.json"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller.SetOutbox(outbox)
+
+	// Nothing listens on port 1, so every tagged peer is offline
+	pr.AddPeer(&Peer{ID: "rig-2", Name: "rig-2", Address: "127.0.0.1:1", Role: RoleWorker, Tags: []string{"gpu-farm"}})
+	pr.AddPeer(&Peer{ID: "rig-1", Name: "rig-1", Address: "127.0.0.1:1", Role: RoleWorker, Tags: []string{"gpu-farm"}})
+	pr.AddPeer(&Peer{ID: "cpu-1", Name: "cpu-1", Address: "127.0.0.1:1", Role: RoleWorker})
+
+	results := controller.StartMinerOnTag("gpu-farm", "xmrig", "profile-1", nil)
+	if len(results) != 2 || results[0].PeerID != "rig-1" || results[1].PeerID != "rig-2" {
+		t.Fatalf("expected results for rig-1 and rig-2 in order, got %+v", results)
+	}
+	for _, r := range results {
+		if !r.Queued || r.Error != "" {
+			t.Errorf("expected %s queued, got %+v", r.PeerID, r)
+		}
+	}
+	if counts := outbox.Counts(); counts["rig-1"] != 1 || counts["rig-2"] != 1 || counts["cpu-1"] != 0 {
+		t.Errorf("unexpected outbox counts %v", counts)
+	}
+
+	if results := controller.StopMinerOnTag("no-such-tag", "xmrig"); len(results) != 0 {
+		t.Errorf("expected no results for an unused tag, got %+v", results)
+	}
+}
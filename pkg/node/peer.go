@@ -3,9 +3,11 @@ package node
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,13 +18,20 @@ import (
 
 // Peer represents a known remote node.
 type Peer struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	PublicKey string    `json:"publicKey"`
-	Address   string    `json:"address"` // host:port for WebSocket connection
-	Role      NodeRole  `json:"role"`
-	AddedAt   time.Time `json:"addedAt"`
-	LastSeen  time.Time `json:"lastSeen"`
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	PublicKey string   `json:"publicKey"`
+	Address   string   `json:"address"` // host:port for WebSocket connection
+	Role      NodeRole `json:"role"`
+	Tags      []string `json:"tags,omitempty"` // Operator-defined groups, e.g. "gpu-farm"
+	// TLSFingerprint pins the SHA-256 of the peer's TLS certificate (hex), checked when dialing wss://
+	TLSFingerprint string    `json:"tlsFingerprint,omitempty"`
+	AddedAt        time.Time `json:"addedAt"`
+	LastSeen       time.Time `json:"lastSeen"`
+
+	// Set when the peer announces a key rotation
+	PreviousPublicKey    string     `json:"previousPublicKey,omitempty"`
+	PreviousKeyExpiresAt *time.Time `json:"previousKeyExpiresAt,omitempty"`
 
 	// Poindexter metrics (updated dynamically)
 	PingMS float64 `json:"pingMs"` // Latency in milliseconds
@@ -34,6 +43,16 @@ type Peer struct {
 	Connected bool `json:"-"`
 }
 
+// HasTag reports whether the peer is in the group tag.
+func (p *Peer) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // saveDebounceInterval is the minimum time between disk writes.
 const saveDebounceInterval = 5 * time.Second
 
@@ -56,6 +75,26 @@ const (
 // peerNameRegex validates peer names: alphanumeric, hyphens, underscores, and spaces
 var peerNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9\-_ ]{0,62}[a-zA-Z0-9]$|^[a-zA-Z0-9]$`)
 
+// peerTagRegex validates peer tags: alphanumeric, dots, hyphens and underscores
+var peerTagRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
+
+// NormalizePeerTags validates tags and returns them deduplicated and sorted.
+func NormalizePeerTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !peerTagRegex.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: must be 1-64 alphanumeric characters, dots, hyphens or underscores", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
 // safeKeyPrefix returns a truncated key for logging, handling short keys safely
 func safeKeyPrefix(key string) string {
 	if len(key) >= 16 {
@@ -88,15 +127,17 @@ func validatePeerName(name string) error {
 
 // PeerRegistry manages known peers with KD-tree based selection.
 type PeerRegistry struct {
-	peers  map[string]*Peer
-	kdTree *poindexter.KDTree[string] // KD-tree with peer ID as payload
-	path   string
-	mu     sync.RWMutex
+	peers   map[string]*Peer
+	kdTree  *poindexter.KDTree[string] // KD-tree with peer ID as payload
+	weights SelectionWeights           // Dimension weights for the KD-tree
+	path    string
+	mu      sync.RWMutex
 
 	// Authentication settings
-	authMode           PeerAuthMode    // How to handle unknown peers
-	allowedPublicKeys  map[string]bool // Allowlist of public keys (when authMode is Allowlist)
-	allowedPublicKeyMu sync.RWMutex    // Protects allowedPublicKeys
+	authMode           PeerAuthMode         // How to handle unknown peers
+	allowedPublicKeys  map[string]bool      // Allowlist of public keys (when authMode is Allowlist)
+	allowedKeyExpiry   map[string]time.Time // Rotated-out keys, allowed until their grace period ends
+	allowedPublicKeyMu sync.RWMutex         // Protects allowedPublicKeys and allowedKeyExpiry
 
 	// Debounce disk writes
 	dirty        bool          // Whether there are unsaved changes
@@ -106,14 +147,34 @@ type PeerRegistry struct {
 	saveStopOnce sync.Once     // Ensure stopChan is closed only once
 }
 
-// Dimension weights for peer selection
-// Lower ping, hops, geo are better; higher score is better
-var (
-	pingWeight  = 1.0
-	hopsWeight  = 0.7
-	geoWeight   = 0.2
-	scoreWeight = 1.2
-)
+// SelectionWeights scale each dimension of peer selection.
+// Lower ping, hops, geo are better; higher score is better. A larger weight
+// makes differences in that dimension count for more.
+type SelectionWeights struct {
+	Ping  float64 `json:"ping"`
+	Hops  float64 `json:"hops"`
+	Geo   float64 `json:"geo"`
+	Score float64 `json:"score"`
+}
+
+// DefaultSelectionWeights returns the default dimension weights.
+func DefaultSelectionWeights() SelectionWeights {
+	return SelectionWeights{Ping: 1.0, Hops: 0.7, Geo: 0.2, Score: 1.2}
+}
+
+// Validate checks that every weight is a non-negative number.
+func (w SelectionWeights) Validate() error {
+	weights := []struct {
+		name  string
+		value float64
+	}{{"ping", w.Ping}, {"hops", w.Hops}, {"geo", w.Geo}, {"score", w.Score}}
+	for _, weight := range weights {
+		if weight.value < 0 || math.IsNaN(weight.value) || math.IsInf(weight.value, 0) {
+			return fmt.Errorf("invalid %s weight %v: must be a non-negative number", weight.name, weight.value)
+		}
+	}
+	return nil
+}
 
 // NewPeerRegistry creates a new PeerRegistry, loading existing peers if available.
 func NewPeerRegistry() (*PeerRegistry, error) {
@@ -134,6 +195,13 @@ func NewPeerRegistryWithPath(peersPath string) (*PeerRegistry, error) {
 		stopChan:          make(chan struct{}),
 		authMode:          PeerAuthOpen, // Default to open for backward compatibility
 		allowedPublicKeys: make(map[string]bool),
+		allowedKeyExpiry:  make(map[string]time.Time),
+		weights:           DefaultSelectionWeights(),
+	}
+
+	// The allowlist must not silently fall back to open mode
+	if err := pr.loadAuth(); err != nil {
+		return nil, err
 	}
 
 	// Try to load existing peers
@@ -147,12 +215,34 @@ func NewPeerRegistryWithPath(peersPath string) (*PeerRegistry, error) {
 	return pr, nil
 }
 
-// SetAuthMode sets the authentication mode for peer connections.
-func (r *PeerRegistry) SetAuthMode(mode PeerAuthMode) {
+// SetSelectionWeights sets the dimension weights used for peer selection and
+// rebuilds the KD-tree so they take effect immediately.
+func (r *PeerRegistry) SetSelectionWeights(weights SelectionWeights) error {
+	if err := weights.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.weights = weights
+	r.rebuildKDTree()
+	return nil
+}
+
+// GetSelectionWeights returns the dimension weights used for peer selection.
+func (r *PeerRegistry) GetSelectionWeights() SelectionWeights {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.weights
+}
+
+// SetAuthMode sets and saves the authentication mode for peer connections.
+func (r *PeerRegistry) SetAuthMode(mode PeerAuthMode) error {
 	r.allowedPublicKeyMu.Lock()
 	defer r.allowedPublicKeyMu.Unlock()
 	r.authMode = mode
-	logging.Info("peer auth mode changed", logging.Fields{"mode": mode})
+	logging.Info("peer auth mode changed", logging.Fields{"mode": mode.String()})
+	return r.saveAuthLocked()
 }
 
 // GetAuthMode returns the current authentication mode.
@@ -162,26 +252,39 @@ func (r *PeerRegistry) GetAuthMode() PeerAuthMode {
 	return r.authMode
 }
 
-// AllowPublicKey adds a public key to the allowlist.
-func (r *PeerRegistry) AllowPublicKey(publicKey string) {
+// AllowPublicKey adds a public key to the allowlist and saves it.
+func (r *PeerRegistry) AllowPublicKey(publicKey string) error {
 	r.allowedPublicKeyMu.Lock()
 	defer r.allowedPublicKeyMu.Unlock()
 	r.allowedPublicKeys[publicKey] = true
+	delete(r.allowedKeyExpiry, publicKey)
 	logging.Debug("public key added to allowlist", logging.Fields{"key": safeKeyPrefix(publicKey)})
+	return r.saveAuthLocked()
 }
 
-// RevokePublicKey removes a public key from the allowlist.
-func (r *PeerRegistry) RevokePublicKey(publicKey string) {
+// RevokePublicKey removes a public key from the allowlist and saves it.
+func (r *PeerRegistry) RevokePublicKey(publicKey string) error {
 	r.allowedPublicKeyMu.Lock()
 	defer r.allowedPublicKeyMu.Unlock()
 	delete(r.allowedPublicKeys, publicKey)
+	delete(r.allowedKeyExpiry, publicKey)
 	logging.Debug("public key removed from allowlist", logging.Fields{"key": safeKeyPrefix(publicKey)})
+	return r.saveAuthLocked()
 }
 
 // IsPublicKeyAllowed checks if a public key is in the allowlist.
 func (r *PeerRegistry) IsPublicKeyAllowed(publicKey string) bool {
 	r.allowedPublicKeyMu.RLock()
 	defer r.allowedPublicKeyMu.RUnlock()
+	return r.keyAllowedLocked(publicKey)
+}
+
+// keyAllowedLocked reports whether a key is allowlisted and, if it was
+// rotated out, still within its grace period. Caller must hold allowedPublicKeyMu.
+func (r *PeerRegistry) keyAllowedLocked(publicKey string) bool {
+	if expiry, rotated := r.allowedKeyExpiry[publicKey]; rotated && time.Now().After(expiry) {
+		return false
+	}
 	return r.allowedPublicKeys[publicKey]
 }
 
@@ -192,7 +295,7 @@ func (r *PeerRegistry) IsPublicKeyAllowed(publicKey string) bool {
 func (r *PeerRegistry) IsPeerAllowed(peerID string, publicKey string) bool {
 	r.allowedPublicKeyMu.RLock()
 	authMode := r.authMode
-	keyAllowed := r.allowedPublicKeys[publicKey]
+	keyAllowed := r.keyAllowedLocked(publicKey)
 	r.allowedPublicKeyMu.RUnlock()
 
 	// Open mode allows everyone
@@ -220,8 +323,11 @@ func (r *PeerRegistry) ListAllowedPublicKeys() []string {
 
 	keys := make([]string, 0, len(r.allowedPublicKeys))
 	for key := range r.allowedPublicKeys {
-		keys = append(keys, key)
+		if r.keyAllowedLocked(key) {
+			keys = append(keys, key)
+		}
 	}
+	sort.Strings(keys)
 	return keys
 }
 
@@ -269,7 +375,7 @@ func (r *PeerRegistry) UpdatePeer(peer *Peer) error {
 
 	if _, exists := r.peers[peer.ID]; !exists {
 		r.mu.Unlock()
-		return fmt.Errorf("peer %s not found", peer.ID)
+		return errPeerNotFound(peer.ID)
 	}
 
 	r.peers[peer.ID] = peer
@@ -286,7 +392,7 @@ func (r *PeerRegistry) RemovePeer(id string) error {
 
 	if _, exists := r.peers[id]; !exists {
 		r.mu.Unlock()
-		return fmt.Errorf("peer %s not found", id)
+		return errPeerNotFound(id)
 	}
 
 	delete(r.peers, id)
@@ -324,6 +430,79 @@ func (r *PeerRegistry) ListPeers() []*Peer {
 	return peers
 }
 
+// SetTags replaces a peer's tags.
+func (r *PeerRegistry) SetTags(id string, tags []string) error {
+	normalized, err := NormalizePeerTags(tags)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	peer, exists := r.peers[id]
+	if !exists {
+		r.mu.Unlock()
+		return errPeerNotFound(id)
+	}
+	// Replace rather than modify the slice, since copies handed out share it
+	peer.Tags = normalized
+	r.mu.Unlock()
+
+	return r.save()
+}
+
+// AddTag adds a peer to the group tag.
+func (r *PeerRegistry) AddTag(id, tag string) error {
+	peer := r.GetPeer(id)
+	if peer == nil {
+		return errPeerNotFound(id)
+	}
+	return r.SetTags(id, append(append([]string{}, peer.Tags...), tag))
+}
+
+// RemoveTag removes a peer from the group tag.
+func (r *PeerRegistry) RemoveTag(id, tag string) error {
+	peer := r.GetPeer(id)
+	if peer == nil {
+		return errPeerNotFound(id)
+	}
+	tags := make([]string, 0, len(peer.Tags))
+	for _, t := range peer.Tags {
+		if t != tag {
+			tags = append(tags, t)
+		}
+	}
+	return r.SetTags(id, tags)
+}
+
+// ListPeersByTag returns the peers in the group tag.
+func (r *PeerRegistry) ListPeersByTag(tag string) []*Peer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	peers := make([]*Peer, 0)
+	for _, peer := range r.peers {
+		if peer.HasTag(tag) {
+			peerCopy := *peer
+			peers = append(peers, &peerCopy)
+		}
+	}
+	return peers
+}
+
+// TagCounts returns every tag in use with the number of peers carrying it.
+func (r *PeerRegistry) TagCounts() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, peer := range r.peers {
+		for _, tag := range peer.Tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
 // UpdateMetrics updates a peer's performance metrics.
 // Note: Persistence is debounced. Call Close() to flush before shutdown.
 func (r *PeerRegistry) UpdateMetrics(id string, pingMS, geoKM float64, hops int) error {
@@ -332,7 +511,7 @@ func (r *PeerRegistry) UpdateMetrics(id string, pingMS, geoKM float64, hops int)
 	peer, exists := r.peers[id]
 	if !exists {
 		r.mu.Unlock()
-		return fmt.Errorf("peer %s not found", id)
+		return errPeerNotFound(id)
 	}
 
 	peer.PingMS = pingMS
@@ -354,7 +533,7 @@ func (r *PeerRegistry) UpdateScore(id string, score float64) error {
 	peer, exists := r.peers[id]
 	if !exists {
 		r.mu.Unlock()
-		return fmt.Errorf("peer %s not found", id)
+		return errPeerNotFound(id)
 	}
 
 	// Clamp score to 0-100
@@ -526,6 +705,47 @@ func (r *PeerRegistry) SelectNearestPeers(n int) []*Peer {
 	return peers
 }
 
+// PeerSelection is a peer chosen by SelectPeers with the weighted distance
+// from the ideal peer (0 ping, 0 hops, 0 km, score 100) it was ranked by.
+type PeerSelection struct {
+	Peer     *Peer   `json:"peer"`
+	Distance float64 `json:"distance"` // Lower is better
+}
+
+// SelectPeers returns up to n peers accepted by filter, best first. filter
+// may be nil, and is called without the registry lock held.
+func (r *PeerRegistry) SelectPeers(n int, filter func(*Peer) bool) []PeerSelection {
+	r.mu.RLock()
+	if r.kdTree == nil || len(r.peers) == 0 || n <= 0 {
+		r.mu.RUnlock()
+		return nil
+	}
+
+	// Rank every peer so filtering can't leave fewer than n when enough qualify
+	target := []float64{0, 0, 0, 0}
+	results, distances := r.kdTree.KNearest(target, len(r.peers))
+	ranked := make([]PeerSelection, 0, len(results))
+	for i, result := range results {
+		if peer, exists := r.peers[result.Value]; exists {
+			peerCopy := *peer
+			ranked = append(ranked, PeerSelection{Peer: &peerCopy, Distance: distances[i]})
+		}
+	}
+	r.mu.RUnlock()
+
+	selected := make([]PeerSelection, 0, n)
+	for _, selection := range ranked {
+		if filter != nil && !filter(selection.Peer) {
+			continue
+		}
+		selected = append(selected, selection)
+		if len(selected) == n {
+			break
+		}
+	}
+	return selected
+}
+
 // GetConnectedPeers returns all currently connected peers.
 func (r *PeerRegistry) GetConnectedPeers() []*Peer {
 	r.mu.RLock()
@@ -563,10 +783,10 @@ func (r *PeerRegistry) rebuildKDTree() {
 		point := poindexter.KDPoint[string]{
 			ID: peer.ID,
 			Coords: []float64{
-				peer.PingMS * pingWeight,
-				float64(peer.Hops) * hopsWeight,
-				peer.GeoKM * geoWeight,
-				(100 - peer.Score) * scoreWeight, // Invert score
+				peer.PingMS * r.weights.Ping,
+				float64(peer.Hops) * r.weights.Hops,
+				peer.GeoKM * r.weights.Geo,
+				(100 - peer.Score) * r.weights.Score, // Invert score
 			},
 			Value: peer.ID,
 		}
@@ -0,0 +1,139 @@
+package node
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// peerAuthFileName is the file holding the auth mode and allowlist, kept next
+// to peers.json so the CLI and a running node share them.
+const peerAuthFileName = "peer-auth.json"
+
+// publicKeySize is the length of an X25519 public key.
+const publicKeySize = 32
+
+// ValidatePublicKey checks publicKey is a base64 X25519 public key, the form
+// shown by 'node info' and in a peer's identity.
+func ValidatePublicKey(publicKey string) error {
+	raw, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("public key is not valid base64: %w", err)
+	}
+	if len(raw) != publicKeySize {
+		return fmt.Errorf("public key must be %d bytes, got %d", publicKeySize, len(raw))
+	}
+	return nil
+}
+
+// String returns "open" or "allowlist".
+func (m PeerAuthMode) String() string {
+	if m == PeerAuthAllowlist {
+		return "allowlist"
+	}
+	return "open"
+}
+
+// ParsePeerAuthMode parses "open" or "allowlist".
+func ParsePeerAuthMode(s string) (PeerAuthMode, error) {
+	switch s {
+	case "open":
+		return PeerAuthOpen, nil
+	case "allowlist":
+		return PeerAuthAllowlist, nil
+	}
+	return PeerAuthOpen, fmt.Errorf("invalid auth mode %q: must be 'open' or 'allowlist'", s)
+}
+
+// peerAuthState is the on-disk form of the auth settings.
+type peerAuthState struct {
+	Mode        string               `json:"mode"`
+	AllowedKeys []string             `json:"allowedKeys"`
+	KeyExpiry   map[string]time.Time `json:"keyExpiry,omitempty"` // Rotated-out keys and when their grace period ends
+}
+
+// authPath returns the auth settings file, or "" for a registry without a path.
+func (r *PeerRegistry) authPath() string {
+	if r.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(r.path), peerAuthFileName)
+}
+
+// loadAuth reads the auth settings. A missing file leaves the defaults.
+func (r *PeerRegistry) loadAuth() error {
+	path := r.authPath()
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read peer auth settings: %w", err)
+	}
+
+	var state peerAuthState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse peer auth settings: %w", err)
+	}
+	mode, err := ParsePeerAuthMode(state.Mode)
+	if err != nil {
+		return err
+	}
+
+	r.allowedPublicKeyMu.Lock()
+	defer r.allowedPublicKeyMu.Unlock()
+	r.authMode = mode
+	for _, key := range state.AllowedKeys {
+		r.allowedPublicKeys[key] = true
+	}
+	for key, expiry := range state.KeyExpiry {
+		r.allowedKeyExpiry[key] = expiry
+	}
+	return nil
+}
+
+// saveAuthLocked writes the auth settings. Changes are rare and security
+// relevant, so they're written straight away rather than debounced.
+// Caller must hold allowedPublicKeyMu.
+func (r *PeerRegistry) saveAuthLocked() error {
+	path := r.authPath()
+	if path == "" {
+		return nil
+	}
+
+	state := peerAuthState{Mode: r.authMode.String(), AllowedKeys: make([]string, 0, len(r.allowedPublicKeys))}
+	for key := range r.allowedPublicKeys {
+		state.AllowedKeys = append(state.AllowedKeys, key)
+	}
+	sort.Strings(state.AllowedKeys)
+	if len(r.allowedKeyExpiry) > 0 {
+		state.KeyExpiry = make(map[string]time.Time, len(r.allowedKeyExpiry))
+		for key, expiry := range r.allowedKeyExpiry {
+			state.KeyExpiry[key] = expiry
+		}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal peer auth settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create peers directory: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write peer auth settings: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save peer auth settings: %w", err)
+	}
+	return nil
+}
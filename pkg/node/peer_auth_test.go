@@ -0,0 +1,85 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePeerAuthMode(t *testing.T) {
+	for _, mode := range []PeerAuthMode{PeerAuthOpen, PeerAuthAllowlist} {
+		parsed, err := ParsePeerAuthMode(mode.String())
+		if err != nil || parsed != mode {
+			t.Errorf("expected %s to round trip, got %v, %v", mode, parsed, err)
+		}
+	}
+	if _, err := ParsePeerAuthMode("closed"); err == nil {
+		t.Error("expected an invalid mode to be rejected")
+	}
+}
+
+func TestValidatePublicKey(t *testing.T) {
+	nm, cleanup := setupTestNodeManager(t)
+	defer cleanup()
+	if err := nm.GenerateIdentity("key-check", RoleDual); err != nil {
+		t.Fatalf("failed to generate identity: %v", err)
+	}
+	if err := ValidatePublicKey(nm.GetIdentity().PublicKey); err != nil {
+		t.Errorf("expected a node's own key to be valid, got %v", err)
+	}
+	for _, key := range []string{"", "not base64!", "c2hvcnQ="} {
+		if err := ValidatePublicKey(key); err == nil {
+			t.Errorf("expected %q to be rejected", key)
+		}
+	}
+}
+
+func TestPeerRegistry_AuthPersistence(t *testing.T) {
+	peersPath := filepath.Join(t.TempDir(), "peers.json")
+	pr, err := NewPeerRegistryWithPath(peersPath)
+	if err != nil {
+		t.Fatalf("failed to create peer registry: %v", err)
+	}
+
+	if err := pr.SetAuthMode(PeerAuthAllowlist); err != nil {
+		t.Fatalf("SetAuthMode failed: %v", err)
+	}
+	for _, key := range []string{"key-b", "key-a", "key-c"} {
+		if err := pr.AllowPublicKey(key); err != nil {
+			t.Fatalf("AllowPublicKey failed: %v", err)
+		}
+	}
+	if err := pr.RevokePublicKey("key-c"); err != nil {
+		t.Fatalf("RevokePublicKey failed: %v", err)
+	}
+	// A rotated-out key keeps its grace period across a restart
+	pr.allowedPublicKeyMu.Lock()
+	pr.allowedKeyExpiry["key-b"] = time.Now().Add(-time.Minute)
+	pr.saveAuthLocked()
+	pr.allowedPublicKeyMu.Unlock()
+	pr.Close()
+
+	reloaded, err := NewPeerRegistryWithPath(peersPath)
+	if err != nil {
+		t.Fatalf("failed to reload peer registry: %v", err)
+	}
+	if reloaded.GetAuthMode() != PeerAuthAllowlist {
+		t.Errorf("expected allowlist mode after reload, got %s", reloaded.GetAuthMode())
+	}
+	keys := reloaded.ListAllowedPublicKeys()
+	if len(keys) != 1 || keys[0] != "key-a" {
+		t.Errorf("expected only key-a allowed after reload, got %v", keys)
+	}
+}
+
+func TestPeerRegistry_CorruptAuthFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, peerAuthFileName), []byte(`{"mode": "closed"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Falling back to open mode would silently drop the allowlist
+	if _, err := NewPeerRegistryWithPath(filepath.Join(dir, "peers.json")); err == nil {
+		t.Error("expected an invalid auth file to fail loudly")
+	}
+}
@@ -637,3 +637,122 @@ func TestPeerRegistry_GetPeersByScore(t *testing.T) {
 		t.Errorf("third peer should be low-score, got %s", sorted[2].ID)
 	}
 }
+
+func TestPeerRegistry_SelectionWeights(t *testing.T) {
+	pr, cleanup := setupTestPeerRegistry(t)
+	defer cleanup()
+
+	if pr.GetSelectionWeights() != DefaultSelectionWeights() {
+		t.Errorf("expected default weights, got %+v", pr.GetSelectionWeights())
+	}
+
+	// fast has low latency but poor reliability; reliable is the opposite
+	pr.AddPeer(&Peer{ID: "fast", Name: "fast", PingMS: 5, Score: 10})
+	pr.AddPeer(&Peer{ID: "reliable", Name: "reliable", PingMS: 80, Score: 100})
+
+	if err := pr.SetSelectionWeights(SelectionWeights{Ping: 1}); err != nil {
+		t.Fatalf("SetSelectionWeights failed: %v", err)
+	}
+	if best := pr.SelectOptimalPeer(); best == nil || best.ID != "fast" {
+		t.Errorf("expected latency-only weights to pick fast, got %+v", best)
+	}
+
+	if err := pr.SetSelectionWeights(SelectionWeights{Score: 1}); err != nil {
+		t.Fatalf("SetSelectionWeights failed: %v", err)
+	}
+	if best := pr.SelectOptimalPeer(); best == nil || best.ID != "reliable" {
+		t.Errorf("expected score-only weights to pick reliable, got %+v", best)
+	}
+
+	if err := pr.SetSelectionWeights(SelectionWeights{Ping: -1}); err == nil {
+		t.Error("expected error for negative weight")
+	}
+	if pr.GetSelectionWeights() != (SelectionWeights{Score: 1}) {
+		t.Error("invalid weights must not be applied")
+	}
+}
+
+func TestPeerRegistry_SelectPeers(t *testing.T) {
+	pr, cleanup := setupTestPeerRegistry(t)
+	defer cleanup()
+
+	pr.AddPeer(&Peer{ID: "best", Name: "best", Role: RoleWorker, PingMS: 5, Score: 100})
+	pr.AddPeer(&Peer{ID: "good", Name: "good", Role: RoleDual, PingMS: 20, Score: 90})
+	pr.AddPeer(&Peer{ID: "offline", Name: "offline", Role: RoleWorker, PingMS: 1, Score: 100})
+	pr.AddPeer(&Peer{ID: "ctl", Name: "ctl", Role: RoleController, PingMS: 1, Score: 100})
+	pr.SetConnected("best", true)
+	pr.SetConnected("good", true)
+	pr.SetConnected("ctl", true)
+
+	connectedWorkers := func(p *Peer) bool { return p.Connected && p.Role != RoleController }
+
+	selected := pr.SelectPeers(5, connectedWorkers)
+	if len(selected) != 2 || selected[0].Peer.ID != "best" || selected[1].Peer.ID != "good" {
+		t.Fatalf("expected best then good, got %+v", selected)
+	}
+	if selected[0].Distance >= selected[1].Distance {
+		t.Errorf("expected ascending distances, got %v then %v", selected[0].Distance, selected[1].Distance)
+	}
+
+	if one := pr.SelectPeers(1, connectedWorkers); len(one) != 1 || one[0].Peer.ID != "best" {
+		t.Errorf("expected only best, got %+v", one)
+	}
+	if all := pr.SelectPeers(10, nil); len(all) != 4 {
+		t.Errorf("expected all 4 peers without a filter, got %d", len(all))
+	}
+	if none := pr.SelectPeers(0, nil); len(none) != 0 {
+		t.Errorf("expected no peers for n=0, got %d", len(none))
+	}
+}
+
+func TestPeerRegistry_Tags(t *testing.T) {
+	peersPath := filepath.Join(t.TempDir(), "peers.json")
+	pr, err := NewPeerRegistryWithPath(peersPath)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	pr.AddPeer(&Peer{ID: "rig-1", Name: "rig-1", Role: RoleWorker})
+	pr.AddPeer(&Peer{ID: "rig-2", Name: "rig-2", Role: RoleWorker})
+
+	if err := pr.SetTags("rig-1", []string{"gpu-farm", "eu", "gpu-farm"}); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+	if tags := pr.GetPeer("rig-1").Tags; len(tags) != 2 || tags[0] != "eu" || tags[1] != "gpu-farm" {
+		t.Errorf("expected tags deduplicated and sorted, got %v", tags)
+	}
+	if err := pr.AddTag("rig-2", "gpu-farm"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := pr.AddTag("rig-2", "bad tag"); err == nil {
+		t.Error("expected error for invalid tag")
+	}
+	if err := pr.AddTag("missing", "eu"); err == nil {
+		t.Error("expected error for unknown peer")
+	}
+
+	if tagged := pr.ListPeersByTag("gpu-farm"); len(tagged) != 2 {
+		t.Errorf("expected 2 peers tagged gpu-farm, got %d", len(tagged))
+	}
+	if counts := pr.TagCounts(); counts["gpu-farm"] != 2 || counts["eu"] != 1 {
+		t.Errorf("unexpected tag counts %v", counts)
+	}
+
+	if err := pr.RemoveTag("rig-1", "gpu-farm"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if tagged := pr.ListPeersByTag("gpu-farm"); len(tagged) != 1 || tagged[0].ID != "rig-2" {
+		t.Errorf("expected only rig-2 tagged gpu-farm, got %+v", tagged)
+	}
+
+	if err := pr.Close(); err != nil {
+		t.Fatalf("failed to close registry: %v", err)
+	}
+	reloaded, err := NewPeerRegistryWithPath(peersPath)
+	if err != nil {
+		t.Fatalf("failed to reload registry: %v", err)
+	}
+	if !reloaded.GetPeer("rig-2").HasTag("gpu-farm") || !reloaded.GetPeer("rig-1").HasTag("eu") {
+		t.Error("tags not persisted")
+	}
+}
@@ -0,0 +1,129 @@
+package node
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Remote log limits. A page is capped at MaxRemoteLogLines lines and sent in
+// messages of at most remoteLogChunkBytes of text, so large pages stay under
+// the transport's message size limit.
+const (
+	MaxRemoteLogLines      = 10000
+	MaxRemoteLogPattern    = 256
+	remoteLogHistoryLines  = 100000 // Lines of history searched for a page
+	remoteLogChunkBytes    = 256 << 10
+	remoteLogsChunkTimeout = 30 * time.Second
+)
+
+// ansiEscapeRegex matches terminal colour/formatting sequences in miner output.
+var ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// logPage selects the requested page of lines from history, newest last:
+// the Lines matching lines that precede the newest Offset matches.
+func logPage(history []string, req GetLogsPayload) (LogsPayload, error) {
+	var pattern *regexp.Regexp
+	if req.Grep != "" {
+		if len(req.Grep) > MaxRemoteLogPattern {
+			return LogsPayload{}, fmt.Errorf("pattern too long (max %d chars)", MaxRemoteLogPattern)
+		}
+		expr := req.Grep
+		if req.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return LogsPayload{}, fmt.Errorf("invalid pattern: %w", err)
+		}
+		pattern = re
+	}
+	if req.Offset < 0 {
+		return LogsPayload{}, fmt.Errorf("offset must not be negative")
+	}
+
+	matched := history
+	if pattern != nil {
+		matched = make([]string, 0, len(history))
+		for _, line := range history {
+			if pattern.MatchString(ansiEscapeRegex.ReplaceAllString(line, "")) != req.Invert {
+				matched = append(matched, line)
+			}
+		}
+	}
+
+	end := max(len(matched)-req.Offset, 0)
+	start := max(end-req.Lines, 0)
+	page := LogsPayload{
+		MinerName: req.MinerName,
+		Lines:     matched[start:end],
+		HasMore:   start > 0,
+	}
+	if page.HasMore {
+		page.NextOffset = req.Offset + len(page.Lines)
+	}
+	return page, nil
+}
+
+// chunkLines splits lines into runs of at most maxBytes of text, keeping at
+// least one line per run.
+func chunkLines(lines []string, maxBytes int) [][]string {
+	var chunks [][]string
+	start, size := 0, 0
+	for i, line := range lines {
+		if i > start && size+len(line) > maxBytes {
+			chunks = append(chunks, lines[start:i])
+			start, size = i, 0
+		}
+		size += len(line)
+	}
+	return append(chunks, lines[start:])
+}
+
+// GetRemoteLogsPage requests one page of a remote miner's console logs,
+// filtered on the worker. Large pages arrive as several messages and are
+// reassembled in order. Pass the returned NextOffset as req.Offset to fetch
+// the next, older page; offsets shift as the miner logs new lines.
+func (c *Controller) GetRemoteLogsPage(peerID string, req GetLogsPayload) (*LogsPayload, error) {
+	identity := c.node.GetIdentity()
+	if identity == nil {
+		return nil, ErrIdentityNotInitialized
+	}
+	if req.Lines <= 0 || req.Lines > MaxRemoteLogLines {
+		req.Lines = MaxRemoteLogLines
+	}
+
+	msg, err := NewMessage(MsgGetLogs, identity.ID, peerID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	// Chunks are handled in order on the connection's read loop, before the
+	// final reply is passed to sendRequest, so lines needs no lock
+	var lines []string
+	c.mu.Lock()
+	c.progress[msg.ID] = func(chunk *Message) {
+		var part LogsPayload
+		if err := chunk.ParsePayload(&part); err == nil {
+			lines = append(lines, part.Lines...)
+		}
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.progress, msg.ID)
+		c.mu.Unlock()
+	}()
+
+	resp, err := c.sendRequest(peerID, msg, remoteLogsChunkTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs LogsPayload
+	if err := ParseResponse(resp, MsgLogs, &logs); err != nil {
+		return nil, err
+	}
+	logs.Lines = append(lines, logs.Lines...)
+	return &logs, nil
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogPage(t *testing.T) {
+	history := []string{"a 1", "\x1b[31mERROR\x1b[0m b", "c 2", "error d", "e 3"}
+
+	page, err := logPage(history, GetLogsPayload{Lines: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(page.Lines, []string{"error d", "e 3"}) || !page.HasMore || page.NextOffset != 2 {
+		t.Errorf("unexpected first page %+v", page)
+	}
+	page, _ = logPage(history, GetLogsPayload{Lines: 2, Offset: page.NextOffset})
+	if !reflect.DeepEqual(page.Lines, []string{"\x1b[31mERROR\x1b[0m b", "c 2"}) || page.NextOffset != 4 {
+		t.Errorf("unexpected second page %+v", page)
+	}
+	page, _ = logPage(history, GetLogsPayload{Lines: 2, Offset: 4})
+	if !reflect.DeepEqual(page.Lines, []string{"a 1"}) || page.HasMore {
+		t.Errorf("unexpected last page %+v", page)
+	}
+	if page, _ = logPage(history, GetLogsPayload{Lines: 2, Offset: 10}); len(page.Lines) != 0 || page.HasMore {
+		t.Errorf("expected an empty page past the oldest line, got %+v", page)
+	}
+
+	// Patterns are matched without colour codes
+	page, _ = logPage(history, GetLogsPayload{Lines: 10, Grep: "^error", IgnoreCase: true})
+	if !reflect.DeepEqual(page.Lines, []string{"\x1b[31mERROR\x1b[0m b", "error d"}) {
+		t.Errorf("unexpected grep result %+v", page.Lines)
+	}
+	page, _ = logPage(history, GetLogsPayload{Lines: 10, Grep: "error", IgnoreCase: true, Invert: true})
+	if !reflect.DeepEqual(page.Lines, []string{"a 1", "c 2", "e 3"}) {
+		t.Errorf("unexpected inverted grep result %+v", page.Lines)
+	}
+
+	if _, err := logPage(history, GetLogsPayload{Lines: 10, Grep: "("}); err == nil {
+		t.Error("expected an invalid pattern to be rejected")
+	}
+	if _, err := logPage(history, GetLogsPayload{Lines: 10, Offset: -1}); err == nil {
+		t.Error("expected a negative offset to be rejected")
+	}
+}
+
+func TestChunkLines(t *testing.T) {
+	lines := []string{"aaaa", "bbbb", "cccc", "dddddddddd", "e"}
+	got := chunkLines(lines, 8)
+	want := [][]string{{"aaaa", "bbbb"}, {"cccc"}, {"dddddddddd"}, {"e"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := chunkLines(nil, 8); len(got) != 1 || len(got[0]) != 0 {
+		t.Errorf("expected one empty chunk, got %v", got)
+	}
+}
+
+func TestController_GetRemoteLogsPage(t *testing.T) {
+	// Enough text for the page to be split into several messages
+	logs := make([]string, 3000)
+	for i := range logs {
+		logs[i] = fmt.Sprintf("line %04d %s", i, strings.Repeat("x", 200))
+	}
+	controller, workerID := setupControllerAndWorker(t, func(w *Worker) {
+		w.SetMinerManager(&mockMinerManager{miners: []MinerInstance{
+			&mockMinerInstance{name: "gpu", minerType: "tt-miner", logs: logs},
+		}})
+	})
+
+	page, err := controller.GetRemoteLogsPage(workerID, GetLogsPayload{MinerName: "gpu", Lines: 2500, Offset: 100})
+	if err != nil {
+		t.Fatalf("GetRemoteLogsPage failed: %v", err)
+	}
+	if !reflect.DeepEqual(page.Lines, logs[400:2900]) {
+		t.Fatalf("expected lines 400-2899 in order, got %d lines", len(page.Lines))
+	}
+	if !page.HasMore || page.NextOffset != 2600 {
+		t.Errorf("unexpected paging %v/%d", page.HasMore, page.NextOffset)
+	}
+
+	page, err = controller.GetRemoteLogsPage(workerID, GetLogsPayload{MinerName: "gpu", Lines: 10, Grep: `^line 00[0-4]7 `})
+	if err != nil {
+		t.Fatalf("GetRemoteLogsPage failed: %v", err)
+	}
+	if len(page.Lines) != 5 || !strings.HasPrefix(page.Lines[0], "line 0007") || page.HasMore {
+		t.Errorf("unexpected filtered page %d lines, hasMore %v", len(page.Lines), page.HasMore)
+	}
+}
@@ -0,0 +1,228 @@
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Snider/Mining/pkg/logging"
+)
+
+// CapabilityStatsPush is advertised in the handshake by workers that push
+// MsgStats on a timer once a controller sends MsgStatsConfig, instead of
+// waiting to be polled with MsgGetStats.
+const CapabilityStatsPush = "stats-push"
+
+// Stats push intervals. Pushed stats are considered stale after
+// statsStaleIntervals intervals without an update.
+const (
+	DefaultStatsPushInterval = 10 * time.Second
+	MinStatsPushInterval     = time.Second
+	MaxStatsPushInterval     = time.Hour
+	statsStaleIntervals      = 3
+)
+
+// statsPusher sends a worker's stats to one controller until stopped.
+type statsPusher struct {
+	conn     *PeerConnection
+	interval time.Duration
+	stop     chan struct{}
+}
+
+// clampStatsPushInterval applies the default to a zero interval and bounds the rest.
+func clampStatsPushInterval(interval time.Duration) time.Duration {
+	if interval == 0 {
+		return DefaultStatsPushInterval
+	}
+	return min(max(interval, MinStatsPushInterval), MaxStatsPushInterval)
+}
+
+// EnableStatsPush advertises CapabilityStatsPush so controllers can subscribe
+// to pushed stats. Only connections established afterwards see it.
+func (w *Worker) EnableStatsPush() {
+	w.pushMu.Lock()
+	w.pushEnabled = true
+	if w.pushers == nil {
+		w.pushers = make(map[string]*statsPusher)
+	}
+	w.pushMu.Unlock()
+	w.transport.AdvertiseCapability(CapabilityStatsPush)
+}
+
+// handleStatsConfig starts, retunes or stops pushing stats to the sender and
+// replies with the current stats.
+func (w *Worker) handleStatsConfig(conn *PeerConnection, msg *Message) (*Message, error) {
+	var payload StatsConfigPayload
+	if err := msg.ParsePayload(&payload); err != nil {
+		return nil, fmt.Errorf("invalid stats config payload: %w", err)
+	}
+
+	w.pushMu.Lock()
+	if !w.pushEnabled {
+		w.pushMu.Unlock()
+		return nil, fmt.Errorf("stats push not enabled on this node")
+	}
+	if previous := w.pushers[conn.Peer.ID]; previous != nil {
+		close(previous.stop)
+		delete(w.pushers, conn.Peer.ID)
+	}
+	if payload.Enabled {
+		pusher := &statsPusher{
+			conn:     conn,
+			interval: clampStatsPushInterval(time.Duration(payload.IntervalMS) * time.Millisecond),
+			stop:     make(chan struct{}),
+		}
+		w.pushers[conn.Peer.ID] = pusher
+		go w.pushStats(pusher)
+	}
+	w.pushMu.Unlock()
+
+	return w.handleGetStats(msg)
+}
+
+// pushStats sends unsolicited stats every interval until the pusher is
+// replaced or the connection closes.
+func (w *Worker) pushStats(pusher *statsPusher) {
+	ticker := time.NewTicker(pusher.interval)
+	defer ticker.Stop()
+	peerID := pusher.conn.Peer.ID
+
+	for {
+		select {
+		case <-pusher.stop:
+			return
+		case <-w.transport.ctx.Done():
+			w.stopPusher(peerID, pusher)
+			return
+		case <-ticker.C:
+		}
+
+		// A reconnect creates a new connection, and the controller subscribes again
+		if w.transport.GetConnection(peerID) != pusher.conn {
+			w.stopPusher(peerID, pusher)
+			return
+		}
+		stats, err := w.collectStats()
+		if err != nil {
+			continue
+		}
+		msg, err := NewMessage(MsgStats, stats.NodeID, peerID, stats)
+		if err != nil {
+			continue
+		}
+		if err := pusher.conn.Send(msg); err != nil {
+			logging.Debug("failed to push stats", logging.Fields{"peer_id": peerID, "error": err})
+		}
+	}
+}
+
+// stopPusher removes pusher if it is still the peer's current one.
+func (w *Worker) stopPusher(peerID string, pusher *statsPusher) {
+	w.pushMu.Lock()
+	defer w.pushMu.Unlock()
+	if w.pushers[peerID] == pusher {
+		delete(w.pushers, peerID)
+	}
+}
+
+// pushedStats is the latest stats a worker pushed to the controller.
+type pushedStats struct {
+	stats      *StatsPayload
+	receivedAt time.Time
+	interval   time.Duration
+}
+
+// EnableStatsPush subscribes to pushed stats, at interval, from every worker
+// advertising CapabilityStatsPush as it connects. GetAllStats then reads
+// those workers' stats from the cache instead of polling them.
+func (c *Controller) EnableStatsPush(interval time.Duration) {
+	c.statsMu.Lock()
+	c.pushInterval = clampStatsPushInterval(interval)
+	c.statsMu.Unlock()
+
+	c.transport.OnConnect(c.subscribeStats)
+}
+
+// subscribeStats asks a newly connected worker to push its stats.
+func (c *Controller) subscribeStats(peerID string) {
+	c.statsMu.RLock()
+	interval := c.pushInterval
+	c.statsMu.RUnlock()
+
+	conn := c.transport.GetConnection(peerID)
+	if interval == 0 || conn == nil || !conn.HasCapability(CapabilityStatsPush) {
+		return
+	}
+	if err := c.SetStatsPushInterval(peerID, interval); err != nil {
+		logging.Warn("failed to subscribe to pushed stats", logging.Fields{"peer_id": peerID, "error": err})
+	}
+}
+
+// SetStatsPushInterval asks a worker to push its stats every interval, or to
+// stop pushing when interval is zero, after which it is polled again.
+func (c *Controller) SetStatsPushInterval(peerID string, interval time.Duration) error {
+	identity := c.node.GetIdentity()
+	if identity == nil {
+		return ErrIdentityNotInitialized
+	}
+	if interval < 0 {
+		return fmt.Errorf("stats push interval must not be negative")
+	}
+
+	payload := StatsConfigPayload{Enabled: interval > 0}
+	if payload.Enabled {
+		interval = clampStatsPushInterval(interval)
+		payload.IntervalMS = interval.Milliseconds()
+	}
+	msg, err := NewMessage(MsgStatsConfig, identity.ID, peerID, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+
+	resp, err := c.sendRequest(peerID, msg, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	var stats StatsPayload
+	if err := ParseResponse(resp, MsgStats, &stats); err != nil {
+		return err
+	}
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	if payload.Enabled {
+		c.pushed[resp.From] = &pushedStats{stats: &stats, receivedAt: time.Now(), interval: interval}
+	} else {
+		delete(c.pushed, resp.From)
+	}
+	return nil
+}
+
+// handlePushedStats caches stats a subscribed worker pushed.
+func (c *Controller) handlePushedStats(conn *PeerConnection, msg *Message) {
+	var stats StatsPayload
+	if err := msg.ParsePayload(&stats); err != nil {
+		logging.Debug("invalid pushed stats", logging.Fields{"peer_id": conn.Peer.ID, "error": err})
+		return
+	}
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	if entry := c.pushed[conn.Peer.ID]; entry != nil {
+		// Entries are replaced rather than updated, since CachedStats reads them unlocked
+		c.pushed[conn.Peer.ID] = &pushedStats{stats: &stats, receivedAt: time.Now(), interval: entry.interval}
+	}
+}
+
+// CachedStats returns the stats a connected worker last pushed, if they
+// arrived within a few push intervals.
+func (c *Controller) CachedStats(peerID string) (*StatsPayload, bool) {
+	c.statsMu.RLock()
+	entry := c.pushed[peerID]
+	c.statsMu.RUnlock()
+
+	if entry == nil || c.transport.GetConnection(peerID) == nil ||
+		time.Since(entry.receivedAt) > statsStaleIntervals*entry.interval {
+		return nil, false
+	}
+	return entry.stats, true
+}
@@ -0,0 +1,62 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsPush(t *testing.T) {
+	controller, workerID := setupControllerAndWorker(t, (*Worker).EnableStatsPush)
+	controller.EnableStatsPush(MinStatsPushInterval)
+	if err := controller.ConnectToPeer(workerID); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	if conn := controller.transport.GetConnection(workerID); conn == nil || !conn.HasCapability(CapabilityStatsPush) {
+		t.Fatal("expected the worker to advertise stats push")
+	}
+
+	// The controller subscribes on connect and the worker then pushes every interval
+	deadline := time.Now().Add(5 * time.Second)
+	var first time.Time
+	for {
+		controller.statsMu.RLock()
+		entry := controller.pushed[workerID]
+		controller.statsMu.RUnlock()
+		if entry != nil && first.IsZero() {
+			first = entry.receivedAt
+		} else if entry != nil && entry.receivedAt.After(first) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("worker did not push stats")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if stats, ok := controller.CachedStats(workerID); !ok || stats.NodeID != workerID {
+		t.Fatalf("expected cached stats from the worker, got %+v", stats)
+	}
+	if all := controller.GetAllStats(); all[workerID] == nil {
+		t.Errorf("expected GetAllStats to include the worker, got %v", all)
+	}
+
+	if err := controller.SetStatsPushInterval(workerID, 0); err != nil {
+		t.Fatalf("failed to stop stats push: %v", err)
+	}
+	if _, ok := controller.CachedStats(workerID); ok {
+		t.Error("expected no cached stats after stopping the push")
+	}
+}
+
+func TestStatsPush_NotSupported(t *testing.T) {
+	controller, workerID := setupControllerAndWorker(t, nil)
+	if err := controller.ConnectToPeer(workerID); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	if controller.transport.GetConnection(workerID).HasCapability(CapabilityStatsPush) {
+		t.Error("worker should not advertise stats push")
+	}
+	if err := controller.SetStatsPushInterval(workerID, time.Second); err == nil {
+		t.Error("expected a worker without stats push to refuse the subscription")
+	}
+}
@@ -0,0 +1,190 @@
+package node
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// The auto-generated certificate is only a transport wrapper: SMSG already
+// encrypts and authenticates every message, so TLS is defense-in-depth that
+// also hides message types and sizes from the network. Peers verify it by
+// fingerprint (Peer.TLSFingerprint) rather than through a CA.
+const (
+	autoCertFile     = "p2p.crt"
+	autoKeyFile      = "p2p.key"
+	autoCertValidity = 10 * 365 * 24 * time.Hour
+	autoCertRenewal  = 30 * 24 * time.Hour // Regenerate this long before expiry
+)
+
+// DefaultTLSDir returns the directory auto-generated transport certificates are kept in.
+func DefaultTLSDir() (string, error) {
+	certPath, err := xdg.DataFile("lethean-desktop/node/tls/" + autoCertFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to get TLS directory: %w", err)
+	}
+	return filepath.Dir(certPath), nil
+}
+
+// CertFingerprint returns the hex SHA-256 of a DER-encoded certificate, the
+// format Peer.TLSFingerprint is pinned in.
+func CertFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeFingerprint lowercases a fingerprint and strips the colons some
+// tools print between bytes.
+func NormalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
+
+// certFileFingerprint loads a certificate/key pair and returns the leaf's fingerprint.
+func certFileFingerprint(certPath, keyPath string) (string, error) {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return "", err
+	}
+	return CertFingerprint(pair.Certificate[0]), nil
+}
+
+// EnsureSelfSignedCert returns a self-signed certificate for nodeID in dir,
+// generating one if none exists, it belongs to another node or it is close to
+// expiry.
+func EnsureSelfSignedCert(dir, nodeID string) (certPath, keyPath string, err error) {
+	certPath = filepath.Join(dir, autoCertFile)
+	keyPath = filepath.Join(dir, autoKeyFile)
+
+	if pair, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		if cert, err := x509.ParseCertificate(pair.Certificate[0]); err == nil &&
+			cert.Subject.CommonName == nodeID && time.Until(cert.NotAfter) > autoCertRenewal {
+			return certPath, keyPath, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate TLS key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: nodeID, Organization: []string{"Mining P2P node"}},
+		DNSNames:     []string{nodeID},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(autoCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create TLS certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal TLS key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("failed to create TLS directory: %w", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return "", "", fmt.Errorf("failed to write TLS key: %w", err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write TLS certificate: %w", err)
+	}
+	return certPath, keyPath, nil
+}
+
+// pinnedCertVerifier checks the peer's leaf certificate against a pinned fingerprint.
+func pinnedCertVerifier(fingerprint string) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	want := NormalizeFingerprint(fingerprint)
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("peer presented no TLS certificate")
+		}
+		if got := CertFingerprint(rawCerts[0]); got != want {
+			return fmt.Errorf("peer TLS certificate fingerprint %s does not match the pinned %s", got, want)
+		}
+		return nil
+	}
+}
+
+// tlsEnabled reports whether the transport serves and dials wss://.
+func (t *Transport) tlsEnabled() bool {
+	return (t.config.TLSCertPath != "" && t.config.TLSKeyPath != "") || t.config.TLSAutoCert
+}
+
+// tlsCertFiles returns the certificate and key to serve, generating the
+// self-signed certificate if configured, or empty paths for plain ws://.
+func (t *Transport) tlsCertFiles() (certPath, keyPath string, err error) {
+	certPath, keyPath = t.config.TLSCertPath, t.config.TLSKeyPath
+	if certPath == "" && t.config.TLSAutoCert {
+		identity := t.node.GetIdentity()
+		if identity == nil {
+			return "", "", fmt.Errorf("node identity required to generate a TLS certificate")
+		}
+		dir := t.config.TLSDir
+		if dir == "" {
+			if dir, err = DefaultTLSDir(); err != nil {
+				return "", "", err
+			}
+		}
+		if certPath, keyPath, err = EnsureSelfSignedCert(dir, identity.ID); err != nil {
+			return "", "", err
+		}
+	}
+	if certPath == "" || keyPath == "" {
+		t.tlsPrint.Store("")
+		return "", "", nil
+	}
+
+	fingerprint, err := certFileFingerprint(certPath, keyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	t.tlsPrint.Store(fingerprint)
+	return certPath, keyPath, nil
+}
+
+// TLSFingerprint returns the fingerprint of the certificate the transport
+// serves, for peers to pin, or "" when it isn't serving TLS.
+func (t *Transport) TLSFingerprint() string {
+	fingerprint, _ := t.tlsPrint.Load().(string)
+	return fingerprint
+}
+
+// dialTLSConfig verifies a peer's certificate against its pinned fingerprint.
+// Self-signed certificates can't be verified through a CA, so with an
+// auto-generated certificate and no pin any certificate is accepted: the SMSG
+// handshake still authenticates the peer. Otherwise the system roots apply.
+func (t *Transport) dialTLSConfig(peer *Peer) *tls.Config {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case peer.TLSFingerprint != "":
+		config.InsecureSkipVerify = true // Replaced by the pin check
+		config.VerifyPeerCertificate = pinnedCertVerifier(peer.TLSFingerprint)
+	case t.config.TLSAutoCert:
+		config.InsecureSkipVerify = true
+	}
+	return config
+}
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath, err := EnsureSelfSignedCert(dir, "node-a")
+	if err != nil {
+		t.Fatalf("EnsureSelfSignedCert failed: %v", err)
+	}
+	first, err := certFileFingerprint(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The certificate is reused for the same node and replaced for another
+	EnsureSelfSignedCert(dir, "node-a")
+	if again, _ := certFileFingerprint(certPath, keyPath); again != first {
+		t.Error("expected the certificate to be reused")
+	}
+	EnsureSelfSignedCert(dir, "node-b")
+	if other, _ := certFileFingerprint(certPath, keyPath); other == first {
+		t.Error("expected a new certificate for another node identity")
+	}
+
+	if got := NormalizeFingerprint("AB:CD:ef"); got != "abcdef" {
+		t.Errorf("unexpected normalized fingerprint %q", got)
+	}
+}
+
+func TestTransport_TLSAutoCert(t *testing.T) {
+	addr := freeAddr(t)
+	server, serverCleanup := setupTestTransport(t, addr)
+	defer serverCleanup()
+	server.config.TLSAutoCert = true
+	server.config.TLSDir = t.TempDir()
+	client, clientCleanup := setupTestTransport(t, "127.0.0.1:0")
+	defer clientCleanup()
+	client.config.TLSAutoCert = true
+	client.config.TLSDir = t.TempDir()
+
+	if err := server.Start(); err == nil || !strings.Contains(err.Error(), "identity") {
+		t.Fatalf("expected a TLS certificate to require an identity, got %v", err)
+	}
+	if err := server.node.GenerateIdentity("tls-server", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.node.GenerateIdentity("tls-client", RoleController); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+	fingerprint := server.TLSFingerprint()
+	if len(fingerprint) != 64 {
+		t.Fatalf("expected a SHA-256 fingerprint, got %q", fingerprint)
+	}
+
+	if _, err := client.Connect(&Peer{ID: "wrong-pin", Address: addr, TLSFingerprint: strings.Repeat("0", 64)}); err == nil {
+		t.Error("expected a connection with a mismatched pin to fail")
+	}
+	if _, err := client.Connect(&Peer{ID: "pinned", Address: addr, TLSFingerprint: strings.ToUpper(fingerprint)}); err != nil {
+		t.Errorf("expected a connection with the pinned fingerprint to succeed: %v", err)
+	}
+}
@@ -1,15 +1,20 @@
 package node
 
 import (
+	"container/list"
 	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/Snider/Borg/pkg/smsg"
@@ -26,16 +31,33 @@ const debugLogInterval = 100
 // DefaultMaxMessageSize is the default maximum message size (1MB)
 const DefaultMaxMessageSize int64 = 1 << 20 // 1MB
 
+// DefaultMaxMissedPongs is how many keepalive pings in a row may go unanswered.
+const DefaultMaxMissedPongs = 3
+
+// Handshake defaults
+const (
+	DefaultHandshakeTimeout     = 10 * time.Second
+	DefaultMaxPendingHandshakes = 16
+)
+
 // TransportConfig configures the WebSocket transport.
 type TransportConfig struct {
 	ListenAddr     string // ":9091" default
 	WSPath         string // "/ws" - WebSocket endpoint path
 	TLSCertPath    string // Optional TLS for wss://
 	TLSKeyPath     string
+	TLSAutoCert    bool          // Without TLSCertPath, generate a self-signed certificate for the node identity
+	TLSDir         string        // Where the auto-generated certificate is kept (default XDG data dir)
 	MaxConns       int           // Maximum concurrent connections
 	MaxMessageSize int64         // Maximum message size in bytes (0 = 1MB default)
 	PingInterval   time.Duration // WebSocket keepalive interval
 	PongTimeout    time.Duration // Timeout waiting for pong
+	MaxMissedPongs int           // Consecutive unanswered pings before a connection is dead (0 = 3 default)
+	DedupTTL       time.Duration // How long message IDs are remembered (0 = 5 minute default)
+	DedupMaxSize   int           // Maximum remembered message IDs (0 = 100000 default)
+
+	HandshakeTimeout     time.Duration // Time allowed to complete a handshake (0 = 10 second default)
+	MaxPendingHandshakes int           // Maximum concurrent inbound handshakes (0 = 16 default)
 }
 
 // DefaultTransportConfig returns sensible defaults.
@@ -47,55 +69,111 @@ func DefaultTransportConfig() TransportConfig {
 		MaxMessageSize: DefaultMaxMessageSize,
 		PingInterval:   30 * time.Second,
 		PongTimeout:    10 * time.Second,
+		MaxMissedPongs: DefaultMaxMissedPongs,
+		DedupTTL:       DefaultDedupTTL,
+		DedupMaxSize:   DefaultDedupMaxSize,
+
+		HandshakeTimeout:     DefaultHandshakeTimeout,
+		MaxPendingHandshakes: DefaultMaxPendingHandshakes,
 	}
 }
 
 // MessageHandler processes incoming messages.
 type MessageHandler func(conn *PeerConnection, msg *Message)
 
-// MessageDeduplicator tracks seen message IDs to prevent duplicate processing
+// Message deduplication defaults
+const (
+	DefaultDedupTTL     = 5 * time.Minute
+	DefaultDedupMaxSize = 100000
+)
+
+// MessageDeduplicator tracks seen message IDs to prevent duplicate processing.
+// It holds at most maxSize IDs; when full, the least recently seen are evicted
+// first, so a flood of unique IDs can't grow memory between cleanup sweeps.
 type MessageDeduplicator struct {
-	seen map[string]time.Time
-	mu   sync.RWMutex
-	ttl  time.Duration
+	seen    map[string]*list.Element // message ID -> element in order
+	order   *list.List               // dedupEntry values, least recently seen first
+	mu      sync.RWMutex
+	ttl     time.Duration
+	maxSize int
+}
+
+type dedupEntry struct {
+	id   string
+	seen time.Time
 }
 
-// NewMessageDeduplicator creates a deduplicator with specified TTL
-func NewMessageDeduplicator(ttl time.Duration) *MessageDeduplicator {
+// NewMessageDeduplicator creates a deduplicator with the specified TTL that
+// remembers at most maxSize message IDs (0 = DefaultDedupMaxSize).
+func NewMessageDeduplicator(ttl time.Duration, maxSize int) *MessageDeduplicator {
+	if maxSize <= 0 {
+		maxSize = DefaultDedupMaxSize
+	}
 	d := &MessageDeduplicator{
-		seen: make(map[string]time.Time),
-		ttl:  ttl,
+		seen:    make(map[string]*list.Element),
+		order:   list.New(),
+		ttl:     ttl,
+		maxSize: maxSize,
 	}
 	return d
 }
 
-// IsDuplicate checks if a message ID has been seen recently
+// IsDuplicate checks if a message ID has been seen within the TTL
 func (d *MessageDeduplicator) IsDuplicate(msgID string) bool {
 	d.mu.RLock()
-	_, exists := d.seen[msgID]
-	d.mu.RUnlock()
-	return exists
+	defer d.mu.RUnlock()
+	elem, exists := d.seen[msgID]
+	return exists && time.Since(elem.Value.(*dedupEntry).seen) <= d.ttl
 }
 
 // Mark records a message ID as seen
 func (d *MessageDeduplicator) Mark(msgID string) {
 	d.mu.Lock()
-	d.seen[msgID] = time.Now()
-	d.mu.Unlock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if elem, exists := d.seen[msgID]; exists {
+		elem.Value.(*dedupEntry).seen = now
+		d.order.MoveToBack(elem)
+		return
+	}
+
+	// Near the cap, sweep expired IDs before evicting live ones
+	if d.order.Len() >= d.maxSize*9/10 {
+		d.removeExpired(now)
+	}
+	for d.order.Len() >= d.maxSize {
+		d.remove(d.order.Front())
+	}
+	d.seen[msgID] = d.order.PushBack(&dedupEntry{id: msgID, seen: now})
+}
+
+// Len returns the number of message IDs currently remembered
+func (d *MessageDeduplicator) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.seen)
 }
 
 // Cleanup removes expired entries
 func (d *MessageDeduplicator) Cleanup() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	now := time.Now()
-	for id, seen := range d.seen {
-		if now.Sub(seen) > d.ttl {
-			delete(d.seen, id)
-		}
+	d.removeExpired(time.Now())
+}
+
+// removeExpired drops IDs older than the TTL. Entries are ordered by when they
+// were last seen, so it stops at the first live one. Caller must hold d.mu.
+func (d *MessageDeduplicator) removeExpired(now time.Time) {
+	for elem := d.order.Front(); elem != nil && now.Sub(elem.Value.(*dedupEntry).seen) > d.ttl; elem = d.order.Front() {
+		d.remove(elem)
 	}
 }
 
+func (d *MessageDeduplicator) remove(elem *list.Element) {
+	delete(d.seen, elem.Value.(*dedupEntry).id)
+	d.order.Remove(elem)
+}
+
 // Transport manages WebSocket connections with SMSG encryption.
 type Transport struct {
 	config       TransportConfig
@@ -106,11 +184,17 @@ type Transport struct {
 	node         *NodeManager
 	registry     *PeerRegistry
 	handler      MessageHandler
-	dedup        *MessageDeduplicator // Message deduplication
+	onConnect    []func(peerID string) // Called after a peer connection is established
+	dedup        *MessageDeduplicator  // Message deduplication
+	challenges   *MessageDeduplicator  // Recently seen handshake challenges, to reject replays early
+	capabilities []string              // Advertised in handshakes, guarded by mu
 	mu           sync.RWMutex
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
+	listening    atomic.Bool  // true while the HTTP listener is accepting connections
+	tlsPrint     atomic.Value // string fingerprint of the certificate being served
+	counters     transportCounters
 }
 
 // PeerRateLimiter implements a simple token bucket rate limiter per peer
@@ -164,18 +248,45 @@ type PeerConnection struct {
 	transport    *Transport
 	closeOnce    sync.Once        // Ensure Close() is only called once
 	rateLimiter  *PeerRateLimiter // Per-peer message rate limiting
+	connectedAt  time.Time
+	outbound     bool         // We dialed the peer
+	lastActive   atomic.Int64 // UnixNano of LastActivity, safe to read from other goroutines
+	counters     connCounters
+	keepalive    keepaliveState
+	capabilities []string // Advertised by the peer in the handshake
+}
+
+// keepaliveState tracks a connection's outstanding keepalive ping.
+type keepaliveState struct {
+	pingID atomic.Value  // string ID of the ping awaiting a pong
+	pongs  chan struct{} // Signalled when the pong for pingID arrives
+	rtt    atomic.Int64  // Round-trip time of the last answered ping, in nanoseconds
+	missed atomic.Int32  // Consecutive unanswered pings
 }
 
 // NewTransport creates a new WebSocket transport.
 func NewTransport(node *NodeManager, registry *PeerRegistry, config TransportConfig) *Transport {
 	ctx, cancel := context.WithCancel(context.Background())
+	if config.DedupTTL <= 0 {
+		config.DedupTTL = DefaultDedupTTL
+	}
+	if config.MaxMissedPongs <= 0 {
+		config.MaxMissedPongs = DefaultMaxMissedPongs
+	}
+	if config.HandshakeTimeout <= 0 {
+		config.HandshakeTimeout = DefaultHandshakeTimeout
+	}
+	if config.MaxPendingHandshakes <= 0 {
+		config.MaxPendingHandshakes = DefaultMaxPendingHandshakes
+	}
 
 	return &Transport{
-		config:   config,
-		node:     node,
-		registry: registry,
-		conns:    make(map[string]*PeerConnection),
-		dedup:    NewMessageDeduplicator(5 * time.Minute), // 5 minute TTL for dedup
+		config:     config,
+		node:       node,
+		registry:   registry,
+		conns:      make(map[string]*PeerConnection),
+		dedup:      NewMessageDeduplicator(config.DedupTTL, config.DedupMaxSize),
+		challenges: NewMessageDeduplicator(config.DedupTTL, config.DedupMaxSize),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -200,7 +311,25 @@ func NewTransport(node *NodeManager, registry *PeerRegistry, config TransportCon
 }
 
 // Start begins listening for incoming connections.
+// The listen address is bound before returning, so a port already in use is reported here.
 func (t *Transport) Start() error {
+	if t.listening.Load() {
+		return fmt.Errorf("transport already listening on %s", t.config.ListenAddr)
+	}
+
+	certPath, keyPath, err := t.tlsCertFiles()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", t.config.ListenAddr)
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return fmt.Errorf("P2P listen address %s is already in use: %w", t.config.ListenAddr, err)
+		}
+		return fmt.Errorf("failed to listen on %s: %w", t.config.ListenAddr, err)
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc(t.config.WSPath, t.handleWSUpgrade)
 
@@ -214,7 +343,7 @@ func (t *Transport) Start() error {
 	}
 
 	// Apply TLS hardening if TLS is enabled
-	if t.config.TLSCertPath != "" && t.config.TLSKeyPath != "" {
+	if certPath != "" {
 		t.server.TLSConfig = &tls.Config{
 			MinVersion: tls.VersionTLS12,
 			CipherSuites: []uint16{
@@ -237,14 +366,16 @@ func (t *Transport) Start() error {
 		}
 	}
 
+	t.listening.Store(true)
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
+		defer t.listening.Store(false)
 		var err error
-		if t.config.TLSCertPath != "" && t.config.TLSKeyPath != "" {
-			err = t.server.ListenAndServeTLS(t.config.TLSCertPath, t.config.TLSKeyPath)
+		if certPath != "" {
+			err = t.server.ServeTLS(listener, certPath, keyPath)
 		} else {
-			err = t.server.ListenAndServe()
+			err = t.server.Serve(listener)
 		}
 		if err != nil && err != http.ErrServerClosed {
 			logging.Error("HTTP server error", logging.Fields{"error": err, "addr": t.config.ListenAddr})
@@ -255,7 +386,7 @@ func (t *Transport) Start() error {
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
-		ticker := time.NewTicker(time.Minute)
+		ticker := time.NewTicker(max(time.Second, min(time.Minute, t.config.DedupTTL/2)))
 		defer ticker.Stop()
 		for {
 			select {
@@ -263,6 +394,7 @@ func (t *Transport) Start() error {
 				return
 			case <-ticker.C:
 				t.dedup.Cleanup()
+				t.challenges.Cleanup()
 			}
 		}
 	}()
@@ -270,6 +402,16 @@ func (t *Transport) Start() error {
 	return nil
 }
 
+// IsListening reports whether the transport is accepting incoming connections.
+func (t *Transport) IsListening() bool {
+	return t.listening.Load()
+}
+
+// ListenAddr returns the configured listen address.
+func (t *Transport) ListenAddr() string {
+	return t.config.ListenAddr
+}
+
 // Stop gracefully shuts down the transport.
 func (t *Transport) Stop() error {
 	t.cancel()
@@ -303,22 +445,62 @@ func (t *Transport) OnMessage(handler MessageHandler) {
 	t.handler = handler
 }
 
+// AdvertiseCapability adds an optional feature to those advertised in
+// handshakes. Connections established earlier don't learn about it.
+func (t *Transport) AdvertiseCapability(capability string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !slices.Contains(t.capabilities, capability) {
+		t.capabilities = append(t.capabilities, capability)
+	}
+}
+
+// Capabilities returns the features advertised in handshakes.
+func (t *Transport) Capabilities() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slices.Clone(t.capabilities)
+}
+
+// OnConnect registers a callback run in its own goroutine whenever a
+// connection to a peer is established, in either direction.
+func (t *Transport) OnConnect(handler func(peerID string)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onConnect = append(t.onConnect, handler)
+}
+
+// notifyConnect runs the OnConnect callbacks for a new connection.
+func (t *Transport) notifyConnect(peerID string) {
+	t.mu.RLock()
+	handlers := t.onConnect
+	t.mu.RUnlock()
+	for _, handler := range handlers {
+		go handler(peerID)
+	}
+}
+
 // Connect establishes a connection to a peer.
 func (t *Transport) Connect(peer *Peer) (*PeerConnection, error) {
+	if t.ctx.Err() != nil {
+		return nil, ErrTransportNotRunning
+	}
+
 	// Build WebSocket URL
 	scheme := "ws"
-	if t.config.TLSCertPath != "" {
+	if t.tlsEnabled() {
 		scheme = "wss"
 	}
 	u := url.URL{Scheme: scheme, Host: peer.Address, Path: t.config.WSPath}
 
 	// Dial the peer with timeout to prevent hanging on unresponsive peers
 	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
+		HandshakeTimeout: t.config.HandshakeTimeout,
+		TLSClientConfig:  t.dialTLSConfig(peer),
 	}
 	conn, _, err := dialer.Dial(u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to peer: %w", err)
+		return nil, newNodeError(CodeConnectFailed, peer.ID, "failed to connect to peer", err)
 	}
 
 	pc := &PeerConnection{
@@ -327,13 +509,17 @@ func (t *Transport) Connect(peer *Peer) (*PeerConnection, error) {
 		LastActivity: time.Now(),
 		transport:    t,
 		rateLimiter:  NewPeerRateLimiter(100, 50), // 100 burst, 50/sec refill
+		connectedAt:  time.Now(),
+		outbound:     true,
+		keepalive:    keepaliveState{pongs: make(chan struct{}, 1)},
 	}
+	pc.lastActive.Store(pc.LastActivity.UnixNano())
 
 	// Perform handshake with challenge-response authentication
 	// This also derives and stores the shared secret in pc.SharedSecret
 	if err := t.performHandshake(pc); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("handshake failed: %w", err)
+		return nil, newNodeError(CodeHandshakeFailed, peer.ID, "handshake failed", err)
 	}
 
 	// Store connection using the real peer ID from handshake
@@ -356,9 +542,21 @@ func (t *Transport) Connect(peer *Peer) (*PeerConnection, error) {
 	t.wg.Add(1)
 	go t.keepalive(pc)
 
+	t.notifyConnect(pc.Peer.ID)
 	return pc, nil
 }
 
+// checkDuplicate marks msg as seen, returning a NodeError with
+// CodeDuplicateMessage if it was already seen within the dedup TTL.
+func (t *Transport) checkDuplicate(peerID string, msg *Message) *NodeError {
+	if t.dedup.IsDuplicate(msg.ID) {
+		t.counters.duplicates.Add(1)
+		return newNodeError(CodeDuplicateMessage, peerID, fmt.Sprintf("duplicate message %s dropped", msg.ID), nil)
+	}
+	t.dedup.Mark(msg.ID)
+	return nil
+}
+
 // Send sends a message to a specific peer.
 func (t *Transport) Send(peerID string, msg *Message) error {
 	t.mu.RLock()
@@ -366,7 +564,7 @@ func (t *Transport) Send(peerID string, msg *Message) error {
 	t.mu.RUnlock()
 
 	if !exists {
-		return fmt.Errorf("peer %s not connected", peerID)
+		return errPeerNotConnected(peerID)
 	}
 
 	return pc.Send(msg)
@@ -404,22 +602,29 @@ func (t *Transport) GetConnection(peerID string) *PeerConnection {
 
 // handleWSUpgrade handles incoming WebSocket connections.
 func (t *Transport) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
+	// Track this connection as pending during handshake. Reserving the slot
+	// before checking the limits keeps concurrent upgrades from overshooting them.
+	pendingConns := int(t.pendingConns.Add(1))
+	defer t.pendingConns.Add(-1)
+
+	// Cap in-progress handshakes separately, so stalled half-open handshakes
+	// can't consume the whole connection budget
+	if pendingConns > t.config.MaxPendingHandshakes {
+		t.counters.handshakesRejected.Add(1)
+		http.Error(w, "Too many pending handshakes", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Enforce MaxConns limit (including pending connections during handshake)
 	t.mu.RLock()
 	currentConns := len(t.conns)
 	t.mu.RUnlock()
-	pendingConns := int(t.pendingConns.Load())
-
-	totalConns := currentConns + pendingConns
-	if totalConns >= t.config.MaxConns {
+	if currentConns+pendingConns > t.config.MaxConns {
+		t.counters.handshakesRejected.Add(1)
 		http.Error(w, "Too many connections", http.StatusServiceUnavailable)
 		return
 	}
 
-	// Track this connection as pending during handshake
-	t.pendingConns.Add(1)
-	defer t.pendingConns.Add(-1)
-
 	conn, err := t.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
@@ -433,8 +638,7 @@ func (t *Transport) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
 	conn.SetReadLimit(maxSize)
 
 	// Set handshake timeout to prevent slow/malicious clients from blocking
-	handshakeTimeout := 10 * time.Second
-	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	conn.SetReadDeadline(time.Now().Add(t.config.HandshakeTimeout))
 
 	// Wait for handshake from client
 	_, data, err := conn.ReadMessage()
@@ -484,6 +688,29 @@ func (t *Transport) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A challenge seen before means the handshake is a recording being replayed
+	challengeKey := base64.StdEncoding.EncodeToString(payload.Challenge)
+	if len(payload.Challenge) != ChallengeSize || t.challenges.IsDuplicate(challengeKey) {
+		logging.Warn("peer connection rejected: missing or replayed handshake challenge", logging.Fields{
+			"peer_id": payload.Identity.ID,
+		})
+		identity := t.node.GetIdentity()
+		if identity != nil {
+			rejectPayload := HandshakeAckPayload{
+				Identity: *identity,
+				Accepted: false,
+				Reason:   "missing or replayed handshake challenge",
+			}
+			rejectMsg, _ := NewMessage(MsgHandshakeAck, identity.ID, payload.Identity.ID, rejectPayload)
+			if rejectData, err := MarshalJSON(rejectMsg); err == nil {
+				conn.WriteMessage(websocket.TextMessage, rejectData)
+			}
+		}
+		conn.Close()
+		return
+	}
+	t.challenges.Mark(challengeKey)
+
 	// Derive shared secret from peer's public key
 	sharedSecret, err := t.node.DeriveSharedSecret(payload.Identity.PublicKey)
 	if err != nil {
@@ -515,34 +742,6 @@ func (t *Transport) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create peer if not exists (only if auth passed)
-	peer := t.registry.GetPeer(payload.Identity.ID)
-	if peer == nil {
-		// Auto-register the peer since they passed allowlist check
-		peer = &Peer{
-			ID:        payload.Identity.ID,
-			Name:      payload.Identity.Name,
-			PublicKey: payload.Identity.PublicKey,
-			Role:      payload.Identity.Role,
-			AddedAt:   time.Now(),
-			Score:     50,
-		}
-		t.registry.AddPeer(peer)
-		logging.Info("auto-registered new peer", logging.Fields{
-			"peer_id":   peer.ID,
-			"peer_name": peer.Name,
-		})
-	}
-
-	pc := &PeerConnection{
-		Peer:         peer,
-		Conn:         conn,
-		SharedSecret: sharedSecret,
-		LastActivity: time.Now(),
-		transport:    t,
-		rateLimiter:  NewPeerRateLimiter(100, 50), // 100 burst, 50/sec refill
-	}
-
 	// Send handshake acknowledgment
 	identity := t.node.GetIdentity()
 	if identity == nil {
@@ -550,19 +749,22 @@ func (t *Transport) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Sign the client's challenge to prove we have the matching private key
-	var challengeResponse []byte
-	if len(payload.Challenge) > 0 {
-		challengeResponse = SignChallenge(payload.Challenge, sharedSecret)
+	// Sign the client's challenge to prove we have the matching private key,
+	// and send a fresh nonce the client must sign to complete the handshake
+	nonce, err := GenerateChallenge()
+	if err != nil {
+		conn.Close()
+		return
 	}
-
 	ackPayload := HandshakeAckPayload{
 		Identity:          *identity,
-		ChallengeResponse: challengeResponse,
+		ChallengeResponse: SignChallenge(payload.Challenge, sharedSecret),
+		Nonce:             nonce,
 		Accepted:          true,
+		Capabilities:      t.Capabilities(),
 	}
 
-	ackMsg, err := NewMessage(MsgHandshakeAck, identity.ID, peer.ID, ackPayload)
+	ackMsg, err := NewMessage(MsgHandshakeAck, identity.ID, payload.Identity.ID, ackPayload)
 	if err != nil {
 		conn.Close()
 		return
@@ -580,6 +782,48 @@ func (t *Transport) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := readHandshakeConfirm(conn, payload.Challenge, nonce, sharedSecret); err != nil {
+		logging.Warn("peer connection rejected: handshake not confirmed", logging.Fields{
+			"peer_id": payload.Identity.ID,
+			"error":   err,
+		})
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	// Create peer if not exists (only if auth passed)
+	peer := t.registry.GetPeer(payload.Identity.ID)
+	if peer == nil {
+		// Auto-register the peer since they passed allowlist check
+		peer = &Peer{
+			ID:        payload.Identity.ID,
+			Name:      payload.Identity.Name,
+			PublicKey: payload.Identity.PublicKey,
+			Role:      payload.Identity.Role,
+			AddedAt:   time.Now(),
+			Score:     50,
+		}
+		t.registry.AddPeer(peer)
+		logging.Info("auto-registered new peer", logging.Fields{
+			"peer_id":   peer.ID,
+			"peer_name": peer.Name,
+		})
+	}
+
+	pc := &PeerConnection{
+		Peer:         peer,
+		Conn:         conn,
+		SharedSecret: sharedSecret,
+		LastActivity: time.Now(),
+		transport:    t,
+		rateLimiter:  NewPeerRateLimiter(100, 50), // 100 burst, 50/sec refill
+		connectedAt:  time.Now(),
+		keepalive:    keepaliveState{pongs: make(chan struct{}, 1)},
+		capabilities: payload.Capabilities,
+	}
+	pc.lastActive.Store(pc.LastActivity.UnixNano())
+
 	// Store connection
 	t.mu.Lock()
 	t.conns[peer.ID] = pc
@@ -595,14 +839,15 @@ func (t *Transport) handleWSUpgrade(w http.ResponseWriter, r *http.Request) {
 	// Start keepalive
 	t.wg.Add(1)
 	go t.keepalive(pc)
+
+	t.notifyConnect(peer.ID)
 }
 
 // performHandshake initiates handshake with a peer.
 func (t *Transport) performHandshake(pc *PeerConnection) error {
 	// Set handshake timeout
-	handshakeTimeout := 10 * time.Second
-	pc.Conn.SetWriteDeadline(time.Now().Add(handshakeTimeout))
-	pc.Conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	pc.Conn.SetWriteDeadline(time.Now().Add(t.config.HandshakeTimeout))
+	pc.Conn.SetReadDeadline(time.Now().Add(t.config.HandshakeTimeout))
 	defer func() {
 		// Reset deadlines after handshake
 		pc.Conn.SetWriteDeadline(time.Time{})
@@ -611,7 +856,7 @@ func (t *Transport) performHandshake(pc *PeerConnection) error {
 
 	identity := t.node.GetIdentity()
 	if identity == nil {
-		return fmt.Errorf("node identity not initialized")
+		return ErrIdentityNotInitialized
 	}
 
 	// Generate challenge for the server to prove it has the matching private key
@@ -621,9 +866,10 @@ func (t *Transport) performHandshake(pc *PeerConnection) error {
 	}
 
 	payload := HandshakePayload{
-		Identity:  *identity,
-		Challenge: challenge,
-		Version:   ProtocolVersion,
+		Identity:     *identity,
+		Challenge:    challenge,
+		Version:      ProtocolVersion,
+		Capabilities: t.Capabilities(),
 	}
 
 	msg, err := NewMessage(MsgHandshake, identity.ID, pc.Peer.ID, payload)
@@ -670,6 +916,7 @@ func (t *Transport) performHandshake(pc *PeerConnection) error {
 	pc.Peer.PublicKey = ackPayload.Identity.PublicKey
 	pc.Peer.Name = ackPayload.Identity.Name
 	pc.Peer.Role = ackPayload.Identity.Role
+	pc.capabilities = ackPayload.Capabilities
 
 	// Verify challenge response - derive shared secret first using the peer's public key
 	sharedSecret, err := t.node.DeriveSharedSecret(pc.Peer.PublicKey)
@@ -685,6 +932,24 @@ func (t *Transport) performHandshake(pc *PeerConnection) error {
 		return fmt.Errorf("challenge response verification failed: server may not have matching private key")
 	}
 
+	// Confirm the handshake by signing the server's nonce
+	if len(ackPayload.Nonce) != ChallengeSize {
+		return fmt.Errorf("server did not provide a handshake nonce")
+	}
+	confirmMsg, err := NewMessage(MsgHandshakeConfirm, identity.ID, pc.Peer.ID, HandshakeConfirmPayload{
+		Signature: SignHandshakeConfirm(challenge, ackPayload.Nonce, sharedSecret),
+	})
+	if err != nil {
+		return fmt.Errorf("create handshake confirmation: %w", err)
+	}
+	confirmData, err := MarshalJSON(confirmMsg)
+	if err != nil {
+		return fmt.Errorf("marshal handshake confirmation: %w", err)
+	}
+	if err := pc.Conn.WriteMessage(websocket.TextMessage, confirmData); err != nil {
+		return fmt.Errorf("send handshake confirmation: %w", err)
+	}
+
 	// Store the shared secret for later use
 	pc.SharedSecret = sharedSecret
 
@@ -702,6 +967,30 @@ func (t *Transport) performHandshake(pc *PeerConnection) error {
 	return nil
 }
 
+// readHandshakeConfirm waits for the client's handshake confirmation and
+// checks it signs this upgrade's nonce.
+func readHandshakeConfirm(conn *websocket.Conn, challenge, nonce, sharedSecret []byte) error {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("read handshake confirmation: %w", err)
+	}
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("unmarshal handshake confirmation: %w", err)
+	}
+	if msg.Type != MsgHandshakeConfirm {
+		return fmt.Errorf("expected handshake_confirm, got %s", msg.Type)
+	}
+	var payload HandshakeConfirmPayload
+	if err := msg.ParsePayload(&payload); err != nil {
+		return fmt.Errorf("parse handshake confirmation: %w", err)
+	}
+	if !VerifyHandshakeConfirm(challenge, nonce, payload.Signature, sharedSecret) {
+		return fmt.Errorf("handshake confirmation signature is invalid")
+	}
+	return nil
+}
+
 // readLoop reads messages from a peer connection.
 func (t *Transport) readLoop(pc *PeerConnection) {
 	defer t.wg.Done()
@@ -735,10 +1024,15 @@ func (t *Transport) readLoop(pc *PeerConnection) {
 		}
 
 		pc.LastActivity = time.Now()
+		pc.lastActive.Store(pc.LastActivity.UnixNano())
+		t.counters.received.Add(1)
+		pc.counters.received.Add(1)
 
 		// Check rate limit before processing
 		if pc.rateLimiter != nil && !pc.rateLimiter.Allow() {
 			logging.Warn("peer rate limited, dropping message", logging.Fields{"peer_id": pc.Peer.ID})
+			t.counters.rateLimited.Add(1)
+			pc.counters.rateLimited.Add(1)
 			continue // Drop message from rate-limited peer
 		}
 
@@ -746,21 +1040,32 @@ func (t *Transport) readLoop(pc *PeerConnection) {
 		msg, err := t.decryptMessage(data, pc.SharedSecret)
 		if err != nil {
 			logging.Debug("decrypt error from peer", logging.Fields{"peer_id": pc.Peer.ID, "error": err, "data_len": len(data)})
+			t.counters.decryptFailures.Add(1)
 			continue // Skip invalid messages
 		}
 
 		// Check for duplicate messages (prevents amplification attacks)
-		if t.dedup.IsDuplicate(msg.ID) {
-			logging.Debug("dropping duplicate message", logging.Fields{"msg_id": msg.ID, "peer_id": pc.Peer.ID})
+		if err := t.checkDuplicate(pc.Peer.ID, msg); err != nil {
+			logging.Debug("dropping duplicate message", logging.Fields{"msg_id": msg.ID, "peer_id": pc.Peer.ID, "code": err.Code})
 			continue
 		}
-		t.dedup.Mark(msg.ID)
 
 		// Rate limit debug logs in hot path to reduce noise (log 1 in N messages)
 		if debugLogCounter.Add(1)%debugLogInterval == 0 {
 			logging.Debug("received message from peer", logging.Fields{"type": msg.Type, "peer_id": pc.Peer.ID, "reply_to": msg.ReplyTo, "sample": "1/100"})
 		}
 
+		// Keepalive pings and pongs are handled here rather than by the handler
+		if t.handleKeepalive(pc, msg) {
+			continue
+		}
+
+		// Key rotations update the registry rather than reaching the handler
+		if msg.Type == MsgKeyRotation {
+			t.handleKeyRotation(pc, msg)
+			continue
+		}
+
 		// Dispatch to handler (read handler under lock to avoid race)
 		t.mu.RLock()
 		handler := t.handler
@@ -771,39 +1076,100 @@ func (t *Transport) readLoop(pc *PeerConnection) {
 	}
 }
 
-// keepalive sends periodic pings.
+// keepalive pings the peer every PingInterval and measures the round trip
+// from its pong. Liveness is judged by pongs alone, since other traffic can
+// keep arriving over a connection that no longer delivers our messages. The
+// connection is dead after MaxMissedPongs pings in a row go unanswered
+// within PongTimeout; an unanswered ping is retried immediately.
 func (t *Transport) keepalive(pc *PeerConnection) {
 	defer t.wg.Done()
 
-	ticker := time.NewTicker(t.config.PingInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(t.config.PingInterval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-t.ctx.Done():
 			return
-		case <-ticker.C:
-			// Check if connection is still alive
-			if time.Since(pc.LastActivity) > t.config.PingInterval+t.config.PongTimeout {
-				t.removeConnection(pc)
-				return
-			}
+		case <-timer.C:
+		}
 
-			// Send ping
-			identity := t.node.GetIdentity()
-			pingMsg, err := NewMessage(MsgPing, identity.ID, pc.Peer.ID, PingPayload{
-				SentAt: time.Now().UnixMilli(),
-			})
-			if err != nil {
-				continue
-			}
+		// Send ping
+		identity := t.node.GetIdentity()
+		sentAt := time.Now()
+		pingMsg, err := NewMessage(MsgPing, identity.ID, pc.Peer.ID, PingPayload{
+			SentAt:    sentAt.UnixMilli(),
+			Keepalive: true,
+		})
+		if err != nil {
+			timer.Reset(t.config.PingInterval)
+			continue
+		}
+		pc.keepalive.pingID.Store(pingMsg.ID)
+		select {
+		case <-pc.keepalive.pongs: // Discard a late pong to an earlier ping
+		default:
+		}
+
+		if err := pc.Send(pingMsg); err != nil {
+			t.removeConnection(pc)
+			return
+		}
 
-			if err := pc.Send(pingMsg); err != nil {
+		pongTimer := time.NewTimer(t.config.PongTimeout)
+		select {
+		case <-t.ctx.Done():
+			pongTimer.Stop()
+			return
+		case <-pc.keepalive.pongs:
+			pongTimer.Stop()
+			rtt := time.Since(sentAt)
+			pc.keepalive.rtt.Store(int64(rtt))
+			pc.keepalive.missed.Store(0)
+			if peer := t.registry.GetPeer(pc.Peer.ID); peer != nil {
+				t.registry.UpdateMetrics(pc.Peer.ID, float64(rtt.Microseconds())/1000, peer.GeoKM, peer.Hops)
+			}
+			timer.Reset(t.config.PingInterval)
+		case <-pongTimer.C:
+			missed := pc.keepalive.missed.Add(1)
+			if int(missed) >= t.config.MaxMissedPongs {
+				logging.Warn("peer stopped answering keepalive pings, closing connection", logging.Fields{
+					"peer_id": pc.Peer.ID,
+					"missed":  missed,
+				})
 				t.removeConnection(pc)
 				return
 			}
+			timer.Reset(0)
+		}
+	}
+}
+
+// handleKeepalive answers keepalive pings and matches pongs to the
+// outstanding ping, reporting whether msg was one of them.
+func (t *Transport) handleKeepalive(pc *PeerConnection, msg *Message) bool {
+	switch msg.Type {
+	case MsgPing:
+		var ping PingPayload
+		if err := msg.ParsePayload(&ping); err != nil || !ping.Keepalive {
+			return false // Pings from the controller are answered by the worker
+		}
+		pong, err := msg.Reply(MsgPong, PongPayload{SentAt: ping.SentAt, ReceivedAt: time.Now().UnixMilli()})
+		if err == nil {
+			pc.Send(pong)
+		}
+		return true
+	case MsgPong:
+		if pingID, _ := pc.keepalive.pingID.Load().(string); pingID == "" || msg.ReplyTo != pingID {
+			return false
+		}
+		select {
+		case pc.keepalive.pongs <- struct{}{}:
+		default:
 		}
+		return true
 	}
+	return false
 }
 
 // removeConnection removes and cleans up a connection.
@@ -833,7 +1199,17 @@ func (pc *PeerConnection) Send(msg *Message) error {
 	}
 	defer pc.Conn.SetWriteDeadline(time.Time{}) // Reset deadline after send
 
-	return pc.Conn.WriteMessage(websocket.BinaryMessage, data)
+	if err := pc.Conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+		return err
+	}
+	pc.transport.counters.sent.Add(1)
+	pc.counters.sent.Add(1)
+	return nil
+}
+
+// HasCapability reports whether the peer advertised capability in the handshake.
+func (pc *PeerConnection) HasCapability(capability string) bool {
+	return slices.Contains(pc.capabilities, capability)
 }
 
 // Close closes the connection.
@@ -874,8 +1250,8 @@ func (pc *PeerConnection) GracefulClose(reason string, code int) error {
 				}
 				msg, msgErr := NewMessage(MsgDisconnect, identity.ID, pc.Peer.ID, payload)
 				if msgErr == nil {
-					// Set short deadline for disconnect message
-					pc.Conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
+					// Send sets its own write deadline under writeMu; setting
+					// one here would race with keepalive pongs being written
 					pc.Send(msg)
 				}
 			}
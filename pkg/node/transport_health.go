@@ -0,0 +1,112 @@
+package node
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// transportCounters are the transport-wide totals since NewTransport.
+type transportCounters struct {
+	sent            atomic.Uint64
+	received        atomic.Uint64 // Frames read, before rate limiting, decryption and dedup
+	rateLimited     atomic.Uint64
+	decryptFailures atomic.Uint64
+	duplicates      atomic.Uint64
+
+	handshakesRejected atomic.Uint64 // Upgrades refused by MaxConns or MaxPendingHandshakes
+}
+
+// connCounters are the message totals of a single connection.
+type connCounters struct {
+	sent        atomic.Uint64
+	received    atomic.Uint64
+	rateLimited atomic.Uint64
+}
+
+// TransportHealth is a snapshot of the P2P transport's state and counters.
+type TransportHealth struct {
+	Running              bool                   `json:"running"`
+	ListenAddr           string                 `json:"listenAddr"`
+	ActiveConnections    int                    `json:"activeConnections"`
+	PendingHandshakes    int                    `json:"pendingHandshakes"`
+	MaxPendingHandshakes int                    `json:"maxPendingHandshakes"`
+	MaxConnections       int                    `json:"maxConnections"`
+	RejectedHandshakes   uint64                 `json:"rejectedHandshakes"`
+	MessagesSent         uint64                 `json:"messagesSent"`
+	MessagesReceived     uint64                 `json:"messagesReceived"`
+	RateLimitDrops       uint64                 `json:"rateLimitDrops"`
+	DecryptFailures      uint64                 `json:"decryptFailures"`
+	DuplicatesDropped    uint64                 `json:"duplicatesDropped"`
+	DedupCacheSize       int                    `json:"dedupCacheSize"`
+	Peers                []PeerConnectionHealth `json:"peers"`
+}
+
+// PeerConnectionHealth describes one active peer connection.
+type PeerConnectionHealth struct {
+	PeerID           string    `json:"peerId"`
+	Name             string    `json:"name,omitempty"`
+	Address          string    `json:"address,omitempty"`
+	Outbound         bool      `json:"outbound"` // We dialed the peer
+	ConnectedAt      time.Time `json:"connectedAt"`
+	AgeSeconds       float64   `json:"ageSeconds"`
+	LastActivity     time.Time `json:"lastActivity"`
+	IdleSeconds      float64   `json:"idleSeconds"`
+	MessagesSent     uint64    `json:"messagesSent"`
+	MessagesReceived uint64    `json:"messagesReceived"`
+	RateLimitDrops   uint64    `json:"rateLimitDrops"`
+	RTTMS            float64   `json:"rttMs"`       // Round trip of the last answered keepalive ping
+	MissedPongs      int       `json:"missedPongs"` // Consecutive unanswered keepalive pings
+}
+
+// lastActivity returns when a message was last read from the peer. Unlike
+// the LastActivity field it is safe to call while the read loop is running.
+func (pc *PeerConnection) lastActivity() time.Time {
+	return time.Unix(0, pc.lastActive.Load())
+}
+
+// Health returns a snapshot of the transport's state, counters and active
+// connections, sorted by peer ID.
+func (t *Transport) Health() TransportHealth {
+	now := time.Now()
+	health := TransportHealth{
+		Running:              t.IsListening(),
+		ListenAddr:           t.config.ListenAddr,
+		PendingHandshakes:    int(t.pendingConns.Load()),
+		MaxPendingHandshakes: t.config.MaxPendingHandshakes,
+		MaxConnections:       t.config.MaxConns,
+		RejectedHandshakes:   t.counters.handshakesRejected.Load(),
+		MessagesSent:         t.counters.sent.Load(),
+		MessagesReceived:     t.counters.received.Load(),
+		RateLimitDrops:       t.counters.rateLimited.Load(),
+		DecryptFailures:      t.counters.decryptFailures.Load(),
+		DuplicatesDropped:    t.counters.duplicates.Load(),
+		DedupCacheSize:       t.dedup.Len(),
+		Peers:                []PeerConnectionHealth{},
+	}
+
+	t.mu.RLock()
+	for _, pc := range t.conns {
+		peer := PeerConnectionHealth{
+			Outbound:         pc.outbound,
+			ConnectedAt:      pc.connectedAt,
+			AgeSeconds:       now.Sub(pc.connectedAt).Seconds(),
+			LastActivity:     pc.lastActivity(),
+			IdleSeconds:      now.Sub(pc.lastActivity()).Seconds(),
+			MessagesSent:     pc.counters.sent.Load(),
+			MessagesReceived: pc.counters.received.Load(),
+			RateLimitDrops:   pc.counters.rateLimited.Load(),
+			RTTMS:            float64(time.Duration(pc.keepalive.rtt.Load()).Microseconds()) / 1000,
+			MissedPongs:      int(pc.keepalive.missed.Load()),
+		}
+		if pc.Peer != nil {
+			peer.PeerID, peer.Name, peer.Address = pc.Peer.ID, pc.Peer.Name, pc.Peer.Address
+		}
+		health.Peers = append(health.Peers, peer)
+	}
+	t.mu.RUnlock()
+
+	health.ActiveConnections = len(health.Peers)
+	sort.Slice(health.Peers, func(i, j int) bool { return health.Peers[i].PeerID < health.Peers[j].PeerID })
+	return health
+}
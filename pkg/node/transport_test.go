@@ -0,0 +1,464 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func setupTestTransport(t *testing.T, listenAddr string) (*Transport, func()) {
+	nm, nmCleanup := setupTestNodeManager(t)
+	pr, prCleanup := setupTestPeerRegistry(t)
+
+	config := DefaultTransportConfig()
+	config.ListenAddr = listenAddr
+	transport := NewTransport(nm, pr, config)
+
+	cleanup := func() {
+		transport.Stop()
+		prCleanup()
+		nmCleanup()
+	}
+	return transport, cleanup
+}
+
+// freeAddr returns a local address with a port nothing is listening on.
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// setupControllerAndWorker starts a worker, configured by configure if not
+// nil, and returns a controller that knows it as a peer.
+func setupControllerAndWorker(t *testing.T, configure func(*Worker)) (*Controller, string) {
+	t.Helper()
+	addr := freeAddr(t)
+	workerTransport, workerCleanup := setupTestTransport(t, addr)
+	t.Cleanup(workerCleanup)
+	if err := workerTransport.node.GenerateIdentity("test-worker", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	worker := NewWorker(workerTransport.node, workerTransport)
+	if configure != nil {
+		configure(worker)
+	}
+	worker.RegisterWithTransport()
+	if err := workerTransport.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+
+	controllerTransport, controllerCleanup := setupTestTransport(t, "127.0.0.1:0")
+	t.Cleanup(controllerCleanup)
+	if err := controllerTransport.node.GenerateIdentity("test-controller", RoleController); err != nil {
+		t.Fatal(err)
+	}
+	controller := NewController(controllerTransport.node, controllerTransport.registry, controllerTransport)
+
+	workerID := workerTransport.node.GetIdentity().ID
+	if err := controllerTransport.registry.AddPeer(&Peer{ID: workerID, Name: "test-worker", Address: addr}); err != nil {
+		t.Fatal(err)
+	}
+	return controller, workerID
+}
+
+func TestTransport_StartStop(t *testing.T) {
+	transport, cleanup := setupTestTransport(t, "127.0.0.1:0")
+	defer cleanup()
+
+	if transport.IsListening() {
+		t.Error("transport should not be listening before Start")
+	}
+
+	if err := transport.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+	if !transport.IsListening() {
+		t.Error("transport should be listening after Start")
+	}
+
+	if err := transport.Start(); err == nil {
+		t.Error("starting an already listening transport should fail")
+	}
+
+	if err := transport.Stop(); err != nil {
+		t.Fatalf("failed to stop transport: %v", err)
+	}
+	if transport.IsListening() {
+		t.Error("transport should not be listening after Stop")
+	}
+}
+
+func TestTransport_StartPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve port: %v", err)
+	}
+	defer listener.Close()
+
+	transport, cleanup := setupTestTransport(t, listener.Addr().String())
+	defer cleanup()
+
+	err = transport.Start()
+	if err == nil {
+		t.Fatal("expected error when port is already in use")
+	}
+	if !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("expected a clear port-in-use error, got: %v", err)
+	}
+	if transport.IsListening() {
+		t.Error("transport should not report listening after a failed Start")
+	}
+}
+
+func TestTransport_ConnectAfterStop(t *testing.T) {
+	transport, cleanup := setupTestTransport(t, "127.0.0.1:0")
+	defer cleanup()
+
+	transport.Stop()
+
+	_, err := transport.Connect(&Peer{ID: "peer", Address: "127.0.0.1:1"})
+	if err == nil || !strings.Contains(err.Error(), "transport is not running") {
+		t.Errorf("expected transport not running error, got: %v", err)
+	}
+}
+
+func TestTransport_Health(t *testing.T) {
+	addr := freeAddr(t)
+
+	server, serverCleanup := setupTestTransport(t, addr)
+	defer serverCleanup()
+	client, clientCleanup := setupTestTransport(t, "127.0.0.1:0")
+	defer clientCleanup()
+	if err := server.node.GenerateIdentity("health-server", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.node.GenerateIdentity("health-client", RoleController); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan struct{}, 1)
+	server.OnMessage(func(conn *PeerConnection, msg *Message) { received <- struct{}{} })
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+
+	pc, err := client.Connect(&Peer{ID: "server", Address: addr})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	msg, err := NewMessage(MsgPing, client.node.GetIdentity().ID, pc.Peer.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pc.Send(msg); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+
+	health := server.Health()
+	if !health.Running || health.ListenAddr != addr || health.ActiveConnections != 1 || len(health.Peers) != 1 {
+		t.Fatalf("unexpected server health %+v", health)
+	}
+	if health.MessagesReceived != 1 || health.DedupCacheSize != 1 || health.Peers[0].Outbound {
+		t.Errorf("expected one inbound message, got %+v", health)
+	}
+	if peer := health.Peers[0]; peer.MessagesReceived != 1 || peer.ConnectedAt.IsZero() || peer.LastActivity.Before(peer.ConnectedAt) {
+		t.Errorf("unexpected peer health %+v", peer)
+	}
+
+	clientHealth := client.Health()
+	if clientHealth.Running || clientHealth.MessagesSent != 1 || len(clientHealth.Peers) != 1 || !clientHealth.Peers[0].Outbound {
+		t.Errorf("unexpected client health %+v", clientHealth)
+	}
+}
+
+func TestMessageDeduplicator_Bounded(t *testing.T) {
+	d := NewMessageDeduplicator(time.Minute, 100)
+	for i := 0; i < 10000; i++ {
+		d.Mark(fmt.Sprintf("msg-%d", i))
+		if d.Len() > 100 {
+			t.Fatalf("deduplicator grew to %d entries, cap is 100", d.Len())
+		}
+	}
+	if !d.IsDuplicate("msg-9999") {
+		t.Error("expected the most recent ID to be remembered")
+	}
+	if d.IsDuplicate("msg-0") {
+		t.Error("expected the oldest ID to be evicted")
+	}
+
+	// Seeing an ID again makes it the most recently seen
+	d.Mark("msg-9900")
+	for i := 0; i < 99; i++ {
+		d.Mark(fmt.Sprintf("new-%d", i))
+	}
+	if !d.IsDuplicate("msg-9900") || d.IsDuplicate("msg-9901") {
+		t.Error("expected eviction in least recently seen order")
+	}
+}
+
+func TestMessageDeduplicator_TTL(t *testing.T) {
+	d := NewMessageDeduplicator(20*time.Millisecond, 0)
+	d.Mark("msg")
+	if !d.IsDuplicate("msg") {
+		t.Fatal("expected a fresh ID to be a duplicate")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if d.IsDuplicate("msg") {
+		t.Error("expected an expired ID not to be a duplicate")
+	}
+	d.Cleanup()
+	if d.Len() != 0 {
+		t.Errorf("expected cleanup to remove the expired ID, %d left", d.Len())
+	}
+}
+
+func TestTransport_MaxPendingHandshakes(t *testing.T) {
+	addr := freeAddr(t)
+
+	nm, nmCleanup := setupTestNodeManager(t)
+	defer nmCleanup()
+	pr, prCleanup := setupTestPeerRegistry(t)
+	defer prCleanup()
+	config := DefaultTransportConfig()
+	config.ListenAddr = addr
+	config.MaxPendingHandshakes = 3
+	config.HandshakeTimeout = 300 * time.Millisecond
+	transport := NewTransport(nm, pr, config)
+	if err := transport.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+	defer transport.Stop()
+
+	// Stalled clients upgrade the connection but never send a handshake
+	url := "ws://" + addr + config.WSPath
+	for i := 0; i < 3; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("stalled handshake %d was refused: %v", i, err)
+		}
+		defer conn.Close()
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected an upgrade beyond the pending handshake cap to be refused, got %v", err)
+	}
+	if health := transport.Health(); health.PendingHandshakes != 3 || health.RejectedHandshakes != 1 {
+		t.Errorf("unexpected health %+v", health)
+	}
+
+	// The handshake timeout frees the stalled slots
+	deadline := time.Now().Add(5 * time.Second)
+	for transport.Health().PendingHandshakes > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("stalled handshakes were not timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("expected upgrades to be accepted once handshakes timed out: %v", err)
+	}
+	conn.Close()
+}
+
+func TestTransport_HandshakeReplay(t *testing.T) {
+	addr := freeAddr(t)
+	server, serverCleanup := setupTestTransport(t, addr)
+	defer serverCleanup()
+	if err := server.node.GenerateIdentity("replay-server", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+	client, clientCleanup := setupTestNodeManager(t)
+	defer clientCleanup()
+	if err := client.GenerateIdentity("replay-client", RoleController); err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := GenerateChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handshake, _ := NewMessage(MsgHandshake, client.GetIdentity().ID, "", HandshakePayload{
+		Identity: *client.GetIdentity(), Challenge: challenge, Version: ProtocolVersion,
+	})
+	recorded, _ := MarshalJSON(handshake)
+
+	// upgrade sends a handshake and returns the server's ack
+	url := "ws://" + addr + DefaultTransportConfig().WSPath
+	upgrade := func(data []byte) (*websocket.Conn, HandshakeAckPayload) {
+		t.Helper()
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			t.Fatal(err)
+		}
+		_, ackData, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read ack failed: %v", err)
+		}
+		var ackMsg Message
+		var ack HandshakeAckPayload
+		if err := json.Unmarshal(ackData, &ackMsg); err != nil || ackMsg.ParsePayload(&ack) != nil {
+			t.Fatalf("invalid ack %s", ackData)
+		}
+		return conn, ack
+	}
+	confirm := func(conn *websocket.Conn, signature []byte) {
+		t.Helper()
+		msg, _ := NewMessage(MsgHandshakeConfirm, client.GetIdentity().ID, "", HandshakeConfirmPayload{Signature: signature})
+		data, _ := MarshalJSON(msg)
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitForConns := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for server.ConnectedPeers() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d connections, got %d", want, server.ConnectedPeers())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The genuine client completes the handshake by signing the nonce
+	conn, ack := upgrade(recorded)
+	defer conn.Close()
+	if !ack.Accepted || len(ack.Nonce) != ChallengeSize {
+		t.Fatalf("expected the handshake to be accepted with a nonce, got %+v", ack)
+	}
+	secret, err := client.DeriveSharedSecret(ack.Identity.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirm(conn, SignHandshakeConfirm(challenge, ack.Nonce, secret))
+	waitForConns(1)
+
+	// Replaying the recorded handshake is rejected outright
+	replay, ack := upgrade(recorded)
+	defer replay.Close()
+	if ack.Accepted || !strings.Contains(ack.Reason, "replayed") {
+		t.Errorf("expected a replayed handshake to be rejected, got %+v", ack)
+	}
+
+	// A fresh challenge gets a new nonce, which a recorded confirmation doesn't sign
+	freshChallenge, _ := GenerateChallenge()
+	handshake, _ = NewMessage(MsgHandshake, client.GetIdentity().ID, "", HandshakePayload{
+		Identity: *client.GetIdentity(), Challenge: freshChallenge, Version: ProtocolVersion,
+	})
+	fresh, _ := MarshalJSON(handshake)
+	attacker, ack := upgrade(fresh)
+	defer attacker.Close()
+	if !ack.Accepted {
+		t.Fatalf("expected the fresh handshake to get an ack, got %+v", ack)
+	}
+	confirm(attacker, SignHandshakeConfirm(challenge, ack.Nonce, []byte("not the shared secret")))
+	if _, _, err := attacker.ReadMessage(); err == nil {
+		t.Error("expected the server to close an unconfirmed handshake")
+	}
+	waitForConns(1)
+}
+
+// connectKeepaliveTest connects a client with the given keepalive settings to
+// a server, returning both transports and the client's connection.
+func connectKeepaliveTest(t *testing.T, interval, timeout time.Duration, maxMissed int) (server, client *Transport, pc *PeerConnection) {
+	t.Helper()
+	addr := freeAddr(t)
+	server, serverCleanup := setupTestTransport(t, addr)
+	t.Cleanup(serverCleanup)
+	client, clientCleanup := setupTestTransport(t, "127.0.0.1:0")
+	t.Cleanup(clientCleanup)
+	client.config.PingInterval = interval
+	client.config.PongTimeout = timeout
+	client.config.MaxMissedPongs = maxMissed
+	if err := server.node.GenerateIdentity("keepalive-server", RoleWorker); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.node.GenerateIdentity("keepalive-client", RoleController); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start transport: %v", err)
+	}
+
+	peer := &Peer{ID: server.node.GetIdentity().ID, Name: "keepalive-server", Address: addr}
+	if err := client.registry.AddPeer(peer); err != nil {
+		t.Fatal(err)
+	}
+	pc, err := client.Connect(peer)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	return server, client, pc
+}
+
+func TestTransport_KeepaliveRTT(t *testing.T) {
+	_, client, pc := connectKeepaliveTest(t, 20*time.Millisecond, time.Second, 3)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pc.keepalive.rtt.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no pong was received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	health := client.Health()
+	if len(health.Peers) != 1 || health.Peers[0].RTTMS <= 0 || health.Peers[0].MissedPongs != 0 {
+		t.Errorf("unexpected peer health %+v", health.Peers)
+	}
+	if peer := client.registry.GetPeer(pc.Peer.ID); peer == nil || peer.PingMS <= 0 {
+		t.Errorf("expected the registry to record the ping, got %+v", peer)
+	}
+}
+
+func TestTransport_KeepaliveDeadPeer(t *testing.T) {
+	server, client, pc := connectKeepaliveTest(t, 20*time.Millisecond, 100*time.Millisecond, 2)
+
+	// Drop everything the server reads so pings go unanswered
+	clientID := client.node.GetIdentity().ID
+	var serverPC *PeerConnection
+	deadline := time.Now().Add(5 * time.Second)
+	for serverPC == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not register the connection")
+		}
+		server.mu.RLock()
+		serverPC = server.conns[clientID]
+		server.mu.RUnlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	serverPC.rateLimiter.mu.Lock()
+	serverPC.rateLimiter.tokens = 0
+	serverPC.rateLimiter.refillRate = 0
+	serverPC.rateLimiter.mu.Unlock()
+
+	for client.GetConnection(pc.Peer.ID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("connection was not closed after missed pongs (missed %d)", pc.keepalive.missed.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
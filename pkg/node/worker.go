@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/Snider/Mining/pkg/logging"
@@ -28,10 +29,22 @@ type MinerInstance interface {
 	GetConsoleHistory(lines int) []string
 }
 
+// MinerInstaller is implemented by miner managers that can provision miners
+// on this node. progress is called as each stage begins.
+type MinerInstaller interface {
+	InstallMiner(minerType string, progress func(stage, message string)) (version string, err error)
+	UninstallMiner(minerType string) error
+	UpdateMiner(minerType string, progress func(stage, message string)) (version string, err error)
+}
+
 // ProfileManager interface for profile operations.
 type ProfileManager interface {
 	GetProfile(id string) (interface{}, error)
 	SaveProfile(profile interface{}) error
+	// ListProfiles and GetMaskedProfile serve remote controllers, so
+	// implementations must mask secrets such as pool passwords.
+	ListProfiles() ([]interface{}, error)
+	GetMaskedProfile(id string) (interface{}, error)
 }
 
 // Worker handles incoming messages on a worker node.
@@ -41,6 +54,10 @@ type Worker struct {
 	minerManager   MinerManager
 	profileManager ProfileManager
 	startTime      time.Time
+
+	pushMu      sync.Mutex
+	pushEnabled bool
+	pushers     map[string]*statsPusher // peer ID -> pusher
 }
 
 // NewWorker creates a new Worker instance.
@@ -72,19 +89,38 @@ func (w *Worker) HandleMessage(conn *PeerConnection, msg *Message) {
 		response, err = w.handlePing(msg)
 	case MsgGetStats:
 		response, err = w.handleGetStats(msg)
+	case MsgStatsConfig:
+		response, err = w.handleStatsConfig(conn, msg)
 	case MsgStartMiner:
 		response, err = w.handleStartMiner(msg)
 	case MsgStopMiner:
 		response, err = w.handleStopMiner(msg)
 	case MsgGetLogs:
-		response, err = w.handleGetLogs(msg)
+		response, err = w.handleGetLogs(conn, msg)
 	case MsgDeploy:
 		response, err = w.handleDeploy(conn, msg)
+	case MsgListProfiles:
+		response, err = w.handleListProfiles(msg)
+	case MsgGetProfile:
+		response, err = w.handleGetProfile(msg)
+	case MsgInstallMiner, MsgUninstallMiner, MsgUpdateMiner:
+		// Installs can take minutes; run them off the read loop so the
+		// connection keeps processing pings and other requests
+		go func() {
+			response, err := w.handleProvision(conn, msg)
+			w.respond(conn, msg, response, err)
+		}()
+		return
 	default:
 		// Unknown message type - ignore or send error
 		return
 	}
 
+	w.respond(conn, msg, response, err)
+}
+
+// respond sends a handler's response, or an error message if it failed.
+func (w *Worker) respond(conn *PeerConnection, msg *Message, response *Message, err error) {
 	if err != nil {
 		// Send error response
 		identity := w.node.GetIdentity()
@@ -128,9 +164,18 @@ func (w *Worker) handlePing(msg *Message) (*Message, error) {
 
 // handleGetStats responds with current miner statistics.
 func (w *Worker) handleGetStats(msg *Message) (*Message, error) {
+	stats, err := w.collectStats()
+	if err != nil {
+		return nil, err
+	}
+	return msg.Reply(MsgStats, stats)
+}
+
+// collectStats gathers the stats of every running miner.
+func (w *Worker) collectStats() (*StatsPayload, error) {
 	identity := w.node.GetIdentity()
 	if identity == nil {
-		return nil, fmt.Errorf("node identity not initialized")
+		return nil, ErrIdentityNotInitialized
 	}
 
 	stats := StatsPayload{
@@ -155,7 +200,7 @@ func (w *Worker) handleGetStats(msg *Message) (*Message, error) {
 		}
 	}
 
-	return msg.Reply(MsgStats, stats)
+	return &stats, nil
 }
 
 // convertMinerStats converts miner stats to the protocol format.
@@ -260,8 +305,9 @@ func (w *Worker) handleStopMiner(msg *Message) (*Message, error) {
 	return msg.Reply(MsgMinerAck, ack)
 }
 
-// handleGetLogs returns console logs from a miner.
-func (w *Worker) handleGetLogs(msg *Message) (*Message, error) {
+// handleGetLogs responds with a page of console logs filtered as requested.
+// Lines beyond the first chunk are sent ahead of the reply as MsgLogsChunk.
+func (w *Worker) handleGetLogs(conn *PeerConnection, msg *Message) (*Message, error) {
 	if w.minerManager == nil {
 		return nil, fmt.Errorf("miner manager not configured")
 	}
@@ -272,9 +318,8 @@ func (w *Worker) handleGetLogs(msg *Message) (*Message, error) {
 	}
 
 	// Validate and limit the Lines parameter to prevent resource exhaustion
-	const maxLogLines = 10000
-	if payload.Lines <= 0 || payload.Lines > maxLogLines {
-		payload.Lines = maxLogLines
+	if payload.Lines <= 0 || payload.Lines > MaxRemoteLogLines {
+		payload.Lines = MaxRemoteLogLines
 	}
 
 	miner, err := w.minerManager.GetMiner(payload.MinerName)
@@ -282,15 +327,123 @@ func (w *Worker) handleGetLogs(msg *Message) (*Message, error) {
 		return nil, fmt.Errorf("miner not found: %s", payload.MinerName)
 	}
 
-	lines := miner.GetConsoleHistory(payload.Lines)
+	page, err := logPage(miner.GetConsoleHistory(remoteLogHistoryLines), payload)
+	if err != nil {
+		return nil, err
+	}
 
-	logs := LogsPayload{
-		MinerName: payload.MinerName,
-		Lines:     lines,
-		HasMore:   len(lines) >= payload.Lines,
+	chunks := chunkLines(page.Lines, remoteLogChunkBytes)
+	for _, lines := range chunks[:len(chunks)-1] {
+		chunk, err := msg.Reply(MsgLogsChunk, LogsPayload{MinerName: page.MinerName, Lines: lines})
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.Send(chunk); err != nil {
+			return nil, fmt.Errorf("failed to send logs: %w", err)
+		}
+	}
+	page.Lines = chunks[len(chunks)-1]
+
+	return msg.Reply(MsgLogs, page)
+}
+
+// handleListProfiles returns the worker's profiles with secrets masked.
+func (w *Worker) handleListProfiles(msg *Message) (*Message, error) {
+	if w.profileManager == nil {
+		return nil, fmt.Errorf("profile manager not configured")
+	}
+
+	profiles, err := w.profileManager.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	payload := ProfilesPayload{Profiles: make([]json.RawMessage, 0, len(profiles))}
+	for _, profile := range profiles {
+		data, err := json.Marshal(profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode profile: %w", err)
+		}
+		payload.Profiles = append(payload.Profiles, data)
+	}
+
+	return msg.Reply(MsgProfiles, payload)
+}
+
+// handleGetProfile returns a single profile with secrets masked.
+func (w *Worker) handleGetProfile(msg *Message) (*Message, error) {
+	if w.profileManager == nil {
+		return nil, fmt.Errorf("profile manager not configured")
 	}
 
-	return msg.Reply(MsgLogs, logs)
+	var payload GetProfilePayload
+	if err := msg.ParsePayload(&payload); err != nil {
+		return nil, fmt.Errorf("invalid get profile payload: %w", err)
+	}
+
+	profile, err := w.profileManager.GetMaskedProfile(payload.ProfileID)
+	if err != nil {
+		return nil, fmt.Errorf("profile not found: %s", payload.ProfileID)
+	}
+
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	return msg.Reply(MsgProfile, ProfilePayload{Profile: data})
+}
+
+// handleProvision installs, uninstalls or updates a miner, sending
+// MsgInstallProgress replies as stages begin.
+func (w *Worker) handleProvision(conn *PeerConnection, msg *Message) (*Message, error) {
+	installer, ok := w.minerManager.(MinerInstaller)
+	if !ok {
+		return nil, fmt.Errorf("miner manager does not support installs")
+	}
+
+	var payload InstallMinerPayload
+	if err := msg.ParsePayload(&payload); err != nil {
+		return nil, fmt.Errorf("invalid install payload: %w", err)
+	}
+	if payload.MinerType == "" {
+		return nil, fmt.Errorf("miner type is required")
+	}
+
+	progress := func(stage, message string) {
+		update, err := msg.Reply(MsgInstallProgress, InstallProgressPayload{
+			MinerType: payload.MinerType,
+			Stage:     stage,
+			Message:   message,
+		})
+		if err != nil || conn == nil {
+			return
+		}
+		if err := conn.Send(update); err != nil {
+			logging.Debug("failed to send install progress", logging.Fields{"error": err})
+		}
+	}
+
+	var version string
+	var err error
+	switch msg.Type {
+	case MsgInstallMiner:
+		version, err = installer.InstallMiner(payload.MinerType, progress)
+	case MsgUpdateMiner:
+		version, err = installer.UpdateMiner(payload.MinerType, progress)
+	case MsgUninstallMiner:
+		err = installer.UninstallMiner(payload.MinerType)
+	}
+
+	ack := InstallAckPayload{
+		Success:   err == nil,
+		MinerType: payload.MinerType,
+		Version:   version,
+	}
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	return msg.Reply(MsgInstallAck, ack)
 }
 
 // handleDeploy handles deployment of profiles or miner bundles.
@@ -330,7 +330,7 @@ func TestWorker_HandleGetLogs_NoManager(t *testing.T) {
 	}
 
 	// Without miner manager, should return error
-	_, err = worker.handleGetLogs(msg)
+	_, err = worker.handleGetLogs(nil, msg)
 	if err == nil {
 		t.Error("expected error when miner manager is nil")
 	}
@@ -419,6 +419,51 @@ func TestWorker_HandleDeploy_UnknownType(t *testing.T) {
 	}
 }
 
+func TestWorker_HandleProfiles_NoManager(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	nm, err := NewNodeManager()
+	if err != nil {
+		t.Fatalf("failed to create node manager: %v", err)
+	}
+	if err := nm.GenerateIdentity("test-worker", RoleWorker); err != nil {
+		t.Fatalf("failed to generate identity: %v", err)
+	}
+
+	pr, err := NewPeerRegistryWithPath(t.TempDir() + "/peers.json")
+	if err != nil {
+		t.Fatalf("failed to create peer registry: %v", err)
+	}
+
+	transport := NewTransport(nm, pr, DefaultTransportConfig())
+	worker := NewWorker(nm, transport)
+
+	identity := nm.GetIdentity()
+	listMsg, _ := NewMessage(MsgListProfiles, "sender-id", identity.ID, nil)
+	if _, err := worker.handleListProfiles(listMsg); err == nil {
+		t.Error("expected error when profile manager is nil")
+	}
+
+	getMsg, _ := NewMessage(MsgGetProfile, "sender-id", identity.ID, GetProfilePayload{ProfileID: "p1"})
+	if _, err := worker.handleGetProfile(getMsg); err == nil {
+		t.Error("expected error when profile manager is nil")
+	}
+
+	worker.SetProfileManager(&mockProfileManager{})
+	resp, err := worker.handleListProfiles(listMsg)
+	if err != nil {
+		t.Fatalf("handleListProfiles failed: %v", err)
+	}
+	var profiles ProfilesPayload
+	if err := ParseResponse(resp, MsgProfiles, &profiles); err != nil {
+		t.Fatalf("failed to parse profiles response: %v", err)
+	}
+	if profiles.Profiles == nil || len(profiles.Profiles) != 0 {
+		t.Errorf("expected empty profile list, got %v", profiles.Profiles)
+	}
+}
+
 func TestConvertMinerStats(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -495,12 +540,18 @@ type mockMinerInstance struct {
 	name      string
 	minerType string
 	stats     interface{}
+	logs      []string
 }
 
-func (m *mockMinerInstance) GetName() string                      { return m.name }
-func (m *mockMinerInstance) GetType() string                      { return m.minerType }
-func (m *mockMinerInstance) GetStats() (interface{}, error)       { return m.stats, nil }
-func (m *mockMinerInstance) GetConsoleHistory(lines int) []string { return []string{} }
+func (m *mockMinerInstance) GetName() string                { return m.name }
+func (m *mockMinerInstance) GetType() string                { return m.minerType }
+func (m *mockMinerInstance) GetStats() (interface{}, error) { return m.stats, nil }
+func (m *mockMinerInstance) GetConsoleHistory(lines int) []string {
+	if len(m.logs) > lines {
+		return m.logs[len(m.logs)-lines:]
+	}
+	return m.logs
+}
 
 type mockProfileManager struct{}
 
@@ -511,3 +562,11 @@ func (m *mockProfileManager) GetProfile(id string) (interface{}, error) {
 func (m *mockProfileManager) SaveProfile(profile interface{}) error {
 	return nil
 }
+
+func (m *mockProfileManager) ListProfiles() ([]interface{}, error) {
+	return nil, nil
+}
+
+func (m *mockProfileManager) GetMaskedProfile(id string) (interface{}, error) {
+	return nil, nil
+}